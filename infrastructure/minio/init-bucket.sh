@@ -14,6 +14,10 @@ mc mb local/stream-demo-videos --ignore-existing
 echo "📦 創建處理後影片桶..."
 mc mb local/stream-demo-processed --ignore-existing
 
+# 創建私訊附件桶（如果不存在），不開放公開讀取，只透過預簽名URL存取
+echo "📦 創建私訊附件桶..."
+mc mb local/stream-demo-chat --ignore-existing
+
 # 設置桶的公開讀取權限
 echo "🔓 設置桶權限..."
 mc anonymous set public local/stream-demo-videos
@@ -0,0 +1,99 @@
+package api
+
+import (
+	"net/http"
+	"stream-demo/backend/dto"
+	"stream-demo/backend/dto/response"
+	"stream-demo/backend/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// BrandingHandler 創作者品牌設定處理器
+type BrandingHandler struct {
+	brandingService *services.BrandingService
+}
+
+// NewBrandingHandler 創建品牌設定處理器
+func NewBrandingHandler(brandingService *services.BrandingService) *BrandingHandler {
+	return &BrandingHandler{brandingService: brandingService}
+}
+
+// GetBranding 獲取目前用戶的品牌設定
+func (h *BrandingHandler) GetBranding(c *gin.Context) {
+	userID, err := getUserIDFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, response.NewErrorResponse(401, "未登入"))
+		return
+	}
+
+	branding, err := h.brandingService.GetBranding(uint(userID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, response.NewErrorResponse(500, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, response.NewSuccessResponse(branding))
+}
+
+// UpdateBranding 更新目前用戶的品牌設定
+func (h *BrandingHandler) UpdateBranding(c *gin.Context) {
+	userID, err := getUserIDFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, response.NewErrorResponse(401, "未登入"))
+		return
+	}
+
+	var req dto.UserBrandingUpdateDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, response.NewErrorResponse(400, err.Error()))
+		return
+	}
+
+	branding, err := h.brandingService.UpdateBranding(uint(userID), &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.NewErrorResponse(400, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, response.NewSuccessResponse(branding))
+}
+
+// DeleteBranding 清除目前用戶的品牌設定
+func (h *BrandingHandler) DeleteBranding(c *gin.Context) {
+	userID, err := getUserIDFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, response.NewErrorResponse(401, "未登入"))
+		return
+	}
+
+	if err := h.brandingService.DeleteBranding(uint(userID)); err != nil {
+		c.JSON(http.StatusInternalServerError, response.NewErrorResponse(500, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, response.NewSuccessResponse(gin.H{"message": "刪除成功"}))
+}
+
+// CreateWatermarkUploadURL 取得浮水印圖片的上傳URL
+func (h *BrandingHandler) CreateWatermarkUploadURL(c *gin.Context) {
+	userID, err := getUserIDFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, response.NewErrorResponse(401, "未登入"))
+		return
+	}
+
+	var req dto.BrandingWatermarkUploadRequestDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, response.NewErrorResponse(400, err.Error()))
+		return
+	}
+
+	upload, err := h.brandingService.CreateWatermarkUploadURL(uint(userID), req.Filename)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.NewErrorResponse(400, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, response.NewSuccessResponse(upload))
+}
@@ -0,0 +1,51 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"stream-demo/backend/dto/response"
+	"stream-demo/backend/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CallHandler 通話處理器，信令經由私訊 WebSocket 收發
+type CallHandler struct {
+	callService *services.CallService
+}
+
+// NewCallHandler 創建通話處理器
+func NewCallHandler(callService *services.CallService) *CallHandler {
+	return &CallHandler{callService: callService}
+}
+
+// ListCalls 列出通話紀錄
+func (h *CallHandler) ListCalls(c *gin.Context) {
+	userID, err := getUserIDFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, response.NewErrorResponse(401, "未登入"))
+		return
+	}
+
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	calls, total, err := h.callService.ListCalls(uint(userID), offset, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, response.NewErrorResponse(500, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, response.NewSuccessResponse(response.NewListResponse(total, calls)))
+}
+
+// GetICEServers 獲取 STUN/TURN 伺服器與有時效的 TURN 憑證
+func (h *CallHandler) GetICEServers(c *gin.Context) {
+	userID, err := getUserIDFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, response.NewErrorResponse(401, "未登入"))
+		return
+	}
+
+	c.JSON(http.StatusOK, response.NewSuccessResponse(h.callService.ICEServers(uint(userID))))
+}
@@ -0,0 +1,146 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"stream-demo/backend/dto"
+	"stream-demo/backend/dto/response"
+	"stream-demo/backend/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CaptionHandler 字幕處理器
+type CaptionHandler struct {
+	captionService *services.CaptionService
+}
+
+// NewCaptionHandler 創建字幕處理器
+func NewCaptionHandler(captionService *services.CaptionService) *CaptionHandler {
+	return &CaptionHandler{captionService: captionService}
+}
+
+// ListCaptions 列出影片字幕
+func (h *CaptionHandler) ListCaptions(c *gin.Context) {
+	videoID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.NewErrorResponse(400, "無效的影片ID"))
+		return
+	}
+
+	captions, err := h.captionService.ListCaptions(viewerIDFromContext(c), uint(videoID))
+	if err != nil {
+		if errors.Is(err, services.ErrVideoNotVisible) {
+			c.JSON(http.StatusNotFound, response.NewErrorResponse(404, err.Error()))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, response.NewErrorResponse(500, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, response.NewSuccessResponse(response.NewListResponse(int64(len(captions)), captions)))
+}
+
+// CreateCaption 新增字幕並取得上傳URL
+func (h *CaptionHandler) CreateCaption(c *gin.Context) {
+	userID, err := getUserIDFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, response.NewErrorResponse(401, "未登入"))
+		return
+	}
+
+	videoID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.NewErrorResponse(400, "無效的影片ID"))
+		return
+	}
+
+	var req dto.VideoCaptionCreateDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, response.NewErrorResponse(400, err.Error()))
+		return
+	}
+
+	upload, err := h.captionService.CreateCaption(uint(userID), uint(videoID), &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.NewErrorResponse(400, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusCreated, response.NewSuccessResponse(upload))
+}
+
+// ConfirmCaptionUpload 確認字幕上傳完成
+func (h *CaptionHandler) ConfirmCaptionUpload(c *gin.Context) {
+	userID, err := getUserIDFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, response.NewErrorResponse(401, "未登入"))
+		return
+	}
+
+	videoID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.NewErrorResponse(400, "無效的影片ID"))
+		return
+	}
+
+	caption, err := h.captionService.ConfirmCaptionUpload(uint(userID), uint(videoID), c.Param("lang"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.NewErrorResponse(400, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, response.NewSuccessResponse(caption))
+}
+
+// UpdateCaption 更新字幕
+func (h *CaptionHandler) UpdateCaption(c *gin.Context) {
+	userID, err := getUserIDFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, response.NewErrorResponse(401, "未登入"))
+		return
+	}
+
+	videoID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.NewErrorResponse(400, "無效的影片ID"))
+		return
+	}
+
+	var req dto.VideoCaptionUpdateDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, response.NewErrorResponse(400, err.Error()))
+		return
+	}
+
+	result, err := h.captionService.UpdateCaption(uint(userID), uint(videoID), c.Param("lang"), &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.NewErrorResponse(400, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, response.NewSuccessResponse(result))
+}
+
+// DeleteCaption 刪除字幕
+func (h *CaptionHandler) DeleteCaption(c *gin.Context) {
+	userID, err := getUserIDFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, response.NewErrorResponse(401, "未登入"))
+		return
+	}
+
+	videoID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.NewErrorResponse(400, "無效的影片ID"))
+		return
+	}
+
+	if err := h.captionService.DeleteCaption(uint(userID), uint(videoID), c.Param("lang")); err != nil {
+		c.JSON(http.StatusBadRequest, response.NewErrorResponse(400, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, response.NewSuccessResponse(gin.H{"message": "刪除成功"}))
+}
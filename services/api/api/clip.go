@@ -0,0 +1,99 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"stream-demo/backend/dto"
+	"stream-demo/backend/dto/response"
+	"stream-demo/backend/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ClipHandler 精華片段處理器
+type ClipHandler struct {
+	clipService *services.ClipService
+}
+
+// NewClipHandler 創建精華片段處理器
+func NewClipHandler(clipService *services.ClipService) *ClipHandler {
+	return &ClipHandler{clipService: clipService}
+}
+
+// CreateVideoClip 從影片剪輯精華片段
+func (h *ClipHandler) CreateVideoClip(c *gin.Context) {
+	userID, err := getUserIDFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, response.NewErrorResponse(401, "未登入"))
+		return
+	}
+
+	videoID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.NewErrorResponse(400, "無效的影片ID"))
+		return
+	}
+
+	var req dto.VideoClipCreateDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, response.NewErrorResponse(400, err.Error()))
+		return
+	}
+
+	clip, err := h.clipService.CreateVideoClip(uint(userID), uint(videoID), &req)
+	if err != nil {
+		if errors.Is(err, services.ErrVideoNotVisible) {
+			c.JSON(http.StatusNotFound, response.NewErrorResponse(404, err.Error()))
+			return
+		}
+		c.JSON(http.StatusBadRequest, response.NewErrorResponse(400, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusCreated, response.NewSuccessResponse(clip))
+}
+
+// ListVideoClips 列出影片的精華片段
+func (h *ClipHandler) ListVideoClips(c *gin.Context) {
+	videoID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.NewErrorResponse(400, "無效的影片ID"))
+		return
+	}
+
+	clips, err := h.clipService.GetClipsBySourceVideo(viewerIDFromContext(c), uint(videoID))
+	if err != nil {
+		if errors.Is(err, services.ErrVideoNotVisible) {
+			c.JSON(http.StatusNotFound, response.NewErrorResponse(404, err.Error()))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, response.NewErrorResponse(500, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, response.NewSuccessResponse(response.NewListResponse(int64(len(clips)), clips)))
+}
+
+// CreateLiveClip 從直播 DVR 視窗剪輯精華片段
+func (h *ClipHandler) CreateLiveClip(c *gin.Context) {
+	userID, err := getUserIDFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, response.NewErrorResponse(401, "未登入"))
+		return
+	}
+
+	var req dto.VideoClipCreateDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, response.NewErrorResponse(400, err.Error()))
+		return
+	}
+
+	clip, err := h.clipService.CreateLiveClip(userID, c.Param("id"), &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.NewErrorResponse(400, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusCreated, response.NewSuccessResponse(clip))
+}
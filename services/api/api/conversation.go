@@ -0,0 +1,247 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"stream-demo/backend/dto"
+	"stream-demo/backend/dto/response"
+	"stream-demo/backend/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ConversationHandler 私訊處理器
+type ConversationHandler struct {
+	conversationService *services.ConversationService
+}
+
+// NewConversationHandler 創建私訊處理器
+func NewConversationHandler(conversationService *services.ConversationService) *ConversationHandler {
+	return &ConversationHandler{conversationService: conversationService}
+}
+
+// ListConversations 列出參與的會話，含最後訊息與未讀數
+func (h *ConversationHandler) ListConversations(c *gin.Context) {
+	userID, err := getUserIDFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, response.NewErrorResponse(401, "未登入"))
+		return
+	}
+
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	conversations, total, err := h.conversationService.ListConversations(uint(userID), offset, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, response.NewErrorResponse(500, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, response.NewSuccessResponse(response.NewListResponse(total, conversations)))
+}
+
+// OpenConversation 開啟與用戶的一對一會話
+func (h *ConversationHandler) OpenConversation(c *gin.Context) {
+	userID, err := getUserIDFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, response.NewErrorResponse(401, "未登入"))
+		return
+	}
+
+	var req dto.ConversationCreateDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, response.NewErrorResponse(400, err.Error()))
+		return
+	}
+
+	conversation, err := h.conversationService.OpenDirectConversation(uint(userID), req.UserID)
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response.NewSuccessResponse(conversation))
+}
+
+// GetConversation 獲取會話
+func (h *ConversationHandler) GetConversation(c *gin.Context) {
+	userID, conversationID, ok := h.parseRequest(c)
+	if !ok {
+		return
+	}
+
+	conversation, err := h.conversationService.GetConversation(userID, conversationID)
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response.NewSuccessResponse(conversation))
+}
+
+// ListMessages 獲取會話訊息，以 cursor 往前翻頁，以 after 補抓之後的訊息
+func (h *ConversationHandler) ListMessages(c *gin.Context) {
+	userID, conversationID, ok := h.parseRequest(c)
+	if !ok {
+		return
+	}
+
+	var query dto.MessageListQueryDTO
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, response.NewErrorResponse(400, err.Error()))
+		return
+	}
+
+	messages, err := h.conversationService.ListMessages(userID, conversationID, &query)
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response.NewSuccessResponse(messages))
+}
+
+// SendMessage 發送訊息
+func (h *ConversationHandler) SendMessage(c *gin.Context) {
+	userID, conversationID, ok := h.parseRequest(c)
+	if !ok {
+		return
+	}
+
+	var req dto.MessageSendDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, response.NewErrorResponse(400, err.Error()))
+		return
+	}
+
+	message, err := h.conversationService.SendMessage(userID, conversationID, &req)
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response.NewSuccessResponse(message))
+}
+
+// MarkDelivered 回報訊息已送達
+func (h *ConversationHandler) MarkDelivered(c *gin.Context) {
+	h.markReceipt(c, h.conversationService.MarkDelivered)
+}
+
+// MarkRead 回報訊息已讀
+func (h *ConversationHandler) MarkRead(c *gin.Context) {
+	h.markReceipt(c, h.conversationService.MarkRead)
+}
+
+// SetTyping 回報輸入中狀態
+func (h *ConversationHandler) SetTyping(c *gin.Context) {
+	userID, conversationID, ok := h.parseRequest(c)
+	if !ok {
+		return
+	}
+
+	var req dto.TypingDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, response.NewErrorResponse(400, err.Error()))
+		return
+	}
+
+	if err := h.conversationService.SetTyping(userID, conversationID, req.Typing); err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response.NewSuccessResponse(nil))
+}
+
+// markReceipt 處理送達與已讀回條
+func (h *ConversationHandler) markReceipt(c *gin.Context, mark func(userID, conversationID, messageID uint) error) {
+	userID, conversationID, ok := h.parseRequest(c)
+	if !ok {
+		return
+	}
+
+	var req dto.MessageReceiptDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, response.NewErrorResponse(400, err.Error()))
+		return
+	}
+
+	if err := mark(userID, conversationID, req.MessageID); err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response.NewSuccessResponse(nil))
+}
+
+// CreateAttachmentUploadURL 產生附件的預簽名上傳URL，上傳後以回傳的 key 發送訊息
+func (h *ConversationHandler) CreateAttachmentUploadURL(c *gin.Context) {
+	userID, conversationID, ok := h.parseRequest(c)
+	if !ok {
+		return
+	}
+
+	var req dto.AttachmentUploadRequestDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, response.NewErrorResponse(400, err.Error()))
+		return
+	}
+
+	upload, err := h.conversationService.CreateAttachmentUploadURL(userID, conversationID, &req)
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response.NewSuccessResponse(upload))
+}
+
+// GetAttachment 重新取得訊息附件的下載URL
+func (h *ConversationHandler) GetAttachment(c *gin.Context) {
+	userID, conversationID, ok := h.parseRequest(c)
+	if !ok {
+		return
+	}
+	messageID, ok := parseUintParam(c, "messageID", "無效的訊息ID")
+	if !ok {
+		return
+	}
+
+	attachment, err := h.conversationService.GetAttachment(userID, conversationID, messageID)
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response.NewSuccessResponse(attachment))
+}
+
+// parseRequest 解析登入用戶與會話ID，失敗時已寫入回應
+func (h *ConversationHandler) parseRequest(c *gin.Context) (uint, uint, bool) {
+	userID, err := getUserIDFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, response.NewErrorResponse(401, "未登入"))
+		return 0, 0, false
+	}
+	conversationID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.NewErrorResponse(400, "無效的會話ID"))
+		return 0, 0, false
+	}
+	return uint(userID), uint(conversationID), true
+}
+
+// respondError 會話或邀請連結不存在回傳 404，封鎖或沒有群組權限時回傳 403，其餘回傳 400
+func (h *ConversationHandler) respondError(c *gin.Context, err error) {
+	if errors.Is(err, services.ErrConversationNotFound) || errors.Is(err, services.ErrInviteNotFound) {
+		c.JSON(http.StatusNotFound, response.NewErrorResponse(404, err.Error()))
+		return
+	}
+	if errors.Is(err, services.ErrUserBlocked) || errors.Is(err, services.ErrGroupPermission) {
+		c.JSON(http.StatusForbidden, response.NewErrorResponse(403, err.Error()))
+		return
+	}
+	c.JSON(http.StatusBadRequest, response.NewErrorResponse(400, err.Error()))
+}
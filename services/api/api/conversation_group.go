@@ -0,0 +1,248 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"stream-demo/backend/dto"
+	"stream-demo/backend/dto/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CreateGroup 建立群組
+func (h *ConversationHandler) CreateGroup(c *gin.Context) {
+	userID, err := getUserIDFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, response.NewErrorResponse(401, "未登入"))
+		return
+	}
+
+	var req dto.GroupCreateDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, response.NewErrorResponse(400, err.Error()))
+		return
+	}
+
+	conversation, err := h.conversationService.CreateGroup(uint(userID), &req)
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, response.NewSuccessResponse(conversation))
+}
+
+// JoinGroup 以邀請碼加入群組
+func (h *ConversationHandler) JoinGroup(c *gin.Context) {
+	userID, err := getUserIDFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, response.NewErrorResponse(401, "未登入"))
+		return
+	}
+
+	var req dto.GroupJoinDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, response.NewErrorResponse(400, err.Error()))
+		return
+	}
+
+	conversation, err := h.conversationService.JoinByInvite(uint(userID), req.Code)
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response.NewSuccessResponse(conversation))
+}
+
+// UpdateGroup 更新群組名稱與頭像
+func (h *ConversationHandler) UpdateGroup(c *gin.Context) {
+	userID, conversationID, ok := h.parseRequest(c)
+	if !ok {
+		return
+	}
+
+	var req dto.GroupUpdateDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, response.NewErrorResponse(400, err.Error()))
+		return
+	}
+
+	conversation, err := h.conversationService.UpdateGroup(userID, conversationID, &req)
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response.NewSuccessResponse(conversation))
+}
+
+// CreateGroupAvatarUploadURL 產生群組頭像的預簽名上傳URL
+func (h *ConversationHandler) CreateGroupAvatarUploadURL(c *gin.Context) {
+	userID, conversationID, ok := h.parseRequest(c)
+	if !ok {
+		return
+	}
+
+	var req dto.GroupAvatarUploadRequestDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, response.NewErrorResponse(400, err.Error()))
+		return
+	}
+
+	upload, err := h.conversationService.CreateAvatarUploadURL(userID, conversationID, req.Filename)
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response.NewSuccessResponse(upload))
+}
+
+// AddGroupMembers 將用戶加入群組
+func (h *ConversationHandler) AddGroupMembers(c *gin.Context) {
+	userID, conversationID, ok := h.parseRequest(c)
+	if !ok {
+		return
+	}
+
+	var req dto.GroupMembersAddDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, response.NewErrorResponse(400, err.Error()))
+		return
+	}
+
+	conversation, err := h.conversationService.AddMembers(userID, conversationID, req.UserIDs)
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response.NewSuccessResponse(conversation))
+}
+
+// RemoveGroupMember 將成員移出群組
+func (h *ConversationHandler) RemoveGroupMember(c *gin.Context) {
+	userID, conversationID, ok := h.parseRequest(c)
+	if !ok {
+		return
+	}
+	targetID, ok := parseUintParam(c, "userID", "無效的用戶ID")
+	if !ok {
+		return
+	}
+
+	if err := h.conversationService.RemoveMember(userID, conversationID, targetID); err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response.NewSuccessResponse(nil))
+}
+
+// UpdateGroupMemberRole 設定或取消管理員
+func (h *ConversationHandler) UpdateGroupMemberRole(c *gin.Context) {
+	userID, conversationID, ok := h.parseRequest(c)
+	if !ok {
+		return
+	}
+	targetID, ok := parseUintParam(c, "userID", "無效的用戶ID")
+	if !ok {
+		return
+	}
+
+	var req dto.GroupMemberRoleDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, response.NewErrorResponse(400, err.Error()))
+		return
+	}
+
+	if err := h.conversationService.UpdateMemberRole(userID, conversationID, targetID, req.Role); err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response.NewSuccessResponse(nil))
+}
+
+// LeaveGroup 離開群組
+func (h *ConversationHandler) LeaveGroup(c *gin.Context) {
+	userID, conversationID, ok := h.parseRequest(c)
+	if !ok {
+		return
+	}
+
+	if err := h.conversationService.Leave(userID, conversationID); err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response.NewSuccessResponse(nil))
+}
+
+// CreateGroupInvite 建立邀請連結
+func (h *ConversationHandler) CreateGroupInvite(c *gin.Context) {
+	userID, conversationID, ok := h.parseRequest(c)
+	if !ok {
+		return
+	}
+
+	var req dto.GroupInviteCreateDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, response.NewErrorResponse(400, err.Error()))
+		return
+	}
+
+	invite, err := h.conversationService.CreateInvite(userID, conversationID, &req)
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, response.NewSuccessResponse(invite))
+}
+
+// ListGroupInvites 列出群組的邀請連結
+func (h *ConversationHandler) ListGroupInvites(c *gin.Context) {
+	userID, conversationID, ok := h.parseRequest(c)
+	if !ok {
+		return
+	}
+
+	invites, err := h.conversationService.ListInvites(userID, conversationID)
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response.NewSuccessResponse(invites))
+}
+
+// RevokeGroupInvite 撤銷邀請連結
+func (h *ConversationHandler) RevokeGroupInvite(c *gin.Context) {
+	userID, conversationID, ok := h.parseRequest(c)
+	if !ok {
+		return
+	}
+	inviteID, ok := parseUintParam(c, "inviteID", "無效的邀請連結ID")
+	if !ok {
+		return
+	}
+
+	if err := h.conversationService.RevokeInvite(userID, conversationID, inviteID); err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response.NewSuccessResponse(nil))
+}
+
+// parseUintParam 解析路徑中的ID參數，失敗時回傳 400
+func parseUintParam(c *gin.Context, name, message string) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param(name), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.NewErrorResponse(400, message))
+		return 0, false
+	}
+	return uint(id), true
+}
@@ -0,0 +1,143 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"stream-demo/backend/dto/response"
+	"stream-demo/backend/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// FollowHandler 追蹤與追蹤動態處理器
+type FollowHandler struct {
+	followService *services.FollowService
+	feedService   *services.FeedService
+}
+
+// NewFollowHandler 創建追蹤處理器
+func NewFollowHandler(followService *services.FollowService, feedService *services.FeedService) *FollowHandler {
+	return &FollowHandler{followService: followService, feedService: feedService}
+}
+
+// Follow 追蹤用戶
+func (h *FollowHandler) Follow(c *gin.Context) {
+	userID, targetID, ok := parseFollowRequest(c)
+	if !ok {
+		return
+	}
+
+	status, err := h.followService.Follow(userID, targetID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.NewErrorResponse(400, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, response.NewSuccessResponse(status))
+}
+
+// Unfollow 取消追蹤用戶
+func (h *FollowHandler) Unfollow(c *gin.Context) {
+	userID, targetID, ok := parseFollowRequest(c)
+	if !ok {
+		return
+	}
+
+	status, err := h.followService.Unfollow(userID, targetID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.NewErrorResponse(400, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, response.NewSuccessResponse(status))
+}
+
+// GetFollowStatus 獲取是否追蹤用戶與對方的追蹤統計
+func (h *FollowHandler) GetFollowStatus(c *gin.Context) {
+	targetID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.NewErrorResponse(400, "無效的用戶ID"))
+		return
+	}
+
+	status, err := h.followService.GetFollowStatus(viewerIDFromContext(c), uint(targetID))
+	if err != nil {
+		c.JSON(http.StatusNotFound, response.NewErrorResponse(404, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, response.NewSuccessResponse(status))
+}
+
+// ListFollowers 列出用戶的追隨者
+func (h *FollowHandler) ListFollowers(c *gin.Context) {
+	targetID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.NewErrorResponse(400, "無效的用戶ID"))
+		return
+	}
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	users, total, err := h.followService.ListFollowers(uint(targetID), offset, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, response.NewErrorResponse(500, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, response.NewSuccessResponse(response.NewListResponse(total, users)))
+}
+
+// ListFollowing 列出用戶追蹤中的用戶
+func (h *FollowHandler) ListFollowing(c *gin.Context) {
+	targetID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.NewErrorResponse(400, "無效的用戶ID"))
+		return
+	}
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	users, total, err := h.followService.ListFollowing(uint(targetID), offset, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, response.NewErrorResponse(500, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, response.NewSuccessResponse(response.NewListResponse(total, users)))
+}
+
+// GetFeed 獲取追蹤中創作者的新影片與正在進行的直播，以 cursor 取得下一頁
+func (h *FollowHandler) GetFeed(c *gin.Context) {
+	userID, err := getUserIDFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, response.NewErrorResponse(401, "未登入"))
+		return
+	}
+	limit, _ := strconv.Atoi(c.Query("limit"))
+
+	feed, err := h.feedService.GetFeed(uint(userID), c.Query("cursor"), limit)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.NewErrorResponse(400, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, response.NewSuccessResponse(feed))
+}
+
+// parseFollowRequest 解析登入用戶與目標用戶ID，失敗時已寫入錯誤回應
+func parseFollowRequest(c *gin.Context) (uint, uint, bool) {
+	userID, err := getUserIDFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, response.NewErrorResponse(401, "未登入"))
+		return 0, 0, false
+	}
+
+	targetID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.NewErrorResponse(400, "無效的用戶ID"))
+		return 0, 0, false
+	}
+
+	return uint(userID), uint(targetID), true
+}
@@ -0,0 +1,202 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"stream-demo/backend/dto/response"
+	"stream-demo/backend/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// FriendHandler 好友與封鎖處理器
+type FriendHandler struct {
+	friendService *services.FriendService
+}
+
+// NewFriendHandler 創建好友處理器
+func NewFriendHandler(friendService *services.FriendService) *FriendHandler {
+	return &FriendHandler{friendService: friendService}
+}
+
+// ListFriends 列出好友與上線狀態
+func (h *FriendHandler) ListFriends(c *gin.Context) {
+	userID, err := getUserIDFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, response.NewErrorResponse(401, "未登入"))
+		return
+	}
+
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	friends, total, err := h.friendService.ListFriends(uint(userID), offset, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, response.NewErrorResponse(500, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, response.NewSuccessResponse(response.NewListResponse(total, friends)))
+}
+
+// ListRequests 列出好友邀請，direction 為 incoming（預設）或 outgoing
+func (h *FriendHandler) ListRequests(c *gin.Context) {
+	userID, err := getUserIDFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, response.NewErrorResponse(401, "未登入"))
+		return
+	}
+
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	requests, total, err := h.friendService.ListRequests(uint(userID), c.Query("direction"), offset, limit)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.NewErrorResponse(400, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, response.NewSuccessResponse(response.NewListResponse(total, requests)))
+}
+
+// ListBlocked 列出已封鎖的用戶
+func (h *FriendHandler) ListBlocked(c *gin.Context) {
+	userID, err := getUserIDFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, response.NewErrorResponse(401, "未登入"))
+		return
+	}
+
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	users, total, err := h.friendService.ListBlocked(uint(userID), offset, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, response.NewErrorResponse(500, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, response.NewSuccessResponse(response.NewListResponse(total, users)))
+}
+
+// GetStatus 獲取與用戶的好友關係
+func (h *FriendHandler) GetStatus(c *gin.Context) {
+	userID, targetID, ok := parseFollowRequest(c)
+	if !ok {
+		return
+	}
+
+	status, err := h.friendService.GetStatus(userID, targetID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, response.NewErrorResponse(500, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, response.NewSuccessResponse(status))
+}
+
+// SendRequest 發送好友邀請
+func (h *FriendHandler) SendRequest(c *gin.Context) {
+	userID, targetID, ok := parseFollowRequest(c)
+	if !ok {
+		return
+	}
+
+	status, err := h.friendService.SendRequest(userID, targetID)
+	if err != nil {
+		respondFriendError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response.NewSuccessResponse(status))
+}
+
+// Accept 接受好友邀請
+func (h *FriendHandler) Accept(c *gin.Context) {
+	userID, requesterID, ok := parseFollowRequest(c)
+	if !ok {
+		return
+	}
+
+	status, err := h.friendService.Accept(userID, requesterID)
+	if err != nil {
+		respondFriendError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response.NewSuccessResponse(status))
+}
+
+// Decline 拒絕好友邀請
+func (h *FriendHandler) Decline(c *gin.Context) {
+	userID, requesterID, ok := parseFollowRequest(c)
+	if !ok {
+		return
+	}
+
+	if err := h.friendService.Decline(userID, requesterID); err != nil {
+		respondFriendError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response.NewSuccessResponse(nil))
+}
+
+// Remove 解除好友或取消送出的邀請
+func (h *FriendHandler) Remove(c *gin.Context) {
+	userID, friendID, ok := parseFollowRequest(c)
+	if !ok {
+		return
+	}
+
+	if err := h.friendService.Remove(userID, friendID); err != nil {
+		respondFriendError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response.NewSuccessResponse(nil))
+}
+
+// Block 封鎖用戶
+func (h *FriendHandler) Block(c *gin.Context) {
+	userID, targetID, ok := parseFollowRequest(c)
+	if !ok {
+		return
+	}
+
+	if err := h.friendService.Block(userID, targetID); err != nil {
+		respondFriendError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response.NewSuccessResponse(nil))
+}
+
+// Unblock 解除封鎖
+func (h *FriendHandler) Unblock(c *gin.Context) {
+	userID, targetID, ok := parseFollowRequest(c)
+	if !ok {
+		return
+	}
+
+	if err := h.friendService.Unblock(userID, targetID); err != nil {
+		respondFriendError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response.NewSuccessResponse(nil))
+}
+
+// respondFriendError 邀請不存在時回應 404，封鎖時回應 403，其餘回應 400
+func respondFriendError(c *gin.Context, err error) {
+	if errors.Is(err, services.ErrFriendRequestNotFound) {
+		c.JSON(http.StatusNotFound, response.NewErrorResponse(404, err.Error()))
+		return
+	}
+	if errors.Is(err, services.ErrUserBlocked) {
+		c.JSON(http.StatusForbidden, response.NewErrorResponse(403, err.Error()))
+		return
+	}
+	c.JSON(http.StatusBadRequest, response.NewErrorResponse(400, err.Error()))
+}
@@ -0,0 +1,105 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"stream-demo/backend/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ScheduleRoom 創建預約直播間
+func (h *LiveRoomHandler) ScheduleRoom(c *gin.Context) {
+	userID, err := getUserIDFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	var req struct {
+		Title       string    `json:"title" binding:"required"`
+		Description string    `json:"description"`
+		ScheduledAt time.Time `json:"scheduled_at" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "請求參數錯誤", "details": err.Error()})
+		return
+	}
+
+	room, err := h.liveRoomService.ScheduleRoom(userID, req.Title, req.Description, req.ScheduledAt)
+	if err != nil {
+		utils.LogError("創建預約直播間失敗: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "創建預約直播間失敗", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "預約直播間創建成功",
+		"data":    room,
+	})
+}
+
+// GetScheduledRooms 獲取即將開播的預約直播間
+func (h *LiveRoomHandler) GetScheduledRooms(c *gin.Context) {
+	limitStr := c.DefaultQuery("limit", "20")
+	limit, err := strconv.Atoi(limitStr)
+	if err != nil {
+		limit = 20
+	}
+
+	rooms, err := h.liveRoomService.GetScheduledRooms(limit)
+	if err != nil {
+		utils.LogError("獲取預約直播間列表失敗: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "獲取預約直播間列表失敗", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "獲取成功",
+		"data":    rooms,
+		"total":   len(rooms),
+	})
+}
+
+// RSVPRoom 預約直播開播提醒
+func (h *LiveRoomHandler) RSVPRoom(c *gin.Context) {
+	userID, err := getUserIDFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	roomID := c.Param("id")
+	if err := h.liveRoomService.RSVPRoom(roomID, userID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "預約提醒失敗", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "已預約開播提醒",
+		"room_id": roomID,
+	})
+}
+
+// CancelRSVP 取消預約開播提醒
+func (h *LiveRoomHandler) CancelRSVP(c *gin.Context) {
+	userID, err := getUserIDFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	roomID := c.Param("id")
+	if err := h.liveRoomService.CancelRSVP(roomID, userID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "取消預約提醒失敗", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "已取消開播提醒",
+		"room_id": roomID,
+	})
+}
@@ -176,8 +176,9 @@ func (h *PublicStreamHandler) GetStreamURLs(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
 		"data": gin.H{
-			"stream_name": streamName,
-			"urls":        urls,
+			"stream_name":        streamName,
+			"urls":               urls,
+			"preferred_manifest": h.service.PreferredManifest(),
 		},
 	})
 }
@@ -0,0 +1,159 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"stream-demo/backend/dto"
+	"stream-demo/backend/services"
+	"stream-demo/backend/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RestreamHandler 多平台轉推處理器
+type RestreamHandler struct {
+	restreamService *services.RestreamService
+}
+
+// NewRestreamHandler 創建多平台轉推處理器
+func NewRestreamHandler(restreamService *services.RestreamService) *RestreamHandler {
+	return &RestreamHandler{
+		restreamService: restreamService,
+	}
+}
+
+// ListTargets 獲取直播間轉推目標
+func (h *RestreamHandler) ListTargets(c *gin.Context) {
+	userID, err := getUserIDFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	roomID := c.Param("id")
+	targets, err := h.restreamService.ListTargets(roomID, userID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "獲取轉推目標失敗", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "獲取轉推目標成功",
+		"data":    targets,
+	})
+}
+
+// CreateTarget 新增轉推目標
+func (h *RestreamHandler) CreateTarget(c *gin.Context) {
+	userID, err := getUserIDFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	var req dto.RestreamTargetCreateDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "請求參數錯誤", "details": err.Error()})
+		return
+	}
+
+	roomID := c.Param("id")
+	target, err := h.restreamService.CreateTarget(roomID, userID, &req)
+	if err != nil {
+		utils.LogError("新增轉推目標失敗: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "新增轉推目標失敗", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "轉推目標新增成功",
+		"data":    target,
+	})
+}
+
+// UpdateTarget 更新轉推目標
+func (h *RestreamHandler) UpdateTarget(c *gin.Context) {
+	userID, err := getUserIDFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	targetID, err := strconv.ParseUint(c.Param("targetID"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "無效的轉推目標ID"})
+		return
+	}
+
+	var req dto.RestreamTargetUpdateDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "請求參數錯誤", "details": err.Error()})
+		return
+	}
+
+	roomID := c.Param("id")
+	target, err := h.restreamService.UpdateTarget(roomID, uint(targetID), userID, &req)
+	if err != nil {
+		utils.LogError("更新轉推目標失敗: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "更新轉推目標失敗", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "轉推目標更新成功",
+		"data":    target,
+	})
+}
+
+// DeleteTarget 刪除轉推目標
+func (h *RestreamHandler) DeleteTarget(c *gin.Context) {
+	userID, err := getUserIDFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	targetID, err := strconv.ParseUint(c.Param("targetID"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "無效的轉推目標ID"})
+		return
+	}
+
+	roomID := c.Param("id")
+	if err := h.restreamService.DeleteTarget(roomID, uint(targetID), userID); err != nil {
+		utils.LogError("刪除轉推目標失敗: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "刪除轉推目標失敗", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "轉推目標刪除成功",
+	})
+}
+
+// RetryTarget 手動重試轉推目標
+func (h *RestreamHandler) RetryTarget(c *gin.Context) {
+	userID, err := getUserIDFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	targetID, err := strconv.ParseUint(c.Param("targetID"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "無效的轉推目標ID"})
+		return
+	}
+
+	roomID := c.Param("id")
+	if err := h.restreamService.RetryTarget(roomID, uint(targetID), userID); err != nil {
+		utils.LogError("重試轉推目標失敗: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "重試轉推失敗", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "已重新啟動轉推",
+	})
+}
@@ -12,12 +12,29 @@ type Router struct {
 	engine *gin.Engine
 
 	// 處理器
-	userHandler         *UserHandler
-	videoHandler        *VideoHandler
-	liveHandler         *LiveHandler
-	liveRoomHandler     *LiveRoomHandler
-	paymentHandler      *PaymentHandler
-	publicStreamHandler *PublicStreamHandler
+	userHandler            *UserHandler
+	videoHandler           *VideoHandler
+	liveHandler            *LiveHandler
+	liveRoomHandler        *LiveRoomHandler
+	paymentHandler         *PaymentHandler
+	publicStreamHandler    *PublicStreamHandler
+	restreamHandler        *RestreamHandler
+	clipHandler            *ClipHandler
+	captionHandler         *CaptionHandler
+	videoKeyHandler        *VideoKeyHandler
+	brandingHandler        *BrandingHandler
+	videoUploadHandler     *VideoUploadHandler
+	videoDuplicateHandler  *VideoDuplicateHandler
+	videoTrashHandler      *VideoTrashHandler
+	videoVisibilityHandler *VideoVisibilityHandler
+	videoReactionHandler   *VideoReactionHandler
+	videoCommentHandler    *VideoCommentHandler
+	followHandler          *FollowHandler
+	notificationHandler    *NotificationHandler
+	webhookHandler         *WebhookHandler
+	conversationHandler    *ConversationHandler
+	friendHandler          *FriendHandler
+	callHandler            *CallHandler
 
 	// 工具
 	jwtUtil *utils.JWTUtil
@@ -32,17 +49,51 @@ func NewRouter(
 	liveRoomHandler *LiveRoomHandler,
 	paymentHandler *PaymentHandler,
 	publicStreamHandler *PublicStreamHandler,
+	restreamHandler *RestreamHandler,
+	clipHandler *ClipHandler,
+	captionHandler *CaptionHandler,
+	videoKeyHandler *VideoKeyHandler,
+	brandingHandler *BrandingHandler,
+	videoUploadHandler *VideoUploadHandler,
+	videoDuplicateHandler *VideoDuplicateHandler,
+	videoTrashHandler *VideoTrashHandler,
+	videoVisibilityHandler *VideoVisibilityHandler,
+	videoReactionHandler *VideoReactionHandler,
+	videoCommentHandler *VideoCommentHandler,
+	followHandler *FollowHandler,
+	notificationHandler *NotificationHandler,
+	webhookHandler *WebhookHandler,
+	conversationHandler *ConversationHandler,
+	friendHandler *FriendHandler,
+	callHandler *CallHandler,
 	jwtUtil *utils.JWTUtil,
 ) *Router {
 	return &Router{
-		engine:              engine,
-		userHandler:         userHandler,
-		videoHandler:        videoHandler,
-		liveHandler:         liveHandler,
-		liveRoomHandler:     liveRoomHandler,
-		paymentHandler:      paymentHandler,
-		publicStreamHandler: publicStreamHandler,
-		jwtUtil:             jwtUtil,
+		engine:                 engine,
+		userHandler:            userHandler,
+		videoHandler:           videoHandler,
+		liveHandler:            liveHandler,
+		liveRoomHandler:        liveRoomHandler,
+		paymentHandler:         paymentHandler,
+		publicStreamHandler:    publicStreamHandler,
+		restreamHandler:        restreamHandler,
+		clipHandler:            clipHandler,
+		captionHandler:         captionHandler,
+		videoKeyHandler:        videoKeyHandler,
+		brandingHandler:        brandingHandler,
+		videoUploadHandler:     videoUploadHandler,
+		videoDuplicateHandler:  videoDuplicateHandler,
+		videoTrashHandler:      videoTrashHandler,
+		videoVisibilityHandler: videoVisibilityHandler,
+		videoReactionHandler:   videoReactionHandler,
+		videoCommentHandler:    videoCommentHandler,
+		followHandler:          followHandler,
+		notificationHandler:    notificationHandler,
+		webhookHandler:         webhookHandler,
+		conversationHandler:    conversationHandler,
+		friendHandler:          friendHandler,
+		callHandler:            callHandler,
+		jwtUtil:                jwtUtil,
 	}
 }
 
@@ -83,6 +134,16 @@ func (r *Router) setupPublicRoutes() {
 		if r.publicStreamHandler != nil {
 			r.setupPublicStreamRoutes(public)
 		}
+
+		// HLS 金鑰伺服器（以播放憑證驗證，不經 JWT）
+		if r.videoKeyHandler != nil {
+			public.GET("/videos/:id/key", r.videoKeyHandler.GetKey)
+		}
+
+		// 不公開影片分享連結（持有連結即可觀看）
+		if r.videoVisibilityHandler != nil {
+			public.GET("/videos/shared/:slug", r.videoVisibilityHandler.GetSharedVideo)
+		}
 	}
 }
 
@@ -115,6 +176,106 @@ func (r *Router) setupUserRoutes(group *gin.RouterGroup) {
 		users.GET("/:id", r.userHandler.GetUser)
 		users.PUT("/:id", r.userHandler.UpdateUser)
 		users.DELETE("/:id", r.userHandler.DeleteUser)
+
+		// 追蹤
+		if r.followHandler != nil {
+			users.GET("/:id/follow", r.followHandler.GetFollowStatus)
+			users.POST("/:id/follow", r.followHandler.Follow)
+			users.DELETE("/:id/follow", r.followHandler.Unfollow)
+			users.GET("/:id/followers", r.followHandler.ListFollowers)
+			users.GET("/:id/following", r.followHandler.ListFollowing)
+		}
+	}
+
+	// 追蹤動態
+	if r.followHandler != nil {
+		group.GET("/feed", r.followHandler.GetFeed)
+	}
+
+	// 站內通知
+	if r.notificationHandler != nil {
+		notifications := group.Group("/notifications")
+		{
+			notifications.GET("", r.notificationHandler.ListNotifications)
+			notifications.GET("/unread-count", r.notificationHandler.GetUnreadCount)
+			notifications.POST("/read-all", r.notificationHandler.MarkAllRead)
+			notifications.POST("/:id/read", r.notificationHandler.MarkRead)
+			notifications.GET("/preferences", r.notificationHandler.GetPreferences)
+			notifications.PUT("/preferences", r.notificationHandler.UpdatePreferences)
+		}
+	}
+
+	// 對外 Webhook
+	if r.webhookHandler != nil {
+		webhooks := group.Group("/webhooks")
+		{
+			webhooks.GET("", r.webhookHandler.ListEndpoints)
+			webhooks.POST("", r.webhookHandler.CreateEndpoint)
+			webhooks.GET("/events", r.webhookHandler.ListEventTypes)
+			webhooks.GET("/:id", r.webhookHandler.GetEndpoint)
+			webhooks.PUT("/:id", r.webhookHandler.UpdateEndpoint)
+			webhooks.DELETE("/:id", r.webhookHandler.DeleteEndpoint)
+			webhooks.POST("/:id/rotate-secret", r.webhookHandler.RotateSecret)
+			webhooks.GET("/:id/deliveries", r.webhookHandler.ListDeliveries)
+			webhooks.GET("/:id/deliveries/:deliveryID", r.webhookHandler.GetDelivery)
+			webhooks.POST("/:id/deliveries/:deliveryID/redeliver", r.webhookHandler.Redeliver)
+		}
+	}
+
+	// 私訊
+	if r.conversationHandler != nil {
+		conversations := group.Group("/conversations")
+		{
+			conversations.GET("", r.conversationHandler.ListConversations)
+			conversations.POST("", r.conversationHandler.OpenConversation)
+			conversations.GET("/:id", r.conversationHandler.GetConversation)
+			conversations.GET("/:id/messages", r.conversationHandler.ListMessages)
+			conversations.POST("/:id/messages", r.conversationHandler.SendMessage)
+			conversations.POST("/:id/attachments", r.conversationHandler.CreateAttachmentUploadURL)
+			conversations.GET("/:id/messages/:messageID/attachment", r.conversationHandler.GetAttachment)
+			conversations.POST("/:id/delivered", r.conversationHandler.MarkDelivered)
+			conversations.POST("/:id/read", r.conversationHandler.MarkRead)
+			conversations.POST("/:id/typing", r.conversationHandler.SetTyping)
+
+			// 群組
+			conversations.POST("/groups", r.conversationHandler.CreateGroup)
+			conversations.POST("/join", r.conversationHandler.JoinGroup)
+			conversations.PUT("/:id", r.conversationHandler.UpdateGroup)
+			conversations.POST("/:id/avatar-upload", r.conversationHandler.CreateGroupAvatarUploadURL)
+			conversations.POST("/:id/members", r.conversationHandler.AddGroupMembers)
+			conversations.DELETE("/:id/members/:userID", r.conversationHandler.RemoveGroupMember)
+			conversations.PUT("/:id/members/:userID/role", r.conversationHandler.UpdateGroupMemberRole)
+			conversations.POST("/:id/leave", r.conversationHandler.LeaveGroup)
+			conversations.GET("/:id/invites", r.conversationHandler.ListGroupInvites)
+			conversations.POST("/:id/invites", r.conversationHandler.CreateGroupInvite)
+			conversations.DELETE("/:id/invites/:inviteID", r.conversationHandler.RevokeGroupInvite)
+		}
+	}
+
+	// 好友與封鎖
+	if r.friendHandler != nil {
+		friends := group.Group("/friends")
+		{
+			friends.GET("", r.friendHandler.ListFriends)
+			friends.GET("/requests", r.friendHandler.ListRequests)
+			friends.GET("/blocked", r.friendHandler.ListBlocked)
+			friends.GET("/:id", r.friendHandler.GetStatus)
+			friends.DELETE("/:id", r.friendHandler.Remove)
+			friends.POST("/:id/request", r.friendHandler.SendRequest)
+			friends.POST("/:id/accept", r.friendHandler.Accept)
+			friends.POST("/:id/decline", r.friendHandler.Decline)
+			friends.POST("/:id/block", r.friendHandler.Block)
+			friends.DELETE("/:id/block", r.friendHandler.Unblock)
+		}
+	}
+
+	// 通話紀錄與 ICE 伺服器（信令經由 /ws/chat）
+	if r.callHandler != nil {
+		calls := group.Group("/calls")
+		{
+			calls.GET("", r.callHandler.ListCalls)
+			calls.GET("/ice-servers", r.callHandler.GetICEServers)
+		}
 	}
 }
 
@@ -125,17 +286,96 @@ func (r *Router) setupVideoRoutes(group *gin.RouterGroup) {
 		videos.GET("", r.videoHandler.ListVideos)
 		videos.POST("/upload-url", r.videoHandler.GenerateUploadURL)
 		videos.POST("/confirm-upload", r.videoHandler.ConfirmUpload)
-		videos.POST("", r.videoHandler.UploadVideo)
 		videos.GET("/:id", r.videoHandler.GetVideo)
 		videos.GET("/:id/transcode-status", r.videoHandler.GetVideoTranscodeStatus)
 		videos.PUT("/:id", r.videoHandler.UpdateVideo)
 		videos.DELETE("/:id", r.videoHandler.DeleteVideo)
 		videos.GET("/search", r.videoHandler.SearchVideos)
 		videos.POST("/:id/like", r.videoHandler.LikeVideo)
+		videos.DELETE("/:id/like", r.videoHandler.UnlikeVideo)
+
+		// 伺服器端串流上傳
+		if r.videoUploadHandler != nil {
+			videos.POST("", r.videoUploadHandler.UploadVideo)
+		}
+
+		// 重複上傳：共用既有轉碼結果或重新轉碼
+		if r.videoDuplicateHandler != nil {
+			videos.POST("/:id/duplicate/link", r.videoDuplicateHandler.LinkDuplicate)
+			videos.POST("/:id/duplicate/transcode", r.videoDuplicateHandler.TranscodeDuplicate)
+		}
+
+		// 垃圾桶：刪除的影片保留期內可還原
+		if r.videoTrashHandler != nil {
+			videos.GET("/trash", r.videoTrashHandler.ListTrash)
+			videos.POST("/:id/restore", r.videoTrashHandler.RestoreVideo)
+		}
+
+		// 可見度、不公開分享連結與私人影片授權
+		if r.videoVisibilityHandler != nil {
+			videos.GET("/:id/visibility", r.videoVisibilityHandler.GetVisibility)
+			videos.PUT("/:id/visibility", r.videoVisibilityHandler.UpdateVisibility)
+			videos.POST("/:id/share-link", r.videoVisibilityHandler.RegenerateShareSlug)
+			videos.GET("/:id/grants", r.videoVisibilityHandler.ListGrants)
+			videos.POST("/:id/grants", r.videoVisibilityHandler.GrantAccess)
+			videos.DELETE("/:id/grants/:userID", r.videoVisibilityHandler.RevokeAccess)
+		}
+
+		// 影片反應：喜歡、不喜歡與表情
+		if r.videoReactionHandler != nil {
+			videos.GET("/:id/reactions", r.videoReactionHandler.GetReactions)
+			videos.PUT("/:id/reaction", r.videoReactionHandler.SetReaction)
+			videos.DELETE("/:id/reaction", r.videoReactionHandler.RemoveReaction)
+		}
+
+		// 影片留言：回覆、時間點、置頂、喜歡與審核
+		if r.videoCommentHandler != nil {
+			videos.GET("/:id/comments", r.videoCommentHandler.ListComments)
+			videos.POST("/:id/comments", r.videoCommentHandler.CreateComment)
+			videos.GET("/:id/comments/:commentID/replies", r.videoCommentHandler.ListReplies)
+			videos.PUT("/:id/comments/:commentID", r.videoCommentHandler.UpdateComment)
+			videos.DELETE("/:id/comments/:commentID", r.videoCommentHandler.DeleteComment)
+			videos.PUT("/:id/comments/:commentID/moderation", r.videoCommentHandler.ModerateComment)
+			videos.POST("/:id/comments/:commentID/pin", r.videoCommentHandler.PinComment)
+			videos.DELETE("/:id/comments/:commentID/pin", r.videoCommentHandler.UnpinComment)
+			videos.POST("/:id/comments/:commentID/like", r.videoCommentHandler.LikeComment)
+			videos.DELETE("/:id/comments/:commentID/like", r.videoCommentHandler.UnlikeComment)
+		}
+
+		// 精華片段
+		if r.clipHandler != nil {
+			videos.GET("/:id/clips", r.clipHandler.ListVideoClips)
+			videos.POST("/:id/clips", r.clipHandler.CreateVideoClip)
+		}
+
+		// 字幕
+		if r.captionHandler != nil {
+			videos.GET("/:id/captions", r.captionHandler.ListCaptions)
+			videos.POST("/:id/captions", r.captionHandler.CreateCaption)
+			videos.PUT("/:id/captions/:lang", r.captionHandler.UpdateCaption)
+			videos.DELETE("/:id/captions/:lang", r.captionHandler.DeleteCaption)
+			videos.POST("/:id/captions/:lang/confirm", r.captionHandler.ConfirmCaptionUpload)
+		}
+
+		// 加密影片播放憑證
+		if r.videoKeyHandler != nil {
+			videos.GET("/:id/playback-token", r.videoKeyHandler.GetPlaybackToken)
+		}
 	}
 
 	// 用戶視頻路由
 	group.GET("/users/:id/videos", r.videoHandler.GetUserVideos)
+
+	// 創作者品牌設定（浮水印、片頭/片尾）
+	if r.brandingHandler != nil {
+		branding := group.Group("/branding")
+		{
+			branding.GET("", r.brandingHandler.GetBranding)
+			branding.PUT("", r.brandingHandler.UpdateBranding)
+			branding.DELETE("", r.brandingHandler.DeleteBranding)
+			branding.POST("/watermark/upload-url", r.brandingHandler.CreateWatermarkUploadURL)
+		}
+	}
 }
 
 // setupLiveRoutes 設置直播路由
@@ -154,16 +394,34 @@ func (r *Router) setupLiveRoutes(group *gin.RouterGroup) {
 func (r *Router) setupLiveRoomRoutes(group *gin.RouterGroup) {
 	rooms := group.Group("/live-rooms")
 	{
-		rooms.GET("", r.liveRoomHandler.GetActiveRooms)       // 獲取活躍直播間列表
-		rooms.GET("/all", r.liveRoomHandler.GetAllRooms)      // 獲取所有直播間列表（包括已結束的）
-		rooms.POST("", r.liveRoomHandler.CreateRoom)          // 創建直播間
-		rooms.GET("/:id/role", r.liveRoomHandler.GetUserRole) // 獲取用戶角色 (必須在 /:id 之前)
-		rooms.GET("/:id", r.liveRoomHandler.GetRoomByID)      // 獲取直播間信息
-		rooms.POST("/:id/join", r.liveRoomHandler.JoinRoom)   // 加入直播間
-		rooms.POST("/:id/leave", r.liveRoomHandler.LeaveRoom) // 離開直播間
-		rooms.POST("/:id/start", r.liveRoomHandler.StartLive) // 開始直播
-		rooms.POST("/:id/end", r.liveRoomHandler.EndLive)     // 結束直播
-		rooms.DELETE("/:id", r.liveRoomHandler.CloseRoom)     // 關閉直播間
+		rooms.GET("", r.liveRoomHandler.GetActiveRooms)              // 獲取活躍直播間列表
+		rooms.GET("/all", r.liveRoomHandler.GetAllRooms)             // 獲取所有直播間列表（包括已結束的）
+		rooms.POST("", r.liveRoomHandler.CreateRoom)                 // 創建直播間
+		rooms.GET("/scheduled", r.liveRoomHandler.GetScheduledRooms) // 獲取預約直播間列表
+		rooms.POST("/schedule", r.liveRoomHandler.ScheduleRoom)      // 創建預約直播間
+		rooms.GET("/:id/role", r.liveRoomHandler.GetUserRole)        // 獲取用戶角色 (必須在 /:id 之前)
+		rooms.GET("/:id", r.liveRoomHandler.GetRoomByID)             // 獲取直播間信息
+		rooms.POST("/:id/join", r.liveRoomHandler.JoinRoom)          // 加入直播間
+		rooms.POST("/:id/leave", r.liveRoomHandler.LeaveRoom)        // 離開直播間
+		rooms.POST("/:id/start", r.liveRoomHandler.StartLive)        // 開始直播
+		rooms.POST("/:id/end", r.liveRoomHandler.EndLive)            // 結束直播
+		rooms.DELETE("/:id", r.liveRoomHandler.CloseRoom)            // 關閉直播間
+		rooms.POST("/:id/rsvp", r.liveRoomHandler.RSVPRoom)          // 預約開播提醒
+		rooms.DELETE("/:id/rsvp", r.liveRoomHandler.CancelRSVP)      // 取消開播提醒
+
+		// 多平台轉推
+		if r.restreamHandler != nil {
+			rooms.GET("/:id/restreams", r.restreamHandler.ListTargets)
+			rooms.POST("/:id/restreams", r.restreamHandler.CreateTarget)
+			rooms.PUT("/:id/restreams/:targetID", r.restreamHandler.UpdateTarget)
+			rooms.DELETE("/:id/restreams/:targetID", r.restreamHandler.DeleteTarget)
+			rooms.POST("/:id/restreams/:targetID/retry", r.restreamHandler.RetryTarget)
+		}
+
+		// 直播精華片段
+		if r.clipHandler != nil {
+			rooms.POST("/:id/clips", r.clipHandler.CreateLiveClip)
+		}
 	}
 }
 
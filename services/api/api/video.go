@@ -24,7 +24,7 @@ func (h *VideoHandler) ListVideos(c *gin.Context) {
 	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
 	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
 
-	videos, total, err := h.videoService.GetVideos(offset, limit)
+	videos, total, err := h.videoService.GetVideos(viewerIDFromContext(c), offset, limit)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, response.NewErrorResponse(500, err.Error()))
 		return
@@ -107,7 +107,7 @@ func (h *VideoHandler) GetVideoTranscodeStatus(c *gin.Context) {
 		return
 	}
 
-	video, err := h.videoService.GetVideoByID(uint(videoID))
+	video, err := h.videoService.GetVideoByID(viewerIDFromContext(c), uint(videoID))
 	if err != nil {
 		c.JSON(http.StatusNotFound, response.NewErrorResponse(http.StatusNotFound, "影片不存在"))
 		return
@@ -121,6 +121,8 @@ func (h *VideoHandler) GetVideoTranscodeStatus(c *gin.Context) {
 		"original_url":        video.OriginalURL,
 		"mp4_url":             video.MP4URL,
 		"hls_master_url":      video.HLSMasterURL,
+		"dash_manifest_url":   video.DASHManifestURL,
+		"packaging":           video.Packaging,
 		"thumbnail_url":       video.ThumbnailURL,
 		"file_size":           video.FileSize,
 		"original_format":     video.OriginalFormat,
@@ -131,57 +133,6 @@ func (h *VideoHandler) GetVideoTranscodeStatus(c *gin.Context) {
 	c.JSON(http.StatusOK, response.NewSuccessResponse(status))
 }
 
-// UploadVideo 傳統表單上傳方式（保留相容性）
-func (h *VideoHandler) UploadVideo(c *gin.Context) {
-	// 從 context 獲取使用者 ID
-	userID, exists := c.Get("user_id")
-	if !exists {
-		c.JSON(http.StatusUnauthorized, response.NewErrorResponse(401, "未登入"))
-		return
-	}
-
-	// 獲取表單數據
-	title := c.PostForm("title")
-	description := c.PostForm("description")
-
-	if title == "" {
-		c.JSON(http.StatusBadRequest, response.NewErrorResponse(400, "標題不能為空"))
-		return
-	}
-
-	// 獲取影片檔案
-	videoFile, videoHeader, err := c.Request.FormFile("video")
-	if err != nil {
-		c.JSON(http.StatusBadRequest, response.NewErrorResponse(400, "請上傳影片檔案"))
-		return
-	}
-	defer videoFile.Close()
-
-	// 生成上傳URL
-	uploadURL, err := h.videoService.GenerateUploadURL(userID.(uint), videoHeader.Filename, videoHeader.Size)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, response.NewErrorResponse(400, err.Error()))
-		return
-	}
-
-	// 創建影片記錄
-	video, err := h.videoService.CreateVideoRecord(userID.(uint), title, description, uploadURL.Key)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, response.NewErrorResponse(500, err.Error()))
-		return
-	}
-
-	// TODO: 在實際應用中，將檔案上傳到S3
-	// 這裡可以實現直接上傳邏輯，或者返回預簽名 URL 讓前端處理
-
-	c.JSON(http.StatusCreated, response.NewSuccessResponse(gin.H{
-		"video":      video,
-		"upload_url": uploadURL.UploadURL,
-		"form_data":  uploadURL.FormData,
-		"message":    "檔案已接收，請使用返回的upload_url完成S3上傳，或調用確認上傳API",
-	}))
-}
-
 // GetVideo 獲取影片詳情
 func (h *VideoHandler) GetVideo(c *gin.Context) {
 	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
@@ -190,7 +141,7 @@ func (h *VideoHandler) GetVideo(c *gin.Context) {
 		return
 	}
 
-	video, err := h.videoService.GetVideoByID(uint(id))
+	video, err := h.videoService.GetVideoByID(viewerIDFromContext(c), uint(id))
 	if err != nil {
 		c.JSON(http.StatusNotFound, response.NewErrorResponse(404, "影片不存在"))
 		return
@@ -224,7 +175,7 @@ func (h *VideoHandler) UpdateVideo(c *gin.Context) {
 	}
 
 	// 獲取更新後的影片
-	video, err := h.videoService.GetVideoByID(uint(id))
+	video, err := h.videoService.GetVideoByID(viewerIDFromContext(c), uint(id))
 	if err != nil {
 		c.JSON(http.StatusOK, response.NewSuccessResponse(gin.H{"message": "更新成功"}))
 		return
@@ -246,35 +197,41 @@ func (h *VideoHandler) DeleteVideo(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, response.NewSuccessResponse(gin.H{"message": "刪除成功"}))
+	c.JSON(http.StatusOK, response.NewSuccessResponse(gin.H{"message": "已移至垃圾桶"}))
 }
 
-// SearchVideos 搜尋影片
+// SearchVideos 搜尋影片（支援篩選、排序與分面統計）
 func (h *VideoHandler) SearchVideos(c *gin.Context) {
-	var req request.SearchVideoRequest
+	var req dto.VideoSearchDTO
 	if err := c.ShouldBindQuery(&req); err != nil {
 		c.JSON(http.StatusBadRequest, response.NewErrorResponse(400, err.Error()))
 		return
 	}
 
-	videos, total, err := h.videoService.SearchVideos(req.Query, req.Offset, req.Limit)
+	result, err := h.videoService.SearchVideos(viewerIDFromContext(c), &req)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, response.NewErrorResponse(500, err.Error()))
 		return
 	}
 
-	c.JSON(http.StatusOK, response.NewSuccessResponse(response.NewListResponse(total, videos)))
+	c.JSON(http.StatusOK, response.NewSuccessResponse(result))
 }
 
-// LikeVideo 點讚影片
+// LikeVideo 點讚影片（重複按讚不會重複計數）
 func (h *VideoHandler) LikeVideo(c *gin.Context) {
+	userID, err := getUserIDFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, response.NewErrorResponse(401, "未登入"))
+		return
+	}
+
 	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, response.NewErrorResponse(400, "無效的影片 ID"))
 		return
 	}
 
-	if err := h.videoService.LikeVideo(uint(id)); err != nil {
+	if err := h.videoService.LikeVideo(uint(userID), uint(id)); err != nil {
 		c.JSON(http.StatusInternalServerError, response.NewErrorResponse(500, err.Error()))
 		return
 	}
@@ -282,6 +239,28 @@ func (h *VideoHandler) LikeVideo(c *gin.Context) {
 	c.JSON(http.StatusOK, response.NewSuccessResponse(gin.H{"message": "按讚成功"}))
 }
 
+// UnlikeVideo 取消點讚影片
+func (h *VideoHandler) UnlikeVideo(c *gin.Context) {
+	userID, err := getUserIDFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, response.NewErrorResponse(401, "未登入"))
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.NewErrorResponse(400, "無效的影片 ID"))
+		return
+	}
+
+	if err := h.videoService.UnlikeVideo(uint(userID), uint(id)); err != nil {
+		c.JSON(http.StatusInternalServerError, response.NewErrorResponse(500, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, response.NewSuccessResponse(gin.H{"message": "已取消按讚"}))
+}
+
 // GetUserVideos 獲取用戶影片
 func (h *VideoHandler) GetUserVideos(c *gin.Context) {
 	// 獲取用戶 ID 參數
@@ -291,7 +270,7 @@ func (h *VideoHandler) GetUserVideos(c *gin.Context) {
 		return
 	}
 
-	videos, total, err := h.videoService.GetVideosByUserID(uint(userID))
+	videos, total, err := h.videoService.GetVideosByUserID(viewerIDFromContext(c), uint(userID))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, response.NewErrorResponse(500, err.Error()))
 		return
@@ -299,3 +278,12 @@ func (h *VideoHandler) GetUserVideos(c *gin.Context) {
 
 	c.JSON(http.StatusOK, response.NewSuccessResponse(response.NewListResponse(total, videos)))
 }
+
+// viewerIDFromContext 取得目前檢視者的用戶ID，未登入時為 0（只能看到公開影片）
+func viewerIDFromContext(c *gin.Context) uint {
+	userID, err := getUserIDFromContext(c)
+	if err != nil {
+		return 0
+	}
+	return uint(userID)
+}
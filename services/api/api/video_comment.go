@@ -0,0 +1,244 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"stream-demo/backend/dto"
+	"stream-demo/backend/dto/response"
+	"stream-demo/backend/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// VideoCommentHandler 影片留言處理器
+type VideoCommentHandler struct {
+	videoCommentService *services.VideoCommentService
+}
+
+// NewVideoCommentHandler 創建影片留言處理器
+func NewVideoCommentHandler(videoCommentService *services.VideoCommentService) *VideoCommentHandler {
+	return &VideoCommentHandler{videoCommentService: videoCommentService}
+}
+
+// ListComments 列出影片留言，sort 為 top（預設）或 newest，以 cursor 取得下一頁
+func (h *VideoCommentHandler) ListComments(c *gin.Context) {
+	videoID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.NewErrorResponse(400, "無效的影片ID"))
+		return
+	}
+
+	var query dto.VideoCommentListQueryDTO
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, response.NewErrorResponse(400, err.Error()))
+		return
+	}
+
+	comments, err := h.videoCommentService.ListComments(viewerIDFromContext(c), isAdminFromContext(c), uint(videoID), &query)
+	if err != nil {
+		respondCommentError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response.NewSuccessResponse(comments))
+}
+
+// ListReplies 列出留言的回覆
+func (h *VideoCommentHandler) ListReplies(c *gin.Context) {
+	videoID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.NewErrorResponse(400, "無效的影片ID"))
+		return
+	}
+	commentID, err := strconv.ParseUint(c.Param("commentID"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.NewErrorResponse(400, "無效的留言ID"))
+		return
+	}
+	limit, _ := strconv.Atoi(c.Query("limit"))
+
+	replies, err := h.videoCommentService.ListReplies(viewerIDFromContext(c), isAdminFromContext(c), uint(videoID), uint(commentID), c.Query("cursor"), limit)
+	if err != nil {
+		respondCommentError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response.NewSuccessResponse(replies))
+}
+
+// CreateComment 新增留言或回覆
+func (h *VideoCommentHandler) CreateComment(c *gin.Context) {
+	userID, videoID, ok := parseVisibilityRequest(c)
+	if !ok {
+		return
+	}
+
+	var req dto.VideoCommentCreateDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, response.NewErrorResponse(400, err.Error()))
+		return
+	}
+
+	comment, err := h.videoCommentService.CreateComment(userID, videoID, &req)
+	if err != nil {
+		respondCommentError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, response.NewSuccessResponse(comment))
+}
+
+// UpdateComment 編輯自己的留言
+func (h *VideoCommentHandler) UpdateComment(c *gin.Context) {
+	userID, videoID, commentID, ok := parseCommentRequest(c)
+	if !ok {
+		return
+	}
+
+	var req dto.VideoCommentUpdateDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, response.NewErrorResponse(400, err.Error()))
+		return
+	}
+
+	comment, err := h.videoCommentService.UpdateComment(userID, videoID, commentID, req.Content)
+	if err != nil {
+		respondCommentError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response.NewSuccessResponse(comment))
+}
+
+// DeleteComment 刪除留言與其回覆
+func (h *VideoCommentHandler) DeleteComment(c *gin.Context) {
+	userID, videoID, commentID, ok := parseCommentRequest(c)
+	if !ok {
+		return
+	}
+
+	if err := h.videoCommentService.DeleteComment(userID, isAdminFromContext(c), videoID, commentID); err != nil {
+		respondCommentError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response.NewSuccessResponse(gin.H{"message": "留言已刪除"}))
+}
+
+// ModerateComment 隱藏或恢復留言
+func (h *VideoCommentHandler) ModerateComment(c *gin.Context) {
+	userID, videoID, commentID, ok := parseCommentRequest(c)
+	if !ok {
+		return
+	}
+
+	var req dto.VideoCommentModerateDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, response.NewErrorResponse(400, err.Error()))
+		return
+	}
+
+	comment, err := h.videoCommentService.ModerateComment(userID, isAdminFromContext(c), videoID, commentID, req.Status)
+	if err != nil {
+		respondCommentError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response.NewSuccessResponse(comment))
+}
+
+// PinComment 置頂留言
+func (h *VideoCommentHandler) PinComment(c *gin.Context) {
+	userID, videoID, commentID, ok := parseCommentRequest(c)
+	if !ok {
+		return
+	}
+
+	if err := h.videoCommentService.PinComment(userID, videoID, commentID); err != nil {
+		respondCommentError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response.NewSuccessResponse(gin.H{"message": "已置頂"}))
+}
+
+// UnpinComment 取消置頂留言
+func (h *VideoCommentHandler) UnpinComment(c *gin.Context) {
+	userID, videoID, commentID, ok := parseCommentRequest(c)
+	if !ok {
+		return
+	}
+
+	if err := h.videoCommentService.UnpinComment(userID, videoID, commentID); err != nil {
+		respondCommentError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response.NewSuccessResponse(gin.H{"message": "已取消置頂"}))
+}
+
+// LikeComment 喜歡留言
+func (h *VideoCommentHandler) LikeComment(c *gin.Context) {
+	userID, videoID, commentID, ok := parseCommentRequest(c)
+	if !ok {
+		return
+	}
+
+	if err := h.videoCommentService.LikeComment(userID, videoID, commentID); err != nil {
+		respondCommentError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response.NewSuccessResponse(gin.H{"message": "按讚成功"}))
+}
+
+// UnlikeComment 取消喜歡留言
+func (h *VideoCommentHandler) UnlikeComment(c *gin.Context) {
+	userID, videoID, commentID, ok := parseCommentRequest(c)
+	if !ok {
+		return
+	}
+
+	if err := h.videoCommentService.UnlikeComment(userID, videoID, commentID); err != nil {
+		respondCommentError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response.NewSuccessResponse(gin.H{"message": "已取消按讚"}))
+}
+
+// parseCommentRequest 解析登入用戶、影片ID與留言ID，失敗時已寫入錯誤回應
+func parseCommentRequest(c *gin.Context) (uint, uint, uint, bool) {
+	userID, videoID, ok := parseVisibilityRequest(c)
+	if !ok {
+		return 0, 0, 0, false
+	}
+
+	commentID, err := strconv.ParseUint(c.Param("commentID"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.NewErrorResponse(400, "無效的留言ID"))
+		return 0, 0, 0, false
+	}
+
+	return userID, videoID, uint(commentID), true
+}
+
+// isAdminFromContext 目前用戶是否為管理員
+func isAdminFromContext(c *gin.Context) bool {
+	role, _ := c.Get("role")
+	return role == "admin"
+}
+
+// respondCommentError 影片或留言不存在時回應 404，封鎖時回應 403，其餘回應 400
+func respondCommentError(c *gin.Context, err error) {
+	if errors.Is(err, services.ErrVideoNotVisible) || errors.Is(err, services.ErrCommentNotFound) {
+		c.JSON(http.StatusNotFound, response.NewErrorResponse(404, err.Error()))
+		return
+	}
+	if errors.Is(err, services.ErrUserBlocked) {
+		c.JSON(http.StatusForbidden, response.NewErrorResponse(403, err.Error()))
+		return
+	}
+	c.JSON(http.StatusBadRequest, response.NewErrorResponse(400, err.Error()))
+}
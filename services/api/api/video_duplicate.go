@@ -0,0 +1,69 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"stream-demo/backend/dto/response"
+	"stream-demo/backend/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// VideoDuplicateHandler 重複上傳處理器
+type VideoDuplicateHandler struct {
+	videoDuplicateService *services.VideoDuplicateService
+}
+
+// NewVideoDuplicateHandler 創建重複上傳處理器
+func NewVideoDuplicateHandler(videoDuplicateService *services.VideoDuplicateService) *VideoDuplicateHandler {
+	return &VideoDuplicateHandler{videoDuplicateService: videoDuplicateService}
+}
+
+// LinkDuplicate 共用重複來源影片的轉碼結果
+func (h *VideoDuplicateHandler) LinkDuplicate(c *gin.Context) {
+	userID, videoID, ok := parseDuplicateRequest(c)
+	if !ok {
+		return
+	}
+
+	video, err := h.videoDuplicateService.LinkDuplicate(userID, videoID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.NewErrorResponse(400, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, response.NewSuccessResponse(video))
+}
+
+// TranscodeDuplicate 忽略重複偵測並照常轉碼
+func (h *VideoDuplicateHandler) TranscodeDuplicate(c *gin.Context) {
+	userID, videoID, ok := parseDuplicateRequest(c)
+	if !ok {
+		return
+	}
+
+	video, err := h.videoDuplicateService.TranscodeDuplicate(userID, videoID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.NewErrorResponse(400, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, response.NewSuccessResponse(video))
+}
+
+// parseDuplicateRequest 解析登入用戶與影片ID，失敗時已寫入錯誤回應
+func parseDuplicateRequest(c *gin.Context) (uint, uint, bool) {
+	userID, err := getUserIDFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, response.NewErrorResponse(401, "未登入"))
+		return 0, 0, false
+	}
+
+	videoID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.NewErrorResponse(400, "無效的影片ID"))
+		return 0, 0, false
+	}
+
+	return uint(userID), uint(videoID), true
+}
@@ -0,0 +1,85 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"stream-demo/backend/dto/response"
+	"stream-demo/backend/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// VideoKeyHandler 內容金鑰處理器
+type VideoKeyHandler struct {
+	videoKeyService *services.VideoKeyService
+}
+
+// NewVideoKeyHandler 創建內容金鑰處理器
+func NewVideoKeyHandler(videoKeyService *services.VideoKeyService) *VideoKeyHandler {
+	return &VideoKeyHandler{videoKeyService: videoKeyService}
+}
+
+// GetPlaybackToken 取得加密影片的播放憑證，觀看不公開影片時以 share 查詢參數帶上分享代碼
+func (h *VideoKeyHandler) GetPlaybackToken(c *gin.Context) {
+	userID, err := getUserIDFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, response.NewErrorResponse(401, "未登入"))
+		return
+	}
+
+	videoID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.NewErrorResponse(400, "無效的影片ID"))
+		return
+	}
+
+	token, err := h.videoKeyService.IssuePlaybackToken(uint(userID), uint(videoID), c.Query("share"))
+	if err != nil {
+		c.JSON(http.StatusForbidden, response.NewErrorResponse(403, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, response.NewSuccessResponse(token))
+}
+
+// GetKey 金鑰伺服器：驗證播放憑證與播放權限後回傳 AES-128 金鑰
+// 播放器請求金鑰時無法帶 JWT，改以 token 查詢參數或 X-Playback-Token 標頭傳遞播放憑證
+func (h *VideoKeyHandler) GetKey(c *gin.Context) {
+	videoID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.NewErrorResponse(400, "無效的影片ID"))
+		return
+	}
+
+	keyIndex, err := strconv.Atoi(c.DefaultQuery("k", "0"))
+	if err != nil || keyIndex < 0 {
+		c.JSON(http.StatusBadRequest, response.NewErrorResponse(400, "無效的金鑰序號"))
+		return
+	}
+
+	token := c.Query("token")
+	if token == "" {
+		token = c.GetHeader("X-Playback-Token")
+	}
+
+	userID, shareSlug, err := h.videoKeyService.VerifyPlaybackToken(token, uint(videoID))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, response.NewErrorResponse(401, err.Error()))
+		return
+	}
+
+	// 簽發後權限可能已變更，取得金鑰時重新檢查
+	if _, err := h.videoKeyService.AuthorizePlayback(userID, uint(videoID), shareSlug); err != nil {
+		c.JSON(http.StatusForbidden, response.NewErrorResponse(403, err.Error()))
+		return
+	}
+
+	key, err := h.videoKeyService.GetContentKey(uint(videoID), keyIndex)
+	if err != nil {
+		c.JSON(http.StatusNotFound, response.NewErrorResponse(404, "找不到內容金鑰"))
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.Data(http.StatusOK, "application/octet-stream", key)
+}
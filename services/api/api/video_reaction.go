@@ -0,0 +1,76 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"stream-demo/backend/dto"
+	"stream-demo/backend/dto/response"
+	"stream-demo/backend/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// VideoReactionHandler 影片反應處理器
+type VideoReactionHandler struct {
+	videoReactionService *services.VideoReactionService
+}
+
+// NewVideoReactionHandler 創建影片反應處理器
+func NewVideoReactionHandler(videoReactionService *services.VideoReactionService) *VideoReactionHandler {
+	return &VideoReactionHandler{videoReactionService: videoReactionService}
+}
+
+// GetReactions 獲取影片反應數量與自己的反應，不公開影片以 share 查詢參數帶上分享代碼
+func (h *VideoReactionHandler) GetReactions(c *gin.Context) {
+	videoID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.NewErrorResponse(400, "無效的影片ID"))
+		return
+	}
+
+	summary, err := h.videoReactionService.GetSummary(viewerIDFromContext(c), uint(videoID), c.Query("share"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, response.NewErrorResponse(404, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, response.NewSuccessResponse(summary))
+}
+
+// SetReaction 設定自己對影片的反應（喜歡、不喜歡或表情）
+func (h *VideoReactionHandler) SetReaction(c *gin.Context) {
+	userID, videoID, ok := parseVisibilityRequest(c)
+	if !ok {
+		return
+	}
+
+	var req dto.VideoReactionSetDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, response.NewErrorResponse(400, err.Error()))
+		return
+	}
+
+	summary, err := h.videoReactionService.SetReaction(userID, videoID, req.Type, req.Emoji, c.Query("share"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.NewErrorResponse(400, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, response.NewSuccessResponse(summary))
+}
+
+// RemoveReaction 移除自己對影片的反應
+func (h *VideoReactionHandler) RemoveReaction(c *gin.Context) {
+	userID, videoID, ok := parseVisibilityRequest(c)
+	if !ok {
+		return
+	}
+
+	summary, err := h.videoReactionService.RemoveReaction(userID, videoID, c.Query("type"), c.Query("share"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.NewErrorResponse(400, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, response.NewSuccessResponse(summary))
+}
@@ -33,7 +33,7 @@ func TestVideoHandler_ListVideos(t *testing.T) {
 				"limit":  "10",
 			},
 			mockSetup: func(mockService *mocks.MockVideoService) {
-				mockService.On("GetVideos", 0, 10).Return([]*dto.VideoDTO{
+				mockService.On("GetVideos", uint(0), 0, 10).Return([]*dto.VideoDTO{
 					{
 						ID:          1,
 						Title:       "測試影片1",
@@ -56,7 +56,7 @@ func TestVideoHandler_ListVideos(t *testing.T) {
 				"limit":  "10",
 			},
 			mockSetup: func(mockService *mocks.MockVideoService) {
-				mockService.On("GetVideos", 0, 10).Return(nil, int64(0), assert.AnError)
+				mockService.On("GetVideos", uint(0), 0, 10).Return(nil, int64(0), assert.AnError)
 			},
 			expectedStatus: http.StatusInternalServerError,
 			expectedError:  true,
@@ -130,7 +130,7 @@ func TestVideoHandler_GetVideo(t *testing.T) {
 			name:    "成功獲取影片",
 			videoID: "1",
 			mockSetup: func(mockService *mocks.MockVideoService) {
-				mockService.On("GetVideoByID", uint(1)).Return(&dto.VideoDTO{
+				mockService.On("GetVideoByID", uint(0), uint(1)).Return(&dto.VideoDTO{
 					ID:          1,
 					Title:       "測試影片",
 					Description: "這是測試影片",
@@ -148,7 +148,7 @@ func TestVideoHandler_GetVideo(t *testing.T) {
 			name:    "影片不存在",
 			videoID: "999",
 			mockSetup: func(mockService *mocks.MockVideoService) {
-				mockService.On("GetVideoByID", uint(999)).Return((*dto.VideoDTO)(nil), assert.AnError)
+				mockService.On("GetVideoByID", uint(0), uint(999)).Return((*dto.VideoDTO)(nil), assert.AnError)
 			},
 			expectedStatus: http.StatusNotFound,
 			expectedError:  true,
@@ -224,7 +224,7 @@ func TestVideoHandler_LikeVideo(t *testing.T) {
 			name:    "成功喜歡影片",
 			videoID: "1",
 			mockSetup: func(mockService *mocks.MockVideoService) {
-				mockService.On("LikeVideo", uint(1)).Return(nil)
+				mockService.On("LikeVideo", uint(1), uint(1)).Return(nil)
 			},
 			expectedStatus: http.StatusOK,
 			expectedError:  false,
@@ -233,7 +233,7 @@ func TestVideoHandler_LikeVideo(t *testing.T) {
 			name:    "影片不存在",
 			videoID: "999",
 			mockSetup: func(mockService *mocks.MockVideoService) {
-				mockService.On("LikeVideo", uint(999)).Return(assert.AnError)
+				mockService.On("LikeVideo", uint(1), uint(999)).Return(assert.AnError)
 			},
 			expectedStatus: http.StatusInternalServerError,
 			expectedError:  true,
@@ -272,6 +272,10 @@ func TestVideoHandler_LikeVideo(t *testing.T) {
 
 			// 創建路由
 			router := gin.New()
+			router.Use(func(c *gin.Context) {
+				c.Set("user_id", uint(1))
+				c.Next()
+			})
 			router.POST("/api/videos/:id/like", handler.LikeVideo)
 
 			// 執行請求
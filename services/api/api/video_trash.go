@@ -0,0 +1,60 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"stream-demo/backend/dto/response"
+	"stream-demo/backend/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// VideoTrashHandler 影片垃圾桶處理器
+type VideoTrashHandler struct {
+	videoTrashService *services.VideoTrashService
+}
+
+// NewVideoTrashHandler 創建影片垃圾桶處理器
+func NewVideoTrashHandler(videoTrashService *services.VideoTrashService) *VideoTrashHandler {
+	return &VideoTrashHandler{videoTrashService: videoTrashService}
+}
+
+// ListTrash 列出垃圾桶中的影片與預計永久刪除時間
+func (h *VideoTrashHandler) ListTrash(c *gin.Context) {
+	userID, err := getUserIDFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, response.NewErrorResponse(401, "未登入"))
+		return
+	}
+
+	videos, err := h.videoTrashService.ListTrash(uint(userID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, response.NewErrorResponse(500, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, response.NewSuccessResponse(videos))
+}
+
+// RestoreVideo 從垃圾桶還原影片
+func (h *VideoTrashHandler) RestoreVideo(c *gin.Context) {
+	userID, err := getUserIDFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, response.NewErrorResponse(401, "未登入"))
+		return
+	}
+
+	videoID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.NewErrorResponse(400, "無效的影片ID"))
+		return
+	}
+
+	video, err := h.videoTrashService.RestoreVideo(uint(userID), uint(videoID))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.NewErrorResponse(400, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, response.NewSuccessResponse(video))
+}
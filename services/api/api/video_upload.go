@@ -0,0 +1,128 @@
+package api
+
+import (
+	"errors"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"stream-demo/backend/dto/response"
+	"stream-demo/backend/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// maxUploadFieldSize 表單文字欄位的讀取上限
+const maxUploadFieldSize = 4096
+
+// VideoUploadHandler 伺服器端串流上傳處理器
+type VideoUploadHandler struct {
+	videoUploadService *services.VideoUploadService
+}
+
+// NewVideoUploadHandler 創建串流上傳處理器
+func NewVideoUploadHandler(videoUploadService *services.VideoUploadService) *VideoUploadHandler {
+	return &VideoUploadHandler{videoUploadService: videoUploadService}
+}
+
+// UploadVideo 表單上傳影片（供無法使用預簽名 URL 的客戶端）
+// 以 multipart/form-data 逐段讀取，title、description 欄位需在 video 檔案之前送出；
+// 可帶 upload_id 查詢參數，並先連線 /ws/uploads/:uploadID 接收上傳進度
+func (h *VideoUploadHandler) UploadVideo(c *gin.Context) {
+	userID, err := getUserIDFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, response.NewErrorResponse(401, "未登入"))
+		return
+	}
+
+	uploadID := c.Query("upload_id")
+	if uploadID == "" {
+		uploadID = uuid.New().String()
+	} else if len(uploadID) > 64 {
+		c.JSON(http.StatusBadRequest, response.NewErrorResponse(400, "無效的上傳ID"))
+		return
+	}
+
+	reader, err := c.Request.MultipartReader()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.NewErrorResponse(400, "請使用 multipart/form-data 上傳"))
+		return
+	}
+
+	var title, description string
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			c.JSON(http.StatusBadRequest, response.NewErrorResponse(400, "請上傳影片檔案"))
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusBadRequest, response.NewErrorResponse(400, "讀取上傳內容失敗"))
+			return
+		}
+
+		switch part.FormName() {
+		case "title":
+			title, err = readUploadField(part)
+		case "description":
+			description, err = readUploadField(part)
+		case "video":
+			if title == "" {
+				c.JSON(http.StatusBadRequest, response.NewErrorResponse(400, "標題不能為空（title 欄位需在影片檔案之前送出）"))
+				return
+			}
+			if len([]rune(title)) > 100 || len([]rune(description)) > 500 {
+				c.JSON(http.StatusBadRequest, response.NewErrorResponse(400, "標題或描述過長"))
+				return
+			}
+
+			result, err := h.videoUploadService.StreamUploadVideo(
+				c.Request.Context(),
+				uint(userID),
+				uploadID,
+				title,
+				description,
+				part.FileName(),
+				part,
+				c.Request.ContentLength,
+			)
+			if err != nil {
+				c.JSON(uploadErrorStatus(err), response.NewErrorResponse(uploadErrorStatus(err), err.Error()))
+				return
+			}
+
+			c.JSON(http.StatusCreated, response.NewSuccessResponse(result))
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusBadRequest, response.NewErrorResponse(400, err.Error()))
+			return
+		}
+	}
+}
+
+// readUploadField 讀取表單文字欄位
+func readUploadField(part *multipart.Part) (string, error) {
+	data, err := io.ReadAll(io.LimitReader(part, maxUploadFieldSize+1))
+	if err != nil {
+		return "", errors.New("讀取表單欄位失敗")
+	}
+	if len(data) > maxUploadFieldSize {
+		return "", errors.New("表單欄位過長")
+	}
+	return string(data), nil
+}
+
+// uploadErrorStatus 依上傳錯誤決定 HTTP 狀態碼
+func uploadErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrVideoUploadTooLarge):
+		return http.StatusRequestEntityTooLarge
+	case errors.Is(err, services.ErrUnsupportedVideoFormat):
+		return http.StatusBadRequest
+	case errors.Is(err, services.ErrVideoUploadUnconfigured):
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
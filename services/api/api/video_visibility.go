@@ -0,0 +1,162 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"stream-demo/backend/dto"
+	"stream-demo/backend/dto/response"
+	"stream-demo/backend/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// VideoVisibilityHandler 影片可見度與分享處理器
+type VideoVisibilityHandler struct {
+	videoVisibilityService *services.VideoVisibilityService
+}
+
+// NewVideoVisibilityHandler 創建影片可見度處理器
+func NewVideoVisibilityHandler(videoVisibilityService *services.VideoVisibilityService) *VideoVisibilityHandler {
+	return &VideoVisibilityHandler{videoVisibilityService: videoVisibilityService}
+}
+
+// GetVisibility 獲取影片可見度與分享連結
+func (h *VideoVisibilityHandler) GetVisibility(c *gin.Context) {
+	userID, videoID, ok := parseVisibilityRequest(c)
+	if !ok {
+		return
+	}
+
+	visibility, err := h.videoVisibilityService.GetVisibility(userID, videoID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.NewErrorResponse(400, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, response.NewSuccessResponse(visibility))
+}
+
+// UpdateVisibility 更新影片可見度
+func (h *VideoVisibilityHandler) UpdateVisibility(c *gin.Context) {
+	userID, videoID, ok := parseVisibilityRequest(c)
+	if !ok {
+		return
+	}
+
+	var req dto.VideoVisibilityUpdateDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, response.NewErrorResponse(400, err.Error()))
+		return
+	}
+
+	visibility, err := h.videoVisibilityService.UpdateVisibility(userID, videoID, req.Visibility)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.NewErrorResponse(400, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, response.NewSuccessResponse(visibility))
+}
+
+// RegenerateShareSlug 重新產生分享連結
+func (h *VideoVisibilityHandler) RegenerateShareSlug(c *gin.Context) {
+	userID, videoID, ok := parseVisibilityRequest(c)
+	if !ok {
+		return
+	}
+
+	visibility, err := h.videoVisibilityService.RegenerateShareSlug(userID, videoID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.NewErrorResponse(400, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, response.NewSuccessResponse(visibility))
+}
+
+// GetSharedVideo 以分享連結觀看不公開影片（不需登入）
+func (h *VideoVisibilityHandler) GetSharedVideo(c *gin.Context) {
+	video, err := h.videoVisibilityService.GetSharedVideo(c.Param("slug"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, response.NewErrorResponse(404, "影片不存在"))
+		return
+	}
+
+	c.JSON(http.StatusOK, response.NewSuccessResponse(video))
+}
+
+// ListGrants 列出私人影片的授權用戶
+func (h *VideoVisibilityHandler) ListGrants(c *gin.Context) {
+	userID, videoID, ok := parseVisibilityRequest(c)
+	if !ok {
+		return
+	}
+
+	grants, err := h.videoVisibilityService.ListGrants(userID, videoID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.NewErrorResponse(400, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, response.NewSuccessResponse(grants))
+}
+
+// GrantAccess 授權用戶觀看私人影片
+func (h *VideoVisibilityHandler) GrantAccess(c *gin.Context) {
+	userID, videoID, ok := parseVisibilityRequest(c)
+	if !ok {
+		return
+	}
+
+	var req dto.VideoShareGrantCreateDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, response.NewErrorResponse(400, err.Error()))
+		return
+	}
+
+	grant, err := h.videoVisibilityService.GrantAccess(userID, videoID, req.UserID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.NewErrorResponse(400, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusCreated, response.NewSuccessResponse(grant))
+}
+
+// RevokeAccess 撤銷私人影片授權
+func (h *VideoVisibilityHandler) RevokeAccess(c *gin.Context) {
+	userID, videoID, ok := parseVisibilityRequest(c)
+	if !ok {
+		return
+	}
+
+	granteeID, err := strconv.ParseUint(c.Param("userID"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.NewErrorResponse(400, "無效的用戶ID"))
+		return
+	}
+
+	if err := h.videoVisibilityService.RevokeAccess(userID, videoID, uint(granteeID)); err != nil {
+		c.JSON(http.StatusBadRequest, response.NewErrorResponse(400, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, response.NewSuccessResponse(gin.H{"message": "已撤銷授權"}))
+}
+
+// parseVisibilityRequest 解析登入用戶與影片ID，失敗時已寫入錯誤回應
+func parseVisibilityRequest(c *gin.Context) (uint, uint, bool) {
+	userID, err := getUserIDFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, response.NewErrorResponse(401, "未登入"))
+		return 0, 0, false
+	}
+
+	videoID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.NewErrorResponse(400, "無效的影片ID"))
+		return 0, 0, false
+	}
+
+	return uint(userID), uint(videoID), true
+}
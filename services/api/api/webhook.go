@@ -0,0 +1,229 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"stream-demo/backend/database/models"
+	"stream-demo/backend/dto"
+	"stream-demo/backend/dto/response"
+	"stream-demo/backend/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// WebhookHandler 對外 Webhook 處理器
+type WebhookHandler struct {
+	webhookService *services.WebhookService
+}
+
+// NewWebhookHandler 創建 Webhook 處理器
+func NewWebhookHandler(webhookService *services.WebhookService) *WebhookHandler {
+	return &WebhookHandler{webhookService: webhookService}
+}
+
+// ListEventTypes 列出可訂閱的事件類型
+func (h *WebhookHandler) ListEventTypes(c *gin.Context) {
+	c.JSON(http.StatusOK, response.NewSuccessResponse(models.WebhookEventTypes))
+}
+
+// ListEndpoints 列出自己的 Webhook 端點
+func (h *WebhookHandler) ListEndpoints(c *gin.Context) {
+	userID, err := getUserIDFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, response.NewErrorResponse(401, "未登入"))
+		return
+	}
+
+	endpoints, err := h.webhookService.ListEndpoints(uint(userID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, response.NewErrorResponse(500, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, response.NewSuccessResponse(endpoints))
+}
+
+// CreateEndpoint 註冊 Webhook 端點，回應中的 secret 只會顯示這一次
+func (h *WebhookHandler) CreateEndpoint(c *gin.Context) {
+	userID, err := getUserIDFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, response.NewErrorResponse(401, "未登入"))
+		return
+	}
+
+	var req dto.WebhookEndpointCreateDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, response.NewErrorResponse(400, err.Error()))
+		return
+	}
+
+	endpoint, err := h.webhookService.CreateEndpoint(uint(userID), isAdminFromContext(c), &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.NewErrorResponse(400, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusCreated, response.NewSuccessResponse(endpoint))
+}
+
+// GetEndpoint 獲取 Webhook 端點
+func (h *WebhookHandler) GetEndpoint(c *gin.Context) {
+	userID, endpointID, ok := parseWebhookRequest(c)
+	if !ok {
+		return
+	}
+
+	endpoint, err := h.webhookService.GetEndpoint(userID, isAdminFromContext(c), endpointID)
+	if err != nil {
+		respondWebhookError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response.NewSuccessResponse(endpoint))
+}
+
+// UpdateEndpoint 更新 Webhook 端點
+func (h *WebhookHandler) UpdateEndpoint(c *gin.Context) {
+	userID, endpointID, ok := parseWebhookRequest(c)
+	if !ok {
+		return
+	}
+
+	var req dto.WebhookEndpointUpdateDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, response.NewErrorResponse(400, err.Error()))
+		return
+	}
+
+	endpoint, err := h.webhookService.UpdateEndpoint(userID, isAdminFromContext(c), endpointID, &req)
+	if err != nil {
+		respondWebhookError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response.NewSuccessResponse(endpoint))
+}
+
+// DeleteEndpoint 刪除 Webhook 端點
+func (h *WebhookHandler) DeleteEndpoint(c *gin.Context) {
+	userID, endpointID, ok := parseWebhookRequest(c)
+	if !ok {
+		return
+	}
+
+	if err := h.webhookService.DeleteEndpoint(userID, isAdminFromContext(c), endpointID); err != nil {
+		respondWebhookError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response.NewSuccessResponse(gin.H{"message": "Webhook 端點已刪除"}))
+}
+
+// RotateSecret 更換簽章金鑰，回應中的 secret 只會顯示這一次
+func (h *WebhookHandler) RotateSecret(c *gin.Context) {
+	userID, endpointID, ok := parseWebhookRequest(c)
+	if !ok {
+		return
+	}
+
+	endpoint, err := h.webhookService.RotateSecret(userID, isAdminFromContext(c), endpointID)
+	if err != nil {
+		respondWebhookError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response.NewSuccessResponse(endpoint))
+}
+
+// ListDeliveries 列出端點的投遞記錄
+func (h *WebhookHandler) ListDeliveries(c *gin.Context) {
+	userID, endpointID, ok := parseWebhookRequest(c)
+	if !ok {
+		return
+	}
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	deliveries, total, err := h.webhookService.ListDeliveries(userID, isAdminFromContext(c), endpointID, offset, limit)
+	if err != nil {
+		respondWebhookError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response.NewSuccessResponse(response.NewListResponse(total, deliveries)))
+}
+
+// GetDelivery 獲取投遞記錄與投遞內容
+func (h *WebhookHandler) GetDelivery(c *gin.Context) {
+	userID, endpointID, deliveryID, ok := parseWebhookDeliveryRequest(c)
+	if !ok {
+		return
+	}
+
+	delivery, err := h.webhookService.GetDelivery(userID, isAdminFromContext(c), endpointID, deliveryID)
+	if err != nil {
+		respondWebhookError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response.NewSuccessResponse(delivery))
+}
+
+// Redeliver 重新投遞，以相同事件ID建立新的投遞記錄
+func (h *WebhookHandler) Redeliver(c *gin.Context) {
+	userID, endpointID, deliveryID, ok := parseWebhookDeliveryRequest(c)
+	if !ok {
+		return
+	}
+
+	delivery, err := h.webhookService.Redeliver(userID, isAdminFromContext(c), endpointID, deliveryID)
+	if err != nil {
+		respondWebhookError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, response.NewSuccessResponse(delivery))
+}
+
+// parseWebhookRequest 解析登入用戶與端點ID，失敗時已寫入錯誤回應
+func parseWebhookRequest(c *gin.Context) (uint, uint, bool) {
+	userID, err := getUserIDFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, response.NewErrorResponse(401, "未登入"))
+		return 0, 0, false
+	}
+
+	endpointID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.NewErrorResponse(400, "無效的 Webhook 端點ID"))
+		return 0, 0, false
+	}
+
+	return uint(userID), uint(endpointID), true
+}
+
+// parseWebhookDeliveryRequest 解析登入用戶、端點ID與投遞ID，失敗時已寫入錯誤回應
+func parseWebhookDeliveryRequest(c *gin.Context) (uint, uint, uint, bool) {
+	userID, endpointID, ok := parseWebhookRequest(c)
+	if !ok {
+		return 0, 0, 0, false
+	}
+
+	deliveryID, err := strconv.ParseUint(c.Param("deliveryID"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.NewErrorResponse(400, "無效的投遞ID"))
+		return 0, 0, 0, false
+	}
+
+	return userID, endpointID, uint(deliveryID), true
+}
+
+// respondWebhookError 端點或投遞不存在時回應 404，其餘回應 400
+func respondWebhookError(c *gin.Context, err error) {
+	if errors.Is(err, services.ErrWebhookNotFound) || errors.Is(err, services.ErrWebhookDeliveryNotFound) {
+		c.JSON(http.StatusNotFound, response.NewErrorResponse(404, err.Error()))
+		return
+	}
+	c.JSON(http.StatusBadRequest, response.NewErrorResponse(400, err.Error()))
+}
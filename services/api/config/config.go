@@ -33,6 +33,66 @@ type Configurations struct {
 	Video        VideoConfiguration        `mapstructure:"video"`
 	// 直播配置
 	Live LiveConfiguration `mapstructure:"live"`
+	// 追蹤動態配置
+	Feed FeedConfiguration `mapstructure:"feed"`
+	// 站內通知配置
+	Notification NotificationConfiguration `mapstructure:"notification"`
+	// 對外 Webhook 配置
+	Webhook WebhookConfiguration `mapstructure:"webhook"`
+	// 私訊配置
+	Chat ChatConfiguration `mapstructure:"chat"`
+	Call CallConfiguration `mapstructure:"call"`
+}
+
+// FeedConfiguration 追蹤動態配置，新影片與開播寫入時擴散到追隨者的 Redis 有序集合
+type FeedConfiguration struct {
+	PollInterval int `mapstructure:"poll_interval"` // 檢查新完成影片的間隔(秒)
+	MaxItems     int `mapstructure:"max_items"`     // 每位用戶動態保留的項目數
+	FanOutBatch  int `mapstructure:"fan_out_batch"` // 擴散時每批處理的追隨者數
+	TTLDays      int `mapstructure:"ttl_days"`      // 動態閒置過期天數，過期後讀取時由資料庫重建
+}
+
+// NotificationConfiguration 站內通知配置
+type NotificationConfiguration struct {
+	PollInterval  int `mapstructure:"poll_interval"`  // 檢查轉碼結果的間隔(秒)
+	RetentionDays int `mapstructure:"retention_days"` // 通知保留天數，超過後定期清除
+}
+
+// WebhookConfiguration 對外 Webhook 配置，投遞排入 Redis 佇列並以指數退避重試
+type WebhookConfiguration struct {
+	PollInterval         int  `mapstructure:"poll_interval"`          // 檢查到期投遞的間隔(秒)
+	Timeout              int  `mapstructure:"timeout"`                // 單次投遞逾時(秒)
+	MaxAttempts          int  `mapstructure:"max_attempts"`           // 最多嘗試次數，用盡後標記為失敗
+	BackoffBase          int  `mapstructure:"backoff_base"`           // 第一次重試的等待時間(秒)，之後每次加倍
+	BackoffMax           int  `mapstructure:"backoff_max"`            // 重試等待時間上限(秒)
+	Concurrency          int  `mapstructure:"concurrency"`            // 同時投遞數
+	MaxEndpoints         int  `mapstructure:"max_endpoints"`          // 每位用戶可註冊的端點數
+	RetentionDays        int  `mapstructure:"retention_days"`         // 投遞記錄保留天數
+	AllowPrivateNetworks bool `mapstructure:"allow_private_networks"` // 允許投遞到內網與本機位址（僅供開發環境）
+}
+
+// ChatConfiguration 私訊配置
+type ChatConfiguration struct {
+	MaxMessageLength  int `mapstructure:"max_message_length"`  // 訊息字數上限
+	MaxGroupMembers   int `mapstructure:"max_group_members"`   // 群組人數上限
+	InviteExpiryHours int `mapstructure:"invite_expiry_hours"` // 群組邀請連結預設有效時數
+
+	AttachmentBucket     string `mapstructure:"attachment_bucket"`      // 附件所在的私有桶，只透過預簽名URL存取
+	MaxImageSizeMB       int    `mapstructure:"max_image_size_mb"`      // 圖片附件大小上限(MB)
+	MaxVideoSizeMB       int    `mapstructure:"max_video_size_mb"`      // 影片附件大小上限(MB)
+	MaxFileSizeMB        int    `mapstructure:"max_file_size_mb"`       // 其他檔案附件大小上限(MB)
+	ThumbnailSize        int    `mapstructure:"thumbnail_size"`         // 圖片縮圖最長邊(像素)
+	AttachmentURLMinutes int    `mapstructure:"attachment_url_minutes"` // 附件下載URL有效分鐘數
+}
+
+// CallConfiguration 音視訊通話配置，TURN 帳密依 TURN REST API 以共享密鑰簽發
+type CallConfiguration struct {
+	STUNURLs        []string `mapstructure:"stun_urls"`
+	TURNURLs        []string `mapstructure:"turn_urls"`
+	TURNSecret      string   `mapstructure:"turn_secret"`      // 與 Coturn 的 static-auth-secret 相同
+	CredentialTTL   int      `mapstructure:"credential_ttl"`   // TURN 帳密有效秒數
+	RingTimeout     int      `mapstructure:"ring_timeout"`     // 響鈴逾時秒數，逾時未接聽視為未接
+	MaxParticipants int      `mapstructure:"max_participants"` // 通話人數上限（含發起者），多人通話為點對點網狀連線
 }
 
 type SwaggerConfigurations struct {
@@ -166,18 +226,102 @@ type FFmpegConfiguration struct {
 
 // VideoConfiguration 影片處理配置
 type VideoConfiguration struct {
-	MaxFileSize      int64                   `mapstructure:"max_file_size"`
-	MinFileSize      int64                   `mapstructure:"min_file_size"` // 最小轉檔檔案大小
-	AllowedFormats   []string                `mapstructure:"allowed_formats"`
-	TranscodePresets []TranscodePresetConfig `mapstructure:"transcode_presets"`
+	MaxFileSize      int64                     `mapstructure:"max_file_size"`
+	MinFileSize      int64                     `mapstructure:"min_file_size"` // 最小轉檔檔案大小
+	AllowedFormats   []string                  `mapstructure:"allowed_formats"`
+	TranscodePresets []TranscodePresetConfig   `mapstructure:"transcode_presets"`
+	Packaging        string                    `mapstructure:"packaging"` // ts: MPEG-TS HLS, cmaf: fMP4 HLS + DASH
+	Clip             ClipConfiguration         `mapstructure:"clip"`
+	Encryption       EncryptionConfiguration   `mapstructure:"encryption"`
+	StreamUpload     StreamUploadConfiguration `mapstructure:"stream_upload"`
+	Trash            TrashConfiguration        `mapstructure:"trash"`
+	Reactions        ReactionConfiguration     `mapstructure:"reactions"`
+	Comments         CommentConfiguration      `mapstructure:"comments"`
+}
+
+// CommentConfiguration 影片留言配置
+type CommentConfiguration struct {
+	MaxLength        int      `mapstructure:"max_length"`         // 留言最大字數
+	BannedWords      []string `mapstructure:"banned_words"`       // 敏感詞
+	BannedWordAction string   `mapstructure:"banned_word_action"` // mask: 以 * 遮蔽, reject: 拒絕留言
+}
+
+// 敏感詞處理方式
+const (
+	BannedWordMask   = "mask"
+	BannedWordReject = "reject"
+)
+
+// ReactionConfiguration 影片反應（喜歡、不喜歡、表情）配置
+type ReactionConfiguration struct {
+	SyncInterval int      `mapstructure:"sync_interval"` // Redis 計數與資料庫對帳的間隔(秒)
+	Emojis       []string `mapstructure:"emojis"`        // 允許的表情反應
+}
+
+// TrashConfiguration 影片垃圾桶與儲存垃圾回收配置
+type TrashConfiguration struct {
+	RetentionDays      int  `mapstructure:"retention_days"`       // 刪除後可還原的天數，期滿後清除儲存檔案與記錄
+	GCInterval         int  `mapstructure:"gc_interval"`          // 垃圾回收執行間隔(秒)
+	OrphanScanInterval int  `mapstructure:"orphan_scan_interval"` // 孤兒檔案掃描間隔(秒)
+	DeleteOrphans      bool `mapstructure:"delete_orphans"`       // 是否刪除掃描到的孤兒檔案，否則只記錄
+}
+
+// StreamUploadConfiguration 伺服器端串流上傳配置（無法使用預簽名 URL 的客戶端）
+type StreamUploadConfiguration struct {
+	PartSize    int64 `mapstructure:"part_size"`   // multipart 分段大小(位元組)，S3 最小 5MB
+	Concurrency int   `mapstructure:"concurrency"` // 同時上傳的分段數，單一上傳的記憶體用量約為 part_size * concurrency
+}
+
+// EncryptionConfiguration HLS AES-128 內容加密配置
+type EncryptionConfiguration struct {
+	Enabled     bool   `mapstructure:"enabled"`      // 新上傳的影片是否加密
+	KeySecret   string `mapstructure:"key_secret"`   // 內容金鑰加密保存用，需與 converter 的 HLS_KEY_SECRET 一致
+	TokenSecret string `mapstructure:"token_secret"` // 播放憑證簽章用
+	TokenTTL    int    `mapstructure:"token_ttl"`    // 播放憑證有效時間(秒)
+}
+
+// 影片封裝格式
+const (
+	PackagingTS   = "ts"   // MPEG-TS 分段，僅輸出 HLS
+	PackagingCMAF = "cmaf" // CMAF fMP4 分段，同一組分段同時輸出 HLS 與 DASH
+)
+
+// ClipConfiguration 精華片段剪輯配置
+type ClipConfiguration struct {
+	MinDuration    int    `mapstructure:"min_duration"`      // 最短片段(秒)
+	MaxDuration    int    `mapstructure:"max_duration"`      // 最長片段(秒)
+	LiveDVRWindow  int    `mapstructure:"live_dvr_window"`   // 直播可回溯的 DVR 視窗上限(秒)，實際以播放列表保留的分段為準
+	LiveHLSBaseURL string `mapstructure:"live_hls_base_url"` // API 與轉碼服務讀取直播 HLS 的地址
 }
 
 // TranscodePresetConfig 轉碼預設配置
+// 作為畫質階梯的上限：轉碼服務依內容複雜度決定各畫質實際位元率，不超過此設定，
+// 並捨棄無法帶來畫質提升的畫質
 type TranscodePresetConfig struct {
 	Name    string `mapstructure:"name"`
 	Width   int    `mapstructure:"width"`
 	Height  int    `mapstructure:"height"`
-	Bitrate int    `mapstructure:"bitrate"`
+	Bitrate int    `mapstructure:"bitrate"` // 位元率上限(kbps)
+}
+
+// TranscodeLadder 將轉碼預設序列化為轉碼服務使用的畫質階梯，格式為 name:width:height:bitratek，以逗號分隔
+func (v VideoConfiguration) TranscodeLadder() string {
+	rungs := make([]string, 0, len(v.TranscodePresets))
+	for _, preset := range v.TranscodePresets {
+		if preset.Name == "" || preset.Width <= 0 || preset.Height <= 0 || preset.Bitrate <= 0 {
+			continue
+		}
+		rungs = append(rungs, fmt.Sprintf("%s:%d:%d:%dk", preset.Name, preset.Width, preset.Height, preset.Bitrate))
+	}
+	return strings.Join(rungs, ",")
+}
+
+// PackagingFormat 回傳正規化後的封裝格式，未設定或無法識別時使用 MPEG-TS
+func (v VideoConfiguration) PackagingFormat() string {
+	if strings.EqualFold(strings.TrimSpace(v.Packaging), PackagingCMAF) {
+		return PackagingCMAF
+	}
+	return PackagingTS
 }
 
 // CacheConfiguration 緩存配置（支援PostgreSQL和Redis）
@@ -208,6 +352,10 @@ type LiveConfiguration struct {
 	Local   LocalLiveConfiguration  `mapstructure:"local"`
 	Cloud   CloudLiveConfiguration  `mapstructure:"cloud"`
 	Hybrid  HybridLiveConfiguration `mapstructure:"hybrid"`
+	// 多平台轉推配置
+	Restream RestreamConfiguration `mapstructure:"restream"`
+	// 預約直播配置
+	Schedule LiveScheduleConfiguration `mapstructure:"schedule"`
 }
 
 // LocalLiveConfiguration 本地直播配置
@@ -238,6 +386,24 @@ type HybridLiveConfiguration struct {
 	CloudProvider   string `mapstructure:"cloud_provider"`
 }
 
+// RestreamConfiguration 多平台轉推配置
+type RestreamConfiguration struct {
+	Enabled       bool   `mapstructure:"enabled"`
+	FFmpegPath    string `mapstructure:"ffmpeg_path"`
+	EncryptionKey string `mapstructure:"encryption_key"` // 推流金鑰加密用
+	MaxRetries    int    `mapstructure:"max_retries"`    // 單一目標最大重試次數
+	RetryInterval int    `mapstructure:"retry_interval"` // 重試間隔(秒)
+	MaxTargets    int    `mapstructure:"max_targets"`    // 每個直播間最多轉推目標數
+}
+
+// LiveScheduleConfiguration 預約直播配置
+type LiveScheduleConfiguration struct {
+	OpenBeforeMinutes  int `mapstructure:"open_before_minutes"`  // 開播前幾分鐘開放進入等待
+	GracePeriodMinutes int `mapstructure:"grace_period_minutes"` // 超過預定時間多久未開播即取消
+	CheckInterval      int `mapstructure:"check_interval"`       // 排程檢查間隔(秒)
+	MaxPerUser         int `mapstructure:"max_per_user"`         // 每位用戶最多同時擁有的預約直播間數
+}
+
 type Config struct {
 	*Configurations
 	DB              map[string]*gorm.DB
@@ -422,7 +588,66 @@ func bindEnvironmentVariables() {
 	// 影片配置
 	viper.BindEnv("video.max_file_size", "STREAM_DEMO_VIDEO_MAX_FILE_SIZE")
 	viper.BindEnv("video.min_file_size", "STREAM_DEMO_VIDEO_MIN_FILE_SIZE")
+	viper.BindEnv("video.clip.min_duration", "STREAM_DEMO_VIDEO_CLIP_MIN_DURATION")
+	viper.BindEnv("video.clip.max_duration", "STREAM_DEMO_VIDEO_CLIP_MAX_DURATION")
+	viper.BindEnv("video.clip.live_dvr_window", "STREAM_DEMO_VIDEO_CLIP_LIVE_DVR_WINDOW")
+	viper.BindEnv("video.clip.live_hls_base_url", "STREAM_DEMO_VIDEO_CLIP_LIVE_HLS_BASE_URL")
 	viper.BindEnv("video.allowed_formats", "STREAM_DEMO_VIDEO_ALLOWED_FORMATS")
+	viper.BindEnv("video.packaging", "STREAM_DEMO_VIDEO_PACKAGING")
+	viper.BindEnv("video.encryption.enabled", "STREAM_DEMO_VIDEO_ENCRYPTION_ENABLED")
+	viper.BindEnv("video.encryption.key_secret", "STREAM_DEMO_VIDEO_ENCRYPTION_KEY_SECRET")
+	viper.BindEnv("video.encryption.token_secret", "STREAM_DEMO_VIDEO_ENCRYPTION_TOKEN_SECRET")
+	viper.BindEnv("video.encryption.token_ttl", "STREAM_DEMO_VIDEO_ENCRYPTION_TOKEN_TTL")
+	viper.BindEnv("video.stream_upload.part_size", "STREAM_DEMO_VIDEO_STREAM_UPLOAD_PART_SIZE")
+	viper.BindEnv("video.stream_upload.concurrency", "STREAM_DEMO_VIDEO_STREAM_UPLOAD_CONCURRENCY")
+	viper.BindEnv("video.trash.retention_days", "STREAM_DEMO_VIDEO_TRASH_RETENTION_DAYS")
+	viper.BindEnv("video.trash.gc_interval", "STREAM_DEMO_VIDEO_TRASH_GC_INTERVAL")
+	viper.BindEnv("video.trash.orphan_scan_interval", "STREAM_DEMO_VIDEO_TRASH_ORPHAN_SCAN_INTERVAL")
+	viper.BindEnv("video.trash.delete_orphans", "STREAM_DEMO_VIDEO_TRASH_DELETE_ORPHANS")
+	viper.BindEnv("video.reactions.sync_interval", "STREAM_DEMO_VIDEO_REACTIONS_SYNC_INTERVAL")
+	viper.BindEnv("video.comments.max_length", "STREAM_DEMO_VIDEO_COMMENTS_MAX_LENGTH")
+	viper.BindEnv("video.comments.banned_words", "STREAM_DEMO_VIDEO_COMMENTS_BANNED_WORDS")
+	viper.BindEnv("video.comments.banned_word_action", "STREAM_DEMO_VIDEO_COMMENTS_BANNED_WORD_ACTION")
+
+	// 追蹤動態配置
+	viper.BindEnv("feed.poll_interval", "STREAM_DEMO_FEED_POLL_INTERVAL")
+	viper.BindEnv("feed.max_items", "STREAM_DEMO_FEED_MAX_ITEMS")
+	viper.BindEnv("feed.fan_out_batch", "STREAM_DEMO_FEED_FAN_OUT_BATCH")
+	viper.BindEnv("feed.ttl_days", "STREAM_DEMO_FEED_TTL_DAYS")
+
+	// 站內通知配置
+	viper.BindEnv("notification.poll_interval", "STREAM_DEMO_NOTIFICATION_POLL_INTERVAL")
+	viper.BindEnv("notification.retention_days", "STREAM_DEMO_NOTIFICATION_RETENTION_DAYS")
+
+	// 對外 Webhook 配置
+	viper.BindEnv("webhook.poll_interval", "STREAM_DEMO_WEBHOOK_POLL_INTERVAL")
+	viper.BindEnv("webhook.timeout", "STREAM_DEMO_WEBHOOK_TIMEOUT")
+	viper.BindEnv("webhook.max_attempts", "STREAM_DEMO_WEBHOOK_MAX_ATTEMPTS")
+	viper.BindEnv("webhook.backoff_base", "STREAM_DEMO_WEBHOOK_BACKOFF_BASE")
+	viper.BindEnv("webhook.backoff_max", "STREAM_DEMO_WEBHOOK_BACKOFF_MAX")
+	viper.BindEnv("webhook.concurrency", "STREAM_DEMO_WEBHOOK_CONCURRENCY")
+	viper.BindEnv("webhook.max_endpoints", "STREAM_DEMO_WEBHOOK_MAX_ENDPOINTS")
+	viper.BindEnv("webhook.retention_days", "STREAM_DEMO_WEBHOOK_RETENTION_DAYS")
+	viper.BindEnv("webhook.allow_private_networks", "STREAM_DEMO_WEBHOOK_ALLOW_PRIVATE_NETWORKS")
+
+	// 私訊配置
+	viper.BindEnv("chat.max_message_length", "STREAM_DEMO_CHAT_MAX_MESSAGE_LENGTH")
+	viper.BindEnv("chat.max_group_members", "STREAM_DEMO_CHAT_MAX_GROUP_MEMBERS")
+	viper.BindEnv("chat.invite_expiry_hours", "STREAM_DEMO_CHAT_INVITE_EXPIRY_HOURS")
+	viper.BindEnv("chat.attachment_bucket", "STREAM_DEMO_CHAT_ATTACHMENT_BUCKET")
+	viper.BindEnv("chat.max_image_size_mb", "STREAM_DEMO_CHAT_MAX_IMAGE_SIZE_MB")
+	viper.BindEnv("chat.max_video_size_mb", "STREAM_DEMO_CHAT_MAX_VIDEO_SIZE_MB")
+	viper.BindEnv("chat.max_file_size_mb", "STREAM_DEMO_CHAT_MAX_FILE_SIZE_MB")
+	viper.BindEnv("chat.thumbnail_size", "STREAM_DEMO_CHAT_THUMBNAIL_SIZE")
+	viper.BindEnv("chat.attachment_url_minutes", "STREAM_DEMO_CHAT_ATTACHMENT_URL_MINUTES")
+
+	// 通話配置
+	viper.BindEnv("call.stun_urls", "STREAM_DEMO_CALL_STUN_URLS")
+	viper.BindEnv("call.turn_urls", "STREAM_DEMO_CALL_TURN_URLS")
+	viper.BindEnv("call.turn_secret", "STREAM_DEMO_CALL_TURN_SECRET")
+	viper.BindEnv("call.credential_ttl", "STREAM_DEMO_CALL_CREDENTIAL_TTL")
+	viper.BindEnv("call.ring_timeout", "STREAM_DEMO_CALL_RING_TIMEOUT")
+	viper.BindEnv("call.max_participants", "STREAM_DEMO_CALL_MAX_PARTICIPANTS")
 
 	// 直播配置
 	viper.BindEnv("live.enabled", "STREAM_DEMO_LIVE_ENABLED")
@@ -449,6 +674,18 @@ func bindEnvironmentVariables() {
 	viper.BindEnv("live.hybrid.cloud_enabled", "STREAM_DEMO_LIVE_HYBRID_CLOUD_ENABLED")
 	viper.BindEnv("live.hybrid.fallback_to_local", "STREAM_DEMO_LIVE_HYBRID_FALLBACK_TO_LOCAL")
 	viper.BindEnv("live.hybrid.cloud_provider", "STREAM_DEMO_LIVE_HYBRID_CLOUD_PROVIDER")
+
+	// 多平台轉推配置
+	viper.BindEnv("live.restream.enabled", "STREAM_DEMO_LIVE_RESTREAM_ENABLED")
+	viper.BindEnv("live.restream.ffmpeg_path", "STREAM_DEMO_LIVE_RESTREAM_FFMPEG_PATH")
+	viper.BindEnv("live.restream.encryption_key", "STREAM_DEMO_LIVE_RESTREAM_ENCRYPTION_KEY")
+	viper.BindEnv("live.restream.max_retries", "STREAM_DEMO_LIVE_RESTREAM_MAX_RETRIES")
+	viper.BindEnv("live.restream.retry_interval", "STREAM_DEMO_LIVE_RESTREAM_RETRY_INTERVAL")
+	viper.BindEnv("live.restream.max_targets", "STREAM_DEMO_LIVE_RESTREAM_MAX_TARGETS")
+	viper.BindEnv("live.schedule.open_before_minutes", "STREAM_DEMO_LIVE_SCHEDULE_OPEN_BEFORE_MINUTES")
+	viper.BindEnv("live.schedule.grace_period_minutes", "STREAM_DEMO_LIVE_SCHEDULE_GRACE_PERIOD_MINUTES")
+	viper.BindEnv("live.schedule.check_interval", "STREAM_DEMO_LIVE_SCHEDULE_CHECK_INTERVAL")
+	viper.BindEnv("live.schedule.max_per_user", "STREAM_DEMO_LIVE_SCHEDULE_MAX_PER_USER")
 }
 
 // setDefaultValues 設定預設配置值
@@ -609,6 +846,151 @@ func setDefaultValues(config *Configurations) {
 	if len(config.Video.AllowedFormats) == 0 {
 		config.Video.AllowedFormats = []string{"mp4", "avi", "mov", "mkv", "webm"}
 	}
+	if len(config.Video.TranscodePresets) == 0 {
+		config.Video.TranscodePresets = []TranscodePresetConfig{
+			{Name: "720p", Width: 1280, Height: 720, Bitrate: 2500},
+			{Name: "480p", Width: 854, Height: 480, Bitrate: 1200},
+			{Name: "360p", Width: 640, Height: 360, Bitrate: 800},
+		}
+	}
+	if config.Video.Packaging == "" {
+		config.Video.Packaging = PackagingTS
+	}
+	if config.Video.Encryption.KeySecret == "" {
+		config.Video.Encryption.KeySecret = "local_video_key_secret"
+	}
+	if config.Video.Encryption.TokenSecret == "" {
+		config.Video.Encryption.TokenSecret = "local_playback_token_secret"
+	}
+	if config.Video.Encryption.TokenTTL == 0 {
+		config.Video.Encryption.TokenTTL = 300
+	}
+	if config.Video.StreamUpload.PartSize == 0 {
+		config.Video.StreamUpload.PartSize = 8388608 // 8MB
+	}
+	if config.Video.StreamUpload.Concurrency == 0 {
+		config.Video.StreamUpload.Concurrency = 2
+	}
+	if config.Video.Trash.RetentionDays == 0 {
+		config.Video.Trash.RetentionDays = 30
+	}
+	if config.Video.Trash.GCInterval == 0 {
+		config.Video.Trash.GCInterval = 3600
+	}
+	if config.Video.Trash.OrphanScanInterval == 0 {
+		config.Video.Trash.OrphanScanInterval = 86400
+	}
+	if config.Video.Reactions.SyncInterval == 0 {
+		config.Video.Reactions.SyncInterval = 60
+	}
+	if len(config.Video.Reactions.Emojis) == 0 {
+		config.Video.Reactions.Emojis = []string{"❤️", "😂", "😮", "😢", "😡", "👏"}
+	}
+	if config.Video.Comments.MaxLength == 0 {
+		config.Video.Comments.MaxLength = 2000
+	}
+	if config.Video.Comments.BannedWordAction == "" {
+		config.Video.Comments.BannedWordAction = BannedWordMask
+	}
+	if config.Feed.PollInterval == 0 {
+		config.Feed.PollInterval = 30
+	}
+	if config.Feed.MaxItems == 0 {
+		config.Feed.MaxItems = 500
+	}
+	if config.Feed.FanOutBatch == 0 {
+		config.Feed.FanOutBatch = 1000
+	}
+	if config.Feed.TTLDays == 0 {
+		config.Feed.TTLDays = 30
+	}
+	if config.Notification.PollInterval == 0 {
+		config.Notification.PollInterval = 15
+	}
+	if config.Notification.RetentionDays == 0 {
+		config.Notification.RetentionDays = 90
+	}
+	if config.Webhook.PollInterval == 0 {
+		config.Webhook.PollInterval = 2
+	}
+	if config.Webhook.Timeout == 0 {
+		config.Webhook.Timeout = 10
+	}
+	if config.Webhook.MaxAttempts == 0 {
+		config.Webhook.MaxAttempts = 8
+	}
+	if config.Webhook.BackoffBase == 0 {
+		config.Webhook.BackoffBase = 30
+	}
+	if config.Webhook.BackoffMax == 0 {
+		config.Webhook.BackoffMax = 3600
+	}
+	if config.Webhook.Concurrency == 0 {
+		config.Webhook.Concurrency = 8
+	}
+	if config.Webhook.MaxEndpoints == 0 {
+		config.Webhook.MaxEndpoints = 10
+	}
+	if config.Webhook.RetentionDays == 0 {
+		config.Webhook.RetentionDays = 30
+	}
+	if config.Chat.MaxMessageLength == 0 {
+		config.Chat.MaxMessageLength = 4000
+	}
+	if config.Chat.MaxGroupMembers == 0 {
+		config.Chat.MaxGroupMembers = 200
+	}
+	if config.Chat.InviteExpiryHours == 0 {
+		config.Chat.InviteExpiryHours = 168
+	}
+	if config.Chat.AttachmentBucket == "" {
+		config.Chat.AttachmentBucket = "stream-demo-chat"
+	}
+	if config.Chat.MaxImageSizeMB == 0 {
+		config.Chat.MaxImageSizeMB = 10
+	}
+	if config.Chat.MaxVideoSizeMB == 0 {
+		config.Chat.MaxVideoSizeMB = 200
+	}
+	if config.Chat.MaxFileSizeMB == 0 {
+		config.Chat.MaxFileSizeMB = 50
+	}
+	if config.Chat.ThumbnailSize == 0 {
+		config.Chat.ThumbnailSize = 320
+	}
+	if config.Chat.AttachmentURLMinutes == 0 {
+		config.Chat.AttachmentURLMinutes = 60
+	}
+	if len(config.Call.STUNURLs) == 0 {
+		config.Call.STUNURLs = []string{"stun:localhost:3478"}
+	}
+	if len(config.Call.TURNURLs) == 0 {
+		config.Call.TURNURLs = []string{"turn:localhost:3478?transport=udp", "turn:localhost:3478?transport=tcp"}
+	}
+	if config.Call.TURNSecret == "" {
+		config.Call.TURNSecret = "stream-demo-turn-secret"
+	}
+	if config.Call.CredentialTTL == 0 {
+		config.Call.CredentialTTL = 12 * 3600
+	}
+	if config.Call.RingTimeout == 0 {
+		config.Call.RingTimeout = 45
+	}
+	if config.Call.MaxParticipants == 0 {
+		config.Call.MaxParticipants = 6
+	}
+	if config.Video.Clip.MinDuration == 0 {
+		config.Video.Clip.MinDuration = 1
+	}
+	if config.Video.Clip.MaxDuration == 0 {
+		config.Video.Clip.MaxDuration = 180
+	}
+	if config.Video.Clip.LiveDVRWindow == 0 {
+		config.Video.Clip.LiveDVRWindow = 120
+	}
+	if config.Video.Clip.LiveHLSBaseURL == "" {
+		config.Video.Clip.LiveHLSBaseURL = "http://live-cdn/live/hls"
+	}
 
 	// 直播預設值
 	if !config.Live.Enabled {
@@ -635,23 +1017,55 @@ func setDefaultValues(config *Configurations) {
 	if config.Live.Local.HTTPPort == 0 {
 		config.Live.Local.HTTPPort = 8081
 	}
+
+	// 轉推預設值（布林值以 viper 預設，明確設定為 false 時不被覆蓋）
+	viper.SetDefault("live.restream.enabled", true)
+	if config.Live.Restream.FFmpegPath == "" {
+		config.Live.Restream.FFmpegPath = "ffmpeg"
+	}
+	if config.Live.Restream.EncryptionKey == "" {
+		config.Live.Restream.EncryptionKey = "local_restream_secret"
+	}
+	if config.Live.Restream.MaxRetries == 0 {
+		config.Live.Restream.MaxRetries = 5
+	}
+	if config.Live.Restream.RetryInterval == 0 {
+		config.Live.Restream.RetryInterval = 5
+	}
+	if config.Live.Restream.MaxTargets == 0 {
+		config.Live.Restream.MaxTargets = 5
+	}
+
+	// 預約直播預設值
+	if config.Live.Schedule.OpenBeforeMinutes == 0 {
+		config.Live.Schedule.OpenBeforeMinutes = 15
+	}
+	if config.Live.Schedule.GracePeriodMinutes == 0 {
+		config.Live.Schedule.GracePeriodMinutes = 30
+	}
+	if config.Live.Schedule.CheckInterval == 0 {
+		config.Live.Schedule.CheckInterval = 30
+	}
+	if config.Live.Schedule.MaxPerUser == 0 {
+		config.Live.Schedule.MaxPerUser = 5
+	}
 }
 
 // overrideWithEnvironmentVariables 用環境變數覆蓋配置
 func overrideWithEnvironmentVariables(config *Configurations) {
 	utils.LogInfo("進入環境變數覆蓋函數")
-	
+
 	// 列出所有 STREAM_DEMO_ 開頭的環境變數
 	for _, env := range os.Environ() {
 		if strings.HasPrefix(env, "STREAM_DEMO_") {
 			utils.LogInfo("發現環境變數: %s", env)
 		}
 	}
-	
+
 	// 資料庫配置覆蓋
 	if postgresqlConfig, exists := config.Databases["postgresql"]; exists {
 		utils.LogInfo("找到 PostgreSQL 配置，當前主機: %s", postgresqlConfig.Master.Host)
-		
+
 		// 直接從環境變數讀取，不使用 viper 前綴
 		if host := os.Getenv("STREAM_DEMO_DB_HOST"); host != "" {
 			utils.LogInfo("覆蓋資料庫主機: %s", host)
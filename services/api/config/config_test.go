@@ -3,7 +3,9 @@ package config
 import (
 	"testing"
 
+	"github.com/spf13/viper"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestDetermineDatabase(t *testing.T) {
@@ -204,3 +206,38 @@ func TestNewConfigWithEnvironmentVariables(t *testing.T) {
 	// 由於 NewConfig 會嘗試連接資料庫，我們跳過這個測試
 	t.Skip("Skipping test that requires database connection")
 }
+
+func TestVideoConfiguration_TranscodeLadder(t *testing.T) {
+	video := VideoConfiguration{
+		TranscodePresets: []TranscodePresetConfig{
+			{Name: "720p", Width: 1280, Height: 720, Bitrate: 2500},
+			{Name: "invalid", Width: 0, Height: 0, Bitrate: 0},
+			{Name: "360p", Width: 640, Height: 360, Bitrate: 800},
+		},
+	}
+
+	assert.Equal(t, "720p:1280:720:2500k,360p:640:360:800k", video.TranscodeLadder())
+	assert.Equal(t, "", VideoConfiguration{}.TranscodeLadder())
+}
+
+func TestVideoConfiguration_PackagingFormat(t *testing.T) {
+	assert.Equal(t, PackagingTS, VideoConfiguration{}.PackagingFormat())
+	assert.Equal(t, PackagingTS, VideoConfiguration{Packaging: "unknown"}.PackagingFormat())
+	assert.Equal(t, PackagingCMAF, VideoConfiguration{Packaging: " CMAF "}.PackagingFormat())
+}
+
+func TestSetDefaultValues_RestreamEnabled(t *testing.T) {
+	bindEnvironmentVariables()
+
+	var config Configurations
+	setDefaultValues(&config)
+	require.NoError(t, viper.Unmarshal(&config))
+	assert.True(t, config.Live.Restream.Enabled)
+
+	// 明確關閉轉推時不被預設值覆蓋
+	t.Setenv("STREAM_DEMO_LIVE_RESTREAM_ENABLED", "false")
+	config = Configurations{}
+	setDefaultValues(&config)
+	require.NoError(t, viper.Unmarshal(&config))
+	assert.False(t, config.Live.Restream.Enabled)
+}
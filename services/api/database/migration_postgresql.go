@@ -21,6 +21,28 @@ func MigratePostgreSQL(conf *config.Config) error {
 		&models.User{},
 		&models.Video{},
 		&models.VideoQuality{}, // 新增 VideoQuality 模型
+		&models.VideoAudioTrack{},
+		&models.VideoClip{},
+		&models.VideoCaption{},
+		&models.VideoEncryptionKey{},
+		&models.VideoShareGrant{},
+		&models.VideoReaction{},
+		&models.VideoComment{},
+		&models.VideoCommentLike{},
+		&models.Follow{},
+		&models.Friendship{},
+		&models.Notification{},
+		&models.NotificationPreference{},
+		&models.WebhookEndpoint{},
+		&models.WebhookDelivery{},
+		&models.Conversation{},
+		&models.ConversationMember{},
+		&models.Message{},
+		&models.MessageAttachment{},
+		&models.ConversationInvite{},
+		&models.Call{},
+		&models.CallParticipant{},
+		&models.UserBranding{},
 		&models.Payment{},
 		&models.Live{},
 		&models.ChatMessage{},
@@ -43,6 +65,9 @@ func MigratePostgreSQL(conf *config.Config) error {
 		return err
 	}
 
+	// 創建依賴擴展的搜尋索引
+	createTrigramIndexes(db)
+
 	// 添加直播間相關資料表
 	if err := migrateLiveRoomTables(db); err != nil {
 		return fmt.Errorf("migrate live room tables failed: %v", err)
@@ -66,8 +91,9 @@ func createPostgreSQLIndexes(db *gorm.DB) error {
 		"CREATE INDEX IF NOT EXISTS idx_videos_created_at ON videos (created_at DESC)",
 		"CREATE INDEX IF NOT EXISTS idx_videos_views ON videos (views DESC)",
 		"CREATE INDEX IF NOT EXISTS idx_videos_likes ON videos (likes DESC)",
-		// PostgreSQL全文搜索索引
-		"CREATE INDEX IF NOT EXISTS idx_videos_search ON videos USING gin(to_tsvector('english', title || ' ' || description))",
+		// PostgreSQL全文搜索索引（simple 設定，與影片搜尋查詢的 tsvector 表達式一致）
+		"DROP INDEX IF EXISTS idx_videos_search",
+		"CREATE INDEX IF NOT EXISTS idx_videos_search_simple ON videos USING gin(to_tsvector('simple', coalesce(title, '') || ' ' || coalesce(description, '')))",
 
 		// 影片品質表索引
 		"CREATE INDEX IF NOT EXISTS idx_video_qualities_video_id ON video_qualities (video_id)",
@@ -121,6 +147,21 @@ func createPostgreSQLExtensions(db *gorm.DB) error {
 	return nil
 }
 
+// createTrigramIndexes 創建三元組索引，加速中文等無空白分隔文字的 ILIKE 與相似度搜尋
+// pg_trgm 擴展無法建立時只記錄警告，影片搜尋會偵測擴展是否存在並省略三元組相似比對
+func createTrigramIndexes(db *gorm.DB) {
+	indexes := []string{
+		"CREATE INDEX IF NOT EXISTS idx_videos_title_trgm ON videos USING gin (title gin_trgm_ops)",
+		"CREATE INDEX IF NOT EXISTS idx_videos_description_trgm ON videos USING gin (description gin_trgm_ops)",
+	}
+
+	for _, index := range indexes {
+		if err := db.Exec(index).Error; err != nil {
+			utils.LogWarn("創建三元組索引失敗（需要 pg_trgm 擴展）: %s - %v", index, err)
+		}
+	}
+}
+
 // CreateTriggersAndFunctions 創建PostgreSQL觸發器和函數
 func CreateTriggersAndFunctions(db *gorm.DB) error {
 	// 創建自動更新updated_at的函數
@@ -177,5 +218,10 @@ func migrateLiveRoomTables(db *gorm.DB) error {
 		return fmt.Errorf("migrate UserLiveStats failed: %v", err)
 	}
 
+	// 直播間轉推目標表
+	if err := db.AutoMigrate(&models.RestreamTarget{}); err != nil {
+		return fmt.Errorf("migrate RestreamTarget failed: %v", err)
+	}
+
 	return nil
 }
@@ -0,0 +1,71 @@
+package models
+
+import "time"
+
+// 通話類型
+const (
+	CallTypeAudio = "audio"
+	CallTypeVideo = "video"
+)
+
+// 通話狀態，ringing 與 active 為進行中，其餘為結束時的結果
+const (
+	CallStatusRinging   = "ringing"   // 響鈴中，尚無人接聽
+	CallStatusActive    = "active"    // 通話中
+	CallStatusCompleted = "completed" // 有人接聽後結束
+	CallStatusMissed    = "missed"    // 響鈴逾時無人接聽
+	CallStatusRejected  = "rejected"  // 所有受邀者都拒接
+	CallStatusCancelled = "cancelled" // 發起者在接聽前取消
+)
+
+// 通話參與者狀態
+const (
+	CallParticipantInvited  = "invited"  // 響鈴中
+	CallParticipantJoined   = "joined"   // 已加入
+	CallParticipantDeclined = "declined" // 拒接
+	CallParticipantMissed   = "missed"   // 未接
+	CallParticipantLeft     = "left"     // 已離開
+	CallParticipantBusy     = "busy"     // 發起時正在其他通話中，未響鈴
+)
+
+// Call 通話紀錄，進行中的狀態存放在 Redis，結束時寫回結果
+type Call struct {
+	ID             uint       `json:"id" gorm:"primaryKey"`
+	CallID         string     `json:"call_id" gorm:"size:36;not null;uniqueIndex"` // 信令使用的通話ID
+	ConversationID uint       `json:"conversation_id" gorm:"not null;index"`
+	CallerID       uint       `json:"caller_id" gorm:"not null"`
+	Type           string     `json:"type" gorm:"size:10;not null"`
+	Status         string     `json:"status" gorm:"size:20;not null"`
+	AnsweredAt     *time.Time `json:"answered_at"`
+	EndedAt        *time.Time `json:"ended_at"`
+	Duration       int        `json:"duration" gorm:"not null;default:0"` // 接聽到結束的秒數
+	CreatedAt      time.Time  `json:"created_at" gorm:"index"`
+
+	// 關聯關係
+	Conversation *Conversation     `json:"-" gorm:"foreignKey:ConversationID;constraint:OnDelete:CASCADE"`
+	Participants []CallParticipant `json:"participants,omitempty" gorm:"foreignKey:CallID"`
+}
+
+// TableName 指定表名
+func (Call) TableName() string {
+	return "calls"
+}
+
+// CallParticipant 通話參與者
+type CallParticipant struct {
+	ID       uint       `json:"id" gorm:"primaryKey"`
+	CallID   uint       `json:"call_id" gorm:"not null;uniqueIndex:idx_call_participants_call_user,priority:1"`
+	UserID   uint       `json:"user_id" gorm:"not null;uniqueIndex:idx_call_participants_call_user,priority:2;index"`
+	Status   string     `json:"status" gorm:"size:20;not null"`
+	JoinedAt *time.Time `json:"joined_at"`
+	LeftAt   *time.Time `json:"left_at"`
+
+	// 關聯關係
+	Call *Call `json:"-" gorm:"foreignKey:CallID;constraint:OnDelete:CASCADE"`
+	User *User `json:"user,omitempty" gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+}
+
+// TableName 指定表名
+func (CallParticipant) TableName() string {
+	return "call_participants"
+}
@@ -0,0 +1,133 @@
+package models
+
+import "time"
+
+// 會話類型
+const (
+	ConversationTypeDirect = "direct" // 一對一私訊
+	ConversationTypeGroup  = "group"  // 群組
+)
+
+// 群組成員角色
+const (
+	ConversationRoleOwner  = "owner"  // 群主，可管理管理員與成員
+	ConversationRoleAdmin  = "admin"  // 管理員，可管理一般成員
+	ConversationRoleMember = "member" // 一般成員
+)
+
+// 訊息類型
+const (
+	MessageTypeText   = "text"
+	MessageTypeSystem = "system" // 成員異動等系統訊息，data 記錄異動內容
+	MessageTypeImage  = "image"  // 圖片附件，content 為說明文字
+	MessageTypeVideo  = "video"  // 影片附件
+	MessageTypeFile   = "file"   // 其他檔案附件
+)
+
+// Conversation 私訊會話
+type Conversation struct {
+	ID            uint       `json:"id" gorm:"primaryKey"`
+	Type          string     `json:"type" gorm:"size:20;not null"`
+	DirectKey     *string    `json:"-" gorm:"size:50;uniqueIndex"` // 一對一會話的成員組合 <較小用戶ID>:<較大用戶ID>，避免重複建立
+	Name          string     `json:"name" gorm:"size:100"`         // 群組名稱
+	AvatarKey     string     `json:"avatar_key" gorm:"size:255"`   // 群組頭像的儲存 Key
+	CreatedBy     uint       `json:"created_by" gorm:"not null"`
+	LastMessageID *uint      `json:"last_message_id"`
+	LastMessageAt *time.Time `json:"last_message_at" gorm:"index"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+
+	// 關聯關係
+	Members []ConversationMember `json:"members,omitempty" gorm:"foreignKey:ConversationID"`
+}
+
+// TableName 指定表名
+func (Conversation) TableName() string {
+	return "conversations"
+}
+
+// ConversationMember 會話成員，以已送達與已讀的最後訊息ID記錄回條
+type ConversationMember struct {
+	ID                     uint      `json:"id" gorm:"primaryKey"`
+	ConversationID         uint      `json:"conversation_id" gorm:"not null;uniqueIndex:idx_conversation_members_conversation_user,priority:1"`
+	UserID                 uint      `json:"user_id" gorm:"not null;uniqueIndex:idx_conversation_members_conversation_user,priority:2;index"`
+	Role                   string    `json:"role" gorm:"size:20;not null;default:'member'"`
+	LastDeliveredMessageID uint      `json:"last_delivered_message_id" gorm:"not null;default:0"`
+	LastReadMessageID      uint      `json:"last_read_message_id" gorm:"not null;default:0"`
+	CreatedAt              time.Time `json:"created_at"`
+
+	// 關聯關係
+	Conversation *Conversation `json:"-" gorm:"foreignKey:ConversationID;constraint:OnDelete:CASCADE"`
+	User         *User         `json:"user,omitempty" gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+}
+
+// TableName 指定表名
+func (ConversationMember) TableName() string {
+	return "conversation_members"
+}
+
+// Message 私訊訊息
+type Message struct {
+	ID             uint      `json:"id" gorm:"primaryKey;index:idx_messages_conversation_id,priority:2"`
+	ConversationID uint      `json:"conversation_id" gorm:"not null;index:idx_messages_conversation_id,priority:1"`
+	SenderID       uint      `json:"sender_id" gorm:"not null;uniqueIndex:idx_messages_sender_client,priority:1,where:client_id <> ''"`
+	Type           string    `json:"type" gorm:"size:20;not null"`
+	Content        string    `json:"content" gorm:"type:text"`
+	Data           string    `json:"data" gorm:"type:jsonb;not null;default:'{}'"`                                                                         // 系統訊息的異動內容，如 action、user_ids
+	ClientID       string    `json:"client_id" gorm:"size:64;not null;default:'';uniqueIndex:idx_messages_sender_client,priority:2,where:client_id <> ''"` // 客戶端產生的ID，重送時避免重複
+	CreatedAt      time.Time `json:"created_at"`
+
+	// 關聯關係
+	Conversation *Conversation      `json:"-" gorm:"foreignKey:ConversationID;constraint:OnDelete:CASCADE"`
+	Sender       *User              `json:"-" gorm:"foreignKey:SenderID;constraint:OnDelete:CASCADE"`
+	Attachment   *MessageAttachment `json:"attachment,omitempty" gorm:"foreignKey:MessageID"`
+}
+
+// TableName 指定表名
+func (Message) TableName() string {
+	return "messages"
+}
+
+// ConversationInvite 群組邀請連結
+type ConversationInvite struct {
+	ID             uint       `json:"id" gorm:"primaryKey"`
+	ConversationID uint       `json:"conversation_id" gorm:"not null;index"`
+	Code           string     `json:"code" gorm:"size:32;not null;uniqueIndex"`
+	CreatedBy      uint       `json:"created_by" gorm:"not null"`
+	MaxUses        int        `json:"max_uses" gorm:"not null;default:0"` // 0 表示不限次數
+	Uses           int        `json:"uses" gorm:"not null;default:0"`
+	ExpiresAt      *time.Time `json:"expires_at"`
+	CreatedAt      time.Time  `json:"created_at"`
+
+	// 關聯關係
+	Conversation *Conversation `json:"-" gorm:"foreignKey:ConversationID;constraint:OnDelete:CASCADE"`
+}
+
+// TableName 指定表名
+func (ConversationInvite) TableName() string {
+	return "conversation_invites"
+}
+
+// MessageAttachment 訊息附件，檔案存放在私有的附件桶，只透過預簽名URL提供給會話成員
+type MessageAttachment struct {
+	ID           uint      `json:"id" gorm:"primaryKey"`
+	MessageID    uint      `json:"message_id" gorm:"not null;uniqueIndex"`
+	Kind         string    `json:"kind" gorm:"size:20;not null"` // image, video, file，與訊息類型相同
+	Key          string    `json:"-" gorm:"size:255;not null;uniqueIndex"`
+	ThumbnailKey string    `json:"-" gorm:"size:255"` // 圖片縮圖
+	Filename     string    `json:"filename" gorm:"size:255;not null"`
+	ContentType  string    `json:"content_type" gorm:"size:100;not null"`
+	Size         int64     `json:"size" gorm:"not null"`
+	Width        int       `json:"width"`
+	Height       int       `json:"height"`
+	Duration     float64   `json:"duration"` // 影片長度(秒)
+	CreatedAt    time.Time `json:"created_at"`
+
+	// 關聯關係
+	Message *Message `json:"-" gorm:"foreignKey:MessageID;constraint:OnDelete:CASCADE"`
+}
+
+// TableName 指定表名
+func (MessageAttachment) TableName() string {
+	return "message_attachments"
+}
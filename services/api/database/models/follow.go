@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// Follow 用戶追蹤關係
+type Follow struct {
+	ID         uint      `json:"id" gorm:"primaryKey"`
+	FollowerID uint      `json:"follower_id" gorm:"not null;uniqueIndex:idx_follows_follower_followee,priority:1"`
+	FolloweeID uint      `json:"followee_id" gorm:"not null;uniqueIndex:idx_follows_follower_followee,priority:2;index"`
+	CreatedAt  time.Time `json:"created_at"`
+
+	// 關聯關係
+	Follower *User `json:"follower,omitempty" gorm:"foreignKey:FollowerID;constraint:OnDelete:CASCADE"`
+	Followee *User `json:"followee,omitempty" gorm:"foreignKey:FolloweeID;constraint:OnDelete:CASCADE"`
+}
+
+// TableName 指定表名
+func (Follow) TableName() string {
+	return "follows"
+}
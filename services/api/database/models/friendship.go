@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+// 好友關係狀態
+const (
+	FriendshipStatusPending  = "pending"  // user_id 向 friend_id 發出的好友邀請
+	FriendshipStatusAccepted = "accepted" // 雙方為好友
+	FriendshipStatusBlocked  = "blocked"  // user_id 封鎖了 friend_id
+)
+
+// Friendship 好友關係，每對用戶最多一筆邀請或好友記錄，封鎖則由封鎖者各自記錄
+type Friendship struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	UserID    uint      `json:"user_id" gorm:"not null;uniqueIndex:idx_friendships_user_friend,priority:1"`
+	FriendID  uint      `json:"friend_id" gorm:"not null;uniqueIndex:idx_friendships_user_friend,priority:2;index"`
+	Status    string    `json:"status" gorm:"size:20;not null;default:'pending'"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	// 關聯關係
+	User   *User `json:"user,omitempty" gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+	Friend *User `json:"friend,omitempty" gorm:"foreignKey:FriendID;constraint:OnDelete:CASCADE"`
+}
+
+// TableName 指定表名
+func (Friendship) TableName() string {
+	return "friendships"
+}
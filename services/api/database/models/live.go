@@ -8,7 +8,7 @@ type Live struct {
 	Title       string    `json:"title" gorm:"size:100;not null"`
 	Description string    `json:"description" gorm:"size:500"`
 	UserID      uint      `json:"user_id" gorm:"not null;index:idx_lives_user_status,priority:1"`
-	Status      string    `json:"status" gorm:"size:20;not null;index:idx_lives_user_status,priority:2;index:idx_lives_status_start,priority:1"` // scheduled, live, ended
+	Status      string    `json:"status" gorm:"size:20;not null;index:idx_lives_user_status,priority:2;index:idx_lives_status_start,priority:1"` // scheduled, live, ended, cancelled
 	StartTime   time.Time `json:"start_time" gorm:"index:idx_lives_status_start,priority:2"`
 	EndTime     time.Time `json:"end_time"`
 	StreamKey   string    `json:"stream_key" gorm:"size:100;uniqueIndex"`
@@ -12,7 +12,7 @@ type UserLiveSession struct {
 	Title         string     `gorm:"size:255" json:"title"`
 	Description   string     `gorm:"type:text" json:"description"`
 	StreamKey     string     `gorm:"size:255" json:"stream_key"`
-	Status        string     `gorm:"size:50;default:'created'" json:"status"` // created, waiting, live, paused, ended, cancelled
+	Status        string     `gorm:"size:50;default:'created'" json:"status"` // created, scheduled, waiting, live, paused, ended, cancelled
 	StartedAt     *time.Time `json:"started_at"`
 	EndedAt       *time.Time `json:"ended_at"`
 	Duration      int        `gorm:"default:0" json:"duration"` // 直播時長(秒)
@@ -0,0 +1,70 @@
+package models
+
+import "time"
+
+// 通知類型
+const (
+	NotificationTranscodeFinished   = "transcode_finished"    // 影片轉碼完成
+	NotificationTranscodeFailed     = "transcode_failed"      // 影片轉碼失敗
+	NotificationDuplicateUpload     = "duplicate_upload"      // 偵測到重複上傳，可共用轉碼結果
+	NotificationPaymentCompleted    = "payment_completed"     // 付款完成
+	NotificationNewFollower         = "new_follower"          // 新的追隨者
+	NotificationFollowedLiveStarted = "followed_live_started" // 追蹤的創作者開始直播
+	NotificationLiveRoomReminder    = "live_room_reminder"    // 預約的直播即將開始
+	NotificationLiveRoomStarted     = "live_room_started"     // 預約的直播已開始
+	NotificationLiveRoomCancelled   = "live_room_cancelled"   // 預約的直播已取消
+	NotificationFriendRequest       = "friend_request"        // 收到好友邀請
+	NotificationFriendAccepted      = "friend_accepted"       // 好友邀請已被接受
+)
+
+// NotificationTypes 所有通知類型，可逐一設定是否接收
+var NotificationTypes = []string{
+	NotificationTranscodeFinished,
+	NotificationTranscodeFailed,
+	NotificationDuplicateUpload,
+	NotificationPaymentCompleted,
+	NotificationNewFollower,
+	NotificationFollowedLiveStarted,
+	NotificationLiveRoomReminder,
+	NotificationLiveRoomStarted,
+	NotificationLiveRoomCancelled,
+	NotificationFriendRequest,
+	NotificationFriendAccepted,
+}
+
+// Notification 站內通知
+type Notification struct {
+	ID        uint       `json:"id" gorm:"primaryKey"`
+	UserID    uint       `json:"user_id" gorm:"not null;index:idx_notifications_user_id,priority:1;index:idx_notifications_user_read,priority:1"`
+	Type      string     `json:"type" gorm:"size:50;not null"`
+	Title     string     `json:"title" gorm:"size:200;not null"`
+	Content   string     `json:"content" gorm:"type:text"`
+	Data      string     `json:"data" gorm:"type:jsonb;not null;default:'{}'"` // 前端導向用的附加資料，如 video_id、room_id
+	ReadAt    *time.Time `json:"read_at" gorm:"index:idx_notifications_user_read,priority:2"`
+	CreatedAt time.Time  `json:"created_at" gorm:"index"`
+
+	// 關聯關係
+	User *User `json:"-" gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+}
+
+// TableName 指定表名
+func (Notification) TableName() string {
+	return "notifications"
+}
+
+// NotificationPreference 用戶對各通知類型的接收設定，沒有記錄時預設接收
+type NotificationPreference struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	UserID    uint      `json:"user_id" gorm:"not null;uniqueIndex:idx_notification_preferences_user_type,priority:1"`
+	Type      string    `json:"type" gorm:"size:50;not null;uniqueIndex:idx_notification_preferences_user_type,priority:2"`
+	Enabled   bool      `json:"enabled" gorm:"not null"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	// 關聯關係
+	User *User `json:"-" gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+}
+
+// TableName 指定表名
+func (NotificationPreference) TableName() string {
+	return "notification_preferences"
+}
@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// RestreamTarget 直播間轉推目標
+type RestreamTarget struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	RoomID    string    `gorm:"not null;index;size:255" json:"room_id"`
+	UserID    int       `gorm:"not null;index" json:"user_id"`
+	Name      string    `gorm:"size:100;not null" json:"name"` // 例如 YouTube、Twitch
+	URL       string    `gorm:"size:500;not null" json:"url"`  // RTMP 推流地址（不含金鑰）
+	StreamKey string    `gorm:"type:text;not null" json:"-"`   // 加密後的推流金鑰
+	Enabled   bool      `gorm:"default:true" json:"enabled"`
+	Status    string    `gorm:"size:20;default:'idle'" json:"status"` // idle, starting, running, retrying, failed, stopped
+	LastError string    `gorm:"size:500" json:"last_error"`
+	Retries   int       `gorm:"default:0" json:"retries"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TableName 指定表名
+func (RestreamTarget) TableName() string {
+	return "live_room_restream_targets"
+}
@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+// UserBranding 創作者品牌設定
+// 轉碼時由 converter 將浮水印燒入影片，並在正片前後接上片頭/片尾影片
+type UserBranding struct {
+	ID      uint `json:"id" gorm:"primaryKey"`
+	UserID  uint `json:"user_id" gorm:"not null;uniqueIndex"`
+	Enabled bool `json:"enabled" gorm:"default:true"`
+
+	// 浮水印（原始桶中的圖片）
+	WatermarkKey      string  `json:"watermark_key" gorm:"size:500"`
+	WatermarkPosition string  `json:"watermark_position" gorm:"size:20;default:bottom-right"` // top-left, top-right, bottom-left, bottom-right, center
+	WatermarkOpacity  float64 `json:"watermark_opacity" gorm:"default:0.8"`                   // 0-1
+	WatermarkScale    float64 `json:"watermark_scale" gorm:"default:0.15"`                    // 浮水印寬度佔影片寬度的比例
+
+	// 片頭/片尾（引用自己已完成處理的影片）
+	IntroVideoID *uint `json:"intro_video_id"`
+	OutroVideoID *uint `json:"outro_video_id"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	// 關聯關係
+	User *User `json:"user,omitempty" gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+}
+
+// TableName 指定表名
+func (UserBranding) TableName() string {
+	return "user_brandings"
+}
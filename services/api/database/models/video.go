@@ -1,6 +1,18 @@
 package models
 
-import "time"
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 影片可見度
+const (
+	VideoVisibilityPublic    = "public"    // 所有人可見，出現在列表與搜尋
+	VideoVisibilityUnlisted  = "unlisted"  // 不出現在列表與搜尋，持有分享連結者可觀看
+	VideoVisibilityPrivate   = "private"   // 僅擁有者與獲得授權的用戶可見
+	VideoVisibilityFollowers = "followers" // 僅擁有者的追隨者可見
+)
 
 // Video 影片模型
 type Video struct {
@@ -18,37 +30,77 @@ type Video struct {
 	HLSMasterURL string `json:"hls_master_url" gorm:"size:500"`
 	HLSKey       string `json:"hls_key" gorm:"size:500"`
 
+	// 封裝格式（ts / cmaf），cmaf 時另有與 HLS 共用分段的 DASH MPD
+	Packaging       string `json:"packaging" gorm:"size:10;default:ts"`
+	DASHManifestURL string `json:"dash_manifest_url" gorm:"size:500"`
+
+	// HLS 分段是否以 AES-128 加密，金鑰由 /api/videos/:id/key 發放
+	Encrypted bool `json:"encrypted" gorm:"default:false"`
+
+	// 轉碼時實際套用的品牌元素（watermark, intro, outro 以逗號分隔）
+	BrandingApplied string `json:"branding_applied" gorm:"size:50"`
+
 	// MP4轉碼版本（網頁播放）
 	MP4URL string `json:"mp4_url" gorm:"size:500"`
 	MP4Key string `json:"mp4_key" gorm:"size:500"`
 
+	// 拖曳預覽（雪碧圖 + WebVTT 縮圖軌）
+	PreviewVTTURL      string `json:"preview_vtt_url" gorm:"size:500"`
+	PreviewSpriteCount int    `json:"preview_sprite_count" gorm:"default:0"`
+	PreviewInterval    int    `json:"preview_interval" gorm:"default:0"` // 每張縮圖間隔秒數
+
 	// 影片屬性
 	Duration       int    `json:"duration" gorm:"default:0"`      // 秒數
 	FileSize       int64  `json:"file_size" gorm:"default:0"`     // 位元組
 	OriginalFormat string `json:"original_format" gorm:"size:10"` // mp4, avi等
+	ContentSHA256  string `json:"-" gorm:"size:64;index"`         // 原始檔 SHA-256，串流上傳時或由 converter 轉碼前計算
+
+	// 重複上傳偵測：與同一用戶已完成的影片相同時狀態改為 duplicate，由用戶決定共用轉碼結果或重新轉碼
+	PerceptualHash   string `json:"-" gorm:"size:512"`               // 取樣畫面的 dHash，每張 16 位十六進位，由 converter 計算
+	DuplicateOfID    *uint  `json:"duplicate_of_id" gorm:"index"`    // 偵測到的重複來源影片
+	DuplicateMatch   string `json:"duplicate_match" gorm:"size:20"`  // sha256, perceptual
+	RenditionsFromID *uint  `json:"renditions_from_id" gorm:"index"` // 共用該影片的轉碼結果，未自行轉碼
+
+	// 轉碼畫質階梯上限（建立時依設定寫入，name:width:height:bitratek 以逗號分隔）
+	TranscodeLadder string `json:"-" gorm:"size:500"`
+
+	// 可見度與不公開影片的分享代碼
+	Visibility string  `json:"visibility" gorm:"size:20;not null;default:public;index"`
+	ShareSlug  *string `json:"-" gorm:"size:32;uniqueIndex"`
+
+	// 完成後擴散到追隨者動態的時間，尚未擴散時為 nil
+	FeedPublishedAt *time.Time `json:"-" gorm:"index"`
+
+	// 已通知擁有者的處理結果（ready 或 failed），與 Status 不同時由通知服務發送轉碼通知
+	NotifiedStatus string `json:"-" gorm:"size:20;not null;default:''"`
 
 	// 狀態管理
 	Status string `json:"status" gorm:"size:20;not null;index:idx_videos_user_status,priority:2;index:idx_videos_status_created,priority:1"`
-	// 狀態: uploading, processing, transcoding, ready, failed
+	// 狀態: uploading, clipping, processing, transcoding, duplicate, ready, failed
 	ProcessingProgress int    `json:"processing_progress" gorm:"default:0"` // 0-100
 	ErrorMessage       string `json:"error_message" gorm:"size:500"`
 
 	// 統計資料
 	Views     int64     `json:"views" gorm:"default:0"`
-	Likes     int64     `json:"likes" gorm:"default:0"`
+	Likes     int64     `json:"likes" gorm:"default:0"`    // 由反應計數對帳寫入
+	Dislikes  int64     `json:"dislikes" gorm:"default:0"` // 由反應計數對帳寫入
 	CreatedAt time.Time `json:"created_at" gorm:"index:idx_videos_user_created,priority:2;index:idx_videos_status_created,priority:2"`
 	UpdatedAt time.Time `json:"updated_at"`
 
+	// 軟刪除：移至垃圾桶，保留期滿後由垃圾回收清除儲存檔案與記錄
+	DeletedAt gorm.DeletedAt `json:"deleted_at,omitempty" gorm:"index"`
+
 	// 關聯關係
-	User           *User          `json:"user,omitempty" gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
-	VideoQualities []VideoQuality `json:"video_qualities,omitempty" gorm:"foreignKey:VideoID;constraint:OnDelete:CASCADE"`
+	User           *User             `json:"user,omitempty" gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+	VideoQualities []VideoQuality    `json:"video_qualities,omitempty" gorm:"foreignKey:VideoID;constraint:OnDelete:CASCADE"`
+	AudioTracks    []VideoAudioTrack `json:"audio_tracks,omitempty" gorm:"foreignKey:VideoID;constraint:OnDelete:CASCADE"`
 }
 
 // VideoQuality 影片品質資訊模型
 type VideoQuality struct {
 	ID       uint   `json:"id" gorm:"primaryKey"`
 	VideoID  uint   `json:"video_id" gorm:"not null;index"`
-	Quality  string `json:"quality" gorm:"size:10;not null"` // 360p, 480p, 720p, 1080p
+	Quality  string `json:"quality" gorm:"size:10;not null"` // 360p, 480p, 720p, 1080p, audio（純音訊）
 	Width    int    `json:"width" gorm:"not null"`
 	Height   int    `json:"height" gorm:"not null"`
 	Bitrate  int    `json:"bitrate" gorm:"not null"`
@@ -63,3 +115,21 @@ type VideoQuality struct {
 	// 關聯關係
 	Video *Video `json:"video,omitempty" gorm:"foreignKey:VideoID;constraint:OnDelete:CASCADE"`
 }
+
+// VideoAudioTrack 影片音軌資訊模型
+// 每條來源音軌在 HLS 中輸出為 TYPE=AUDIO 的獨立播放列表
+type VideoAudioTrack struct {
+	ID             uint   `json:"id" gorm:"primaryKey"`
+	VideoID        uint   `json:"video_id" gorm:"not null;index"`
+	Name           string `json:"name" gorm:"size:20;not null"`     // HLS 目錄名稱，例如 audio_0
+	Language       string `json:"language" gorm:"size:35;not null"` // 來源標記的語言，未標記為 und
+	Label          string `json:"label" gorm:"size:100"`
+	Channels       int    `json:"channels" gorm:"not null"`        // 輸出聲道數
+	SourceChannels int    `json:"source_channels" gorm:"not null"` // 來源聲道數
+	Bitrate        int    `json:"bitrate" gorm:"not null"`         // kbps
+	PlaylistURL    string `json:"playlist_url" gorm:"size:500;not null"`
+	IsDefault      bool   `json:"is_default" gorm:"default:false"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
@@ -0,0 +1,30 @@
+package models
+
+import "time"
+
+// VideoCaption 影片字幕軌
+// 上傳的 SRT/WebVTT 由轉碼服務轉為 WebVTT 分段，並加入 HLS 主播放列表
+type VideoCaption struct {
+	ID       uint   `json:"id" gorm:"primaryKey"`
+	VideoID  uint   `json:"video_id" gorm:"not null;uniqueIndex:idx_video_captions_video_lang,priority:1"`
+	UserID   uint   `json:"user_id" gorm:"not null;index"`
+	Language string `json:"language" gorm:"size:35;not null;uniqueIndex:idx_video_captions_video_lang,priority:2"` // BCP 47，例如 zh-TW, en
+	Label    string `json:"label" gorm:"size:100;not null"`                                                        // 播放器顯示名稱
+	Format   string `json:"format" gorm:"size:10;not null"`                                                        // 上傳格式: srt, vtt
+
+	SourceKey   string `json:"-" gorm:"size:500"`            // 原始字幕檔 Key（原始桶）
+	VTTURL      string `json:"vtt_url" gorm:"size:500"`      // 完整 WebVTT，供 MP4 播放使用
+	PlaylistURL string `json:"playlist_url" gorm:"size:500"` // HLS 字幕播放列表
+	IsDefault   bool   `json:"is_default" gorm:"default:false"`
+
+	// 狀態: uploading, processing, ready, failed, deleting
+	Status       string    `json:"status" gorm:"size:20;not null;index"`
+	ErrorMessage string    `json:"error_message" gorm:"size:500"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// TableName 指定表名
+func (VideoCaption) TableName() string {
+	return "video_captions"
+}
@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+// VideoClip 精華片段剪輯資訊
+// 剪輯產生的片段本身是一支獨立的 Video，此表記錄其來源與剪輯範圍
+type VideoClip struct {
+	ID      uint `json:"id" gorm:"primaryKey"`
+	VideoID uint `json:"video_id" gorm:"not null;uniqueIndex"` // 剪輯產生的影片
+	UserID  uint `json:"user_id" gorm:"not null;index"`
+
+	// 來源資訊
+	SourceType          string `json:"source_type" gorm:"size:10;not null"` // video, live
+	SourceVideoID       *uint  `json:"source_video_id" gorm:"index"`
+	SourceLiveSessionID *uint  `json:"source_live_session_id" gorm:"index"`
+	SourceRoomID        string `json:"source_room_id" gorm:"size:255"`
+	SourceInput         string `json:"-" gorm:"size:500;not null"` // 轉碼服務讀取的來源（桶/Key 或直播 HLS URL）
+
+	// 剪輯範圍
+	StartTime float64    `json:"start_time"` // 相對來源開頭的秒數
+	EndTime   float64    `json:"end_time"`
+	StartAt   *time.Time `json:"start_at,omitempty"` // 直播剪輯的實際時間點
+	EndAt     *time.Time `json:"end_at,omitempty"`
+
+	CutMode   string    `json:"cut_mode" gorm:"size:20"` // copy（關鍵幀直接切割）, reencode
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TableName 指定表名
+func (VideoClip) TableName() string {
+	return "video_clips"
+}
@@ -0,0 +1,61 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 留言審核狀態
+const (
+	CommentStatusVisible = "visible"
+	CommentStatusHidden  = "hidden" // 影片擁有者或管理員隱藏，只有留言者本人與管理者看得到
+)
+
+// VideoComment 影片留言，回覆只有一層：回覆其他回覆時掛在同一則主留言下並記錄回覆對象
+type VideoComment struct {
+	ID               uint   `json:"id" gorm:"primaryKey"`
+	VideoID          uint   `json:"video_id" gorm:"not null;index:idx_video_comments_video_parent,priority:1"`
+	ParentID         *uint  `json:"parent_id" gorm:"index:idx_video_comments_video_parent,priority:2"` // 主留言為 nil
+	UserID           uint   `json:"user_id" gorm:"not null;index"`
+	ReplyToUserID    *uint  `json:"reply_to_user_id"`
+	Content          string `json:"content" gorm:"type:text;not null"`
+	TimestampSeconds *int   `json:"timestamp_seconds"` // 留言指向的影片時間點(秒)
+	Pinned           bool   `json:"pinned" gorm:"not null;default:false"`
+	Status           string `json:"status" gorm:"size:20;not null;default:visible"`
+	Likes            int64  `json:"likes" gorm:"not null;default:0"`
+	ReplyCount       int64  `json:"reply_count" gorm:"not null;default:0"`
+
+	EditedAt  *time.Time     `json:"edited_at"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+
+	// 關聯關係
+	Video       *Video        `json:"video,omitempty" gorm:"foreignKey:VideoID;constraint:OnDelete:CASCADE"`
+	User        *User         `json:"user,omitempty" gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+	ReplyToUser *User         `json:"reply_to_user,omitempty" gorm:"foreignKey:ReplyToUserID;constraint:OnDelete:SET NULL"`
+	Parent      *VideoComment `json:"-" gorm:"foreignKey:ParentID;constraint:OnDelete:CASCADE"`
+}
+
+// TableName 指定表名
+func (VideoComment) TableName() string {
+	return "video_comments"
+}
+
+// VideoCommentLike 用戶對留言的喜歡，每位用戶對每則留言只有一筆
+type VideoCommentLike struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	CommentID uint      `json:"comment_id" gorm:"not null;uniqueIndex:idx_video_comment_likes_comment_user,priority:1"`
+	UserID    uint      `json:"user_id" gorm:"not null;uniqueIndex:idx_video_comment_likes_comment_user,priority:2;index"`
+	CreatedAt time.Time `json:"created_at"`
+
+	// 關聯關係
+	Comment *VideoComment `json:"-" gorm:"foreignKey:CommentID;constraint:OnDelete:CASCADE"`
+	User    *User         `json:"-" gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+}
+
+// TableName 指定表名
+func (VideoCommentLike) TableName() string {
+	return "video_comment_likes"
+}
@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// VideoEncryptionKey 影片 HLS AES-128 內容金鑰
+// 長影片每隔固定分段數輪換一把金鑰，KeyIndex 對應播放列表 EXT-X-KEY 的 k 參數
+type VideoEncryptionKey struct {
+	ID       uint   `json:"id" gorm:"primaryKey"`
+	VideoID  uint   `json:"video_id" gorm:"not null;uniqueIndex:idx_video_encryption_keys_video_index,priority:1"`
+	KeyIndex int    `json:"key_index" gorm:"not null;uniqueIndex:idx_video_encryption_keys_video_index,priority:2"`
+	Key      string `json:"-" gorm:"size:255;not null"` // 以 AES-256-GCM 加密保存的十六進位金鑰
+
+	CreatedAt time.Time `json:"created_at"`
+
+	// 關聯關係
+	Video *Video `json:"video,omitempty" gorm:"foreignKey:VideoID;constraint:OnDelete:CASCADE"`
+}
+
+// TableName 指定表名
+func (VideoEncryptionKey) TableName() string {
+	return "video_encryption_keys"
+}
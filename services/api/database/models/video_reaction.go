@@ -0,0 +1,31 @@
+package models
+
+import "time"
+
+// 影片反應類型
+const (
+	ReactionLike    = "like"
+	ReactionDislike = "dislike"
+	ReactionEmoji   = "emoji"
+)
+
+// VideoReaction 用戶對影片的反應，每位用戶對每部影片只有一筆
+type VideoReaction struct {
+	ID      uint   `json:"id" gorm:"primaryKey"`
+	VideoID uint   `json:"video_id" gorm:"not null;uniqueIndex:idx_video_reactions_video_user,priority:1;index:idx_video_reactions_video_type,priority:1"`
+	UserID  uint   `json:"user_id" gorm:"not null;uniqueIndex:idx_video_reactions_video_user,priority:2;index"`
+	Type    string `json:"type" gorm:"size:10;not null;index:idx_video_reactions_video_type,priority:2"` // like, dislike, emoji
+	Emoji   string `json:"emoji" gorm:"size:16"`                                                         // type 為 emoji 時的表情
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	// 關聯關係
+	Video *Video `json:"video,omitempty" gorm:"foreignKey:VideoID;constraint:OnDelete:CASCADE"`
+	User  *User  `json:"user,omitempty" gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+}
+
+// TableName 指定表名
+func (VideoReaction) TableName() string {
+	return "video_reactions"
+}
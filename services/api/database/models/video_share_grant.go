@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// VideoShareGrant 私人影片的個別用戶授權
+type VideoShareGrant struct {
+	ID      uint `json:"id" gorm:"primaryKey"`
+	VideoID uint `json:"video_id" gorm:"not null;uniqueIndex:idx_video_share_grants_video_user,priority:1"`
+	UserID  uint `json:"user_id" gorm:"not null;uniqueIndex:idx_video_share_grants_video_user,priority:2;index"`
+
+	CreatedAt time.Time `json:"created_at"`
+
+	// 關聯關係
+	Video *Video `json:"video,omitempty" gorm:"foreignKey:VideoID;constraint:OnDelete:CASCADE"`
+	User  *User  `json:"user,omitempty" gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+}
+
+// TableName 指定表名
+func (VideoShareGrant) TableName() string {
+	return "video_share_grants"
+}
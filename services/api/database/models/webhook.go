@@ -0,0 +1,77 @@
+package models
+
+import "time"
+
+// Webhook 事件類型
+const (
+	WebhookEventVideoReady       = "video.ready"
+	WebhookEventVideoFailed      = "video.failed"
+	WebhookEventLiveStarted      = "live.started"
+	WebhookEventLiveEnded        = "live.ended"
+	WebhookEventPaymentCompleted = "payment.completed"
+)
+
+// WebhookEventTypes 可訂閱的事件類型
+var WebhookEventTypes = []string{
+	WebhookEventVideoReady,
+	WebhookEventVideoFailed,
+	WebhookEventLiveStarted,
+	WebhookEventLiveEnded,
+	WebhookEventPaymentCompleted,
+}
+
+// Webhook 投遞狀態
+const (
+	WebhookDeliveryPending   = "pending"   // 等待投遞或重試
+	WebhookDeliverySucceeded = "succeeded" // 端點回應 2xx
+	WebhookDeliveryFailed    = "failed"    // 重試次數用盡或端點已停用
+)
+
+// WebhookEndpoint 用戶註冊的 Webhook 端點
+type WebhookEndpoint struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	UserID      uint      `json:"user_id" gorm:"not null;index"`
+	URL         string    `json:"url" gorm:"size:2048;not null"`
+	Secret      string    `json:"-" gorm:"size:100;not null"`      // HMAC 簽章金鑰
+	Events      string    `json:"events" gorm:"size:500;not null"` // 訂閱的事件類型，以逗號分隔
+	AllUsers    bool      `json:"all_users" gorm:"not null;index"` // 接收所有用戶的事件（僅管理員可設定）
+	Description string    `json:"description" gorm:"size:200"`
+	Enabled     bool      `json:"enabled" gorm:"not null"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+
+	// 關聯關係
+	User *User `json:"-" gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+}
+
+// TableName 指定表名
+func (WebhookEndpoint) TableName() string {
+	return "webhook_endpoints"
+}
+
+// WebhookDelivery Webhook 投遞記錄，重新投遞時建立新記錄並沿用事件ID與內容
+type WebhookDelivery struct {
+	ID             uint       `json:"id" gorm:"primaryKey"`
+	EndpointID     uint       `json:"endpoint_id" gorm:"not null;index:idx_webhook_deliveries_endpoint,priority:1"`
+	EventID        string     `json:"event_id" gorm:"size:64;not null;index"`
+	EventType      string     `json:"event_type" gorm:"size:50;not null"`
+	Payload        string     `json:"payload" gorm:"type:text;not null"`
+	Status         string     `json:"status" gorm:"size:20;not null;index"`
+	Attempts       int        `json:"attempts" gorm:"not null;default:0"`
+	ResponseStatus int        `json:"response_status"`
+	ResponseBody   string     `json:"response_body" gorm:"size:1000"`
+	Error          string     `json:"error" gorm:"size:500"`
+	NextAttemptAt  *time.Time `json:"next_attempt_at"`
+	DeliveredAt    *time.Time `json:"delivered_at"`
+	RedeliveryOf   *uint      `json:"redelivery_of"` // 手動重新投遞的來源記錄
+	CreatedAt      time.Time  `json:"created_at" gorm:"index;index:idx_webhook_deliveries_endpoint,priority:2"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+
+	// 關聯關係
+	Endpoint *WebhookEndpoint `json:"-" gorm:"foreignKey:EndpointID;constraint:OnDelete:CASCADE"`
+}
+
+// TableName 指定表名
+func (WebhookDelivery) TableName() string {
+	return "webhook_deliveries"
+}
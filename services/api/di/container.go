@@ -22,9 +22,12 @@ type Container struct {
 	Messaging *utils.RedisMessaging
 
 	// WebSocket
-	Hub               *ws.Hub
-	WSHandler         *ws.Handler
-	LiveRoomWSHandler *ws.LiveRoomHandler
+	Hub                   *ws.Hub
+	WSHandler             *ws.Handler
+	LiveRoomWSHandler     *ws.LiveRoomHandler
+	UploadWSHandler       *ws.UploadProgressHandler
+	NotificationWSHandler *ws.NotificationHandler
+	ChatWSHandler         *ws.ChatHandler
 
 	// 倉儲層
 	UserRepo    *postgresqlRepo.PostgreSQLRepo
@@ -33,21 +36,58 @@ type Container struct {
 	PaymentRepo *postgresqlRepo.PostgreSQLRepo
 
 	// 服務層
-	UserService         *services.UserService
-	VideoService        *services.VideoService
-	LiveService         *services.LiveService
-	LiveRoomService     *services.LiveRoomService
-	LiveRoomSyncService *services.LiveRoomSyncService
-	PaymentService      *services.PaymentService
-	PublicStreamService *services.PublicStreamService
+	UserService            *services.UserService
+	VideoService           *services.VideoService
+	LiveService            *services.LiveService
+	LiveRoomService        *services.LiveRoomService
+	LiveRoomSyncService    *services.LiveRoomSyncService
+	LiveRoomScheduler      *services.LiveRoomSchedulerService
+	PaymentService         *services.PaymentService
+	PublicStreamService    *services.PublicStreamService
+	RestreamService        *services.RestreamService
+	ClipService            *services.ClipService
+	CaptionService         *services.CaptionService
+	VideoKeyService        *services.VideoKeyService
+	BrandingService        *services.BrandingService
+	VideoUploadService     *services.VideoUploadService
+	VideoDuplicateService  *services.VideoDuplicateService
+	VideoTrashService      *services.VideoTrashService
+	VideoVisibilityService *services.VideoVisibilityService
+	VideoReactionService   *services.VideoReactionService
+	VideoCommentService    *services.VideoCommentService
+	FeedService            *services.FeedService
+	FollowService          *services.FollowService
+	NotificationService    *services.NotificationService
+	WebhookService         *services.WebhookService
+	ConversationService    *services.ConversationService
+	PresenceService        *services.PresenceService
+	FriendService          *services.FriendService
+	CallService            *services.CallService
 
 	// 處理器層
-	UserHandler         *api.UserHandler
-	VideoHandler        *api.VideoHandler
-	LiveHandler         *api.LiveHandler
-	LiveRoomHandler     *api.LiveRoomHandler
-	PaymentHandler      *api.PaymentHandler
-	PublicStreamHandler *api.PublicStreamHandler
+	UserHandler            *api.UserHandler
+	VideoHandler           *api.VideoHandler
+	LiveHandler            *api.LiveHandler
+	LiveRoomHandler        *api.LiveRoomHandler
+	PaymentHandler         *api.PaymentHandler
+	PublicStreamHandler    *api.PublicStreamHandler
+	RestreamHandler        *api.RestreamHandler
+	ClipHandler            *api.ClipHandler
+	CaptionHandler         *api.CaptionHandler
+	VideoKeyHandler        *api.VideoKeyHandler
+	BrandingHandler        *api.BrandingHandler
+	VideoUploadHandler     *api.VideoUploadHandler
+	VideoDuplicateHandler  *api.VideoDuplicateHandler
+	VideoTrashHandler      *api.VideoTrashHandler
+	VideoVisibilityHandler *api.VideoVisibilityHandler
+	VideoReactionHandler   *api.VideoReactionHandler
+	VideoCommentHandler    *api.VideoCommentHandler
+	FollowHandler          *api.FollowHandler
+	NotificationHandler    *api.NotificationHandler
+	WebhookHandler         *api.WebhookHandler
+	ConversationHandler    *api.ConversationHandler
+	FriendHandler          *api.FriendHandler
+	CallHandler            *api.CallHandler
 
 	// 路由
 	Router *api.Router
@@ -86,6 +126,15 @@ func NewContainer(cfg *config.Config) (*Container, error) {
 
 	// 設置 WebSocket 處理器到服務中
 	container.LiveRoomService.SetWSHandler(container.LiveRoomWSHandler)
+	container.VideoUploadService.SetProgressNotifier(container.UploadWSHandler)
+	container.NotificationService.SetNotifier(container.NotificationWSHandler)
+	container.ConversationService.SetNotifier(container.ChatWSHandler)
+	container.ChatWSHandler.SetActions(container.ConversationService)
+	container.ChatWSHandler.SetPresenceTracker(container.PresenceService)
+	container.ChatWSHandler.SetCallActions(container.CallService)
+	container.NotificationWSHandler.SetPresenceTracker(container.PresenceService)
+	container.LiveRoomWSHandler.SetBlockChecker(container.FriendService)
+	container.Hub.SetBlockChecker(container.FriendService)
 
 	return container, nil
 }
@@ -169,8 +218,32 @@ func (c *Container) initServices() error {
 	// 初始化用戶服務
 	c.UserService = services.NewUserService(c.Config)
 
+	// 初始化站內通知服務
+	c.NotificationService = services.NewNotificationService(c.Config, c.Messaging)
+
+	// 初始化對外 Webhook 服務（訂閱影片、直播與支付事件）
+	c.WebhookService = services.NewWebhookService(c.Config, c.Messaging)
+
+	// 初始化好友服務（上線狀態來自 WebSocket 連線）
+	c.PresenceService = services.NewPresenceService()
+	c.FriendService = services.NewFriendService(c.Config, c.NotificationService, c.PresenceService)
+
 	// 初始化影片服務
 	c.VideoService = services.NewVideoService(c.Config)
+	c.VideoUploadService = services.NewVideoUploadService(c.Config, c.Messaging, c.VideoService.S3Storage)
+	c.VideoDuplicateService = services.NewVideoDuplicateService(c.Config)
+	c.VideoTrashService = services.NewVideoTrashService(c.Config, c.VideoService.S3Storage)
+	c.VideoVisibilityService = services.NewVideoVisibilityService(c.Config)
+	c.VideoReactionService = services.NewVideoReactionService(c.Config)
+	c.VideoCommentService = services.NewVideoCommentService(c.Config)
+	c.VideoCommentService.SetFriendService(c.FriendService)
+
+	// 初始化私訊服務（群組頭像存放於影片服務的 S3）
+	c.ConversationService = services.NewConversationService(c.Config, c.Messaging, c.VideoService.S3Storage)
+	c.ConversationService.SetFriendService(c.FriendService)
+
+	// 初始化通話信令服務（事件經由私訊推送）
+	c.CallService = services.NewCallService(c.Config, c.ConversationService, c.PresenceService)
 
 	// 初始化直播服務
 	liveService, err := services.NewLiveService(c.Config)
@@ -182,11 +255,35 @@ func (c *Container) initServices() error {
 	// 初始化直播間服務
 	c.LiveRoomService = services.NewLiveRoomService(c.Config, c.Config.DB["master"])
 
+	// 初始化多平台轉推服務
+	c.RestreamService = services.NewRestreamService(c.Config, c.LiveRoomService)
+	c.LiveRoomService.SetRestreamService(c.RestreamService)
+
 	// 初始化直播間同步服務
 	c.LiveRoomSyncService = services.NewLiveRoomSyncService(c.LiveRoomService)
 
+	// 初始化預約直播排程服務
+	c.LiveRoomService.SetNotificationService(c.NotificationService)
+	c.LiveRoomService.SetMessaging(c.Messaging)
+	c.LiveRoomScheduler = services.NewLiveRoomSchedulerService(c.LiveRoomService, c.LiveService)
+
+	// 初始化追蹤與追蹤動態服務
+	c.FeedService = services.NewFeedService(c.Config, c.LiveRoomService, c.NotificationService)
+	c.LiveRoomService.SetFeedService(c.FeedService)
+	c.FollowService = services.NewFollowService(c.Config, c.FeedService, c.NotificationService)
+
+	// 初始化精華片段服務
+	c.ClipService = services.NewClipService(c.Config, c.VideoService.S3Storage, c.LiveRoomService)
+
+	// 初始化字幕服務
+	c.CaptionService = services.NewCaptionService(c.Config, c.VideoService.S3Storage)
+	c.VideoKeyService = services.NewVideoKeyService(c.Config)
+	c.BrandingService = services.NewBrandingService(c.Config, c.VideoService.S3Storage)
+
 	// 初始化支付服務
 	c.PaymentService = services.NewPaymentService(c.Config)
+	c.PaymentService.SetNotificationService(c.NotificationService)
+	c.PaymentService.SetMessaging(c.Messaging)
 
 	// 初始化公開流服務
 	if redisCache, ok := c.Cache.(*utils.RedisCache); ok {
@@ -197,8 +294,6 @@ func (c *Container) initServices() error {
 		c.PublicStreamService = publicStreamService
 	}
 
-
-
 	return nil
 }
 
@@ -209,6 +304,18 @@ func (c *Container) initHandlers() error {
 
 	// 初始化影片處理器
 	c.VideoHandler = api.NewVideoHandler(c.VideoService)
+	c.VideoUploadHandler = api.NewVideoUploadHandler(c.VideoUploadService)
+	c.VideoDuplicateHandler = api.NewVideoDuplicateHandler(c.VideoDuplicateService)
+	c.VideoTrashHandler = api.NewVideoTrashHandler(c.VideoTrashService)
+	c.VideoVisibilityHandler = api.NewVideoVisibilityHandler(c.VideoVisibilityService)
+	c.VideoReactionHandler = api.NewVideoReactionHandler(c.VideoReactionService)
+	c.VideoCommentHandler = api.NewVideoCommentHandler(c.VideoCommentService)
+	c.FollowHandler = api.NewFollowHandler(c.FollowService, c.FeedService)
+	c.NotificationHandler = api.NewNotificationHandler(c.NotificationService)
+	c.WebhookHandler = api.NewWebhookHandler(c.WebhookService)
+	c.ConversationHandler = api.NewConversationHandler(c.ConversationService)
+	c.FriendHandler = api.NewFriendHandler(c.FriendService)
+	c.CallHandler = api.NewCallHandler(c.CallService)
 
 	// 初始化直播處理器
 	c.LiveHandler = api.NewLiveHandler(c.LiveService)
@@ -216,6 +323,17 @@ func (c *Container) initHandlers() error {
 	// 初始化直播間處理器
 	c.LiveRoomHandler = api.NewLiveRoomHandler(c.LiveRoomService)
 
+	// 初始化多平台轉推處理器
+	c.RestreamHandler = api.NewRestreamHandler(c.RestreamService)
+
+	// 初始化精華片段處理器
+	c.ClipHandler = api.NewClipHandler(c.ClipService)
+
+	// 初始化字幕處理器
+	c.CaptionHandler = api.NewCaptionHandler(c.CaptionService)
+	c.VideoKeyHandler = api.NewVideoKeyHandler(c.VideoKeyService)
+	c.BrandingHandler = api.NewBrandingHandler(c.BrandingService)
+
 	// 初始化支付處理器
 	c.PaymentHandler = api.NewPaymentHandler(c.PaymentService)
 
@@ -238,6 +356,15 @@ func (c *Container) initWebSocket() error {
 	// 初始化直播間 WebSocket Handler
 	c.LiveRoomWSHandler = ws.NewLiveRoomHandler(c.JWTUtil)
 
+	// 初始化串流上傳進度 WebSocket Handler
+	c.UploadWSHandler = ws.NewUploadProgressHandler(c.JWTUtil, c.Messaging)
+
+	// 初始化站內通知 WebSocket Handler
+	c.NotificationWSHandler = ws.NewNotificationHandler(c.JWTUtil, c.Messaging)
+
+	// 初始化私訊 WebSocket Handler
+	c.ChatWSHandler = ws.NewChatHandler(c.JWTUtil, c.Messaging)
+
 	return nil
 }
 
@@ -248,6 +375,46 @@ func (c *Container) StartServices() {
 		c.LiveRoomSyncService.Start()
 	}
 
+	// 啟動預約直播排程服務
+	if c.LiveRoomScheduler != nil {
+		c.LiveRoomScheduler.Start()
+	}
+
+	// 啟動影片垃圾回收服務
+	if c.VideoTrashService != nil {
+		c.VideoTrashService.Start()
+	}
+
+	// 啟動影片反應計數對帳服務
+	if c.VideoReactionService != nil {
+		c.VideoReactionService.Start()
+	}
+
+	// 啟動追蹤動態擴散服務
+	if c.FeedService != nil {
+		c.FeedService.Start()
+	}
+
+	// 啟動站內通知服務
+	if c.NotificationService != nil {
+		c.NotificationService.Start()
+	}
+
+	// 啟動對外 Webhook 投遞服務
+	if c.WebhookService != nil {
+		c.WebhookService.Start()
+	}
+
+	// 啟動上線狀態心跳
+	if c.PresenceService != nil {
+		c.PresenceService.Start()
+	}
+
+	// 啟動通話響鈴逾時與斷線檢查
+	if c.CallService != nil {
+		c.CallService.Start()
+	}
+
 	// WebSocket Hub 不需要額外啟動，會在需要時自動創建房間
 }
 
@@ -258,6 +425,51 @@ func (c *Container) StopServices() {
 		c.LiveRoomSyncService.Stop()
 	}
 
+	// 停止預約直播排程服務
+	if c.LiveRoomScheduler != nil {
+		c.LiveRoomScheduler.Stop()
+	}
+
+	// 停止影片垃圾回收服務
+	if c.VideoTrashService != nil {
+		c.VideoTrashService.Stop()
+	}
+
+	// 停止影片反應計數對帳服務
+	if c.VideoReactionService != nil {
+		c.VideoReactionService.Stop()
+	}
+
+	// 停止追蹤動態擴散服務
+	if c.FeedService != nil {
+		c.FeedService.Stop()
+	}
+
+	// 停止站內通知服務
+	if c.NotificationService != nil {
+		c.NotificationService.Stop()
+	}
+
+	// 停止對外 Webhook 投遞服務
+	if c.WebhookService != nil {
+		c.WebhookService.Stop()
+	}
+
+	// 停止通話檢查
+	if c.CallService != nil {
+		c.CallService.Stop()
+	}
+
+	// 停止上線狀態心跳並移除本實例的在線記錄
+	if c.PresenceService != nil {
+		c.PresenceService.Stop()
+	}
+
+	// 停止所有轉推
+	if c.RestreamService != nil {
+		c.RestreamService.Stop()
+	}
+
 	// 關閉 WebSocket Hub
 	if c.Hub != nil {
 		c.Hub.Close()
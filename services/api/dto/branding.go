@@ -0,0 +1,41 @@
+package dto
+
+import "time"
+
+// UserBrandingDTO 創作者品牌設定資料傳輸物件
+type UserBrandingDTO struct {
+	Enabled           bool      `json:"enabled"`
+	WatermarkKey      string    `json:"watermark_key"`
+	WatermarkURL      string    `json:"watermark_url,omitempty"`
+	WatermarkPosition string    `json:"watermark_position"`
+	WatermarkOpacity  float64   `json:"watermark_opacity"`
+	WatermarkScale    float64   `json:"watermark_scale"`
+	IntroVideoID      *uint     `json:"intro_video_id"`
+	OutroVideoID      *uint     `json:"outro_video_id"`
+	UpdatedAt         time.Time `json:"updated_at"`
+}
+
+// UserBrandingUpdateDTO 更新品牌設定請求，未提供的欄位維持原值
+// 片頭/片尾傳入 0 表示移除
+type UserBrandingUpdateDTO struct {
+	Enabled           *bool    `json:"enabled"`
+	WatermarkKey      *string  `json:"watermark_key"`
+	WatermarkPosition *string  `json:"watermark_position"`
+	WatermarkOpacity  *float64 `json:"watermark_opacity"`
+	WatermarkScale    *float64 `json:"watermark_scale"`
+	IntroVideoID      *uint    `json:"intro_video_id"`
+	OutroVideoID      *uint    `json:"outro_video_id"`
+}
+
+// BrandingWatermarkUploadRequestDTO 浮水印上傳URL請求
+type BrandingWatermarkUploadRequestDTO struct {
+	Filename string `json:"filename" binding:"required"`
+	FileSize int64  `json:"file_size" binding:"required,min=1,max=2097152"`
+}
+
+// BrandingWatermarkUploadDTO 浮水印上傳URL響應，上傳完成後以 key 更新 watermark_key
+type BrandingWatermarkUploadDTO struct {
+	UploadURL string            `json:"upload_url"`
+	FormData  map[string]string `json:"form_data"`
+	Key       string            `json:"key"`
+}
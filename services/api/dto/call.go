@@ -0,0 +1,67 @@
+package dto
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// CallParticipantDTO 通話參與者
+// status: invited（響鈴中）, joined, declined, missed, left, busy（正在其他通話中）
+type CallParticipantDTO struct {
+	UserID   uint       `json:"user_id"`
+	Username string     `json:"username,omitempty"`
+	Status   string     `json:"status"`
+	JoinedAt *time.Time `json:"joined_at,omitempty"`
+	LeftAt   *time.Time `json:"left_at,omitempty"`
+}
+
+// CallDTO 通話狀態，進行中與歷史紀錄共用
+// status: ringing, active, completed, missed, rejected, cancelled
+type CallDTO struct {
+	CallID         string                `json:"call_id"`
+	ConversationID uint                  `json:"conversation_id"`
+	CallerID       uint                  `json:"caller_id"`
+	Type           string                `json:"type"`
+	Status         string                `json:"status"`
+	Participants   []*CallParticipantDTO `json:"participants"`
+	CreatedAt      time.Time             `json:"created_at"`
+	AnsweredAt     *time.Time            `json:"answered_at,omitempty"`
+	EndedAt        *time.Time            `json:"ended_at,omitempty"`
+	Duration       int                   `json:"duration"` // 秒
+}
+
+// CallInviteDTO 發起通話
+type CallInviteDTO struct {
+	ConversationID uint   `json:"conversation_id" binding:"required"`
+	Type           string `json:"type" binding:"required,oneof=audio video"`
+}
+
+// CallSignalDTO 轉發給通話中其他成員的 WebRTC 信令
+// kind: offer, answer, ice；payload 為 SDP 或 ICE candidate，伺服器不解析
+type CallSignalDTO struct {
+	CallID   string          `json:"call_id"`
+	ToUserID uint            `json:"to_user_id"`
+	Kind     string          `json:"kind"`
+	Payload  json.RawMessage `json:"payload"`
+}
+
+// CallSignalEventDTO 推送給接收方的信令
+type CallSignalEventDTO struct {
+	CallID     string          `json:"call_id"`
+	FromUserID uint            `json:"from_user_id"`
+	Kind       string          `json:"kind"`
+	Payload    json.RawMessage `json:"payload"`
+}
+
+// ICEServerDTO WebRTC RTCIceServer 設定
+type ICEServerDTO struct {
+	URLs       []string `json:"urls"`
+	Username   string   `json:"username,omitempty"`
+	Credential string   `json:"credential,omitempty"`
+}
+
+// ICEServersDTO STUN/TURN 伺服器與有時效的 TURN 憑證
+type ICEServersDTO struct {
+	ICEServers []ICEServerDTO `json:"ice_servers"`
+	ExpiresAt  time.Time      `json:"expires_at"`
+}
@@ -0,0 +1,55 @@
+package dto
+
+import "time"
+
+// VideoCommentDTO 影片留言
+type VideoCommentDTO struct {
+	ID               uint       `json:"id"`
+	VideoID          uint       `json:"video_id"`
+	ParentID         *uint      `json:"parent_id,omitempty"`
+	UserID           uint       `json:"user_id"`
+	Username         string     `json:"username"`
+	Avatar           string     `json:"avatar,omitempty"`
+	ReplyToUserID    *uint      `json:"reply_to_user_id,omitempty"`
+	ReplyToUsername  string     `json:"reply_to_username,omitempty"`
+	Content          string     `json:"content"`
+	TimestampSeconds *int       `json:"timestamp_seconds,omitempty"`
+	TimestampLabel   string     `json:"timestamp_label,omitempty"` // 例如 02:31
+	Pinned           bool       `json:"pinned"`
+	Status           string     `json:"status"`
+	Likes            int64      `json:"likes"`
+	ReplyCount       int64      `json:"reply_count"`
+	LikedByMe        bool       `json:"liked_by_me"`
+	EditedAt         *time.Time `json:"edited_at,omitempty"`
+	CreatedAt        time.Time  `json:"created_at"`
+}
+
+// VideoCommentListDTO 留言列表，next_cursor 為空時表示沒有下一頁
+type VideoCommentListDTO struct {
+	Items      []*VideoCommentDTO `json:"items"`
+	NextCursor string             `json:"next_cursor,omitempty"`
+}
+
+// VideoCommentCreateDTO 新增留言或回覆請求
+type VideoCommentCreateDTO struct {
+	Content          string `json:"content" binding:"required"`
+	ParentID         *uint  `json:"parent_id"`
+	TimestampSeconds *int   `json:"timestamp_seconds" binding:"omitempty,min=0"`
+}
+
+// VideoCommentUpdateDTO 編輯留言請求
+type VideoCommentUpdateDTO struct {
+	Content string `json:"content" binding:"required"`
+}
+
+// VideoCommentModerateDTO 審核留言請求
+type VideoCommentModerateDTO struct {
+	Status string `json:"status" binding:"required,oneof=visible hidden"`
+}
+
+// VideoCommentListQueryDTO 留言列表查詢參數
+type VideoCommentListQueryDTO struct {
+	Sort   string `form:"sort" binding:"omitempty,oneof=top newest"`
+	Cursor string `form:"cursor"`
+	Limit  int    `form:"limit" binding:"omitempty,min=1,max=100"`
+}
@@ -0,0 +1,172 @@
+package dto
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// ConversationDTO 私訊會話
+type ConversationDTO struct {
+	ID            uint                     `json:"id"`
+	Type          string                   `json:"type"`
+	Name          string                   `json:"name,omitempty"`
+	AvatarURL     string                   `json:"avatar_url,omitempty"`
+	Members       []*ConversationMemberDTO `json:"members"`
+	LastMessage   *MessageDTO              `json:"last_message"`
+	LastMessageAt *time.Time               `json:"last_message_at"`
+	UnreadCount   int64                    `json:"unread_count"`
+	CreatedAt     time.Time                `json:"created_at"`
+}
+
+// ConversationMemberDTO 會話成員與其回條
+type ConversationMemberDTO struct {
+	UserID                 uint   `json:"user_id"`
+	Username               string `json:"username"`
+	Avatar                 string `json:"avatar"`
+	Role                   string `json:"role"`
+	LastDeliveredMessageID uint   `json:"last_delivered_message_id"`
+	LastReadMessageID      uint   `json:"last_read_message_id"`
+}
+
+// MessageDTO 私訊訊息，status 只在自己發送的訊息上提供：sent, delivered, read
+type MessageDTO struct {
+	ID             uint                  `json:"id"`
+	ConversationID uint                  `json:"conversation_id"`
+	SenderID       uint                  `json:"sender_id"`
+	Type           string                `json:"type"`
+	Content        string                `json:"content"`
+	Data           json.RawMessage       `json:"data,omitempty"`       // 系統訊息的異動內容
+	Attachment     *MessageAttachmentDTO `json:"attachment,omitempty"` // 圖片、影片與檔案訊息的附件
+	ClientID       string                `json:"client_id,omitempty"`
+	Status         string                `json:"status,omitempty"`
+	CreatedAt      time.Time             `json:"created_at"`
+}
+
+// MessageAttachmentDTO 訊息附件，url 與 thumbnail_url 為到 url_expires_at 前有效的預簽名下載URL
+type MessageAttachmentDTO struct {
+	Kind         string    `json:"kind"`
+	Filename     string    `json:"filename"`
+	ContentType  string    `json:"content_type"`
+	Size         int64     `json:"size"`
+	Width        int       `json:"width,omitempty"`
+	Height       int       `json:"height,omitempty"`
+	Duration     float64   `json:"duration,omitempty"`
+	URL          string    `json:"url"`
+	ThumbnailURL string    `json:"thumbnail_url,omitempty"`
+	URLExpiresAt time.Time `json:"url_expires_at"`
+}
+
+// MessageListDTO 訊息列表，next_cursor 為空時表示沒有更早的訊息
+type MessageListDTO struct {
+	Messages   []*MessageDTO `json:"messages"`
+	NextCursor string        `json:"next_cursor"`
+}
+
+// MessageListQueryDTO 訊息列表查詢參數，after 用於斷線後補抓之後的訊息（由舊到新）
+type MessageListQueryDTO struct {
+	Cursor string `form:"cursor"`
+	After  uint   `form:"after"`
+	Limit  int    `form:"limit"`
+}
+
+// ConversationCreateDTO 開啟一對一會話請求
+type ConversationCreateDTO struct {
+	UserID uint `json:"user_id" binding:"required"`
+}
+
+// MessageSendDTO 發送訊息請求，附帶附件時 content 為說明文字，可以為空
+type MessageSendDTO struct {
+	Content    string                     `json:"content"`
+	ClientID   string                     `json:"client_id" binding:"max=64"`
+	Attachment *MessageAttachmentInputDTO `json:"attachment"`
+}
+
+// MessageAttachmentInputDTO 發送附件，key 為上傳URL回傳的 key
+// 圖片的寬高由伺服器讀取，影片的寬高與長度由客戶端提供
+type MessageAttachmentInputDTO struct {
+	Key      string  `json:"key" binding:"required"`
+	Filename string  `json:"filename"`
+	Width    int     `json:"width" binding:"min=0"`
+	Height   int     `json:"height" binding:"min=0"`
+	Duration float64 `json:"duration" binding:"min=0"`
+}
+
+// AttachmentUploadRequestDTO 附件上傳URL請求
+type AttachmentUploadRequestDTO struct {
+	Filename string `json:"filename" binding:"required"`
+	Size     int64  `json:"size" binding:"required,min=1"`
+}
+
+// AttachmentUploadDTO 附件上傳URL響應，上傳完成後以 key 發送訊息
+type AttachmentUploadDTO struct {
+	Kind      string            `json:"kind"`
+	UploadURL string            `json:"upload_url"`
+	FormData  map[string]string `json:"form_data"`
+	Key       string            `json:"key"`
+}
+
+// MessageReceiptDTO 送達或已讀回條請求
+type MessageReceiptDTO struct {
+	MessageID uint `json:"message_id" binding:"required"`
+}
+
+// TypingDTO 輸入中狀態請求
+type TypingDTO struct {
+	Typing bool `json:"typing"`
+}
+
+// GroupCreateDTO 建立群組請求
+type GroupCreateDTO struct {
+	Name      string `json:"name" binding:"required,max=100"`
+	MemberIDs []uint `json:"member_ids"`
+}
+
+// GroupUpdateDTO 更新群組請求，avatar_key 為上傳頭像後取得的 key，空字串表示移除
+type GroupUpdateDTO struct {
+	Name      *string `json:"name" binding:"omitempty,max=100"`
+	AvatarKey *string `json:"avatar_key"`
+}
+
+// GroupAvatarUploadRequestDTO 群組頭像上傳URL請求
+type GroupAvatarUploadRequestDTO struct {
+	Filename string `json:"filename" binding:"required"`
+}
+
+// GroupAvatarUploadDTO 群組頭像上傳URL響應，上傳完成後以 key 更新 avatar_key
+type GroupAvatarUploadDTO struct {
+	UploadURL string            `json:"upload_url"`
+	FormData  map[string]string `json:"form_data"`
+	Key       string            `json:"key"`
+}
+
+// GroupMembersAddDTO 加入群組成員請求
+type GroupMembersAddDTO struct {
+	UserIDs []uint `json:"user_ids" binding:"required,min=1"`
+}
+
+// GroupMemberRoleDTO 變更成員角色請求
+type GroupMemberRoleDTO struct {
+	Role string `json:"role" binding:"required,oneof=admin member"`
+}
+
+// GroupInviteCreateDTO 建立邀請連結請求，expires_in_hours 為 0 時使用預設有效時數，max_uses 為 0 時不限次數
+type GroupInviteCreateDTO struct {
+	ExpiresInHours int `json:"expires_in_hours" binding:"min=0"`
+	MaxUses        int `json:"max_uses" binding:"min=0"`
+}
+
+// GroupInviteDTO 群組邀請連結
+type GroupInviteDTO struct {
+	ID        uint       `json:"id"`
+	Code      string     `json:"code"`
+	CreatedBy uint       `json:"created_by"`
+	MaxUses   int        `json:"max_uses"`
+	Uses      int        `json:"uses"`
+	ExpiresAt *time.Time `json:"expires_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// GroupJoinDTO 以邀請連結加入群組請求
+type GroupJoinDTO struct {
+	Code string `json:"code" binding:"required"`
+}
@@ -0,0 +1,43 @@
+package dto
+
+import "time"
+
+// FollowStatusDTO 追蹤狀態與對方的追蹤統計
+type FollowStatusDTO struct {
+	UserID         uint  `json:"user_id"`
+	Following      bool  `json:"following"`
+	FollowerCount  int64 `json:"follower_count"`
+	FollowingCount int64 `json:"following_count"`
+}
+
+// FollowUserDTO 追隨者或追蹤中的用戶
+type FollowUserDTO struct {
+	ID         uint      `json:"id"`
+	Username   string    `json:"username"`
+	Avatar     string    `json:"avatar"`
+	FollowedAt time.Time `json:"followed_at"`
+}
+
+// FeedItemDTO 追蹤動態項目，type 為 video 或 live
+type FeedItemDTO struct {
+	Type        string           `json:"type"`
+	PublishedAt time.Time        `json:"published_at"`
+	Video       *VideoDTO        `json:"video,omitempty"`
+	LiveRoom    *FeedLiveRoomDTO `json:"live_room,omitempty"`
+}
+
+// FeedLiveRoomDTO 動態中正在直播的直播間
+type FeedLiveRoomDTO struct {
+	ID          string    `json:"id"`
+	Title       string    `json:"title"`
+	Description string    `json:"description"`
+	CreatorID   uint      `json:"creator_id"`
+	ViewerCount int       `json:"viewer_count"`
+	StartedAt   time.Time `json:"started_at"`
+}
+
+// FeedDTO 追蹤動態，next_cursor 為空時表示沒有下一頁
+type FeedDTO struct {
+	Items      []*FeedItemDTO `json:"items"`
+	NextCursor string         `json:"next_cursor,omitempty"`
+}
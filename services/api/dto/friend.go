@@ -0,0 +1,37 @@
+package dto
+
+import "time"
+
+// FriendStatusDTO 與用戶的好友關係
+// status: none, friends, pending_outgoing（已送出邀請）, pending_incoming（收到邀請）, blocked（已封鎖對方）
+type FriendStatusDTO struct {
+	UserID uint   `json:"user_id"`
+	Status string `json:"status"`
+}
+
+// FriendDTO 好友與其上線狀態
+type FriendDTO struct {
+	ID         uint       `json:"id"`
+	Username   string     `json:"username"`
+	Avatar     string     `json:"avatar"`
+	Online     bool       `json:"online"`
+	LastSeenAt *time.Time `json:"last_seen_at,omitempty"`
+	FriendedAt time.Time  `json:"friended_at"`
+}
+
+// FriendRequestDTO 收到或送出的好友邀請
+type FriendRequestDTO struct {
+	ID          uint      `json:"id"`
+	Username    string    `json:"username"`
+	Avatar      string    `json:"avatar"`
+	Direction   string    `json:"direction"` // incoming, outgoing
+	RequestedAt time.Time `json:"requested_at"`
+}
+
+// BlockedUserDTO 已封鎖的用戶
+type BlockedUserDTO struct {
+	ID        uint      `json:"id"`
+	Username  string    `json:"username"`
+	Avatar    string    `json:"avatar"`
+	BlockedAt time.Time `json:"blocked_at"`
+}
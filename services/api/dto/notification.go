@@ -0,0 +1,43 @@
+package dto
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// NotificationDTO 站內通知
+type NotificationDTO struct {
+	ID        uint            `json:"id"`
+	Type      string          `json:"type"`
+	Title     string          `json:"title"`
+	Content   string          `json:"content"`
+	Data      json.RawMessage `json:"data"`
+	Read      bool            `json:"read"`
+	ReadAt    *time.Time      `json:"read_at"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+// NotificationListDTO 通知列表，next_cursor 為空時表示沒有下一頁
+type NotificationListDTO struct {
+	Notifications []*NotificationDTO `json:"notifications"`
+	UnreadCount   int64              `json:"unread_count"`
+	NextCursor    string             `json:"next_cursor"`
+}
+
+// NotificationListQueryDTO 通知列表查詢參數
+type NotificationListQueryDTO struct {
+	Cursor     string `form:"cursor"`
+	Limit      int    `form:"limit"`
+	UnreadOnly bool   `form:"unread_only"`
+}
+
+// NotificationPreferenceDTO 通知類型的接收設定
+type NotificationPreferenceDTO struct {
+	Type    string `json:"type" binding:"required"`
+	Enabled bool   `json:"enabled"`
+}
+
+// NotificationPreferencesUpdateDTO 更新通知偏好請求
+type NotificationPreferencesUpdateDTO struct {
+	Preferences []NotificationPreferenceDTO `json:"preferences" binding:"required,min=1,dive"`
+}
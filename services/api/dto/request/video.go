@@ -12,13 +12,6 @@ type UpdateVideoRequest struct {
 	Description string `json:"description" binding:"omitempty,max=500"`
 }
 
-// SearchVideoRequest 搜尋影片請求
-type SearchVideoRequest struct {
-	Query  string `form:"q" binding:"required"`
-	Offset int    `form:"offset" binding:"min=0"`
-	Limit  int    `form:"limit" binding:"min=1,max=50"`
-}
-
 // GenerateUploadURLRequest 生成上傳URL請求
 type GenerateUploadURLRequest struct {
 	Filename    string `json:"filename" binding:"required"`
@@ -15,6 +15,10 @@ type UserResponse struct {
 	Status    string    `json:"status"`
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
+
+	// 追蹤統計
+	FollowerCount  int64 `json:"follower_count"`
+	FollowingCount int64 `json:"following_count"`
 }
 
 // LoginResponse 登入回應
@@ -46,13 +50,15 @@ func NewUserResponse(user interface{}) *UserResponse {
 	// 類型斷言 - 處理 *dto.UserDTO
 	if u, ok := user.(*dto.UserDTO); ok {
 		return &UserResponse{
-			ID:        u.ID,
-			Username:  u.Username,
-			Email:     u.Email,
-			Role:      "user",   // 默認角色
-			Status:    "active", // 默認狀態
-			CreatedAt: u.CreatedAt,
-			UpdatedAt: u.UpdatedAt,
+			ID:             u.ID,
+			Username:       u.Username,
+			Email:          u.Email,
+			Role:           "user",   // 默認角色
+			Status:         "active", // 默認狀態
+			CreatedAt:      u.CreatedAt,
+			UpdatedAt:      u.UpdatedAt,
+			FollowerCount:  u.FollowerCount,
+			FollowingCount: u.FollowingCount,
 		}
 	}
 
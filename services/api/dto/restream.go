@@ -0,0 +1,34 @@
+package dto
+
+import "time"
+
+// RestreamTargetDTO 轉推目標資料傳輸物件
+type RestreamTargetDTO struct {
+	ID        uint      `json:"id"`
+	RoomID    string    `json:"room_id"`
+	Name      string    `json:"name"`
+	URL       string    `json:"url"`
+	StreamKey string    `json:"stream_key"` // 已遮罩，僅顯示末四碼
+	Enabled   bool      `json:"enabled"`
+	Status    string    `json:"status"`
+	Retries   int       `json:"retries"`
+	LastError string    `json:"last_error"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// RestreamTargetCreateDTO 新增轉推目標請求
+type RestreamTargetCreateDTO struct {
+	Name      string `json:"name" binding:"required,max=100"`
+	URL       string `json:"url" binding:"required,max=500"`
+	StreamKey string `json:"stream_key" binding:"required"`
+	Enabled   *bool  `json:"enabled"`
+}
+
+// RestreamTargetUpdateDTO 更新轉推目標請求
+type RestreamTargetUpdateDTO struct {
+	Name      string `json:"name" binding:"omitempty,max=100"`
+	URL       string `json:"url" binding:"omitempty,max=500"`
+	StreamKey string `json:"stream_key"`
+	Enabled   *bool  `json:"enabled"`
+}
@@ -11,6 +11,10 @@ type UserDTO struct {
 	Bio       string    `json:"bio"`
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
+
+	// 追蹤統計
+	FollowerCount  int64 `json:"follower_count"`
+	FollowingCount int64 `json:"following_count"`
 }
 
 // UserRegisterDTO 用戶註冊請求
@@ -16,23 +16,59 @@ type VideoDTO struct {
 	HLSMasterURL string `json:"hls_master_url"`
 	MP4URL       string `json:"mp4_url"`
 
+	// 封裝格式與 DASH 清單（僅 cmaf 封裝）
+	Packaging       string `json:"packaging"`
+	DASHManifestURL string `json:"dash_manifest_url,omitempty"`
+
+	// HLS 分段已加密，播放前需取得播放憑證
+	Encrypted bool `json:"encrypted"`
+
+	// 轉碼時套用的品牌元素：watermark, intro, outro
+	BrandingApplied []string `json:"branding_applied,omitempty"`
+
 	// 影片屬性
 	Duration       int    `json:"duration"`
 	FileSize       int64  `json:"file_size"`
 	OriginalFormat string `json:"original_format"`
 
+	// 重複上傳：status 為 duplicate 時可選擇共用 duplicate_of_id 的轉碼結果或重新轉碼
+	DuplicateOfID    *uint  `json:"duplicate_of_id,omitempty"`
+	DuplicateMatch   string `json:"duplicate_match,omitempty"`
+	RenditionsFromID *uint  `json:"renditions_from_id,omitempty"`
+
+	// 可見度（public, unlisted, private, followers）
+	Visibility string `json:"visibility"`
+
 	// 狀態相關
 	Status             string `json:"status"`
 	ProcessingProgress int    `json:"processing_progress"`
 	ErrorMessage       string `json:"error_message,omitempty"`
 
 	// 統計資料
-	Views int64 `json:"views"`
-	Likes int64 `json:"likes"`
+	Views    int64 `json:"views"`
+	Likes    int64 `json:"likes"`
+	Dislikes int64 `json:"dislikes"`
+
+	// 檢視者自己的反應
+	LikedByMe  bool   `json:"liked_by_me"`
+	MyReaction string `json:"my_reaction,omitempty"`
 
 	// 品質資訊
 	Qualities []VideoQualityDTO `json:"qualities,omitempty"`
 
+	// 音軌資訊
+	AudioTracks    []VideoAudioTrackDTO `json:"audio_tracks,omitempty"`
+	AudioLanguages []string             `json:"audio_languages,omitempty"`
+
+	// 拖曳預覽縮圖
+	Preview *VideoPreviewDTO `json:"preview,omitempty"`
+
+	// 字幕軌（僅已完成處理的字幕）
+	Captions []VideoCaptionDTO `json:"captions,omitempty"`
+
+	// 精華片段來源（僅剪輯產生的影片）
+	Clip *VideoClipDTO `json:"clip,omitempty"`
+
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 }
@@ -49,6 +85,85 @@ type VideoQualityDTO struct {
 	Status   string `json:"status"`
 }
 
+// VideoAudioTrackDTO 影片音軌資料傳輸物件
+type VideoAudioTrackDTO struct {
+	ID          uint   `json:"id"`
+	Language    string `json:"language"`
+	Label       string `json:"label"`
+	Channels    int    `json:"channels"`
+	Bitrate     int    `json:"bitrate"`
+	IsDefault   bool   `json:"is_default"`
+	PlaylistURL string `json:"playlist_url"`
+}
+
+// VideoPreviewDTO 進度條拖曳預覽資訊
+// VTT 中每個時間區段以 sprite_xxx.jpg#xywh=x,y,w,h 指向雪碧圖上的位置
+type VideoPreviewDTO struct {
+	VTTURL     string   `json:"vtt_url"`
+	SpriteURLs []string `json:"sprite_urls"`
+	Interval   int      `json:"interval"` // 秒
+}
+
+// VideoCaptionDTO 字幕軌資訊
+type VideoCaptionDTO struct {
+	ID           uint      `json:"id"`
+	Language     string    `json:"language"`
+	Label        string    `json:"label"`
+	Format       string    `json:"format"`
+	IsDefault    bool      `json:"is_default"`
+	Status       string    `json:"status"`
+	VTTURL       string    `json:"vtt_url,omitempty"`
+	PlaylistURL  string    `json:"playlist_url,omitempty"`
+	ErrorMessage string    `json:"error_message,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// VideoCaptionCreateDTO 新增字幕請求，回應預簽名上傳URL
+type VideoCaptionCreateDTO struct {
+	Language  string `json:"language" binding:"required,max=35"`
+	Label     string `json:"label" binding:"required,max=100"`
+	Filename  string `json:"filename" binding:"required"`
+	FileSize  int64  `json:"file_size" binding:"required,min=1,max=5242880"`
+	IsDefault bool   `json:"is_default"`
+}
+
+// VideoCaptionUpdateDTO 更新字幕請求，提供 filename 時會重新上傳字幕檔
+type VideoCaptionUpdateDTO struct {
+	Label     *string `json:"label" binding:"omitempty,max=100"`
+	IsDefault *bool   `json:"is_default"`
+	Filename  string  `json:"filename"`
+	FileSize  int64   `json:"file_size" binding:"omitempty,min=1,max=5242880"`
+}
+
+// VideoCaptionUploadDTO 字幕上傳URL響應
+type VideoCaptionUploadDTO struct {
+	UploadURL string            `json:"upload_url"`
+	FormData  map[string]string `json:"form_data"`
+	Key       string            `json:"key"`
+	Caption   *VideoCaptionDTO  `json:"caption"`
+}
+
+// VideoClipDTO 精華片段來源資訊
+type VideoClipDTO struct {
+	SourceType          string  `json:"source_type"` // video, live
+	SourceVideoID       *uint   `json:"source_video_id,omitempty"`
+	SourceLiveSessionID *uint   `json:"source_live_session_id,omitempty"`
+	SourceRoomID        string  `json:"source_room_id,omitempty"`
+	StartTime           float64 `json:"start_time"`
+	EndTime             float64 `json:"end_time"`
+	CutMode             string  `json:"cut_mode,omitempty"`
+}
+
+// VideoClipCreateDTO 建立精華片段請求
+// 影片來源的 start/end 為影片內的秒數；直播來源為相對開播時間的秒數
+type VideoClipCreateDTO struct {
+	Title       string  `json:"title" binding:"omitempty,max=100"`
+	Description string  `json:"description" binding:"omitempty,max=500"`
+	Start       float64 `json:"start" binding:"min=0"`
+	End         float64 `json:"end" binding:"required,gtfield=Start"`
+}
+
 // VideoCreateDTO 建立影片請求
 type VideoCreateDTO struct {
 	Title       string `json:"title" binding:"required,max=100"`
@@ -86,8 +201,119 @@ type VideoListDTO struct {
 
 // VideoSearchDTO 搜尋影片請求
 type VideoSearchDTO struct {
-	Query  string `form:"q" binding:"required"`
+	Query  string `form:"q" binding:"required,max=100"`
 	Offset int    `form:"offset" binding:"min=0"`
-	Limit  int    `form:"limit" binding:"min=1,max=50"`
-	Status string `form:"status"`
+	Limit  int    `form:"limit" binding:"omitempty,min=1,max=50"`
+	Status string `form:"status" binding:"omitempty,oneof=ready processing transcoding"`
+
+	// 篩選：duration 為長度分類（short < 4 分鐘, medium 4-20 分鐘, long > 20 分鐘），可另以秒數指定範圍
+	Duration    string `form:"duration" binding:"omitempty,oneof=short medium long"`
+	MinDuration int    `form:"min_duration" binding:"min=0"`
+	MaxDuration int    `form:"max_duration" binding:"min=0"`
+	UploadDate  string `form:"upload_date" binding:"omitempty,oneof=today week month year"`
+	UserID      uint   `form:"user_id"`
+
+	// 排序：relevance（預設）, views, likes, newest
+	Sort string `form:"sort" binding:"omitempty,oneof=relevance views likes newest"`
+}
+
+// VideoSearchResultDTO 搜尋結果與分面統計
+type VideoSearchResultDTO struct {
+	Total  int64                 `json:"total"`
+	Items  []*VideoDTO           `json:"items"`
+	Facets *VideoSearchFacetsDTO `json:"facets"`
+}
+
+// VideoSearchFacetsDTO 搜尋結果分面，每個維度的數量套用其他維度的篩選
+type VideoSearchFacetsDTO struct {
+	Duration   []SearchFacetDTO  `json:"duration"`
+	UploadDate []SearchFacetDTO  `json:"upload_date"`
+	Status     []SearchFacetDTO  `json:"status"`
+	Creators   []CreatorFacetDTO `json:"creators"`
+}
+
+// SearchFacetDTO 分面選項與數量
+type SearchFacetDTO struct {
+	Value string `json:"value"`
+	Count int64  `json:"count"`
+}
+
+// CreatorFacetDTO 創作者分面
+type CreatorFacetDTO struct {
+	UserID   uint   `json:"user_id"`
+	Username string `json:"username"`
+	Count    int64  `json:"count"`
+}
+
+// VideoPlaybackTokenDTO 加密影片的播放憑證
+// 播放器向 key_url 取得金鑰時需以 token 查詢參數或 X-Playback-Token 標頭帶上憑證
+type VideoPlaybackTokenDTO struct {
+	Token     string    `json:"token"`
+	KeyURL    string    `json:"key_url"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// UploadProgressDTO 串流上傳進度，total 為請求長度（含表單欄位），percent 未知時為 -1
+type UploadProgressDTO struct {
+	UploadID string `json:"upload_id"`
+	Bytes    int64  `json:"bytes"`
+	Total    int64  `json:"total"`
+	Percent  int    `json:"percent"`
+}
+
+// VideoStreamUploadDTO 伺服器端串流上傳結果
+type VideoStreamUploadDTO struct {
+	UploadID string    `json:"upload_id"`
+	Video    *VideoDTO `json:"video"`
+	Size     int64     `json:"size"`
+	SHA256   string    `json:"sha256"`
+}
+
+// TrashedVideoDTO 垃圾桶中的影片，purge_at 之後將永久刪除
+type TrashedVideoDTO struct {
+	*VideoDTO
+	DeletedAt time.Time `json:"deleted_at"`
+	PurgeAt   time.Time `json:"purge_at"`
+}
+
+// VideoVisibilityDTO 影片可見度，share_slug 僅在不公開影片時回傳
+type VideoVisibilityDTO struct {
+	VideoID    uint   `json:"video_id"`
+	Visibility string `json:"visibility"`
+	ShareSlug  string `json:"share_slug,omitempty"`
+	SharePath  string `json:"share_path,omitempty"`
+}
+
+// VideoShareGrantDTO 私人影片的授權用戶
+type VideoShareGrantDTO struct {
+	UserID    uint      `json:"user_id"`
+	Username  string    `json:"username"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// VideoVisibilityUpdateDTO 更新影片可見度請求
+type VideoVisibilityUpdateDTO struct {
+	Visibility string `json:"visibility" binding:"required,oneof=public unlisted private followers"`
+}
+
+// VideoShareGrantCreateDTO 授權用戶觀看私人影片請求
+type VideoShareGrantCreateDTO struct {
+	UserID uint `json:"user_id" binding:"required"`
+}
+
+// VideoReactionSetDTO 設定影片反應請求
+type VideoReactionSetDTO struct {
+	Type  string `json:"type" binding:"required,oneof=like dislike emoji"`
+	Emoji string `json:"emoji"`
+}
+
+// VideoReactionSummaryDTO 影片反應數量與檢視者自己的反應
+type VideoReactionSummaryDTO struct {
+	VideoID    uint             `json:"video_id"`
+	Likes      int64            `json:"likes"`
+	Dislikes   int64            `json:"dislikes"`
+	Emojis     map[string]int64 `json:"emojis"`
+	LikedByMe  bool             `json:"liked_by_me"`
+	MyReaction string           `json:"my_reaction,omitempty"`
+	MyEmoji    string           `json:"my_emoji,omitempty"`
 }
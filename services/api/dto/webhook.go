@@ -0,0 +1,63 @@
+package dto
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// WebhookEndpointDTO Webhook 端點
+type WebhookEndpointDTO struct {
+	ID          uint      `json:"id"`
+	UserID      uint      `json:"user_id"`
+	URL         string    `json:"url"`
+	Events      []string  `json:"events"`
+	AllUsers    bool      `json:"all_users"`
+	Description string    `json:"description"`
+	Enabled     bool      `json:"enabled"`
+	Secret      string    `json:"secret,omitempty"` // 只在建立與更換金鑰時回傳
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// WebhookEndpointCreateDTO 建立 Webhook 端點請求
+type WebhookEndpointCreateDTO struct {
+	URL         string   `json:"url" binding:"required,url,max=2048"`
+	Events      []string `json:"events" binding:"required,min=1"`
+	AllUsers    bool     `json:"all_users"` // 接收所有用戶的事件，僅管理員可設定
+	Description string   `json:"description" binding:"max=200"`
+}
+
+// WebhookEndpointUpdateDTO 更新 Webhook 端點請求，未提供的欄位維持原值
+type WebhookEndpointUpdateDTO struct {
+	URL         *string  `json:"url" binding:"omitempty,url,max=2048"`
+	Events      []string `json:"events" binding:"omitempty,min=1"`
+	AllUsers    *bool    `json:"all_users"`
+	Description *string  `json:"description" binding:"omitempty,max=200"`
+	Enabled     *bool    `json:"enabled"`
+}
+
+// WebhookDeliveryDTO Webhook 投遞記錄，列表中不含 payload
+type WebhookDeliveryDTO struct {
+	ID             uint            `json:"id"`
+	EndpointID     uint            `json:"endpoint_id"`
+	EventID        string          `json:"event_id"`
+	EventType      string          `json:"event_type"`
+	Status         string          `json:"status"`
+	Attempts       int             `json:"attempts"`
+	ResponseStatus int             `json:"response_status"`
+	ResponseBody   string          `json:"response_body"`
+	Error          string          `json:"error"`
+	NextAttemptAt  *time.Time      `json:"next_attempt_at"`
+	DeliveredAt    *time.Time      `json:"delivered_at"`
+	RedeliveryOf   *uint           `json:"redelivery_of"`
+	CreatedAt      time.Time       `json:"created_at"`
+	Payload        json.RawMessage `json:"payload,omitempty"`
+}
+
+// WebhookEventDTO 投遞到端點的事件內容
+type WebhookEventDTO struct {
+	ID        string                 `json:"id"`
+	Type      string                 `json:"type"`
+	CreatedAt time.Time              `json:"created_at"`
+	Data      map[string]interface{} `json:"data"`
+}
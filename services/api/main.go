@@ -68,6 +68,23 @@ func main() {
 		container.LiveRoomHandler,
 		container.PaymentHandler,
 		container.PublicStreamHandler,
+		container.RestreamHandler,
+		container.ClipHandler,
+		container.CaptionHandler,
+		container.VideoKeyHandler,
+		container.BrandingHandler,
+		container.VideoUploadHandler,
+		container.VideoDuplicateHandler,
+		container.VideoTrashHandler,
+		container.VideoVisibilityHandler,
+		container.VideoReactionHandler,
+		container.VideoCommentHandler,
+		container.FollowHandler,
+		container.NotificationHandler,
+		container.WebhookHandler,
+		container.ConversationHandler,
+		container.FriendHandler,
+		container.CallHandler,
 		container.JWTUtil,
 	)
 
@@ -84,6 +101,21 @@ func main() {
 		r.GET("/ws/live-room/:roomID", container.LiveRoomWSHandler.ServeWS)
 	}
 
+	// 設置串流上傳進度 WebSocket 路由
+	if container.UploadWSHandler != nil {
+		r.GET("/ws/uploads/:uploadID", container.UploadWSHandler.ServeWS)
+	}
+
+	// 設置站內通知 WebSocket 路由
+	if container.NotificationWSHandler != nil {
+		r.GET("/ws/notifications", container.NotificationWSHandler.ServeWS)
+	}
+
+	// 設置私訊 WebSocket 路由
+	if container.ChatWSHandler != nil {
+		r.GET("/ws/chat", container.ChatWSHandler.ServeWS)
+	}
+
 	// 啟動服務器
 	addr := fmt.Sprintf(":%d", cfg.Gin.Port)
 	utils.LogInfo("🌐 HTTP 服務器啟動在 %s", addr)
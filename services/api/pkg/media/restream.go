@@ -0,0 +1,370 @@
+package media
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RelayStatus 轉推狀態
+type RelayStatus string
+
+const (
+	RelayStatusStarting RelayStatus = "starting"
+	RelayStatusRunning  RelayStatus = "running"
+	RelayStatusRetrying RelayStatus = "retrying"
+	RelayStatusFailed   RelayStatus = "failed"
+	RelayStatusStopped  RelayStatus = "stopped"
+)
+
+const (
+	// relayStableDuration 轉推持續超過此時間視為穩定，重試次數歸零
+	relayStableDuration = 30 * time.Second
+	// relayMaxBackoff 重試間隔上限
+	relayMaxBackoff = 2 * time.Minute
+	// relayErrorTailSize 保留的錯誤輸出長度
+	relayErrorTailSize = 500
+	// relayWaitDelay 停止轉推後等待輸出關閉的時間
+	relayWaitDelay = 5 * time.Second
+)
+
+// RestreamConfig 轉推監控配置
+type RestreamConfig struct {
+	FFmpegPath    string
+	MaxRetries    int
+	RetryInterval time.Duration
+}
+
+// RelayState 單一轉推目標狀態
+type RelayState struct {
+	TargetID  uint        `json:"target_id"`
+	GroupID   string      `json:"group_id"`
+	Status    RelayStatus `json:"status"`
+	Retries   int         `json:"retries"`
+	LastError string      `json:"last_error"`
+	StartedAt time.Time   `json:"started_at"`
+}
+
+// relay 單一轉推進程
+type relay struct {
+	state  RelayState
+	source string
+	dest   string
+	cancel context.CancelFunc
+	retry  chan struct{}
+	done   chan struct{}
+}
+
+// RestreamSupervisor 多平台轉推監控器
+// 每個目標各自執行一個 copy-codec 的 FFmpeg 轉推，失敗時獨立重試
+type RestreamSupervisor struct {
+	config   RestreamConfig
+	mu       sync.Mutex
+	relays   map[uint]*relay
+	onStatus func(RelayState)
+
+	// newCommand 建立轉推進程，測試時可替換
+	newCommand func(ctx context.Context, source, dest string) *exec.Cmd
+}
+
+// NewRestreamSupervisor 創建轉推監控器
+func NewRestreamSupervisor(config RestreamConfig) *RestreamSupervisor {
+	if config.FFmpegPath == "" {
+		config.FFmpegPath = "ffmpeg"
+	}
+	if config.RetryInterval <= 0 {
+		config.RetryInterval = 5 * time.Second
+	}
+
+	s := &RestreamSupervisor{
+		config: config,
+		relays: make(map[uint]*relay),
+	}
+	s.newCommand = s.ffmpegCommand
+	return s
+}
+
+// OnStatusChange 設置狀態變更回調
+func (s *RestreamSupervisor) OnStatusChange(fn func(RelayState)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.onStatus = fn
+}
+
+// StartRelay 啟動單一目標轉推，已在執行時直接返回
+func (s *RestreamSupervisor) StartRelay(groupID string, targetID uint, source, dest string) error {
+	if source == "" || dest == "" {
+		return fmt.Errorf("轉推來源或目標不能為空")
+	}
+
+	s.mu.Lock()
+	if r, exists := s.relays[targetID]; exists {
+		select {
+		case <-r.done:
+			// 已結束，可重新啟動
+		default:
+			s.mu.Unlock()
+			return nil
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	r := &relay{
+		state: RelayState{
+			TargetID:  targetID,
+			GroupID:   groupID,
+			Status:    RelayStatusStarting,
+			StartedAt: time.Now(),
+		},
+		source: source,
+		dest:   dest,
+		cancel: cancel,
+		retry:  make(chan struct{}, 1),
+		done:   make(chan struct{}),
+	}
+	s.relays[targetID] = r
+	s.mu.Unlock()
+
+	log.Printf("📡 啟動轉推: group=%s, target=%d", groupID, targetID)
+	go s.run(ctx, r)
+	return nil
+}
+
+// StopRelay 停止單一目標轉推
+func (s *RestreamSupervisor) StopRelay(targetID uint) {
+	s.mu.Lock()
+	r, exists := s.relays[targetID]
+	if exists {
+		delete(s.relays, targetID)
+	}
+	s.mu.Unlock()
+
+	if exists {
+		r.cancel()
+		<-r.done
+	}
+}
+
+// StopGroup 停止同一直播間的所有轉推
+func (s *RestreamSupervisor) StopGroup(groupID string) {
+	s.mu.Lock()
+	var targets []uint
+	for id, r := range s.relays {
+		if r.state.GroupID == groupID {
+			targets = append(targets, id)
+		}
+	}
+	s.mu.Unlock()
+
+	for _, id := range targets {
+		s.StopRelay(id)
+	}
+}
+
+// StopAll 停止所有轉推
+func (s *RestreamSupervisor) StopAll() {
+	s.mu.Lock()
+	var targets []uint
+	for id := range s.relays {
+		targets = append(targets, id)
+	}
+	s.mu.Unlock()
+
+	for _, id := range targets {
+		s.StopRelay(id)
+	}
+}
+
+// Retry 立即重試轉推（包含已放棄的目標）
+func (s *RestreamSupervisor) Retry(targetID uint) error {
+	s.mu.Lock()
+	r, exists := s.relays[targetID]
+	s.mu.Unlock()
+
+	if !exists {
+		return fmt.Errorf("轉推目標不存在或未啟動: %d", targetID)
+	}
+
+	select {
+	case r.retry <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// Status 獲取單一目標狀態
+func (s *RestreamSupervisor) Status(targetID uint) (RelayState, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r, exists := s.relays[targetID]
+	if !exists {
+		return RelayState{}, false
+	}
+	return r.state, true
+}
+
+// GroupStatus 獲取直播間所有轉推狀態
+func (s *RestreamSupervisor) GroupStatus(groupID string) []RelayState {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var states []RelayState
+	for _, r := range s.relays {
+		if r.state.GroupID == groupID {
+			states = append(states, r.state)
+		}
+	}
+	return states
+}
+
+// run 轉推主循環
+func (s *RestreamSupervisor) run(ctx context.Context, r *relay) {
+	defer close(r.done)
+
+	for {
+		s.setState(r, RelayStatusStarting, "")
+
+		errTail := &tailWriter{limit: relayErrorTailSize}
+		cmd := s.newCommand(ctx, r.source, r.dest)
+		cmd.Stderr = errTail
+		cmd.WaitDelay = relayWaitDelay
+
+		startedAt := time.Now()
+		err := cmd.Start()
+		if err == nil {
+			s.setState(r, RelayStatusRunning, "")
+			err = cmd.Wait()
+		}
+
+		if ctx.Err() != nil {
+			s.setState(r, RelayStatusStopped, "")
+			log.Printf("🛑 轉推已停止: target=%d", r.state.TargetID)
+			return
+		}
+
+		reason := errTail.String()
+		if reason == "" && err != nil {
+			reason = err.Error()
+		}
+		if reason == "" {
+			reason = "轉推進程意外結束"
+		}
+
+		s.mu.Lock()
+		if time.Since(startedAt) >= relayStableDuration {
+			r.state.Retries = 0
+		}
+		r.state.Retries++
+		retries := r.state.Retries
+		s.mu.Unlock()
+
+		if s.config.MaxRetries > 0 && retries > s.config.MaxRetries {
+			s.setState(r, RelayStatusFailed, reason)
+			log.Printf("❌ 轉推重試次數已達上限: target=%d, error=%s", r.state.TargetID, reason)
+
+			// 等待手動重試或停止
+			select {
+			case <-ctx.Done():
+				s.setState(r, RelayStatusStopped, reason)
+				return
+			case <-r.retry:
+				s.mu.Lock()
+				r.state.Retries = 0
+				s.mu.Unlock()
+				continue
+			}
+		}
+
+		s.setState(r, RelayStatusRetrying, reason)
+		log.Printf("⚠️ 轉推中斷，準備重試: target=%d, retry=%d, error=%s", r.state.TargetID, retries, reason)
+
+		timer := time.NewTimer(s.backoff(retries))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			s.setState(r, RelayStatusStopped, reason)
+			return
+		case <-r.retry:
+			timer.Stop()
+		case <-timer.C:
+		}
+	}
+}
+
+// backoff 計算指數退避重試間隔
+func (s *RestreamSupervisor) backoff(retries int) time.Duration {
+	delay := s.config.RetryInterval
+	for i := 1; i < retries; i++ {
+		delay *= 2
+		if delay >= relayMaxBackoff {
+			return relayMaxBackoff
+		}
+	}
+	return delay
+}
+
+// setState 更新狀態並通知回調
+func (s *RestreamSupervisor) setState(r *relay, status RelayStatus, lastError string) {
+	s.mu.Lock()
+	r.state.Status = status
+	if lastError != "" || status == RelayStatusRunning {
+		r.state.LastError = lastError
+	}
+	state := r.state
+	onStatus := s.onStatus
+	s.mu.Unlock()
+
+	if onStatus != nil {
+		onStatus(state)
+	}
+}
+
+// ffmpegCommand 建立 copy-codec 轉推命令
+func (s *RestreamSupervisor) ffmpegCommand(ctx context.Context, source, dest string) *exec.Cmd {
+	args := []string{
+		"-hide_banner",
+		"-loglevel", "error",
+		"-i", source,
+		"-c", "copy",
+		"-f", "flv",
+		dest,
+	}
+	return exec.CommandContext(ctx, s.config.FFmpegPath, args...)
+}
+
+// BuildRestreamDestination 組合推流地址與金鑰
+func BuildRestreamDestination(url, streamKey string) string {
+	if streamKey == "" {
+		return url
+	}
+	return strings.TrimRight(url, "/") + "/" + strings.TrimLeft(streamKey, "/")
+}
+
+// tailWriter 只保留最後 limit 個位元組的輸出
+type tailWriter struct {
+	mu    sync.Mutex
+	limit int
+	buf   []byte
+}
+
+func (w *tailWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.buf = append(w.buf, p...)
+	if len(w.buf) > w.limit {
+		w.buf = w.buf[len(w.buf)-w.limit:]
+	}
+	return len(p), nil
+}
+
+func (w *tailWriter) String() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return strings.TrimSpace(string(w.buf))
+}
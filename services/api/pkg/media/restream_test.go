@@ -0,0 +1,118 @@
+package media
+
+import (
+	"context"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestSupervisor 建立以 shell 命令模擬轉推的監控器
+// dest 為 "fail" 時模擬推流失敗，其他值模擬穩定推流
+func newTestSupervisor(maxRetries int) *RestreamSupervisor {
+	s := NewRestreamSupervisor(RestreamConfig{
+		MaxRetries:    maxRetries,
+		RetryInterval: 10 * time.Millisecond,
+	})
+	s.newCommand = func(ctx context.Context, source, dest string) *exec.Cmd {
+		if strings.HasSuffix(dest, "fail") {
+			return exec.CommandContext(ctx, "sh", "-c", "echo 'connection refused' >&2; exit 1")
+		}
+		return exec.CommandContext(ctx, "sh", "-c", "exec sleep 30")
+	}
+	return s
+}
+
+func waitForStatus(t *testing.T, s *RestreamSupervisor, targetID uint, status RelayStatus) RelayState {
+	t.Helper()
+	deadline := time.Now().Add(3 * time.Second)
+	for time.Now().Before(deadline) {
+		if state, ok := s.Status(targetID); ok && state.Status == status {
+			return state
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	state, _ := s.Status(targetID)
+	t.Fatalf("target %d 未進入 %s 狀態，目前為 %s", targetID, status, state.Status)
+	return state
+}
+
+func TestRestreamSupervisor_IndependentRetries(t *testing.T) {
+	s := newTestSupervisor(2)
+	defer s.StopAll()
+
+	require.NoError(t, s.StartRelay("room-1", 1, "rtmp://localhost/live/key", "rtmp://sink/app/ok"))
+	require.NoError(t, s.StartRelay("room-1", 2, "rtmp://localhost/live/key", "rtmp://sink/app/fail"))
+
+	failed := waitForStatus(t, s, 2, RelayStatusFailed)
+	assert.Equal(t, 3, failed.Retries)
+	assert.Contains(t, failed.LastError, "connection refused")
+
+	running := waitForStatus(t, s, 1, RelayStatusRunning)
+	assert.Equal(t, 0, running.Retries)
+	assert.Len(t, s.GroupStatus("room-1"), 2)
+}
+
+func TestRestreamSupervisor_StopGroup(t *testing.T) {
+	s := newTestSupervisor(1)
+
+	var stopped []uint
+	done := make(chan struct{}, 2)
+	s.OnStatusChange(func(state RelayState) {
+		if state.Status == RelayStatusStopped {
+			stopped = append(stopped, state.TargetID)
+			done <- struct{}{}
+		}
+	})
+
+	require.NoError(t, s.StartRelay("room-1", 1, "rtmp://localhost/live/a", "rtmp://sink/app/ok"))
+	require.NoError(t, s.StartRelay("room-2", 2, "rtmp://localhost/live/b", "rtmp://sink/app/ok"))
+	waitForStatus(t, s, 1, RelayStatusRunning)
+	waitForStatus(t, s, 2, RelayStatusRunning)
+
+	s.StopGroup("room-1")
+	<-done
+
+	_, exists := s.Status(1)
+	assert.False(t, exists)
+	assert.Equal(t, []uint{1}, stopped)
+
+	_, exists = s.Status(2)
+	assert.True(t, exists)
+	s.StopAll()
+}
+
+func TestRestreamSupervisor_ManualRetry(t *testing.T) {
+	s := newTestSupervisor(1)
+	defer s.StopAll()
+
+	require.NoError(t, s.StartRelay("room-1", 1, "rtmp://localhost/live/key", "rtmp://sink/app/fail"))
+	waitForStatus(t, s, 1, RelayStatusFailed)
+
+	require.NoError(t, s.Retry(1))
+	waitForStatus(t, s, 1, RelayStatusFailed)
+	assert.Error(t, s.Retry(99))
+}
+
+func TestRestreamSupervisor_StartRelayValidation(t *testing.T) {
+	s := newTestSupervisor(1)
+	assert.Error(t, s.StartRelay("room-1", 1, "", "rtmp://sink/app/ok"))
+	assert.Error(t, s.StartRelay("room-1", 1, "rtmp://localhost/live/key", ""))
+}
+
+func TestRestreamSupervisor_Backoff(t *testing.T) {
+	s := NewRestreamSupervisor(RestreamConfig{RetryInterval: time.Second})
+	assert.Equal(t, time.Second, s.backoff(1))
+	assert.Equal(t, 4*time.Second, s.backoff(3))
+	assert.Equal(t, relayMaxBackoff, s.backoff(20))
+}
+
+func TestBuildRestreamDestination(t *testing.T) {
+	assert.Equal(t, "rtmp://a.rtmp.youtube.com/live2/abc", BuildRestreamDestination("rtmp://a.rtmp.youtube.com/live2/", "abc"))
+	assert.Equal(t, "rtmp://live.twitch.tv/app/xyz", BuildRestreamDestination("rtmp://live.twitch.tv/app", "xyz"))
+	assert.Equal(t, "rtmp://sink/app", BuildRestreamDestination("rtmp://sink/app", ""))
+}
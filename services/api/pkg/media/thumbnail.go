@@ -0,0 +1,110 @@
+package media
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/jpeg"
+
+	// 註冊支援的圖片解碼器
+	_ "image/gif"
+	_ "image/png"
+)
+
+// 解碼前檢查的像素上限，避免壓縮炸彈佔用大量記憶體
+const maxImagePixels = 40_000_000
+
+// ImageInfo 圖片格式與尺寸
+type ImageInfo struct {
+	Format string // jpeg, png, gif
+	Width  int
+	Height int
+}
+
+// ProbeImage 讀取圖片標頭取得格式與尺寸，不支援的格式或尺寸過大時回傳錯誤
+func ProbeImage(data []byte) (*ImageInfo, error) {
+	config, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("無法識別的圖片格式: %w", err)
+	}
+	if config.Width <= 0 || config.Height <= 0 {
+		return nil, fmt.Errorf("無效的圖片尺寸")
+	}
+	if config.Width*config.Height > maxImagePixels {
+		return nil, fmt.Errorf("圖片尺寸過大: %dx%d", config.Width, config.Height)
+	}
+	return &ImageInfo{Format: format, Width: config.Width, Height: config.Height}, nil
+}
+
+// ThumbnailSize 依最長邊等比縮小後的尺寸，原圖較小時維持原尺寸
+func ThumbnailSize(width, height, maxSize int) (int, int) {
+	if width <= maxSize && height <= maxSize {
+		return width, height
+	}
+	if width >= height {
+		return maxSize, max(1, height*maxSize/width)
+	}
+	return max(1, width*maxSize/height), maxSize
+}
+
+// ImageThumbnail 產生 JPEG 縮圖，最長邊不超過 maxSize
+// 以區域平均縮小，透明區域以白色填底
+func ImageThumbnail(data []byte, maxSize int) ([]byte, error) {
+	if _, err := ProbeImage(data); err != nil {
+		return nil, err
+	}
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("解碼圖片失敗: %w", err)
+	}
+
+	bounds := src.Bounds()
+	width, height := ThumbnailSize(bounds.Dx(), bounds.Dy(), maxSize)
+
+	// 轉為 RGBA 並填白底，之後直接讀取像素陣列
+	rgba := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(rgba, rgba.Bounds(), image.White, image.Point{}, draw.Src)
+	draw.Draw(rgba, rgba.Bounds(), src, bounds.Min, draw.Over)
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, downscale(rgba, width, height), &jpeg.Options{Quality: 80}); err != nil {
+		return nil, fmt.Errorf("編碼縮圖失敗: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// downscale 區域平均縮小，每個目標像素取來源對應區塊的平均值
+func downscale(src *image.RGBA, width, height int) *image.RGBA {
+	srcW, srcH := src.Bounds().Dx(), src.Bounds().Dy()
+	if srcW == width && srcH == height {
+		return src
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		y0 := y * srcH / height
+		y1 := max(y0+1, (y+1)*srcH/height)
+		for x := 0; x < width; x++ {
+			x0 := x * srcW / width
+			x1 := max(x0+1, (x+1)*srcW/width)
+
+			var r, g, b, count int
+			for sy := y0; sy < y1; sy++ {
+				row := src.Pix[sy*src.Stride:]
+				for sx := x0; sx < x1; sx++ {
+					r += int(row[sx*4])
+					g += int(row[sx*4+1])
+					b += int(row[sx*4+2])
+					count++
+				}
+			}
+			i := dst.PixOffset(x, y)
+			dst.Pix[i] = uint8(r / count)
+			dst.Pix[i+1] = uint8(g / count)
+			dst.Pix[i+2] = uint8(b / count)
+			dst.Pix[i+3] = 0xff
+		}
+	}
+	return dst
+}
@@ -0,0 +1,66 @@
+package media
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func encodeTestPNG(t *testing.T, width, height int) []byte {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, color.RGBA{R: 200, G: 100, B: 50, A: 255})
+		}
+	}
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, img))
+	return buf.Bytes()
+}
+
+func TestThumbnailSize(t *testing.T) {
+	w, h := ThumbnailSize(1920, 1080, 320)
+	assert.Equal(t, 320, w)
+	assert.Equal(t, 180, h)
+
+	w, h = ThumbnailSize(1080, 1920, 320)
+	assert.Equal(t, 180, w)
+	assert.Equal(t, 320, h)
+
+	// 小圖不放大
+	w, h = ThumbnailSize(100, 50, 320)
+	assert.Equal(t, 100, w)
+	assert.Equal(t, 50, h)
+}
+
+func TestProbeImage(t *testing.T) {
+	info, err := ProbeImage(encodeTestPNG(t, 40, 20))
+	require.NoError(t, err)
+	assert.Equal(t, "png", info.Format)
+	assert.Equal(t, 40, info.Width)
+	assert.Equal(t, 20, info.Height)
+
+	_, err = ProbeImage([]byte("%PDF-1.4 not an image"))
+	assert.Error(t, err)
+}
+
+func TestImageThumbnail(t *testing.T) {
+	thumb, err := ImageThumbnail(encodeTestPNG(t, 400, 200), 100)
+	require.NoError(t, err)
+
+	img, format, err := image.Decode(bytes.NewReader(thumb))
+	require.NoError(t, err)
+	assert.Equal(t, "jpeg", format)
+	assert.Equal(t, 100, img.Bounds().Dx())
+	assert.Equal(t, 50, img.Bounds().Dy())
+
+	r, g, b, _ := img.At(50, 25).RGBA()
+	assert.InDelta(t, 200, r>>8, 4)
+	assert.InDelta(t, 100, g>>8, 4)
+	assert.InDelta(t, 50, b>>8, 4)
+}
@@ -2,19 +2,28 @@ package storage
 
 import (
 	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"hash"
 	"io"
 	"mime/multipart"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/credentials"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3manager"
 	"github.com/google/uuid"
 )
 
+// ProcessedBucket 轉碼後檔案所在的桶
+const ProcessedBucket = "stream-demo-processed"
+
 // S3Config S3配置
 type S3Config struct {
 	AccessKey string
@@ -75,8 +84,11 @@ func NewS3Storage(config S3Config) (*S3Storage, error) {
 // GeneratePresignedUploadURL 生成預簽名上傳URL
 func (s *S3Storage) GeneratePresignedUploadURL(userID uint, fileExt string, fileSize int64) (*PresignedUploadURL, error) {
 	// 生成唯一檔名
-	key := fmt.Sprintf("videos/original/%d/%s%s", userID, uuid.New().String(), fileExt)
+	return s.GeneratePresignedUploadURLForKey(VideoOriginalKey(userID, fileExt), fileExt)
+}
 
+// GeneratePresignedUploadURLForKey 為指定 Key 生成預簽名上傳URL
+func (s *S3Storage) GeneratePresignedUploadURLForKey(key, fileExt string) (*PresignedUploadURL, error) {
 	// 創建預簽名請求（簡化版本，不預設複雜 headers）
 	req, _ := s.client.PutObjectRequest(&s3.PutObjectInput{
 		Bucket: aws.String(s.bucket),
@@ -134,12 +146,81 @@ func (s *S3Storage) GenerateCDNURL(key string) string {
 
 // GenerateProcessedCDNURL 生成處理後檔案的 CDN URL
 func (s *S3Storage) GenerateProcessedCDNURL(key string) string {
-	processedBucket := "stream-demo-processed"
 	if s.cdnDomain != "" {
 		return fmt.Sprintf("%s/%s", s.cdnDomain, key)
 	}
 	// 如果沒有CDN，返回處理後桶的 MinIO URL（本地開發）
-	return fmt.Sprintf("http://localhost:9000/%s/%s", processedBucket, key)
+	return fmt.Sprintf("http://localhost:9000/%s/%s", ProcessedBucket, key)
+}
+
+// Bucket 原始檔案所在的桶
+func (s *S3Storage) Bucket() string {
+	return s.bucket
+}
+
+// VideoOriginalKey 上傳影片原始檔的 Key
+func VideoOriginalKey(userID uint, fileExt string) string {
+	return fmt.Sprintf("videos/original/%d/%s%s", userID, uuid.New().String(), fileExt)
+}
+
+// ProcessedVideoPrefix 影片轉碼輸出（HLS、MP4、縮圖）所在的前綴，與 converter 的輸出路徑一致
+func ProcessedVideoPrefix(userID, videoID uint) string {
+	return fmt.Sprintf("videos/processed/%d/%d/", userID, videoID)
+}
+
+// CaptionSourcePrefix 影片字幕原始檔所在的前綴
+func CaptionSourcePrefix(videoID uint) string {
+	return fmt.Sprintf("captions/%d/", videoID)
+}
+
+// ClipOriginalKey 精華片段剪輯輸出的原始檔 Key（之後交由轉碼流程處理）
+func ClipOriginalKey(userID, videoID uint) string {
+	return fmt.Sprintf("videos/original/%d/clip_%d.mp4", userID, videoID)
+}
+
+// CaptionSourceKey 上傳字幕原始檔的 Key
+func CaptionSourceKey(videoID uint, language, fileExt string) string {
+	return fmt.Sprintf("captions/%d/%s_%s%s", videoID, language, uuid.New().String(), fileExt)
+}
+
+// BrandingWatermarkKey 上傳品牌浮水印圖片的 Key
+func BrandingWatermarkKey(userID uint, fileExt string) string {
+	return fmt.Sprintf("branding/%d/watermark_%s%s", userID, uuid.New().String(), fileExt)
+}
+
+// ConversationAvatarPrefix 群組頭像所在的前綴
+func ConversationAvatarPrefix(conversationID uint) string {
+	return fmt.Sprintf("chat/groups/%d/", conversationID)
+}
+
+// ConversationAvatarKey 上傳群組頭像的 Key
+func ConversationAvatarKey(conversationID uint, fileExt string) string {
+	return fmt.Sprintf("%savatar_%s%s", ConversationAvatarPrefix(conversationID), uuid.New().String(), fileExt)
+}
+
+// ChatAttachmentPrefix 會話附件所在的前綴
+func ChatAttachmentPrefix(conversationID uint) string {
+	return fmt.Sprintf("chat/attachments/%d/", conversationID)
+}
+
+// ChatAttachmentKey 上傳會話附件的 Key
+func ChatAttachmentKey(conversationID uint, fileExt string) string {
+	return fmt.Sprintf("%s%s%s", ChatAttachmentPrefix(conversationID), uuid.New().String(), fileExt)
+}
+
+// ChatAttachmentThumbnailKey 圖片附件縮圖的 Key，與原檔放在同一目錄
+func ChatAttachmentThumbnailKey(key string) string {
+	return strings.TrimSuffix(key, filepath.Ext(key)) + "_thumb.jpg"
+}
+
+// PreviewSpriteURLs 根據 WebVTT 縮圖軌 URL 推算同目錄下的雪碧圖 URL（sprite_001.jpg 起）
+func PreviewSpriteURLs(vttURL string, count int) []string {
+	base := vttURL[:strings.LastIndex(vttURL, "/")+1]
+	urls := make([]string, count)
+	for i := range urls {
+		urls[i] = fmt.Sprintf("%ssprite_%03d.jpg", base, i+1)
+	}
+	return urls
 }
 
 // CheckFileExists 檢查檔案是否存在
@@ -164,32 +245,122 @@ func (s *S3Storage) GetFileInfo(key string) (*s3.HeadObjectOutput, error) {
 	})
 }
 
-// UploadVideo 上傳影片到S3（保留舊方法以兼容）
-func (s *S3Storage) UploadVideo(file multipart.File, header *multipart.FileHeader, userID uint) (string, error) {
-	// 生成唯一檔名
-	ext := filepath.Ext(header.Filename)
-	filename := fmt.Sprintf("videos/original/%d/%s%s", userID, uuid.New().String(), ext)
+// StreamUploadResult 串流上傳結果
+type StreamUploadResult struct {
+	Key    string
+	Size   int64
+	SHA256 string
+}
 
-	// 讀取檔案內容
-	buffer := bytes.NewBuffer(nil)
-	if _, err := io.Copy(buffer, file); err != nil {
-		return "", fmt.Errorf("讀取檔案失敗: %w", err)
+// UploadStream 將資料流直接寫入 S3 multipart upload，不落地也不整檔緩衝
+// 記憶體用量上限約為 partSize * concurrency，失敗時會中止 multipart upload 並清除已上傳的分段
+func (s *S3Storage) UploadStream(ctx context.Context, key string, body io.Reader, partSize int64, concurrency int, onProgress func(int64)) (*StreamUploadResult, error) {
+	if partSize < s3manager.MinUploadPartSize {
+		partSize = s3manager.MinUploadPartSize
+	}
+	if concurrency < 1 {
+		concurrency = 1
 	}
 
-	// 上傳到S3
+	reader := NewHashingReader(body, onProgress)
+	uploader := s3manager.NewUploaderWithClient(s.client, func(u *s3manager.Uploader) {
+		u.PartSize = partSize
+		u.Concurrency = concurrency
+		u.LeavePartsOnError = false
+	})
+
+	_, err := uploader.UploadWithContext(ctx, &s3manager.UploadInput{
+		Bucket:      aws.String(s.bucket),
+		Key:         aws.String(key),
+		Body:        reader,
+		ContentType: aws.String(getContentType(strings.ToLower(filepath.Ext(key)))),
+		ACL:         aws.String("private"),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("串流上傳到S3失敗: %w", err)
+	}
+
+	return &StreamUploadResult{
+		Key:    key,
+		Size:   reader.Size(),
+		SHA256: reader.Sum(),
+	}, nil
+}
+
+// ReadObject 讀取檔案內容，超過 maxBytes 時回傳錯誤
+func (s *S3Storage) ReadObject(key string, maxBytes int64) ([]byte, error) {
+	object, err := s.client.GetObject(&s3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("讀取檔案失敗: %w", err)
+	}
+	defer object.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(object.Body, maxBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("讀取檔案失敗: %w", err)
+	}
+	if int64(len(data)) > maxBytes {
+		return nil, fmt.Errorf("檔案超過 %d 位元組", maxBytes)
+	}
+	return data, nil
+}
+
+// UploadBytes 上傳檔案內容（不設定公開讀取）
+func (s *S3Storage) UploadBytes(key string, data []byte, contentType string) error {
 	_, err := s.client.PutObject(&s3.PutObjectInput{
 		Bucket:        aws.String(s.bucket),
-		Key:           aws.String(filename),
-		Body:          bytes.NewReader(buffer.Bytes()),
-		ContentLength: aws.Int64(int64(buffer.Len())),
-		ContentType:   aws.String(getContentType(ext)),
-		ACL:           aws.String("private"),
+		Key:           aws.String(key),
+		Body:          bytes.NewReader(data),
+		ContentLength: aws.Int64(int64(len(data))),
+		ContentType:   aws.String(contentType),
 	})
 	if err != nil {
-		return "", fmt.Errorf("上傳到S3失敗: %w", err)
+		return fmt.Errorf("上傳檔案失敗: %w", err)
 	}
+	return nil
+}
 
-	return s.GenerateCDNURL(filename), nil
+// HashingReader 讀取時同步計算 SHA-256 與已讀位元組數
+type HashingReader struct {
+	reader     io.Reader
+	hash       hash.Hash
+	size       int64
+	onProgress func(int64)
+}
+
+// NewHashingReader 包裝資料流，onProgress 會在每次讀取後收到累計位元組數
+func NewHashingReader(reader io.Reader, onProgress func(int64)) *HashingReader {
+	return &HashingReader{
+		reader:     reader,
+		hash:       sha256.New(),
+		onProgress: onProgress,
+	}
+}
+
+// Read 實作 io.Reader
+func (r *HashingReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	if n > 0 {
+		r.hash.Write(p[:n])
+		r.size += int64(n)
+		if r.onProgress != nil {
+			r.onProgress(r.size)
+		}
+	}
+	return n, err
+}
+
+// Size 已讀取的位元組數
+func (r *HashingReader) Size() int64 {
+	return r.size
+}
+
+// Sum 已讀取內容的 SHA-256（十六進位）
+func (r *HashingReader) Sum() string {
+	return hex.EncodeToString(r.hash.Sum(nil))
 }
 
 // UploadThumbnail 上傳縮圖到S3
@@ -229,6 +400,75 @@ func (s *S3Storage) DeleteFile(key string) error {
 	return err
 }
 
+// DeletePrefix 刪除指定桶中某前綴下的所有檔案，回傳刪除數量
+func (s *S3Storage) DeletePrefix(bucket, prefix string) (int, error) {
+	if prefix == "" {
+		return 0, fmt.Errorf("前綴不能為空")
+	}
+
+	deleted := 0
+	var deleteErr error
+	err := s.client.ListObjectsV2Pages(&s3.ListObjectsV2Input{
+		Bucket: aws.String(bucket),
+		Prefix: aws.String(prefix),
+	}, func(page *s3.ListObjectsV2Output, lastPage bool) bool {
+		if len(page.Contents) == 0 {
+			return true
+		}
+
+		// 每頁最多 1000 筆，與 DeleteObjects 上限一致
+		objects := make([]*s3.ObjectIdentifier, 0, len(page.Contents))
+		for _, object := range page.Contents {
+			objects = append(objects, &s3.ObjectIdentifier{Key: object.Key})
+		}
+		output, err := s.client.DeleteObjects(&s3.DeleteObjectsInput{
+			Bucket: aws.String(bucket),
+			Delete: &s3.Delete{Objects: objects, Quiet: aws.Bool(true)},
+		})
+		if err != nil {
+			deleteErr = err
+			return false
+		}
+		if len(output.Errors) > 0 {
+			deleteErr = fmt.Errorf("%d 個檔案刪除失敗: %s", len(output.Errors), aws.StringValue(output.Errors[0].Message))
+			return false
+		}
+		deleted += len(objects)
+		return true
+	})
+	if err != nil {
+		return deleted, fmt.Errorf("列出檔案失敗: %w", err)
+	}
+	if deleteErr != nil {
+		return deleted, fmt.Errorf("刪除檔案失敗: %w", deleteErr)
+	}
+	return deleted, nil
+}
+
+// ListChildPrefixes 列出某前綴下一層的子目錄（以 / 結尾）
+func (s *S3Storage) ListChildPrefixes(bucket, prefix string) ([]string, error) {
+	var prefixes []string
+	err := s.client.ListObjectsV2Pages(&s3.ListObjectsV2Input{
+		Bucket:    aws.String(bucket),
+		Prefix:    aws.String(prefix),
+		Delimiter: aws.String("/"),
+	}, func(page *s3.ListObjectsV2Output, lastPage bool) bool {
+		for _, commonPrefix := range page.CommonPrefixes {
+			prefixes = append(prefixes, aws.StringValue(commonPrefix.Prefix))
+		}
+		return true
+	})
+	if err != nil {
+		return nil, fmt.Errorf("列出目錄失敗: %w", err)
+	}
+	return prefixes, nil
+}
+
+// ContentTypeForExt 根據副檔名（不分大小寫）返回Content-Type
+func ContentTypeForExt(ext string) string {
+	return getContentType(strings.ToLower(ext))
+}
+
 // getContentType 根據檔案擴展名返回Content-Type
 func getContentType(ext string) string {
 	switch ext {
@@ -246,6 +486,18 @@ func getContentType(ext string) string {
 		return "image/jpeg"
 	case ".png":
 		return "image/png"
+	case ".gif":
+		return "image/gif"
+	case ".pdf":
+		return "application/pdf"
+	case ".zip":
+		return "application/zip"
+	case ".txt":
+		return "text/plain"
+	case ".vtt":
+		return "text/vtt"
+	case ".srt":
+		return "application/x-subrip"
 	default:
 		return "application/octet-stream"
 	}
@@ -1,6 +1,8 @@
 package storage
 
 import (
+	"io"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -77,6 +79,72 @@ func TestS3Storage_GenerateProcessedCDNURL(t *testing.T) {
 	}
 }
 
+func TestVideoOriginalKey(t *testing.T) {
+	key := VideoOriginalKey(3, ".mp4")
+	assert.True(t, strings.HasPrefix(key, "videos/original/3/"))
+	assert.True(t, strings.HasSuffix(key, ".mp4"))
+}
+
+func TestStoragePrefixes(t *testing.T) {
+	assert.Equal(t, "videos/processed/3/42/", ProcessedVideoPrefix(3, 42))
+	assert.Equal(t, "captions/42/", CaptionSourcePrefix(42))
+}
+
+func TestHashingReader(t *testing.T) {
+	var progress []int64
+	reader := NewHashingReader(strings.NewReader("hello world"), func(n int64) {
+		progress = append(progress, n)
+	})
+
+	data, err := io.ReadAll(io.LimitReader(reader, 5))
+	assert.NoError(t, err)
+	assert.Equal(t, "hello", string(data))
+
+	_, err = io.ReadAll(reader)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(11), reader.Size())
+	assert.Equal(t, "b94d27b9934d3e08a52e52d7da7dabfac484efe37a5380ee9088f7ace2efcde9", reader.Sum())
+	assert.Equal(t, int64(11), progress[len(progress)-1])
+}
+
+func TestClipOriginalKey(t *testing.T) {
+	assert.Equal(t, "videos/original/3/clip_42.mp4", ClipOriginalKey(3, 42))
+}
+
+func TestCaptionSourceKey(t *testing.T) {
+	key := CaptionSourceKey(5, "zh-TW", ".srt")
+	assert.True(t, strings.HasPrefix(key, "captions/5/zh-TW_"))
+	assert.True(t, strings.HasSuffix(key, ".srt"))
+}
+
+func TestBrandingWatermarkKey(t *testing.T) {
+	key := BrandingWatermarkKey(5, ".png")
+	assert.True(t, strings.HasPrefix(key, "branding/5/watermark_"))
+	assert.True(t, strings.HasSuffix(key, ".png"))
+}
+
+func TestConversationAvatarKey(t *testing.T) {
+	key := ConversationAvatarKey(9, ".jpg")
+	assert.True(t, strings.HasPrefix(key, ConversationAvatarPrefix(9)+"avatar_"))
+	assert.True(t, strings.HasSuffix(key, ".jpg"))
+}
+
+func TestChatAttachmentKey(t *testing.T) {
+	key := ChatAttachmentKey(7, ".png")
+	assert.True(t, strings.HasPrefix(key, "chat/attachments/7/"))
+	assert.True(t, strings.HasSuffix(key, ".png"))
+	assert.Equal(t, strings.TrimSuffix(key, ".png")+"_thumb.jpg", ChatAttachmentThumbnailKey(key))
+}
+
+func TestPreviewSpriteURLs(t *testing.T) {
+	vttURL := "http://cdn/videos/processed/1/2/thumbnails/preview/thumbnails.vtt"
+	assert.Equal(t, []string{
+		"http://cdn/videos/processed/1/2/thumbnails/preview/sprite_001.jpg",
+		"http://cdn/videos/processed/1/2/thumbnails/preview/sprite_002.jpg",
+	}, PreviewSpriteURLs(vttURL, 2))
+	assert.Empty(t, PreviewSpriteURLs(vttURL, 0))
+}
+
 func TestGetContentType(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -108,6 +176,21 @@ func TestGetContentType(t *testing.T) {
 			ext:      ".png",
 			expected: "image/png",
 		},
+		{
+			name:     "GIF 圖片",
+			ext:      ".gif",
+			expected: "image/gif",
+		},
+		{
+			name:     "PDF 文件",
+			ext:      ".pdf",
+			expected: "application/pdf",
+		},
+		{
+			name:     "WebVTT 字幕",
+			ext:      ".vtt",
+			expected: "text/vtt",
+		},
 		{
 			name:     "未知格式",
 			ext:      ".xyz",
@@ -198,7 +281,7 @@ func TestS3Storage_GetFileInfo(t *testing.T) {
 	t.Skip("Skipping test that requires AWS credentials")
 }
 
-func TestS3Storage_UploadVideo(t *testing.T) {
+func TestS3Storage_UploadStream(t *testing.T) {
 	// 這個測試需要實際的 AWS 憑證，所以我們跳過它
 	t.Skip("Skipping test that requires AWS credentials")
 }
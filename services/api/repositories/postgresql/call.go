@@ -0,0 +1,56 @@
+package postgresql
+
+import (
+	"stream-demo/backend/database/models"
+
+	"gorm.io/gorm"
+)
+
+// CreateCall 建立通話紀錄與參與者
+func (r *PostgreSQLRepo) CreateCall(call *models.Call) error {
+	return r.PostgreSQLDB.Create(call).Error
+}
+
+// FinishCall 寫入通話結果與各參與者的最終狀態
+func (r *PostgreSQLRepo) FinishCall(call *models.Call) error {
+	return r.PostgreSQLDB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Call{}).Where("id = ?", call.ID).Updates(map[string]interface{}{
+			"status":      call.Status,
+			"answered_at": call.AnsweredAt,
+			"ended_at":    call.EndedAt,
+			"duration":    call.Duration,
+		}).Error; err != nil {
+			return err
+		}
+		for _, participant := range call.Participants {
+			if err := tx.Model(&models.CallParticipant{}).
+				Where("call_id = ? AND user_id = ?", call.ID, participant.UserID).
+				Updates(map[string]interface{}{
+					"status":    participant.Status,
+					"joined_at": participant.JoinedAt,
+					"left_at":   participant.LeftAt,
+				}).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// FindUserCalls 分頁查找用戶參與的通話，最新的在前
+func (r *PostgreSQLRepo) FindUserCalls(userID uint, offset, limit int) ([]models.Call, int64, error) {
+	var calls []models.Call
+	var total int64
+
+	query := r.PostgreSQLDB.Model(&models.Call{}).
+		Where("id IN (?)", r.PostgreSQLDB.Model(&models.CallParticipant{}).Select("call_id").Where("user_id = ?", userID))
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	err := query.Preload("Participants.User").
+		Order("created_at DESC, id DESC").
+		Offset(offset).
+		Limit(limit).
+		Find(&calls).Error
+	return calls, total, err
+}
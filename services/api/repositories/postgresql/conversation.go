@@ -0,0 +1,353 @@
+package postgresql
+
+import (
+	"errors"
+	"time"
+
+	"stream-demo/backend/database/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	// ErrConversationFull 加入後會超過群組人數上限
+	ErrConversationFull = errors.New("群組人數已達上限")
+	// ErrInviteUnavailable 邀請連結已過期或已達使用次數
+	ErrInviteUnavailable = errors.New("邀請連結已失效")
+)
+
+// CreateConversation 建立會話與成員
+func (r *PostgreSQLRepo) CreateConversation(conversation *models.Conversation, members []models.ConversationMember) error {
+	return r.PostgreSQLDB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(conversation).Error; err != nil {
+			return err
+		}
+		for i := range members {
+			members[i].ConversationID = conversation.ID
+		}
+		return tx.Create(&members).Error
+	})
+}
+
+// UpdateConversation 更新會話欄位
+func (r *PostgreSQLRepo) UpdateConversation(conversationID uint, updates map[string]interface{}) error {
+	return r.PostgreSQLDB.Model(&models.Conversation{}).Where("id = ?", conversationID).Updates(updates).Error
+}
+
+// AddConversationMembers 將用戶加入群組，已是成員的略過，回傳實際加入的用戶ID
+// 鎖定會話後再檢查人數，避免同時加入超過上限
+func (r *PostgreSQLRepo) AddConversationMembers(conversationID uint, userIDs []uint, maxMembers int) ([]uint, error) {
+	var added []uint
+	err := r.PostgreSQLDB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		added, err = addConversationMembers(tx, conversationID, userIDs, maxMembers)
+		return err
+	})
+	return added, err
+}
+
+// JoinConversationByInvite 以邀請連結加入群組並計入使用次數，已是成員時回傳 false 且不計次
+func (r *PostgreSQLRepo) JoinConversationByInvite(invite *models.ConversationInvite, userID uint, maxMembers int) (bool, error) {
+	joined := false
+	err := r.PostgreSQLDB.Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&models.ConversationMember{}).
+			Where("conversation_id = ? AND user_id = ?", invite.ConversationID, userID).
+			Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return nil
+		}
+
+		result := tx.Model(&models.ConversationInvite{}).
+			Where("id = ? AND (max_uses = 0 OR uses < max_uses) AND (expires_at IS NULL OR expires_at > ?)", invite.ID, time.Now()).
+			Update("uses", gorm.Expr("uses + 1"))
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrInviteUnavailable
+		}
+
+		added, err := addConversationMembers(tx, invite.ConversationID, []uint{userID}, maxMembers)
+		joined = len(added) > 0
+		return err
+	})
+	return joined, err
+}
+
+// addConversationMembers 在交易中鎖定會話並加入成員
+func addConversationMembers(tx *gorm.DB, conversationID uint, userIDs []uint, maxMembers int) ([]uint, error) {
+	var conversation models.Conversation
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&conversation, conversationID).Error; err != nil {
+		return nil, err
+	}
+
+	var existing []uint
+	if err := tx.Model(&models.ConversationMember{}).
+		Where("conversation_id = ?", conversationID).
+		Pluck("user_id", &existing).Error; err != nil {
+		return nil, err
+	}
+	isMember := make(map[uint]bool, len(existing))
+	for _, id := range existing {
+		isMember[id] = true
+	}
+
+	// 新成員不計入加入前的訊息未讀
+	var lastMessageID uint
+	if conversation.LastMessageID != nil {
+		lastMessageID = *conversation.LastMessageID
+	}
+
+	var members []models.ConversationMember
+	var added []uint
+	for _, userID := range userIDs {
+		if isMember[userID] {
+			continue
+		}
+		isMember[userID] = true
+		added = append(added, userID)
+		members = append(members, models.ConversationMember{
+			ConversationID:         conversationID,
+			UserID:                 userID,
+			Role:                   models.ConversationRoleMember,
+			LastDeliveredMessageID: lastMessageID,
+			LastReadMessageID:      lastMessageID,
+		})
+	}
+	if len(members) == 0 {
+		return nil, nil
+	}
+	if maxMembers > 0 && len(existing)+len(members) > maxMembers {
+		return nil, ErrConversationFull
+	}
+	return added, tx.Create(&members).Error
+}
+
+// RemoveConversationMember 移除會話成員，不是成員時回傳 false
+func (r *PostgreSQLRepo) RemoveConversationMember(conversationID, userID uint) (bool, error) {
+	result := r.PostgreSQLDB.Where("conversation_id = ? AND user_id = ?", conversationID, userID).Delete(&models.ConversationMember{})
+	return result.RowsAffected > 0, result.Error
+}
+
+// LeaveConversation 成員離開會話，newOwnerID 不為 0 時同時將群主轉交給該成員
+func (r *PostgreSQLRepo) LeaveConversation(conversationID, userID, newOwnerID uint) error {
+	return r.PostgreSQLDB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("conversation_id = ? AND user_id = ?", conversationID, userID).Delete(&models.ConversationMember{}).Error; err != nil {
+			return err
+		}
+		if newOwnerID == 0 {
+			return nil
+		}
+		return tx.Model(&models.ConversationMember{}).
+			Where("conversation_id = ? AND user_id = ?", conversationID, newOwnerID).
+			Update("role", models.ConversationRoleOwner).Error
+	})
+}
+
+// UpdateConversationMemberRole 更新成員角色
+func (r *PostgreSQLRepo) UpdateConversationMemberRole(conversationID, userID uint, role string) error {
+	return r.PostgreSQLDB.Model(&models.ConversationMember{}).
+		Where("conversation_id = ? AND user_id = ?", conversationID, userID).
+		Update("role", role).Error
+}
+
+// CreateConversationInvite 建立群組邀請連結
+func (r *PostgreSQLRepo) CreateConversationInvite(invite *models.ConversationInvite) error {
+	return r.PostgreSQLDB.Create(invite).Error
+}
+
+// FindConversationInviteByCode 根據邀請碼查找邀請連結
+func (r *PostgreSQLRepo) FindConversationInviteByCode(code string) (*models.ConversationInvite, error) {
+	var invite models.ConversationInvite
+	if err := r.PostgreSQLDB.Where("code = ?", code).First(&invite).Error; err != nil {
+		return nil, err
+	}
+	return &invite, nil
+}
+
+// FindConversationInvites 查找群組的邀請連結，新的在前
+func (r *PostgreSQLRepo) FindConversationInvites(conversationID uint) ([]models.ConversationInvite, error) {
+	var invites []models.ConversationInvite
+	err := r.PostgreSQLDB.Where("conversation_id = ?", conversationID).Order("id DESC").Find(&invites).Error
+	return invites, err
+}
+
+// DeleteConversationInvite 撤銷邀請連結，不存在時回傳 false
+func (r *PostgreSQLRepo) DeleteConversationInvite(conversationID, inviteID uint) (bool, error) {
+	result := r.PostgreSQLDB.Where("id = ? AND conversation_id = ?", inviteID, conversationID).Delete(&models.ConversationInvite{})
+	return result.RowsAffected > 0, result.Error
+}
+
+// FindConversationByID 根據ID查找會話與成員
+func (r *PostgreSQLRepo) FindConversationByID(id uint) (*models.Conversation, error) {
+	var conversation models.Conversation
+	if err := r.PostgreSQLDB.Preload("Members.User").First(&conversation, id).Error; err != nil {
+		return nil, err
+	}
+	return &conversation, nil
+}
+
+// FindDirectConversation 根據成員組合查找一對一會話
+func (r *PostgreSQLRepo) FindDirectConversation(directKey string) (*models.Conversation, error) {
+	var conversation models.Conversation
+	if err := r.PostgreSQLDB.Preload("Members.User").Where("direct_key = ?", directKey).First(&conversation).Error; err != nil {
+		return nil, err
+	}
+	return &conversation, nil
+}
+
+// FindUserConversations 分頁查找用戶參與的會話，最近有訊息的在前
+func (r *PostgreSQLRepo) FindUserConversations(userID uint, offset, limit int) ([]models.Conversation, int64, error) {
+	var conversations []models.Conversation
+	var total int64
+
+	query := r.PostgreSQLDB.Model(&models.Conversation{}).
+		Where("id IN (?)", r.PostgreSQLDB.Model(&models.ConversationMember{}).Select("conversation_id").Where("user_id = ?", userID))
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	err := query.Preload("Members.User").
+		Order("last_message_at DESC NULLS LAST, id DESC").
+		Offset(offset).
+		Limit(limit).
+		Find(&conversations).Error
+	return conversations, total, err
+}
+
+// FindConversationMember 查找會話成員
+func (r *PostgreSQLRepo) FindConversationMember(conversationID, userID uint) (*models.ConversationMember, error) {
+	var member models.ConversationMember
+	if err := r.PostgreSQLDB.Where("conversation_id = ? AND user_id = ?", conversationID, userID).First(&member).Error; err != nil {
+		return nil, err
+	}
+	return &member, nil
+}
+
+// FindConversationMemberIDs 查找會話所有成員的用戶ID
+func (r *PostgreSQLRepo) FindConversationMemberIDs(conversationID uint) ([]uint, error) {
+	var ids []uint
+	err := r.PostgreSQLDB.Model(&models.ConversationMember{}).Where("conversation_id = ?", conversationID).Pluck("user_id", &ids).Error
+	return ids, err
+}
+
+// CountUnreadMessages 統計用戶在各會話中他人發送的未讀訊息數
+func (r *PostgreSQLRepo) CountUnreadMessages(userID uint, conversationIDs []uint) (map[uint]int64, error) {
+	counts := make(map[uint]int64, len(conversationIDs))
+	if len(conversationIDs) == 0 {
+		return counts, nil
+	}
+
+	var rows []struct {
+		ConversationID uint
+		Count          int64
+	}
+	err := r.PostgreSQLDB.Table("messages AS m").
+		Select("m.conversation_id, COUNT(*) AS count").
+		Joins("JOIN conversation_members AS cm ON cm.conversation_id = m.conversation_id AND cm.user_id = ?", userID).
+		Where("m.conversation_id IN ? AND m.id > cm.last_read_message_id AND m.sender_id <> ?", conversationIDs, userID).
+		Group("m.conversation_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		counts[row.ConversationID] = row.Count
+	}
+	return counts, nil
+}
+
+// CreateMessage 建立訊息（含附件），同時更新會話的最後訊息與發送者的回條
+func (r *PostgreSQLRepo) CreateMessage(message *models.Message) error {
+	return r.PostgreSQLDB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(message).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.Conversation{}).Where("id = ?", message.ConversationID).Updates(map[string]interface{}{
+			"last_message_id": message.ID,
+			"last_message_at": message.CreatedAt,
+		}).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.ConversationMember{}).
+			Where("conversation_id = ? AND user_id = ?", message.ConversationID, message.SenderID).
+			Updates(map[string]interface{}{
+				"last_delivered_message_id": message.ID,
+				"last_read_message_id":      message.ID,
+			}).Error
+	})
+}
+
+// FindMessageByClientID 根據客戶端ID查找發送者的訊息
+func (r *PostgreSQLRepo) FindMessageByClientID(senderID uint, clientID string) (*models.Message, error) {
+	var message models.Message
+	if err := r.PostgreSQLDB.Preload("Attachment").Where("sender_id = ? AND client_id = ?", senderID, clientID).First(&message).Error; err != nil {
+		return nil, err
+	}
+	return &message, nil
+}
+
+// FindMessagesByIDs 根據ID查找訊息
+func (r *PostgreSQLRepo) FindMessagesByIDs(ids []uint) ([]models.Message, error) {
+	var messages []models.Message
+	if len(ids) == 0 {
+		return messages, nil
+	}
+	err := r.PostgreSQLDB.Preload("Attachment").Where("id IN ?", ids).Find(&messages).Error
+	return messages, err
+}
+
+// FindConversationMessages 查找會話訊息
+// beforeID 不為 0 時依ID由新到舊查找更早的訊息；afterID 不為 0 時依ID由舊到新查找之後的訊息（斷線補訊息）
+func (r *PostgreSQLRepo) FindConversationMessages(conversationID, beforeID, afterID uint, limit int) ([]models.Message, error) {
+	var messages []models.Message
+	query := r.PostgreSQLDB.Preload("Attachment").Where("conversation_id = ?", conversationID)
+	switch {
+	case afterID > 0:
+		query = query.Where("id > ?", afterID).Order("id ASC")
+	case beforeID > 0:
+		query = query.Where("id < ?", beforeID).Order("id DESC")
+	default:
+		query = query.Order("id DESC")
+	}
+	err := query.Limit(limit).Find(&messages).Error
+	return messages, err
+}
+
+// FindConversationMessage 查找會話中的訊息與附件
+func (r *PostgreSQLRepo) FindConversationMessage(conversationID, messageID uint) (*models.Message, error) {
+	var message models.Message
+	if err := r.PostgreSQLDB.Preload("Attachment").Where("id = ? AND conversation_id = ?", messageID, conversationID).First(&message).Error; err != nil {
+		return nil, err
+	}
+	return &message, nil
+}
+
+// ConversationHasMessage 訊息是否屬於會話
+func (r *PostgreSQLRepo) ConversationHasMessage(conversationID, messageID uint) (bool, error) {
+	var count int64
+	err := r.PostgreSQLDB.Model(&models.Message{}).
+		Where("id = ? AND conversation_id = ?", messageID, conversationID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// AdvanceMessageReceipt 將成員的已送達（與已讀）回條前移到指定訊息，不會倒退，回傳是否有更新
+func (r *PostgreSQLRepo) AdvanceMessageReceipt(conversationID, userID, messageID uint, read bool) (bool, error) {
+	column := "last_delivered_message_id"
+	updates := map[string]interface{}{
+		"last_delivered_message_id": gorm.Expr("GREATEST(last_delivered_message_id, ?)", messageID),
+	}
+	if read {
+		column = "last_read_message_id"
+		updates["last_read_message_id"] = messageID
+	}
+
+	result := r.PostgreSQLDB.Model(&models.ConversationMember{}).
+		Where("conversation_id = ? AND user_id = ? AND "+column+" < ?", conversationID, userID, messageID).
+		Updates(updates)
+	return result.RowsAffected > 0, result.Error
+}
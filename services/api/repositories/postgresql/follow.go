@@ -0,0 +1,127 @@
+package postgresql
+
+import (
+	"time"
+
+	"stream-demo/backend/database/models"
+
+	"gorm.io/gorm/clause"
+)
+
+// CreateFollow 追蹤用戶，已追蹤時回傳 false
+func (r *PostgreSQLRepo) CreateFollow(followerID, followeeID uint) (bool, error) {
+	result := r.PostgreSQLDB.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.Follow{
+		FollowerID: followerID,
+		FolloweeID: followeeID,
+	})
+	return result.RowsAffected > 0, result.Error
+}
+
+// DeleteFollow 取消追蹤，未追蹤時回傳 false
+func (r *PostgreSQLRepo) DeleteFollow(followerID, followeeID uint) (bool, error) {
+	result := r.PostgreSQLDB.Where("follower_id = ? AND followee_id = ?", followerID, followeeID).Delete(&models.Follow{})
+	return result.RowsAffected > 0, result.Error
+}
+
+// IsFollowing 檢查是否追蹤用戶
+func (r *PostgreSQLRepo) IsFollowing(followerID, followeeID uint) (bool, error) {
+	var count int64
+	err := r.PostgreSQLDB.Model(&models.Follow{}).
+		Where("follower_id = ? AND followee_id = ?", followerID, followeeID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// CountFollowers 統計追隨者數
+func (r *PostgreSQLRepo) CountFollowers(userID uint) (int64, error) {
+	var count int64
+	err := r.PostgreSQLDB.Model(&models.Follow{}).Where("followee_id = ?", userID).Count(&count).Error
+	return count, err
+}
+
+// CountFollowing 統計追蹤中的用戶數
+func (r *PostgreSQLRepo) CountFollowing(userID uint) (int64, error) {
+	var count int64
+	err := r.PostgreSQLDB.Model(&models.Follow{}).Where("follower_id = ?", userID).Count(&count).Error
+	return count, err
+}
+
+// FindFollowers 分頁查找追隨者，新追蹤的在前
+func (r *PostgreSQLRepo) FindFollowers(userID uint, offset, limit int) ([]models.Follow, int64, error) {
+	var follows []models.Follow
+	var total int64
+
+	query := r.PostgreSQLDB.Model(&models.Follow{}).Where("followee_id = ?", userID)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	if err := query.Preload("Follower").Order("created_at DESC").Offset(offset).Limit(limit).Find(&follows).Error; err != nil {
+		return nil, 0, err
+	}
+	return follows, total, nil
+}
+
+// FindFollowing 分頁查找追蹤中的用戶，新追蹤的在前
+func (r *PostgreSQLRepo) FindFollowing(userID uint, offset, limit int) ([]models.Follow, int64, error) {
+	var follows []models.Follow
+	var total int64
+
+	query := r.PostgreSQLDB.Model(&models.Follow{}).Where("follower_id = ?", userID)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	if err := query.Preload("Followee").Order("created_at DESC").Offset(offset).Limit(limit).Find(&follows).Error; err != nil {
+		return nil, 0, err
+	}
+	return follows, total, nil
+}
+
+// FindFollowerBatch 依追蹤記錄ID分批查找追隨者，供大量追隨者時分批擴散
+func (r *PostgreSQLRepo) FindFollowerBatch(followeeID, afterID uint, limit int) ([]models.Follow, error) {
+	var follows []models.Follow
+	err := r.PostgreSQLDB.Select("id, follower_id").
+		Where("followee_id = ? AND id > ?", followeeID, afterID).
+		Order("id ASC").
+		Limit(limit).
+		Find(&follows).Error
+	return follows, err
+}
+
+// FindFollowingIDs 查找追蹤中的所有用戶ID
+func (r *PostgreSQLRepo) FindFollowingIDs(followerID uint) ([]uint, error) {
+	var ids []uint
+	err := r.PostgreSQLDB.Model(&models.Follow{}).Where("follower_id = ?", followerID).Pluck("followee_id", &ids).Error
+	return ids, err
+}
+
+// FindFeedVideos 查找創作者已擴散到動態的影片，依擴散時間由新到舊
+// before 不為零值時只查找更早的影片
+func (r *PostgreSQLRepo) FindFeedVideos(creatorIDs []uint, before time.Time, limit int) ([]models.Video, error) {
+	var videos []models.Video
+	if len(creatorIDs) == 0 {
+		return videos, nil
+	}
+
+	query := r.PostgreSQLDB.Where("user_id IN ? AND status = ? AND feed_published_at IS NOT NULL", creatorIDs, "ready")
+	if !before.IsZero() {
+		query = query.Where("feed_published_at < ?", before)
+	}
+	err := query.Order("feed_published_at DESC").Limit(limit).Find(&videos).Error
+	return videos, err
+}
+
+// FindUnpublishedFeedVideos 查找已完成但尚未擴散到動態的影片
+func (r *PostgreSQLRepo) FindUnpublishedFeedVideos(limit int) ([]models.Video, error) {
+	var videos []models.Video
+	err := r.PostgreSQLDB.Select("id, user_id, created_at").
+		Where("status = ? AND feed_published_at IS NULL", "ready").
+		Order("id ASC").
+		Limit(limit).
+		Find(&videos).Error
+	return videos, err
+}
+
+// MarkVideoFeedPublished 記錄影片擴散到動態的時間（不更新 updated_at）
+func (r *PostgreSQLRepo) MarkVideoFeedPublished(videoID uint, publishedAt time.Time) error {
+	return r.PostgreSQLDB.Model(&models.Video{}).Where("id = ?", videoID).UpdateColumn("feed_published_at", publishedAt).Error
+}
@@ -0,0 +1,139 @@
+package postgresql
+
+import (
+	"stream-demo/backend/database/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// FindFriendshipsBetween 查找兩個用戶之間雙向的好友、邀請與封鎖記錄
+func (r *PostgreSQLRepo) FindFriendshipsBetween(userID, otherID uint) ([]models.Friendship, error) {
+	var friendships []models.Friendship
+	err := r.PostgreSQLDB.
+		Where("(user_id = ? AND friend_id = ?) OR (user_id = ? AND friend_id = ?)", userID, otherID, otherID, userID).
+		Find(&friendships).Error
+	return friendships, err
+}
+
+// CreateFriendship 建立好友關係記錄
+func (r *PostgreSQLRepo) CreateFriendship(friendship *models.Friendship) error {
+	return r.PostgreSQLDB.Create(friendship).Error
+}
+
+// AcceptFriendRequest 接受好友邀請，邀請不存在時回傳 false
+func (r *PostgreSQLRepo) AcceptFriendRequest(requesterID, addresseeID uint) (bool, error) {
+	result := r.PostgreSQLDB.Model(&models.Friendship{}).
+		Where("user_id = ? AND friend_id = ? AND status = ?", requesterID, addresseeID, models.FriendshipStatusPending).
+		Update("status", models.FriendshipStatusAccepted)
+	return result.RowsAffected > 0, result.Error
+}
+
+// DeleteFriendship 刪除指定方向與狀態的記錄，不存在時回傳 false
+func (r *PostgreSQLRepo) DeleteFriendship(userID, friendID uint, status string) (bool, error) {
+	result := r.PostgreSQLDB.
+		Where("user_id = ? AND friend_id = ? AND status = ?", userID, friendID, status).
+		Delete(&models.Friendship{})
+	return result.RowsAffected > 0, result.Error
+}
+
+// DeleteAcceptedFriendship 解除好友（不分方向），不是好友時回傳 false
+func (r *PostgreSQLRepo) DeleteAcceptedFriendship(userID, friendID uint) (bool, error) {
+	result := r.PostgreSQLDB.
+		Where("((user_id = ? AND friend_id = ?) OR (user_id = ? AND friend_id = ?)) AND status = ?",
+			userID, friendID, friendID, userID, models.FriendshipStatusAccepted).
+		Delete(&models.Friendship{})
+	return result.RowsAffected > 0, result.Error
+}
+
+// BlockUser 封鎖用戶，同時移除雙方的好友與邀請記錄（對方對自己的封鎖保留）
+func (r *PostgreSQLRepo) BlockUser(blockerID, blockedID uint) error {
+	return r.PostgreSQLDB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.
+			Where("((user_id = ? AND friend_id = ?) OR (user_id = ? AND friend_id = ?)) AND status <> ?",
+				blockerID, blockedID, blockedID, blockerID, models.FriendshipStatusBlocked).
+			Delete(&models.Friendship{}).Error; err != nil {
+			return err
+		}
+		return tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.Friendship{
+			UserID:   blockerID,
+			FriendID: blockedID,
+			Status:   models.FriendshipStatusBlocked,
+		}).Error
+	})
+}
+
+// FindFriends 分頁查找好友，最近成為好友的在前
+func (r *PostgreSQLRepo) FindFriends(userID uint, offset, limit int) ([]models.Friendship, int64, error) {
+	var friendships []models.Friendship
+	var total int64
+
+	query := r.PostgreSQLDB.Model(&models.Friendship{}).
+		Where("(user_id = ? OR friend_id = ?) AND status = ?", userID, userID, models.FriendshipStatusAccepted)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	if err := query.Preload("User").Preload("Friend").
+		Order("updated_at DESC, id DESC").Offset(offset).Limit(limit).Find(&friendships).Error; err != nil {
+		return nil, 0, err
+	}
+	return friendships, total, nil
+}
+
+// FindFriendRequests 分頁查找收到（incoming）或送出的好友邀請，新的在前
+func (r *PostgreSQLRepo) FindFriendRequests(userID uint, incoming bool, offset, limit int) ([]models.Friendship, int64, error) {
+	var friendships []models.Friendship
+	var total int64
+
+	column := "user_id"
+	if incoming {
+		column = "friend_id"
+	}
+	query := r.PostgreSQLDB.Model(&models.Friendship{}).
+		Where(column+" = ? AND status = ?", userID, models.FriendshipStatusPending)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	if err := query.Preload("User").Preload("Friend").
+		Order("created_at DESC, id DESC").Offset(offset).Limit(limit).Find(&friendships).Error; err != nil {
+		return nil, 0, err
+	}
+	return friendships, total, nil
+}
+
+// FindBlockedUsers 分頁查找自己封鎖的用戶，新封鎖的在前
+func (r *PostgreSQLRepo) FindBlockedUsers(userID uint, offset, limit int) ([]models.Friendship, int64, error) {
+	var friendships []models.Friendship
+	var total int64
+
+	query := r.PostgreSQLDB.Model(&models.Friendship{}).
+		Where("user_id = ? AND status = ?", userID, models.FriendshipStatusBlocked)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	if err := query.Preload("Friend").
+		Order("created_at DESC, id DESC").Offset(offset).Limit(limit).Find(&friendships).Error; err != nil {
+		return nil, 0, err
+	}
+	return friendships, total, nil
+}
+
+// FindBlockRelatedUserIDs 查找與用戶有封鎖關係的用戶ID（不論哪一方封鎖）
+func (r *PostgreSQLRepo) FindBlockRelatedUserIDs(userID uint) ([]uint, error) {
+	var ids []uint
+	err := r.PostgreSQLDB.Model(&models.Friendship{}).
+		Select("CASE WHEN user_id = ? THEN friend_id ELSE user_id END", userID).
+		Where("(user_id = ? OR friend_id = ?) AND status = ?", userID, userID, models.FriendshipStatusBlocked).
+		Scan(&ids).Error
+	return ids, err
+}
+
+// IsBlockedBetween 檢查兩個用戶之間是否有任一方封鎖
+func (r *PostgreSQLRepo) IsBlockedBetween(userID, otherID uint) (bool, error) {
+	var count int64
+	err := r.PostgreSQLDB.Model(&models.Friendship{}).
+		Where("((user_id = ? AND friend_id = ?) OR (user_id = ? AND friend_id = ?)) AND status = ?",
+			userID, otherID, otherID, userID, models.FriendshipStatusBlocked).
+		Count(&count).Error
+	return count > 0, err
+}
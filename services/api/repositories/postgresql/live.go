@@ -3,6 +3,7 @@ package postgresql
 import (
 	"errors"
 	"stream-demo/backend/database/models"
+	"time"
 
 	"gorm.io/gorm"
 )
@@ -81,3 +82,17 @@ func (r *PostgreSQLRepo) IncrementLiveViewerCount(id uint) error {
 func (r *PostgreSQLRepo) DecrementLiveViewerCount(id uint) error {
 	return r.PostgreSQLDB.Model(&models.Live{}).Where("id = ?", id).UpdateColumn("viewer_count", gorm.Expr("viewer_count - ?", 1)).Error
 }
+
+// FindScheduledLivesBefore 查找預定開始時間早於指定時間仍未開播的直播
+func (r *PostgreSQLRepo) FindScheduledLivesBefore(before time.Time) ([]*models.Live, error) {
+	var lives []*models.Live
+	if err := r.PostgreSQLDB.Where("status = ? AND start_time < ?", "scheduled", before).Find(&lives).Error; err != nil {
+		return nil, err
+	}
+	return lives, nil
+}
+
+// UpdateLiveStatus 更新直播狀態
+func (r *PostgreSQLRepo) UpdateLiveStatus(id uint, status string) error {
+	return r.PostgreSQLDB.Model(&models.Live{}).Where("id = ?", id).Update("status", status).Error
+}
@@ -0,0 +1,14 @@
+package postgresql
+
+import (
+	"stream-demo/backend/database/models"
+)
+
+// FindLiveSessionByRoomID 根據直播間ID查找直播記錄
+func (r *PostgreSQLRepo) FindLiveSessionByRoomID(roomID string) (*models.UserLiveSession, error) {
+	var session models.UserLiveSession
+	if err := r.PostgreSQLDB.Where("room_id = ?", roomID).Order("created_at DESC").First(&session).Error; err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
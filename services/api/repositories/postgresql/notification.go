@@ -0,0 +1,129 @@
+package postgresql
+
+import (
+	"time"
+
+	"stream-demo/backend/database/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// CreateNotifications 批次建立通知
+func (r *PostgreSQLRepo) CreateNotifications(notifications []*models.Notification) error {
+	if len(notifications) == 0 {
+		return nil
+	}
+	return r.PostgreSQLDB.CreateInBatches(notifications, 500).Error
+}
+
+// FindNotifications 查找用戶的通知，依ID由新到舊
+// beforeID 不為 0 時只查找更早的通知，unreadOnly 時只查找未讀通知
+func (r *PostgreSQLRepo) FindNotifications(userID, beforeID uint, unreadOnly bool, limit int) ([]models.Notification, error) {
+	var notifications []models.Notification
+	query := r.PostgreSQLDB.Where("user_id = ?", userID)
+	if beforeID > 0 {
+		query = query.Where("id < ?", beforeID)
+	}
+	if unreadOnly {
+		query = query.Where("read_at IS NULL")
+	}
+	err := query.Order("id DESC").Limit(limit).Find(&notifications).Error
+	return notifications, err
+}
+
+// CountUnreadNotifications 統計未讀通知數
+func (r *PostgreSQLRepo) CountUnreadNotifications(userID uint) (int64, error) {
+	var count int64
+	err := r.PostgreSQLDB.Model(&models.Notification{}).
+		Where("user_id = ? AND read_at IS NULL", userID).
+		Count(&count).Error
+	return count, err
+}
+
+// MarkNotificationRead 將用戶的通知標記為已讀，已讀時不更新
+// 通知不存在或不屬於該用戶時回傳 gorm.ErrRecordNotFound
+func (r *PostgreSQLRepo) MarkNotificationRead(userID, notificationID uint, readAt time.Time) error {
+	result := r.PostgreSQLDB.Model(&models.Notification{}).
+		Where("id = ? AND user_id = ? AND read_at IS NULL", notificationID, userID).
+		Update("read_at", readAt)
+	if result.Error != nil || result.RowsAffected > 0 {
+		return result.Error
+	}
+
+	var count int64
+	if err := r.PostgreSQLDB.Model(&models.Notification{}).
+		Where("id = ? AND user_id = ?", notificationID, userID).
+		Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// MarkAllNotificationsRead 將用戶所有未讀通知標記為已讀，回傳標記數
+func (r *PostgreSQLRepo) MarkAllNotificationsRead(userID uint, readAt time.Time) (int64, error) {
+	result := r.PostgreSQLDB.Model(&models.Notification{}).
+		Where("user_id = ? AND read_at IS NULL", userID).
+		Update("read_at", readAt)
+	return result.RowsAffected, result.Error
+}
+
+// DeleteNotificationsBefore 清除早於指定時間的通知，回傳清除數
+func (r *PostgreSQLRepo) DeleteNotificationsBefore(before time.Time) (int64, error) {
+	result := r.PostgreSQLDB.Where("created_at < ?", before).Delete(&models.Notification{})
+	return result.RowsAffected, result.Error
+}
+
+// FindNotificationPreferences 查找用戶已設定的通知偏好
+func (r *PostgreSQLRepo) FindNotificationPreferences(userID uint) ([]models.NotificationPreference, error) {
+	var preferences []models.NotificationPreference
+	err := r.PostgreSQLDB.Where("user_id = ?", userID).Find(&preferences).Error
+	return preferences, err
+}
+
+// SaveNotificationPreference 新增或更新通知偏好
+func (r *PostgreSQLRepo) SaveNotificationPreference(userID uint, notificationType string, enabled bool) error {
+	return r.PostgreSQLDB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "type"}},
+		DoUpdates: clause.AssignmentColumns([]string{"enabled", "updated_at"}),
+	}).Create(&models.NotificationPreference{
+		UserID:  userID,
+		Type:    notificationType,
+		Enabled: enabled,
+	}).Error
+}
+
+// FindNotificationDisabledUserIDs 從用戶中找出關閉該類型通知的用戶ID
+func (r *PostgreSQLRepo) FindNotificationDisabledUserIDs(userIDs []uint, notificationType string) ([]uint, error) {
+	var ids []uint
+	if len(userIDs) == 0 {
+		return ids, nil
+	}
+	err := r.PostgreSQLDB.Model(&models.NotificationPreference{}).
+		Where("user_id IN ? AND type = ? AND enabled = ?", userIDs, notificationType, false).
+		Pluck("user_id", &ids).Error
+	return ids, err
+}
+
+// FindTranscodeResultsToNotify 查找處理結果（完成、失敗或偵測到重複上傳）尚未通知擁有者的影片
+// 只查找 since 之後更新的影片，避免上線時對歷史影片補發通知
+func (r *PostgreSQLRepo) FindTranscodeResultsToNotify(since time.Time, limit int) ([]models.Video, error) {
+	var videos []models.Video
+	err := r.PostgreSQLDB.Select("id, user_id, title, status, processing_progress, error_message, duplicate_of_id, duplicate_match").
+		Where("status IN ? AND notified_status <> status AND updated_at >= ?", []string{"ready", "failed", "duplicate"}, since).
+		Order("updated_at ASC").
+		Limit(limit).
+		Find(&videos).Error
+	return videos, err
+}
+
+// ClaimVideoNotifiedStatus 記錄已通知擁有者的處理結果（不更新 updated_at），已被其他實例記錄時回傳 false
+func (r *PostgreSQLRepo) ClaimVideoNotifiedStatus(videoID uint, status string) (bool, error) {
+	result := r.PostgreSQLDB.Model(&models.Video{}).
+		Where("id = ? AND notified_status <> ?", videoID, status).
+		UpdateColumn("notified_status", status)
+	return result.RowsAffected > 0, result.Error
+}
@@ -0,0 +1,65 @@
+package postgresql
+
+import (
+	"stream-demo/backend/database/models"
+	"time"
+)
+
+// CreateRestreamTarget 創建轉推目標
+func (r *PostgreSQLRepo) CreateRestreamTarget(target *models.RestreamTarget) error {
+	return r.PostgreSQLDB.Create(target).Error
+}
+
+// FindRestreamTargetByID 根據ID查找轉推目標
+func (r *PostgreSQLRepo) FindRestreamTargetByID(id uint) (*models.RestreamTarget, error) {
+	var target models.RestreamTarget
+	if err := r.PostgreSQLDB.First(&target, id).Error; err != nil {
+		return nil, err
+	}
+	return &target, nil
+}
+
+// FindRestreamTargetsByRoomID 查找直播間的所有轉推目標
+func (r *PostgreSQLRepo) FindRestreamTargetsByRoomID(roomID string) ([]models.RestreamTarget, error) {
+	var targets []models.RestreamTarget
+	if err := r.PostgreSQLDB.Where("room_id = ?", roomID).Order("created_at ASC").Find(&targets).Error; err != nil {
+		return nil, err
+	}
+	return targets, nil
+}
+
+// FindEnabledRestreamTargetsByRoomID 查找直播間已啟用的轉推目標
+func (r *PostgreSQLRepo) FindEnabledRestreamTargetsByRoomID(roomID string) ([]models.RestreamTarget, error) {
+	var targets []models.RestreamTarget
+	if err := r.PostgreSQLDB.Where("room_id = ? AND enabled = ?", roomID, true).Order("created_at ASC").Find(&targets).Error; err != nil {
+		return nil, err
+	}
+	return targets, nil
+}
+
+// CountRestreamTargetsByRoomID 統計直播間轉推目標數量
+func (r *PostgreSQLRepo) CountRestreamTargetsByRoomID(roomID string) (int64, error) {
+	var count int64
+	err := r.PostgreSQLDB.Model(&models.RestreamTarget{}).Where("room_id = ?", roomID).Count(&count).Error
+	return count, err
+}
+
+// UpdateRestreamTarget 更新轉推目標
+func (r *PostgreSQLRepo) UpdateRestreamTarget(target *models.RestreamTarget) error {
+	return r.PostgreSQLDB.Save(target).Error
+}
+
+// UpdateRestreamTargetStatus 更新轉推目標狀態
+func (r *PostgreSQLRepo) UpdateRestreamTargetStatus(id uint, status string, retries int, lastError string) error {
+	return r.PostgreSQLDB.Model(&models.RestreamTarget{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"status":     status,
+		"retries":    retries,
+		"last_error": lastError,
+		"updated_at": time.Now(),
+	}).Error
+}
+
+// DeleteRestreamTarget 刪除轉推目標
+func (r *PostgreSQLRepo) DeleteRestreamTarget(id uint) error {
+	return r.PostgreSQLDB.Delete(&models.RestreamTarget{}, id).Error
+}
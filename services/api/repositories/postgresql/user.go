@@ -24,6 +24,16 @@ func (r *PostgreSQLRepo) FindUserByID(id uint) (*models.User, error) {
 	return &user, nil
 }
 
+// FindUsersByIDs 根據ID批次查找用戶，不存在的ID不會出現在結果中
+func (r *PostgreSQLRepo) FindUsersByIDs(ids []uint) ([]models.User, error) {
+	var users []models.User
+	if len(ids) == 0 {
+		return users, nil
+	}
+	err := r.PostgreSQLDB.Where("id IN ?", ids).Find(&users).Error
+	return users, err
+}
+
 // FindUserByUsername 根據用戶名查找用戶
 func (r *PostgreSQLRepo) FindUserByUsername(username string) (*models.User, error) {
 	var user models.User
@@ -0,0 +1,24 @@
+package postgresql
+
+import (
+	"stream-demo/backend/database/models"
+)
+
+// FindUserBranding 查找用戶的品牌設定
+func (r *PostgreSQLRepo) FindUserBranding(userID uint) (*models.UserBranding, error) {
+	var branding models.UserBranding
+	if err := r.PostgreSQLDB.Where("user_id = ?", userID).First(&branding).Error; err != nil {
+		return nil, err
+	}
+	return &branding, nil
+}
+
+// SaveUserBranding 新增或更新用戶的品牌設定
+func (r *PostgreSQLRepo) SaveUserBranding(branding *models.UserBranding) error {
+	return r.PostgreSQLDB.Save(branding).Error
+}
+
+// DeleteUserBranding 刪除用戶的品牌設定
+func (r *PostgreSQLRepo) DeleteUserBranding(userID uint) error {
+	return r.PostgreSQLDB.Where("user_id = ?", userID).Delete(&models.UserBranding{}).Error
+}
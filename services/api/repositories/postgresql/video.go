@@ -22,6 +22,18 @@ func (r *PostgreSQLRepo) FindVideoByID(id uint) (*models.Video, error) {
 	return &video, nil
 }
 
+// FindVideosByIDs 根據ID列表查找影片
+func (r *PostgreSQLRepo) FindVideosByIDs(ids []uint) ([]models.Video, error) {
+	var videos []models.Video
+	if len(ids) == 0 {
+		return videos, nil
+	}
+	if err := r.PostgreSQLDB.Preload("User").Where("id IN ?", ids).Order("created_at DESC").Find(&videos).Error; err != nil {
+		return nil, err
+	}
+	return videos, nil
+}
+
 // FindVideoByUserID 根據用戶ID查找影片列表
 func (r *PostgreSQLRepo) FindVideoByUserID(userID uint) ([]models.Video, error) {
 	var videos []models.Video
@@ -87,17 +99,6 @@ func (r *PostgreSQLRepo) FindAllVideo() ([]models.Video, error) {
 	return videos, nil
 }
 
-// SearchVideo 搜尋影片
-func (r *PostgreSQLRepo) SearchVideo(query string) ([]models.Video, error) {
-	var videos []models.Video
-	searchQuery := "%" + query + "%"
-	// PostgreSQL使用ILIKE進行不區分大小寫的搜尋
-	if err := r.PostgreSQLDB.Where("(title ILIKE ? OR description ILIKE ?) AND status = ?", searchQuery, searchQuery, "completed").Order("created_at DESC").Find(&videos).Error; err != nil {
-		return nil, err
-	}
-	return videos, nil
-}
-
 // UpdateVideo 更新影片
 func (r *PostgreSQLRepo) UpdateVideo(video *models.Video) error {
 	return r.PostgreSQLDB.Save(video).Error
@@ -113,23 +114,18 @@ func (r *PostgreSQLRepo) IncrementVideoViews(id uint) error {
 	return r.PostgreSQLDB.Model(&models.Video{}).Where("id = ?", id).UpdateColumn("views", gorm.Expr("views + ?", 1)).Error
 }
 
-// IncrementVideoLikes 增加影片喜歡次數
-func (r *PostgreSQLRepo) IncrementVideoLikes(id uint) error {
-	return r.PostgreSQLDB.Model(&models.Video{}).Where("id = ?", id).UpdateColumn("likes", gorm.Expr("likes + ?", 1)).Error
-}
-
-// FindVideosWithPagination 分頁查找影片
-func (r *PostgreSQLRepo) FindVideosWithPagination(offset, limit int) ([]models.Video, int64, error) {
+// FindVideosWithPagination 分頁查找檢視者可見的影片
+func (r *PostgreSQLRepo) FindVideosWithPagination(viewerID uint, offset, limit int) ([]models.Video, int64, error) {
 	var videos []models.Video
 	var total int64
 
 	// 使用 IN 語法替代 ANY，更簡潔且兼容性更好
 	statuses := []string{"ready", "processing", "transcoding"}
-	if err := r.PostgreSQLDB.Model(&models.Video{}).Where("status IN ?", statuses).Count(&total).Error; err != nil {
+	if err := r.PostgreSQLDB.Model(&models.Video{}).Scopes(visibleTo(viewerID)).Where("status IN ?", statuses).Count(&total).Error; err != nil {
 		return nil, 0, err
 	}
 
-	err := r.PostgreSQLDB.Where("status IN ?", statuses).
+	err := r.PostgreSQLDB.Scopes(visibleTo(viewerID)).Where("status IN ?", statuses).
 		Order("created_at DESC").
 		Offset(offset).
 		Limit(limit).
@@ -165,3 +161,12 @@ func (r *PostgreSQLRepo) UpdateVideoQuality(quality *models.VideoQuality) error
 func (r *PostgreSQLRepo) DeleteVideoQuality(id uint) error {
 	return r.PostgreSQLDB.Delete(&models.VideoQuality{}, id).Error
 }
+
+// FindVideoAudioTracksByVideoID 根據影片ID查找音軌列表
+func (r *PostgreSQLRepo) FindVideoAudioTracksByVideoID(videoID uint) ([]models.VideoAudioTrack, error) {
+	var tracks []models.VideoAudioTrack
+	if err := r.PostgreSQLDB.Where("video_id = ?", videoID).Order("is_default DESC, name ASC").Find(&tracks).Error; err != nil {
+		return nil, err
+	}
+	return tracks, nil
+}
@@ -0,0 +1,48 @@
+package postgresql
+
+import (
+	"stream-demo/backend/database/models"
+
+	"gorm.io/gorm"
+)
+
+// CreateVideoCaption 創建字幕軌
+func (r *PostgreSQLRepo) CreateVideoCaption(caption *models.VideoCaption) error {
+	return r.PostgreSQLDB.Create(caption).Error
+}
+
+// FindVideoCaption 根據影片與語言查找字幕軌
+func (r *PostgreSQLRepo) FindVideoCaption(videoID uint, language string) (*models.VideoCaption, error) {
+	var caption models.VideoCaption
+	if err := r.PostgreSQLDB.Where("video_id = ? AND language = ?", videoID, language).First(&caption).Error; err != nil {
+		return nil, err
+	}
+	return &caption, nil
+}
+
+// FindVideoCaptionsByVideoID 查找影片的字幕軌（不含刪除中的項目）
+func (r *PostgreSQLRepo) FindVideoCaptionsByVideoID(videoID uint) ([]models.VideoCaption, error) {
+	var captions []models.VideoCaption
+	if err := r.PostgreSQLDB.Where("video_id = ? AND status <> ?", videoID, "deleting").
+		Order("is_default DESC, language ASC").Find(&captions).Error; err != nil {
+		return nil, err
+	}
+	return captions, nil
+}
+
+// UpdateVideoCaptionFields 更新字幕軌欄位
+func (r *PostgreSQLRepo) UpdateVideoCaptionFields(captionID uint, updates map[string]interface{}) error {
+	return r.PostgreSQLDB.Model(&models.VideoCaption{}).Where("id = ?", captionID).Updates(updates).Error
+}
+
+// SetDefaultVideoCaption 設定影片的預設字幕，同一影片只保留一個預設
+func (r *PostgreSQLRepo) SetDefaultVideoCaption(videoID, captionID uint) error {
+	return r.PostgreSQLDB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.VideoCaption{}).
+			Where("video_id = ? AND id <> ? AND is_default = ?", videoID, captionID, true).
+			Update("is_default", false).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.VideoCaption{}).Where("id = ?", captionID).Update("is_default", true).Error
+	})
+}
@@ -0,0 +1,43 @@
+package postgresql
+
+import (
+	"stream-demo/backend/database/models"
+
+	"gorm.io/gorm"
+)
+
+// CreateVideoClip 創建精華片段影片及其剪輯資訊
+// originalKey 依新影片ID產生剪輯輸出的原始檔 Key，與影片同一交易內寫入
+func (r *PostgreSQLRepo) CreateVideoClip(video *models.Video, clip *models.VideoClip, originalKey func(videoID uint) string) error {
+	return r.PostgreSQLDB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(video).Error; err != nil {
+			return err
+		}
+
+		video.OriginalKey = originalKey(video.ID)
+		if err := tx.Model(video).Update("original_key", video.OriginalKey).Error; err != nil {
+			return err
+		}
+
+		clip.VideoID = video.ID
+		return tx.Create(clip).Error
+	})
+}
+
+// FindVideoClipByVideoID 根據片段影片ID查找剪輯資訊
+func (r *PostgreSQLRepo) FindVideoClipByVideoID(videoID uint) (*models.VideoClip, error) {
+	var clip models.VideoClip
+	if err := r.PostgreSQLDB.Where("video_id = ?", videoID).First(&clip).Error; err != nil {
+		return nil, err
+	}
+	return &clip, nil
+}
+
+// FindVideoClipsBySourceVideoID 查找來源影片的所有精華片段
+func (r *PostgreSQLRepo) FindVideoClipsBySourceVideoID(sourceVideoID uint) ([]models.VideoClip, error) {
+	var clips []models.VideoClip
+	if err := r.PostgreSQLDB.Where("source_video_id = ?", sourceVideoID).Order("created_at DESC").Find(&clips).Error; err != nil {
+		return nil, err
+	}
+	return clips, nil
+}
@@ -0,0 +1,185 @@
+package postgresql
+
+import (
+	"time"
+
+	"stream-demo/backend/database/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 留言排序方式
+const (
+	CommentSortTop    = "top"    // 喜歡數由多到少
+	CommentSortNewest = "newest" // 由新到舊
+	CommentSortOldest = "oldest" // 由舊到新（回覆列表）
+)
+
+// VideoCommentFilter 留言列表查詢條件
+type VideoCommentFilter struct {
+	VideoID       uint
+	ParentID      *uint // nil 時查詢主留言
+	ViewerID      uint  // 隱藏的留言只有留言者本人看得到
+	IncludeHidden bool  // 影片擁有者與管理員可看到所有隱藏留言
+	ExcludePinned bool
+	ExcludeUsers  []uint // 與瀏覽者有封鎖關係的用戶留言不顯示
+	Sort          string
+	HasCursor     bool
+	CursorKey     int64 // top 為喜歡數，其他為建立時間(微秒)
+	CursorID      uint
+	Limit         int
+}
+
+// CreateVideoComment 建立留言，回覆時同時更新主留言的回覆數
+func (r *PostgreSQLRepo) CreateVideoComment(comment *models.VideoComment) error {
+	return r.PostgreSQLDB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(comment).Error; err != nil {
+			return err
+		}
+		if comment.ParentID == nil {
+			return nil
+		}
+		return tx.Model(&models.VideoComment{}).Where("id = ?", *comment.ParentID).
+			UpdateColumn("reply_count", gorm.Expr("reply_count + 1")).Error
+	})
+}
+
+// FindVideoCommentByID 根據ID查找留言
+func (r *PostgreSQLRepo) FindVideoCommentByID(id uint) (*models.VideoComment, error) {
+	var comment models.VideoComment
+	if err := r.PostgreSQLDB.Preload("User").Preload("ReplyToUser").First(&comment, id).Error; err != nil {
+		return nil, err
+	}
+	return &comment, nil
+}
+
+// UpdateVideoCommentFields 更新留言欄位
+func (r *PostgreSQLRepo) UpdateVideoCommentFields(id uint, updates map[string]interface{}) error {
+	return r.PostgreSQLDB.Model(&models.VideoComment{}).Where("id = ?", id).Updates(updates).Error
+}
+
+// DeleteVideoComment 刪除留言與其回覆，刪除回覆時同時更新主留言的回覆數
+func (r *PostgreSQLRepo) DeleteVideoComment(comment *models.VideoComment) error {
+	return r.PostgreSQLDB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("id = ? OR parent_id = ?", comment.ID, comment.ID).Delete(&models.VideoComment{}).Error; err != nil {
+			return err
+		}
+		if comment.ParentID == nil {
+			return nil
+		}
+		return tx.Model(&models.VideoComment{}).Where("id = ?", *comment.ParentID).
+			UpdateColumn("reply_count", gorm.Expr("GREATEST(reply_count - 1, 0)")).Error
+	})
+}
+
+// FindVideoComments 依排序與游標查詢留言
+func (r *PostgreSQLRepo) FindVideoComments(filter VideoCommentFilter) ([]models.VideoComment, error) {
+	query := r.PostgreSQLDB.Preload("User").Preload("ReplyToUser").Where("video_id = ?", filter.VideoID)
+	if filter.ParentID == nil {
+		query = query.Where("parent_id IS NULL")
+	} else {
+		query = query.Where("parent_id = ?", *filter.ParentID)
+	}
+	if !filter.IncludeHidden {
+		query = query.Where("status = ? OR user_id = ?", models.CommentStatusVisible, filter.ViewerID)
+	}
+	if filter.ExcludePinned {
+		query = query.Where("pinned = ?", false)
+	}
+	if len(filter.ExcludeUsers) > 0 {
+		query = query.Where("user_id NOT IN ?", filter.ExcludeUsers)
+	}
+
+	switch filter.Sort {
+	case CommentSortTop:
+		if filter.HasCursor {
+			query = query.Where("(likes, id) < (?, ?)", filter.CursorKey, filter.CursorID)
+		}
+		query = query.Order("likes DESC, id DESC")
+	case CommentSortOldest:
+		if filter.HasCursor {
+			query = query.Where("(created_at, id) > (?, ?)", time.UnixMicro(filter.CursorKey), filter.CursorID)
+		}
+		query = query.Order("created_at ASC, id ASC")
+	default:
+		if filter.HasCursor {
+			query = query.Where("(created_at, id) < (?, ?)", time.UnixMicro(filter.CursorKey), filter.CursorID)
+		}
+		query = query.Order("created_at DESC, id DESC")
+	}
+
+	var comments []models.VideoComment
+	if err := query.Limit(filter.Limit).Find(&comments).Error; err != nil {
+		return nil, err
+	}
+	return comments, nil
+}
+
+// FindPinnedVideoComment 查找影片的置頂留言
+func (r *PostgreSQLRepo) FindPinnedVideoComment(videoID uint) (*models.VideoComment, error) {
+	var comment models.VideoComment
+	err := r.PostgreSQLDB.Preload("User").
+		Where("video_id = ? AND parent_id IS NULL AND pinned = ?", videoID, true).
+		First(&comment).Error
+	if err != nil {
+		return nil, err
+	}
+	return &comment, nil
+}
+
+// PinVideoComment 置頂留言，每部影片只有一則置頂留言
+func (r *PostgreSQLRepo) PinVideoComment(videoID, commentID uint) error {
+	return r.PostgreSQLDB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.VideoComment{}).
+			Where("video_id = ? AND pinned = ? AND id <> ?", videoID, true, commentID).
+			UpdateColumn("pinned", false).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.VideoComment{}).Where("id = ?", commentID).UpdateColumn("pinned", true).Error
+	})
+}
+
+// UnpinVideoComment 取消置頂留言
+func (r *PostgreSQLRepo) UnpinVideoComment(commentID uint) error {
+	return r.PostgreSQLDB.Model(&models.VideoComment{}).Where("id = ?", commentID).UpdateColumn("pinned", false).Error
+}
+
+// LikeVideoComment 喜歡留言，重複喜歡不重複計數
+func (r *PostgreSQLRepo) LikeVideoComment(commentID, userID uint) error {
+	return r.PostgreSQLDB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.VideoCommentLike{
+			CommentID: commentID,
+			UserID:    userID,
+		})
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		return tx.Model(&models.VideoComment{}).Where("id = ?", commentID).
+			UpdateColumn("likes", gorm.Expr("likes + 1")).Error
+	})
+}
+
+// UnlikeVideoComment 取消喜歡留言，未喜歡時不變更計數
+func (r *PostgreSQLRepo) UnlikeVideoComment(commentID, userID uint) error {
+	return r.PostgreSQLDB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("comment_id = ? AND user_id = ?", commentID, userID).Delete(&models.VideoCommentLike{})
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		return tx.Model(&models.VideoComment{}).Where("id = ?", commentID).
+			UpdateColumn("likes", gorm.Expr("GREATEST(likes - 1, 0)")).Error
+	})
+}
+
+// FindLikedVideoCommentIDs 查找用戶喜歡的留言ID
+func (r *PostgreSQLRepo) FindLikedVideoCommentIDs(userID uint, commentIDs []uint) ([]uint, error) {
+	var ids []uint
+	if len(commentIDs) == 0 {
+		return ids, nil
+	}
+	err := r.PostgreSQLDB.Model(&models.VideoCommentLike{}).
+		Where("user_id = ? AND comment_id IN ?", userID, commentIDs).
+		Pluck("comment_id", &ids).Error
+	return ids, err
+}
@@ -0,0 +1,70 @@
+package postgresql
+
+import (
+	"time"
+
+	"stream-demo/backend/database/models"
+
+	"gorm.io/gorm"
+)
+
+// FindReadyVideoByContentSHA256 查找同一用戶內容相同且已完成處理的影片
+func (r *PostgreSQLRepo) FindReadyVideoByContentSHA256(userID uint, contentSHA256 string, excludeID uint) (*models.Video, error) {
+	var video models.Video
+	if err := r.PostgreSQLDB.
+		Where("user_id = ? AND content_sha256 = ? AND status = ? AND id <> ?", userID, contentSHA256, "ready", excludeID).
+		Order("created_at ASC").
+		First(&video).Error; err != nil {
+		return nil, err
+	}
+	return &video, nil
+}
+
+// CountRenditionReferences 計算除 excludeID 外仍使用某影片轉碼輸出的記錄數（含垃圾桶中的影片）
+func (r *PostgreSQLRepo) CountRenditionReferences(ownerID, excludeID uint) (int64, error) {
+	var count int64
+	err := r.PostgreSQLDB.Unscoped().Model(&models.Video{}).
+		Where("(id = ? OR renditions_from_id = ?) AND id <> ?", ownerID, ownerID, excludeID).
+		Count(&count).Error
+	return count, err
+}
+
+// LinkVideoRenditions 讓影片共用來源影片的轉碼結果，並複製畫質與音軌資訊
+func (r *PostgreSQLRepo) LinkVideoRenditions(videoID, sourceVideoID uint, updates map[string]interface{}) error {
+	return r.PostgreSQLDB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Video{}).Where("id = ?", videoID).Updates(updates).Error; err != nil {
+			return err
+		}
+
+		var qualities []models.VideoQuality
+		if err := tx.Where("video_id = ?", sourceVideoID).Find(&qualities).Error; err != nil {
+			return err
+		}
+		for i := range qualities {
+			qualities[i].ID = 0
+			qualities[i].VideoID = videoID
+			qualities[i].CreatedAt = time.Time{}
+			qualities[i].UpdatedAt = time.Time{}
+		}
+		if len(qualities) > 0 {
+			if err := tx.Create(&qualities).Error; err != nil {
+				return err
+			}
+		}
+
+		var tracks []models.VideoAudioTrack
+		if err := tx.Where("video_id = ?", sourceVideoID).Find(&tracks).Error; err != nil {
+			return err
+		}
+		for i := range tracks {
+			tracks[i].ID = 0
+			tracks[i].VideoID = videoID
+			tracks[i].CreatedAt = time.Time{}
+			tracks[i].UpdatedAt = time.Time{}
+		}
+		if len(tracks) > 0 {
+			return tx.Create(&tracks).Error
+		}
+		return nil
+	})
+}
@@ -0,0 +1,14 @@
+package postgresql
+
+import (
+	"stream-demo/backend/database/models"
+)
+
+// FindVideoEncryptionKey 根據影片與金鑰序號查找內容金鑰
+func (r *PostgreSQLRepo) FindVideoEncryptionKey(videoID uint, keyIndex int) (*models.VideoEncryptionKey, error) {
+	var key models.VideoEncryptionKey
+	if err := r.PostgreSQLDB.Where("video_id = ? AND key_index = ?", videoID, keyIndex).First(&key).Error; err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
@@ -0,0 +1,114 @@
+package postgresql
+
+import (
+	"errors"
+
+	"stream-demo/backend/database/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// VideoReactionCount 影片各反應的數量
+type VideoReactionCount struct {
+	Type  string
+	Emoji string
+	Count int64
+}
+
+// SetVideoReaction 設定用戶對影片的反應，回傳原本的反應（沒有時為 nil）與是否有變更
+func (r *PostgreSQLRepo) SetVideoReaction(videoID, userID uint, reactionType, emoji string) (*models.VideoReaction, bool, error) {
+	var previous *models.VideoReaction
+	changed := false
+
+	err := r.PostgreSQLDB.Transaction(func(tx *gorm.DB) error {
+		var current models.VideoReaction
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("video_id = ? AND user_id = ?", videoID, userID).
+			First(&current).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			// 同時送出的請求已建立時不重複建立
+			result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.VideoReaction{
+				VideoID: videoID,
+				UserID:  userID,
+				Type:    reactionType,
+				Emoji:   emoji,
+			})
+			changed = result.RowsAffected > 0
+			return result.Error
+		}
+		if err != nil {
+			return err
+		}
+
+		if current.Type == reactionType && current.Emoji == emoji {
+			return nil
+		}
+		previous = &current
+		changed = true
+		return tx.Model(&models.VideoReaction{}).Where("id = ?", current.ID).Updates(map[string]interface{}{
+			"type":  reactionType,
+			"emoji": emoji,
+		}).Error
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	return previous, changed, nil
+}
+
+// DeleteVideoReaction 移除用戶對影片的反應，reactionType 不為空時只移除該類型，回傳被移除的反應
+func (r *PostgreSQLRepo) DeleteVideoReaction(videoID, userID uint, reactionType string) (*models.VideoReaction, error) {
+	var removed []models.VideoReaction
+	query := r.PostgreSQLDB.Clauses(clause.Returning{}).Where("video_id = ? AND user_id = ?", videoID, userID)
+	if reactionType != "" {
+		query = query.Where("type = ?", reactionType)
+	}
+	if err := query.Delete(&removed).Error; err != nil {
+		return nil, err
+	}
+	if len(removed) == 0 {
+		return nil, nil
+	}
+	return &removed[0], nil
+}
+
+// FindVideoReaction 查找用戶對影片的反應
+func (r *PostgreSQLRepo) FindVideoReaction(videoID, userID uint) (*models.VideoReaction, error) {
+	var reaction models.VideoReaction
+	if err := r.PostgreSQLDB.Where("video_id = ? AND user_id = ?", videoID, userID).First(&reaction).Error; err != nil {
+		return nil, err
+	}
+	return &reaction, nil
+}
+
+// FindVideoReactionsByUser 查找用戶對多部影片的反應
+func (r *PostgreSQLRepo) FindVideoReactionsByUser(userID uint, videoIDs []uint) ([]models.VideoReaction, error) {
+	var reactions []models.VideoReaction
+	if len(videoIDs) == 0 {
+		return reactions, nil
+	}
+	if err := r.PostgreSQLDB.Where("user_id = ? AND video_id IN ?", userID, videoIDs).Find(&reactions).Error; err != nil {
+		return nil, err
+	}
+	return reactions, nil
+}
+
+// CountVideoReactions 統計影片各反應的數量
+func (r *PostgreSQLRepo) CountVideoReactions(videoID uint) ([]VideoReactionCount, error) {
+	var counts []VideoReactionCount
+	err := r.PostgreSQLDB.Model(&models.VideoReaction{}).
+		Select("type, emoji, COUNT(*) AS count").
+		Where("video_id = ?", videoID).
+		Group("type, emoji").
+		Scan(&counts).Error
+	return counts, err
+}
+
+// UpdateVideoReactionTotals 寫入影片的喜歡與不喜歡數（不更新 updated_at）
+func (r *PostgreSQLRepo) UpdateVideoReactionTotals(videoID uint, likes, dislikes int64) error {
+	return r.PostgreSQLDB.Model(&models.Video{}).Where("id = ?", videoID).UpdateColumns(map[string]interface{}{
+		"likes":    likes,
+		"dislikes": dislikes,
+	}).Error
+}
@@ -0,0 +1,259 @@
+package postgresql
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"stream-demo/backend/database/models"
+	"stream-demo/backend/utils"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 全文搜尋以 simple 設定建立 tsvector（不做英文詞幹處理，中英混合標題不會被錯誤切割），
+// 中文等無空白分隔的文字則以 ILIKE 與 pg_trgm 三元組相似度補足
+const (
+	searchDocument = "to_tsvector('simple', coalesce(videos.title, '') || ' ' || coalesce(videos.description, ''))"
+	searchTSQuery  = "websearch_to_tsquery('simple', ?)"
+)
+
+// trigramSupport 各資料庫連線是否已安裝 pg_trgm（遷移時擴展為選用），首次搜尋時查詢並快取
+var trigramSupport sync.Map
+
+// 搜尋篩選略過的維度（計算該維度的分面統計時不套用自身的篩選）
+const (
+	searchFacetDuration   = "duration"
+	searchFacetUploadDate = "upload_date"
+	searchFacetStatus     = "status"
+	searchFacetCreator    = "creator"
+)
+
+// 搜尋結果分面的影片長度分界（秒）
+const (
+	SearchShortDurationMax  = 4 * 60
+	SearchMediumDurationMax = 20 * 60
+)
+
+// VideoSearchFilter 影片搜尋條件
+type VideoSearchFilter struct {
+	ViewerID      uint
+	Query         string
+	Statuses      []string
+	MinDuration   int // 秒，0 表示不限
+	MaxDuration   int // 秒（不含），0 表示不限
+	UploadedAfter time.Time
+	UserID        uint
+	Sort          string // relevance, views, likes, newest
+	Offset        int
+	Limit         int
+}
+
+// VideoSearchFacets 搜尋結果分面統計
+type VideoSearchFacets struct {
+	Duration   map[string]int64
+	UploadDate map[string]int64
+	Status     map[string]int64
+	Creators   []VideoSearchCreatorFacet
+}
+
+// VideoSearchCreatorFacet 創作者分面統計
+type VideoSearchCreatorFacet struct {
+	UserID   uint
+	Username string
+	Count    int64
+}
+
+// SearchVideos 全文搜尋檢視者可見的影片，於資料庫端排序與分頁
+func (r *PostgreSQLRepo) SearchVideos(filter *VideoSearchFilter) ([]models.Video, int64, error) {
+	trigram := r.hasTrigram()
+
+	var total int64
+	if err := r.PostgreSQLDB.Model(&models.Video{}).Scopes(searchScope(filter, "", trigram)).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var videos []models.Video
+	err := r.PostgreSQLDB.Preload("User").
+		Scopes(searchScope(filter, "", trigram)).
+		Order(searchOrder(filter, trigram)).
+		Offset(filter.Offset).
+		Limit(filter.Limit).
+		Find(&videos).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return videos, total, nil
+}
+
+// SearchVideoFacets 統計搜尋結果的分面，每個維度套用其他維度的篩選
+func (r *PostgreSQLRepo) SearchVideoFacets(filter *VideoSearchFilter, uploadDateSince map[string]time.Time) (*VideoSearchFacets, error) {
+	facets := &VideoSearchFacets{
+		Duration:   map[string]int64{},
+		UploadDate: map[string]int64{},
+		Status:     map[string]int64{},
+	}
+	trigram := r.hasTrigram()
+
+	var buckets []struct {
+		Value string
+		Count int64
+	}
+
+	durationBucket := fmt.Sprintf(
+		"CASE WHEN videos.duration < %d THEN 'short' WHEN videos.duration < %d THEN 'medium' ELSE 'long' END",
+		SearchShortDurationMax, SearchMediumDurationMax,
+	)
+	if err := r.PostgreSQLDB.Model(&models.Video{}).
+		Scopes(searchScope(filter, searchFacetDuration, trigram)).
+		Select(durationBucket + " AS value, COUNT(*) AS count").
+		Group("value").
+		Scan(&buckets).Error; err != nil {
+		return nil, err
+	}
+	for _, bucket := range buckets {
+		facets.Duration[bucket.Value] = bucket.Count
+	}
+
+	buckets = nil
+	if err := r.PostgreSQLDB.Model(&models.Video{}).
+		Scopes(searchScope(filter, searchFacetStatus, trigram)).
+		Select("videos.status AS value, COUNT(*) AS count").
+		Group("videos.status").
+		Scan(&buckets).Error; err != nil {
+		return nil, err
+	}
+	for _, bucket := range buckets {
+		facets.Status[bucket.Value] = bucket.Count
+	}
+
+	// 上傳日期為累計區間（今天 ⊂ 本週 ⊂ 本月 ⊂ 今年），逐一以 FILTER 計數
+	if len(uploadDateSince) > 0 {
+		names := make([]string, 0, len(uploadDateSince))
+		selects := make([]string, 0, len(uploadDateSince))
+		args := make([]interface{}, 0, len(uploadDateSince))
+		for name, since := range uploadDateSince {
+			names = append(names, name)
+			selects = append(selects, fmt.Sprintf("COUNT(*) FILTER (WHERE videos.created_at >= ?) AS c%d", len(selects)))
+			args = append(args, since)
+		}
+
+		counts := make([]int64, len(names))
+		dest := make([]interface{}, len(names))
+		for i := range counts {
+			dest[i] = &counts[i]
+		}
+		if err := r.PostgreSQLDB.Model(&models.Video{}).
+			Scopes(searchScope(filter, searchFacetUploadDate, trigram)).
+			Select(strings.Join(selects, ", "), args...).
+			Row().Scan(dest...); err != nil {
+			return nil, err
+		}
+		for i, name := range names {
+			facets.UploadDate[name] = counts[i]
+		}
+	}
+
+	if err := r.PostgreSQLDB.Model(&models.Video{}).
+		Scopes(searchScope(filter, searchFacetCreator, trigram)).
+		Joins("JOIN users ON users.id = videos.user_id").
+		Select("videos.user_id AS user_id, users.username AS username, COUNT(*) AS count").
+		Group("videos.user_id, users.username").
+		Order("count DESC").
+		Limit(10).
+		Scan(&facets.Creators).Error; err != nil {
+		return nil, err
+	}
+
+	return facets, nil
+}
+
+// hasTrigram 檢查資料庫是否已安裝 pg_trgm 擴展，查詢失敗時視為未安裝且不快取
+func (r *PostgreSQLRepo) hasTrigram() bool {
+	if installed, ok := trigramSupport.Load(r.PostgreSQLDB); ok {
+		return installed.(bool)
+	}
+
+	var installed bool
+	if err := r.PostgreSQLDB.Raw("SELECT EXISTS (SELECT 1 FROM pg_extension WHERE extname = 'pg_trgm')").Scan(&installed).Error; err != nil {
+		utils.LogWarn("檢查 pg_trgm 擴展失敗: %v", err)
+		return false
+	}
+	if !installed {
+		utils.LogWarn("未安裝 pg_trgm 擴展，影片搜尋不使用三元組相似度")
+	}
+	trigramSupport.Store(r.PostgreSQLDB, installed)
+	return installed
+}
+
+// searchScope 套用可見度、關鍵字與篩選條件，skip 指定的維度不套用
+// trigram 為 false 時（未安裝 pg_trgm）省略三元組相似比對
+func searchScope(filter *VideoSearchFilter, skip string, trigram bool) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		pattern := "%" + escapeLikePattern(filter.Query) + "%"
+		db = db.Scopes(visibleTo(filter.ViewerID))
+		if trigram {
+			db = db.Where(
+				fmt.Sprintf("(%s @@ %s OR videos.title ILIKE ? OR videos.description ILIKE ? OR videos.title %% ?)", searchDocument, searchTSQuery),
+				filter.Query, pattern, pattern, filter.Query,
+			)
+		} else {
+			db = db.Where(
+				fmt.Sprintf("(%s @@ %s OR videos.title ILIKE ? OR videos.description ILIKE ?)", searchDocument, searchTSQuery),
+				filter.Query, pattern, pattern,
+			)
+		}
+
+		if skip != searchFacetStatus && len(filter.Statuses) > 0 {
+			db = db.Where("videos.status IN ?", filter.Statuses)
+		}
+		if skip != searchFacetDuration {
+			if filter.MinDuration > 0 {
+				db = db.Where("videos.duration >= ?", filter.MinDuration)
+			}
+			if filter.MaxDuration > 0 {
+				db = db.Where("videos.duration < ?", filter.MaxDuration)
+			}
+		}
+		if skip != searchFacetUploadDate && !filter.UploadedAfter.IsZero() {
+			db = db.Where("videos.created_at >= ?", filter.UploadedAfter)
+		}
+		if skip != searchFacetCreator && filter.UserID != 0 {
+			db = db.Where("videos.user_id = ?", filter.UserID)
+		}
+		return db
+	}
+}
+
+// searchOrder 依排序方式產生 ORDER BY，相關度為全文排名加上標題三元組相似度（未安裝 pg_trgm 時僅用全文排名）
+// 同分時依影片ID新到舊排序；gorm 合併 ORDER BY 子句時會以後者的 Expression 覆蓋前者，因此次要排序需寫在同一個子句中
+func searchOrder(filter *VideoSearchFilter, trigram bool) interface{} {
+	switch filter.Sort {
+	case "views":
+		return "videos.views DESC, videos.id DESC"
+	case "likes":
+		return "videos.likes DESC, videos.id DESC"
+	case "newest":
+		return "videos.created_at DESC, videos.id DESC"
+	default:
+		rank := fmt.Sprintf("ts_rank(%s, %s)", searchDocument, searchTSQuery)
+		vars := []interface{}{filter.Query}
+		if trigram {
+			rank += " + similarity(videos.title, ?)"
+			vars = append(vars, filter.Query)
+		}
+		return clause.OrderBy{Expression: clause.Expr{
+			SQL:                rank + " DESC, videos.id DESC",
+			Vars:               vars,
+			WithoutParentheses: true,
+		}}
+	}
+}
+
+// escapeLikePattern 跳脫 LIKE 萬用字元，讓關鍵字中的 % 與 _ 以字面比對
+func escapeLikePattern(value string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(value)
+}
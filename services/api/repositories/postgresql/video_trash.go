@@ -0,0 +1,61 @@
+package postgresql
+
+import (
+	"time"
+
+	"stream-demo/backend/database/models"
+
+	"gorm.io/gorm"
+)
+
+// FindTrashedVideosByUserID 查找用戶垃圾桶中的影片
+func (r *PostgreSQLRepo) FindTrashedVideosByUserID(userID uint) ([]models.Video, error) {
+	var videos []models.Video
+	if err := r.PostgreSQLDB.Unscoped().
+		Where("user_id = ? AND deleted_at IS NOT NULL", userID).
+		Order("deleted_at DESC").
+		Find(&videos).Error; err != nil {
+		return nil, err
+	}
+	return videos, nil
+}
+
+// FindTrashedVideo 查找垃圾桶中的影片
+func (r *PostgreSQLRepo) FindTrashedVideo(id uint) (*models.Video, error) {
+	var video models.Video
+	if err := r.PostgreSQLDB.Unscoped().Where("id = ? AND deleted_at IS NOT NULL", id).First(&video).Error; err != nil {
+		return nil, err
+	}
+	return &video, nil
+}
+
+// RestoreVideo 從垃圾桶還原影片
+func (r *PostgreSQLRepo) RestoreVideo(id uint) error {
+	return r.PostgreSQLDB.Unscoped().Model(&models.Video{}).Where("id = ?", id).Update("deleted_at", nil).Error
+}
+
+// FindExpiredTrashedVideos 查找刪除時間早於 before 的影片
+func (r *PostgreSQLRepo) FindExpiredTrashedVideos(before time.Time, limit int) ([]models.Video, error) {
+	var videos []models.Video
+	if err := r.PostgreSQLDB.Unscoped().
+		Where("deleted_at IS NOT NULL AND deleted_at < ?", before).
+		Order("deleted_at ASC").
+		Limit(limit).
+		Find(&videos).Error; err != nil {
+		return nil, err
+	}
+	return videos, nil
+}
+
+// PurgeVideo 永久刪除影片記錄及其剪輯、字幕資訊（畫質、音軌、金鑰與分享授權由外鍵串聯刪除）
+func (r *PostgreSQLRepo) PurgeVideo(id uint) error {
+	return r.PostgreSQLDB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("video_id = ?", id).Delete(&models.VideoClip{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("video_id = ?", id).Delete(&models.VideoCaption{}).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Delete(&models.Video{}, id).Error
+	})
+}
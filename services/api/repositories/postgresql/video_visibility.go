@@ -0,0 +1,75 @@
+package postgresql
+
+import (
+	"stream-demo/backend/database/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// visibleTo 限制為可出現在用戶列表與搜尋的影片：公開影片、自己的影片、獲得授權的私人影片與追蹤中創作者的限追隨者影片
+// 不公開影片只能以分享代碼觀看，不會出現在其他用戶的列表中
+func visibleTo(viewerID uint) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where(
+			"(videos.visibility = ? OR videos.user_id = ?"+
+				" OR (videos.visibility = ? AND EXISTS (SELECT 1 FROM video_share_grants WHERE video_share_grants.video_id = videos.id AND video_share_grants.user_id = ?))"+
+				" OR (videos.visibility = ? AND EXISTS (SELECT 1 FROM follows WHERE follows.followee_id = videos.user_id AND follows.follower_id = ?)))",
+			models.VideoVisibilityPublic, viewerID,
+			models.VideoVisibilityPrivate, viewerID,
+			models.VideoVisibilityFollowers, viewerID,
+		)
+	}
+}
+
+// FindVisibleVideosByUserID 查找指定用戶的影片中檢視者可見的部分
+func (r *PostgreSQLRepo) FindVisibleVideosByUserID(userID, viewerID uint) ([]models.Video, error) {
+	var videos []models.Video
+	if err := r.PostgreSQLDB.Scopes(visibleTo(viewerID)).
+		Where("user_id = ?", userID).
+		Order("created_at DESC").
+		Find(&videos).Error; err != nil {
+		return nil, err
+	}
+	return videos, nil
+}
+
+// FindVideoByShareSlug 根據分享代碼查找影片
+func (r *PostgreSQLRepo) FindVideoByShareSlug(slug string) (*models.Video, error) {
+	var video models.Video
+	if err := r.PostgreSQLDB.Preload("User").Where("share_slug = ?", slug).First(&video).Error; err != nil {
+		return nil, err
+	}
+	return &video, nil
+}
+
+// HasVideoShareGrant 檢查用戶是否獲得私人影片授權
+func (r *PostgreSQLRepo) HasVideoShareGrant(videoID, userID uint) (bool, error) {
+	var count int64
+	err := r.PostgreSQLDB.Model(&models.VideoShareGrant{}).
+		Where("video_id = ? AND user_id = ?", videoID, userID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// CreateVideoShareGrant 授權用戶觀看私人影片（已授權時不重複建立）
+func (r *PostgreSQLRepo) CreateVideoShareGrant(grant *models.VideoShareGrant) error {
+	return r.PostgreSQLDB.Clauses(clause.OnConflict{DoNothing: true}).Create(grant).Error
+}
+
+// DeleteVideoShareGrant 撤銷私人影片授權
+func (r *PostgreSQLRepo) DeleteVideoShareGrant(videoID, userID uint) error {
+	return r.PostgreSQLDB.Where("video_id = ? AND user_id = ?", videoID, userID).Delete(&models.VideoShareGrant{}).Error
+}
+
+// FindVideoShareGrants 查找私人影片的授權用戶
+func (r *PostgreSQLRepo) FindVideoShareGrants(videoID uint) ([]models.VideoShareGrant, error) {
+	var grants []models.VideoShareGrant
+	if err := r.PostgreSQLDB.Preload("User").
+		Where("video_id = ?", videoID).
+		Order("created_at ASC").
+		Find(&grants).Error; err != nil {
+		return nil, err
+	}
+	return grants, nil
+}
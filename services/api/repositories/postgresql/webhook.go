@@ -0,0 +1,107 @@
+package postgresql
+
+import (
+	"time"
+
+	"stream-demo/backend/database/models"
+)
+
+// CreateWebhookEndpoint 建立 Webhook 端點
+func (r *PostgreSQLRepo) CreateWebhookEndpoint(endpoint *models.WebhookEndpoint) error {
+	return r.PostgreSQLDB.Create(endpoint).Error
+}
+
+// FindWebhookEndpointByID 根據ID查找 Webhook 端點
+func (r *PostgreSQLRepo) FindWebhookEndpointByID(id uint) (*models.WebhookEndpoint, error) {
+	var endpoint models.WebhookEndpoint
+	if err := r.PostgreSQLDB.First(&endpoint, id).Error; err != nil {
+		return nil, err
+	}
+	return &endpoint, nil
+}
+
+// FindWebhookEndpointsByUserID 查找用戶的 Webhook 端點
+func (r *PostgreSQLRepo) FindWebhookEndpointsByUserID(userID uint) ([]models.WebhookEndpoint, error) {
+	var endpoints []models.WebhookEndpoint
+	err := r.PostgreSQLDB.Where("user_id = ?", userID).Order("id ASC").Find(&endpoints).Error
+	return endpoints, err
+}
+
+// CountWebhookEndpointsByUserID 統計用戶的 Webhook 端點數
+func (r *PostgreSQLRepo) CountWebhookEndpointsByUserID(userID uint) (int64, error) {
+	var count int64
+	err := r.PostgreSQLDB.Model(&models.WebhookEndpoint{}).Where("user_id = ?", userID).Count(&count).Error
+	return count, err
+}
+
+// FindWebhookEndpointsForOwner 查找會收到該用戶事件的啟用端點：用戶自己的端點與接收所有用戶事件的端點
+func (r *PostgreSQLRepo) FindWebhookEndpointsForOwner(ownerID uint) ([]models.WebhookEndpoint, error) {
+	var endpoints []models.WebhookEndpoint
+	err := r.PostgreSQLDB.Where("enabled = ? AND (user_id = ? OR all_users = ?)", true, ownerID, true).Find(&endpoints).Error
+	return endpoints, err
+}
+
+// UpdateWebhookEndpoint 更新 Webhook 端點
+func (r *PostgreSQLRepo) UpdateWebhookEndpoint(endpoint *models.WebhookEndpoint) error {
+	return r.PostgreSQLDB.Save(endpoint).Error
+}
+
+// DeleteWebhookEndpoint 刪除 Webhook 端點與其投遞記錄
+func (r *PostgreSQLRepo) DeleteWebhookEndpoint(id uint) error {
+	if err := r.PostgreSQLDB.Where("endpoint_id = ?", id).Delete(&models.WebhookDelivery{}).Error; err != nil {
+		return err
+	}
+	return r.PostgreSQLDB.Delete(&models.WebhookEndpoint{}, id).Error
+}
+
+// CreateWebhookDeliveries 批次建立投遞記錄
+func (r *PostgreSQLRepo) CreateWebhookDeliveries(deliveries []*models.WebhookDelivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+	return r.PostgreSQLDB.Create(deliveries).Error
+}
+
+// FindWebhookDeliveryByID 根據ID查找投遞記錄
+func (r *PostgreSQLRepo) FindWebhookDeliveryByID(id uint) (*models.WebhookDelivery, error) {
+	var delivery models.WebhookDelivery
+	if err := r.PostgreSQLDB.First(&delivery, id).Error; err != nil {
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+// FindWebhookDeliveries 分頁查找端點的投遞記錄，新的在前（不含內容）
+func (r *PostgreSQLRepo) FindWebhookDeliveries(endpointID uint, offset, limit int) ([]models.WebhookDelivery, int64, error) {
+	var deliveries []models.WebhookDelivery
+	var total int64
+
+	query := r.PostgreSQLDB.Model(&models.WebhookDelivery{}).Where("endpoint_id = ?", endpointID)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	if err := query.Omit("payload").Order("created_at DESC, id DESC").Offset(offset).Limit(limit).Find(&deliveries).Error; err != nil {
+		return nil, 0, err
+	}
+	return deliveries, total, nil
+}
+
+// FindPendingWebhookDeliveries 查找等待投遞的記錄，供重新排入佇列
+func (r *PostgreSQLRepo) FindPendingWebhookDeliveries() ([]models.WebhookDelivery, error) {
+	var deliveries []models.WebhookDelivery
+	err := r.PostgreSQLDB.Select("id, next_attempt_at").
+		Where("status = ?", models.WebhookDeliveryPending).
+		Find(&deliveries).Error
+	return deliveries, err
+}
+
+// UpdateWebhookDelivery 更新投遞記錄
+func (r *PostgreSQLRepo) UpdateWebhookDelivery(delivery *models.WebhookDelivery) error {
+	return r.PostgreSQLDB.Save(delivery).Error
+}
+
+// DeleteWebhookDeliveriesBefore 清除早於指定時間且已結束的投遞記錄，回傳清除數
+func (r *PostgreSQLRepo) DeleteWebhookDeliveriesBefore(before time.Time) (int64, error) {
+	result := r.PostgreSQLDB.Where("created_at < ? AND status <> ?", before, models.WebhookDeliveryPending).Delete(&models.WebhookDelivery{})
+	return result.RowsAffected, result.Error
+}
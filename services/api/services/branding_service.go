@@ -0,0 +1,222 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"stream-demo/backend/config"
+	"stream-demo/backend/database/models"
+	"stream-demo/backend/dto"
+	"stream-demo/backend/pkg/storage"
+	postgresqlRepo "stream-demo/backend/repositories/postgresql"
+
+	"gorm.io/gorm"
+)
+
+// watermarkPositions 浮水印可用位置
+var watermarkPositions = map[string]bool{
+	"top-left":     true,
+	"top-right":    true,
+	"bottom-left":  true,
+	"bottom-right": true,
+	"center":       true,
+}
+
+// BrandingService 創作者品牌設定服務
+// 設定只影響之後轉碼的影片，已完成的影片不會重新處理
+type BrandingService struct {
+	Conf      *config.Config
+	Repo      *postgresqlRepo.PostgreSQLRepo
+	RepoSlave *postgresqlRepo.PostgreSQLRepo
+	S3Storage *storage.S3Storage
+}
+
+// NewBrandingService 創建品牌設定服務
+func NewBrandingService(conf *config.Config, s3Storage *storage.S3Storage) *BrandingService {
+	return &BrandingService{
+		Conf:      conf,
+		Repo:      postgresqlRepo.NewPostgreSQLRepo(conf.DB["master"]),
+		RepoSlave: postgresqlRepo.NewPostgreSQLRepo(conf.DB["slave"]),
+		S3Storage: s3Storage,
+	}
+}
+
+// ValidateWatermarkSettings 檢查浮水印位置、透明度與縮放比例
+func ValidateWatermarkSettings(position string, opacity, scale float64) error {
+	if !watermarkPositions[position] {
+		return fmt.Errorf("無效的浮水印位置: %s", position)
+	}
+	if opacity <= 0 || opacity > 1 {
+		return fmt.Errorf("浮水印透明度必須介於 0 到 1 之間")
+	}
+	if scale <= 0 || scale > 0.5 {
+		return fmt.Errorf("浮水印縮放比例必須介於 0 到 0.5 之間")
+	}
+	return nil
+}
+
+// GetBranding 獲取用戶的品牌設定，尚未設定時回傳預設值
+func (s *BrandingService) GetBranding(userID uint) (*dto.UserBrandingDTO, error) {
+	branding, err := s.findOrDefault(userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.newUserBrandingDTO(branding), nil
+}
+
+// UpdateBranding 更新用戶的品牌設定
+func (s *BrandingService) UpdateBranding(userID uint, req *dto.UserBrandingUpdateDTO) (*dto.UserBrandingDTO, error) {
+	branding, err := s.findOrDefault(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Enabled != nil {
+		branding.Enabled = *req.Enabled
+	}
+	if req.WatermarkPosition != nil {
+		branding.WatermarkPosition = *req.WatermarkPosition
+	}
+	if req.WatermarkOpacity != nil {
+		branding.WatermarkOpacity = *req.WatermarkOpacity
+	}
+	if req.WatermarkScale != nil {
+		branding.WatermarkScale = *req.WatermarkScale
+	}
+	if err := ValidateWatermarkSettings(branding.WatermarkPosition, branding.WatermarkOpacity, branding.WatermarkScale); err != nil {
+		return nil, err
+	}
+
+	if req.WatermarkKey != nil {
+		if err := s.validateWatermarkKey(userID, *req.WatermarkKey); err != nil {
+			return nil, err
+		}
+		branding.WatermarkKey = *req.WatermarkKey
+	}
+
+	if req.IntroVideoID != nil {
+		if branding.IntroVideoID, err = s.resolveBrandingVideo(userID, *req.IntroVideoID); err != nil {
+			return nil, err
+		}
+	}
+	if req.OutroVideoID != nil {
+		if branding.OutroVideoID, err = s.resolveBrandingVideo(userID, *req.OutroVideoID); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.Repo.SaveUserBranding(branding); err != nil {
+		return nil, fmt.Errorf("更新品牌設定失敗: %v", err)
+	}
+	return s.newUserBrandingDTO(branding), nil
+}
+
+// DeleteBranding 清除用戶的品牌設定
+func (s *BrandingService) DeleteBranding(userID uint) error {
+	if err := s.Repo.DeleteUserBranding(userID); err != nil {
+		return fmt.Errorf("刪除品牌設定失敗: %v", err)
+	}
+	return nil
+}
+
+// CreateWatermarkUploadURL 產生浮水印圖片的預簽名上傳URL
+func (s *BrandingService) CreateWatermarkUploadURL(userID uint, filename string) (*dto.BrandingWatermarkUploadDTO, error) {
+	if s.S3Storage == nil {
+		return nil, fmt.Errorf("S3 儲存未初始化")
+	}
+
+	ext := strings.ToLower(filepath.Ext(filename))
+	if ext != ".png" && ext != ".jpg" && ext != ".jpeg" {
+		return nil, fmt.Errorf("不支援的浮水印格式，僅支援 .png、.jpg")
+	}
+
+	upload, err := s.S3Storage.GeneratePresignedUploadURLForKey(storage.BrandingWatermarkKey(userID, ext), ext)
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.BrandingWatermarkUploadDTO{
+		UploadURL: upload.UploadURL,
+		FormData:  upload.FormData,
+		Key:       upload.Key,
+	}, nil
+}
+
+// findOrDefault 查找品牌設定，不存在時建立未儲存的預設設定
+func (s *BrandingService) findOrDefault(userID uint) (*models.UserBranding, error) {
+	branding, err := s.Repo.FindUserBranding(userID)
+	if err == nil {
+		return branding, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("獲取品牌設定失敗: %v", err)
+	}
+
+	return &models.UserBranding{
+		UserID:            userID,
+		Enabled:           true,
+		WatermarkPosition: "bottom-right",
+		WatermarkOpacity:  0.8,
+		WatermarkScale:    0.15,
+	}, nil
+}
+
+// validateWatermarkKey 浮水印只能引用自己上傳且已存在的圖片，空字串表示移除
+func (s *BrandingService) validateWatermarkKey(userID uint, key string) error {
+	if key == "" {
+		return nil
+	}
+	if !strings.HasPrefix(key, fmt.Sprintf("branding/%d/", userID)) {
+		return fmt.Errorf("無效的浮水印檔案")
+	}
+
+	if s.S3Storage != nil {
+		exists, err := s.S3Storage.CheckFileExists(key)
+		if err != nil {
+			return fmt.Errorf("檢查浮水印檔案失敗: %v", err)
+		}
+		if !exists {
+			return fmt.Errorf("浮水印檔案尚未上傳")
+		}
+	}
+	return nil
+}
+
+// resolveBrandingVideo 片頭/片尾只能引用自己已完成處理的影片，0 表示移除
+func (s *BrandingService) resolveBrandingVideo(userID, videoID uint) (*uint, error) {
+	if videoID == 0 {
+		return nil, nil
+	}
+
+	video, err := s.RepoSlave.FindVideoByID(videoID)
+	if err != nil {
+		return nil, fmt.Errorf("找不到影片: %v", err)
+	}
+	if video.UserID != userID {
+		return nil, fmt.Errorf("只能使用自己的影片作為片頭或片尾")
+	}
+	if video.Status != "ready" {
+		return nil, fmt.Errorf("片頭或片尾影片尚未處理完成")
+	}
+	return &video.ID, nil
+}
+
+// newUserBrandingDTO 將品牌設定轉換為 DTO
+func (s *BrandingService) newUserBrandingDTO(branding *models.UserBranding) *dto.UserBrandingDTO {
+	result := &dto.UserBrandingDTO{
+		Enabled:           branding.Enabled,
+		WatermarkKey:      branding.WatermarkKey,
+		WatermarkPosition: branding.WatermarkPosition,
+		WatermarkOpacity:  branding.WatermarkOpacity,
+		WatermarkScale:    branding.WatermarkScale,
+		IntroVideoID:      branding.IntroVideoID,
+		OutroVideoID:      branding.OutroVideoID,
+		UpdatedAt:         branding.UpdatedAt,
+	}
+	if branding.WatermarkKey != "" && s.S3Storage != nil {
+		result.WatermarkURL = s.S3Storage.GenerateCDNURL(branding.WatermarkKey)
+	}
+	return result
+}
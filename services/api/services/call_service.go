@@ -0,0 +1,910 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"stream-demo/backend/config"
+	"stream-demo/backend/database/models"
+	"stream-demo/backend/dto"
+	postgresqlRepo "stream-demo/backend/repositories/postgresql"
+	"stream-demo/backend/utils"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// 推送給通話成員的事件，經由私訊 WebSocket 送出
+const (
+	CallEventInvite   = "call_invite"   // 來電，data 為通話狀態
+	CallEventRinging  = "call_ringing"  // 受邀者的裝置開始響鈴
+	CallEventAccepted = "call_accepted" // 有成員接聽，data 為通話狀態
+	CallEventRejected = "call_rejected" // 有成員拒接
+	CallEventLeft     = "call_left"     // 有成員離開
+	CallEventMissed   = "call_missed"   // 響鈴逾時，data 含未接的 user_ids
+	CallEventEnded    = "call_ended"    // 通話結束，data 為最終狀態
+	CallEventSignal   = "call_signal"   // WebRTC 信令
+)
+
+// 信令類型
+const (
+	CallSignalOffer  = "offer"
+	CallSignalAnswer = "answer"
+	CallSignalICE    = "ice"
+)
+
+const (
+	callSweepInterval = 5 * time.Second  // 檢查到期通話的間隔
+	callCheckInterval = 60 * time.Second // 通話中檢查成員是否仍在線的間隔
+	callStateTTL      = 3 * callCheckInterval
+	callUpdateRetries = 5
+	callSignalMaxSize = 64 * 1024
+	callPageDefault   = 20
+	callPageMax       = 50
+
+	// callDeadlinesKey 待檢查的通話，score 為下次檢查時間（unix 秒）
+	callDeadlinesKey = "call:deadlines"
+)
+
+var (
+	// ErrCallNotFound 通話不存在、已結束或不是通話成員
+	ErrCallNotFound = errors.New("通話不存在或已結束")
+	// ErrCallBusy 用戶已在其他通話中
+	ErrCallBusy = errors.New("正在其他通話中")
+	// ErrCalleeBusy 受邀者都在其他通話中
+	ErrCalleeBusy = errors.New("對方正在通話中")
+	// ErrCallUnavailable 未連線 Redis 時無法通話
+	ErrCallUnavailable = errors.New("通話服務未啟用")
+)
+
+// releaseCallUserScript 只在用戶仍標記為指定通話時移除，避免清掉後來的通話
+var releaseCallUserScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0`)
+
+// refreshCallUserScript 只在用戶仍標記為指定通話時延長期限
+var refreshCallUserScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("EXPIRE", KEYS[1], ARGV[2])
+end
+return 0`)
+
+// CallParticipantState 通話成員的即時狀態
+type CallParticipantState struct {
+	UserID   uint       `json:"user_id"`
+	Username string     `json:"username"`
+	Status   string     `json:"status"`
+	JoinedAt *time.Time `json:"joined_at,omitempty"`
+	LeftAt   *time.Time `json:"left_at,omitempty"`
+}
+
+// CallState 進行中的通話，存放在 Redis call:state:<call_id>，任一實例都能讀取並更新
+type CallState struct {
+	ID             uint                   `json:"id"` // 通話紀錄ID
+	CallID         string                 `json:"call_id"`
+	ConversationID uint                   `json:"conversation_id"`
+	CallerID       uint                   `json:"caller_id"`
+	Type           string                 `json:"type"`
+	Status         string                 `json:"status"`
+	CreatedAt      time.Time              `json:"created_at"`
+	RingDeadline   time.Time              `json:"ring_deadline"`
+	AnsweredAt     *time.Time             `json:"answered_at,omitempty"`
+	EndedAt        *time.Time             `json:"ended_at,omitempty"`
+	Participants   []CallParticipantState `json:"participants"`
+}
+
+// Participant 查找通話成員，不是成員時回傳 nil
+func (c *CallState) Participant(userID uint) *CallParticipantState {
+	for i := range c.Participants {
+		if c.Participants[i].UserID == userID {
+			return &c.Participants[i]
+		}
+	}
+	return nil
+}
+
+// UserIDsWithStatus 指定狀態的成員ID，未指定時回傳所有曾收到來電的成員（不含忙線）
+func (c *CallState) UserIDsWithStatus(statuses ...string) []uint {
+	var ids []uint
+	for _, participant := range c.Participants {
+		if len(statuses) == 0 {
+			if participant.Status != models.CallParticipantBusy {
+				ids = append(ids, participant.UserID)
+			}
+			continue
+		}
+		for _, status := range statuses {
+			if participant.Status == status {
+				ids = append(ids, participant.UserID)
+				break
+			}
+		}
+	}
+	return ids
+}
+
+// Outcome 依成員狀態判斷通話狀態與是否已結束
+// 接聽前：發起者離開為 cancelled；無人響鈴時有人未接為 missed，全部拒接為 rejected
+// 接聽後：通話中的成員少於兩人即結束，結果為 completed
+func (c *CallState) Outcome() (string, bool) {
+	var joined, invited, missed int
+	for _, participant := range c.Participants {
+		switch participant.Status {
+		case models.CallParticipantJoined:
+			joined++
+		case models.CallParticipantInvited:
+			invited++
+		case models.CallParticipantMissed:
+			missed++
+		}
+	}
+
+	if c.AnsweredAt != nil {
+		if joined >= 2 {
+			return models.CallStatusActive, false
+		}
+		return models.CallStatusCompleted, true
+	}
+	if caller := c.Participant(c.CallerID); caller == nil || caller.Status != models.CallParticipantJoined {
+		return models.CallStatusCancelled, true
+	}
+	if invited > 0 {
+		return models.CallStatusRinging, false
+	}
+	if missed > 0 {
+		return models.CallStatusMissed, true
+	}
+	return models.CallStatusRejected, true
+}
+
+// Finish 結束通話：仍在通話中的成員記為離開，仍在響鈴的記為未接
+func (c *CallState) Finish(status string, now time.Time) {
+	c.Status = status
+	c.EndedAt = &now
+	for i := range c.Participants {
+		participant := &c.Participants[i]
+		switch participant.Status {
+		case models.CallParticipantJoined:
+			participant.Status = models.CallParticipantLeft
+			participant.LeftAt = &now
+		case models.CallParticipantInvited:
+			participant.Status = models.CallParticipantMissed
+		}
+	}
+}
+
+// Duration 接聽到結束的秒數，未接聽為 0
+func (c *CallState) Duration() int {
+	if c.AnsweredAt == nil || c.EndedAt == nil {
+		return 0
+	}
+	return int(c.EndedAt.Sub(*c.AnsweredAt).Seconds())
+}
+
+// TURNCredentials 依 TURN REST API 的規則產生有時效的憑證
+// username 為「到期時間:用戶ID」，password 為以共用密鑰對 username 計算的 HMAC-SHA1（base64），coturn 以 use-auth-secret 驗證
+func TURNCredentials(secret string, userID uint, ttl time.Duration, now time.Time) (string, string, time.Time) {
+	expiresAt := now.Add(ttl)
+	username := fmt.Sprintf("%d:%d", expiresAt.Unix(), userID)
+	mac := hmac.New(sha1.New, []byte(secret))
+	mac.Write([]byte(username))
+	return username, base64.StdEncoding.EncodeToString(mac.Sum(nil)), expiresAt
+}
+
+// CallService 音訊/視訊通話的信令服務
+// 通話狀態存放在 Redis，事件經由私訊的推送管道送到成員所在的實例；結束時寫入通話紀錄
+type CallService struct {
+	Conf      *config.Config
+	Repo      *postgresqlRepo.PostgreSQLRepo
+	RepoSlave *postgresqlRepo.PostgreSQLRepo
+
+	conversations *ConversationService // 會話成員、封鎖檢查與事件推送
+	presence      *PresenceService     // 已離線的成員視為離開通話
+
+	stopChan chan struct{}
+	ticker   *time.Ticker
+}
+
+// NewCallService 創建通話服務
+func NewCallService(conf *config.Config, conversationService *ConversationService, presenceService *PresenceService) *CallService {
+	return &CallService{
+		Conf:          conf,
+		Repo:          postgresqlRepo.NewPostgreSQLRepo(conf.DB["master"]),
+		RepoSlave:     postgresqlRepo.NewPostgreSQLRepo(conf.DB["slave"]),
+		conversations: conversationService,
+		presence:      presenceService,
+		stopChan:      make(chan struct{}),
+	}
+}
+
+// Start 啟動響鈴逾時與斷線檢查
+func (s *CallService) Start() {
+	s.ticker = time.NewTicker(callSweepInterval)
+
+	go func() {
+		for {
+			select {
+			case <-s.ticker.C:
+				s.sweep()
+			case <-s.stopChan:
+				s.ticker.Stop()
+				return
+			}
+		}
+	}()
+
+	utils.LogInfo("通話服務已啟動，檢查間隔: %v", callSweepInterval)
+}
+
+// Stop 停止檢查
+func (s *CallService) Stop() {
+	close(s.stopChan)
+	utils.LogInfo("通話服務已停止")
+}
+
+// Invite 在會話中發起通話，其他成員收到來電；正在其他通話中的成員記為忙線
+func (s *CallService) Invite(userID uint, req *dto.CallInviteDTO) (*dto.CallDTO, error) {
+	client := utils.GetRedisClient()
+	if client == nil {
+		return nil, ErrCallUnavailable
+	}
+	if req.Type != models.CallTypeAudio && req.Type != models.CallTypeVideo {
+		return nil, fmt.Errorf("無效的通話類型")
+	}
+
+	conversation, err := s.conversations.memberConversation(s.RepoSlave, userID, req.ConversationID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.conversations.checkDirectBlocked(conversation, userID); err != nil {
+		return nil, err
+	}
+	if len(conversation.Members) > s.Conf.Call.MaxParticipants {
+		return nil, fmt.Errorf("通話人數不能超過 %d 人", s.Conf.Call.MaxParticipants)
+	}
+
+	ctx := context.Background()
+	callID := uuid.New().String()
+	if _, err := s.acquireUser(ctx, client, userID, callID); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	state := &CallState{
+		CallID:         callID,
+		ConversationID: conversation.ID,
+		CallerID:       userID,
+		Type:           req.Type,
+		Status:         models.CallStatusRinging,
+		CreatedAt:      now,
+		RingDeadline:   now.Add(time.Duration(s.Conf.Call.RingTimeout) * time.Second),
+	}
+	invitees := otherMemberIDs(conversation.Members, userID)
+	busy := s.busyUsers(ctx, client, invitees)
+	for _, member := range conversation.Members {
+		participant := CallParticipantState{UserID: member.UserID, Status: models.CallParticipantInvited}
+		if member.User != nil {
+			participant.Username = member.User.Username
+		}
+		switch {
+		case member.UserID == userID:
+			participant.Status = models.CallParticipantJoined
+			participant.JoinedAt = &now
+		case busy[member.UserID]:
+			participant.Status = models.CallParticipantBusy
+		}
+		state.Participants = append(state.Participants, participant)
+	}
+	if len(state.UserIDsWithStatus(models.CallParticipantInvited)) == 0 {
+		s.releaseUser(ctx, client, userID, callID)
+		return nil, ErrCalleeBusy
+	}
+
+	record := state.toModel()
+	record.CreatedAt = now
+	if err := s.Repo.CreateCall(record); err != nil {
+		s.releaseUser(ctx, client, userID, callID)
+		return nil, fmt.Errorf("建立通話紀錄失敗: %v", err)
+	}
+	state.ID = record.ID
+
+	data, err := json.Marshal(state)
+	if err != nil {
+		s.releaseUser(ctx, client, userID, callID)
+		return nil, err
+	}
+	_, err = client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, callStateKey(callID), data, callStateTTL)
+		pipe.ZAdd(ctx, callDeadlinesKey, redis.Z{Score: float64(state.RingDeadline.Unix()), Member: callID})
+		return nil
+	})
+	if err != nil {
+		s.releaseUser(ctx, client, userID, callID)
+		return nil, fmt.Errorf("儲存通話狀態失敗: %v", err)
+	}
+
+	result := newCallStateDTO(state)
+	s.conversations.publish(state.UserIDsWithStatus(models.CallParticipantInvited), CallEventInvite, result)
+	return result, nil
+}
+
+// Ringing 受邀者的裝置已收到來電並開始響鈴，通知已在通話中的成員
+func (s *CallService) Ringing(userID uint, callID string) error {
+	state, err := s.getCall(callID)
+	if err != nil {
+		return err
+	}
+	participant := state.Participant(userID)
+	if participant == nil {
+		return ErrCallNotFound
+	}
+	if participant.Status != models.CallParticipantInvited {
+		return fmt.Errorf("通話已不在響鈴中")
+	}
+
+	s.conversations.publish(state.UserIDsWithStatus(models.CallParticipantJoined), CallEventRinging, map[string]interface{}{
+		"call_id": callID,
+		"user_id": userID,
+	})
+	return nil
+}
+
+// Accept 接聽通話，用戶同時只能在一個通話中
+func (s *CallService) Accept(userID uint, callID string) (*dto.CallDTO, error) {
+	client := utils.GetRedisClient()
+	if client == nil {
+		return nil, ErrCallUnavailable
+	}
+
+	ctx := context.Background()
+	acquired, err := s.acquireUser(ctx, client, userID, callID)
+	if err != nil {
+		return nil, err
+	}
+	state, _, err := s.updateCall(ctx, client, callID, func(state *CallState, now time.Time) error {
+		participant := state.Participant(userID)
+		if participant == nil {
+			return ErrCallNotFound
+		}
+		if participant.Status != models.CallParticipantInvited {
+			return fmt.Errorf("無法接聽此通話")
+		}
+		participant.Status = models.CallParticipantJoined
+		participant.JoinedAt = &now
+		if state.AnsweredAt == nil {
+			state.AnsweredAt = &now
+		}
+		return nil
+	})
+	if err != nil {
+		if acquired {
+			s.releaseUser(ctx, client, userID, callID)
+		}
+		return nil, err
+	}
+
+	// 接聽者的其他裝置也會收到，用來停止響鈴
+	result := newCallStateDTO(state)
+	s.conversations.publish(state.UserIDsWithStatus(models.CallParticipantInvited, models.CallParticipantJoined), CallEventAccepted, result)
+	return result, nil
+}
+
+// Reject 拒接通話
+func (s *CallService) Reject(userID uint, callID string) error {
+	client := utils.GetRedisClient()
+	if client == nil {
+		return ErrCallUnavailable
+	}
+
+	ctx := context.Background()
+	state, ended, err := s.updateCall(ctx, client, callID, func(state *CallState, now time.Time) error {
+		participant := state.Participant(userID)
+		if participant == nil {
+			return ErrCallNotFound
+		}
+		if participant.Status != models.CallParticipantInvited {
+			return fmt.Errorf("無法拒接此通話")
+		}
+		participant.Status = models.CallParticipantDeclined
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	recipients := append(state.UserIDsWithStatus(models.CallParticipantInvited, models.CallParticipantJoined), userID)
+	s.conversations.publish(recipients, CallEventRejected, map[string]interface{}{
+		"call_id": callID,
+		"user_id": userID,
+	})
+	if ended {
+		s.finishCall(ctx, client, state)
+	}
+	return nil
+}
+
+// Hangup 離開通話；發起者在接聽前掛斷即取消通話，響鈴中的受邀者掛斷視為拒接
+func (s *CallService) Hangup(userID uint, callID string) error {
+	client := utils.GetRedisClient()
+	if client == nil {
+		return ErrCallUnavailable
+	}
+
+	ctx := context.Background()
+	event := CallEventLeft
+	state, ended, err := s.updateCall(ctx, client, callID, func(state *CallState, now time.Time) error {
+		participant := state.Participant(userID)
+		if participant == nil {
+			return ErrCallNotFound
+		}
+		switch participant.Status {
+		case models.CallParticipantJoined:
+			participant.Status = models.CallParticipantLeft
+			participant.LeftAt = &now
+			event = CallEventLeft
+		case models.CallParticipantInvited:
+			participant.Status = models.CallParticipantDeclined
+			event = CallEventRejected
+		default:
+			return ErrCallNotFound
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	s.releaseUser(ctx, client, userID, callID)
+	recipients := append(state.UserIDsWithStatus(models.CallParticipantInvited, models.CallParticipantJoined), userID)
+	s.conversations.publish(recipients, event, map[string]interface{}{
+		"call_id": callID,
+		"user_id": userID,
+	})
+	if ended {
+		s.finishCall(ctx, client, state)
+	}
+	return nil
+}
+
+// Signal 轉發 SDP offer/answer 或 ICE candidate，雙方都必須已在通話中
+func (s *CallService) Signal(userID uint, req *dto.CallSignalDTO) error {
+	switch req.Kind {
+	case CallSignalOffer, CallSignalAnswer, CallSignalICE:
+	default:
+		return fmt.Errorf("無效的信令類型")
+	}
+	if len(req.Payload) == 0 {
+		return fmt.Errorf("信令內容不能為空")
+	}
+	if len(req.Payload) > callSignalMaxSize {
+		return fmt.Errorf("信令內容過長")
+	}
+	if req.ToUserID == userID {
+		return fmt.Errorf("無效的信令對象")
+	}
+
+	state, err := s.getCall(req.CallID)
+	if err != nil {
+		return err
+	}
+	sender := state.Participant(userID)
+	if sender == nil {
+		return ErrCallNotFound
+	}
+	if sender.Status != models.CallParticipantJoined {
+		return fmt.Errorf("尚未加入通話")
+	}
+	if target := state.Participant(req.ToUserID); target == nil || target.Status != models.CallParticipantJoined {
+		return fmt.Errorf("對方不在通話中")
+	}
+
+	s.conversations.publish([]uint{req.ToUserID}, CallEventSignal, &dto.CallSignalEventDTO{
+		CallID:     req.CallID,
+		FromUserID: userID,
+		Kind:       req.Kind,
+		Payload:    req.Payload,
+	})
+	return nil
+}
+
+// UserDisconnected 用戶的連線中斷，已完全離線時離開目前的通話
+func (s *CallService) UserDisconnected(userID uint) {
+	client := utils.GetRedisClient()
+	if client == nil {
+		return
+	}
+
+	callID, err := client.Get(context.Background(), callUserKey(userID)).Result()
+	if err != nil {
+		if !errors.Is(err, redis.Nil) {
+			utils.LogError("查詢用戶 %d 的通話失敗: %v", userID, err)
+		}
+		return
+	}
+	if s.presence != nil && s.presence.Lookup([]uint{userID})[userID].Online {
+		return
+	}
+	if err := s.Hangup(userID, callID); err != nil && !errors.Is(err, ErrCallNotFound) {
+		utils.LogError("用戶 %d 斷線離開通話失敗: %v", userID, err)
+	}
+}
+
+// ListCalls 分頁列出用戶的通話紀錄，最新的在前
+func (s *CallService) ListCalls(userID uint, offset, limit int) ([]*dto.CallDTO, int64, error) {
+	if offset < 0 {
+		offset = 0
+	}
+	if limit <= 0 {
+		limit = callPageDefault
+	}
+	if limit > callPageMax {
+		limit = callPageMax
+	}
+
+	calls, total, err := s.RepoSlave.FindUserCalls(userID, offset, limit)
+	if err != nil {
+		return nil, 0, fmt.Errorf("獲取通話紀錄失敗: %v", err)
+	}
+
+	result := make([]*dto.CallDTO, len(calls))
+	for i := range calls {
+		result[i] = newCallDTO(&calls[i])
+	}
+	return result, total, nil
+}
+
+// ICEServers 產生 WebRTC 使用的 STUN/TURN 伺服器與用戶專屬的 TURN 憑證
+func (s *CallService) ICEServers(userID uint) *dto.ICEServersDTO {
+	conf := s.Conf.Call
+	ttl := time.Duration(conf.CredentialTTL) * time.Second
+	result := &dto.ICEServersDTO{ICEServers: []dto.ICEServerDTO{}, ExpiresAt: time.Now().Add(ttl)}
+
+	if len(conf.STUNURLs) > 0 {
+		result.ICEServers = append(result.ICEServers, dto.ICEServerDTO{URLs: conf.STUNURLs})
+	}
+	if len(conf.TURNURLs) > 0 && conf.TURNSecret != "" {
+		username, password, expiresAt := TURNCredentials(conf.TURNSecret, userID, ttl, time.Now())
+		result.ICEServers = append(result.ICEServers, dto.ICEServerDTO{
+			URLs:       conf.TURNURLs,
+			Username:   username,
+			Credential: password,
+		})
+		result.ExpiresAt = expiresAt
+	}
+	return result
+}
+
+// sweep 處理到期的通話，多個實例同時掃描時由成功移除排程的實例負責
+func (s *CallService) sweep() {
+	client := utils.GetRedisClient()
+	if client == nil {
+		return
+	}
+
+	ctx := context.Background()
+	callIDs, err := client.ZRangeByScore(ctx, callDeadlinesKey, &redis.ZRangeBy{
+		Min: "-inf",
+		Max: strconv.FormatInt(time.Now().Unix(), 10),
+	}).Result()
+	if err != nil {
+		utils.LogError("查詢到期通話失敗: %v", err)
+		return
+	}
+
+	for _, callID := range callIDs {
+		removed, err := client.ZRem(ctx, callDeadlinesKey, callID).Result()
+		if err != nil || removed == 0 {
+			continue
+		}
+		s.checkCall(ctx, client, callID)
+	}
+}
+
+// checkCall 響鈴逾時的受邀者記為未接，已離線的成員記為離開，通話未結束時排定下次檢查
+func (s *CallService) checkCall(ctx context.Context, client *redis.Client, callID string) {
+	current, err := s.getCall(callID)
+	if err != nil {
+		return
+	}
+	offline := make(map[uint]bool)
+	if s.presence != nil {
+		for userID, presence := range s.presence.Lookup(current.UserIDsWithStatus(models.CallParticipantJoined)) {
+			offline[userID] = !presence.Online
+		}
+	}
+
+	var missedIDs, leftIDs []uint
+	state, ended, err := s.updateCall(ctx, client, callID, func(state *CallState, now time.Time) error {
+		missedIDs, leftIDs = nil, nil
+		for i := range state.Participants {
+			participant := &state.Participants[i]
+			switch {
+			case participant.Status == models.CallParticipantInvited && !now.Before(state.RingDeadline):
+				participant.Status = models.CallParticipantMissed
+				missedIDs = append(missedIDs, participant.UserID)
+			case participant.Status == models.CallParticipantJoined && offline[participant.UserID]:
+				participant.Status = models.CallParticipantLeft
+				participant.LeftAt = &now
+				leftIDs = append(leftIDs, participant.UserID)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		if !errors.Is(err, ErrCallNotFound) {
+			utils.LogError("檢查通話 %s 失敗: %v", callID, err)
+		}
+		return
+	}
+
+	recipients := state.UserIDsWithStatus(models.CallParticipantInvited, models.CallParticipantJoined)
+	if len(missedIDs) > 0 {
+		s.conversations.publish(append(recipients, missedIDs...), CallEventMissed, map[string]interface{}{
+			"call_id":  callID,
+			"user_ids": missedIDs,
+		})
+	}
+	for _, userID := range leftIDs {
+		s.releaseUser(ctx, client, userID, callID)
+		s.conversations.publish(recipients, CallEventLeft, map[string]interface{}{
+			"call_id": callID,
+			"user_id": userID,
+		})
+	}
+	if ended {
+		s.finishCall(ctx, client, state)
+		return
+	}
+
+	// 延長通話中成員的忙線標記，實例異常終止時標記會在期限後自動失效
+	for _, userID := range state.UserIDsWithStatus(models.CallParticipantJoined) {
+		if err := refreshCallUserScript.Run(ctx, client, []string{callUserKey(userID)}, callID, int(callStateTTL.Seconds())).Err(); err != nil {
+			utils.LogError("延長用戶 %d 通話標記失敗: %v", userID, err)
+		}
+	}
+	next := time.Now().Add(callCheckInterval)
+	if len(state.UserIDsWithStatus(models.CallParticipantInvited)) > 0 && state.RingDeadline.Before(next) {
+		next = state.RingDeadline
+	}
+	if err := client.ZAdd(ctx, callDeadlinesKey, redis.Z{Score: float64(next.Unix()), Member: callID}).Err(); err != nil {
+		utils.LogError("排定通話 %s 檢查失敗: %v", callID, err)
+	}
+}
+
+// getCall 讀取通話狀態
+func (s *CallService) getCall(callID string) (*CallState, error) {
+	client := utils.GetRedisClient()
+	if client == nil {
+		return nil, ErrCallUnavailable
+	}
+
+	data, err := client.Get(context.Background(), callStateKey(callID)).Bytes()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, ErrCallNotFound
+		}
+		return nil, fmt.Errorf("讀取通話狀態失敗: %v", err)
+	}
+	var state CallState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("解析通話狀態失敗: %v", err)
+	}
+	return &state, nil
+}
+
+// updateCall 以 WATCH 樂觀鎖更新通話狀態，其他實例同時修改時重試
+// 更新後依成員狀態重新計算通話狀態，已結束時移除 Redis 中的狀態並回傳 ended
+func (s *CallService) updateCall(ctx context.Context, client *redis.Client, callID string, update func(state *CallState, now time.Time) error) (*CallState, bool, error) {
+	key := callStateKey(callID)
+	for attempt := 0; attempt < callUpdateRetries; attempt++ {
+		var state CallState
+		var ended bool
+		err := client.Watch(ctx, func(tx *redis.Tx) error {
+			data, err := tx.Get(ctx, key).Bytes()
+			if err != nil {
+				if errors.Is(err, redis.Nil) {
+					return ErrCallNotFound
+				}
+				return err
+			}
+			if err := json.Unmarshal(data, &state); err != nil {
+				return err
+			}
+
+			now := time.Now()
+			if err := update(&state, now); err != nil {
+				return err
+			}
+			var status string
+			status, ended = state.Outcome()
+			if ended {
+				state.Finish(status, now)
+			} else {
+				state.Status = status
+			}
+
+			encoded, err := json.Marshal(&state)
+			if err != nil {
+				return err
+			}
+			_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+				if ended {
+					pipe.Del(ctx, key)
+				} else {
+					pipe.Set(ctx, key, encoded, callStateTTL)
+				}
+				return nil
+			})
+			return err
+		}, key)
+		if errors.Is(err, redis.TxFailedErr) {
+			continue
+		}
+		if err != nil {
+			return nil, false, err
+		}
+		return &state, ended, nil
+	}
+	return nil, false, fmt.Errorf("通話狀態更新衝突，請重試")
+}
+
+// finishCall 通話結束：清除成員的忙線標記與檢查排程、寫入通話紀錄並通知成員
+func (s *CallService) finishCall(ctx context.Context, client *redis.Client, state *CallState) {
+	for _, participant := range state.Participants {
+		s.releaseUser(ctx, client, participant.UserID, state.CallID)
+	}
+	if err := client.ZRem(ctx, callDeadlinesKey, state.CallID).Err(); err != nil {
+		utils.LogError("移除通話 %s 檢查排程失敗: %v", state.CallID, err)
+	}
+
+	if err := s.Repo.FinishCall(state.toModel()); err != nil {
+		utils.LogError("寫入通話 %s 紀錄失敗: %v", state.CallID, err)
+	}
+	s.conversations.publish(state.UserIDsWithStatus(), CallEventEnded, newCallStateDTO(state))
+}
+
+// acquireUser 標記用戶正在通話中，回傳是否為新建立的標記；已在其他通話時回傳 ErrCallBusy
+func (s *CallService) acquireUser(ctx context.Context, client *redis.Client, userID uint, callID string) (bool, error) {
+	key := callUserKey(userID)
+	ok, err := client.SetNX(ctx, key, callID, callStateTTL).Result()
+	if err != nil {
+		return false, fmt.Errorf("更新通話狀態失敗: %v", err)
+	}
+	if ok {
+		return true, nil
+	}
+	if current, err := client.Get(ctx, key).Result(); err == nil && current == callID {
+		return false, nil
+	}
+	return false, ErrCallBusy
+}
+
+// releaseUser 移除用戶的通話標記，已屬於其他通話時保留
+func (s *CallService) releaseUser(ctx context.Context, client *redis.Client, userID uint, callID string) {
+	if err := releaseCallUserScript.Run(ctx, client, []string{callUserKey(userID)}, callID).Err(); err != nil {
+		utils.LogError("移除用戶 %d 通話標記失敗: %v", userID, err)
+	}
+}
+
+// busyUsers 查詢正在其他通話中的用戶
+func (s *CallService) busyUsers(ctx context.Context, client *redis.Client, userIDs []uint) map[uint]bool {
+	busy := make(map[uint]bool, len(userIDs))
+	if len(userIDs) == 0 {
+		return busy
+	}
+
+	cmds := make([]*redis.IntCmd, len(userIDs))
+	_, err := client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i, userID := range userIDs {
+			cmds[i] = pipe.Exists(ctx, callUserKey(userID))
+		}
+		return nil
+	})
+	if err != nil {
+		utils.LogError("查詢通話中的用戶失敗: %v", err)
+		return busy
+	}
+	for i, userID := range userIDs {
+		busy[userID] = cmds[i].Val() > 0
+	}
+	return busy
+}
+
+// toModel 轉換為通話紀錄
+func (c *CallState) toModel() *models.Call {
+	call := &models.Call{
+		ID:             c.ID,
+		CallID:         c.CallID,
+		ConversationID: c.ConversationID,
+		CallerID:       c.CallerID,
+		Type:           c.Type,
+		Status:         c.Status,
+		AnsweredAt:     c.AnsweredAt,
+		EndedAt:        c.EndedAt,
+		Duration:       c.Duration(),
+		Participants:   make([]models.CallParticipant, len(c.Participants)),
+	}
+	for i, participant := range c.Participants {
+		call.Participants[i] = models.CallParticipant{
+			UserID:   participant.UserID,
+			Status:   participant.Status,
+			JoinedAt: participant.JoinedAt,
+			LeftAt:   participant.LeftAt,
+		}
+	}
+	return call
+}
+
+// newCallStateDTO 轉換進行中的通話
+func newCallStateDTO(state *CallState) *dto.CallDTO {
+	result := &dto.CallDTO{
+		CallID:         state.CallID,
+		ConversationID: state.ConversationID,
+		CallerID:       state.CallerID,
+		Type:           state.Type,
+		Status:         state.Status,
+		Participants:   make([]*dto.CallParticipantDTO, len(state.Participants)),
+		CreatedAt:      state.CreatedAt,
+		AnsweredAt:     state.AnsweredAt,
+		EndedAt:        state.EndedAt,
+		Duration:       state.Duration(),
+	}
+	for i, participant := range state.Participants {
+		result.Participants[i] = &dto.CallParticipantDTO{
+			UserID:   participant.UserID,
+			Username: participant.Username,
+			Status:   participant.Status,
+			JoinedAt: participant.JoinedAt,
+			LeftAt:   participant.LeftAt,
+		}
+	}
+	return result
+}
+
+// newCallDTO 轉換通話紀錄
+func newCallDTO(call *models.Call) *dto.CallDTO {
+	result := &dto.CallDTO{
+		CallID:         call.CallID,
+		ConversationID: call.ConversationID,
+		CallerID:       call.CallerID,
+		Type:           call.Type,
+		Status:         call.Status,
+		Participants:   make([]*dto.CallParticipantDTO, len(call.Participants)),
+		CreatedAt:      call.CreatedAt,
+		AnsweredAt:     call.AnsweredAt,
+		EndedAt:        call.EndedAt,
+		Duration:       call.Duration,
+	}
+	for i, participant := range call.Participants {
+		item := &dto.CallParticipantDTO{
+			UserID:   participant.UserID,
+			Status:   participant.Status,
+			JoinedAt: participant.JoinedAt,
+			LeftAt:   participant.LeftAt,
+		}
+		if participant.User != nil {
+			item.Username = participant.User.Username
+		}
+		result.Participants[i] = item
+	}
+	return result
+}
+
+// callStateKey 通話狀態的 Redis key
+func callStateKey(callID string) string {
+	return "call:state:" + callID
+}
+
+// callUserKey 用戶目前所在通話的 Redis key，用於忙線判斷
+func callUserKey(userID uint) string {
+	return fmt.Sprintf("call:user:%d", userID)
+}
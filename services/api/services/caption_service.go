@@ -0,0 +1,337 @@
+package services
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"stream-demo/backend/config"
+	"stream-demo/backend/database/models"
+	"stream-demo/backend/dto"
+	"stream-demo/backend/pkg/storage"
+	postgresqlRepo "stream-demo/backend/repositories/postgresql"
+	"stream-demo/backend/utils"
+)
+
+// captionLanguagePattern BCP 47 語言標籤（主語言 + 選用的文字/地區子標籤）
+var captionLanguagePattern = regexp.MustCompile(`^[A-Za-z]{2,3}(-[A-Za-z0-9]{2,8})*$`)
+
+// CaptionService 字幕服務
+// 字幕檔以預簽名URL上傳到原始桶，確認後由 converter 轉為 WebVTT 分段並寫入 HLS 主播放列表
+type CaptionService struct {
+	Conf      *config.Config
+	Repo      *postgresqlRepo.PostgreSQLRepo
+	RepoSlave *postgresqlRepo.PostgreSQLRepo
+	S3Storage *storage.S3Storage
+}
+
+// NewCaptionService 創建字幕服務
+func NewCaptionService(conf *config.Config, s3Storage *storage.S3Storage) *CaptionService {
+	return &CaptionService{
+		Conf:      conf,
+		Repo:      postgresqlRepo.NewPostgreSQLRepo(conf.DB["master"]),
+		RepoSlave: postgresqlRepo.NewPostgreSQLRepo(conf.DB["slave"]),
+		S3Storage: s3Storage,
+	}
+}
+
+// NormalizeCaptionLanguage 驗證並正規化語言標籤，例如 zh-tw -> zh-TW、zh-hant-tw -> zh-Hant-TW
+func NormalizeCaptionLanguage(language string) (string, error) {
+	language = strings.TrimSpace(strings.ReplaceAll(language, "_", "-"))
+	if !captionLanguagePattern.MatchString(language) {
+		return "", fmt.Errorf("無效的字幕語言: %s", language)
+	}
+
+	parts := strings.Split(language, "-")
+	parts[0] = strings.ToLower(parts[0])
+	for i := 1; i < len(parts); i++ {
+		switch len(parts[i]) {
+		case 2:
+			parts[i] = strings.ToUpper(parts[i])
+		case 4:
+			parts[i] = strings.ToUpper(parts[i][:1]) + strings.ToLower(parts[i][1:])
+		default:
+			parts[i] = strings.ToLower(parts[i])
+		}
+	}
+	return strings.Join(parts, "-"), nil
+}
+
+// CaptionFormatFromFilename 根據檔名判斷字幕格式，僅支援 SRT 與 WebVTT
+func CaptionFormatFromFilename(filename string) (string, error) {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".srt":
+		return "srt", nil
+	case ".vtt":
+		return "vtt", nil
+	default:
+		return "", fmt.Errorf("不支援的字幕格式，僅支援 .srt 與 .vtt")
+	}
+}
+
+// validateCaptionLabel 標籤會寫入 HLS 主播放列表的引號字串，不能包含引號與換行
+func validateCaptionLabel(label string) error {
+	if strings.TrimSpace(label) == "" || strings.ContainsAny(label, "\"\r\n") {
+		return fmt.Errorf("無效的字幕名稱")
+	}
+	return nil
+}
+
+// ListCaptions 獲取檢視者可觀看影片的字幕軌
+func (s *CaptionService) ListCaptions(viewerID, videoID uint) ([]dto.VideoCaptionDTO, error) {
+	video, err := s.RepoSlave.FindVideoByID(videoID)
+	if err != nil || !canViewVideo(s.RepoSlave, video, viewerID) {
+		return nil, ErrVideoNotVisible
+	}
+
+	captions, err := s.RepoSlave.FindVideoCaptionsByVideoID(videoID)
+	if err != nil {
+		return nil, fmt.Errorf("獲取字幕失敗: %v", err)
+	}
+
+	result := make([]dto.VideoCaptionDTO, len(captions))
+	for i := range captions {
+		result[i] = *newVideoCaptionDTO(&captions[i])
+	}
+	return result, nil
+}
+
+// CreateCaption 新增字幕軌並回傳上傳URL
+func (s *CaptionService) CreateCaption(userID, videoID uint, req *dto.VideoCaptionCreateDTO) (*dto.VideoCaptionUploadDTO, error) {
+	video, err := s.findOwnedVideo(userID, videoID)
+	if err != nil {
+		return nil, err
+	}
+	// 共用轉碼結果的影片沒有自己的 HLS 目錄，字幕需加在來源影片
+	if video.RenditionsFromID != nil {
+		return nil, fmt.Errorf("此影片共用其他影片的轉碼結果，請於來源影片管理字幕")
+	}
+
+	language, err := NormalizeCaptionLanguage(req.Language)
+	if err != nil {
+		return nil, err
+	}
+	format, err := CaptionFormatFromFilename(req.Filename)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateCaptionLabel(req.Label); err != nil {
+		return nil, err
+	}
+
+	if existing, err := s.Repo.FindVideoCaption(videoID, language); err == nil {
+		if existing.Status == "deleting" {
+			return nil, fmt.Errorf("該語言字幕正在刪除中，請稍後再試")
+		}
+		return nil, fmt.Errorf("該語言字幕已存在")
+	}
+
+	caption := &models.VideoCaption{
+		VideoID:   videoID,
+		UserID:    userID,
+		Language:  language,
+		Label:     strings.TrimSpace(req.Label),
+		Format:    format,
+		SourceKey: storage.CaptionSourceKey(videoID, language, "."+format),
+		Status:    "uploading",
+	}
+
+	upload, err := s.generateUploadURL(caption)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.Repo.CreateVideoCaption(caption); err != nil {
+		return nil, fmt.Errorf("建立字幕失敗: %v", err)
+	}
+
+	if req.IsDefault {
+		if err := s.Repo.SetDefaultVideoCaption(videoID, caption.ID); err != nil {
+			return nil, fmt.Errorf("設定預設字幕失敗: %v", err)
+		}
+		caption.IsDefault = true
+	}
+
+	upload.Caption = newVideoCaptionDTO(caption)
+	return upload, nil
+}
+
+// ConfirmCaptionUpload 確認字幕上傳完成，交由 converter 處理
+func (s *CaptionService) ConfirmCaptionUpload(userID, videoID uint, language string) (*dto.VideoCaptionDTO, error) {
+	caption, err := s.findOwnedCaption(userID, videoID, language)
+	if err != nil {
+		return nil, err
+	}
+	if caption.Status != "uploading" && caption.Status != "failed" {
+		return nil, fmt.Errorf("字幕狀態為 %s，無法確認上傳", caption.Status)
+	}
+
+	if s.S3Storage != nil {
+		exists, err := s.S3Storage.CheckFileExists(caption.SourceKey)
+		if err != nil {
+			return nil, fmt.Errorf("檢查字幕檔失敗: %v", err)
+		}
+		if !exists {
+			return nil, fmt.Errorf("字幕檔尚未上傳")
+		}
+	}
+
+	caption.Status = "processing"
+	caption.ErrorMessage = ""
+	if err := s.Repo.UpdateVideoCaptionFields(caption.ID, map[string]interface{}{
+		"status":        caption.Status,
+		"error_message": "",
+	}); err != nil {
+		return nil, fmt.Errorf("更新字幕狀態失敗: %v", err)
+	}
+
+	return newVideoCaptionDTO(caption), nil
+}
+
+// UpdateCaption 更新字幕名稱、預設狀態或重新上傳字幕檔
+func (s *CaptionService) UpdateCaption(userID, videoID uint, language string, req *dto.VideoCaptionUpdateDTO) (*dto.VideoCaptionUploadDTO, error) {
+	caption, err := s.findOwnedCaption(userID, videoID, language)
+	if err != nil {
+		return nil, err
+	}
+
+	updates := map[string]interface{}{}
+	result := &dto.VideoCaptionUploadDTO{}
+
+	if req.Label != nil {
+		if err := validateCaptionLabel(*req.Label); err != nil {
+			return nil, err
+		}
+		caption.Label = strings.TrimSpace(*req.Label)
+		updates["label"] = caption.Label
+	}
+
+	if req.IsDefault != nil && !*req.IsDefault {
+		caption.IsDefault = false
+		updates["is_default"] = false
+	}
+
+	if req.Filename != "" {
+		format, err := CaptionFormatFromFilename(req.Filename)
+		if err != nil {
+			return nil, err
+		}
+		caption.Format = format
+		caption.SourceKey = storage.CaptionSourceKey(videoID, caption.Language, "."+format)
+		caption.Status = "uploading"
+
+		upload, err := s.generateUploadURL(caption)
+		if err != nil {
+			return nil, err
+		}
+		result = upload
+
+		updates["format"] = caption.Format
+		updates["source_key"] = caption.SourceKey
+		updates["status"] = caption.Status
+	} else if caption.Status == "ready" && (len(updates) > 0 || req.IsDefault != nil) {
+		// 名稱或預設狀態變更需重建主播放列表
+		caption.Status = "processing"
+		updates["status"] = caption.Status
+	}
+
+	if len(updates) > 0 {
+		if err := s.Repo.UpdateVideoCaptionFields(caption.ID, updates); err != nil {
+			return nil, fmt.Errorf("更新字幕失敗: %v", err)
+		}
+	}
+
+	if req.IsDefault != nil && *req.IsDefault {
+		if err := s.Repo.SetDefaultVideoCaption(videoID, caption.ID); err != nil {
+			return nil, fmt.Errorf("設定預設字幕失敗: %v", err)
+		}
+		caption.IsDefault = true
+	}
+
+	result.Caption = newVideoCaptionDTO(caption)
+	return result, nil
+}
+
+// DeleteCaption 刪除字幕軌，由 converter 移除檔案並重建主播放列表後刪除記錄
+func (s *CaptionService) DeleteCaption(userID, videoID uint, language string) error {
+	caption, err := s.findOwnedCaption(userID, videoID, language)
+	if err != nil {
+		return err
+	}
+
+	if err := s.Repo.UpdateVideoCaptionFields(caption.ID, map[string]interface{}{
+		"status":     "deleting",
+		"is_default": false,
+	}); err != nil {
+		return fmt.Errorf("刪除字幕失敗: %v", err)
+	}
+
+	utils.LogInfo("字幕標記刪除: video=%d, language=%s", videoID, caption.Language)
+	return nil
+}
+
+// findOwnedVideo 查找影片並確認擁有者
+func (s *CaptionService) findOwnedVideo(userID, videoID uint) (*models.Video, error) {
+	video, err := s.Repo.FindVideoByID(videoID)
+	if err != nil {
+		return nil, fmt.Errorf("找不到影片: %v", err)
+	}
+	if video.UserID != userID {
+		return nil, fmt.Errorf("無權限管理此影片的字幕")
+	}
+	return video, nil
+}
+
+// findOwnedCaption 查找字幕並確認影片擁有者
+func (s *CaptionService) findOwnedCaption(userID, videoID uint, language string) (*models.VideoCaption, error) {
+	if _, err := s.findOwnedVideo(userID, videoID); err != nil {
+		return nil, err
+	}
+
+	language, err := NormalizeCaptionLanguage(language)
+	if err != nil {
+		return nil, err
+	}
+
+	caption, err := s.Repo.FindVideoCaption(videoID, language)
+	if err != nil || caption.Status == "deleting" {
+		return nil, fmt.Errorf("找不到字幕: %s", language)
+	}
+	return caption, nil
+}
+
+// generateUploadURL 產生字幕檔的預簽名上傳URL
+func (s *CaptionService) generateUploadURL(caption *models.VideoCaption) (*dto.VideoCaptionUploadDTO, error) {
+	if s.S3Storage == nil {
+		return nil, fmt.Errorf("S3 儲存未初始化")
+	}
+
+	upload, err := s.S3Storage.GeneratePresignedUploadURLForKey(caption.SourceKey, "."+caption.Format)
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.VideoCaptionUploadDTO{
+		UploadURL: upload.UploadURL,
+		FormData:  upload.FormData,
+		Key:       upload.Key,
+	}, nil
+}
+
+// newVideoCaptionDTO 將字幕軌轉換為 DTO
+func newVideoCaptionDTO(caption *models.VideoCaption) *dto.VideoCaptionDTO {
+	return &dto.VideoCaptionDTO{
+		ID:           caption.ID,
+		Language:     caption.Language,
+		Label:        caption.Label,
+		Format:       caption.Format,
+		IsDefault:    caption.IsDefault,
+		Status:       caption.Status,
+		VTTURL:       caption.VTTURL,
+		PlaylistURL:  caption.PlaylistURL,
+		ErrorMessage: caption.ErrorMessage,
+		CreatedAt:    caption.CreatedAt,
+		UpdatedAt:    caption.UpdatedAt,
+	}
+}
@@ -0,0 +1,310 @@
+package services
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"stream-demo/backend/config"
+	"stream-demo/backend/database/models"
+	"stream-demo/backend/dto"
+	"stream-demo/backend/pkg/storage"
+	postgresqlRepo "stream-demo/backend/repositories/postgresql"
+	"stream-demo/backend/utils"
+)
+
+// ClipService 精華片段剪輯服務
+// 建立 status 為 clipping 的新影片，交由 converter 剪輯後再進入一般轉碼流程
+type ClipService struct {
+	Conf            *config.Config
+	Repo            *postgresqlRepo.PostgreSQLRepo
+	RepoSlave       *postgresqlRepo.PostgreSQLRepo
+	S3Storage       *storage.S3Storage
+	liveRoomService *LiveRoomService
+	httpClient      *http.Client // 讀取直播 HLS 播放列表
+}
+
+// 讀取直播播放列表的逾時與大小上限
+const (
+	livePlaylistTimeout = 5 * time.Second
+	livePlaylistMaxSize = 1 << 20
+)
+
+// NewClipService 創建精華片段剪輯服務
+func NewClipService(conf *config.Config, s3Storage *storage.S3Storage, liveRoomService *LiveRoomService) *ClipService {
+	return &ClipService{
+		Conf:            conf,
+		Repo:            postgresqlRepo.NewPostgreSQLRepo(conf.DB["master"]),
+		RepoSlave:       postgresqlRepo.NewPostgreSQLRepo(conf.DB["slave"]),
+		S3Storage:       s3Storage,
+		liveRoomService: liveRoomService,
+		httpClient:      &http.Client{Timeout: livePlaylistTimeout},
+	}
+}
+
+// ValidateClipRange 檢查剪輯範圍，available 為來源可剪輯的結束秒數（小於等於 0 表示未知）
+func ValidateClipRange(start, end, available float64, minDuration, maxDuration int) error {
+	if start < 0 || end <= start {
+		return fmt.Errorf("無效的剪輯範圍")
+	}
+
+	duration := end - start
+	if minDuration > 0 && duration < float64(minDuration) {
+		return fmt.Errorf("片段長度不能少於 %d 秒", minDuration)
+	}
+	if maxDuration > 0 && duration > float64(maxDuration) {
+		return fmt.Errorf("片段長度不能超過 %d 秒", maxDuration)
+	}
+	if available > 0 && end > available {
+		return fmt.Errorf("剪輯範圍超出來源長度")
+	}
+	return nil
+}
+
+// LivePlaylistDuration 計算 HLS 媒體播放列表中保留的分段總長度（秒），即直播目前可回溯的範圍
+func LivePlaylistDuration(playlist string) float64 {
+	total := 0.0
+	scanner := bufio.NewScanner(strings.NewReader(playlist))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if !strings.HasPrefix(line, "#EXTINF:") {
+			continue
+		}
+		value := strings.TrimPrefix(line, "#EXTINF:")
+		if i := strings.IndexByte(value, ','); i >= 0 {
+			value = value[:i]
+		}
+		if duration, err := strconv.ParseFloat(value, 64); err == nil && duration > 0 {
+			total += duration
+		}
+	}
+	return total
+}
+
+// CreateVideoClip 從已完成且可觀看的影片剪輯精華片段，片段沿用來源影片的可見度
+func (s *ClipService) CreateVideoClip(userID, sourceVideoID uint, req *dto.VideoClipCreateDTO) (*dto.VideoDTO, error) {
+	source, err := s.RepoSlave.FindVideoByID(sourceVideoID)
+	if err != nil {
+		return nil, fmt.Errorf("找不到來源影片: %v", err)
+	}
+	if !canViewVideo(s.RepoSlave, source, userID) {
+		return nil, ErrVideoNotVisible
+	}
+	if source.Status != "ready" {
+		return nil, fmt.Errorf("來源影片尚未處理完成")
+	}
+
+	clipConf := s.Conf.Video.Clip
+	if err := ValidateClipRange(req.Start, req.End, float64(source.Duration), clipConf.MinDuration, clipConf.MaxDuration); err != nil {
+		return nil, err
+	}
+
+	// 優先使用轉碼後的 MP4，關鍵幀較規律
+	sourceInput := fmt.Sprintf("%s/%s", s.Conf.Storage.S3.Bucket, source.OriginalKey)
+	if source.MP4Key != "" {
+		sourceInput = fmt.Sprintf("%s/%s", storage.ProcessedBucket, source.MP4Key)
+	}
+
+	title := req.Title
+	if title == "" {
+		title = clipTitle(source.Title)
+	}
+
+	clip := &models.VideoClip{
+		UserID:        userID,
+		SourceType:    "video",
+		SourceVideoID: &source.ID,
+		SourceInput:   sourceInput,
+		StartTime:     req.Start,
+		EndTime:       req.End,
+	}
+
+	return s.createClip(userID, title, req.Description, source.Visibility, clip)
+}
+
+// CreateLiveClip 從直播 DVR 視窗剪輯精華片段
+// 可回溯範圍以直播播放列表中實際保留的分段為準，並以 live_dvr_window 為上限
+func (s *ClipService) CreateLiveClip(userID int, roomID string, req *dto.VideoClipCreateDTO) (*dto.VideoDTO, error) {
+	room, err := s.liveRoomService.GetRoomByID(roomID)
+	if err != nil {
+		return nil, err
+	}
+	if room.Status != "live" || room.StartedAt.IsZero() {
+		return nil, fmt.Errorf("直播間未在直播中")
+	}
+
+	clipConf := s.Conf.Video.Clip
+	elapsed := time.Since(room.StartedAt).Seconds()
+	if err := ValidateClipRange(req.Start, req.End, elapsed, clipConf.MinDuration, clipConf.MaxDuration); err != nil {
+		return nil, err
+	}
+
+	sourceInput := fmt.Sprintf("%s/%s/index.m3u8", clipConf.LiveHLSBaseURL, room.StreamKey)
+	window, err := s.livePlaylistWindow(sourceInput)
+	if err != nil {
+		utils.LogError("讀取直播播放列表失敗: room=%s, %v", roomID, err)
+		return nil, fmt.Errorf("無法讀取直播回放內容，請稍後再試")
+	}
+	if limit := float64(clipConf.LiveDVRWindow); limit > 0 && limit < window {
+		window = limit
+	}
+	if req.Start < elapsed-window {
+		return nil, fmt.Errorf("只能剪輯最近 %d 秒內的直播內容", int(window))
+	}
+
+	startAt := room.StartedAt.Add(time.Duration(req.Start * float64(time.Second)))
+	endAt := room.StartedAt.Add(time.Duration(req.End * float64(time.Second)))
+
+	clip := &models.VideoClip{
+		UserID:       uint(userID),
+		SourceType:   "live",
+		SourceRoomID: roomID,
+		SourceInput:  sourceInput,
+		StartTime:    req.Start,
+		EndTime:      req.End,
+		StartAt:      &startAt,
+		EndAt:        &endAt,
+	}
+
+	if session, err := s.RepoSlave.FindLiveSessionByRoomID(roomID); err == nil {
+		clip.SourceLiveSessionID = &session.ID
+	}
+
+	title := req.Title
+	if title == "" {
+		title = clipTitle(room.Title)
+	}
+
+	return s.createClip(uint(userID), title, req.Description, models.VideoVisibilityPublic, clip)
+}
+
+// livePlaylistWindow 讀取直播 HLS 播放列表，回傳目前保留的分段總長度（秒）
+func (s *ClipService) livePlaylistWindow(playlistURL string) (float64, error) {
+	resp, err := s.httpClient.Get(playlistURL)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("播放列表回應 %d", resp.StatusCode)
+	}
+
+	var playlist strings.Builder
+	if _, err := io.Copy(&playlist, io.LimitReader(resp.Body, livePlaylistMaxSize)); err != nil {
+		return 0, err
+	}
+	return LivePlaylistDuration(playlist.String()), nil
+}
+
+// GetClipsBySourceVideo 獲取來源影片中檢視者可觀看的精華片段
+func (s *ClipService) GetClipsBySourceVideo(viewerID, sourceVideoID uint) ([]*dto.VideoDTO, error) {
+	source, err := s.RepoSlave.FindVideoByID(sourceVideoID)
+	if err != nil || !canViewVideo(s.RepoSlave, source, viewerID) {
+		return nil, ErrVideoNotVisible
+	}
+
+	clips, err := s.RepoSlave.FindVideoClipsBySourceVideoID(sourceVideoID)
+	if err != nil {
+		return nil, fmt.Errorf("獲取精華片段失敗: %v", err)
+	}
+
+	clipByVideo := make(map[uint]*models.VideoClip, len(clips))
+	videoIDs := make([]uint, 0, len(clips))
+	for i := range clips {
+		clipByVideo[clips[i].VideoID] = &clips[i]
+		videoIDs = append(videoIDs, clips[i].VideoID)
+	}
+
+	videos, err := s.RepoSlave.FindVideosByIDs(videoIDs)
+	if err != nil {
+		return nil, fmt.Errorf("獲取精華片段失敗: %v", err)
+	}
+
+	result := make([]*dto.VideoDTO, 0, len(videos))
+	for i := range videos {
+		if !canViewVideo(s.RepoSlave, &videos[i], viewerID) {
+			continue
+		}
+		videoDTO := newVideoDTO(&videos[i])
+		videoDTO.Clip = newVideoClipDTO(clipByVideo[videos[i].ID])
+		result = append(result, videoDTO)
+	}
+	return result, nil
+}
+
+// createClip 建立片段影片與剪輯任務，不公開的片段另外產生自己的分享代碼
+func (s *ClipService) createClip(userID uint, title, description, visibility string, clip *models.VideoClip) (*dto.VideoDTO, error) {
+	video := &models.Video{
+		Title:          title,
+		Description:    description,
+		UserID:         userID,
+		Visibility:     visibility,
+		Duration:       int(math.Ceil(clip.EndTime - clip.StartTime)),
+		OriginalFormat: "mp4",
+		Status:         "clipping",
+
+		TranscodeLadder: s.Conf.Video.TranscodeLadder(),
+		Packaging:       s.Conf.Video.PackagingFormat(),
+		Encrypted:       s.Conf.Video.Encryption.Enabled,
+	}
+	if visibility == models.VideoVisibilityUnlisted {
+		slug, err := utils.RandomToken(shareSlugBytes)
+		if err != nil {
+			return nil, fmt.Errorf("產生分享代碼失敗: %v", err)
+		}
+		video.ShareSlug = &slug
+	}
+
+	if err := s.Repo.CreateVideoClip(video, clip, func(videoID uint) string {
+		return storage.ClipOriginalKey(userID, videoID)
+	}); err != nil {
+		return nil, fmt.Errorf("建立精華片段失敗: %v", err)
+	}
+
+	if s.S3Storage != nil {
+		video.OriginalURL = s.S3Storage.GenerateCDNURL(video.OriginalKey)
+		if err := s.Repo.UpdateVideoFields(video.ID, map[string]interface{}{
+			"original_url": video.OriginalURL,
+		}); err != nil {
+			utils.LogError("更新精華片段原始 URL 失敗: %v", err)
+		}
+	}
+
+	utils.LogInfo("精華片段建立成功: video=%d, source=%s", video.ID, clip.SourceType)
+
+	videoDTO := newVideoDTO(video)
+	videoDTO.Clip = newVideoClipDTO(clip)
+	return videoDTO, nil
+}
+
+// clipTitle 產生預設片段標題，避免超過影片標題長度限制
+func clipTitle(sourceTitle string) string {
+	suffix := " - 精華片段"
+	runes := []rune(sourceTitle)
+	if max := 100 - len([]rune(suffix)); len(runes) > max {
+		runes = runes[:max]
+	}
+	return string(runes) + suffix
+}
+
+// newVideoClipDTO 將剪輯資訊轉換為 DTO
+func newVideoClipDTO(clip *models.VideoClip) *dto.VideoClipDTO {
+	if clip == nil {
+		return nil
+	}
+	return &dto.VideoClipDTO{
+		SourceType:          clip.SourceType,
+		SourceVideoID:       clip.SourceVideoID,
+		SourceLiveSessionID: clip.SourceLiveSessionID,
+		SourceRoomID:        clip.SourceRoomID,
+		StartTime:           clip.StartTime,
+		EndTime:             clip.EndTime,
+		CutMode:             clip.CutMode,
+	}
+}
@@ -0,0 +1,233 @@
+package services
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"stream-demo/backend/database/models"
+	"stream-demo/backend/dto"
+	"stream-demo/backend/pkg/media"
+	"stream-demo/backend/pkg/storage"
+	"stream-demo/backend/utils"
+)
+
+// 附件檔名長度上限
+const attachmentFilenameMax = 255
+
+// 支援的附件副檔名與對應的類型
+var attachmentKinds = map[string]string{
+	".jpg":  models.MessageTypeImage,
+	".jpeg": models.MessageTypeImage,
+	".png":  models.MessageTypeImage,
+	".gif":  models.MessageTypeImage,
+	".mp4":  models.MessageTypeVideo,
+	".mov":  models.MessageTypeVideo,
+	".webm": models.MessageTypeVideo,
+	".pdf":  models.MessageTypeFile,
+	".zip":  models.MessageTypeFile,
+	".txt":  models.MessageTypeFile,
+	".docx": models.MessageTypeFile,
+	".xlsx": models.MessageTypeFile,
+	".pptx": models.MessageTypeFile,
+}
+
+// AttachmentKind 依副檔名判斷附件類型（image、video、file），不支援的格式回傳 false
+func AttachmentKind(filename string) (string, bool) {
+	kind, ok := attachmentKinds[strings.ToLower(filepath.Ext(filename))]
+	return kind, ok
+}
+
+// AttachmentFilename 清理客戶端提供的檔名，只保留檔名部分並限制長度，空白時使用 fallback
+func AttachmentFilename(filename, fallback string) string {
+	name := strings.TrimSpace(filepath.Base(strings.ReplaceAll(filename, "\\", "/")))
+	if name == "" || name == "." || name == "/" {
+		name = fallback
+	}
+	if utf8.RuneCountInString(name) > attachmentFilenameMax {
+		name = string([]rune(name)[:attachmentFilenameMax])
+	}
+	return name
+}
+
+// CreateAttachmentUploadURL 產生附件的預簽名上傳URL，檢查格式與宣告的檔案大小
+func (s *ConversationService) CreateAttachmentUploadURL(userID, conversationID uint, req *dto.AttachmentUploadRequestDTO) (*dto.AttachmentUploadDTO, error) {
+	conversation, err := s.memberConversation(s.RepoSlave, userID, conversationID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkDirectBlocked(conversation, userID); err != nil {
+		return nil, err
+	}
+	if s.ChatStorage == nil {
+		return nil, fmt.Errorf("附件儲存未初始化")
+	}
+
+	kind, ok := AttachmentKind(req.Filename)
+	if !ok {
+		return nil, fmt.Errorf("不支援的附件格式")
+	}
+	if maxSize := s.attachmentMaxSize(kind); req.Size > maxSize {
+		return nil, fmt.Errorf("檔案大小不能超過 %d MB", maxSize>>20)
+	}
+
+	ext := strings.ToLower(filepath.Ext(req.Filename))
+	upload, err := s.ChatStorage.GeneratePresignedUploadURLForKey(storage.ChatAttachmentKey(conversationID, ext), ext)
+	if err != nil {
+		return nil, err
+	}
+	return &dto.AttachmentUploadDTO{
+		Kind:      kind,
+		UploadURL: upload.UploadURL,
+		FormData:  upload.FormData,
+		Key:       upload.Key,
+	}, nil
+}
+
+// GetAttachment 重新產生訊息附件的下載URL，供先前的URL過期時使用
+func (s *ConversationService) GetAttachment(userID, conversationID, messageID uint) (*dto.MessageAttachmentDTO, error) {
+	if _, err := s.memberConversation(s.RepoSlave, userID, conversationID); err != nil {
+		return nil, err
+	}
+	message, err := s.RepoSlave.FindConversationMessage(conversationID, messageID)
+	if err != nil {
+		return nil, fmt.Errorf("訊息不存在")
+	}
+	if message.Attachment == nil {
+		return nil, fmt.Errorf("訊息沒有附件")
+	}
+	return s.newAttachmentDTO(message.Attachment), nil
+}
+
+// prepareAttachment 驗證已上傳的附件並建立附件資料
+// 以實際檔案大小檢查上限；圖片由伺服器讀取尺寸並產生縮圖，影片的尺寸與長度採用客戶端提供的值
+func (s *ConversationService) prepareAttachment(conversationID uint, input *dto.MessageAttachmentInputDTO) (*models.MessageAttachment, error) {
+	if s.ChatStorage == nil {
+		return nil, fmt.Errorf("附件儲存未初始化")
+	}
+
+	key := input.Key
+	if !strings.HasPrefix(key, storage.ChatAttachmentPrefix(conversationID)) || strings.HasSuffix(key, "_thumb.jpg") {
+		return nil, fmt.Errorf("無效的附件")
+	}
+	kind, ok := AttachmentKind(key)
+	if !ok {
+		return nil, fmt.Errorf("不支援的附件格式")
+	}
+
+	info, err := s.ChatStorage.GetFileInfo(key)
+	if err != nil || info.ContentLength == nil {
+		return nil, fmt.Errorf("附件尚未上傳")
+	}
+	size := *info.ContentLength
+	maxSize := s.attachmentMaxSize(kind)
+	if size > maxSize {
+		s.discardAttachment(key)
+		return nil, fmt.Errorf("檔案大小不能超過 %d MB", maxSize>>20)
+	}
+
+	ext := filepath.Ext(key)
+	attachment := &models.MessageAttachment{
+		Kind:        kind,
+		Key:         key,
+		Filename:    AttachmentFilename(input.Filename, filepath.Base(key)),
+		ContentType: storage.ContentTypeForExt(ext),
+		Size:        size,
+	}
+
+	switch kind {
+	case models.MessageTypeImage:
+		if err := s.processImageAttachment(attachment, maxSize); err != nil {
+			return nil, err
+		}
+	case models.MessageTypeVideo:
+		if input.Width < 0 || input.Height < 0 || input.Duration < 0 {
+			return nil, fmt.Errorf("無效的影片資訊")
+		}
+		attachment.Width = input.Width
+		attachment.Height = input.Height
+		attachment.Duration = input.Duration
+	}
+	return attachment, nil
+}
+
+// processImageAttachment 確認圖片內容可以解碼，記錄實際格式與尺寸並上傳縮圖
+func (s *ConversationService) processImageAttachment(attachment *models.MessageAttachment, maxSize int64) error {
+	data, err := s.ChatStorage.ReadObject(attachment.Key, maxSize)
+	if err != nil {
+		return fmt.Errorf("讀取圖片失敗: %v", err)
+	}
+
+	info, err := media.ProbeImage(data)
+	if err != nil {
+		// 副檔名是圖片但內容不是，不保留檔案
+		s.discardAttachment(attachment.Key)
+		return fmt.Errorf("圖片格式無效: %v", err)
+	}
+	attachment.ContentType = "image/" + info.Format
+	attachment.Width = info.Width
+	attachment.Height = info.Height
+
+	thumbnail, err := media.ImageThumbnail(data, s.Conf.Chat.ThumbnailSize)
+	if err != nil {
+		return fmt.Errorf("產生縮圖失敗: %v", err)
+	}
+	thumbnailKey := storage.ChatAttachmentThumbnailKey(attachment.Key)
+	if err := s.ChatStorage.UploadBytes(thumbnailKey, thumbnail, "image/jpeg"); err != nil {
+		return fmt.Errorf("上傳縮圖失敗: %v", err)
+	}
+	attachment.ThumbnailKey = thumbnailKey
+	return nil
+}
+
+// discardAttachment 刪除未通過驗證的附件，失敗只記錄日誌
+func (s *ConversationService) discardAttachment(key string) {
+	if err := s.ChatStorage.DeleteFile(key); err != nil {
+		utils.LogError("刪除附件 %s 失敗: %v", key, err)
+	}
+}
+
+// attachmentMaxSize 附件類型的大小上限(位元組)
+func (s *ConversationService) attachmentMaxSize(kind string) int64 {
+	switch kind {
+	case models.MessageTypeImage:
+		return int64(s.Conf.Chat.MaxImageSizeMB) << 20
+	case models.MessageTypeVideo:
+		return int64(s.Conf.Chat.MaxVideoSizeMB) << 20
+	default:
+		return int64(s.Conf.Chat.MaxFileSizeMB) << 20
+	}
+}
+
+// newAttachmentDTO 轉換附件並產生預簽名下載URL，只在回應會話成員時使用
+func (s *ConversationService) newAttachmentDTO(attachment *models.MessageAttachment) *dto.MessageAttachmentDTO {
+	result := &dto.MessageAttachmentDTO{
+		Kind:        attachment.Kind,
+		Filename:    attachment.Filename,
+		ContentType: attachment.ContentType,
+		Size:        attachment.Size,
+		Width:       attachment.Width,
+		Height:      attachment.Height,
+		Duration:    attachment.Duration,
+	}
+	if s.ChatStorage == nil {
+		return result
+	}
+
+	expiry := time.Duration(s.Conf.Chat.AttachmentURLMinutes) * time.Minute
+	result.URLExpiresAt = time.Now().Add(expiry)
+	url, err := s.ChatStorage.GeneratePresignedDownloadURL(attachment.Key, expiry)
+	if err != nil {
+		utils.LogError("產生附件下載URL失敗: %v", err)
+		return result
+	}
+	result.URL = url
+	if attachment.ThumbnailKey != "" {
+		if url, err := s.ChatStorage.GeneratePresignedDownloadURL(attachment.ThumbnailKey, expiry); err == nil {
+			result.ThumbnailURL = url
+		}
+	}
+	return result
+}
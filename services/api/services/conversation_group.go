@@ -0,0 +1,607 @@
+package services
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"stream-demo/backend/database/models"
+	"stream-demo/backend/dto"
+	"stream-demo/backend/pkg/storage"
+	postgresqlRepo "stream-demo/backend/repositories/postgresql"
+	"stream-demo/backend/utils"
+
+	"gorm.io/gorm"
+)
+
+// 群組系統訊息的異動類型，記錄在訊息 data.action
+const (
+	GroupActionCreated       = "group_created"
+	GroupActionUpdated       = "group_updated"
+	GroupActionMembersAdded  = "members_added"
+	GroupActionMemberRemoved = "member_removed"
+	GroupActionMemberLeft    = "member_left"
+	GroupActionMemberJoined  = "member_joined"
+	GroupActionRoleChanged   = "role_changed"
+)
+
+// 邀請連結最長有效時數
+const inviteMaxExpiryHours = 720
+
+var (
+	// ErrGroupPermission 沒有管理群組的權限
+	ErrGroupPermission = errors.New("沒有管理群組的權限")
+	// ErrInviteNotFound 邀請連結不存在
+	ErrInviteNotFound = errors.New("邀請連結不存在")
+)
+
+// groupRoleRank 角色權限高低，數字越大權限越高
+func groupRoleRank(role string) int {
+	switch role {
+	case models.ConversationRoleOwner:
+		return 2
+	case models.ConversationRoleAdmin:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// CanManageGroup 群主與管理員可以管理群組資料、成員與邀請連結
+func CanManageGroup(role string) bool {
+	return groupRoleRank(role) > 0
+}
+
+// CanManageMember 只能管理角色低於自己的成員：群主可管理管理員與成員，管理員可管理一般成員
+func CanManageMember(actorRole, targetRole string) bool {
+	return CanManageGroup(actorRole) && groupRoleRank(actorRole) > groupRoleRank(targetRole)
+}
+
+// NextGroupOwner 群主離開時的接手成員：最早加入的管理員，沒有管理員時為最早加入的成員
+func NextGroupOwner(members []models.ConversationMember, leavingUserID uint) (uint, bool) {
+	var next *models.ConversationMember
+	for i := range members {
+		member := &members[i]
+		if member.UserID == leavingUserID {
+			continue
+		}
+		if next == nil {
+			next = member
+			continue
+		}
+		rank, nextRank := groupRoleRank(member.Role), groupRoleRank(next.Role)
+		if rank > nextRank || (rank == nextRank && member.ID < next.ID) {
+			next = member
+		}
+	}
+	if next == nil {
+		return 0, false
+	}
+	return next.UserID, true
+}
+
+// InviteUsable 邀請連結是否未過期且未達使用次數
+func InviteUsable(invite *models.ConversationInvite, now time.Time) bool {
+	if invite.ExpiresAt != nil && !now.Before(*invite.ExpiresAt) {
+		return false
+	}
+	return invite.MaxUses == 0 || invite.Uses < invite.MaxUses
+}
+
+// CreateGroup 建立群組，建立者為群主
+func (s *ConversationService) CreateGroup(userID uint, req *dto.GroupCreateDTO) (*dto.ConversationDTO, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, fmt.Errorf("群組名稱不能為空")
+	}
+
+	memberIDs := uniqueGroupUserIDs(req.MemberIDs, userID)
+	if len(memberIDs)+1 > s.Conf.Chat.MaxGroupMembers {
+		return nil, fmt.Errorf("群組人數上限為 %d 人", s.Conf.Chat.MaxGroupMembers)
+	}
+	if err := s.checkGroupInvitees(userID, memberIDs); err != nil {
+		return nil, err
+	}
+
+	conversation := &models.Conversation{
+		Type:      models.ConversationTypeGroup,
+		Name:      name,
+		CreatedBy: userID,
+	}
+	members := []models.ConversationMember{{UserID: userID, Role: models.ConversationRoleOwner}}
+	for _, memberID := range memberIDs {
+		members = append(members, models.ConversationMember{UserID: memberID, Role: models.ConversationRoleMember})
+	}
+	if err := s.Repo.CreateConversation(conversation, members); err != nil {
+		return nil, fmt.Errorf("建立群組失敗: %v", err)
+	}
+
+	s.postSystemMessage(conversation.ID, userID, GroupActionCreated, map[string]interface{}{"name": name},
+		fmt.Sprintf("%s 建立了群組「%s」", s.username(userID), name))
+	return s.reloadGroup(userID, conversation.ID)
+}
+
+// UpdateGroup 更新群組名稱與頭像
+func (s *ConversationService) UpdateGroup(userID, conversationID uint, req *dto.GroupUpdateDTO) (*dto.ConversationDTO, error) {
+	conversation, role, err := s.groupConversation(userID, conversationID)
+	if err != nil {
+		return nil, err
+	}
+	if !CanManageGroup(role) {
+		return nil, ErrGroupPermission
+	}
+
+	updates := make(map[string]interface{})
+	data := make(map[string]interface{})
+	if req.Name != nil {
+		name := strings.TrimSpace(*req.Name)
+		if name == "" {
+			return nil, fmt.Errorf("群組名稱不能為空")
+		}
+		if name != conversation.Name {
+			updates["name"] = name
+			data["name"] = name
+		}
+	}
+	if req.AvatarKey != nil && *req.AvatarKey != conversation.AvatarKey {
+		if err := s.validateAvatarKey(conversationID, *req.AvatarKey); err != nil {
+			return nil, err
+		}
+		updates["avatar_key"] = *req.AvatarKey
+		data["avatar"] = true
+	}
+	if len(updates) == 0 {
+		return s.toConversationDTO(s.Repo, userID, conversation)
+	}
+
+	if err := s.Repo.UpdateConversation(conversationID, updates); err != nil {
+		return nil, fmt.Errorf("更新群組失敗: %v", err)
+	}
+	if _, ok := updates["avatar_key"]; ok && conversation.AvatarKey != "" && s.S3Storage != nil {
+		if err := s.S3Storage.DeleteFile(conversation.AvatarKey); err != nil {
+			utils.LogError("刪除群組 %d 舊頭像失敗: %v", conversationID, err)
+		}
+	}
+
+	content := fmt.Sprintf("%s 更新了群組資料", s.username(userID))
+	if name, ok := data["name"]; ok {
+		content = fmt.Sprintf("%s 將群組名稱改為「%s」", s.username(userID), name)
+	}
+	s.postSystemMessage(conversationID, userID, GroupActionUpdated, data, content)
+	return s.reloadGroup(userID, conversationID)
+}
+
+// CreateAvatarUploadURL 產生群組頭像的預簽名上傳URL
+func (s *ConversationService) CreateAvatarUploadURL(userID, conversationID uint, filename string) (*dto.GroupAvatarUploadDTO, error) {
+	_, role, err := s.groupConversation(userID, conversationID)
+	if err != nil {
+		return nil, err
+	}
+	if !CanManageGroup(role) {
+		return nil, ErrGroupPermission
+	}
+	if s.S3Storage == nil {
+		return nil, fmt.Errorf("S3 儲存未初始化")
+	}
+
+	ext := strings.ToLower(filepath.Ext(filename))
+	if ext != ".png" && ext != ".jpg" && ext != ".jpeg" {
+		return nil, fmt.Errorf("不支援的頭像格式，僅支援 .png、.jpg")
+	}
+
+	upload, err := s.S3Storage.GeneratePresignedUploadURLForKey(storage.ConversationAvatarKey(conversationID, ext), ext)
+	if err != nil {
+		return nil, err
+	}
+	return &dto.GroupAvatarUploadDTO{
+		UploadURL: upload.UploadURL,
+		FormData:  upload.FormData,
+		Key:       upload.Key,
+	}, nil
+}
+
+// AddMembers 將用戶加入群組，已是成員的略過
+func (s *ConversationService) AddMembers(userID, conversationID uint, userIDs []uint) (*dto.ConversationDTO, error) {
+	_, role, err := s.groupConversation(userID, conversationID)
+	if err != nil {
+		return nil, err
+	}
+	if !CanManageGroup(role) {
+		return nil, ErrGroupPermission
+	}
+
+	memberIDs := uniqueGroupUserIDs(userIDs, userID)
+	if len(memberIDs) == 0 {
+		return nil, fmt.Errorf("請指定要加入的用戶")
+	}
+	if err := s.checkGroupInvitees(userID, memberIDs); err != nil {
+		return nil, err
+	}
+
+	added, err := s.Repo.AddConversationMembers(conversationID, memberIDs, s.Conf.Chat.MaxGroupMembers)
+	if err != nil {
+		return nil, s.groupJoinError(err)
+	}
+	if len(added) > 0 {
+		s.postSystemMessage(conversationID, userID, GroupActionMembersAdded, map[string]interface{}{"user_ids": added},
+			fmt.Sprintf("%s 將 %s 加入群組", s.username(userID), strings.Join(s.usernames(added), "、")))
+	}
+	return s.reloadGroup(userID, conversationID)
+}
+
+// RemoveMember 將成員移出群組
+func (s *ConversationService) RemoveMember(userID, conversationID, targetID uint) error {
+	if userID == targetID {
+		return fmt.Errorf("請使用離開群組")
+	}
+	conversation, role, err := s.groupConversation(userID, conversationID)
+	if err != nil {
+		return err
+	}
+	target := findMember(conversation.Members, targetID)
+	if target == nil {
+		return fmt.Errorf("用戶不是群組成員")
+	}
+	if !CanManageMember(role, target.Role) {
+		return ErrGroupPermission
+	}
+
+	removed, err := s.Repo.RemoveConversationMember(conversationID, targetID)
+	if err != nil {
+		return fmt.Errorf("移除成員失敗: %v", err)
+	}
+	if removed {
+		// 被移除的用戶也收到系統訊息，以更新會話列表
+		s.postSystemMessage(conversationID, userID, GroupActionMemberRemoved, map[string]interface{}{"user_id": targetID},
+			fmt.Sprintf("%s 將 %s 移出群組", s.username(userID), s.username(targetID)), targetID)
+	}
+	return nil
+}
+
+// UpdateMemberRole 群主設定或取消管理員
+func (s *ConversationService) UpdateMemberRole(userID, conversationID, targetID uint, newRole string) error {
+	if newRole != models.ConversationRoleAdmin && newRole != models.ConversationRoleMember {
+		return fmt.Errorf("無效的角色")
+	}
+	conversation, role, err := s.groupConversation(userID, conversationID)
+	if err != nil {
+		return err
+	}
+	if role != models.ConversationRoleOwner {
+		return ErrGroupPermission
+	}
+	target := findMember(conversation.Members, targetID)
+	if target == nil {
+		return fmt.Errorf("用戶不是群組成員")
+	}
+	if target.Role == models.ConversationRoleOwner {
+		return fmt.Errorf("不能變更群主的角色")
+	}
+	if target.Role == newRole {
+		return nil
+	}
+
+	if err := s.Repo.UpdateConversationMemberRole(conversationID, targetID, newRole); err != nil {
+		return fmt.Errorf("變更角色失敗: %v", err)
+	}
+
+	format := "%s 將 %s 設為管理員"
+	if newRole == models.ConversationRoleMember {
+		format = "%s 取消了 %s 的管理員"
+	}
+	s.postSystemMessage(conversationID, userID, GroupActionRoleChanged, map[string]interface{}{"user_id": targetID, "role": newRole},
+		fmt.Sprintf(format, s.username(userID), s.username(targetID)))
+	return nil
+}
+
+// Leave 離開群組，群主離開時轉交給最早加入的管理員或成員
+func (s *ConversationService) Leave(userID, conversationID uint) error {
+	conversation, role, err := s.groupConversation(userID, conversationID)
+	if err != nil {
+		return err
+	}
+
+	var newOwnerID uint
+	data := map[string]interface{}{"user_id": userID}
+	if role == models.ConversationRoleOwner {
+		if nextID, ok := NextGroupOwner(conversation.Members, userID); ok {
+			newOwnerID = nextID
+			data["new_owner_id"] = nextID
+		}
+	}
+
+	if err := s.Repo.LeaveConversation(conversationID, userID, newOwnerID); err != nil {
+		return fmt.Errorf("離開群組失敗: %v", err)
+	}
+	content := fmt.Sprintf("%s 離開了群組", s.username(userID))
+	if newOwnerID != 0 {
+		content = fmt.Sprintf("%s，%s 成為群主", content, s.username(newOwnerID))
+	}
+	// 離開的用戶其他裝置也收到系統訊息，以更新會話列表
+	s.postSystemMessage(conversationID, userID, GroupActionMemberLeft, data, content, userID)
+	return nil
+}
+
+// CreateInvite 建立邀請連結，未指定有效時數時使用預設值
+func (s *ConversationService) CreateInvite(userID, conversationID uint, req *dto.GroupInviteCreateDTO) (*dto.GroupInviteDTO, error) {
+	_, role, err := s.groupConversation(userID, conversationID)
+	if err != nil {
+		return nil, err
+	}
+	if !CanManageGroup(role) {
+		return nil, ErrGroupPermission
+	}
+
+	hours := req.ExpiresInHours
+	if hours <= 0 {
+		hours = s.Conf.Chat.InviteExpiryHours
+	}
+	if hours > inviteMaxExpiryHours {
+		return nil, fmt.Errorf("邀請連結有效時數不能超過 %d 小時", inviteMaxExpiryHours)
+	}
+	if req.MaxUses < 0 {
+		return nil, fmt.Errorf("無效的使用次數")
+	}
+
+	code, err := generateInviteCode()
+	if err != nil {
+		return nil, fmt.Errorf("產生邀請碼失敗: %v", err)
+	}
+	expiresAt := time.Now().Add(time.Duration(hours) * time.Hour)
+	invite := &models.ConversationInvite{
+		ConversationID: conversationID,
+		Code:           code,
+		CreatedBy:      userID,
+		MaxUses:        req.MaxUses,
+		ExpiresAt:      &expiresAt,
+	}
+	if err := s.Repo.CreateConversationInvite(invite); err != nil {
+		return nil, fmt.Errorf("建立邀請連結失敗: %v", err)
+	}
+	return newGroupInviteDTO(invite), nil
+}
+
+// ListInvites 列出群組的邀請連結
+func (s *ConversationService) ListInvites(userID, conversationID uint) ([]*dto.GroupInviteDTO, error) {
+	_, role, err := s.groupConversation(userID, conversationID)
+	if err != nil {
+		return nil, err
+	}
+	if !CanManageGroup(role) {
+		return nil, ErrGroupPermission
+	}
+
+	invites, err := s.Repo.FindConversationInvites(conversationID)
+	if err != nil {
+		return nil, fmt.Errorf("獲取邀請連結失敗: %v", err)
+	}
+	result := make([]*dto.GroupInviteDTO, len(invites))
+	for i := range invites {
+		result[i] = newGroupInviteDTO(&invites[i])
+	}
+	return result, nil
+}
+
+// RevokeInvite 撤銷邀請連結
+func (s *ConversationService) RevokeInvite(userID, conversationID, inviteID uint) error {
+	_, role, err := s.groupConversation(userID, conversationID)
+	if err != nil {
+		return err
+	}
+	if !CanManageGroup(role) {
+		return ErrGroupPermission
+	}
+
+	deleted, err := s.Repo.DeleteConversationInvite(conversationID, inviteID)
+	if err != nil {
+		return fmt.Errorf("撤銷邀請連結失敗: %v", err)
+	}
+	if !deleted {
+		return ErrInviteNotFound
+	}
+	return nil
+}
+
+// JoinByInvite 以邀請碼加入群組，已是成員時直接回傳群組
+func (s *ConversationService) JoinByInvite(userID uint, code string) (*dto.ConversationDTO, error) {
+	invite, err := s.Repo.FindConversationInviteByCode(strings.TrimSpace(code))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInviteNotFound
+		}
+		return nil, fmt.Errorf("獲取邀請連結失敗: %v", err)
+	}
+	if !InviteUsable(invite, time.Now()) {
+		return nil, postgresqlRepo.ErrInviteUnavailable
+	}
+
+	joined, err := s.Repo.JoinConversationByInvite(invite, userID, s.Conf.Chat.MaxGroupMembers)
+	if err != nil {
+		return nil, s.groupJoinError(err)
+	}
+	if joined {
+		s.postSystemMessage(invite.ConversationID, userID, GroupActionMemberJoined, map[string]interface{}{"invite_id": invite.ID},
+			fmt.Sprintf("%s 透過邀請連結加入群組", s.username(userID)))
+	}
+	return s.reloadGroup(userID, invite.ConversationID)
+}
+
+// groupConversation 查找用戶參與的群組與其角色
+func (s *ConversationService) groupConversation(userID, conversationID uint) (*models.Conversation, string, error) {
+	conversation, err := s.memberConversation(s.Repo, userID, conversationID)
+	if err != nil {
+		return nil, "", err
+	}
+	if conversation.Type != models.ConversationTypeGroup {
+		return nil, "", fmt.Errorf("不是群組會話")
+	}
+	return conversation, findMember(conversation.Members, userID).Role, nil
+}
+
+// reloadGroup 重新讀取群組（主庫），反映剛完成的異動
+func (s *ConversationService) reloadGroup(userID, conversationID uint) (*dto.ConversationDTO, error) {
+	conversation, err := s.memberConversation(s.Repo, userID, conversationID)
+	if err != nil {
+		return nil, err
+	}
+	return s.toConversationDTO(s.Repo, userID, conversation)
+}
+
+// checkGroupInvitees 被加入的用戶必須存在且與操作者沒有封鎖關係
+func (s *ConversationService) checkGroupInvitees(userID uint, memberIDs []uint) error {
+	if len(memberIDs) == 0 {
+		return nil
+	}
+	users, err := s.Repo.FindUsersByIDs(memberIDs)
+	if err != nil {
+		return fmt.Errorf("獲取用戶失敗: %v", err)
+	}
+	if len(users) != len(memberIDs) {
+		return fmt.Errorf("找不到部分用戶")
+	}
+	return s.checkBlocked(userID, memberIDs)
+}
+
+// validateAvatarKey 頭像只能引用此群組上傳且已存在的圖片，空字串表示移除
+func (s *ConversationService) validateAvatarKey(conversationID uint, key string) error {
+	if key == "" {
+		return nil
+	}
+	if !strings.HasPrefix(key, storage.ConversationAvatarPrefix(conversationID)) {
+		return fmt.Errorf("無效的頭像檔案")
+	}
+
+	if s.S3Storage != nil {
+		exists, err := s.S3Storage.CheckFileExists(key)
+		if err != nil {
+			return fmt.Errorf("檢查頭像檔案失敗: %v", err)
+		}
+		if !exists {
+			return fmt.Errorf("頭像檔案尚未上傳")
+		}
+	}
+	return nil
+}
+
+// groupJoinError 轉換加入群組時的錯誤
+func (s *ConversationService) groupJoinError(err error) error {
+	switch {
+	case errors.Is(err, postgresqlRepo.ErrConversationFull):
+		return fmt.Errorf("群組人數上限為 %d 人", s.Conf.Chat.MaxGroupMembers)
+	case errors.Is(err, postgresqlRepo.ErrInviteUnavailable):
+		return err
+	default:
+		return fmt.Errorf("加入群組失敗: %v", err)
+	}
+}
+
+// postSystemMessage 建立群組異動的系統訊息，推送給目前的成員與已不在群組的 extraRecipients
+// 系統訊息是異動的附帶結果，失敗只記錄日誌
+func (s *ConversationService) postSystemMessage(conversationID, actorID uint, action string, data map[string]interface{}, content string, extraRecipients ...uint) {
+	payload := map[string]interface{}{"action": action, "actor_id": actorID}
+	for key, value := range data {
+		payload[key] = value
+	}
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		utils.LogError("序列化群組 %d 系統訊息失敗: %v", conversationID, err)
+		return
+	}
+
+	message := &models.Message{
+		ConversationID: conversationID,
+		SenderID:       actorID,
+		Type:           models.MessageTypeSystem,
+		Content:        content,
+		Data:           string(raw),
+	}
+	if err := s.Repo.CreateMessage(message); err != nil {
+		utils.LogError("建立群組 %d 系統訊息失敗: %v", conversationID, err)
+		return
+	}
+
+	recipients, err := s.Repo.FindConversationMemberIDs(conversationID)
+	if err != nil {
+		utils.LogError("獲取群組 %d 成員失敗: %v", conversationID, err)
+		return
+	}
+	s.publish(append(recipients, extraRecipients...), ChatEventMessage, s.newMessageDTO(message, actorID, nil))
+}
+
+// username 用戶名稱，找不到時以ID表示
+func (s *ConversationService) username(userID uint) string {
+	if user, err := findExistingUser(s.Repo, userID); err == nil {
+		return user.Username
+	}
+	return fmt.Sprintf("用戶 %d", userID)
+}
+
+// usernames 依序回傳多位用戶的名稱
+func (s *ConversationService) usernames(userIDs []uint) []string {
+	names := make(map[uint]string, len(userIDs))
+	if users, err := s.Repo.FindUsersByIDs(userIDs); err == nil {
+		for _, user := range users {
+			names[user.ID] = user.Username
+		}
+	}
+	result := make([]string, len(userIDs))
+	for i, id := range userIDs {
+		if name, ok := names[id]; ok {
+			result[i] = name
+		} else {
+			result[i] = fmt.Sprintf("用戶 %d", id)
+		}
+	}
+	return result
+}
+
+// findMember 查找會話成員，不是成員時回傳 nil
+func findMember(members []models.ConversationMember, userID uint) *models.ConversationMember {
+	for i := range members {
+		if members[i].UserID == userID {
+			return &members[i]
+		}
+	}
+	return nil
+}
+
+// uniqueGroupUserIDs 去除重複與操作者自己
+func uniqueGroupUserIDs(userIDs []uint, selfID uint) []uint {
+	seen := map[uint]bool{selfID: true}
+	result := make([]uint, 0, len(userIDs))
+	for _, id := range userIDs {
+		if id == 0 || seen[id] {
+			continue
+		}
+		seen[id] = true
+		result = append(result, id)
+	}
+	return result
+}
+
+// generateInviteCode 產生邀請碼
+func generateInviteCode() (string, error) {
+	buf := make([]byte, 12)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// newGroupInviteDTO 轉換邀請連結
+func newGroupInviteDTO(invite *models.ConversationInvite) *dto.GroupInviteDTO {
+	return &dto.GroupInviteDTO{
+		ID:        invite.ID,
+		Code:      invite.Code,
+		CreatedBy: invite.CreatedBy,
+		MaxUses:   invite.MaxUses,
+		Uses:      invite.Uses,
+		ExpiresAt: invite.ExpiresAt,
+		CreatedAt: invite.CreatedAt,
+	}
+}
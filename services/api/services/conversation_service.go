@@ -0,0 +1,531 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"stream-demo/backend/config"
+	"stream-demo/backend/database/models"
+	"stream-demo/backend/dto"
+	"stream-demo/backend/pkg/storage"
+	postgresqlRepo "stream-demo/backend/repositories/postgresql"
+	"stream-demo/backend/utils"
+)
+
+// 推送給用戶私訊 WebSocket 的事件
+const (
+	ChatEventMessage   = "message"   // 新訊息，data 為訊息內容
+	ChatEventTyping    = "typing"    // 輸入中狀態
+	ChatEventDelivered = "delivered" // 送達回條
+	ChatEventRead      = "read"      // 已讀回條
+)
+
+// 訊息回條狀態
+const (
+	MessageStatusSent      = "sent"
+	MessageStatusDelivered = "delivered"
+	MessageStatusRead      = "read"
+)
+
+const (
+	conversationPageDefault = 20
+	conversationPageMax     = 50
+	messagePageDefault      = 30
+	messagePageMax          = 100
+)
+
+// ErrConversationNotFound 會話不存在或不是會話成員
+var ErrConversationNotFound = errors.New("會話不存在")
+
+// DirectConversationKey 一對一會話的成員組合鍵，與順序無關
+func DirectConversationKey(userID, otherID uint) string {
+	if userID > otherID {
+		userID, otherID = otherID, userID
+	}
+	return fmt.Sprintf("%d:%d", userID, otherID)
+}
+
+// MessageReceiptStatus 依其他成員的回條計算訊息狀態：全部已讀為 read，全部送達為 delivered，否則為 sent
+func MessageReceiptStatus(messageID, senderID uint, members []models.ConversationMember) string {
+	status := MessageStatusRead
+	for _, member := range members {
+		if member.UserID == senderID {
+			continue
+		}
+		if member.LastDeliveredMessageID < messageID && member.LastReadMessageID < messageID {
+			return MessageStatusSent
+		}
+		if member.LastReadMessageID < messageID {
+			status = MessageStatusDelivered
+		}
+	}
+	return status
+}
+
+// ChatEventNotifier 推送私訊事件給用戶在本實例的 WebSocket 連線，未啟用 Redis 訊息時使用
+type ChatEventNotifier interface {
+	NotifyUsers(userIDs []uint, event string, data interface{})
+}
+
+// ConversationService 私訊服務
+type ConversationService struct {
+	Conf        *config.Config
+	Repo        *postgresqlRepo.PostgreSQLRepo
+	RepoSlave   *postgresqlRepo.PostgreSQLRepo
+	S3Storage   *storage.S3Storage // 群組頭像
+	ChatStorage *storage.S3Storage // 私有的附件桶
+
+	messaging     *utils.RedisMessaging // 多實例時經由 Redis 推送
+	notifier      ChatEventNotifier     // 未啟用 Redis 訊息時直接推送
+	friendService *FriendService        // 封鎖的用戶之間不能傳訊息
+}
+
+// NewConversationService 創建私訊服務
+func NewConversationService(conf *config.Config, messaging *utils.RedisMessaging, s3Storage *storage.S3Storage) *ConversationService {
+	// 附件使用同一組 S3 憑證，但存放在不公開的附件桶
+	chatStorage, err := storage.NewS3Storage(storage.S3Config{
+		AccessKey: conf.Storage.S3.AccessKey,
+		SecretKey: conf.Storage.S3.SecretKey,
+		Region:    conf.Storage.S3.Region,
+		Bucket:    conf.Chat.AttachmentBucket,
+		Endpoint:  conf.Storage.S3.Endpoint,
+	})
+	if err != nil {
+		utils.LogError("初始化私訊附件儲存失敗: %v", err)
+		chatStorage = nil
+	}
+
+	return &ConversationService{
+		Conf:        conf,
+		Repo:        postgresqlRepo.NewPostgreSQLRepo(conf.DB["master"]),
+		RepoSlave:   postgresqlRepo.NewPostgreSQLRepo(conf.DB["slave"]),
+		S3Storage:   s3Storage,
+		ChatStorage: chatStorage,
+		messaging:   messaging,
+	}
+}
+
+// SetNotifier 設置本實例的 WebSocket 推送器
+func (s *ConversationService) SetNotifier(notifier ChatEventNotifier) {
+	s.notifier = notifier
+}
+
+// SetFriendService 設置好友服務
+func (s *ConversationService) SetFriendService(friendService *FriendService) {
+	s.friendService = friendService
+}
+
+// OpenDirectConversation 開啟與用戶的一對一會話，已存在時回傳原會話
+func (s *ConversationService) OpenDirectConversation(userID, otherID uint) (*dto.ConversationDTO, error) {
+	if userID == otherID {
+		return nil, fmt.Errorf("不能傳訊息給自己")
+	}
+	if _, err := findExistingUser(s.RepoSlave, otherID); err != nil {
+		return nil, fmt.Errorf("找不到用戶: %v", err)
+	}
+	if err := s.checkBlocked(userID, []uint{otherID}); err != nil {
+		return nil, err
+	}
+
+	key := DirectConversationKey(userID, otherID)
+	conversation, err := s.Repo.FindDirectConversation(key)
+	if err != nil {
+		conversation = &models.Conversation{
+			Type:      models.ConversationTypeDirect,
+			DirectKey: &key,
+			CreatedBy: userID,
+		}
+		members := []models.ConversationMember{
+			{UserID: userID, Role: models.ConversationRoleMember},
+			{UserID: otherID, Role: models.ConversationRoleMember},
+		}
+		if err := s.Repo.CreateConversation(conversation, members); err != nil {
+			// 雙方同時開啟時由唯一索引擋下，改讀取已建立的會話
+			existing, findErr := s.Repo.FindDirectConversation(key)
+			if findErr != nil {
+				return nil, fmt.Errorf("建立會話失敗: %v", err)
+			}
+			conversation = existing
+		} else if conversation, err = s.Repo.FindConversationByID(conversation.ID); err != nil {
+			return nil, fmt.Errorf("獲取會話失敗: %v", err)
+		}
+	}
+
+	return s.toConversationDTO(s.Repo, userID, conversation)
+}
+
+// ListConversations 分頁列出參與的會話，最近有訊息的在前
+func (s *ConversationService) ListConversations(userID uint, offset, limit int) ([]*dto.ConversationDTO, int64, error) {
+	if offset < 0 {
+		offset = 0
+	}
+	if limit <= 0 {
+		limit = conversationPageDefault
+	}
+	if limit > conversationPageMax {
+		limit = conversationPageMax
+	}
+
+	conversations, total, err := s.RepoSlave.FindUserConversations(userID, offset, limit)
+	if err != nil {
+		return nil, 0, fmt.Errorf("獲取會話失敗: %v", err)
+	}
+
+	ids := make([]uint, len(conversations))
+	var lastMessageIDs []uint
+	for i, conversation := range conversations {
+		ids[i] = conversation.ID
+		if conversation.LastMessageID != nil {
+			lastMessageIDs = append(lastMessageIDs, *conversation.LastMessageID)
+		}
+	}
+	unread, err := s.RepoSlave.CountUnreadMessages(userID, ids)
+	if err != nil {
+		return nil, 0, fmt.Errorf("獲取未讀數失敗: %v", err)
+	}
+	lastMessages, err := s.RepoSlave.FindMessagesByIDs(lastMessageIDs)
+	if err != nil {
+		return nil, 0, fmt.Errorf("獲取最後訊息失敗: %v", err)
+	}
+	lastByID := make(map[uint]*models.Message, len(lastMessages))
+	for i := range lastMessages {
+		lastByID[lastMessages[i].ID] = &lastMessages[i]
+	}
+
+	result := make([]*dto.ConversationDTO, len(conversations))
+	for i := range conversations {
+		conversation := &conversations[i]
+		item := s.newConversationDTO(conversation)
+		item.UnreadCount = unread[conversation.ID]
+		if conversation.LastMessageID != nil {
+			if message, ok := lastByID[*conversation.LastMessageID]; ok {
+				item.LastMessage = s.newMessageDTO(message, userID, conversation.Members)
+			}
+		}
+		result[i] = item
+	}
+	return result, total, nil
+}
+
+// GetConversation 獲取會話
+func (s *ConversationService) GetConversation(userID, conversationID uint) (*dto.ConversationDTO, error) {
+	conversation, err := s.memberConversation(s.RepoSlave, userID, conversationID)
+	if err != nil {
+		return nil, err
+	}
+	return s.toConversationDTO(s.RepoSlave, userID, conversation)
+}
+
+// ListMessages 獲取會話訊息
+// 預設依時間由新到舊，cursor 為上一頁最後一則訊息的ID；指定 after 時回傳該訊息之後的訊息（由舊到新）
+func (s *ConversationService) ListMessages(userID, conversationID uint, query *dto.MessageListQueryDTO) (*dto.MessageListDTO, error) {
+	conversation, err := s.memberConversation(s.RepoSlave, userID, conversationID)
+	if err != nil {
+		return nil, err
+	}
+
+	limit := query.Limit
+	if limit <= 0 {
+		limit = messagePageDefault
+	}
+	if limit > messagePageMax {
+		limit = messagePageMax
+	}
+
+	var beforeID uint
+	if query.Cursor != "" {
+		parsed, err := strconv.ParseUint(query.Cursor, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("無效的游標")
+		}
+		beforeID = uint(parsed)
+	}
+
+	messages, err := s.RepoSlave.FindConversationMessages(conversationID, beforeID, query.After, limit+1)
+	if err != nil {
+		return nil, fmt.Errorf("獲取訊息失敗: %v", err)
+	}
+
+	result := &dto.MessageListDTO{Messages: make([]*dto.MessageDTO, 0, len(messages))}
+	if len(messages) > limit {
+		messages = messages[:limit]
+		if query.After == 0 {
+			result.NextCursor = strconv.FormatUint(uint64(messages[limit-1].ID), 10)
+		}
+	}
+	for i := range messages {
+		result.Messages = append(result.Messages, s.newMessageDTO(&messages[i], userID, conversation.Members))
+	}
+	return result, nil
+}
+
+// SendMessage 發送訊息並推送給會話成員，相同 client_id 重送時回傳原訊息
+func (s *ConversationService) SendMessage(userID, conversationID uint, req *dto.MessageSendDTO) (*dto.MessageDTO, error) {
+	content := strings.TrimSpace(req.Content)
+	if content == "" && req.Attachment == nil {
+		return nil, fmt.Errorf("訊息不能為空")
+	}
+	if utf8.RuneCountInString(content) > s.Conf.Chat.MaxMessageLength {
+		return nil, fmt.Errorf("訊息不能超過 %d 字", s.Conf.Chat.MaxMessageLength)
+	}
+
+	if len(req.ClientID) > 64 {
+		return nil, fmt.Errorf("client_id 不能超過 64 字元")
+	}
+
+	conversation, err := s.memberConversation(s.Repo, userID, conversationID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkDirectBlocked(conversation, userID); err != nil {
+		return nil, err
+	}
+
+	if req.ClientID != "" {
+		if existing, err := s.Repo.FindMessageByClientID(userID, req.ClientID); err == nil {
+			if existing.ConversationID != conversationID {
+				return nil, fmt.Errorf("client_id 已用於其他會話")
+			}
+			return s.newMessageDTO(existing, userID, conversation.Members), nil
+		}
+	}
+
+	message := &models.Message{
+		ConversationID: conversationID,
+		SenderID:       userID,
+		Type:           models.MessageTypeText,
+		Content:        content,
+		ClientID:       req.ClientID,
+	}
+	if req.Attachment != nil {
+		attachment, err := s.prepareAttachment(conversationID, req.Attachment)
+		if err != nil {
+			return nil, err
+		}
+		message.Type = attachment.Kind
+		message.Attachment = attachment
+	}
+	if err := s.Repo.CreateMessage(message); err != nil {
+		return nil, fmt.Errorf("發送訊息失敗: %v", err)
+	}
+
+	result := s.newMessageDTO(message, userID, conversation.Members)
+	result.Status = MessageStatusSent
+	s.publish(memberUserIDs(conversation.Members), ChatEventMessage, result)
+	return result, nil
+}
+
+// SetTyping 通知會話其他成員輸入中狀態
+func (s *ConversationService) SetTyping(userID, conversationID uint, typing bool) error {
+	conversation, err := s.memberConversation(s.RepoSlave, userID, conversationID)
+	if err != nil {
+		return err
+	}
+	if err := s.checkDirectBlocked(conversation, userID); err != nil {
+		return err
+	}
+
+	s.publish(otherMemberIDs(conversation.Members, userID), ChatEventTyping, map[string]interface{}{
+		"conversation_id": conversationID,
+		"user_id":         userID,
+		"typing":          typing,
+	})
+	return nil
+}
+
+// MarkDelivered 回報訊息已送達（含之前的訊息）
+func (s *ConversationService) MarkDelivered(userID, conversationID, messageID uint) error {
+	return s.advanceReceipt(userID, conversationID, messageID, false)
+}
+
+// MarkRead 回報訊息已讀（含之前的訊息）
+func (s *ConversationService) MarkRead(userID, conversationID, messageID uint) error {
+	return s.advanceReceipt(userID, conversationID, messageID, true)
+}
+
+// advanceReceipt 前移回條並推送給會話成員（包含自己的其他裝置，以同步未讀數）
+func (s *ConversationService) advanceReceipt(userID, conversationID, messageID uint, read bool) error {
+	conversation, err := s.memberConversation(s.Repo, userID, conversationID)
+	if err != nil {
+		return err
+	}
+	exists, err := s.Repo.ConversationHasMessage(conversationID, messageID)
+	if err != nil {
+		return fmt.Errorf("獲取訊息失敗: %v", err)
+	}
+	if !exists {
+		return fmt.Errorf("訊息不存在")
+	}
+
+	changed, err := s.Repo.AdvanceMessageReceipt(conversationID, userID, messageID, read)
+	if err != nil {
+		return fmt.Errorf("更新回條失敗: %v", err)
+	}
+	if !changed {
+		return nil
+	}
+
+	event := ChatEventDelivered
+	if read {
+		event = ChatEventRead
+	}
+	s.publish(memberUserIDs(conversation.Members), event, map[string]interface{}{
+		"conversation_id": conversationID,
+		"user_id":         userID,
+		"message_id":      messageID,
+	})
+	return nil
+}
+
+// checkDirectBlocked 一對一會話的雙方有封鎖關係時不能互動
+func (s *ConversationService) checkDirectBlocked(conversation *models.Conversation, userID uint) error {
+	if conversation.Type != models.ConversationTypeDirect {
+		return nil
+	}
+	return s.checkBlocked(userID, otherMemberIDs(conversation.Members, userID))
+}
+
+// checkBlocked 與任一用戶有封鎖關係時回傳 ErrUserBlocked
+func (s *ConversationService) checkBlocked(userID uint, otherIDs []uint) error {
+	if s.friendService == nil {
+		return nil
+	}
+	for _, otherID := range otherIDs {
+		blocked, err := s.friendService.IsBlocked(userID, otherID)
+		if err != nil {
+			return fmt.Errorf("獲取封鎖關係失敗: %v", err)
+		}
+		if blocked {
+			return ErrUserBlocked
+		}
+	}
+	return nil
+}
+
+// memberConversation 查找用戶參與的會話，不是成員時回傳 ErrConversationNotFound
+func (s *ConversationService) memberConversation(repo *postgresqlRepo.PostgreSQLRepo, userID, conversationID uint) (*models.Conversation, error) {
+	conversation, err := repo.FindConversationByID(conversationID)
+	if err != nil {
+		return nil, ErrConversationNotFound
+	}
+	for _, member := range conversation.Members {
+		if member.UserID == userID {
+			return conversation, nil
+		}
+	}
+	return nil, ErrConversationNotFound
+}
+
+// toConversationDTO 轉換為會話 DTO 並附上最後訊息與未讀數
+func (s *ConversationService) toConversationDTO(repo *postgresqlRepo.PostgreSQLRepo, userID uint, conversation *models.Conversation) (*dto.ConversationDTO, error) {
+	result := s.newConversationDTO(conversation)
+
+	unread, err := repo.CountUnreadMessages(userID, []uint{conversation.ID})
+	if err != nil {
+		return nil, fmt.Errorf("獲取未讀數失敗: %v", err)
+	}
+	result.UnreadCount = unread[conversation.ID]
+
+	if conversation.LastMessageID != nil {
+		messages, err := repo.FindMessagesByIDs([]uint{*conversation.LastMessageID})
+		if err != nil {
+			return nil, fmt.Errorf("獲取最後訊息失敗: %v", err)
+		}
+		if len(messages) > 0 {
+			result.LastMessage = s.newMessageDTO(&messages[0], userID, conversation.Members)
+		}
+	}
+	return result, nil
+}
+
+// publish 推送私訊事件，啟用 Redis 訊息時經由 Redis 送到所有實例
+func (s *ConversationService) publish(userIDs []uint, event string, data interface{}) {
+	if len(userIDs) == 0 {
+		return
+	}
+	if s.messaging != nil {
+		if err := s.messaging.PublishChatEvent(userIDs, event, data); err != nil {
+			utils.LogError("推送私訊事件 %s 失敗: %v", event, err)
+		}
+		return
+	}
+	if s.notifier != nil {
+		s.notifier.NotifyUsers(userIDs, event, data)
+	}
+}
+
+// memberUserIDs 會話所有成員的用戶ID
+func memberUserIDs(members []models.ConversationMember) []uint {
+	ids := make([]uint, len(members))
+	for i, member := range members {
+		ids[i] = member.UserID
+	}
+	return ids
+}
+
+// otherMemberIDs 除了指定用戶以外的成員ID
+func otherMemberIDs(members []models.ConversationMember, userID uint) []uint {
+	ids := make([]uint, 0, len(members))
+	for _, member := range members {
+		if member.UserID != userID {
+			ids = append(ids, member.UserID)
+		}
+	}
+	return ids
+}
+
+// newConversationDTO 轉換會話與成員
+func (s *ConversationService) newConversationDTO(conversation *models.Conversation) *dto.ConversationDTO {
+	result := &dto.ConversationDTO{
+		ID:            conversation.ID,
+		Type:          conversation.Type,
+		Name:          conversation.Name,
+		Members:       make([]*dto.ConversationMemberDTO, len(conversation.Members)),
+		LastMessageAt: conversation.LastMessageAt,
+		CreatedAt:     conversation.CreatedAt,
+	}
+	if conversation.AvatarKey != "" && s.S3Storage != nil {
+		result.AvatarURL = s.S3Storage.GenerateCDNURL(conversation.AvatarKey)
+	}
+	for i, member := range conversation.Members {
+		item := &dto.ConversationMemberDTO{
+			UserID:                 member.UserID,
+			Role:                   member.Role,
+			LastDeliveredMessageID: member.LastDeliveredMessageID,
+			LastReadMessageID:      member.LastReadMessageID,
+		}
+		if member.User != nil {
+			item.Username = member.User.Username
+			item.Avatar = member.User.Avatar
+		}
+		result.Members[i] = item
+	}
+	return result
+}
+
+// newMessageDTO 轉換訊息，自己發送的訊息附上回條狀態，附件附上預簽名下載URL
+func (s *ConversationService) newMessageDTO(message *models.Message, viewerID uint, members []models.ConversationMember) *dto.MessageDTO {
+	result := &dto.MessageDTO{
+		ID:             message.ID,
+		ConversationID: message.ConversationID,
+		SenderID:       message.SenderID,
+		Type:           message.Type,
+		Content:        message.Content,
+		ClientID:       message.ClientID,
+		CreatedAt:      message.CreatedAt,
+	}
+	if message.Type == models.MessageTypeSystem && message.Data != "" {
+		result.Data = json.RawMessage(message.Data)
+	}
+	if message.Attachment != nil {
+		result.Attachment = s.newAttachmentDTO(message.Attachment)
+	}
+	if message.SenderID == viewerID && message.Type != models.MessageTypeSystem {
+		result.Status = MessageReceiptStatus(message.ID, message.SenderID, members)
+	}
+	return result
+}
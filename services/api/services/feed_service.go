@@ -0,0 +1,531 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"stream-demo/backend/config"
+	"stream-demo/backend/database/models"
+	"stream-demo/backend/dto"
+	postgresqlRepo "stream-demo/backend/repositories/postgresql"
+	"stream-demo/backend/utils"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// 追蹤動態存於 Redis 有序集合 feed:user:<id>，成員為 video:<影片ID> 或 live:<直播間ID>，分數為發布時間(毫秒)
+const (
+	feedTypeVideo      = "video"
+	feedTypeLive       = "live"
+	feedPublishBatch   = 100 // 每次查詢待擴散影片的數量
+	feedBackfillVideos = 20  // 新追蹤時補進動態的影片數
+	feedPageDefault    = 20
+	feedPageMax        = 50
+)
+
+// ParseFeedMember 解析動態成員，回傳類型（video 或 live）與ID
+func ParseFeedMember(member string) (string, string, bool) {
+	kind, id, ok := strings.Cut(member, ":")
+	if !ok || id == "" || (kind != feedTypeVideo && kind != feedTypeLive) {
+		return "", "", false
+	}
+	return kind, id, true
+}
+
+// feedKey 用戶動態的 Redis 鍵
+func feedKey(userID uint) string {
+	return fmt.Sprintf("feed:user:%d", userID)
+}
+
+// feedMember 動態成員
+func feedMember(kind, id string) string {
+	return kind + ":" + id
+}
+
+// FeedService 追蹤動態服務：新影片完成與開播時擴散到追隨者的動態（寫入時擴散）
+type FeedService struct {
+	Conf      *config.Config
+	Repo      *postgresqlRepo.PostgreSQLRepo
+	RepoSlave *postgresqlRepo.PostgreSQLRepo
+
+	liveRoomService     *LiveRoomService     // 查詢正在直播的直播間
+	notificationService *NotificationService // 開播通知
+
+	stopChan chan struct{}
+	ticker   *time.Ticker
+}
+
+// NewFeedService 創建追蹤動態服務
+func NewFeedService(conf *config.Config, liveRoomService *LiveRoomService, notificationService *NotificationService) *FeedService {
+	return &FeedService{
+		Conf:                conf,
+		Repo:                postgresqlRepo.NewPostgreSQLRepo(conf.DB["master"]),
+		RepoSlave:           postgresqlRepo.NewPostgreSQLRepo(conf.DB["slave"]),
+		liveRoomService:     liveRoomService,
+		notificationService: notificationService,
+		stopChan:            make(chan struct{}),
+	}
+}
+
+// Start 啟動新完成影片的擴散
+func (s *FeedService) Start() {
+	interval := time.Duration(s.Conf.Feed.PollInterval) * time.Second
+	s.ticker = time.NewTicker(interval)
+
+	go func() {
+		s.PublishReadyVideos()
+		for {
+			select {
+			case <-s.ticker.C:
+				s.PublishReadyVideos()
+			case <-s.stopChan:
+				s.ticker.Stop()
+				return
+			}
+		}
+	}()
+
+	utils.LogInfo("追蹤動態服務已啟動，檢查間隔: %v", interval)
+}
+
+// Stop 停止追蹤動態服務
+func (s *FeedService) Stop() {
+	close(s.stopChan)
+	utils.LogInfo("追蹤動態服務已停止")
+}
+
+// PublishReadyVideos 將已完成轉碼但尚未擴散的影片寫入追隨者的動態
+// 轉碼服務直接更新資料庫狀態，因此以 feed_published_at 判斷是否已擴散
+func (s *FeedService) PublishReadyVideos() {
+	for {
+		videos, err := s.Repo.FindUnpublishedFeedVideos(feedPublishBatch)
+		if err != nil {
+			utils.LogError("查詢待擴散影片失敗: %v", err)
+			return
+		}
+
+		for _, video := range videos {
+			publishedAt := time.Now()
+			if err := s.Repo.MarkVideoFeedPublished(video.ID, publishedAt); err != nil {
+				utils.LogError("記錄影片 %d 擴散時間失敗: %v", video.ID, err)
+				return
+			}
+			s.fanOut(video.UserID, feedMember(feedTypeVideo, strconv.FormatUint(uint64(video.ID), 10)), publishedAt, nil)
+		}
+
+		if len(videos) < feedPublishBatch {
+			return
+		}
+	}
+}
+
+// FanOutLiveStarted 開播時寫入追隨者的動態並通知追隨者
+func (s *FeedService) FanOutLiveStarted(creatorID uint, roomID string, startedAt time.Time) {
+	title := ""
+	if s.liveRoomService != nil {
+		if room, err := s.liveRoomService.GetRoomByID(roomID); err == nil {
+			title = room.Title
+		}
+	}
+	creatorName := fmt.Sprintf("用戶 %d", creatorID)
+	if creator, err := findExistingUser(s.RepoSlave, creatorID); err == nil {
+		creatorName = creator.Username
+	}
+
+	content := fmt.Sprintf("%s 開始直播：%s", creatorName, title)
+	data := map[string]interface{}{"room_id": roomID, "creator_id": creatorID}
+	s.fanOut(creatorID, feedMember(feedTypeLive, roomID), startedAt, func(followerIDs []uint) {
+		if s.notificationService == nil {
+			return
+		}
+		if err := s.notificationService.NotifyMany(followerIDs, models.NotificationFollowedLiveStarted, "追蹤的創作者開始直播", content, data); err != nil {
+			utils.LogError("發送用戶 %d 的開播通知失敗: %v", creatorID, err)
+		}
+	})
+}
+
+// OnFollow 新追蹤時將對方最近的影片與正在進行的直播補進動態
+// 動態尚未建立時不處理，讀取時會由資料庫重建
+func (s *FeedService) OnFollow(followerID, followeeID uint) {
+	client := utils.GetRedisClient()
+	if client == nil {
+		return
+	}
+
+	ctx := context.Background()
+	key := feedKey(followerID)
+	if exists, err := client.Exists(ctx, key).Result(); err != nil || exists == 0 {
+		return
+	}
+
+	entries, err := s.feedEntries([]uint{followeeID}, feedBackfillVideos)
+	if err != nil {
+		utils.LogError("補進用戶 %d 動態失敗: %v", followerID, err)
+		return
+	}
+	s.writeFeed(ctx, client, key, entries)
+}
+
+// OnUnfollow 取消追蹤時清除動態，讀取時由資料庫重建
+func (s *FeedService) OnUnfollow(followerID uint) {
+	if client := utils.GetRedisClient(); client != nil {
+		client.Del(context.Background(), feedKey(followerID))
+	}
+}
+
+// GetFeed 獲取追蹤動態，依發布時間由新到舊，cursor 為上一頁最後一項的發布時間(毫秒)
+func (s *FeedService) GetFeed(userID uint, cursor string, limit int) (*dto.FeedDTO, error) {
+	if limit <= 0 {
+		limit = feedPageDefault
+	}
+	if limit > feedPageMax {
+		limit = feedPageMax
+	}
+
+	var before int64
+	if cursor != "" {
+		parsed, err := strconv.ParseInt(cursor, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("無效的游標")
+		}
+		before = parsed
+	}
+
+	client := utils.GetRedisClient()
+	if client == nil {
+		return s.feedFromDatabase(userID, before, limit)
+	}
+
+	ctx := context.Background()
+	key := feedKey(userID)
+	if err := s.ensureFeed(ctx, client, userID); err != nil {
+		utils.LogWarn("重建用戶 %d 動態失敗，改由資料庫查詢: %v", userID, err)
+		return s.feedFromDatabase(userID, before, limit)
+	}
+
+	result := &dto.FeedDTO{Items: []*dto.FeedItemDTO{}}
+	maxScore := "+inf"
+	if before > 0 {
+		maxScore = "(" + strconv.FormatInt(before, 10)
+	}
+
+	exhausted := false
+	for len(result.Items) < limit && !exhausted {
+		entries, err := client.ZRevRangeByScoreWithScores(ctx, key, &redis.ZRangeBy{
+			Min:   "-inf",
+			Max:   maxScore,
+			Count: int64(limit),
+		}).Result()
+		if err != nil {
+			return nil, fmt.Errorf("獲取動態失敗: %v", err)
+		}
+		exhausted = len(entries) < limit
+		if len(entries) == 0 {
+			break
+		}
+
+		for _, item := range s.hydrateFeed(ctx, client, key, userID, entries) {
+			if len(result.Items) == limit {
+				exhausted = false
+				break
+			}
+			result.Items = append(result.Items, item)
+		}
+		maxScore = "(" + strconv.FormatInt(int64(entries[len(entries)-1].Score), 10)
+	}
+
+	if len(result.Items) == limit && !exhausted {
+		result.NextCursor = strconv.FormatInt(result.Items[len(result.Items)-1].PublishedAt.UnixMilli(), 10)
+	}
+	return result, nil
+}
+
+// fanOut 分批將動態成員寫入追隨者的動態，notify 不為 nil 時對每位追隨者呼叫
+func (s *FeedService) fanOut(creatorID uint, member string, publishedAt time.Time, notify func(followerIDs []uint)) {
+	client := utils.GetRedisClient()
+	ctx := context.Background()
+	score := float64(publishedAt.UnixMilli())
+	batch := s.Conf.Feed.FanOutBatch
+
+	var afterID uint
+	for {
+		follows, err := s.Repo.FindFollowerBatch(creatorID, afterID, batch)
+		if err != nil {
+			utils.LogError("查詢用戶 %d 的追隨者失敗: %v", creatorID, err)
+			return
+		}
+		if len(follows) == 0 {
+			return
+		}
+		afterID = follows[len(follows)-1].ID
+
+		if client != nil {
+			s.pushToFeeds(ctx, client, follows, redis.Z{Score: score, Member: member})
+		}
+		if notify != nil {
+			followerIDs := make([]uint, len(follows))
+			for i, follow := range follows {
+				followerIDs[i] = follow.FollowerID
+			}
+			notify(followerIDs)
+		}
+
+		if len(follows) < batch {
+			return
+		}
+	}
+}
+
+// pushToFeeds 寫入一批追隨者已建立的動態，尚未建立的動態讀取時才由資料庫重建
+func (s *FeedService) pushToFeeds(ctx context.Context, client *redis.Client, follows []models.Follow, entry redis.Z) {
+	existsPipe := client.Pipeline()
+	checks := make([]*redis.IntCmd, len(follows))
+	for i, follow := range follows {
+		checks[i] = existsPipe.Exists(ctx, feedKey(follow.FollowerID))
+	}
+	if _, err := existsPipe.Exec(ctx); err != nil {
+		utils.LogError("檢查追隨者動態失敗: %v", err)
+		return
+	}
+
+	pipe := client.Pipeline()
+	for i, follow := range follows {
+		if checks[i].Val() == 0 {
+			continue
+		}
+		s.queueFeedWrite(ctx, pipe, feedKey(follow.FollowerID), []redis.Z{entry})
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		utils.LogError("寫入追隨者動態失敗: %v", err)
+	}
+}
+
+// ensureFeed 動態不存在時由資料庫重建
+func (s *FeedService) ensureFeed(ctx context.Context, client *redis.Client, userID uint) error {
+	key := feedKey(userID)
+	exists, err := client.Exists(ctx, key).Result()
+	if err != nil {
+		return err
+	}
+	if exists > 0 {
+		return nil
+	}
+
+	followingIDs, err := s.RepoSlave.FindFollowingIDs(userID)
+	if err != nil {
+		return err
+	}
+	entries, err := s.feedEntries(followingIDs, s.Conf.Feed.MaxItems)
+	if err != nil {
+		return err
+	}
+	s.writeFeed(ctx, client, key, entries)
+	return nil
+}
+
+// feedEntries 查詢創作者已擴散的影片與正在進行的直播
+func (s *FeedService) feedEntries(creatorIDs []uint, videoLimit int) ([]redis.Z, error) {
+	if len(creatorIDs) == 0 {
+		return nil, nil
+	}
+
+	videos, err := s.RepoSlave.FindFeedVideos(creatorIDs, time.Time{}, videoLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]redis.Z, 0, len(videos))
+	for _, video := range videos {
+		entries = append(entries, redis.Z{
+			Score:  float64(video.FeedPublishedAt.UnixMilli()),
+			Member: feedMember(feedTypeVideo, strconv.FormatUint(uint64(video.ID), 10)),
+		})
+	}
+	for _, room := range s.liveRoomsOf(creatorIDs) {
+		entries = append(entries, redis.Z{
+			Score:  float64(room.StartedAt.UnixMilli()),
+			Member: feedMember(feedTypeLive, room.ID),
+		})
+	}
+	return entries, nil
+}
+
+// writeFeed 寫入動態項目
+func (s *FeedService) writeFeed(ctx context.Context, client *redis.Client, key string, entries []redis.Z) {
+	if len(entries) == 0 {
+		return
+	}
+	pipe := client.Pipeline()
+	s.queueFeedWrite(ctx, pipe, key, entries)
+	if _, err := pipe.Exec(ctx); err != nil {
+		utils.LogError("寫入動態 %s 失敗: %v", key, err)
+	}
+}
+
+// queueFeedWrite 寫入項目、只保留最新的 max_items 筆並更新過期時間
+func (s *FeedService) queueFeedWrite(ctx context.Context, pipe redis.Pipeliner, key string, entries []redis.Z) {
+	pipe.ZAdd(ctx, key, entries...)
+	pipe.ZRemRangeByRank(ctx, key, 0, int64(-s.Conf.Feed.MaxItems-1))
+	pipe.Expire(ctx, key, time.Duration(s.Conf.Feed.TTLDays)*24*time.Hour)
+}
+
+// hydrateFeed 將動態成員轉換為影片與直播間，移除已刪除、已結束或無權觀看的項目
+func (s *FeedService) hydrateFeed(ctx context.Context, client *redis.Client, key string, viewerID uint, entries []redis.Z) []*dto.FeedItemDTO {
+	var videoIDs []uint
+	for _, entry := range entries {
+		if kind, id, ok := ParseFeedMember(fmt.Sprint(entry.Member)); ok && kind == feedTypeVideo {
+			if videoID, err := strconv.ParseUint(id, 10, 32); err == nil {
+				videoIDs = append(videoIDs, uint(videoID))
+			}
+		}
+	}
+
+	videos := make(map[string]*models.Video, len(videoIDs))
+	if len(videoIDs) > 0 {
+		found, err := s.RepoSlave.FindVideosByIDs(videoIDs)
+		if err != nil {
+			utils.LogError("查詢動態影片失敗: %v", err)
+		}
+		for i := range found {
+			videos[strconv.FormatUint(uint64(found[i].ID), 10)] = &found[i]
+		}
+	}
+
+	items := make([]*dto.FeedItemDTO, 0, len(entries))
+	var videoDTOs []*dto.VideoDTO
+	var stale []interface{}
+	for _, entry := range entries {
+		member := fmt.Sprint(entry.Member)
+		publishedAt := time.UnixMilli(int64(entry.Score))
+		kind, id, ok := ParseFeedMember(member)
+		if !ok {
+			stale = append(stale, member)
+			continue
+		}
+
+		switch kind {
+		case feedTypeVideo:
+			video, found := videos[id]
+			if !found || video.Status != "ready" || !canViewVideo(s.RepoSlave, video, viewerID) {
+				stale = append(stale, member)
+				continue
+			}
+			videoDTO := newVideoDTO(video)
+			videoDTOs = append(videoDTOs, videoDTO)
+			items = append(items, &dto.FeedItemDTO{Type: feedTypeVideo, PublishedAt: publishedAt, Video: videoDTO})
+		case feedTypeLive:
+			room := s.liveRoom(id)
+			if room == nil {
+				stale = append(stale, member)
+				continue
+			}
+			items = append(items, &dto.FeedItemDTO{Type: feedTypeLive, PublishedAt: publishedAt, LiveRoom: room})
+		}
+	}
+
+	if len(stale) > 0 {
+		client.ZRem(ctx, key, stale...)
+	}
+	applyViewerReactions(s.RepoSlave, viewerID, videoDTOs)
+	return items
+}
+
+// feedFromDatabase Redis 無法使用時直接由資料庫查詢動態，正在直播的直播間只出現在第一頁
+func (s *FeedService) feedFromDatabase(userID uint, before int64, limit int) (*dto.FeedDTO, error) {
+	followingIDs, err := s.RepoSlave.FindFollowingIDs(userID)
+	if err != nil {
+		return nil, fmt.Errorf("獲取追蹤列表失敗: %v", err)
+	}
+
+	var beforeTime time.Time
+	if before > 0 {
+		beforeTime = time.UnixMilli(before)
+	}
+	videos, err := s.RepoSlave.FindFeedVideos(followingIDs, beforeTime, limit)
+	if err != nil {
+		return nil, fmt.Errorf("獲取動態失敗: %v", err)
+	}
+
+	result := &dto.FeedDTO{Items: []*dto.FeedItemDTO{}}
+	if before == 0 {
+		for _, room := range s.liveRoomsOf(followingIDs) {
+			result.Items = append(result.Items, &dto.FeedItemDTO{Type: feedTypeLive, PublishedAt: room.StartedAt, LiveRoom: newFeedLiveRoomDTO(room)})
+		}
+	}
+
+	var videoDTOs []*dto.VideoDTO
+	for i := range videos {
+		if !canViewVideo(s.RepoSlave, &videos[i], userID) {
+			continue
+		}
+		videoDTO := newVideoDTO(&videos[i])
+		videoDTOs = append(videoDTOs, videoDTO)
+		result.Items = append(result.Items, &dto.FeedItemDTO{Type: feedTypeVideo, PublishedAt: *videos[i].FeedPublishedAt, Video: videoDTO})
+	}
+	applyViewerReactions(s.RepoSlave, userID, videoDTOs)
+
+	sort.SliceStable(result.Items, func(i, j int) bool {
+		return result.Items[i].PublishedAt.After(result.Items[j].PublishedAt)
+	})
+	if len(videos) == limit {
+		result.NextCursor = strconv.FormatInt(videos[len(videos)-1].FeedPublishedAt.UnixMilli(), 10)
+	}
+	return result, nil
+}
+
+// liveRoomsOf 查詢創作者正在進行的直播
+func (s *FeedService) liveRoomsOf(creatorIDs []uint) []*LiveRoomInfo {
+	client := utils.GetRedisClient()
+	if client == nil || s.liveRoomService == nil || len(creatorIDs) == 0 {
+		return nil
+	}
+
+	creators := make(map[int]bool, len(creatorIDs))
+	for _, id := range creatorIDs {
+		creators[int(id)] = true
+	}
+
+	roomIDs, err := client.ZRange(context.Background(), "live:active_rooms", 0, -1).Result()
+	if err != nil {
+		utils.LogError("獲取活躍直播間失敗: %v", err)
+		return nil
+	}
+
+	var rooms []*LiveRoomInfo
+	for _, roomID := range roomIDs {
+		room, err := s.liveRoomService.GetRoomByID(roomID)
+		if err != nil || room.Status != "live" || !creators[room.CreatorID] {
+			continue
+		}
+		rooms = append(rooms, room)
+	}
+	return rooms
+}
+
+// liveRoom 查詢正在直播的直播間，已結束時回傳 nil
+func (s *FeedService) liveRoom(roomID string) *dto.FeedLiveRoomDTO {
+	if s.liveRoomService == nil {
+		return nil
+	}
+	room, err := s.liveRoomService.GetRoomByID(roomID)
+	if err != nil || room.Status != "live" {
+		return nil
+	}
+	return newFeedLiveRoomDTO(room)
+}
+
+// newFeedLiveRoomDTO 轉換動態中的直播間
+func newFeedLiveRoomDTO(room *LiveRoomInfo) *dto.FeedLiveRoomDTO {
+	return &dto.FeedLiveRoomDTO{
+		ID:          room.ID,
+		Title:       room.Title,
+		Description: room.Description,
+		CreatorID:   uint(room.CreatorID),
+		ViewerCount: room.ViewerCount,
+		StartedAt:   room.StartedAt,
+	}
+}
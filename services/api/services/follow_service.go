@@ -0,0 +1,170 @@
+package services
+
+import (
+	"fmt"
+
+	"stream-demo/backend/config"
+	"stream-demo/backend/database/models"
+	"stream-demo/backend/dto"
+	postgresqlRepo "stream-demo/backend/repositories/postgresql"
+	"stream-demo/backend/utils"
+)
+
+// FollowService 追蹤服務
+type FollowService struct {
+	Conf      *config.Config
+	Repo      *postgresqlRepo.PostgreSQLRepo
+	RepoSlave *postgresqlRepo.PostgreSQLRepo
+
+	feedService         *FeedService         // 追蹤變更時更新動態
+	notificationService *NotificationService // 通知被追蹤的用戶
+}
+
+// NewFollowService 創建追蹤服務
+func NewFollowService(conf *config.Config, feedService *FeedService, notificationService *NotificationService) *FollowService {
+	return &FollowService{
+		Conf:                conf,
+		Repo:                postgresqlRepo.NewPostgreSQLRepo(conf.DB["master"]),
+		RepoSlave:           postgresqlRepo.NewPostgreSQLRepo(conf.DB["slave"]),
+		feedService:         feedService,
+		notificationService: notificationService,
+	}
+}
+
+// Follow 追蹤用戶（重複追蹤視為成功）
+func (s *FollowService) Follow(followerID, followeeID uint) (*dto.FollowStatusDTO, error) {
+	if followerID == followeeID {
+		return nil, fmt.Errorf("不能追蹤自己")
+	}
+	if _, err := findExistingUser(s.Repo, followeeID); err != nil {
+		return nil, fmt.Errorf("找不到用戶: %v", err)
+	}
+
+	created, err := s.Repo.CreateFollow(followerID, followeeID)
+	if err != nil {
+		return nil, fmt.Errorf("追蹤失敗: %v", err)
+	}
+	if created && s.feedService != nil {
+		go s.feedService.OnFollow(followerID, followeeID)
+	}
+	if created && s.notificationService != nil {
+		go s.notifyNewFollower(followerID, followeeID)
+	}
+
+	return s.followStatus(s.Repo, followeeID, true)
+}
+
+// Unfollow 取消追蹤（未追蹤時視為成功）
+func (s *FollowService) Unfollow(followerID, followeeID uint) (*dto.FollowStatusDTO, error) {
+	removed, err := s.Repo.DeleteFollow(followerID, followeeID)
+	if err != nil {
+		return nil, fmt.Errorf("取消追蹤失敗: %v", err)
+	}
+	if removed && s.feedService != nil {
+		s.feedService.OnUnfollow(followerID)
+	}
+
+	return s.followStatus(s.Repo, followeeID, false)
+}
+
+// notifyNewFollower 通知被追蹤的用戶有新的追隨者
+func (s *FollowService) notifyNewFollower(followerID, followeeID uint) {
+	followerName := fmt.Sprintf("用戶 %d", followerID)
+	if follower, err := findExistingUser(s.Repo, followerID); err == nil {
+		followerName = follower.Username
+	}
+
+	data := map[string]interface{}{"follower_id": followerID}
+	if err := s.notificationService.Notify(followeeID, models.NotificationNewFollower, "新的追隨者", fmt.Sprintf("%s 開始追蹤你", followerName), data); err != nil {
+		utils.LogError("發送新追隨者通知給用戶 %d 失敗: %v", followeeID, err)
+	}
+}
+
+// GetFollowStatus 獲取是否追蹤用戶與對方的追蹤統計
+func (s *FollowService) GetFollowStatus(viewerID, userID uint) (*dto.FollowStatusDTO, error) {
+	if _, err := findExistingUser(s.RepoSlave, userID); err != nil {
+		return nil, fmt.Errorf("找不到用戶: %v", err)
+	}
+
+	following := false
+	if viewerID != 0 && viewerID != userID {
+		var err error
+		if following, err = s.RepoSlave.IsFollowing(viewerID, userID); err != nil {
+			return nil, fmt.Errorf("獲取追蹤狀態失敗: %v", err)
+		}
+	}
+	return s.followStatus(s.RepoSlave, userID, following)
+}
+
+// followPageMax 追蹤列表每頁上限
+const followPageMax = 100
+
+// ListFollowers 分頁列出追隨者
+func (s *FollowService) ListFollowers(userID uint, offset, limit int) ([]*dto.FollowUserDTO, int64, error) {
+	offset, limit = normalizeFollowPage(offset, limit)
+	follows, total, err := s.RepoSlave.FindFollowers(userID, offset, limit)
+	if err != nil {
+		return nil, 0, fmt.Errorf("獲取追隨者失敗: %v", err)
+	}
+
+	users := make([]*dto.FollowUserDTO, len(follows))
+	for i := range follows {
+		users[i] = newFollowUserDTO(follows[i].Follower, follows[i].FollowerID, &follows[i])
+	}
+	return users, total, nil
+}
+
+// ListFollowing 分頁列出追蹤中的用戶
+func (s *FollowService) ListFollowing(userID uint, offset, limit int) ([]*dto.FollowUserDTO, int64, error) {
+	offset, limit = normalizeFollowPage(offset, limit)
+	follows, total, err := s.RepoSlave.FindFollowing(userID, offset, limit)
+	if err != nil {
+		return nil, 0, fmt.Errorf("獲取追蹤列表失敗: %v", err)
+	}
+
+	users := make([]*dto.FollowUserDTO, len(follows))
+	for i := range follows {
+		users[i] = newFollowUserDTO(follows[i].Followee, follows[i].FolloweeID, &follows[i])
+	}
+	return users, total, nil
+}
+
+// followStatus 組合追蹤狀態與追蹤統計
+func (s *FollowService) followStatus(repo *postgresqlRepo.PostgreSQLRepo, userID uint, following bool) (*dto.FollowStatusDTO, error) {
+	followers, err := repo.CountFollowers(userID)
+	if err != nil {
+		return nil, fmt.Errorf("獲取追隨者數失敗: %v", err)
+	}
+	followingCount, err := repo.CountFollowing(userID)
+	if err != nil {
+		return nil, fmt.Errorf("獲取追蹤數失敗: %v", err)
+	}
+
+	return &dto.FollowStatusDTO{
+		UserID:         userID,
+		Following:      following,
+		FollowerCount:  followers,
+		FollowingCount: followingCount,
+	}, nil
+}
+
+// normalizeFollowPage 修正分頁參數
+func normalizeFollowPage(offset, limit int) (int, int) {
+	if offset < 0 {
+		offset = 0
+	}
+	if limit <= 0 || limit > followPageMax {
+		limit = 20
+	}
+	return offset, limit
+}
+
+// newFollowUserDTO 轉換追蹤列表中的用戶
+func newFollowUserDTO(user *models.User, userID uint, follow *models.Follow) *dto.FollowUserDTO {
+	userDTO := &dto.FollowUserDTO{ID: userID, FollowedAt: follow.CreatedAt}
+	if user != nil {
+		userDTO.Username = user.Username
+		userDTO.Avatar = user.Avatar
+	}
+	return userDTO
+}
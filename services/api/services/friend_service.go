@@ -0,0 +1,374 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"stream-demo/backend/config"
+	"stream-demo/backend/database/models"
+	"stream-demo/backend/dto"
+	postgresqlRepo "stream-demo/backend/repositories/postgresql"
+	"stream-demo/backend/utils"
+)
+
+// 與用戶的好友關係
+const (
+	FriendStatusNone            = "none"
+	FriendStatusFriends         = "friends"
+	FriendStatusPendingOutgoing = "pending_outgoing"
+	FriendStatusPendingIncoming = "pending_incoming"
+	FriendStatusBlocked         = "blocked"
+)
+
+const (
+	blockCacheTTL  = 30 * time.Second // 封鎖名單快取時間，其他實例的封鎖變更最多延遲此時間生效
+	blockCacheSize = 10000            // 快取筆數超過時清除過期項目
+)
+
+var (
+	// ErrFriendRequestNotFound 好友邀請或好友關係不存在
+	ErrFriendRequestNotFound = errors.New("好友邀請不存在")
+	// ErrUserBlocked 雙方有封鎖關係，不透露是哪一方封鎖
+	ErrUserBlocked = errors.New("無法與此用戶互動")
+)
+
+// FriendshipStatusFor 依雙方之間的記錄計算從 userID 看到的好友關係，對方封鎖自己時不透露
+func FriendshipStatusFor(userID uint, friendships []models.Friendship) string {
+	status := FriendStatusNone
+	for _, friendship := range friendships {
+		switch friendship.Status {
+		case models.FriendshipStatusBlocked:
+			if friendship.UserID == userID {
+				return FriendStatusBlocked
+			}
+		case models.FriendshipStatusAccepted:
+			status = FriendStatusFriends
+		case models.FriendshipStatusPending:
+			if friendship.UserID == userID {
+				status = FriendStatusPendingOutgoing
+			} else {
+				status = FriendStatusPendingIncoming
+			}
+		}
+	}
+	return status
+}
+
+// blockCacheEntry 用戶的封鎖關係快取
+type blockCacheEntry struct {
+	userIDs   map[uint]bool
+	expiresAt time.Time
+}
+
+// FriendService 好友服務
+type FriendService struct {
+	Conf      *config.Config
+	Repo      *postgresqlRepo.PostgreSQLRepo
+	RepoSlave *postgresqlRepo.PostgreSQLRepo
+
+	notificationService *NotificationService // 好友邀請與接受通知
+	presenceService     *PresenceService     // 好友上線狀態
+
+	blockMu    sync.Mutex
+	blockCache map[uint]blockCacheEntry
+}
+
+// NewFriendService 創建好友服務
+func NewFriendService(conf *config.Config, notificationService *NotificationService, presenceService *PresenceService) *FriendService {
+	return &FriendService{
+		Conf:                conf,
+		Repo:                postgresqlRepo.NewPostgreSQLRepo(conf.DB["master"]),
+		RepoSlave:           postgresqlRepo.NewPostgreSQLRepo(conf.DB["slave"]),
+		notificationService: notificationService,
+		presenceService:     presenceService,
+		blockCache:          make(map[uint]blockCacheEntry),
+	}
+}
+
+// GetStatus 獲取與用戶的好友關係
+func (s *FriendService) GetStatus(userID, targetID uint) (*dto.FriendStatusDTO, error) {
+	friendships, err := s.RepoSlave.FindFriendshipsBetween(userID, targetID)
+	if err != nil {
+		return nil, fmt.Errorf("獲取好友關係失敗: %v", err)
+	}
+	return &dto.FriendStatusDTO{UserID: targetID, Status: FriendshipStatusFor(userID, friendships)}, nil
+}
+
+// SendRequest 發送好友邀請，對方已邀請自己時直接成為好友
+func (s *FriendService) SendRequest(userID, targetID uint) (*dto.FriendStatusDTO, error) {
+	if userID == targetID {
+		return nil, fmt.Errorf("不能加自己為好友")
+	}
+	if _, err := findExistingUser(s.Repo, targetID); err != nil {
+		return nil, fmt.Errorf("找不到用戶: %v", err)
+	}
+
+	friendships, err := s.Repo.FindFriendshipsBetween(userID, targetID)
+	if err != nil {
+		return nil, fmt.Errorf("獲取好友關係失敗: %v", err)
+	}
+	for _, friendship := range friendships {
+		if friendship.Status == models.FriendshipStatusBlocked {
+			return nil, ErrUserBlocked
+		}
+	}
+
+	switch FriendshipStatusFor(userID, friendships) {
+	case FriendStatusFriends, FriendStatusPendingOutgoing:
+		return s.GetStatus(userID, targetID)
+	case FriendStatusPendingIncoming:
+		return s.Accept(userID, targetID)
+	}
+
+	if err := s.Repo.CreateFriendship(&models.Friendship{
+		UserID:   userID,
+		FriendID: targetID,
+		Status:   models.FriendshipStatusPending,
+	}); err != nil {
+		return nil, fmt.Errorf("發送好友邀請失敗: %v", err)
+	}
+	go s.notify(targetID, userID, models.NotificationFriendRequest, "好友邀請", "%s 想加你為好友")
+
+	return &dto.FriendStatusDTO{UserID: targetID, Status: FriendStatusPendingOutgoing}, nil
+}
+
+// Accept 接受好友邀請
+func (s *FriendService) Accept(userID, requesterID uint) (*dto.FriendStatusDTO, error) {
+	accepted, err := s.Repo.AcceptFriendRequest(requesterID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("接受好友邀請失敗: %v", err)
+	}
+	if !accepted {
+		return nil, ErrFriendRequestNotFound
+	}
+	// 雙方同時送出邀請時移除自己送出的那筆
+	if _, err := s.Repo.DeleteFriendship(userID, requesterID, models.FriendshipStatusPending); err != nil {
+		utils.LogError("清除用戶 %d 對 %d 的好友邀請失敗: %v", userID, requesterID, err)
+	}
+	go s.notify(requesterID, userID, models.NotificationFriendAccepted, "好友邀請已接受", "%s 接受了你的好友邀請")
+
+	return &dto.FriendStatusDTO{UserID: requesterID, Status: FriendStatusFriends}, nil
+}
+
+// Decline 拒絕好友邀請
+func (s *FriendService) Decline(userID, requesterID uint) error {
+	deleted, err := s.Repo.DeleteFriendship(requesterID, userID, models.FriendshipStatusPending)
+	if err != nil {
+		return fmt.Errorf("拒絕好友邀請失敗: %v", err)
+	}
+	if !deleted {
+		return ErrFriendRequestNotFound
+	}
+	return nil
+}
+
+// Remove 解除好友，尚未成為好友時取消自己送出的邀請
+func (s *FriendService) Remove(userID, friendID uint) error {
+	removed, err := s.Repo.DeleteAcceptedFriendship(userID, friendID)
+	if err != nil {
+		return fmt.Errorf("解除好友失敗: %v", err)
+	}
+	if removed {
+		return nil
+	}
+
+	cancelled, err := s.Repo.DeleteFriendship(userID, friendID, models.FriendshipStatusPending)
+	if err != nil {
+		return fmt.Errorf("取消好友邀請失敗: %v", err)
+	}
+	if !cancelled {
+		return ErrFriendRequestNotFound
+	}
+	return nil
+}
+
+// Block 封鎖用戶並解除好友與邀請
+func (s *FriendService) Block(userID, targetID uint) error {
+	if userID == targetID {
+		return fmt.Errorf("不能封鎖自己")
+	}
+	if _, err := findExistingUser(s.Repo, targetID); err != nil {
+		return fmt.Errorf("找不到用戶: %v", err)
+	}
+
+	if err := s.Repo.BlockUser(userID, targetID); err != nil {
+		return fmt.Errorf("封鎖失敗: %v", err)
+	}
+	s.invalidateBlocks(userID, targetID)
+	return nil
+}
+
+// Unblock 解除封鎖（未封鎖時視為成功）
+func (s *FriendService) Unblock(userID, targetID uint) error {
+	if _, err := s.Repo.DeleteFriendship(userID, targetID, models.FriendshipStatusBlocked); err != nil {
+		return fmt.Errorf("解除封鎖失敗: %v", err)
+	}
+	s.invalidateBlocks(userID, targetID)
+	return nil
+}
+
+// ListFriends 分頁列出好友與上線狀態
+func (s *FriendService) ListFriends(userID uint, offset, limit int) ([]*dto.FriendDTO, int64, error) {
+	offset, limit = normalizeFollowPage(offset, limit)
+	friendships, total, err := s.RepoSlave.FindFriends(userID, offset, limit)
+	if err != nil {
+		return nil, 0, fmt.Errorf("獲取好友失敗: %v", err)
+	}
+
+	friends := make([]*dto.FriendDTO, len(friendships))
+	ids := make([]uint, len(friendships))
+	for i := range friendships {
+		friendship := &friendships[i]
+		friend := &dto.FriendDTO{ID: friendship.FriendID, FriendedAt: friendship.UpdatedAt}
+		user := friendship.Friend
+		if friendship.FriendID == userID {
+			friend.ID = friendship.UserID
+			user = friendship.User
+		}
+		if user != nil {
+			friend.Username = user.Username
+			friend.Avatar = user.Avatar
+		}
+		friends[i] = friend
+		ids[i] = friend.ID
+	}
+
+	if s.presenceService != nil {
+		presence := s.presenceService.Lookup(ids)
+		for _, friend := range friends {
+			friend.Online = presence[friend.ID].Online
+			friend.LastSeenAt = presence[friend.ID].LastSeenAt
+		}
+	}
+	return friends, total, nil
+}
+
+// ListRequests 分頁列出收到（incoming）或送出（outgoing）的好友邀請
+func (s *FriendService) ListRequests(userID uint, direction string, offset, limit int) ([]*dto.FriendRequestDTO, int64, error) {
+	if direction == "" {
+		direction = "incoming"
+	}
+	if direction != "incoming" && direction != "outgoing" {
+		return nil, 0, fmt.Errorf("無效的邀請方向: %s", direction)
+	}
+
+	offset, limit = normalizeFollowPage(offset, limit)
+	friendships, total, err := s.RepoSlave.FindFriendRequests(userID, direction == "incoming", offset, limit)
+	if err != nil {
+		return nil, 0, fmt.Errorf("獲取好友邀請失敗: %v", err)
+	}
+
+	requests := make([]*dto.FriendRequestDTO, len(friendships))
+	for i := range friendships {
+		friendship := &friendships[i]
+		request := &dto.FriendRequestDTO{ID: friendship.FriendID, Direction: direction, RequestedAt: friendship.CreatedAt}
+		user := friendship.Friend
+		if direction == "incoming" {
+			request.ID = friendship.UserID
+			user = friendship.User
+		}
+		if user != nil {
+			request.Username = user.Username
+			request.Avatar = user.Avatar
+		}
+		requests[i] = request
+	}
+	return requests, total, nil
+}
+
+// ListBlocked 分頁列出已封鎖的用戶
+func (s *FriendService) ListBlocked(userID uint, offset, limit int) ([]*dto.BlockedUserDTO, int64, error) {
+	offset, limit = normalizeFollowPage(offset, limit)
+	friendships, total, err := s.RepoSlave.FindBlockedUsers(userID, offset, limit)
+	if err != nil {
+		return nil, 0, fmt.Errorf("獲取封鎖名單失敗: %v", err)
+	}
+
+	users := make([]*dto.BlockedUserDTO, len(friendships))
+	for i := range friendships {
+		user := &dto.BlockedUserDTO{ID: friendships[i].FriendID, BlockedAt: friendships[i].CreatedAt}
+		if friendships[i].Friend != nil {
+			user.Username = friendships[i].Friend.Username
+			user.Avatar = friendships[i].Friend.Avatar
+		}
+		users[i] = user
+	}
+	return users, total, nil
+}
+
+// IsBlocked 檢查兩個用戶之間是否有任一方封鎖，查詢主庫以立即反映封鎖變更
+func (s *FriendService) IsBlocked(userID, otherID uint) (bool, error) {
+	if userID == 0 || otherID == 0 || userID == otherID {
+		return false, nil
+	}
+	return s.Repo.IsBlockedBetween(userID, otherID)
+}
+
+// BlockedWith 與用戶有封鎖關係的用戶ID（快取），供聊天訊息與留言列表過濾
+func (s *FriendService) BlockedWith(userID uint) map[uint]bool {
+	if userID == 0 {
+		return nil
+	}
+
+	s.blockMu.Lock()
+	entry, ok := s.blockCache[userID]
+	s.blockMu.Unlock()
+	if ok && time.Now().Before(entry.expiresAt) {
+		return entry.userIDs
+	}
+
+	ids, err := s.RepoSlave.FindBlockRelatedUserIDs(userID)
+	if err != nil {
+		utils.LogError("獲取用戶 %d 封鎖關係失敗: %v", userID, err)
+		if ok {
+			return entry.userIDs
+		}
+		return nil
+	}
+
+	blocked := make(map[uint]bool, len(ids))
+	for _, id := range ids {
+		blocked[id] = true
+	}
+
+	now := time.Now()
+	s.blockMu.Lock()
+	if len(s.blockCache) >= blockCacheSize {
+		for id, cached := range s.blockCache {
+			if now.After(cached.expiresAt) {
+				delete(s.blockCache, id)
+			}
+		}
+	}
+	s.blockCache[userID] = blockCacheEntry{userIDs: blocked, expiresAt: now.Add(blockCacheTTL)}
+	s.blockMu.Unlock()
+	return blocked
+}
+
+// invalidateBlocks 清除雙方的封鎖名單快取
+func (s *FriendService) invalidateBlocks(userIDs ...uint) {
+	s.blockMu.Lock()
+	defer s.blockMu.Unlock()
+	for _, userID := range userIDs {
+		delete(s.blockCache, userID)
+	}
+}
+
+// notify 發送好友相關通知，format 中的 %s 代入對方名稱
+func (s *FriendService) notify(userID, actorID uint, notificationType, title, format string) {
+	if s.notificationService == nil {
+		return
+	}
+
+	actorName := fmt.Sprintf("用戶 %d", actorID)
+	if actor, err := findExistingUser(s.Repo, actorID); err == nil {
+		actorName = actor.Username
+	}
+
+	data := map[string]interface{}{"user_id": actorID}
+	if err := s.notificationService.Notify(userID, notificationType, title, fmt.Sprintf(format, actorName), data); err != nil {
+		utils.LogError("發送好友通知給用戶 %d 失敗: %v", userID, err)
+	}
+}
@@ -21,15 +21,15 @@ type VideoServiceInterface interface {
 	CreateVideoRecord(userID uint, title, description, s3Key string) (*dto.VideoDTO, error)
 	ConfirmUploadOnly(videoID uint, s3Key string) error
 	ConfirmUploadAndStartProcessingWithKey(videoID uint, s3Key string) error
-	GetVideos(offset, limit int) ([]*dto.VideoDTO, int64, error)
-	GetVideoByID(videoID uint) (*dto.VideoDTO, error)
-	GetVideosByUserID(userID uint) ([]*dto.VideoDTO, int64, error)
+	GetVideos(viewerID uint, offset, limit int) ([]*dto.VideoDTO, int64, error)
+	GetVideoByID(viewerID, videoID uint) (*dto.VideoDTO, error)
+	GetVideosByUserID(viewerID, userID uint) ([]*dto.VideoDTO, int64, error)
 	UpdateVideo(id uint, title string, description string, videoData *dto.VideoDTO) error
 	DeleteVideo(id uint) error
-	SearchVideos(query string, offset, limit int) ([]*dto.VideoDTO, int64, error)
-	LikeVideo(id uint) error
+	SearchVideos(viewerID uint, req *dto.VideoSearchDTO) (*dto.VideoSearchResultDTO, error)
+	LikeVideo(userID, id uint) error
+	UnlikeVideo(userID, id uint) error
 	IncrementViews(id uint) error
-	IncrementLikes(id uint) error
 }
 
 // LiveServiceInterface 直播服務接口
@@ -351,3 +351,22 @@ func (s *LiveService) GetActiveLives() ([]*dto.LiveDTO, error) {
 
 	return liveDTOs, nil
 }
+
+// CancelNoShowLives 取消超過預定開始時間仍未開播的預約直播
+func (s *LiveService) CancelNoShowLives(before time.Time) (int, error) {
+	lives, err := s.Repo.FindScheduledLivesBefore(before)
+	if err != nil {
+		return 0, err
+	}
+
+	cancelled := 0
+	for _, live := range lives {
+		if err := s.Repo.UpdateLiveStatus(live.ID, "cancelled"); err != nil {
+			log.Printf("取消預約直播 %d 失敗: %v", live.ID, err)
+			continue
+		}
+		cancelled++
+	}
+
+	return cancelled, nil
+}
@@ -0,0 +1,405 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"stream-demo/backend/database/models"
+	"stream-demo/backend/utils"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// scheduledRoomsKey 預約直播排程（score 為預約開播時間）
+const scheduledRoomsKey = "live:scheduled_rooms"
+
+// userScheduledRoomsKey 用戶建立的預約直播間，用於限制每位用戶的預約數量
+func userScheduledRoomsKey(userID int) string {
+	return fmt.Sprintf("user:%d:scheduled_rooms", userID)
+}
+
+// 預約直播排程動作
+const (
+	ScheduleActionNone   = ""
+	ScheduleActionOpen   = "open"
+	ScheduleActionCancel = "cancel"
+)
+
+// transitionRoomStatusScript 只在房間狀態仍為允許的來源狀態（ARGV[3:]）時更新，
+// 多個實例同時處理排程時只有一個能完成轉換，避免預約提醒重複發送
+var transitionRoomStatusScript = redis.NewScript(`
+local status = redis.call("HGET", KEYS[1], "status")
+for i = 3, #ARGV do
+	if status == ARGV[i] then
+		redis.call("HSET", KEYS[1], "status", ARGV[1], "updated_at", ARGV[2])
+		return 1
+	end
+end
+return 0`)
+
+// ScheduledRoomAction 根據房間狀態與時間決定排程器應執行的動作
+// scheduled 狀態在開播前 openBefore 開放進入等待；超過預定時間 grace 仍未開播則取消
+func ScheduledRoomAction(status string, scheduledAt, now time.Time, openBefore, grace time.Duration) string {
+	if scheduledAt.IsZero() {
+		return ScheduleActionNone
+	}
+
+	switch status {
+	case "scheduled", "waiting":
+		if now.After(scheduledAt.Add(grace)) {
+			return ScheduleActionCancel
+		}
+		if status == "scheduled" && !now.Before(scheduledAt.Add(-openBefore)) {
+			return ScheduleActionOpen
+		}
+	}
+	return ScheduleActionNone
+}
+
+// ScheduleRoom 創建預約直播間
+func (s *LiveRoomService) ScheduleRoom(userID int, title, description string, scheduledAt time.Time) (*LiveRoomInfo, error) {
+	ctx := context.Background()
+
+	if !scheduledAt.After(time.Now()) {
+		return nil, fmt.Errorf("預約開播時間必須晚於現在")
+	}
+
+	// 檢查用戶是否已經有活躍的直播間
+	if s.hasActiveRoom(ctx, userID) {
+		return nil, fmt.Errorf("用戶已有活躍的直播間，請先結束現有直播間")
+	}
+
+	// 預約直播間不佔用當前房間，另外限制每位用戶的預約數量
+	count, err := s.countScheduledRooms(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if maxRooms := s.conf.Live.Schedule.MaxPerUser; maxRooms > 0 && count >= maxRooms {
+		return nil, fmt.Errorf("預約直播間最多 %d 個，請先取消或完成現有預約", maxRooms)
+	}
+
+	roomID := fmt.Sprintf("room_%s", uuid.New().String()[:8])
+	streamKey := fmt.Sprintf("stream_%s", uuid.New().String()[:12])
+
+	now := time.Now()
+	roomInfo := &LiveRoomInfo{
+		ID:          roomID,
+		Title:       title,
+		Description: description,
+		CreatorID:   userID,
+		Status:      "scheduled",
+		StreamKey:   streamKey,
+		ViewerCount: 0,
+		MaxViewers:  1000,
+		ScheduledAt: scheduledAt,
+		StartsIn:    int64(time.Until(scheduledAt).Seconds()),
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+
+	// 保存到 Redis
+	if err := s.saveRoomToRedis(ctx, roomInfo); err != nil {
+		return nil, fmt.Errorf("save room to redis failed: %v", err)
+	}
+
+	// 設置創建者角色（開放前不加入房間用戶列表）
+	if err := utils.GetRedisClient().HSet(ctx, fmt.Sprintf("live:room:%s:roles", roomID), userID, "creator").Err(); err != nil {
+		return nil, fmt.Errorf("set creator role failed: %v", err)
+	}
+
+	// 加入預約排程
+	if err := utils.GetRedisClient().ZAdd(ctx, scheduledRoomsKey, redis.Z{
+		Score:  float64(scheduledAt.Unix()),
+		Member: roomID,
+	}).Err(); err != nil {
+		return nil, fmt.Errorf("add to scheduled rooms failed: %v", err)
+	}
+	if err := utils.GetRedisClient().SAdd(ctx, userScheduledRoomsKey(userID), roomID).Err(); err != nil {
+		return nil, fmt.Errorf("add to user scheduled rooms failed: %v", err)
+	}
+
+	// 保存到 PostgreSQL (異步)
+	go s.saveRoomToDatabase(roomInfo)
+
+	utils.LogInfo("預約直播間創建成功: %s, 用戶: %d, 預定時間: %s", roomID, userID, scheduledAt.Format(time.RFC3339))
+	return roomInfo, nil
+}
+
+// GetScheduledRooms 獲取即將開播的預約直播間
+func (s *LiveRoomService) GetScheduledRooms(limit int) ([]*LiveRoomInfo, error) {
+	ctx := context.Background()
+
+	if limit <= 0 {
+		limit = 20
+	}
+
+	roomIDs, err := utils.GetRedisClient().ZRange(ctx, scheduledRoomsKey, 0, int64(limit-1)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("get scheduled room ids failed: %v", err)
+	}
+
+	var rooms []*LiveRoomInfo
+	for _, roomID := range roomIDs {
+		room, err := s.GetRoomByID(roomID)
+		if err != nil {
+			utils.LogError("獲取預約房間信息失敗: %s, %v", roomID, err)
+			continue
+		}
+		rooms = append(rooms, room)
+	}
+
+	return rooms, nil
+}
+
+// RSVPRoom 預約直播提醒
+func (s *LiveRoomService) RSVPRoom(roomID string, userID int) error {
+	ctx := context.Background()
+
+	status, err := utils.GetRedisClient().HGet(ctx, fmt.Sprintf("live:room:%s", roomID), "status").Result()
+	if err != nil {
+		return fmt.Errorf("room not found: %s", roomID)
+	}
+
+	if status != "scheduled" && status != "waiting" {
+		return fmt.Errorf("只能預約尚未開播的直播")
+	}
+
+	return utils.GetRedisClient().SAdd(ctx, fmt.Sprintf("live:room:%s:rsvps", roomID), userID).Err()
+}
+
+// CancelRSVP 取消預約直播提醒
+func (s *LiveRoomService) CancelRSVP(roomID string, userID int) error {
+	ctx := context.Background()
+	return utils.GetRedisClient().SRem(ctx, fmt.Sprintf("live:room:%s:rsvps", roomID), userID).Err()
+}
+
+// HasRSVP 檢查用戶是否已預約提醒
+func (s *LiveRoomService) HasRSVP(roomID string, userID int) (bool, error) {
+	ctx := context.Background()
+	return utils.GetRedisClient().SIsMember(ctx, fmt.Sprintf("live:room:%s:rsvps", roomID), userID).Result()
+}
+
+// OpenScheduledRoom 開放預約直播間進入等待狀態
+func (s *LiveRoomService) OpenScheduledRoom(roomID string) error {
+	ctx := context.Background()
+
+	room, err := s.GetRoomByID(roomID)
+	if err != nil {
+		return err
+	}
+	if room.Status != "scheduled" {
+		return fmt.Errorf("cannot open room from status: %s", room.Status)
+	}
+
+	// 創建者正在其他直播間時保持預約狀態，不覆蓋其當前房間
+	if s.hasActiveRoom(ctx, room.CreatorID) {
+		return fmt.Errorf("創建者 %d 已有活躍的直播間", room.CreatorID)
+	}
+
+	if err := s.transitionRoomStatus(ctx, roomID, "waiting", "scheduled"); err != nil {
+		return err
+	}
+
+	// 創建者進入房間並加入活躍房間列表
+	if err := s.addUserToRoom(ctx, roomID, room.CreatorID, "creator"); err != nil {
+		utils.LogError("添加創建者到房間失敗: %v", err)
+	}
+	if err := s.setUserCurrentRoom(ctx, room.CreatorID, roomID); err != nil {
+		utils.LogError("設置創建者當前房間失敗: %v", err)
+	}
+	if err := s.addToActiveRooms(ctx, roomID); err != nil {
+		utils.LogError("加入活躍房間列表失敗: %v", err)
+	}
+
+	s.broadcastRoomUpdate(roomID, "room_waiting", map[string]interface{}{
+		"message":      "直播間已開放，等待開播",
+		"room_id":      roomID,
+		"status":       "waiting",
+		"scheduled_at": room.ScheduledAt,
+		"starts_in":    int64(time.Until(room.ScheduledAt).Seconds()),
+	})
+
+	minutes := int(time.Until(room.ScheduledAt).Minutes())
+	s.notifyRSVPs(roomID, models.NotificationLiveRoomReminder, "直播即將開始",
+		fmt.Sprintf("你預約的直播「%s」將在 %d 分鐘後開始", room.Title, minutes))
+
+	go s.syncRoomToDatabase(roomID)
+
+	utils.LogInfo("預約直播間 %s 已開放等待", roomID)
+	return nil
+}
+
+// CancelScheduledRoom 取消未準時開播的預約直播間
+func (s *LiveRoomService) CancelScheduledRoom(roomID string) error {
+	ctx := context.Background()
+
+	room, err := s.GetRoomByID(roomID)
+	if err != nil {
+		return err
+	}
+	if room.Status != "scheduled" && room.Status != "waiting" {
+		return fmt.Errorf("cannot cancel room from status: %s", room.Status)
+	}
+
+	if err := s.transitionRoomStatus(ctx, roomID, "cancelled", "scheduled", "waiting"); err != nil {
+		return err
+	}
+
+	pipe := utils.GetRedisClient().Pipeline()
+	pipe.ZRem(ctx, scheduledRoomsKey, roomID)
+	pipe.ZRem(ctx, "live:active_rooms", roomID)
+	if _, err := pipe.Exec(ctx); err != nil {
+		utils.LogError("移除取消的預約直播間失敗: %v", err)
+	}
+
+	// 釋放創建者的當前房間，避免無法再開新直播間
+	currentRoom, err := utils.GetRedisClient().Get(ctx, fmt.Sprintf("user:%d:current_room", room.CreatorID)).Result()
+	if err == nil && currentRoom == roomID {
+		utils.GetRedisClient().Del(ctx, fmt.Sprintf("user:%d:current_room", room.CreatorID))
+	}
+
+	s.broadcastRoomUpdate(roomID, "room_cancelled", map[string]interface{}{
+		"message": "主播未在預定時間開播，直播已取消",
+		"room_id": roomID,
+		"status":  "cancelled",
+	})
+
+	s.notifyRSVPs(roomID, models.NotificationLiveRoomCancelled, "直播已取消",
+		fmt.Sprintf("你預約的直播「%s」已取消", room.Title))
+
+	go s.syncRoomToDatabase(roomID)
+
+	utils.LogInfo("預約直播間 %s 未準時開播，已自動取消", roomID)
+	return nil
+}
+
+// ProcessScheduledRooms 處理到期的預約直播間，返回開放與取消的數量
+func (s *LiveRoomService) ProcessScheduledRooms(now time.Time) (opened, cancelled int) {
+	ctx := context.Background()
+
+	openBefore := time.Duration(s.conf.Live.Schedule.OpenBeforeMinutes) * time.Minute
+	grace := time.Duration(s.conf.Live.Schedule.GracePeriodMinutes) * time.Minute
+
+	// 只需檢查即將開放或已過預定時間的房間
+	roomIDs, err := utils.GetRedisClient().ZRangeByScore(ctx, scheduledRoomsKey, &redis.ZRangeBy{
+		Min: "-inf",
+		Max: strconv.FormatInt(now.Add(openBefore).Unix(), 10),
+	}).Result()
+	if err != nil {
+		utils.LogError("獲取預約直播排程失敗: %v", err)
+		return 0, 0
+	}
+
+	for _, roomID := range roomIDs {
+		room, err := s.GetRoomByID(roomID)
+		if err != nil {
+			// 房間已被刪除，清除排程
+			utils.GetRedisClient().ZRem(ctx, scheduledRoomsKey, roomID)
+			continue
+		}
+
+		switch ScheduledRoomAction(room.Status, room.ScheduledAt, now, openBefore, grace) {
+		case ScheduleActionOpen:
+			if err := s.OpenScheduledRoom(roomID); err != nil {
+				utils.LogError("開放預約直播間 %s 失敗: %v", roomID, err)
+				continue
+			}
+			opened++
+		case ScheduleActionCancel:
+			if err := s.CancelScheduledRoom(roomID); err != nil {
+				utils.LogError("取消預約直播間 %s 失敗: %v", roomID, err)
+				continue
+			}
+			cancelled++
+		default:
+			if room.Status != "scheduled" && room.Status != "waiting" {
+				utils.GetRedisClient().ZRem(ctx, scheduledRoomsKey, roomID)
+			}
+		}
+	}
+
+	return opened, cancelled
+}
+
+// transitionRoomStatus 原子地將房間狀態由 from 其中之一轉為 to，狀態已被其他請求或實例變更時回傳錯誤
+func (s *LiveRoomService) transitionRoomStatus(ctx context.Context, roomID, to string, from ...string) error {
+	args := []interface{}{to, time.Now().Format(time.RFC3339)}
+	for _, status := range from {
+		args = append(args, status)
+	}
+
+	changed, err := transitionRoomStatusScript.Run(ctx, utils.GetRedisClient(), []string{fmt.Sprintf("live:room:%s", roomID)}, args...).Int()
+	if err != nil {
+		return fmt.Errorf("update room status failed: %v", err)
+	}
+	if changed == 0 {
+		return fmt.Errorf("room %s is no longer in status %v", roomID, from)
+	}
+	return nil
+}
+
+// countScheduledRooms 計算用戶尚未開播的預約直播間數量，同時清除已開播、取消或刪除的記錄
+func (s *LiveRoomService) countScheduledRooms(ctx context.Context, userID int) (int, error) {
+	key := userScheduledRoomsKey(userID)
+	roomIDs, err := utils.GetRedisClient().SMembers(ctx, key).Result()
+	if err != nil {
+		return 0, fmt.Errorf("get user scheduled rooms failed: %v", err)
+	}
+
+	count := 0
+	for _, roomID := range roomIDs {
+		status, err := utils.GetRedisClient().HGet(ctx, fmt.Sprintf("live:room:%s", roomID), "status").Result()
+		if err != nil && err != redis.Nil {
+			return 0, fmt.Errorf("get room status failed: %v", err)
+		}
+		if status == "scheduled" || status == "waiting" {
+			count++
+			continue
+		}
+		utils.GetRedisClient().SRem(ctx, key, roomID)
+	}
+	return count, nil
+}
+
+// notifyRSVPs 通知所有預約提醒的用戶
+func (s *LiveRoomService) notifyRSVPs(roomID, notificationType, title, content string) {
+	if s.notificationService == nil {
+		return
+	}
+
+	ctx := context.Background()
+	members, err := utils.GetRedisClient().SMembers(ctx, fmt.Sprintf("live:room:%s:rsvps", roomID)).Result()
+	if err != nil {
+		utils.LogError("獲取預約用戶失敗: %v", err)
+		return
+	}
+
+	userIDs := make([]uint, 0, len(members))
+	for _, member := range members {
+		userID, err := strconv.Atoi(member)
+		if err != nil {
+			continue
+		}
+		userIDs = append(userIDs, uint(userID))
+	}
+
+	data := map[string]interface{}{"room_id": roomID}
+	if err := s.notificationService.NotifyMany(userIDs, notificationType, title, content, data); err != nil {
+		utils.LogError("發送直播間 %s 預約提醒失敗: %v", roomID, err)
+	}
+}
+
+// broadcastRoomUpdate 通過 WebSocket 廣播房間更新
+func (s *LiveRoomService) broadcastRoomUpdate(roomID, updateType string, data interface{}) {
+	if s.wsHandler == nil {
+		return
+	}
+	if handler, ok := s.wsHandler.(interface {
+		BroadcastRoomUpdate(roomID string, updateType string, data interface{})
+	}); ok {
+		handler.BroadcastRoomUpdate(roomID, updateType, data)
+	}
+}
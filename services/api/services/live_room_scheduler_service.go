@@ -0,0 +1,77 @@
+package services
+
+import (
+	"time"
+
+	"stream-demo/backend/utils"
+)
+
+// LiveRoomSchedulerService 預約直播排程服務
+type LiveRoomSchedulerService struct {
+	liveRoomService *LiveRoomService
+	liveService     *LiveService
+	stopChan        chan bool
+	ticker          *time.Ticker
+}
+
+// NewLiveRoomSchedulerService 創建預約直播排程服務
+func NewLiveRoomSchedulerService(liveRoomService *LiveRoomService, liveService *LiveService) *LiveRoomSchedulerService {
+	return &LiveRoomSchedulerService{
+		liveRoomService: liveRoomService,
+		liveService:     liveService,
+		stopChan:        make(chan bool),
+	}
+}
+
+// Start 啟動排程服務
+func (s *LiveRoomSchedulerService) Start() {
+	interval := time.Duration(s.liveRoomService.conf.Live.Schedule.CheckInterval) * time.Second
+	if interval <= 0 {
+		interval = 30 * time.Second
+	}
+	s.ticker = time.NewTicker(interval)
+
+	go func() {
+		for {
+			select {
+			case <-s.ticker.C:
+				s.processSchedules()
+			case <-s.stopChan:
+				s.ticker.Stop()
+				return
+			}
+		}
+	}()
+
+	utils.LogInfo("預約直播排程服務已啟動")
+}
+
+// Stop 停止排程服務
+func (s *LiveRoomSchedulerService) Stop() {
+	if s.ticker != nil {
+		s.ticker.Stop()
+	}
+	close(s.stopChan)
+	utils.LogInfo("預約直播排程服務已停止")
+}
+
+// processSchedules 開放即將開播的直播間並取消未準時開播的直播
+func (s *LiveRoomSchedulerService) processSchedules() {
+	now := time.Now()
+
+	opened, cancelled := s.liveRoomService.ProcessScheduledRooms(now)
+	if opened > 0 || cancelled > 0 {
+		utils.LogInfo("預約直播排程：開放 %d 個，取消 %d 個", opened, cancelled)
+	}
+
+	// 舊版直播（models.Live）的預約未開播處理
+	if s.liveService != nil {
+		grace := time.Duration(s.liveRoomService.conf.Live.Schedule.GracePeriodMinutes) * time.Minute
+		count, err := s.liveService.CancelNoShowLives(now.Add(-grace))
+		if err != nil {
+			utils.LogError("取消未開播的預約直播失敗: %v", err)
+		} else if count > 0 {
+			utils.LogInfo("已取消 %d 個未開播的預約直播", count)
+		}
+	}
+}
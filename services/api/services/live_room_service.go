@@ -21,6 +21,11 @@ type LiveRoomService struct {
 	conf      *config.Config
 	db        *gorm.DB
 	wsHandler interface{} // WebSocket 處理器接口
+
+	restreamService     *RestreamService      // 多平台轉推服務
+	notificationService *NotificationService  // 預約提醒通知
+	feedService         *FeedService          // 開播時擴散到追隨者動態並通知追隨者
+	messaging           *utils.RedisMessaging // 發布開播與結束直播事件
 }
 
 // LiveRoomInfo 直播間信息
@@ -34,6 +39,9 @@ type LiveRoomInfo struct {
 	ViewerCount int       `json:"viewer_count"`
 	MaxViewers  int       `json:"max_viewers"`
 	StartedAt   time.Time `json:"started_at"`
+	ScheduledAt time.Time `json:"scheduled_at"` // 預約開播時間，非預約直播為零值
+	StartsIn    int64     `json:"starts_in"`    // 距離預約開播的秒數（倒數計時）
+	RSVPCount   int64     `json:"rsvp_count"`   // 預約提醒人數
 	CreatedAt   time.Time `json:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at"`
 }
@@ -61,18 +69,33 @@ func (s *LiveRoomService) SetWSHandler(handler interface{}) {
 	s.wsHandler = handler
 }
 
+// SetNotificationService 設置站內通知服務
+func (s *LiveRoomService) SetNotificationService(notificationService *NotificationService) {
+	s.notificationService = notificationService
+}
+
+// SetMessaging 設置訊息服務
+func (s *LiveRoomService) SetMessaging(messaging *utils.RedisMessaging) {
+	s.messaging = messaging
+}
+
+// SetRestreamService 設置多平台轉推服務
+func (s *LiveRoomService) SetRestreamService(restreamService *RestreamService) {
+	s.restreamService = restreamService
+}
+
+// SetFeedService 設置追蹤動態服務
+func (s *LiveRoomService) SetFeedService(feedService *FeedService) {
+	s.feedService = feedService
+}
+
 // CreateRoom 創建直播間
 func (s *LiveRoomService) CreateRoom(userID int, title, description string) (*LiveRoomInfo, error) {
 	ctx := context.Background()
 
 	// 檢查用戶是否已經有活躍的直播間
-	existingRoomID, err := utils.GetRedisClient().Get(ctx, fmt.Sprintf("user:%d:current_room", userID)).Result()
-	if err == nil && existingRoomID != "" {
-		// 檢查現有房間是否還活躍
-		roomStatus, err := utils.GetRedisClient().HGet(ctx, fmt.Sprintf("live:room:%s", existingRoomID), "status").Result()
-		if err == nil && (roomStatus == "created" || roomStatus == "live") {
-			return nil, fmt.Errorf("用戶已有活躍的直播間，請先結束現有直播間")
-		}
+	if s.hasActiveRoom(ctx, userID) {
+		return nil, fmt.Errorf("用戶已有活躍的直播間，請先結束現有直播間")
 	}
 
 	// 生成唯一房間ID和推流密鑰
@@ -212,6 +235,13 @@ func (s *LiveRoomService) GetRoomByID(roomID string) (*LiveRoomInfo, error) {
 		return nil, fmt.Errorf("parse room data failed: %v", err)
 	}
 
+	// 預約直播附帶預約人數
+	if !room.ScheduledAt.IsZero() {
+		if count, err := utils.GetRedisClient().SCard(ctx, fmt.Sprintf("live:room:%s:rsvps", roomID)).Result(); err == nil {
+			room.RSVPCount = count
+		}
+	}
+
 	return room, nil
 }
 
@@ -235,7 +265,7 @@ func (s *LiveRoomService) JoinRoom(roomID string, userID int) error {
 		return fmt.Errorf("get room status failed: %v", err)
 	}
 
-	if status == "cancelled" {
+	if status == "cancelled" || status == "scheduled" {
 		return fmt.Errorf("room is not active: %s", status)
 	}
 
@@ -334,20 +364,28 @@ func (s *LiveRoomService) StartLive(roomID string, userID int) error {
 		return fmt.Errorf("user is not room creator")
 	}
 
-	// 檢查房間狀態，允許從 created 或 ended 狀態開始直播
+	// 檢查房間狀態，允許從 created、ended 或預約的 scheduled、waiting 狀態開始直播
 	status, err := utils.GetRedisClient().HGet(ctx, fmt.Sprintf("live:room:%s", roomID), "status").Result()
 	if err != nil {
 		return fmt.Errorf("get room status failed: %v", err)
 	}
 
-	if status != "created" && status != "ended" {
+	if status != "created" && status != "ended" && status != "scheduled" && status != "waiting" {
 		return fmt.Errorf("cannot start live from status: %s", status)
 	}
 
-	// 更新房間狀態
+	// 預約直播尚未開放即直接開播時，創建者不能同時有其他活躍的直播間
+	if status == "scheduled" && s.hasActiveRoom(ctx, userID) {
+		return fmt.Errorf("用戶已有活躍的直播間，請先結束現有直播間")
+	}
+
+	// 原子地更新房間狀態，避免覆蓋同時發生的取消預約
+	if err := s.transitionRoomStatus(ctx, roomID, "live", "created", "ended", "scheduled", "waiting"); err != nil {
+		return err
+	}
+
 	now := time.Now()
 	updates := map[string]interface{}{
-		"status":     "live",
 		"started_at": now.Format(time.RFC3339),
 		"updated_at": now.Format(time.RFC3339),
 	}
@@ -361,6 +399,16 @@ func (s *LiveRoomService) StartLive(roomID string, userID int) error {
 		return fmt.Errorf("update room status failed: %v", err)
 	}
 
+	// 由 scheduled 直接開播時，創建者進入房間，與開放預約直播間相同
+	if status == "scheduled" {
+		if err := s.addUserToRoom(ctx, roomID, userID, "creator"); err != nil {
+			utils.LogError("添加創建者到房間失敗: %v", err)
+		}
+		if err := s.setUserCurrentRoom(ctx, userID, roomID); err != nil {
+			utils.LogError("設置創建者當前房間失敗: %v", err)
+		}
+	}
+
 	// 重新加入活躍房間列表
 	if err := s.addToActiveRooms(ctx, roomID); err != nil {
 		utils.LogError("重新加入活躍房間列表失敗: %v", err)
@@ -379,6 +427,27 @@ func (s *LiveRoomService) StartLive(roomID string, userID int) error {
 		}
 	}
 
+	// 預約直播開播：移出排程並提醒預約的觀眾
+	if status == "scheduled" || status == "waiting" {
+		if err := utils.GetRedisClient().ZRem(ctx, scheduledRoomsKey, roomID).Err(); err != nil {
+			utils.LogError("移出預約直播排程失敗: %v", err)
+		}
+		go s.notifyRSVPs(roomID, models.NotificationLiveRoomStarted, "直播已開始", "你預約的直播已經開始，快來觀看吧！")
+	}
+
+	// 啟動多平台轉推
+	if s.restreamService != nil {
+		go s.restreamService.StartRoomRelays(roomID)
+	}
+
+	// 寫入追隨者動態並通知追隨者
+	if s.feedService != nil {
+		go s.feedService.FanOutLiveStarted(uint(userID), roomID, now)
+	}
+
+	// 發布開播事件
+	s.publishLiveRoomUpdate(roomID, userID, "live_started", map[string]interface{}{"started_at": now})
+
 	// 同步到資料庫
 	go s.syncRoomToDatabase(roomID)
 
@@ -426,6 +495,14 @@ func (s *LiveRoomService) EndLive(roomID string, userID int) error {
 		return fmt.Errorf("remove from active rooms failed: %v", err)
 	}
 
+	// 停止多平台轉推
+	if s.restreamService != nil {
+		go s.restreamService.StopRoomRelays(roomID)
+	}
+
+	// 發布結束直播事件
+	s.publishLiveRoomUpdate(roomID, userID, "live_ended", map[string]interface{}{"ended_at": now})
+
 	// 異步保存到資料庫
 	go s.syncRoomToDatabase(roomID)
 
@@ -433,6 +510,16 @@ func (s *LiveRoomService) EndLive(roomID string, userID int) error {
 	return nil
 }
 
+// publishLiveRoomUpdate 發布直播間事件到 live_updates 頻道
+func (s *LiveRoomService) publishLiveRoomUpdate(roomID string, userID int, eventType string, data map[string]interface{}) {
+	if s.messaging == nil {
+		return
+	}
+	if err := s.messaging.PublishLiveRoomUpdate(roomID, uint(userID), eventType, data); err != nil {
+		utils.LogError("發布直播間 %s 事件 %s 失敗: %v", roomID, eventType, err)
+	}
+}
+
 // CloseRoom 關閉直播間（完全刪除）
 func (s *LiveRoomService) CloseRoom(roomID string, userID int) error {
 	ctx := context.Background()
@@ -534,8 +621,9 @@ func (s *LiveRoomService) CloseRoom(roomID string, userID int) error {
 		pipe.Del(ctx, key)
 	}
 
-	// 從活躍房間列表移除
+	// 從活躍房間列表及預約排程移除
 	pipe.ZRem(ctx, "live:active_rooms", roomID)
+	pipe.ZRem(ctx, scheduledRoomsKey, roomID)
 
 	// 執行所有操作
 	cmds, err := pipe.Exec(ctx)
@@ -622,6 +710,9 @@ func (s *LiveRoomService) saveRoomToRedis(ctx context.Context, room *LiveRoomInf
 	if !room.StartedAt.IsZero() {
 		data["started_at"] = room.StartedAt.Format(time.RFC3339)
 	}
+	if !room.ScheduledAt.IsZero() {
+		data["scheduled_at"] = room.ScheduledAt.Format(time.RFC3339)
+	}
 	if !room.CreatedAt.IsZero() {
 		data["created_at"] = room.CreatedAt.Format(time.RFC3339)
 	}
@@ -643,6 +734,17 @@ func (s *LiveRoomService) addUserToRoom(ctx context.Context, roomID string, user
 	return utils.GetRedisClient().HSet(ctx, fmt.Sprintf("live:room:%s:roles", roomID), userID, role).Err()
 }
 
+// hasActiveRoom 檢查用戶的當前房間是否仍在活躍狀態
+func (s *LiveRoomService) hasActiveRoom(ctx context.Context, userID int) bool {
+	existingRoomID, err := utils.GetRedisClient().Get(ctx, fmt.Sprintf("user:%d:current_room", userID)).Result()
+	if err != nil || existingRoomID == "" {
+		return false
+	}
+
+	roomStatus, err := utils.GetRedisClient().HGet(ctx, fmt.Sprintf("live:room:%s", existingRoomID), "status").Result()
+	return err == nil && (roomStatus == "created" || roomStatus == "waiting" || roomStatus == "live")
+}
+
 // setUserCurrentRoom 設置用戶當前房間
 func (s *LiveRoomService) setUserCurrentRoom(ctx context.Context, userID int, roomID string) error {
 	return utils.GetRedisClient().Set(ctx, fmt.Sprintf("user:%d:current_room", userID), roomID, 0).Err()
@@ -684,6 +786,18 @@ func (s *LiveRoomService) parseRoomFromRedis(data map[string]string, room *LiveR
 		}
 	}
 
+	if scheduledAtStr := data["scheduled_at"]; scheduledAtStr != "" {
+		if scheduledAt, err := time.Parse(time.RFC3339, scheduledAtStr); err == nil {
+			room.ScheduledAt = scheduledAt
+			if room.Status == "scheduled" || room.Status == "waiting" {
+				room.StartsIn = int64(time.Until(scheduledAt).Seconds())
+				if room.StartsIn < 0 {
+					room.StartsIn = 0
+				}
+			}
+		}
+	}
+
 	if createdAtStr := data["created_at"]; createdAtStr != "" {
 		if createdAt, err := time.Parse(time.RFC3339, createdAtStr); err == nil {
 			room.CreatedAt = createdAt
@@ -0,0 +1,415 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"stream-demo/backend/config"
+	"stream-demo/backend/database/models"
+	"stream-demo/backend/dto"
+	postgresqlRepo "stream-demo/backend/repositories/postgresql"
+	"stream-demo/backend/utils"
+
+	"gorm.io/gorm"
+)
+
+// 推送給用戶 WebSocket 的通知事件
+const (
+	NotificationEventCreated     = "notification" // 新通知，data 為通知內容
+	NotificationEventUnreadCount = "unread_count" // 已讀後的未讀數，data 為 {"unread_count": n}
+)
+
+const (
+	notificationPageDefault    = 20
+	notificationPageMax        = 50
+	notificationTranscodeBatch = 100
+	notificationPurgeInterval  = time.Hour
+	// 轉碼結果只回溯到服務啟動前一小時（最多一天），避免上線時對歷史影片補發通知
+	notificationTranscodeLookback    = time.Hour
+	notificationTranscodeMaxLookback = 24 * time.Hour
+)
+
+// ErrNotificationNotFound 通知不存在或不屬於該用戶
+var ErrNotificationNotFound = errors.New("通知不存在")
+
+// IsValidNotificationType 是否為支援的通知類型
+func IsValidNotificationType(notificationType string) bool {
+	for _, t := range models.NotificationTypes {
+		if t == notificationType {
+			return true
+		}
+	}
+	return false
+}
+
+// UserNotifier 推送事件給用戶在本實例的 WebSocket 連線，未啟用 Redis 訊息時使用
+type UserNotifier interface {
+	NotifyUser(userID uint, event string, data interface{})
+}
+
+// NotificationService 站內通知服務：保存通知並即時推送給在線用戶
+type NotificationService struct {
+	Conf      *config.Config
+	Repo      *postgresqlRepo.PostgreSQLRepo
+	RepoSlave *postgresqlRepo.PostgreSQLRepo
+
+	messaging *utils.RedisMessaging // 多實例時經由 Redis 推送
+	notifier  UserNotifier          // 未啟用 Redis 訊息時直接推送
+
+	startedAt time.Time
+	lastPurge time.Time
+	stopChan  chan struct{}
+	ticker    *time.Ticker
+}
+
+// NewNotificationService 創建站內通知服務
+func NewNotificationService(conf *config.Config, messaging *utils.RedisMessaging) *NotificationService {
+	return &NotificationService{
+		Conf:      conf,
+		Repo:      postgresqlRepo.NewPostgreSQLRepo(conf.DB["master"]),
+		RepoSlave: postgresqlRepo.NewPostgreSQLRepo(conf.DB["slave"]),
+		messaging: messaging,
+		startedAt: time.Now(),
+		stopChan:  make(chan struct{}),
+	}
+}
+
+// SetNotifier 設置本實例的 WebSocket 推送器
+func (s *NotificationService) SetNotifier(notifier UserNotifier) {
+	s.notifier = notifier
+}
+
+// Start 啟動轉碼結果通知與過期通知清除
+func (s *NotificationService) Start() {
+	interval := time.Duration(s.Conf.Notification.PollInterval) * time.Second
+	s.ticker = time.NewTicker(interval)
+
+	go func() {
+		s.NotifyTranscodeResults()
+		for {
+			select {
+			case <-s.ticker.C:
+				s.NotifyTranscodeResults()
+				s.purgeExpired()
+			case <-s.stopChan:
+				s.ticker.Stop()
+				return
+			}
+		}
+	}()
+
+	utils.LogInfo("站內通知服務已啟動，檢查間隔: %v", interval)
+}
+
+// Stop 停止站內通知服務
+func (s *NotificationService) Stop() {
+	close(s.stopChan)
+	utils.LogInfo("站內通知服務已停止")
+}
+
+// Notify 發送通知給用戶，用戶關閉該類型通知時略過
+func (s *NotificationService) Notify(userID uint, notificationType, title, content string, data map[string]interface{}) error {
+	return s.NotifyMany([]uint{userID}, notificationType, title, content, data)
+}
+
+// NotifyMany 發送相同通知給多位用戶，略過關閉該類型通知的用戶
+func (s *NotificationService) NotifyMany(userIDs []uint, notificationType, title, content string, data map[string]interface{}) error {
+	if len(userIDs) == 0 {
+		return nil
+	}
+
+	disabled, err := s.Repo.FindNotificationDisabledUserIDs(userIDs, notificationType)
+	if err != nil {
+		return fmt.Errorf("查詢通知偏好失敗: %v", err)
+	}
+	skip := make(map[uint]bool, len(disabled))
+	for _, id := range disabled {
+		skip[id] = true
+	}
+
+	dataJSON := "{}"
+	if len(data) > 0 {
+		encoded, err := json.Marshal(data)
+		if err != nil {
+			return fmt.Errorf("序列化通知資料失敗: %v", err)
+		}
+		dataJSON = string(encoded)
+	}
+
+	notifications := make([]*models.Notification, 0, len(userIDs))
+	for _, userID := range userIDs {
+		if skip[userID] {
+			continue
+		}
+		notifications = append(notifications, &models.Notification{
+			UserID:  userID,
+			Type:    notificationType,
+			Title:   title,
+			Content: content,
+			Data:    dataJSON,
+		})
+	}
+	if err := s.Repo.CreateNotifications(notifications); err != nil {
+		return fmt.Errorf("保存通知失敗: %v", err)
+	}
+
+	for _, notification := range notifications {
+		s.push(notification.UserID, NotificationEventCreated, toNotificationDTO(notification))
+	}
+	return nil
+}
+
+// ListNotifications 獲取通知列表與未讀數，依時間由新到舊，cursor 為上一頁最後一則通知的ID
+func (s *NotificationService) ListNotifications(userID uint, query *dto.NotificationListQueryDTO) (*dto.NotificationListDTO, error) {
+	limit := query.Limit
+	if limit <= 0 {
+		limit = notificationPageDefault
+	}
+	if limit > notificationPageMax {
+		limit = notificationPageMax
+	}
+
+	var beforeID uint
+	if query.Cursor != "" {
+		parsed, err := strconv.ParseUint(query.Cursor, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("無效的游標")
+		}
+		beforeID = uint(parsed)
+	}
+
+	notifications, err := s.RepoSlave.FindNotifications(userID, beforeID, query.UnreadOnly, limit+1)
+	if err != nil {
+		return nil, fmt.Errorf("獲取通知失敗: %v", err)
+	}
+	unread, err := s.RepoSlave.CountUnreadNotifications(userID)
+	if err != nil {
+		return nil, fmt.Errorf("獲取未讀通知數失敗: %v", err)
+	}
+
+	result := &dto.NotificationListDTO{
+		Notifications: make([]*dto.NotificationDTO, 0, len(notifications)),
+		UnreadCount:   unread,
+	}
+	if len(notifications) > limit {
+		notifications = notifications[:limit]
+		result.NextCursor = strconv.FormatUint(uint64(notifications[limit-1].ID), 10)
+	}
+	for i := range notifications {
+		result.Notifications = append(result.Notifications, toNotificationDTO(&notifications[i]))
+	}
+	return result, nil
+}
+
+// GetUnreadCount 獲取未讀通知數
+func (s *NotificationService) GetUnreadCount(userID uint) (int64, error) {
+	count, err := s.RepoSlave.CountUnreadNotifications(userID)
+	if err != nil {
+		return 0, fmt.Errorf("獲取未讀通知數失敗: %v", err)
+	}
+	return count, nil
+}
+
+// MarkRead 將通知標記為已讀（重複標記視為成功），回傳標記後的未讀數
+func (s *NotificationService) MarkRead(userID, notificationID uint) (int64, error) {
+	if err := s.Repo.MarkNotificationRead(userID, notificationID, time.Now()); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return 0, ErrNotificationNotFound
+		}
+		return 0, fmt.Errorf("標記已讀失敗: %v", err)
+	}
+	return s.pushUnreadCount(userID)
+}
+
+// MarkAllRead 將所有未讀通知標記為已讀，回傳標記數
+func (s *NotificationService) MarkAllRead(userID uint) (int64, error) {
+	marked, err := s.Repo.MarkAllNotificationsRead(userID, time.Now())
+	if err != nil {
+		return 0, fmt.Errorf("標記已讀失敗: %v", err)
+	}
+	if marked > 0 {
+		s.push(userID, NotificationEventUnreadCount, map[string]interface{}{"unread_count": 0})
+	}
+	return marked, nil
+}
+
+// GetPreferences 獲取所有通知類型的接收設定，未設定的類型預設接收
+func (s *NotificationService) GetPreferences(userID uint) ([]*dto.NotificationPreferenceDTO, error) {
+	saved, err := s.RepoSlave.FindNotificationPreferences(userID)
+	if err != nil {
+		return nil, fmt.Errorf("獲取通知偏好失敗: %v", err)
+	}
+	return mergeNotificationPreferences(saved), nil
+}
+
+// UpdatePreferences 更新通知類型的接收設定，回傳更新後的所有設定
+func (s *NotificationService) UpdatePreferences(userID uint, preferences []dto.NotificationPreferenceDTO) ([]*dto.NotificationPreferenceDTO, error) {
+	for _, preference := range preferences {
+		if !IsValidNotificationType(preference.Type) {
+			return nil, fmt.Errorf("不支援的通知類型: %s", preference.Type)
+		}
+	}
+	for _, preference := range preferences {
+		if err := s.Repo.SaveNotificationPreference(userID, preference.Type, preference.Enabled); err != nil {
+			return nil, fmt.Errorf("更新通知偏好失敗: %v", err)
+		}
+	}
+
+	// 剛寫入主庫，從主庫讀取避免複寫延遲
+	saved, err := s.Repo.FindNotificationPreferences(userID)
+	if err != nil {
+		return nil, fmt.Errorf("獲取通知偏好失敗: %v", err)
+	}
+	return mergeNotificationPreferences(saved), nil
+}
+
+// NotifyTranscodeResults 通知擁有者影片轉碼完成、失敗或偵測到重複上傳，並發布 video_processing 事件
+// 轉碼服務直接更新資料庫狀態，因此以 notified_status 判斷是否已通知，先標記再通知避免多實例重複發送
+func (s *NotificationService) NotifyTranscodeResults() {
+	since := s.startedAt.Add(-notificationTranscodeLookback)
+	if oldest := time.Now().Add(-notificationTranscodeMaxLookback); since.Before(oldest) {
+		since = oldest
+	}
+
+	for {
+		videos, err := s.Repo.FindTranscodeResultsToNotify(since, notificationTranscodeBatch)
+		if err != nil {
+			utils.LogError("查詢待通知的轉碼結果失敗: %v", err)
+			return
+		}
+
+		for _, video := range videos {
+			claimed, err := s.Repo.ClaimVideoNotifiedStatus(video.ID, video.Status)
+			if err != nil {
+				utils.LogError("記錄影片 %d 通知狀態失敗: %v", video.ID, err)
+				return
+			}
+			if !claimed {
+				continue
+			}
+
+			notificationType := models.NotificationTranscodeFinished
+			title := "影片處理完成"
+			content := fmt.Sprintf("「%s」已可觀看", video.Title)
+			data := map[string]interface{}{"video_id": video.ID}
+			switch video.Status {
+			case "failed":
+				notificationType = models.NotificationTranscodeFailed
+				title = "影片處理失敗"
+				content = fmt.Sprintf("「%s」處理失敗：%s", video.Title, video.ErrorMessage)
+			case "duplicate":
+				// 轉碼服務比對到相同內容的影片，提供共用轉碼結果或重新轉碼的選擇
+				notificationType = models.NotificationDuplicateUpload
+				title = "偵測到重複上傳"
+				content = fmt.Sprintf("「%s」與已上傳的影片內容相同，可共用其轉碼結果或重新轉碼", video.Title)
+				data["duplicate_of_id"] = video.DuplicateOfID
+				data["duplicate_match"] = video.DuplicateMatch
+				data["link_path"] = fmt.Sprintf("/api/videos/%d/duplicate/link", video.ID)
+				data["transcode_path"] = fmt.Sprintf("/api/videos/%d/duplicate/transcode", video.ID)
+			}
+			if err := s.Notify(video.UserID, notificationType, title, content, data); err != nil {
+				utils.LogError("發送影片 %d 轉碼通知失敗: %v", video.ID, err)
+			}
+			s.publishVideoResult(&video)
+		}
+
+		if len(videos) < notificationTranscodeBatch {
+			return
+		}
+	}
+}
+
+// publishVideoResult 發布影片處理結果，供 Webhook 等訂閱者使用
+func (s *NotificationService) publishVideoResult(video *models.Video) {
+	if s.messaging == nil {
+		return
+	}
+	data := map[string]interface{}{
+		"user_id":       video.UserID,
+		"title":         video.Title,
+		"error_message": video.ErrorMessage,
+	}
+	if err := s.messaging.PublishVideoProcessing(video.ID, video.Status, video.ProcessingProgress, data); err != nil {
+		utils.LogError("發布影片 %d 處理結果失敗: %v", video.ID, err)
+	}
+}
+
+// purgeExpired 每小時清除超過保留天數的通知
+func (s *NotificationService) purgeExpired() {
+	if time.Since(s.lastPurge) < notificationPurgeInterval {
+		return
+	}
+	s.lastPurge = time.Now()
+
+	before := time.Now().AddDate(0, 0, -s.Conf.Notification.RetentionDays)
+	deleted, err := s.Repo.DeleteNotificationsBefore(before)
+	if err != nil {
+		utils.LogError("清除過期通知失敗: %v", err)
+		return
+	}
+	if deleted > 0 {
+		utils.LogInfo("已清除 %d 則過期通知", deleted)
+	}
+}
+
+// pushUnreadCount 推送目前的未讀數
+func (s *NotificationService) pushUnreadCount(userID uint) (int64, error) {
+	unread, err := s.Repo.CountUnreadNotifications(userID)
+	if err != nil {
+		return 0, fmt.Errorf("獲取未讀通知數失敗: %v", err)
+	}
+	s.push(userID, NotificationEventUnreadCount, map[string]interface{}{"unread_count": unread})
+	return unread, nil
+}
+
+// push 推送事件給用戶的 WebSocket 連線，啟用 Redis 訊息時經由 Redis 送到所有實例
+func (s *NotificationService) push(userID uint, event string, data interface{}) {
+	if s.messaging != nil {
+		if err := s.messaging.PublishUserNotification(userID, event, data); err != nil {
+			utils.LogError("推送通知給用戶 %d 失敗: %v", userID, err)
+		}
+		return
+	}
+	if s.notifier != nil {
+		s.notifier.NotifyUser(userID, event, data)
+	}
+}
+
+// mergeNotificationPreferences 以已保存的設定覆蓋預設值（全部接收），依通知類型順序回傳
+func mergeNotificationPreferences(saved []models.NotificationPreference) []*dto.NotificationPreferenceDTO {
+	enabled := make(map[string]bool, len(saved))
+	for _, preference := range saved {
+		enabled[preference.Type] = preference.Enabled
+	}
+
+	preferences := make([]*dto.NotificationPreferenceDTO, 0, len(models.NotificationTypes))
+	for _, notificationType := range models.NotificationTypes {
+		value, ok := enabled[notificationType]
+		preferences = append(preferences, &dto.NotificationPreferenceDTO{
+			Type:    notificationType,
+			Enabled: !ok || value,
+		})
+	}
+	return preferences
+}
+
+// toNotificationDTO 轉換為通知 DTO
+func toNotificationDTO(notification *models.Notification) *dto.NotificationDTO {
+	data := json.RawMessage(notification.Data)
+	if len(data) == 0 {
+		data = json.RawMessage("{}")
+	}
+	return &dto.NotificationDTO{
+		ID:        notification.ID,
+		Type:      notification.Type,
+		Title:     notification.Title,
+		Content:   notification.Content,
+		Data:      data,
+		Read:      notification.ReadAt != nil,
+		ReadAt:    notification.ReadAt,
+		CreatedAt: notification.CreatedAt,
+	}
+}
@@ -2,10 +2,12 @@ package services
 
 import (
 	"errors"
+	"fmt"
 	"stream-demo/backend/config"
 	"stream-demo/backend/database/models"
 	dto "stream-demo/backend/dto"
 	postgresqlRepo "stream-demo/backend/repositories/postgresql"
+	"stream-demo/backend/utils"
 	"time"
 
 	"github.com/google/uuid"
@@ -16,6 +18,9 @@ type PaymentService struct {
 	Conf      *config.Config
 	Repo      *postgresqlRepo.PostgreSQLRepo
 	RepoSlave *postgresqlRepo.PostgreSQLRepo
+
+	notificationService *NotificationService  // 付款完成通知
+	messaging           *utils.RedisMessaging // 發布付款完成事件
 }
 
 // NewPaymentService 創建支付服務實例
@@ -27,6 +32,16 @@ func NewPaymentService(conf *config.Config) *PaymentService {
 	}
 }
 
+// SetNotificationService 設置站內通知服務
+func (s *PaymentService) SetNotificationService(notificationService *NotificationService) {
+	s.notificationService = notificationService
+}
+
+// SetMessaging 設置訊息服務
+func (s *PaymentService) SetMessaging(messaging *utils.RedisMessaging) {
+	s.messaging = messaging
+}
+
 // CreatePayment 創建支付
 func (s *PaymentService) CreatePayment(userID uint, createDTO *dto.PaymentCreateDTO) (*dto.PaymentDTO, error) {
 	// 檢查用戶是否存在
@@ -158,6 +173,24 @@ func (s *PaymentService) CompletePayment(id uint) (*dto.PaymentDTO, error) {
 		return nil, err
 	}
 
+	if s.notificationService != nil {
+		content := fmt.Sprintf("你的 %.2f %s 付款已完成", payment.Amount, payment.Currency)
+		data := map[string]interface{}{"payment_id": payment.ID}
+		if err := s.notificationService.Notify(payment.UserID, models.NotificationPaymentCompleted, "付款完成", content, data); err != nil {
+			utils.LogError("發送付款 %d 完成通知失敗: %v", payment.ID, err)
+		}
+	}
+	if s.messaging != nil {
+		data := map[string]interface{}{
+			"amount":         payment.Amount,
+			"currency":       payment.Currency,
+			"transaction_id": payment.TransactionID,
+		}
+		if err := s.messaging.PublishPaymentUpdate(payment.ID, payment.UserID, payment.Status, data); err != nil {
+			utils.LogError("發布付款 %d 完成事件失敗: %v", payment.ID, err)
+		}
+	}
+
 	// 獲取用戶資訊
 	user, err := s.RepoSlave.FindUserByID(payment.UserID)
 	if err != nil {
@@ -0,0 +1,233 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"stream-demo/backend/utils"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	presenceHeartbeat = 30 * time.Second    // 各實例更新在線用戶心跳的間隔
+	presenceTTL       = 90 * time.Second    // 超過此時間沒有心跳視為離線（實例異常終止時）
+	lastSeenTTL       = 30 * 24 * time.Hour // 最後上線時間保留期限
+)
+
+// UserPresence 用戶上線狀態
+type UserPresence struct {
+	Online     bool
+	LastSeenAt *time.Time
+}
+
+// IsPresenceFresh 任一實例的心跳（unix 秒）仍在有效期內即視為在線
+func IsPresenceFresh(heartbeats map[string]string, now time.Time) bool {
+	for _, value := range heartbeats {
+		ts, err := strconv.ParseInt(value, 10, 64)
+		if err == nil && now.Sub(time.Unix(ts, 0)) < presenceTTL {
+			return true
+		}
+	}
+	return false
+}
+
+// PresenceService 依 WebSocket 連線追蹤用戶上線狀態
+// 每個實例在 Redis presence:user:<id> 記錄自己的心跳，用戶在任一實例仍有連線即為在線
+type PresenceService struct {
+	instanceID string
+
+	mu    sync.Mutex
+	local map[uint]int // 本實例各用戶的連線數
+
+	stopChan chan struct{}
+	ticker   *time.Ticker
+}
+
+// NewPresenceService 創建上線狀態服務
+func NewPresenceService() *PresenceService {
+	return &PresenceService{
+		instanceID: uuid.New().String(),
+		local:      make(map[uint]int),
+		stopChan:   make(chan struct{}),
+	}
+}
+
+// Start 啟動心跳更新
+func (s *PresenceService) Start() {
+	s.ticker = time.NewTicker(presenceHeartbeat)
+
+	go func() {
+		for {
+			select {
+			case <-s.ticker.C:
+				s.refresh()
+			case <-s.stopChan:
+				s.ticker.Stop()
+				return
+			}
+		}
+	}()
+
+	utils.LogInfo("上線狀態服務已啟動，心跳間隔: %v", presenceHeartbeat)
+}
+
+// Stop 停止心跳並移除本實例的在線記錄
+func (s *PresenceService) Stop() {
+	close(s.stopChan)
+
+	s.mu.Lock()
+	userIDs := make([]uint, 0, len(s.local))
+	for userID := range s.local {
+		userIDs = append(userIDs, userID)
+	}
+	s.local = make(map[uint]int)
+	s.mu.Unlock()
+
+	for _, userID := range userIDs {
+		s.markOffline(userID)
+	}
+	utils.LogInfo("上線狀態服務已停止")
+}
+
+// Connected 用戶建立 WebSocket 連線
+func (s *PresenceService) Connected(userID uint) {
+	s.mu.Lock()
+	s.local[userID]++
+	first := s.local[userID] == 1
+	s.mu.Unlock()
+
+	if first {
+		s.markOnline(context.Background(), userID)
+	}
+}
+
+// Disconnected 用戶的 WebSocket 連線中斷，本實例已無該用戶連線時移除心跳
+func (s *PresenceService) Disconnected(userID uint) {
+	s.mu.Lock()
+	count, ok := s.local[userID]
+	if !ok {
+		s.mu.Unlock()
+		return
+	}
+	if count > 1 {
+		s.local[userID] = count - 1
+		s.mu.Unlock()
+		return
+	}
+	delete(s.local, userID)
+	s.mu.Unlock()
+
+	s.markOffline(userID)
+}
+
+// Lookup 批次查詢用戶上線狀態
+func (s *PresenceService) Lookup(userIDs []uint) map[uint]UserPresence {
+	result := make(map[uint]UserPresence, len(userIDs))
+	if len(userIDs) == 0 {
+		return result
+	}
+
+	client := utils.GetRedisClient()
+	if client == nil {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		for _, userID := range userIDs {
+			result[userID] = UserPresence{Online: s.local[userID] > 0}
+		}
+		return result
+	}
+
+	ctx := context.Background()
+	heartbeats := make([]*redis.MapStringStringCmd, len(userIDs))
+	lastSeen := make([]*redis.StringCmd, len(userIDs))
+	_, err := client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i, userID := range userIDs {
+			heartbeats[i] = pipe.HGetAll(ctx, presenceKey(userID))
+			lastSeen[i] = pipe.Get(ctx, lastSeenKey(userID))
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, redis.Nil) {
+		utils.LogError("查詢上線狀態失敗: %v", err)
+	}
+
+	now := time.Now()
+	for i, userID := range userIDs {
+		presence := UserPresence{Online: IsPresenceFresh(heartbeats[i].Val(), now)}
+		if !presence.Online {
+			if ts, err := lastSeen[i].Int64(); err == nil {
+				seenAt := time.Unix(ts, 0)
+				presence.LastSeenAt = &seenAt
+			}
+		}
+		result[userID] = presence
+	}
+	return result
+}
+
+// refresh 更新本實例所有在線用戶的心跳
+func (s *PresenceService) refresh() {
+	s.mu.Lock()
+	userIDs := make([]uint, 0, len(s.local))
+	for userID := range s.local {
+		userIDs = append(userIDs, userID)
+	}
+	s.mu.Unlock()
+
+	ctx := context.Background()
+	for _, userID := range userIDs {
+		s.markOnline(ctx, userID)
+	}
+}
+
+// markOnline 寫入本實例的心跳
+func (s *PresenceService) markOnline(ctx context.Context, userID uint) {
+	client := utils.GetRedisClient()
+	if client == nil {
+		return
+	}
+
+	key := presenceKey(userID)
+	_, err := client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, key, s.instanceID, time.Now().Unix())
+		pipe.Expire(ctx, key, presenceTTL)
+		return nil
+	})
+	if err != nil {
+		utils.LogError("更新用戶 %d 上線狀態失敗: %v", userID, err)
+	}
+}
+
+// markOffline 移除本實例的心跳並記錄最後上線時間
+func (s *PresenceService) markOffline(userID uint) {
+	client := utils.GetRedisClient()
+	if client == nil {
+		return
+	}
+
+	ctx := context.Background()
+	_, err := client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HDel(ctx, presenceKey(userID), s.instanceID)
+		pipe.Set(ctx, lastSeenKey(userID), time.Now().Unix(), lastSeenTTL)
+		return nil
+	})
+	if err != nil {
+		utils.LogError("更新用戶 %d 離線狀態失敗: %v", userID, err)
+	}
+}
+
+// presenceKey 用戶各實例心跳的 Redis key
+func presenceKey(userID uint) string {
+	return fmt.Sprintf("presence:user:%d", userID)
+}
+
+// lastSeenKey 用戶最後上線時間的 Redis key
+func lastSeenKey(userID uint) string {
+	return fmt.Sprintf("presence:last_seen:%d", userID)
+}
@@ -142,14 +142,25 @@ func (s *PublicStreamService) GetStreamURLs(streamName string) (map[string]strin
 	// 更新觀看者數量
 	s.incrementViewerCount(streamName)
 
-	// 返回播放 URL
+	// 返回播放 URL，cmaf 封裝時 puller 以同一組 fMP4 分段另外輸出 DASH MPD
 	urls := map[string]string{
 		"hls": fmt.Sprintf("http://localhost:8083/%s/index.m3u8", streamName),
 	}
+	if s.config.Video.PackagingFormat() == config.PackagingCMAF {
+		urls["dash"] = fmt.Sprintf("http://localhost:8083/%s/index.mpd", streamName)
+	}
 
 	return urls, nil
 }
 
+// PreferredManifest 依封裝配置回傳建議使用的播放清單類型（hls / dash）
+func (s *PublicStreamService) PreferredManifest() string {
+	if s.config.Video.PackagingFormat() == config.PackagingCMAF {
+		return "dash"
+	}
+	return "hls"
+}
+
 // GetStreamInfo 獲取流詳細資訊
 func (s *PublicStreamService) GetStreamInfo(streamName string) (*PublicStreamInfo, error) {
 	return s.getStreamInfoFromCache(streamName)
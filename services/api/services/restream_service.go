@@ -0,0 +1,343 @@
+package services
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"stream-demo/backend/config"
+	"stream-demo/backend/database/models"
+	"stream-demo/backend/dto"
+	"stream-demo/backend/pkg/media"
+	postgresqlRepo "stream-demo/backend/repositories/postgresql"
+	"stream-demo/backend/utils"
+)
+
+// RestreamService 多平台轉推服務
+type RestreamService struct {
+	Conf            *config.Config
+	Repo            *postgresqlRepo.PostgreSQLRepo
+	RepoSlave       *postgresqlRepo.PostgreSQLRepo
+	liveRoomService *LiveRoomService
+	supervisor      *media.RestreamSupervisor
+}
+
+// NewRestreamService 創建多平台轉推服務
+func NewRestreamService(conf *config.Config, liveRoomService *LiveRoomService) *RestreamService {
+	restreamConf := conf.Live.Restream
+	s := &RestreamService{
+		Conf:            conf,
+		Repo:            postgresqlRepo.NewPostgreSQLRepo(conf.DB["master"]),
+		RepoSlave:       postgresqlRepo.NewPostgreSQLRepo(conf.DB["slave"]),
+		liveRoomService: liveRoomService,
+		supervisor: media.NewRestreamSupervisor(media.RestreamConfig{
+			FFmpegPath:    restreamConf.FFmpegPath,
+			MaxRetries:    restreamConf.MaxRetries,
+			RetryInterval: time.Duration(restreamConf.RetryInterval) * time.Second,
+		}),
+	}
+	s.supervisor.OnStatusChange(s.handleRelayStatus)
+	return s
+}
+
+// ListTargets 獲取直播間的轉推目標
+func (s *RestreamService) ListTargets(roomID string, userID int) ([]dto.RestreamTargetDTO, error) {
+	if err := s.checkRoomOwner(roomID, userID); err != nil {
+		return nil, err
+	}
+
+	targets, err := s.RepoSlave.FindRestreamTargetsByRoomID(roomID)
+	if err != nil {
+		return nil, fmt.Errorf("獲取轉推目標失敗: %v", err)
+	}
+
+	result := make([]dto.RestreamTargetDTO, 0, len(targets))
+	for i := range targets {
+		result = append(result, s.toDTO(&targets[i]))
+	}
+	return result, nil
+}
+
+// CreateTarget 新增轉推目標，直播中會立即開始轉推
+func (s *RestreamService) CreateTarget(roomID string, userID int, req *dto.RestreamTargetCreateDTO) (*dto.RestreamTargetDTO, error) {
+	if err := s.checkRoomOwner(roomID, userID); err != nil {
+		return nil, err
+	}
+	if err := validateRestreamURL(req.URL); err != nil {
+		return nil, err
+	}
+
+	if maxTargets := s.Conf.Live.Restream.MaxTargets; maxTargets > 0 {
+		count, err := s.Repo.CountRestreamTargetsByRoomID(roomID)
+		if err != nil {
+			return nil, fmt.Errorf("統計轉推目標失敗: %v", err)
+		}
+		if count >= int64(maxTargets) {
+			return nil, fmt.Errorf("轉推目標已達上限 %d 個", maxTargets)
+		}
+	}
+
+	encryptedKey, err := utils.EncryptString(s.Conf.Live.Restream.EncryptionKey, req.StreamKey)
+	if err != nil {
+		return nil, fmt.Errorf("加密推流金鑰失敗: %v", err)
+	}
+
+	target := &models.RestreamTarget{
+		RoomID:    roomID,
+		UserID:    userID,
+		Name:      req.Name,
+		URL:       strings.TrimSpace(req.URL),
+		StreamKey: encryptedKey,
+		Enabled:   true,
+		Status:    "idle",
+	}
+	if req.Enabled != nil {
+		target.Enabled = *req.Enabled
+	}
+
+	if err := s.Repo.CreateRestreamTarget(target); err != nil {
+		return nil, fmt.Errorf("新增轉推目標失敗: %v", err)
+	}
+
+	if target.Enabled {
+		s.startTargetIfLive(target)
+	}
+
+	result := s.toDTO(target)
+	return &result, nil
+}
+
+// UpdateTarget 更新轉推目標，變更後會重新啟動該目標的轉推
+func (s *RestreamService) UpdateTarget(roomID string, targetID uint, userID int, req *dto.RestreamTargetUpdateDTO) (*dto.RestreamTargetDTO, error) {
+	target, err := s.getOwnedTarget(roomID, targetID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name != "" {
+		target.Name = req.Name
+	}
+	if req.URL != "" {
+		if err := validateRestreamURL(req.URL); err != nil {
+			return nil, err
+		}
+		target.URL = strings.TrimSpace(req.URL)
+	}
+	if req.StreamKey != "" {
+		encryptedKey, err := utils.EncryptString(s.Conf.Live.Restream.EncryptionKey, req.StreamKey)
+		if err != nil {
+			return nil, fmt.Errorf("加密推流金鑰失敗: %v", err)
+		}
+		target.StreamKey = encryptedKey
+	}
+	if req.Enabled != nil {
+		target.Enabled = *req.Enabled
+	}
+
+	// 停止舊的轉推，啟用中則以新設定重新啟動
+	s.supervisor.StopRelay(target.ID)
+	target.Status = "idle"
+	target.Retries = 0
+	target.LastError = ""
+
+	if err := s.Repo.UpdateRestreamTarget(target); err != nil {
+		return nil, fmt.Errorf("更新轉推目標失敗: %v", err)
+	}
+
+	if target.Enabled {
+		s.startTargetIfLive(target)
+	}
+
+	result := s.toDTO(target)
+	return &result, nil
+}
+
+// DeleteTarget 刪除轉推目標
+func (s *RestreamService) DeleteTarget(roomID string, targetID uint, userID int) error {
+	target, err := s.getOwnedTarget(roomID, targetID, userID)
+	if err != nil {
+		return err
+	}
+
+	s.supervisor.StopRelay(target.ID)
+
+	if err := s.Repo.DeleteRestreamTarget(target.ID); err != nil {
+		return fmt.Errorf("刪除轉推目標失敗: %v", err)
+	}
+	return nil
+}
+
+// RetryTarget 手動重試轉推目標
+func (s *RestreamService) RetryTarget(roomID string, targetID uint, userID int) error {
+	target, err := s.getOwnedTarget(roomID, targetID, userID)
+	if err != nil {
+		return err
+	}
+	if !target.Enabled {
+		return fmt.Errorf("轉推目標未啟用")
+	}
+
+	if _, running := s.supervisor.Status(target.ID); running {
+		return s.supervisor.Retry(target.ID)
+	}
+
+	room, err := s.liveRoomService.GetRoomByID(roomID)
+	if err != nil {
+		return err
+	}
+	if room.Status != "live" {
+		return fmt.Errorf("直播間未在直播中")
+	}
+	return s.startTarget(room.StreamKey, target)
+}
+
+// StartRoomRelays 直播開始時啟動直播間所有已啟用的轉推
+func (s *RestreamService) StartRoomRelays(roomID string) {
+	if !s.Conf.Live.Restream.Enabled {
+		return
+	}
+
+	room, err := s.liveRoomService.GetRoomByID(roomID)
+	if err != nil {
+		utils.LogError("啟動轉推失敗，獲取直播間失敗: %v", err)
+		return
+	}
+
+	targets, err := s.RepoSlave.FindEnabledRestreamTargetsByRoomID(roomID)
+	if err != nil {
+		utils.LogError("啟動轉推失敗，獲取轉推目標失敗: %v", err)
+		return
+	}
+
+	for i := range targets {
+		if err := s.startTarget(room.StreamKey, &targets[i]); err != nil {
+			utils.LogError("啟動轉推目標 %d 失敗: %v", targets[i].ID, err)
+		}
+	}
+}
+
+// StopRoomRelays 直播結束時停止直播間所有轉推
+func (s *RestreamService) StopRoomRelays(roomID string) {
+	s.supervisor.StopGroup(roomID)
+}
+
+// Stop 停止所有轉推
+func (s *RestreamService) Stop() {
+	s.supervisor.StopAll()
+}
+
+// startTargetIfLive 直播進行中時立即啟動轉推
+func (s *RestreamService) startTargetIfLive(target *models.RestreamTarget) {
+	if !s.Conf.Live.Restream.Enabled {
+		return
+	}
+
+	room, err := s.liveRoomService.GetRoomByID(target.RoomID)
+	if err != nil || room.Status != "live" {
+		return
+	}
+
+	if err := s.startTarget(room.StreamKey, target); err != nil {
+		utils.LogError("啟動轉推目標 %d 失敗: %v", target.ID, err)
+	}
+}
+
+// startTarget 解密推流金鑰並交由監控器啟動轉推
+func (s *RestreamService) startTarget(roomStreamKey string, target *models.RestreamTarget) error {
+	streamKey, err := utils.DecryptString(s.Conf.Live.Restream.EncryptionKey, target.StreamKey)
+	if err != nil {
+		return fmt.Errorf("解密推流金鑰失敗: %v", err)
+	}
+
+	source := s.sourceURL(roomStreamKey)
+	dest := media.BuildRestreamDestination(target.URL, streamKey)
+	return s.supervisor.StartRelay(target.RoomID, target.ID, source, dest)
+}
+
+// sourceURL 直播間在本平台的 RTMP 來源地址
+func (s *RestreamService) sourceURL(streamKey string) string {
+	if s.Conf.Live.Type == "cloud" {
+		return media.BuildRestreamDestination(s.Conf.Live.Cloud.RTMPIngestURL, streamKey)
+	}
+	return fmt.Sprintf("rtmp://%s:%d/live/%s", s.Conf.Live.Local.RTMPServer, s.Conf.Live.Local.RTMPServerPort, streamKey)
+}
+
+// handleRelayStatus 轉推狀態變更時寫回資料庫並通知直播間
+func (s *RestreamService) handleRelayStatus(state media.RelayState) {
+	if err := s.Repo.UpdateRestreamTargetStatus(state.TargetID, string(state.Status), state.Retries, state.LastError); err != nil {
+		utils.LogError("更新轉推狀態失敗: %v", err)
+	}
+
+	if s.liveRoomService != nil && s.liveRoomService.wsHandler != nil {
+		if handler, ok := s.liveRoomService.wsHandler.(interface {
+			BroadcastRoomUpdate(roomID string, updateType string, data interface{})
+		}); ok {
+			handler.BroadcastRoomUpdate(state.GroupID, "restream_status", state)
+		}
+	}
+}
+
+// checkRoomOwner 檢查用戶是否為直播間創建者
+func (s *RestreamService) checkRoomOwner(roomID string, userID int) error {
+	room, err := s.liveRoomService.GetRoomByID(roomID)
+	if err != nil {
+		return err
+	}
+	if room.CreatorID != userID {
+		return fmt.Errorf("只有直播間創建者可以管理轉推")
+	}
+	return nil
+}
+
+// getOwnedTarget 獲取屬於該直播間且用戶有權限的轉推目標
+func (s *RestreamService) getOwnedTarget(roomID string, targetID uint, userID int) (*models.RestreamTarget, error) {
+	if err := s.checkRoomOwner(roomID, userID); err != nil {
+		return nil, err
+	}
+
+	target, err := s.Repo.FindRestreamTargetByID(targetID)
+	if err != nil || target.RoomID != roomID {
+		return nil, fmt.Errorf("轉推目標不存在")
+	}
+	return target, nil
+}
+
+// toDTO 轉換為 DTO，推流金鑰僅顯示遮罩後的值
+func (s *RestreamService) toDTO(target *models.RestreamTarget) dto.RestreamTargetDTO {
+	maskedKey := ""
+	if streamKey, err := utils.DecryptString(s.Conf.Live.Restream.EncryptionKey, target.StreamKey); err == nil {
+		maskedKey = utils.MaskSecret(streamKey)
+	}
+
+	status, retries, lastError := target.Status, target.Retries, target.LastError
+	if state, ok := s.supervisor.Status(target.ID); ok {
+		status, retries, lastError = string(state.Status), state.Retries, state.LastError
+	}
+
+	return dto.RestreamTargetDTO{
+		ID:        target.ID,
+		RoomID:    target.RoomID,
+		Name:      target.Name,
+		URL:       target.URL,
+		StreamKey: maskedKey,
+		Enabled:   target.Enabled,
+		Status:    status,
+		Retries:   retries,
+		LastError: lastError,
+		CreatedAt: target.CreatedAt,
+		UpdatedAt: target.UpdatedAt,
+	}
+}
+
+// validateRestreamURL 檢查轉推地址格式
+func validateRestreamURL(rawURL string) error {
+	parsed, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil || parsed.Host == "" {
+		return fmt.Errorf("無效的轉推地址")
+	}
+	if parsed.Scheme != "rtmp" && parsed.Scheme != "rtmps" {
+		return fmt.Errorf("轉推地址僅支援 rtmp 或 rtmps")
+	}
+	return nil
+}
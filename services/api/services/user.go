@@ -123,7 +123,7 @@ func (s *UserService) GetUserByID(id uint) (*dto.UserDTO, error) {
 	}
 
 	// 轉換為 DTO
-	return &dto.UserDTO{
+	userDTO := &dto.UserDTO{
 		ID:        user.ID,
 		Username:  user.Username,
 		Email:     user.Email,
@@ -131,7 +131,10 @@ func (s *UserService) GetUserByID(id uint) (*dto.UserDTO, error) {
 		Bio:       user.Bio,
 		CreatedAt: user.CreatedAt,
 		UpdatedAt: user.UpdatedAt,
-	}, nil
+	}
+	userDTO.FollowerCount, _ = s.RepoSlave.CountFollowers(user.ID)
+	userDTO.FollowingCount, _ = s.RepoSlave.CountFollowing(user.ID)
+	return userDTO, nil
 }
 
 // UpdateUser 更新用戶
@@ -191,3 +194,15 @@ func (s *UserService) DeleteUser(id uint) error {
 	// 寫操作 - 使用主庫
 	return s.Repo.DeleteUser(id)
 }
+
+// findExistingUser 查找用戶，不存在時回傳錯誤（FindUserByID 找不到時回傳 nil, nil）
+func findExistingUser(repo *postgresqlRepo.PostgreSQLRepo, userID uint) (*models.User, error) {
+	user, err := repo.FindUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return user, nil
+}
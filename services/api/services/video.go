@@ -77,6 +77,10 @@ func (s *VideoService) CreateVideoRecord(userID uint, title, description, s3Key
 		UserID:      userID,
 		OriginalKey: s3Key,
 		Status:      "uploading",
+
+		TranscodeLadder: s.Conf.Video.TranscodeLadder(),
+		Packaging:       s.Conf.Video.PackagingFormat(),
+		Encrypted:       s.Conf.Video.Encryption.Enabled,
 	}
 
 	if err := s.Repo.CreateVideo(video); err != nil {
@@ -111,7 +115,7 @@ func (s *VideoService) ConfirmUploadAndStartProcessingWithKey(videoID uint, s3Ke
 		return fmt.Errorf("找不到影片記錄: %v", err)
 	}
 
-	// 更新影片資訊
+	// 更新影片資訊（內容雜湊由 converter 轉碼前計算，偵測到重複上傳時再通知用戶）
 	updates := map[string]interface{}{
 		"original_key": s3Key,
 		"status":       "processing",
@@ -177,6 +181,11 @@ func (s *VideoService) ConfirmUploadOnly(videoID uint, s3Key string) error {
 
 // isValidVideoFormat 檢查是否為有效的影片格式
 func (s *VideoService) isValidVideoFormat(ext string) bool {
+	return isValidVideoFormat(ext)
+}
+
+// isValidVideoFormat 檢查副檔名是否為支援的影片格式
+func isValidVideoFormat(ext string) bool {
 	validFormats := []string{".mp4", ".avi", ".mov", ".mkv", ".wmv", ".flv", ".webm", ".m4v"}
 	ext = strings.ToLower(ext)
 	for _, format := range validFormats {
@@ -187,42 +196,30 @@ func (s *VideoService) isValidVideoFormat(ext string) bool {
 	return false
 }
 
-// GetVideoByID 根據ID獲取影片
-func (s *VideoService) GetVideoByID(id uint) (*dto.VideoDTO, error) {
+// GetVideoByID 根據ID獲取檢視者可見的影片
+func (s *VideoService) GetVideoByID(viewerID, id uint) (*dto.VideoDTO, error) {
 	video, err := s.Repo.FindVideoByID(id)
 	if err != nil {
 		return nil, fmt.Errorf("找不到影片: %v", err)
 	}
-
-	// 轉換為 DTO
-	videoDTO := &dto.VideoDTO{
-		ID:                 video.ID,
-		Title:              video.Title,
-		Description:        video.Description,
-		UserID:             video.UserID,
-		OriginalURL:        video.OriginalURL,
-		ThumbnailURL:       video.ThumbnailURL,
-		HLSMasterURL:       video.HLSMasterURL,
-		MP4URL:             video.MP4URL,
-		Duration:           video.Duration,
-		FileSize:           video.FileSize,
-		OriginalFormat:     video.OriginalFormat,
-		Status:             video.Status,
-		ProcessingProgress: video.ProcessingProgress,
-		ErrorMessage:       video.ErrorMessage,
-		Views:              video.Views,
-		Likes:              video.Likes,
-		CreatedAt:          video.CreatedAt,
-		UpdatedAt:          video.UpdatedAt,
+	if !canViewVideo(s.Repo, video, viewerID) {
+		return nil, fmt.Errorf("找不到影片: %v", ErrVideoNotVisible)
 	}
 
-	// 如果有用戶資訊，添加用戶名
-	if video.User != nil {
-		videoDTO.Username = video.User.Username
-	}
+	videoDTO := videoDetailDTO(s.Repo, video)
+	applyViewerReactions(s.Repo, viewerID, []*dto.VideoDTO{videoDTO})
+	return videoDTO, nil
+}
+
+// videoDetailDTO 將影片轉換為包含畫質、音軌、片段來源與字幕的完整 DTO
+func videoDetailDTO(repo *postgresqlRepo.PostgreSQLRepo, video *models.Video) *dto.VideoDTO {
+	id := video.ID
+
+	// 轉換為 DTO
+	videoDTO := newVideoDTO(video)
 
 	// 獲取影片品質資訊
-	qualities, err := s.Repo.FindVideoQualitiesByVideoID(id)
+	qualities, err := repo.FindVideoQualitiesByVideoID(id)
 	if err == nil && len(qualities) > 0 {
 		qualityDTOs := make([]dto.VideoQualityDTO, len(qualities))
 		for i, quality := range qualities {
@@ -240,138 +237,72 @@ func (s *VideoService) GetVideoByID(id uint) (*dto.VideoDTO, error) {
 		videoDTO.Qualities = qualityDTOs
 	}
 
-	return videoDTO, nil
-}
-
-// GetVideos 獲取影片列表
-func (s *VideoService) GetVideos(offset, limit int) ([]*dto.VideoDTO, int64, error) {
-	videos, total, err := s.Repo.FindVideosWithPagination(offset, limit)
-	if err != nil {
-		return nil, 0, fmt.Errorf("獲取影片列表失敗: %v", err)
+	// 獲取音軌資訊
+	if tracks, err := repo.FindVideoAudioTracksByVideoID(id); err == nil && len(tracks) > 0 {
+		videoDTO.AudioTracks = make([]dto.VideoAudioTrackDTO, len(tracks))
+		for i, track := range tracks {
+			videoDTO.AudioTracks[i] = dto.VideoAudioTrackDTO{
+				ID:          track.ID,
+				Language:    track.Language,
+				Label:       track.Label,
+				Channels:    track.Channels,
+				Bitrate:     track.Bitrate,
+				IsDefault:   track.IsDefault,
+				PlaylistURL: track.PlaylistURL,
+			}
+		}
+		videoDTO.AudioLanguages = AudioTrackLanguages(tracks)
 	}
 
-	// 轉換為 DTO
-	videoDTOs := make([]*dto.VideoDTO, len(videos))
-	for i, video := range videos {
-		videoDTO := &dto.VideoDTO{
-			ID:                 video.ID,
-			Title:              video.Title,
-			Description:        video.Description,
-			UserID:             video.UserID,
-			OriginalURL:        video.OriginalURL,
-			ThumbnailURL:       video.ThumbnailURL,
-			HLSMasterURL:       video.HLSMasterURL,
-			MP4URL:             video.MP4URL,
-			Duration:           video.Duration,
-			FileSize:           video.FileSize,
-			OriginalFormat:     video.OriginalFormat,
-			Status:             video.Status,
-			ProcessingProgress: video.ProcessingProgress,
-			Views:              video.Views,
-			Likes:              video.Likes,
-			CreatedAt:          video.CreatedAt,
-			UpdatedAt:          video.UpdatedAt,
-		}
+	// 精華片段來源資訊
+	if clip, err := repo.FindVideoClipByVideoID(id); err == nil {
+		videoDTO.Clip = newVideoClipDTO(clip)
+	}
 
-		// 如果有用戶資訊，添加用戶名
-		if video.User != nil {
-			videoDTO.Username = video.User.Username
+	// 已完成處理的字幕軌
+	if captions, err := repo.FindVideoCaptionsByVideoID(id); err == nil {
+		for i := range captions {
+			if captions[i].Status == "ready" {
+				videoDTO.Captions = append(videoDTO.Captions, *newVideoCaptionDTO(&captions[i]))
+			}
 		}
-
-		videoDTOs[i] = videoDTO
 	}
 
-	return videoDTOs, total, nil
+	return videoDTO
 }
 
-// GetVideosByUserID 根據用戶ID獲取影片列表
-func (s *VideoService) GetVideosByUserID(userID uint) ([]*dto.VideoDTO, int64, error) {
-	videos, err := s.Repo.FindVideoByUserID(userID)
+// GetVideos 獲取檢視者可見的影片列表
+func (s *VideoService) GetVideos(viewerID uint, offset, limit int) ([]*dto.VideoDTO, int64, error) {
+	videos, total, err := s.Repo.FindVideosWithPagination(viewerID, offset, limit)
 	if err != nil {
-		return nil, 0, fmt.Errorf("獲取用戶影片列表失敗: %v", err)
+		return nil, 0, fmt.Errorf("獲取影片列表失敗: %v", err)
 	}
 
 	// 轉換為 DTO
 	videoDTOs := make([]*dto.VideoDTO, len(videos))
 	for i, video := range videos {
-		videoDTO := &dto.VideoDTO{
-			ID:                 video.ID,
-			Title:              video.Title,
-			Description:        video.Description,
-			UserID:             video.UserID,
-			OriginalURL:        video.OriginalURL,
-			ThumbnailURL:       video.ThumbnailURL,
-			HLSMasterURL:       video.HLSMasterURL,
-			MP4URL:             video.MP4URL,
-			Duration:           video.Duration,
-			FileSize:           video.FileSize,
-			OriginalFormat:     video.OriginalFormat,
-			Status:             video.Status,
-			ProcessingProgress: video.ProcessingProgress,
-			Views:              video.Views,
-			Likes:              video.Likes,
-			CreatedAt:          video.CreatedAt,
-			UpdatedAt:          video.UpdatedAt,
-		}
-
-		videoDTOs[i] = videoDTO
+		videoDTOs[i] = newVideoDTO(&video)
 	}
+	applyViewerReactions(s.Repo, viewerID, videoDTOs)
 
-	return videoDTOs, int64(len(videos)), nil
+	return videoDTOs, total, nil
 }
 
-// SearchVideos 搜尋影片
-func (s *VideoService) SearchVideos(query string, offset, limit int) ([]*dto.VideoDTO, int64, error) {
-	videos, err := s.Repo.SearchVideo(query)
+// GetVideosByUserID 根據用戶ID獲取檢視者可見的影片列表
+func (s *VideoService) GetVideosByUserID(viewerID, userID uint) ([]*dto.VideoDTO, int64, error) {
+	videos, err := s.Repo.FindVisibleVideosByUserID(userID, viewerID)
 	if err != nil {
-		return nil, 0, fmt.Errorf("搜尋影片失敗: %v", err)
-	}
-
-	// 手動實現分頁
-	total := int64(len(videos))
-	start := offset
-	end := offset + limit
-	if end > len(videos) {
-		end = len(videos)
-	}
-	if start > len(videos) {
-		start = len(videos)
+		return nil, 0, fmt.Errorf("獲取用戶影片列表失敗: %v", err)
 	}
 
-	pagedVideos := videos[start:end]
-
 	// 轉換為 DTO
-	videoDTOs := make([]*dto.VideoDTO, len(pagedVideos))
-	for i, video := range pagedVideos {
-		videoDTO := &dto.VideoDTO{
-			ID:                 video.ID,
-			Title:              video.Title,
-			Description:        video.Description,
-			UserID:             video.UserID,
-			OriginalURL:        video.OriginalURL,
-			ThumbnailURL:       video.ThumbnailURL,
-			HLSMasterURL:       video.HLSMasterURL,
-			MP4URL:             video.MP4URL,
-			Duration:           video.Duration,
-			FileSize:           video.FileSize,
-			OriginalFormat:     video.OriginalFormat,
-			Status:             video.Status,
-			ProcessingProgress: video.ProcessingProgress,
-			Views:              video.Views,
-			Likes:              video.Likes,
-			CreatedAt:          video.CreatedAt,
-			UpdatedAt:          video.UpdatedAt,
-		}
-
-		// 如果有用戶資訊，添加用戶名
-		if video.User != nil {
-			videoDTO.Username = video.User.Username
-		}
-
-		videoDTOs[i] = videoDTO
+	videoDTOs := make([]*dto.VideoDTO, len(videos))
+	for i, video := range videos {
+		videoDTOs[i] = newVideoDTO(&video)
 	}
+	applyViewerReactions(s.Repo, viewerID, videoDTOs)
 
-	return videoDTOs, total, nil
+	return videoDTOs, int64(len(videos)), nil
 }
 
 // UpdateVideo 更新影片資訊
@@ -397,31 +328,13 @@ func (s *VideoService) UpdateVideo(id uint, title string, description string, vi
 	return nil
 }
 
-// DeleteVideo 刪除影片
+// DeleteVideo 刪除影片（移至垃圾桶）
+// 保留期內可還原，S3 檔案與記錄由 VideoTrashService 期滿後清除
 func (s *VideoService) DeleteVideo(id uint) error {
-	// 獲取影片資訊
-	video, err := s.Repo.FindVideoByID(id)
-	if err != nil {
+	if _, err := s.Repo.FindVideoByID(id); err != nil {
 		return fmt.Errorf("找不到影片: %v", err)
 	}
 
-	// 從 S3 刪除檔案（如果存在）
-	if s.S3Storage != nil {
-		// 刪除原始檔案
-		if video.OriginalKey != "" {
-			s.S3Storage.DeleteFile(video.OriginalKey)
-		}
-
-		// 刪除轉碼後的檔案
-		if video.HLSKey != "" {
-			s.S3Storage.DeleteFile(video.HLSKey)
-		}
-		if video.MP4Key != "" {
-			s.S3Storage.DeleteFile(video.MP4Key)
-		}
-	}
-
-	// 從資料庫刪除
 	return s.Repo.DeleteVideo(id)
 }
 
@@ -430,14 +343,87 @@ func (s *VideoService) IncrementViews(id uint) error {
 	return s.Repo.IncrementVideoViews(id)
 }
 
-// IncrementLikes 增加喜歡次數
-func (s *VideoService) IncrementLikes(id uint) error {
-	return s.Repo.IncrementVideoLikes(id)
+// LikeVideo 喜歡影片（重複喜歡不會重複計數）
+func (s *VideoService) LikeVideo(userID, id uint) error {
+	return setVideoReaction(s.Repo, userID, id, models.ReactionLike, "", "")
+}
+
+// UnlikeVideo 取消喜歡影片（未喜歡時視為成功）
+func (s *VideoService) UnlikeVideo(userID, id uint) error {
+	return removeVideoReaction(s.Repo, userID, id, models.ReactionLike)
+}
+
+// newVideoDTO 將影片模型轉換為 DTO
+func newVideoDTO(video *models.Video) *dto.VideoDTO {
+	videoDTO := &dto.VideoDTO{
+		ID:                 video.ID,
+		Title:              video.Title,
+		Description:        video.Description,
+		UserID:             video.UserID,
+		OriginalURL:        video.OriginalURL,
+		ThumbnailURL:       video.ThumbnailURL,
+		HLSMasterURL:       video.HLSMasterURL,
+		MP4URL:             video.MP4URL,
+		Packaging:          video.Packaging,
+		DASHManifestURL:    video.DASHManifestURL,
+		Encrypted:          video.Encrypted,
+		Duration:           video.Duration,
+		FileSize:           video.FileSize,
+		OriginalFormat:     video.OriginalFormat,
+		DuplicateOfID:      video.DuplicateOfID,
+		DuplicateMatch:     video.DuplicateMatch,
+		RenditionsFromID:   video.RenditionsFromID,
+		Visibility:         video.Visibility,
+		Status:             video.Status,
+		ProcessingProgress: video.ProcessingProgress,
+		ErrorMessage:       video.ErrorMessage,
+		Views:              video.Views,
+		Likes:              video.Likes,
+		Dislikes:           video.Dislikes,
+		CreatedAt:          video.CreatedAt,
+		UpdatedAt:          video.UpdatedAt,
+	}
+
+	// 加密影片只能透過 AES-128 HLS 播放，不提供未加密檔案的網址
+	if video.Encrypted {
+		videoDTO.OriginalURL = ""
+		videoDTO.MP4URL = ""
+	}
+
+	// 如果有用戶資訊，添加用戶名
+	if video.User != nil {
+		videoDTO.Username = video.User.Username
+	}
+
+	// 轉碼時套用的品牌元素
+	if video.BrandingApplied != "" {
+		videoDTO.BrandingApplied = strings.Split(video.BrandingApplied, ",")
+	}
+
+	// 轉碼時產生的拖曳預覽
+	if video.PreviewVTTURL != "" {
+		videoDTO.Preview = &dto.VideoPreviewDTO{
+			VTTURL:     video.PreviewVTTURL,
+			SpriteURLs: storage.PreviewSpriteURLs(video.PreviewVTTURL, video.PreviewSpriteCount),
+			Interval:   video.PreviewInterval,
+		}
+	}
+
+	return videoDTO
 }
 
-// LikeVideo 喜歡影片
-func (s *VideoService) LikeVideo(id uint) error {
-	return s.Repo.IncrementVideoLikes(id)
+// AudioTrackLanguages 列出音軌的可用語言（去除重複，保留順序，不含未標記的 und）
+func AudioTrackLanguages(tracks []models.VideoAudioTrack) []string {
+	seen := make(map[string]bool, len(tracks))
+	languages := make([]string, 0, len(tracks))
+	for _, track := range tracks {
+		if track.Language == "" || track.Language == "und" || seen[track.Language] {
+			continue
+		}
+		seen[track.Language] = true
+		languages = append(languages, track.Language)
+	}
+	return languages
 }
 
 // CheckS3Configuration 檢查 S3 配置
@@ -0,0 +1,520 @@
+package services
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"stream-demo/backend/config"
+	"stream-demo/backend/database/models"
+	"stream-demo/backend/dto"
+	postgresqlRepo "stream-demo/backend/repositories/postgresql"
+	"stream-demo/backend/utils"
+)
+
+// 留言列表每頁數量
+const (
+	commentPageDefault = 20
+	commentPageMax     = 100
+)
+
+// ErrCommentNotFound 留言不存在或不屬於此影片
+var ErrCommentNotFound = errors.New("留言不存在")
+
+// FormatCommentTimestamp 將影片時間點格式化為 mm:ss，超過一小時為 h:mm:ss
+func FormatCommentTimestamp(seconds int) string {
+	if seconds >= 3600 {
+		return fmt.Sprintf("%d:%02d:%02d", seconds/3600, seconds%3600/60, seconds%60)
+	}
+	return fmt.Sprintf("%02d:%02d", seconds/60, seconds%60)
+}
+
+// EncodeCommentCursor 將排序鍵與留言ID編碼為分頁游標
+func EncodeCommentCursor(key int64, id uint) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf("%d:%d", key, id)))
+}
+
+// DecodeCommentCursor 解析分頁游標
+func DecodeCommentCursor(cursor string) (int64, uint, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, 0, fmt.Errorf("無效的游標")
+	}
+	keyPart, idPart, ok := strings.Cut(string(raw), ":")
+	if !ok {
+		return 0, 0, fmt.Errorf("無效的游標")
+	}
+	key, err := strconv.ParseInt(keyPart, 10, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("無效的游標")
+	}
+	id, err := strconv.ParseUint(idPart, 10, 32)
+	if err != nil {
+		return 0, 0, fmt.Errorf("無效的游標")
+	}
+	return key, uint(id), nil
+}
+
+// VideoCommentService 影片留言服務
+type VideoCommentService struct {
+	Conf      *config.Config
+	Repo      *postgresqlRepo.PostgreSQLRepo
+	RepoSlave *postgresqlRepo.PostgreSQLRepo
+	filter    *utils.WordFilter
+
+	friendService *FriendService // 封鎖的用戶之間不能留言互動，也看不到彼此的留言
+}
+
+// NewVideoCommentService 創建影片留言服務
+func NewVideoCommentService(conf *config.Config) *VideoCommentService {
+	return &VideoCommentService{
+		Conf:      conf,
+		Repo:      postgresqlRepo.NewPostgreSQLRepo(conf.DB["master"]),
+		RepoSlave: postgresqlRepo.NewPostgreSQLRepo(conf.DB["slave"]),
+		filter:    utils.NewWordFilter(conf.Video.Comments.BannedWords),
+	}
+}
+
+// SetFriendService 設置好友服務
+func (s *VideoCommentService) SetFriendService(friendService *FriendService) {
+	s.friendService = friendService
+}
+
+// ListComments 列出主留言，第一頁時置頂留言排在最前面
+func (s *VideoCommentService) ListComments(viewerID uint, isAdmin bool, videoID uint, query *dto.VideoCommentListQueryDTO) (*dto.VideoCommentListDTO, error) {
+	video, err := s.findViewableVideo(viewerID, videoID)
+	if err != nil {
+		return nil, err
+	}
+
+	sort := query.Sort
+	if sort == "" {
+		sort = postgresqlRepo.CommentSortTop
+	}
+	filter, err := newCommentFilter(videoID, viewerID, sort, query.Cursor, query.Limit)
+	if err != nil {
+		return nil, err
+	}
+	filter.IncludeHidden = isCommentModerator(video, viewerID, isAdmin)
+	filter.ExcludePinned = true
+	filter.ExcludeUsers = s.blockedUserIDs(viewerID)
+
+	comments, err := s.RepoSlave.FindVideoComments(filter)
+	if err != nil {
+		return nil, fmt.Errorf("獲取留言失敗: %v", err)
+	}
+	result := s.newCommentList(comments, filter)
+
+	if !filter.HasCursor {
+		if pinned, err := s.RepoSlave.FindPinnedVideoComment(videoID); err == nil && !slices.Contains(filter.ExcludeUsers, pinned.UserID) &&
+			(pinned.Status == models.CommentStatusVisible || filter.IncludeHidden || pinned.UserID == viewerID) {
+			result.Items = append([]*dto.VideoCommentDTO{newVideoCommentDTO(pinned)}, result.Items...)
+		}
+	}
+
+	s.applyViewerLikes(viewerID, result.Items)
+	return result, nil
+}
+
+// ListReplies 列出主留言的回覆，由舊到新
+func (s *VideoCommentService) ListReplies(viewerID uint, isAdmin bool, videoID, commentID uint, cursor string, limit int) (*dto.VideoCommentListDTO, error) {
+	video, err := s.findViewableVideo(viewerID, videoID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.findComment(s.RepoSlave, videoID, commentID); err != nil {
+		return nil, err
+	}
+
+	filter, err := newCommentFilter(videoID, viewerID, postgresqlRepo.CommentSortOldest, cursor, limit)
+	if err != nil {
+		return nil, err
+	}
+	filter.ParentID = &commentID
+	filter.IncludeHidden = isCommentModerator(video, viewerID, isAdmin)
+	filter.ExcludeUsers = s.blockedUserIDs(viewerID)
+
+	replies, err := s.RepoSlave.FindVideoComments(filter)
+	if err != nil {
+		return nil, fmt.Errorf("獲取回覆失敗: %v", err)
+	}
+	result := s.newCommentList(replies, filter)
+	s.applyViewerLikes(viewerID, result.Items)
+	return result, nil
+}
+
+// CreateComment 新增留言或回覆，回覆其他回覆時掛在同一則主留言下
+func (s *VideoCommentService) CreateComment(userID, videoID uint, req *dto.VideoCommentCreateDTO) (*dto.VideoCommentDTO, error) {
+	video, err := s.findViewableVideo(userID, videoID)
+	if err != nil {
+		return nil, err
+	}
+
+	content, err := s.sanitizeContent(req.Content)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkBlocked(userID, video.UserID); err != nil {
+		return nil, err
+	}
+
+	comment := &models.VideoComment{
+		VideoID: videoID,
+		UserID:  userID,
+		Content: content,
+		Status:  models.CommentStatusVisible,
+	}
+
+	if req.TimestampSeconds != nil {
+		if video.Duration > 0 && *req.TimestampSeconds > video.Duration {
+			return nil, fmt.Errorf("時間點超過影片長度")
+		}
+		comment.TimestampSeconds = req.TimestampSeconds
+	}
+
+	if req.ParentID != nil {
+		parent, err := s.findComment(s.Repo, videoID, *req.ParentID)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.checkBlocked(userID, parent.UserID); err != nil {
+			return nil, err
+		}
+		if parent.ParentID != nil {
+			comment.ParentID = parent.ParentID
+			comment.ReplyToUserID = &parent.UserID
+		} else {
+			comment.ParentID = &parent.ID
+		}
+	}
+
+	if err := s.Repo.CreateVideoComment(comment); err != nil {
+		return nil, fmt.Errorf("新增留言失敗: %v", err)
+	}
+
+	created, err := s.Repo.FindVideoCommentByID(comment.ID)
+	if err != nil {
+		return nil, fmt.Errorf("獲取留言失敗: %v", err)
+	}
+	return newVideoCommentDTO(created), nil
+}
+
+// UpdateComment 編輯自己的留言
+func (s *VideoCommentService) UpdateComment(userID, videoID, commentID uint, content string) (*dto.VideoCommentDTO, error) {
+	comment, err := s.findComment(s.Repo, videoID, commentID)
+	if err != nil {
+		return nil, err
+	}
+	if comment.UserID != userID {
+		return nil, fmt.Errorf("只能編輯自己的留言")
+	}
+
+	content, err = s.sanitizeContent(content)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if err := s.Repo.UpdateVideoCommentFields(commentID, map[string]interface{}{
+		"content":   content,
+		"edited_at": now,
+	}); err != nil {
+		return nil, fmt.Errorf("編輯留言失敗: %v", err)
+	}
+
+	comment.Content = content
+	comment.EditedAt = &now
+	return newVideoCommentDTO(comment), nil
+}
+
+// DeleteComment 刪除留言與其回覆，留言者本人、影片擁有者與管理員可刪除
+func (s *VideoCommentService) DeleteComment(userID uint, isAdmin bool, videoID, commentID uint) error {
+	comment, err := s.findComment(s.Repo, videoID, commentID)
+	if err != nil {
+		return err
+	}
+	if comment.UserID != userID {
+		if _, err := s.findModeratedVideo(userID, isAdmin, videoID); err != nil {
+			return err
+		}
+	}
+
+	if err := s.Repo.DeleteVideoComment(comment); err != nil {
+		return fmt.Errorf("刪除留言失敗: %v", err)
+	}
+	return nil
+}
+
+// ModerateComment 隱藏或恢復留言，影片擁有者與管理員可操作
+func (s *VideoCommentService) ModerateComment(userID uint, isAdmin bool, videoID, commentID uint, status string) (*dto.VideoCommentDTO, error) {
+	if _, err := s.findModeratedVideo(userID, isAdmin, videoID); err != nil {
+		return nil, err
+	}
+	comment, err := s.findComment(s.Repo, videoID, commentID)
+	if err != nil {
+		return nil, err
+	}
+
+	updates := map[string]interface{}{"status": status}
+	if status == models.CommentStatusHidden {
+		// 隱藏的留言不保留置頂
+		updates["pinned"] = false
+		comment.Pinned = false
+	}
+	if err := s.Repo.UpdateVideoCommentFields(commentID, updates); err != nil {
+		return nil, fmt.Errorf("審核留言失敗: %v", err)
+	}
+
+	comment.Status = status
+	return newVideoCommentDTO(comment), nil
+}
+
+// PinComment 置頂主留言，僅影片擁有者可操作，會取代原本的置頂留言
+func (s *VideoCommentService) PinComment(userID, videoID, commentID uint) error {
+	if _, err := s.findModeratedVideo(userID, false, videoID); err != nil {
+		return err
+	}
+	comment, err := s.findComment(s.Repo, videoID, commentID)
+	if err != nil {
+		return err
+	}
+	if comment.ParentID != nil {
+		return fmt.Errorf("只能置頂主留言")
+	}
+	if comment.Status != models.CommentStatusVisible {
+		return fmt.Errorf("隱藏的留言無法置頂")
+	}
+
+	if err := s.Repo.PinVideoComment(videoID, commentID); err != nil {
+		return fmt.Errorf("置頂留言失敗: %v", err)
+	}
+	return nil
+}
+
+// UnpinComment 取消置頂，僅影片擁有者可操作
+func (s *VideoCommentService) UnpinComment(userID, videoID, commentID uint) error {
+	if _, err := s.findModeratedVideo(userID, false, videoID); err != nil {
+		return err
+	}
+	if _, err := s.findComment(s.Repo, videoID, commentID); err != nil {
+		return err
+	}
+
+	if err := s.Repo.UnpinVideoComment(commentID); err != nil {
+		return fmt.Errorf("取消置頂失敗: %v", err)
+	}
+	return nil
+}
+
+// LikeComment 喜歡留言（重複喜歡不會重複計數）
+func (s *VideoCommentService) LikeComment(userID, videoID, commentID uint) error {
+	if _, err := s.findViewableVideo(userID, videoID); err != nil {
+		return err
+	}
+	if _, err := s.findComment(s.Repo, videoID, commentID); err != nil {
+		return err
+	}
+
+	if err := s.Repo.LikeVideoComment(commentID, userID); err != nil {
+		return fmt.Errorf("喜歡留言失敗: %v", err)
+	}
+	return nil
+}
+
+// UnlikeComment 取消喜歡留言
+func (s *VideoCommentService) UnlikeComment(userID, videoID, commentID uint) error {
+	if _, err := s.findComment(s.Repo, videoID, commentID); err != nil {
+		return err
+	}
+
+	if err := s.Repo.UnlikeVideoComment(commentID, userID); err != nil {
+		return fmt.Errorf("取消喜歡留言失敗: %v", err)
+	}
+	return nil
+}
+
+// checkBlocked 與影片擁有者或被回覆的留言者有封鎖關係時不能留言
+func (s *VideoCommentService) checkBlocked(userID, otherID uint) error {
+	if s.friendService == nil {
+		return nil
+	}
+	blocked, err := s.friendService.IsBlocked(userID, otherID)
+	if err != nil {
+		return fmt.Errorf("獲取封鎖關係失敗: %v", err)
+	}
+	if blocked {
+		return ErrUserBlocked
+	}
+	return nil
+}
+
+// blockedUserIDs 與瀏覽者有封鎖關係的用戶ID
+func (s *VideoCommentService) blockedUserIDs(viewerID uint) []uint {
+	if s.friendService == nil || viewerID == 0 {
+		return nil
+	}
+	blocked := s.friendService.BlockedWith(viewerID)
+	ids := make([]uint, 0, len(blocked))
+	for id := range blocked {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// findViewableVideo 查找檢視者可觀看的影片
+func (s *VideoCommentService) findViewableVideo(viewerID, videoID uint) (*models.Video, error) {
+	video, err := s.RepoSlave.FindVideoByID(videoID)
+	if err != nil || !canViewVideo(s.RepoSlave, video, viewerID) {
+		return nil, ErrVideoNotVisible
+	}
+	return video, nil
+}
+
+// findModeratedVideo 查找用戶可管理留言的影片
+func (s *VideoCommentService) findModeratedVideo(userID uint, isAdmin bool, videoID uint) (*models.Video, error) {
+	video, err := s.Repo.FindVideoByID(videoID)
+	if err != nil {
+		return nil, ErrVideoNotVisible
+	}
+	if !isCommentModerator(video, userID, isAdmin) {
+		return nil, fmt.Errorf("無權限管理此影片的留言")
+	}
+	return video, nil
+}
+
+// findComment 查找屬於影片的留言
+func (s *VideoCommentService) findComment(repo *postgresqlRepo.PostgreSQLRepo, videoID, commentID uint) (*models.VideoComment, error) {
+	comment, err := repo.FindVideoCommentByID(commentID)
+	if err != nil || comment.VideoID != videoID {
+		return nil, ErrCommentNotFound
+	}
+	return comment, nil
+}
+
+// sanitizeContent 檢查留言長度並依設定處理敏感詞
+func (s *VideoCommentService) sanitizeContent(content string) (string, error) {
+	content = strings.TrimSpace(content)
+	if content == "" {
+		return "", fmt.Errorf("留言內容不能為空")
+	}
+	if utf8.RuneCountInString(content) > s.Conf.Video.Comments.MaxLength {
+		return "", fmt.Errorf("留言不能超過 %d 個字", s.Conf.Video.Comments.MaxLength)
+	}
+	if !s.filter.Contains(content) {
+		return content, nil
+	}
+	if s.Conf.Video.Comments.BannedWordAction == config.BannedWordReject {
+		return "", fmt.Errorf("留言包含不當字詞")
+	}
+	return s.filter.Mask(content), nil
+}
+
+// applyViewerLikes 標記檢視者喜歡過的留言
+func (s *VideoCommentService) applyViewerLikes(viewerID uint, comments []*dto.VideoCommentDTO) {
+	if viewerID == 0 || len(comments) == 0 {
+		return
+	}
+
+	ids := make([]uint, len(comments))
+	for i, comment := range comments {
+		ids[i] = comment.ID
+	}
+	liked, err := s.RepoSlave.FindLikedVideoCommentIDs(viewerID, ids)
+	if err != nil {
+		utils.LogWarn("查詢留言喜歡狀態失敗: %v", err)
+		return
+	}
+
+	likedSet := make(map[uint]bool, len(liked))
+	for _, id := range liked {
+		likedSet[id] = true
+	}
+	for _, comment := range comments {
+		comment.LikedByMe = likedSet[comment.ID]
+	}
+}
+
+// newCommentList 轉換留言列表，滿頁時以最後一則留言產生下一頁游標
+func (s *VideoCommentService) newCommentList(comments []models.VideoComment, filter postgresqlRepo.VideoCommentFilter) *dto.VideoCommentListDTO {
+	result := &dto.VideoCommentListDTO{Items: make([]*dto.VideoCommentDTO, len(comments))}
+	for i := range comments {
+		result.Items[i] = newVideoCommentDTO(&comments[i])
+	}
+
+	if len(comments) == filter.Limit {
+		last := comments[len(comments)-1]
+		key := last.CreatedAt.UnixMicro()
+		if filter.Sort == postgresqlRepo.CommentSortTop {
+			key = last.Likes
+		}
+		result.NextCursor = EncodeCommentCursor(key, last.ID)
+	}
+	return result
+}
+
+// newCommentFilter 建立留言查詢條件
+func newCommentFilter(videoID, viewerID uint, sort, cursor string, limit int) (postgresqlRepo.VideoCommentFilter, error) {
+	if limit <= 0 {
+		limit = commentPageDefault
+	}
+	if limit > commentPageMax {
+		limit = commentPageMax
+	}
+
+	filter := postgresqlRepo.VideoCommentFilter{
+		VideoID:  videoID,
+		ViewerID: viewerID,
+		Sort:     sort,
+		Limit:    limit,
+	}
+	if cursor != "" {
+		key, id, err := DecodeCommentCursor(cursor)
+		if err != nil {
+			return filter, err
+		}
+		filter.HasCursor = true
+		filter.CursorKey = key
+		filter.CursorID = id
+	}
+	return filter, nil
+}
+
+// isCommentModerator 影片擁有者與管理員可管理留言
+func isCommentModerator(video *models.Video, userID uint, isAdmin bool) bool {
+	return isAdmin || (userID != 0 && video.UserID == userID)
+}
+
+// newVideoCommentDTO 轉換留言
+func newVideoCommentDTO(comment *models.VideoComment) *dto.VideoCommentDTO {
+	commentDTO := &dto.VideoCommentDTO{
+		ID:               comment.ID,
+		VideoID:          comment.VideoID,
+		ParentID:         comment.ParentID,
+		UserID:           comment.UserID,
+		ReplyToUserID:    comment.ReplyToUserID,
+		Content:          comment.Content,
+		TimestampSeconds: comment.TimestampSeconds,
+		Pinned:           comment.Pinned,
+		Status:           comment.Status,
+		Likes:            comment.Likes,
+		ReplyCount:       comment.ReplyCount,
+		EditedAt:         comment.EditedAt,
+		CreatedAt:        comment.CreatedAt,
+	}
+	if comment.TimestampSeconds != nil {
+		commentDTO.TimestampLabel = FormatCommentTimestamp(*comment.TimestampSeconds)
+	}
+	if comment.User != nil {
+		commentDTO.Username = comment.User.Username
+		commentDTO.Avatar = comment.User.Avatar
+	}
+	if comment.ReplyToUser != nil {
+		commentDTO.ReplyToUsername = comment.ReplyToUser.Username
+	}
+	return commentDTO
+}
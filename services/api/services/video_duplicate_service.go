@@ -0,0 +1,139 @@
+package services
+
+import (
+	"fmt"
+	"time"
+
+	"stream-demo/backend/config"
+	"stream-demo/backend/database/models"
+	"stream-demo/backend/dto"
+	postgresqlRepo "stream-demo/backend/repositories/postgresql"
+	"stream-demo/backend/utils"
+)
+
+// 重複上傳比對方式
+const (
+	DuplicateMatchSHA256     = "sha256"     // 原始檔內容完全相同（串流上傳時或由 converter 轉碼前比對）
+	DuplicateMatchPerceptual = "perceptual" // 取樣畫面相近（由 converter 轉碼前比對）
+)
+
+// VideoDuplicateService 重複上傳處理服務
+// 串流上傳時以 SHA-256 比對、converter 轉碼前以 SHA-256 與感知雜湊比對，相符時影片進入 duplicate 狀態並通知用戶決定
+type VideoDuplicateService struct {
+	Conf      *config.Config
+	Repo      *postgresqlRepo.PostgreSQLRepo
+	RepoSlave *postgresqlRepo.PostgreSQLRepo
+}
+
+// NewVideoDuplicateService 創建重複上傳處理服務
+func NewVideoDuplicateService(conf *config.Config) *VideoDuplicateService {
+	return &VideoDuplicateService{
+		Conf:      conf,
+		Repo:      postgresqlRepo.NewPostgreSQLRepo(conf.DB["master"]),
+		RepoSlave: postgresqlRepo.NewPostgreSQLRepo(conf.DB["slave"]),
+	}
+}
+
+// renditionsOwner 轉碼輸出所屬的影片，共用其他影片的轉碼結果時為來源影片
+func renditionsOwner(video *models.Video) uint {
+	if video.RenditionsFromID != nil {
+		return *video.RenditionsFromID
+	}
+	return video.ID
+}
+
+// LinkDuplicate 共用重複來源影片的轉碼結果，不再轉碼
+func (s *VideoDuplicateService) LinkDuplicate(userID, videoID uint) (*dto.VideoDTO, error) {
+	video, err := s.findPendingDuplicate(userID, videoID)
+	if err != nil {
+		return nil, err
+	}
+
+	source, err := s.Repo.FindVideoByID(*video.DuplicateOfID)
+	if err != nil {
+		return nil, fmt.Errorf("找不到重複來源影片: %v", err)
+	}
+	// 來源本身也是共用而來時，直接指向實際轉碼的影片
+	if source.RenditionsFromID != nil {
+		if source, err = s.Repo.FindVideoByID(*source.RenditionsFromID); err != nil {
+			return nil, fmt.Errorf("找不到重複來源影片: %v", err)
+		}
+	}
+	if source.UserID != userID || source.Status != "ready" {
+		return nil, fmt.Errorf("重複來源影片無法共用，請重新轉碼")
+	}
+	// 加密播放列表的金鑰網址綁定來源影片ID，無法共用
+	if source.Encrypted {
+		return nil, fmt.Errorf("加密影片無法共用轉碼結果，請重新轉碼")
+	}
+
+	updates := map[string]interface{}{
+		"status":               "ready",
+		"processing_progress":  100,
+		"error_message":        "",
+		"renditions_from_id":   source.ID,
+		"hls_master_url":       source.HLSMasterURL,
+		"hls_key":              source.HLSKey,
+		"packaging":            source.Packaging,
+		"dash_manifest_url":    source.DASHManifestURL,
+		"encrypted":            false,
+		"mp4_url":              source.MP4URL,
+		"mp4_key":              source.MP4Key,
+		"thumbnail_url":        source.ThumbnailURL,
+		"preview_vtt_url":      source.PreviewVTTURL,
+		"preview_sprite_count": source.PreviewSpriteCount,
+		"preview_interval":     source.PreviewInterval,
+		"duration":             source.Duration,
+		"branding_applied":     source.BrandingApplied,
+		"transcode_ladder":     source.TranscodeLadder,
+		"perceptual_hash":      source.PerceptualHash,
+		"updated_at":           time.Now(),
+	}
+	if err := s.Repo.LinkVideoRenditions(video.ID, source.ID, updates); err != nil {
+		return nil, fmt.Errorf("共用轉碼結果失敗: %v", err)
+	}
+
+	utils.LogInfo("重複上傳共用轉碼結果: video=%d, renditions_from=%d", video.ID, source.ID)
+
+	linked, err := s.Repo.FindVideoByID(video.ID)
+	if err != nil {
+		return nil, fmt.Errorf("獲取影片失敗: %v", err)
+	}
+	return newVideoDTO(linked), nil
+}
+
+// TranscodeDuplicate 忽略重複偵測，照常轉碼
+// 保留 duplicate_of_id 記錄用戶的決定，converter 不會再次攔截
+func (s *VideoDuplicateService) TranscodeDuplicate(userID, videoID uint) (*dto.VideoDTO, error) {
+	video, err := s.findPendingDuplicate(userID, videoID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.Repo.UpdateVideoFields(video.ID, map[string]interface{}{
+		"status":              "processing",
+		"processing_progress": 0,
+		"updated_at":          time.Now(),
+	}); err != nil {
+		return nil, fmt.Errorf("更新影片狀態失敗: %v", err)
+	}
+
+	video.Status = "processing"
+	video.ProcessingProgress = 0
+	return newVideoDTO(video), nil
+}
+
+// findPendingDuplicate 查找等待用戶決定的重複影片
+func (s *VideoDuplicateService) findPendingDuplicate(userID, videoID uint) (*models.Video, error) {
+	video, err := s.Repo.FindVideoByID(videoID)
+	if err != nil {
+		return nil, fmt.Errorf("找不到影片: %v", err)
+	}
+	if video.UserID != userID {
+		return nil, fmt.Errorf("無權限操作此影片")
+	}
+	if video.Status != "duplicate" || video.DuplicateOfID == nil {
+		return nil, fmt.Errorf("影片不是待處理的重複上傳")
+	}
+	return video, nil
+}
@@ -0,0 +1,159 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"stream-demo/backend/config"
+	"stream-demo/backend/database/models"
+	"stream-demo/backend/dto"
+	postgresqlRepo "stream-demo/backend/repositories/postgresql"
+	"stream-demo/backend/utils"
+)
+
+// VideoKeyService HLS 內容金鑰服務
+// 金鑰由 converter 加密分段時產生並加密保存，播放器以播放憑證向金鑰伺服器取得
+type VideoKeyService struct {
+	Conf      *config.Config
+	Repo      *postgresqlRepo.PostgreSQLRepo
+	RepoSlave *postgresqlRepo.PostgreSQLRepo
+}
+
+// NewVideoKeyService 創建內容金鑰服務
+func NewVideoKeyService(conf *config.Config) *VideoKeyService {
+	return &VideoKeyService{
+		Conf:      conf,
+		Repo:      postgresqlRepo.NewPostgreSQLRepo(conf.DB["master"]),
+		RepoSlave: postgresqlRepo.NewPostgreSQLRepo(conf.DB["slave"]),
+	}
+}
+
+// SignPlaybackToken 產生播放憑證，格式為 <videoID>.<userID>.<到期 Unix 秒>.<HMAC-SHA256>
+// 由分享連結取得的憑證一併簽入分享代碼，分享連結重新產生後即失效
+func SignPlaybackToken(secret string, videoID, userID uint, shareSlug string, expiresAt time.Time) string {
+	payload := fmt.Sprintf("%d.%d.%d", videoID, userID, expiresAt.Unix())
+	return payload + "." + playbackTokenSignature(secret, payload, shareSlug)
+}
+
+// VerifyPlaybackToken 驗證播放憑證的簽章、影片與到期時間，回傳憑證所屬用戶與簽入的分享代碼
+// shareSlug 為影片目前的分享代碼，沒有分享連結時傳入空字串
+func VerifyPlaybackToken(secret, token string, videoID uint, shareSlug string, now time.Time) (uint, string, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 4 {
+		return 0, "", fmt.Errorf("無效的播放憑證")
+	}
+
+	payload := strings.Join(parts[:3], ".")
+	signedSlug := ""
+	if !hmac.Equal([]byte(parts[3]), []byte(playbackTokenSignature(secret, payload, ""))) {
+		if shareSlug == "" || !hmac.Equal([]byte(parts[3]), []byte(playbackTokenSignature(secret, payload, shareSlug))) {
+			return 0, "", fmt.Errorf("無效的播放憑證")
+		}
+		signedSlug = shareSlug
+	}
+
+	tokenVideoID, err := strconv.ParseUint(parts[0], 10, 32)
+	if err != nil || uint(tokenVideoID) != videoID {
+		return 0, "", fmt.Errorf("播放憑證與影片不符")
+	}
+	userID, err := strconv.ParseUint(parts[1], 10, 32)
+	if err != nil {
+		return 0, "", fmt.Errorf("無效的播放憑證")
+	}
+	expiresAt, err := strconv.ParseInt(parts[2], 10, 64)
+	if err != nil || now.Unix() > expiresAt {
+		return 0, "", fmt.Errorf("播放憑證已過期")
+	}
+
+	return uint(userID), signedSlug, nil
+}
+
+// playbackTokenSignature 計算播放憑證簽章，有分享代碼時附加於內容之後
+func playbackTokenSignature(secret, payload, shareSlug string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(payload))
+	if shareSlug != "" {
+		mac.Write([]byte("|" + shareSlug))
+	}
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// AuthorizePlayback 檢查用戶是否有權播放加密影片，不公開影片需帶有效的分享代碼
+func (s *VideoKeyService) AuthorizePlayback(userID, videoID uint, shareSlug string) (*models.Video, error) {
+	video, err := s.RepoSlave.FindVideoByID(videoID)
+	if err != nil {
+		return nil, fmt.Errorf("找不到影片: %v", err)
+	}
+
+	// 擁有者可隨時播放，其他用戶僅能播放已完成處理的影片
+	if video.UserID != userID && video.Status != "ready" {
+		return nil, fmt.Errorf("無權限播放此影片")
+	}
+	if !canViewSharedVideo(s.RepoSlave, video, userID, shareSlug) {
+		return nil, fmt.Errorf("無權限播放此影片")
+	}
+	return video, nil
+}
+
+// IssuePlaybackToken 為有權限的用戶簽發播放憑證，shareSlug 為觀看不公開影片時的分享代碼
+func (s *VideoKeyService) IssuePlaybackToken(userID, videoID uint, shareSlug string) (*dto.VideoPlaybackTokenDTO, error) {
+	video, err := s.AuthorizePlayback(userID, videoID, shareSlug)
+	if err != nil {
+		return nil, err
+	}
+	if !video.Encrypted {
+		return nil, fmt.Errorf("影片未加密，不需要播放憑證")
+	}
+
+	// 僅在分享代碼確實授予觀看權限時簽入，擁有者或其他可見度的憑證不受分享連結變更影響
+	if !isShareSlugGrant(video, userID, shareSlug) {
+		shareSlug = ""
+	}
+
+	encryptionConf := s.Conf.Video.Encryption
+	expiresAt := time.Now().Add(time.Duration(encryptionConf.TokenTTL) * time.Second)
+
+	return &dto.VideoPlaybackTokenDTO{
+		Token:     SignPlaybackToken(encryptionConf.TokenSecret, videoID, userID, shareSlug, expiresAt),
+		KeyURL:    fmt.Sprintf("/api/videos/%d/key", videoID),
+		ExpiresAt: expiresAt,
+	}, nil
+}
+
+// VerifyPlaybackToken 以影片目前的分享代碼驗證播放憑證，回傳憑證所屬用戶與簽入的分享代碼
+func (s *VideoKeyService) VerifyPlaybackToken(token string, videoID uint) (uint, string, error) {
+	shareSlug := ""
+	if video, err := s.RepoSlave.FindVideoByID(videoID); err == nil && video.ShareSlug != nil {
+		shareSlug = *video.ShareSlug
+	}
+	return VerifyPlaybackToken(s.Conf.Video.Encryption.TokenSecret, token, videoID, shareSlug, time.Now())
+}
+
+// GetContentKey 取得並解密指定序號的內容金鑰
+func (s *VideoKeyService) GetContentKey(videoID uint, keyIndex int) ([]byte, error) {
+	key, err := s.RepoSlave.FindVideoEncryptionKey(videoID, keyIndex)
+	if err != nil {
+		return nil, fmt.Errorf("找不到內容金鑰: %v", err)
+	}
+
+	return decryptContentKey(s.Conf.Video.Encryption.KeySecret, key.Key)
+}
+
+// decryptContentKey 解密 converter 保存的十六進位金鑰，AES-128 金鑰固定為 16 位元組
+func decryptContentKey(secret, encrypted string) ([]byte, error) {
+	hexKey, err := utils.DecryptString(secret, encrypted)
+	if err != nil {
+		return nil, fmt.Errorf("解密內容金鑰失敗: %v", err)
+	}
+
+	key, err := hex.DecodeString(hexKey)
+	if err != nil || len(key) != 16 {
+		return nil, fmt.Errorf("內容金鑰格式錯誤")
+	}
+	return key, nil
+}
@@ -0,0 +1,364 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"stream-demo/backend/config"
+	"stream-demo/backend/database/models"
+	"stream-demo/backend/dto"
+	postgresqlRepo "stream-demo/backend/repositories/postgresql"
+	"stream-demo/backend/utils"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// 影片反應計數存於 Redis 雜湊 video:<id>:reactions，欄位為 like, dislike, emoji:<表情>
+const (
+	videoReactionsDirtyKey   = "video:reactions:dirty" // 計數有變動、待與資料庫對帳的影片ID
+	videoReactionsCounterTTL = 7 * 24 * time.Hour      // 計數閒置過期後，下次讀取時由資料庫重建
+	videoReactionsSyncBatch  = 200                     // 每次對帳最多處理的影片數
+	reactionEmojiFieldPrefix = "emoji:"
+)
+
+// ValidateReaction 檢查反應類型與表情，表情反應只接受設定中允許的表情
+func ValidateReaction(reactionType, emoji string, allowedEmojis []string) error {
+	switch reactionType {
+	case models.ReactionLike, models.ReactionDislike:
+		if emoji != "" {
+			return fmt.Errorf("喜歡或不喜歡不需要表情")
+		}
+		return nil
+	case models.ReactionEmoji:
+		for _, allowed := range allowedEmojis {
+			if emoji == allowed {
+				return nil
+			}
+		}
+		return fmt.Errorf("不支援的表情: %s", emoji)
+	default:
+		return fmt.Errorf("無效的反應類型: %s", reactionType)
+	}
+}
+
+// ReactionCounterField 反應在 Redis 計數雜湊中的欄位名稱
+func ReactionCounterField(reactionType, emoji string) string {
+	if reactionType == models.ReactionEmoji {
+		return reactionEmojiFieldPrefix + emoji
+	}
+	return reactionType
+}
+
+// videoReactionsKey 影片反應計數的 Redis 鍵
+func videoReactionsKey(videoID uint) string {
+	return fmt.Sprintf("video:%d:reactions", videoID)
+}
+
+// VideoReactionService 影片反應服務
+// 每位用戶對每部影片只保留一筆反應，即時計數存於 Redis，定期對帳寫回 videos.likes / dislikes
+type VideoReactionService struct {
+	Conf      *config.Config
+	Repo      *postgresqlRepo.PostgreSQLRepo
+	RepoSlave *postgresqlRepo.PostgreSQLRepo
+	stopChan  chan bool
+	ticker    *time.Ticker
+}
+
+// NewVideoReactionService 創建影片反應服務
+func NewVideoReactionService(conf *config.Config) *VideoReactionService {
+	return &VideoReactionService{
+		Conf:      conf,
+		Repo:      postgresqlRepo.NewPostgreSQLRepo(conf.DB["master"]),
+		RepoSlave: postgresqlRepo.NewPostgreSQLRepo(conf.DB["slave"]),
+		stopChan:  make(chan bool),
+	}
+}
+
+// SetReaction 設定用戶對影片的反應，重複送出相同反應不會重複計數，shareSlug 為觀看不公開影片時的分享代碼
+func (s *VideoReactionService) SetReaction(userID, videoID uint, reactionType, emoji, shareSlug string) (*dto.VideoReactionSummaryDTO, error) {
+	if err := ValidateReaction(reactionType, emoji, s.Conf.Video.Reactions.Emojis); err != nil {
+		return nil, err
+	}
+	if err := setVideoReaction(s.Repo, userID, videoID, reactionType, emoji, shareSlug); err != nil {
+		return nil, err
+	}
+	return s.GetSummary(userID, videoID, shareSlug)
+}
+
+// RemoveReaction 移除用戶對影片的反應，reactionType 不為空時只移除該類型
+func (s *VideoReactionService) RemoveReaction(userID, videoID uint, reactionType, shareSlug string) (*dto.VideoReactionSummaryDTO, error) {
+	if err := removeVideoReaction(s.Repo, userID, videoID, reactionType); err != nil {
+		return nil, err
+	}
+	return s.GetSummary(userID, videoID, shareSlug)
+}
+
+// GetSummary 獲取影片的反應數量與檢視者自己的反應
+func (s *VideoReactionService) GetSummary(viewerID, videoID uint, shareSlug string) (*dto.VideoReactionSummaryDTO, error) {
+	video, err := s.RepoSlave.FindVideoByID(videoID)
+	if err != nil || !canViewSharedVideo(s.RepoSlave, video, viewerID, shareSlug) {
+		return nil, fmt.Errorf("找不到影片: %v", ErrVideoNotVisible)
+	}
+
+	counts, err := loadReactionCounters(context.Background(), s.Repo, videoID)
+	if err != nil {
+		return nil, fmt.Errorf("獲取反應數量失敗: %v", err)
+	}
+
+	summary := &dto.VideoReactionSummaryDTO{
+		VideoID:  videoID,
+		Likes:    counts[models.ReactionLike],
+		Dislikes: counts[models.ReactionDislike],
+		Emojis:   map[string]int64{},
+	}
+	for field, count := range counts {
+		if emoji, ok := strings.CutPrefix(field, reactionEmojiFieldPrefix); ok && count > 0 {
+			summary.Emojis[emoji] = count
+		}
+	}
+
+	if reaction, err := s.Repo.FindVideoReaction(videoID, viewerID); err == nil {
+		summary.MyReaction = reaction.Type
+		summary.MyEmoji = reaction.Emoji
+		summary.LikedByMe = reaction.Type == models.ReactionLike
+	}
+	return summary, nil
+}
+
+// Start 啟動反應計數對帳
+func (s *VideoReactionService) Start() {
+	s.ticker = time.NewTicker(time.Duration(s.Conf.Video.Reactions.SyncInterval) * time.Second)
+
+	go func() {
+		for {
+			select {
+			case <-s.ticker.C:
+				s.Reconcile()
+			case <-s.stopChan:
+				s.ticker.Stop()
+				return
+			}
+		}
+	}()
+
+	utils.LogInfo("影片反應計數對帳服務已啟動")
+}
+
+// Stop 停止反應計數對帳
+func (s *VideoReactionService) Stop() {
+	close(s.stopChan)
+	utils.LogInfo("影片反應計數對帳服務已停止")
+}
+
+// Reconcile 以資料庫的反應記錄重算有變動影片的計數，寫回 Redis 與 videos.likes / dislikes
+func (s *VideoReactionService) Reconcile() {
+	client := utils.GetRedisClient()
+	if client == nil {
+		return
+	}
+	ctx := context.Background()
+
+	members, err := client.SPopN(ctx, videoReactionsDirtyKey, videoReactionsSyncBatch).Result()
+	if err != nil {
+		utils.LogError("獲取待對帳影片失敗: %v", err)
+		return
+	}
+
+	for _, member := range members {
+		videoID, err := strconv.ParseUint(member, 10, 32)
+		if err != nil {
+			continue
+		}
+		if err := syncReactionCounters(ctx, s.Repo, uint(videoID)); err != nil {
+			utils.LogError("影片反應計數對帳失敗: video=%d, %v", videoID, err)
+			// 放回待對帳集合，下次重試
+			client.SAdd(ctx, videoReactionsDirtyKey, member)
+		}
+	}
+
+	if len(members) > 0 {
+		utils.LogInfo("影片反應計數對帳完成: %d 部影片", len(members))
+	}
+}
+
+// setVideoReaction 寫入反應並調整 Redis 計數，可觀看的影片才能反應
+func setVideoReaction(repo *postgresqlRepo.PostgreSQLRepo, userID, videoID uint, reactionType, emoji, shareSlug string) error {
+	video, err := repo.FindVideoByID(videoID)
+	if err != nil || !canViewSharedVideo(repo, video, userID, shareSlug) {
+		return fmt.Errorf("找不到影片: %v", ErrVideoNotVisible)
+	}
+
+	previous, changed, err := repo.SetVideoReaction(videoID, userID, reactionType, emoji)
+	if err != nil {
+		return fmt.Errorf("更新反應失敗: %v", err)
+	}
+	if !changed {
+		return nil
+	}
+
+	deltas := map[string]int64{ReactionCounterField(reactionType, emoji): 1}
+	if previous != nil {
+		deltas[ReactionCounterField(previous.Type, previous.Emoji)] -= 1
+	}
+	adjustReactionCounters(repo, videoID, deltas)
+	return nil
+}
+
+// removeVideoReaction 移除反應並調整 Redis 計數，沒有反應時視為成功
+func removeVideoReaction(repo *postgresqlRepo.PostgreSQLRepo, userID, videoID uint, reactionType string) error {
+	removed, err := repo.DeleteVideoReaction(videoID, userID, reactionType)
+	if err != nil {
+		return fmt.Errorf("移除反應失敗: %v", err)
+	}
+	if removed == nil {
+		return nil
+	}
+
+	adjustReactionCounters(repo, videoID, map[string]int64{ReactionCounterField(removed.Type, removed.Emoji): -1})
+	return nil
+}
+
+// adjustReactionCounters 調整 Redis 計數並標記待對帳
+// 計數不存在（或未啟用 Redis）時直接由資料庫重算（已包含本次變更），Redis 失敗時交由下次變更或對帳修正
+func adjustReactionCounters(repo *postgresqlRepo.PostgreSQLRepo, videoID uint, deltas map[string]int64) {
+	ctx := context.Background()
+	client := utils.GetRedisClient()
+	if client == nil {
+		if err := syncReactionCounters(ctx, repo, videoID); err != nil {
+			utils.LogError("更新反應計數失敗: video=%d, %v", videoID, err)
+		}
+		return
+	}
+	key := videoReactionsKey(videoID)
+
+	exists, err := client.Exists(ctx, key).Result()
+	if err != nil {
+		utils.LogError("讀取反應計數失敗: video=%d, %v", videoID, err)
+		return
+	}
+	if exists == 0 {
+		if err := syncReactionCounters(ctx, repo, videoID); err != nil {
+			utils.LogError("重建反應計數失敗: video=%d, %v", videoID, err)
+		}
+		return
+	}
+
+	_, err = client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		for field, delta := range deltas {
+			if delta != 0 {
+				pipe.HIncrBy(ctx, key, field, delta)
+			}
+		}
+		pipe.Expire(ctx, key, videoReactionsCounterTTL)
+		pipe.SAdd(ctx, videoReactionsDirtyKey, videoID)
+		return nil
+	})
+	if err != nil {
+		utils.LogError("更新反應計數失敗: video=%d, %v", videoID, err)
+	}
+}
+
+// loadReactionCounters 讀取 Redis 計數，不存在時由資料庫重建
+func loadReactionCounters(ctx context.Context, repo *postgresqlRepo.PostgreSQLRepo, videoID uint) (map[string]int64, error) {
+	client := utils.GetRedisClient()
+	if client != nil {
+		values, err := client.HGetAll(ctx, videoReactionsKey(videoID)).Result()
+		if err == nil && len(values) > 0 {
+			counts := make(map[string]int64, len(values))
+			for field, value := range values {
+				counts[field], _ = strconv.ParseInt(value, 10, 64)
+			}
+			return counts, nil
+		}
+		if err != nil && !errors.Is(err, redis.Nil) {
+			utils.LogError("讀取反應計數失敗: video=%d, %v", videoID, err)
+		}
+	}
+
+	counts, err := countReactions(repo, videoID)
+	if err != nil {
+		return nil, err
+	}
+	if client != nil {
+		writeReactionCounters(ctx, client, videoID, counts)
+	}
+	return counts, nil
+}
+
+// syncReactionCounters 以資料庫重算計數，覆寫 Redis 並寫回影片的喜歡與不喜歡數
+func syncReactionCounters(ctx context.Context, repo *postgresqlRepo.PostgreSQLRepo, videoID uint) error {
+	counts, err := countReactions(repo, videoID)
+	if err != nil {
+		return err
+	}
+
+	if client := utils.GetRedisClient(); client != nil {
+		writeReactionCounters(ctx, client, videoID, counts)
+	}
+	return repo.UpdateVideoReactionTotals(videoID, counts[models.ReactionLike], counts[models.ReactionDislike])
+}
+
+// countReactions 由資料庫統計反應數量，喜歡與不喜歡固定有欄位
+func countReactions(repo *postgresqlRepo.PostgreSQLRepo, videoID uint) (map[string]int64, error) {
+	rows, err := repo.CountVideoReactions(videoID)
+	if err != nil {
+		return nil, err
+	}
+
+	counts := map[string]int64{models.ReactionLike: 0, models.ReactionDislike: 0}
+	for _, row := range rows {
+		counts[ReactionCounterField(row.Type, row.Emoji)] += row.Count
+	}
+	return counts, nil
+}
+
+// writeReactionCounters 覆寫 Redis 計數
+func writeReactionCounters(ctx context.Context, client *redis.Client, videoID uint, counts map[string]int64) {
+	key := videoReactionsKey(videoID)
+	values := make(map[string]interface{}, len(counts))
+	for field, count := range counts {
+		values[field] = count
+	}
+
+	_, err := client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(ctx, key)
+		pipe.HSet(ctx, key, values)
+		pipe.Expire(ctx, key, videoReactionsCounterTTL)
+		return nil
+	})
+	if err != nil {
+		utils.LogError("寫入反應計數失敗: video=%d, %v", videoID, err)
+	}
+}
+
+// applyViewerReactions 標記檢視者對列表中各影片的反應（liked_by_me）
+func applyViewerReactions(repo *postgresqlRepo.PostgreSQLRepo, viewerID uint, videos []*dto.VideoDTO) {
+	if viewerID == 0 || len(videos) == 0 {
+		return
+	}
+
+	videoIDs := make([]uint, len(videos))
+	for i, video := range videos {
+		videoIDs[i] = video.ID
+	}
+	reactions, err := repo.FindVideoReactionsByUser(viewerID, videoIDs)
+	if err != nil {
+		utils.LogError("獲取用戶反應失敗: user=%d, %v", viewerID, err)
+		return
+	}
+
+	byVideo := make(map[uint]*models.VideoReaction, len(reactions))
+	for i := range reactions {
+		byVideo[reactions[i].VideoID] = &reactions[i]
+	}
+	for _, video := range videos {
+		if reaction, ok := byVideo[video.ID]; ok {
+			video.MyReaction = reaction.Type
+			video.LikedByMe = reaction.Type == models.ReactionLike
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package services
+
+import (
+	"fmt"
+	"time"
+
+	"stream-demo/backend/dto"
+	postgresqlRepo "stream-demo/backend/repositories/postgresql"
+)
+
+// 搜尋預設與上限筆數
+const (
+	defaultSearchLimit = 20
+	maxSearchLimit     = 50
+)
+
+// searchDurationPresets 影片長度分類（分面輸出順序）
+var searchDurationPresets = []string{"short", "medium", "long"}
+
+// searchUploadDatePresets 上傳日期區間（分面輸出順序）
+var searchUploadDatePresets = []string{"today", "week", "month", "year"}
+
+// searchStatuses 可搜尋的影片狀態
+var searchStatuses = []string{"ready", "processing", "transcoding"}
+
+// SearchDurationRange 將長度分類轉為秒數範圍 [min, max)，max 為 0 表示不限
+func SearchDurationRange(preset string) (int, int) {
+	switch preset {
+	case "short":
+		return 0, postgresqlRepo.SearchShortDurationMax
+	case "medium":
+		return postgresqlRepo.SearchShortDurationMax, postgresqlRepo.SearchMediumDurationMax
+	case "long":
+		return postgresqlRepo.SearchMediumDurationMax, 0
+	default:
+		return 0, 0
+	}
+}
+
+// SearchUploadDateSince 將上傳日期區間轉為起始時間，未知區間回傳零值
+func SearchUploadDateSince(preset string, now time.Time) time.Time {
+	switch preset {
+	case "today":
+		return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	case "week":
+		return now.AddDate(0, 0, -7)
+	case "month":
+		return now.AddDate(0, -1, 0)
+	case "year":
+		return now.AddDate(-1, 0, 0)
+	default:
+		return time.Time{}
+	}
+}
+
+// SearchVideos 全文搜尋檢視者可見的影片，回傳分頁結果與分面統計
+// 未指定狀態時只搜尋已完成的影片
+func (s *VideoService) SearchVideos(viewerID uint, req *dto.VideoSearchDTO) (*dto.VideoSearchResultDTO, error) {
+	now := time.Now()
+	filter, err := newVideoSearchFilter(viewerID, req, now)
+	if err != nil {
+		return nil, err
+	}
+
+	videos, total, err := s.RepoSlave.SearchVideos(filter)
+	if err != nil {
+		return nil, fmt.Errorf("搜尋影片失敗: %v", err)
+	}
+
+	uploadDateSince := make(map[string]time.Time, len(searchUploadDatePresets))
+	for _, preset := range searchUploadDatePresets {
+		uploadDateSince[preset] = SearchUploadDateSince(preset, now)
+	}
+	facets, err := s.RepoSlave.SearchVideoFacets(filter, uploadDateSince)
+	if err != nil {
+		return nil, fmt.Errorf("統計搜尋分面失敗: %v", err)
+	}
+
+	result := &dto.VideoSearchResultDTO{
+		Total:  total,
+		Items:  make([]*dto.VideoDTO, len(videos)),
+		Facets: newVideoSearchFacetsDTO(facets),
+	}
+	for i := range videos {
+		result.Items[i] = newVideoDTO(&videos[i])
+	}
+	applyViewerReactions(s.RepoSlave, viewerID, result.Items)
+	return result, nil
+}
+
+// newVideoSearchFilter 將搜尋請求轉為資料庫查詢條件，明確指定的秒數範圍優先於長度分類
+func newVideoSearchFilter(viewerID uint, req *dto.VideoSearchDTO, now time.Time) (*postgresqlRepo.VideoSearchFilter, error) {
+	filter := &postgresqlRepo.VideoSearchFilter{
+		ViewerID:      viewerID,
+		Query:         req.Query,
+		Statuses:      []string{"ready"},
+		UploadedAfter: SearchUploadDateSince(req.UploadDate, now),
+		UserID:        req.UserID,
+		Sort:          req.Sort,
+		Offset:        req.Offset,
+		Limit:         req.Limit,
+	}
+
+	if req.Status != "" {
+		filter.Statuses = []string{req.Status}
+	}
+	filter.MinDuration, filter.MaxDuration = SearchDurationRange(req.Duration)
+	if req.MinDuration > 0 {
+		filter.MinDuration = req.MinDuration
+	}
+	if req.MaxDuration > 0 {
+		filter.MaxDuration = req.MaxDuration
+	}
+	if filter.MaxDuration > 0 && filter.MinDuration >= filter.MaxDuration {
+		return nil, fmt.Errorf("影片長度範圍無效")
+	}
+
+	if filter.Limit <= 0 {
+		filter.Limit = defaultSearchLimit
+	} else if filter.Limit > maxSearchLimit {
+		filter.Limit = maxSearchLimit
+	}
+	return filter, nil
+}
+
+// newVideoSearchFacetsDTO 依固定順序輸出分面，數量為 0 的選項也保留
+func newVideoSearchFacetsDTO(facets *postgresqlRepo.VideoSearchFacets) *dto.VideoSearchFacetsDTO {
+	facetsDTO := &dto.VideoSearchFacetsDTO{
+		Duration:   make([]dto.SearchFacetDTO, 0, len(searchDurationPresets)),
+		UploadDate: make([]dto.SearchFacetDTO, 0, len(searchUploadDatePresets)),
+		Status:     make([]dto.SearchFacetDTO, 0, len(searchStatuses)),
+		Creators:   make([]dto.CreatorFacetDTO, len(facets.Creators)),
+	}
+	for _, preset := range searchDurationPresets {
+		facetsDTO.Duration = append(facetsDTO.Duration, dto.SearchFacetDTO{Value: preset, Count: facets.Duration[preset]})
+	}
+	for _, preset := range searchUploadDatePresets {
+		facetsDTO.UploadDate = append(facetsDTO.UploadDate, dto.SearchFacetDTO{Value: preset, Count: facets.UploadDate[preset]})
+	}
+	for _, status := range searchStatuses {
+		facetsDTO.Status = append(facetsDTO.Status, dto.SearchFacetDTO{Value: status, Count: facets.Status[status]})
+	}
+	for i, creator := range facets.Creators {
+		facetsDTO.Creators[i] = dto.CreatorFacetDTO{
+			UserID:   creator.UserID,
+			Username: creator.Username,
+			Count:    creator.Count,
+		}
+	}
+	return facetsDTO
+}
@@ -0,0 +1,256 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"stream-demo/backend/config"
+	"stream-demo/backend/database/models"
+	"stream-demo/backend/dto"
+	"stream-demo/backend/pkg/storage"
+	postgresqlRepo "stream-demo/backend/repositories/postgresql"
+	"stream-demo/backend/utils"
+
+	"gorm.io/gorm"
+)
+
+// trashPurgeBatchSize 每次垃圾回收最多清除的影片數
+const trashPurgeBatchSize = 50
+
+// VideoTrashService 影片垃圾桶與儲存垃圾回收服務
+// 刪除影片時只做軟刪除，保留期內可還原；期滿後由背景垃圾回收清除 S3 檔案與記錄，
+// 並定期掃描轉碼輸出中已無對應記錄的孤兒目錄
+type VideoTrashService struct {
+	Conf      *config.Config
+	Repo      *postgresqlRepo.PostgreSQLRepo
+	RepoSlave *postgresqlRepo.PostgreSQLRepo
+	S3Storage *storage.S3Storage
+	stopChan  chan bool
+	gcTicker  *time.Ticker
+	scanTimer *time.Ticker
+}
+
+// NewVideoTrashService 創建影片垃圾桶服務
+func NewVideoTrashService(conf *config.Config, s3Storage *storage.S3Storage) *VideoTrashService {
+	return &VideoTrashService{
+		Conf:      conf,
+		Repo:      postgresqlRepo.NewPostgreSQLRepo(conf.DB["master"]),
+		RepoSlave: postgresqlRepo.NewPostgreSQLRepo(conf.DB["slave"]),
+		S3Storage: s3Storage,
+		stopChan:  make(chan bool),
+	}
+}
+
+// TrashPurgeAt 計算垃圾桶中的影片永久刪除的時間
+func TrashPurgeAt(deletedAt time.Time, retentionDays int) time.Time {
+	return deletedAt.AddDate(0, 0, retentionDays)
+}
+
+// ParseProcessedVideoPrefix 從轉碼輸出目錄（videos/processed/<userID>/<videoID>/）解析影片ID
+func ParseProcessedVideoPrefix(prefix string) (uint, bool) {
+	parts := strings.Split(strings.TrimSuffix(prefix, "/"), "/")
+	if len(parts) != 4 || parts[0] != "videos" || parts[1] != "processed" {
+		return 0, false
+	}
+	videoID, err := strconv.ParseUint(parts[3], 10, 32)
+	if err != nil {
+		return 0, false
+	}
+	return uint(videoID), true
+}
+
+// ListTrash 列出用戶垃圾桶中的影片
+func (s *VideoTrashService) ListTrash(userID uint) ([]*dto.TrashedVideoDTO, error) {
+	videos, err := s.RepoSlave.FindTrashedVideosByUserID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("獲取垃圾桶失敗: %v", err)
+	}
+
+	result := make([]*dto.TrashedVideoDTO, len(videos))
+	for i := range videos {
+		result[i] = s.newTrashedVideoDTO(&videos[i])
+	}
+	return result, nil
+}
+
+// RestoreVideo 從垃圾桶還原影片
+func (s *VideoTrashService) RestoreVideo(userID, videoID uint) (*dto.VideoDTO, error) {
+	video, err := s.Repo.FindTrashedVideo(videoID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("垃圾桶中沒有此影片")
+		}
+		return nil, fmt.Errorf("獲取影片失敗: %v", err)
+	}
+	if video.UserID != userID {
+		return nil, fmt.Errorf("無權限還原此影片")
+	}
+	// 保留期滿後垃圾回收可能正在清除檔案，不再允許還原
+	if time.Now().After(TrashPurgeAt(video.DeletedAt.Time, s.Conf.Video.Trash.RetentionDays)) {
+		return nil, fmt.Errorf("已超過保留期限，無法還原")
+	}
+
+	if err := s.Repo.RestoreVideo(videoID); err != nil {
+		return nil, fmt.Errorf("還原影片失敗: %v", err)
+	}
+
+	utils.LogInfo("影片已從垃圾桶還原: video=%d", videoID)
+
+	video.DeletedAt = gorm.DeletedAt{}
+	return newVideoDTO(video), nil
+}
+
+// Start 啟動垃圾回收與孤兒檔案掃描
+func (s *VideoTrashService) Start() {
+	trashConf := s.Conf.Video.Trash
+	s.gcTicker = time.NewTicker(time.Duration(trashConf.GCInterval) * time.Second)
+	s.scanTimer = time.NewTicker(time.Duration(trashConf.OrphanScanInterval) * time.Second)
+
+	go func() {
+		for {
+			select {
+			case <-s.gcTicker.C:
+				s.CollectGarbage()
+			case <-s.scanTimer.C:
+				if _, err := s.ScanOrphans(); err != nil {
+					utils.LogError("掃描孤兒檔案失敗: %v", err)
+				}
+			case <-s.stopChan:
+				s.gcTicker.Stop()
+				s.scanTimer.Stop()
+				return
+			}
+		}
+	}()
+
+	utils.LogInfo("影片垃圾回收服務已啟動")
+}
+
+// Stop 停止垃圾回收
+func (s *VideoTrashService) Stop() {
+	close(s.stopChan)
+	utils.LogInfo("影片垃圾回收服務已停止")
+}
+
+// CollectGarbage 清除超過保留期限的影片
+func (s *VideoTrashService) CollectGarbage() {
+	if s.S3Storage == nil {
+		return
+	}
+
+	before := time.Now().AddDate(0, 0, -s.Conf.Video.Trash.RetentionDays)
+	videos, err := s.Repo.FindExpiredTrashedVideos(before, trashPurgeBatchSize)
+	if err != nil {
+		utils.LogError("查詢待清除影片失敗: %v", err)
+		return
+	}
+
+	for i := range videos {
+		if err := s.purgeVideo(&videos[i]); err != nil {
+			// 保留記錄，下次執行時重試
+			utils.LogError("清除影片失敗: video=%d, %v", videos[i].ID, err)
+		}
+	}
+}
+
+// purgeVideo 刪除影片的原始檔、字幕原始檔與轉碼輸出後永久刪除記錄
+func (s *VideoTrashService) purgeVideo(video *models.Video) error {
+	deleted := 0
+
+	if video.OriginalKey != "" {
+		count, err := s.S3Storage.DeletePrefix(s.S3Storage.Bucket(), video.OriginalKey)
+		if err != nil {
+			return fmt.Errorf("刪除原始檔失敗: %v", err)
+		}
+		deleted += count
+	}
+
+	count, err := s.S3Storage.DeletePrefix(s.S3Storage.Bucket(), storage.CaptionSourcePrefix(video.ID))
+	if err != nil {
+		return fmt.Errorf("刪除字幕原始檔失敗: %v", err)
+	}
+	deleted += count
+
+	// 轉碼輸出可能由重複上傳的影片共用，只有最後一筆引用的記錄被清除時才刪除
+	owner := renditionsOwner(video)
+	references, err := s.Repo.CountRenditionReferences(owner, video.ID)
+	if err != nil {
+		return fmt.Errorf("檢查轉碼輸出引用失敗: %v", err)
+	}
+	if references == 0 {
+		count, err := s.S3Storage.DeletePrefix(storage.ProcessedBucket, storage.ProcessedVideoPrefix(video.UserID, owner))
+		if err != nil {
+			return fmt.Errorf("刪除轉碼輸出失敗: %v", err)
+		}
+		deleted += count
+	}
+
+	if err := s.Repo.PurgeVideo(video.ID); err != nil {
+		return fmt.Errorf("刪除影片記錄失敗: %v", err)
+	}
+
+	utils.LogInfo("影片已永久刪除: video=%d, 刪除檔案 %d 個", video.ID, deleted)
+	return nil
+}
+
+// ScanOrphans 掃描轉碼輸出中已無對應影片記錄的目錄，設定允許時一併刪除
+// 垃圾桶中的影片與共用轉碼輸出的影片仍視為有引用
+func (s *VideoTrashService) ScanOrphans() ([]string, error) {
+	if s.S3Storage == nil {
+		return nil, nil
+	}
+
+	userPrefixes, err := s.S3Storage.ListChildPrefixes(storage.ProcessedBucket, "videos/processed/")
+	if err != nil {
+		return nil, err
+	}
+
+	var orphans []string
+	for _, userPrefix := range userPrefixes {
+		videoPrefixes, err := s.S3Storage.ListChildPrefixes(storage.ProcessedBucket, userPrefix)
+		if err != nil {
+			return orphans, err
+		}
+
+		for _, prefix := range videoPrefixes {
+			videoID, ok := ParseProcessedVideoPrefix(prefix)
+			if !ok {
+				continue
+			}
+			references, err := s.Repo.CountRenditionReferences(videoID, 0)
+			if err != nil {
+				return orphans, fmt.Errorf("檢查影片記錄失敗: %v", err)
+			}
+			if references > 0 {
+				continue
+			}
+
+			orphans = append(orphans, prefix)
+			if !s.Conf.Video.Trash.DeleteOrphans {
+				utils.LogInfo("發現孤兒轉碼輸出: %s", prefix)
+				continue
+			}
+			count, err := s.S3Storage.DeletePrefix(storage.ProcessedBucket, prefix)
+			if err != nil {
+				utils.LogError("刪除孤兒轉碼輸出失敗: %s, %v", prefix, err)
+				continue
+			}
+			utils.LogInfo("已刪除孤兒轉碼輸出: %s, 檔案 %d 個", prefix, count)
+		}
+	}
+
+	utils.LogInfo("孤兒檔案掃描完成: 發現 %d 個", len(orphans))
+	return orphans, nil
+}
+
+// newTrashedVideoDTO 將垃圾桶中的影片轉換為 DTO
+func (s *VideoTrashService) newTrashedVideoDTO(video *models.Video) *dto.TrashedVideoDTO {
+	return &dto.TrashedVideoDTO{
+		VideoDTO:  newVideoDTO(video),
+		DeletedAt: video.DeletedAt.Time,
+		PurgeAt:   TrashPurgeAt(video.DeletedAt.Time, s.Conf.Video.Trash.RetentionDays),
+	}
+}
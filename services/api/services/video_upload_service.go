@@ -0,0 +1,206 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"stream-demo/backend/config"
+	"stream-demo/backend/database/models"
+	"stream-demo/backend/dto"
+	"stream-demo/backend/pkg/storage"
+	postgresqlRepo "stream-demo/backend/repositories/postgresql"
+	"stream-demo/backend/utils"
+)
+
+// 串流上傳錯誤
+var (
+	ErrVideoUploadTooLarge     = errors.New("檔案大小超過限制")
+	ErrUnsupportedVideoFormat  = errors.New("不支援的影片格式")
+	ErrVideoUploadUnconfigured = errors.New("S3服務未初始化")
+)
+
+// uploadProgressInterval 上傳進度推送的最短間隔
+const uploadProgressInterval = 500 * time.Millisecond
+
+// UploadProgressNotifier 上傳進度推送（由 WebSocket 處理器實作）
+type UploadProgressNotifier interface {
+	NotifyUploadProgress(userID uint, uploadID, event string, data interface{})
+}
+
+// VideoUploadService 伺服器端串流上傳服務
+// 提供無法使用預簽名 URL 的客戶端上傳，請求內容直接串流寫入 S3 multipart upload
+type VideoUploadService struct {
+	Conf      *config.Config
+	Repo      *postgresqlRepo.PostgreSQLRepo
+	S3Storage *storage.S3Storage
+
+	messaging *utils.RedisMessaging  // 多實例時經由 Redis 推送
+	notifier  UploadProgressNotifier // 未啟用 Redis 訊息時直接推送
+}
+
+// NewVideoUploadService 創建串流上傳服務
+func NewVideoUploadService(conf *config.Config, messaging *utils.RedisMessaging, s3Storage *storage.S3Storage) *VideoUploadService {
+	return &VideoUploadService{
+		Conf:      conf,
+		Repo:      postgresqlRepo.NewPostgreSQLRepo(conf.DB["master"]),
+		S3Storage: s3Storage,
+		messaging: messaging,
+	}
+}
+
+// SetProgressNotifier 設置上傳進度推送
+func (s *VideoUploadService) SetProgressNotifier(notifier UploadProgressNotifier) {
+	s.notifier = notifier
+}
+
+// UploadPercent 計算上傳百分比，total 為請求長度（含表單欄位），未知時回傳 -1
+// 完成前最多回報 99，避免 S3 完成前就顯示 100%
+func UploadPercent(bytes, total int64) int {
+	if total <= 0 {
+		return -1
+	}
+	percent := int(bytes * 100 / total)
+	if percent > 99 {
+		percent = 99
+	}
+	return percent
+}
+
+// StreamUploadVideo 將影片內容串流上傳到 S3，並建立待轉碼的影片記錄
+// total 為請求的 Content-Length，僅用於計算進度
+func (s *VideoUploadService) StreamUploadVideo(ctx context.Context, userID uint, uploadID, title, description, filename string, body io.Reader, total int64) (*dto.VideoStreamUploadDTO, error) {
+	if s.S3Storage == nil {
+		return nil, ErrVideoUploadUnconfigured
+	}
+
+	ext := strings.ToLower(filepath.Ext(filename))
+	if !isValidVideoFormat(ext) {
+		return nil, ErrUnsupportedVideoFormat
+	}
+
+	s.notify(userID, uploadID, "started", dto.UploadProgressDTO{UploadID: uploadID, Total: total, Percent: UploadPercent(0, total)})
+
+	uploadConf := s.Conf.Video.StreamUpload
+	limited := &sizeLimitReader{reader: body, remaining: s.Conf.Video.MaxFileSize}
+	result, err := s.S3Storage.UploadStream(
+		ctx,
+		storage.VideoOriginalKey(userID, ext),
+		limited,
+		uploadConf.PartSize,
+		uploadConf.Concurrency,
+		s.progressReporter(userID, uploadID, total),
+	)
+	if err != nil {
+		// S3 上傳器會包裝讀取錯誤，改以旗標判斷是否超過大小限制
+		if limited.exceeded {
+			err = fmt.Errorf("%w (%d bytes)", ErrVideoUploadTooLarge, s.Conf.Video.MaxFileSize)
+		}
+		s.notify(userID, uploadID, "failed", map[string]interface{}{"upload_id": uploadID, "error": err.Error()})
+		return nil, err
+	}
+
+	video := &models.Video{
+		Title:          title,
+		Description:    description,
+		UserID:         userID,
+		OriginalKey:    result.Key,
+		OriginalURL:    s.S3Storage.GenerateCDNURL(result.Key),
+		FileSize:       result.Size,
+		OriginalFormat: strings.TrimPrefix(ext, "."),
+		ContentSHA256:  result.SHA256,
+		Status:         "processing",
+
+		TranscodeLadder: s.Conf.Video.TranscodeLadder(),
+		Packaging:       s.Conf.Video.PackagingFormat(),
+		Encrypted:       s.Conf.Video.Encryption.Enabled,
+	}
+
+	// 同一用戶已有相同且完成的影片時暫不轉碼，等待用戶決定是否共用轉碼結果
+	if source, err := s.Repo.FindReadyVideoByContentSHA256(userID, result.SHA256, 0); err == nil {
+		video.Status = "duplicate"
+		video.DuplicateOfID = &source.ID
+		video.DuplicateMatch = DuplicateMatchSHA256
+	}
+
+	if err := s.Repo.CreateVideo(video); err != nil {
+		if delErr := s.S3Storage.DeleteFile(result.Key); delErr != nil {
+			utils.LogError("刪除未建立記錄的上傳檔案失敗: %s, %v", result.Key, delErr)
+		}
+		s.notify(userID, uploadID, "failed", map[string]interface{}{"upload_id": uploadID, "error": "創建影片記錄失敗"})
+		return nil, fmt.Errorf("創建影片記錄失敗: %v", err)
+	}
+
+	utils.LogInfo("串流上傳完成: video=%d, size=%d, sha256=%s", video.ID, result.Size, result.SHA256)
+
+	uploaded := &dto.VideoStreamUploadDTO{
+		UploadID: uploadID,
+		Video:    newVideoDTO(video),
+		Size:     result.Size,
+		SHA256:   result.SHA256,
+	}
+	s.notify(userID, uploadID, "completed", uploaded)
+	return uploaded, nil
+}
+
+// progressReporter 依固定間隔推送上傳進度
+// 由 S3 上傳器的讀取 goroutine 依序呼叫，不需加鎖
+func (s *VideoUploadService) progressReporter(userID uint, uploadID string, total int64) func(int64) {
+	var last time.Time
+	return func(bytes int64) {
+		if time.Since(last) < uploadProgressInterval {
+			return
+		}
+		last = time.Now()
+		s.notify(userID, uploadID, "progress", dto.UploadProgressDTO{
+			UploadID: uploadID,
+			Bytes:    bytes,
+			Total:    total,
+			Percent:  UploadPercent(bytes, total),
+		})
+	}
+}
+
+// notify 推送上傳事件，啟用 Redis 訊息時經由 Redis 送到所有實例，未設置推送時略過
+func (s *VideoUploadService) notify(userID uint, uploadID, event string, data interface{}) {
+	if s.messaging != nil {
+		if err := s.messaging.PublishUploadProgress(userID, uploadID, event, data); err != nil {
+			utils.LogError("推送上傳 %s 進度失敗: %v", uploadID, err)
+		}
+		return
+	}
+	if s.notifier != nil {
+		s.notifier.NotifyUploadProgress(userID, uploadID, event, data)
+	}
+}
+
+// sizeLimitReader 超過上限時回傳 ErrVideoUploadTooLarge，讓 S3 上傳器中止 multipart upload
+type sizeLimitReader struct {
+	reader    io.Reader
+	remaining int64
+	exceeded  bool
+}
+
+// Read 實作 io.Reader
+func (r *sizeLimitReader) Read(p []byte) (int, error) {
+	if r.remaining <= 0 {
+		var probe [1]byte
+		n, err := r.reader.Read(probe[:])
+		if n > 0 {
+			r.exceeded = true
+			return 0, ErrVideoUploadTooLarge
+		}
+		return 0, err
+	}
+
+	if int64(len(p)) > r.remaining {
+		p = p[:r.remaining]
+	}
+	n, err := r.reader.Read(p)
+	r.remaining -= int64(n)
+	return n, err
+}
@@ -0,0 +1,253 @@
+package services
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"time"
+
+	"stream-demo/backend/config"
+	"stream-demo/backend/database/models"
+	"stream-demo/backend/dto"
+	postgresqlRepo "stream-demo/backend/repositories/postgresql"
+	"stream-demo/backend/utils"
+)
+
+// ErrVideoNotVisible 檢視者無權觀看影片（對外與影片不存在同樣處理，不透露影片是否存在）
+var ErrVideoNotVisible = errors.New("影片不存在或無權限觀看")
+
+// shareSlugBytes 分享代碼的隨機位元組數（編碼後 22 個字元）
+const shareSlugBytes = 16
+
+// VideoVisibleTo 判斷檢視者能否以影片ID觀看影片
+// 不公開影片只能透過分享代碼觀看；私人影片需擁有者授權，限追隨者影片需追蹤擁有者，granted 表示檢視者符合該條件
+func VideoVisibleTo(visibility string, ownerID, viewerID uint, granted bool) bool {
+	if ownerID == viewerID {
+		return true
+	}
+	switch visibility {
+	case models.VideoVisibilityPublic, "":
+		return true
+	case models.VideoVisibilityPrivate, models.VideoVisibilityFollowers:
+		return granted && viewerID != 0
+	default:
+		return false
+	}
+}
+
+// IsValidVideoVisibility 檢查可見度是否有效
+func IsValidVideoVisibility(visibility string) bool {
+	switch visibility {
+	case models.VideoVisibilityPublic, models.VideoVisibilityUnlisted, models.VideoVisibilityPrivate, models.VideoVisibilityFollowers:
+		return true
+	}
+	return false
+}
+
+// canViewVideo 檢查檢視者能否觀看影片，私人影片時查詢授權，限追隨者影片時查詢追蹤關係
+func canViewVideo(repo *postgresqlRepo.PostgreSQLRepo, video *models.Video, viewerID uint) bool {
+	granted := false
+	if video.UserID != viewerID && viewerID != 0 {
+		switch video.Visibility {
+		case models.VideoVisibilityPrivate:
+			granted, _ = repo.HasVideoShareGrant(video.ID, viewerID)
+		case models.VideoVisibilityFollowers:
+			granted, _ = repo.IsFollowing(viewerID, video.UserID)
+		}
+	}
+	return VideoVisibleTo(video.Visibility, video.UserID, viewerID, granted)
+}
+
+// canViewSharedVideo 不公開影片需持有目前的分享代碼才能觀看，其餘依可見度判斷
+func canViewSharedVideo(repo *postgresqlRepo.PostgreSQLRepo, video *models.Video, viewerID uint, shareSlug string) bool {
+	return isShareSlugGrant(video, viewerID, shareSlug) || canViewVideo(repo, video, viewerID)
+}
+
+// isShareSlugGrant 檢視者是否由分享代碼取得不公開影片的觀看權限
+func isShareSlugGrant(video *models.Video, viewerID uint, shareSlug string) bool {
+	return video.UserID != viewerID && video.Visibility == models.VideoVisibilityUnlisted &&
+		video.ShareSlug != nil && shareSlug != "" &&
+		subtle.ConstantTimeCompare([]byte(*video.ShareSlug), []byte(shareSlug)) == 1
+}
+
+// VideoVisibilityService 影片可見度與分享服務
+type VideoVisibilityService struct {
+	Conf      *config.Config
+	Repo      *postgresqlRepo.PostgreSQLRepo
+	RepoSlave *postgresqlRepo.PostgreSQLRepo
+}
+
+// NewVideoVisibilityService 創建影片可見度服務
+func NewVideoVisibilityService(conf *config.Config) *VideoVisibilityService {
+	return &VideoVisibilityService{
+		Conf:      conf,
+		Repo:      postgresqlRepo.NewPostgreSQLRepo(conf.DB["master"]),
+		RepoSlave: postgresqlRepo.NewPostgreSQLRepo(conf.DB["slave"]),
+	}
+}
+
+// GetVisibility 獲取影片可見度與分享代碼（僅擁有者）
+func (s *VideoVisibilityService) GetVisibility(userID, videoID uint) (*dto.VideoVisibilityDTO, error) {
+	video, err := s.findOwnedVideo(userID, videoID)
+	if err != nil {
+		return nil, err
+	}
+	return newVideoVisibilityDTO(video), nil
+}
+
+// UpdateVisibility 更新影片可見度，改為不公開時產生分享代碼
+// 切換為其他可見度時保留分享代碼，再次改回不公開時沿用原連結
+func (s *VideoVisibilityService) UpdateVisibility(userID, videoID uint, visibility string) (*dto.VideoVisibilityDTO, error) {
+	if !IsValidVideoVisibility(visibility) {
+		return nil, fmt.Errorf("無效的可見度: %s", visibility)
+	}
+
+	video, err := s.findOwnedVideo(userID, videoID)
+	if err != nil {
+		return nil, err
+	}
+
+	updates := map[string]interface{}{
+		"visibility": visibility,
+		"updated_at": time.Now(),
+	}
+	if visibility == models.VideoVisibilityUnlisted && video.ShareSlug == nil {
+		slug, err := utils.RandomToken(shareSlugBytes)
+		if err != nil {
+			return nil, fmt.Errorf("產生分享代碼失敗: %v", err)
+		}
+		updates["share_slug"] = slug
+		video.ShareSlug = &slug
+	}
+
+	if err := s.Repo.UpdateVideoFields(videoID, updates); err != nil {
+		return nil, fmt.Errorf("更新可見度失敗: %v", err)
+	}
+
+	video.Visibility = visibility
+	return newVideoVisibilityDTO(video), nil
+}
+
+// RegenerateShareSlug 重新產生不公開影片的分享代碼，舊連結立即失效
+func (s *VideoVisibilityService) RegenerateShareSlug(userID, videoID uint) (*dto.VideoVisibilityDTO, error) {
+	video, err := s.findOwnedVideo(userID, videoID)
+	if err != nil {
+		return nil, err
+	}
+	if video.Visibility != models.VideoVisibilityUnlisted {
+		return nil, fmt.Errorf("只有不公開影片可以產生分享連結")
+	}
+
+	slug, err := utils.RandomToken(shareSlugBytes)
+	if err != nil {
+		return nil, fmt.Errorf("產生分享代碼失敗: %v", err)
+	}
+	if err := s.Repo.UpdateVideoFields(videoID, map[string]interface{}{
+		"share_slug": slug,
+		"updated_at": time.Now(),
+	}); err != nil {
+		return nil, fmt.Errorf("更新分享代碼失敗: %v", err)
+	}
+
+	video.ShareSlug = &slug
+	return newVideoVisibilityDTO(video), nil
+}
+
+// GetSharedVideo 以分享代碼觀看不公開影片
+func (s *VideoVisibilityService) GetSharedVideo(slug string) (*dto.VideoDTO, error) {
+	video, err := s.RepoSlave.FindVideoByShareSlug(slug)
+	if err != nil || video.Visibility != models.VideoVisibilityUnlisted || video.Status != "ready" {
+		return nil, ErrVideoNotVisible
+	}
+	return videoDetailDTO(s.RepoSlave, video), nil
+}
+
+// ListGrants 列出私人影片的授權用戶
+func (s *VideoVisibilityService) ListGrants(userID, videoID uint) ([]*dto.VideoShareGrantDTO, error) {
+	if _, err := s.findOwnedVideo(userID, videoID); err != nil {
+		return nil, err
+	}
+
+	grants, err := s.RepoSlave.FindVideoShareGrants(videoID)
+	if err != nil {
+		return nil, fmt.Errorf("獲取授權列表失敗: %v", err)
+	}
+
+	result := make([]*dto.VideoShareGrantDTO, len(grants))
+	for i := range grants {
+		result[i] = newVideoShareGrantDTO(&grants[i])
+	}
+	return result, nil
+}
+
+// GrantAccess 授權用戶觀看私人影片
+func (s *VideoVisibilityService) GrantAccess(userID, videoID, granteeID uint) (*dto.VideoShareGrantDTO, error) {
+	if userID == granteeID {
+		return nil, fmt.Errorf("不需要授權給自己")
+	}
+	if _, err := s.findOwnedVideo(userID, videoID); err != nil {
+		return nil, err
+	}
+
+	grantee, err := findExistingUser(s.Repo, granteeID)
+	if err != nil {
+		return nil, fmt.Errorf("找不到用戶: %v", err)
+	}
+
+	grant := &models.VideoShareGrant{VideoID: videoID, UserID: granteeID, CreatedAt: time.Now()}
+	if err := s.Repo.CreateVideoShareGrant(grant); err != nil {
+		return nil, fmt.Errorf("授權失敗: %v", err)
+	}
+
+	grant.User = grantee
+	return newVideoShareGrantDTO(grant), nil
+}
+
+// RevokeAccess 撤銷私人影片授權
+func (s *VideoVisibilityService) RevokeAccess(userID, videoID, granteeID uint) error {
+	if _, err := s.findOwnedVideo(userID, videoID); err != nil {
+		return err
+	}
+
+	if err := s.Repo.DeleteVideoShareGrant(videoID, granteeID); err != nil {
+		return fmt.Errorf("撤銷授權失敗: %v", err)
+	}
+	return nil
+}
+
+// findOwnedVideo 查找用戶擁有的影片
+func (s *VideoVisibilityService) findOwnedVideo(userID, videoID uint) (*models.Video, error) {
+	video, err := s.Repo.FindVideoByID(videoID)
+	if err != nil {
+		return nil, fmt.Errorf("找不到影片: %v", err)
+	}
+	if video.UserID != userID {
+		return nil, fmt.Errorf("無權限操作此影片")
+	}
+	return video, nil
+}
+
+// newVideoVisibilityDTO 轉換影片可見度資訊
+func newVideoVisibilityDTO(video *models.Video) *dto.VideoVisibilityDTO {
+	visibilityDTO := &dto.VideoVisibilityDTO{
+		VideoID:    video.ID,
+		Visibility: video.Visibility,
+	}
+	if video.Visibility == models.VideoVisibilityUnlisted && video.ShareSlug != nil {
+		visibilityDTO.ShareSlug = *video.ShareSlug
+		visibilityDTO.SharePath = "/api/videos/shared/" + *video.ShareSlug
+	}
+	return visibilityDTO
+}
+
+// newVideoShareGrantDTO 轉換私人影片授權
+func newVideoShareGrantDTO(grant *models.VideoShareGrant) *dto.VideoShareGrantDTO {
+	grantDTO := &dto.VideoShareGrantDTO{
+		UserID:    grant.UserID,
+		CreatedAt: grant.CreatedAt,
+	}
+	if grant.User != nil {
+		grantDTO.Username = grant.User.Username
+	}
+	return grantDTO
+}
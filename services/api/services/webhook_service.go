@@ -0,0 +1,785 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"stream-demo/backend/config"
+	"stream-demo/backend/database/models"
+	"stream-demo/backend/dto"
+	postgresqlRepo "stream-demo/backend/repositories/postgresql"
+	"stream-demo/backend/utils"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// 投遞佇列存於 Redis 有序集合，成員為投遞ID，分數為下次嘗試時間(毫秒)
+const (
+	webhookQueueKey          = "webhook:queue"
+	webhookEventKeyPrefix    = "webhook:event:" // 事件去重：每個實例都會收到同一則訊息，只由第一個實例建立投遞
+	webhookEventDedupTTL     = 24 * time.Hour
+	webhookDequeueBatch      = 100
+	webhookResponseBodyLimit = 1000
+	webhookErrorLimit        = 500
+	webhookPurgeInterval     = time.Hour
+	webhookDeliveryPageMax   = 100
+	webhookUserAgent         = "stream-demo-webhook/1.0"
+)
+
+var (
+	// ErrWebhookNotFound Webhook 端點不存在或不屬於該用戶
+	ErrWebhookNotFound = errors.New("Webhook 端點不存在")
+	// ErrWebhookDeliveryNotFound 投遞記錄不存在
+	ErrWebhookDeliveryNotFound = errors.New("投遞記錄不存在")
+)
+
+// SignWebhookPayload 計算投遞簽章：以端點金鑰對「時間戳.內容」做 HMAC-SHA256，格式為 sha256=<hex>
+func SignWebhookPayload(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// WebhookBackoff 第 attempt 次失敗後的重試等待時間：base * 2^(attempt-1)，不超過 max
+func WebhookBackoff(attempt int, base, max time.Duration) time.Duration {
+	delay := base
+	for i := 1; i < attempt; i++ {
+		delay *= 2
+		if delay >= max {
+			return max
+		}
+	}
+	if delay > max {
+		return max
+	}
+	return delay
+}
+
+// IsValidWebhookEvent 是否為可訂閱的事件類型
+func IsValidWebhookEvent(eventType string) bool {
+	for _, t := range models.WebhookEventTypes {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookEventForMessage 將 Redis 訊息對應到 Webhook 事件類型與事件所屬的用戶
+func WebhookEventForMessage(channel string, message *utils.Message) (string, uint, bool) {
+	var eventType string
+	var owner interface{}
+
+	switch channel {
+	case "video_processing":
+		switch message.Payload["status"] {
+		case "ready":
+			eventType = models.WebhookEventVideoReady
+		case "failed":
+			eventType = models.WebhookEventVideoFailed
+		}
+		owner = message.Payload["user_id"]
+	case "live_updates":
+		if _, ok := message.Payload["room_id"].(string); !ok {
+			return "", 0, false
+		}
+		switch message.Type {
+		case "live_started":
+			eventType = models.WebhookEventLiveStarted
+		case "live_ended":
+			eventType = models.WebhookEventLiveEnded
+		}
+		owner = message.Payload["creator_id"]
+	case "payment_events":
+		if message.Type == "completed" {
+			eventType = models.WebhookEventPaymentCompleted
+		}
+		owner = message.Payload["user_id"]
+	}
+
+	ownerID, ok := owner.(float64)
+	if eventType == "" || !ok || ownerID <= 0 {
+		return "", 0, false
+	}
+	return eventType, uint(ownerID), true
+}
+
+// WebhookService 對外 Webhook 服務：訂閱平台事件，簽章後排入 Redis 佇列投遞並以指數退避重試
+type WebhookService struct {
+	Conf      *config.Config
+	Repo      *postgresqlRepo.PostgreSQLRepo
+	RepoSlave *postgresqlRepo.PostgreSQLRepo
+
+	client    *http.Client
+	lastPurge time.Time
+	stopChan  chan struct{}
+	ticker    *time.Ticker
+}
+
+// NewWebhookService 創建 Webhook 服務，啟用 Redis 訊息時訂閱影片、直播與支付事件
+func NewWebhookService(conf *config.Config, messaging *utils.RedisMessaging) *WebhookService {
+	s := &WebhookService{
+		Conf:      conf,
+		Repo:      postgresqlRepo.NewPostgreSQLRepo(conf.DB["master"]),
+		RepoSlave: postgresqlRepo.NewPostgreSQLRepo(conf.DB["slave"]),
+		client:    newWebhookHTTPClient(conf.Webhook),
+		stopChan:  make(chan struct{}),
+	}
+
+	if messaging != nil {
+		for _, channel := range []string{"video_processing", "live_updates", "payment_events"} {
+			if err := messaging.Subscribe(channel, s.handleMessage); err != nil {
+				utils.LogError("Webhook 訂閱頻道 %s 失敗: %v", channel, err)
+			}
+		}
+	}
+
+	return s
+}
+
+// Start 啟動投遞佇列處理
+func (s *WebhookService) Start() {
+	interval := time.Duration(s.Conf.Webhook.PollInterval) * time.Second
+	s.ticker = time.NewTicker(interval)
+
+	go func() {
+		s.requeuePending()
+		for {
+			select {
+			case <-s.ticker.C:
+				s.ProcessDueDeliveries()
+				s.purgeExpired()
+			case <-s.stopChan:
+				s.ticker.Stop()
+				return
+			}
+		}
+	}()
+
+	utils.LogInfo("Webhook 服務已啟動，檢查間隔: %v", interval)
+}
+
+// Stop 停止 Webhook 服務
+func (s *WebhookService) Stop() {
+	close(s.stopChan)
+	utils.LogInfo("Webhook 服務已停止")
+}
+
+// CreateEndpoint 註冊 Webhook 端點，回傳內容包含簽章金鑰（之後不再顯示）
+func (s *WebhookService) CreateEndpoint(userID uint, isAdmin bool, req *dto.WebhookEndpointCreateDTO) (*dto.WebhookEndpointDTO, error) {
+	if err := validateWebhookURL(req.URL); err != nil {
+		return nil, err
+	}
+	events, err := normalizeWebhookEvents(req.Events)
+	if err != nil {
+		return nil, err
+	}
+	if req.AllUsers && !isAdmin {
+		return nil, fmt.Errorf("只有管理員可以接收所有用戶的事件")
+	}
+
+	count, err := s.Repo.CountWebhookEndpointsByUserID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("獲取 Webhook 端點失敗: %v", err)
+	}
+	if count >= int64(s.Conf.Webhook.MaxEndpoints) {
+		return nil, fmt.Errorf("最多只能註冊 %d 個 Webhook 端點", s.Conf.Webhook.MaxEndpoints)
+	}
+
+	secret, err := generateWebhookSecret()
+	if err != nil {
+		return nil, err
+	}
+	endpoint := &models.WebhookEndpoint{
+		UserID:      userID,
+		URL:         req.URL,
+		Secret:      secret,
+		Events:      events,
+		AllUsers:    req.AllUsers,
+		Description: req.Description,
+		Enabled:     true,
+	}
+	if err := s.Repo.CreateWebhookEndpoint(endpoint); err != nil {
+		return nil, fmt.Errorf("建立 Webhook 端點失敗: %v", err)
+	}
+
+	result := toWebhookEndpointDTO(endpoint)
+	result.Secret = endpoint.Secret
+	return result, nil
+}
+
+// ListEndpoints 列出用戶的 Webhook 端點
+func (s *WebhookService) ListEndpoints(userID uint) ([]*dto.WebhookEndpointDTO, error) {
+	endpoints, err := s.RepoSlave.FindWebhookEndpointsByUserID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("獲取 Webhook 端點失敗: %v", err)
+	}
+
+	result := make([]*dto.WebhookEndpointDTO, len(endpoints))
+	for i := range endpoints {
+		result[i] = toWebhookEndpointDTO(&endpoints[i])
+	}
+	return result, nil
+}
+
+// GetEndpoint 獲取 Webhook 端點，管理員可查看所有端點
+func (s *WebhookService) GetEndpoint(userID uint, isAdmin bool, endpointID uint) (*dto.WebhookEndpointDTO, error) {
+	endpoint, err := s.findEndpoint(s.RepoSlave, userID, isAdmin, endpointID)
+	if err != nil {
+		return nil, err
+	}
+	return toWebhookEndpointDTO(endpoint), nil
+}
+
+// UpdateEndpoint 更新 Webhook 端點
+func (s *WebhookService) UpdateEndpoint(userID uint, isAdmin bool, endpointID uint, req *dto.WebhookEndpointUpdateDTO) (*dto.WebhookEndpointDTO, error) {
+	endpoint, err := s.findEndpoint(s.Repo, userID, isAdmin, endpointID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.URL != nil {
+		if err := validateWebhookURL(*req.URL); err != nil {
+			return nil, err
+		}
+		endpoint.URL = *req.URL
+	}
+	if req.Events != nil {
+		events, err := normalizeWebhookEvents(req.Events)
+		if err != nil {
+			return nil, err
+		}
+		endpoint.Events = events
+	}
+	if req.AllUsers != nil {
+		if *req.AllUsers && !isAdmin {
+			return nil, fmt.Errorf("只有管理員可以接收所有用戶的事件")
+		}
+		endpoint.AllUsers = *req.AllUsers
+	}
+	if req.Description != nil {
+		endpoint.Description = *req.Description
+	}
+	if req.Enabled != nil {
+		endpoint.Enabled = *req.Enabled
+	}
+
+	if err := s.Repo.UpdateWebhookEndpoint(endpoint); err != nil {
+		return nil, fmt.Errorf("更新 Webhook 端點失敗: %v", err)
+	}
+	return toWebhookEndpointDTO(endpoint), nil
+}
+
+// DeleteEndpoint 刪除 Webhook 端點與其投遞記錄，佇列中的投遞會在取出時略過
+func (s *WebhookService) DeleteEndpoint(userID uint, isAdmin bool, endpointID uint) error {
+	endpoint, err := s.findEndpoint(s.Repo, userID, isAdmin, endpointID)
+	if err != nil {
+		return err
+	}
+	if err := s.Repo.DeleteWebhookEndpoint(endpoint.ID); err != nil {
+		return fmt.Errorf("刪除 Webhook 端點失敗: %v", err)
+	}
+	return nil
+}
+
+// RotateSecret 更換簽章金鑰，回傳內容包含新金鑰
+func (s *WebhookService) RotateSecret(userID uint, isAdmin bool, endpointID uint) (*dto.WebhookEndpointDTO, error) {
+	endpoint, err := s.findEndpoint(s.Repo, userID, isAdmin, endpointID)
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := generateWebhookSecret()
+	if err != nil {
+		return nil, err
+	}
+	endpoint.Secret = secret
+	if err := s.Repo.UpdateWebhookEndpoint(endpoint); err != nil {
+		return nil, fmt.Errorf("更換金鑰失敗: %v", err)
+	}
+
+	result := toWebhookEndpointDTO(endpoint)
+	result.Secret = endpoint.Secret
+	return result, nil
+}
+
+// ListDeliveries 分頁列出端點的投遞記錄，新的在前
+func (s *WebhookService) ListDeliveries(userID uint, isAdmin bool, endpointID uint, offset, limit int) ([]*dto.WebhookDeliveryDTO, int64, error) {
+	if _, err := s.findEndpoint(s.RepoSlave, userID, isAdmin, endpointID); err != nil {
+		return nil, 0, err
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	if limit <= 0 || limit > webhookDeliveryPageMax {
+		limit = 20
+	}
+
+	deliveries, total, err := s.RepoSlave.FindWebhookDeliveries(endpointID, offset, limit)
+	if err != nil {
+		return nil, 0, fmt.Errorf("獲取投遞記錄失敗: %v", err)
+	}
+
+	result := make([]*dto.WebhookDeliveryDTO, len(deliveries))
+	for i := range deliveries {
+		result[i] = toWebhookDeliveryDTO(&deliveries[i], false)
+	}
+	return result, total, nil
+}
+
+// GetDelivery 獲取投遞記錄與投遞內容
+func (s *WebhookService) GetDelivery(userID uint, isAdmin bool, endpointID, deliveryID uint) (*dto.WebhookDeliveryDTO, error) {
+	delivery, err := s.findDelivery(s.RepoSlave, userID, isAdmin, endpointID, deliveryID)
+	if err != nil {
+		return nil, err
+	}
+	return toWebhookDeliveryDTO(delivery, true), nil
+}
+
+// Redeliver 以相同事件ID與內容建立新的投遞並立即排入佇列
+func (s *WebhookService) Redeliver(userID uint, isAdmin bool, endpointID, deliveryID uint) (*dto.WebhookDeliveryDTO, error) {
+	original, err := s.findDelivery(s.Repo, userID, isAdmin, endpointID, deliveryID)
+	if err != nil {
+		return nil, err
+	}
+	client := utils.GetRedisClient()
+	if client == nil {
+		return nil, fmt.Errorf("未啟用 Redis，無法投遞 Webhook")
+	}
+
+	now := time.Now()
+	delivery := &models.WebhookDelivery{
+		EndpointID:    original.EndpointID,
+		EventID:       original.EventID,
+		EventType:     original.EventType,
+		Payload:       original.Payload,
+		Status:        models.WebhookDeliveryPending,
+		NextAttemptAt: &now,
+		RedeliveryOf:  &original.ID,
+	}
+	if err := s.Repo.CreateWebhookDeliveries([]*models.WebhookDelivery{delivery}); err != nil {
+		return nil, fmt.Errorf("建立投遞失敗: %v", err)
+	}
+	s.enqueue(context.Background(), client, delivery.ID, now)
+
+	return toWebhookDeliveryDTO(delivery, true), nil
+}
+
+// ProcessDueDeliveries 取出到期的投遞並發送，以 ZREM 搶佔避免多實例重複投遞
+func (s *WebhookService) ProcessDueDeliveries() {
+	client := utils.GetRedisClient()
+	if client == nil {
+		return
+	}
+
+	ctx := context.Background()
+	members, err := client.ZRangeByScore(ctx, webhookQueueKey, &redis.ZRangeBy{
+		Min:   "-inf",
+		Max:   strconv.FormatInt(time.Now().UnixMilli(), 10),
+		Count: webhookDequeueBatch,
+	}).Result()
+	if err != nil {
+		utils.LogError("讀取 Webhook 投遞佇列失敗: %v", err)
+		return
+	}
+
+	sem := make(chan struct{}, s.Conf.Webhook.Concurrency)
+	var wg sync.WaitGroup
+	for _, member := range members {
+		removed, err := client.ZRem(ctx, webhookQueueKey, member).Result()
+		if err != nil || removed == 0 {
+			continue
+		}
+		deliveryID, err := strconv.ParseUint(member, 10, 32)
+		if err != nil {
+			continue
+		}
+
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(id uint) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			s.Deliver(id)
+		}(uint(deliveryID))
+	}
+	wg.Wait()
+}
+
+// handleMessage 處理平台事件訊息，建立投遞並排入佇列
+func (s *WebhookService) handleMessage(channel string, payload []byte) error {
+	var message utils.Message
+	if err := utils.UnmarshalMessage(payload, &message); err != nil {
+		return err
+	}
+
+	eventType, ownerID, ok := WebhookEventForMessage(channel, &message)
+	if !ok {
+		return nil
+	}
+	client := utils.GetRedisClient()
+	if client == nil {
+		return nil
+	}
+
+	ctx := context.Background()
+	first, err := client.SetNX(ctx, webhookEventKeyPrefix+message.ID, 1, webhookEventDedupTTL).Result()
+	if err != nil || !first {
+		return err
+	}
+
+	s.dispatch(ctx, client, message.ID, eventType, ownerID, message.Timestamp, message.Payload)
+	return nil
+}
+
+// dispatch 為訂閱該事件的端點建立投遞
+func (s *WebhookService) dispatch(ctx context.Context, client *redis.Client, eventID, eventType string, ownerID uint, occurredAt time.Time, data map[string]interface{}) {
+	endpoints, err := s.Repo.FindWebhookEndpointsForOwner(ownerID)
+	if err != nil {
+		utils.LogError("查詢 Webhook 端點失敗: %v", err)
+		return
+	}
+
+	body, err := json.Marshal(dto.WebhookEventDTO{
+		ID:        eventID,
+		Type:      eventType,
+		CreatedAt: occurredAt,
+		Data:      data,
+	})
+	if err != nil {
+		utils.LogError("序列化 Webhook 事件失敗: %v", err)
+		return
+	}
+
+	now := time.Now()
+	var deliveries []*models.WebhookDelivery
+	for _, endpoint := range endpoints {
+		if !webhookSubscribes(endpoint.Events, eventType) {
+			continue
+		}
+		deliveries = append(deliveries, &models.WebhookDelivery{
+			EndpointID:    endpoint.ID,
+			EventID:       eventID,
+			EventType:     eventType,
+			Payload:       string(body),
+			Status:        models.WebhookDeliveryPending,
+			NextAttemptAt: &now,
+		})
+	}
+	if err := s.Repo.CreateWebhookDeliveries(deliveries); err != nil {
+		utils.LogError("建立 Webhook 投遞失敗: %v", err)
+		return
+	}
+
+	for _, delivery := range deliveries {
+		s.enqueue(ctx, client, delivery.ID, now)
+	}
+}
+
+// Deliver 發送一次投遞，失敗時依指數退避重新排入佇列，次數用盡後標記為失敗
+// 下次重試時間同時保存在資料庫，佇列遺失時由啟動時的 requeuePending 補回
+func (s *WebhookService) Deliver(deliveryID uint) {
+	delivery, err := s.Repo.FindWebhookDeliveryByID(deliveryID)
+	if err != nil || delivery.Status != models.WebhookDeliveryPending {
+		return
+	}
+
+	endpoint, err := s.Repo.FindWebhookEndpointByID(delivery.EndpointID)
+	if err != nil || !endpoint.Enabled {
+		delivery.Status = models.WebhookDeliveryFailed
+		delivery.Error = "端點已停用或已刪除"
+		delivery.NextAttemptAt = nil
+		if err := s.Repo.UpdateWebhookDelivery(delivery); err != nil {
+			utils.LogError("更新 Webhook 投遞 %d 失敗: %v", delivery.ID, err)
+		}
+		return
+	}
+
+	statusCode, responseBody, sendErr := s.send(endpoint, delivery)
+	now := time.Now()
+	delivery.Attempts++
+	delivery.ResponseStatus = statusCode
+	delivery.ResponseBody = truncateRunes(responseBody, webhookResponseBodyLimit)
+
+	var retryAt *time.Time
+	switch {
+	case sendErr == nil && statusCode >= 200 && statusCode < 300:
+		delivery.Status = models.WebhookDeliverySucceeded
+		delivery.Error = ""
+		delivery.DeliveredAt = &now
+		delivery.NextAttemptAt = nil
+	default:
+		if sendErr != nil {
+			delivery.Error = truncateRunes(sendErr.Error(), webhookErrorLimit)
+		} else {
+			delivery.Error = fmt.Sprintf("端點回應 %d", statusCode)
+		}
+		if delivery.Attempts >= s.Conf.Webhook.MaxAttempts {
+			delivery.Status = models.WebhookDeliveryFailed
+			delivery.NextAttemptAt = nil
+		} else {
+			base := time.Duration(s.Conf.Webhook.BackoffBase) * time.Second
+			max := time.Duration(s.Conf.Webhook.BackoffMax) * time.Second
+			next := now.Add(WebhookBackoff(delivery.Attempts, base, max))
+			delivery.NextAttemptAt = &next
+			retryAt = &next
+		}
+	}
+
+	if err := s.Repo.UpdateWebhookDelivery(delivery); err != nil {
+		utils.LogError("更新 Webhook 投遞 %d 失敗: %v", delivery.ID, err)
+		return
+	}
+	if client := utils.GetRedisClient(); client != nil && retryAt != nil {
+		s.enqueue(context.Background(), client, delivery.ID, *retryAt)
+	}
+}
+
+// send 以 POST 發送投遞內容並附上簽章標頭，回傳狀態碼與截斷後的回應內容
+func (s *WebhookService) send(endpoint *models.WebhookEndpoint, delivery *models.WebhookDelivery) (int, string, error) {
+	body := []byte(delivery.Payload)
+	timestamp := time.Now().Unix()
+
+	req, err := http.NewRequest(http.MethodPost, endpoint.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", webhookUserAgent)
+	req.Header.Set("X-Webhook-Event", delivery.EventType)
+	req.Header.Set("X-Webhook-Event-ID", delivery.EventID)
+	req.Header.Set("X-Webhook-Delivery", strconv.FormatUint(uint64(delivery.ID), 10))
+	req.Header.Set("X-Webhook-Timestamp", strconv.FormatInt(timestamp, 10))
+	req.Header.Set("X-Webhook-Signature", SignWebhookPayload(endpoint.Secret, timestamp, body))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, "", err
+	}
+	defer resp.Body.Close()
+
+	responseBody, _ := io.ReadAll(io.LimitReader(resp.Body, webhookResponseBodyLimit))
+	return resp.StatusCode, string(responseBody), nil
+}
+
+// enqueue 排入投遞佇列
+func (s *WebhookService) enqueue(ctx context.Context, client *redis.Client, deliveryID uint, at time.Time) {
+	member := strconv.FormatUint(uint64(deliveryID), 10)
+	if err := client.ZAdd(ctx, webhookQueueKey, redis.Z{Score: float64(at.UnixMilli()), Member: member}).Err(); err != nil {
+		utils.LogError("排入 Webhook 投遞 %d 失敗: %v", deliveryID, err)
+	}
+}
+
+// requeuePending 啟動時將資料庫中等待投遞的記錄重新排入佇列，補回 Redis 遺失或取出後中斷的投遞
+func (s *WebhookService) requeuePending() {
+	client := utils.GetRedisClient()
+	if client == nil {
+		return
+	}
+
+	deliveries, err := s.Repo.FindPendingWebhookDeliveries()
+	if err != nil {
+		utils.LogError("查詢等待投遞的 Webhook 失敗: %v", err)
+		return
+	}
+
+	ctx := context.Background()
+	now := time.Now()
+	for _, delivery := range deliveries {
+		at := now
+		if delivery.NextAttemptAt != nil {
+			at = *delivery.NextAttemptAt
+		}
+		s.enqueue(ctx, client, delivery.ID, at)
+	}
+}
+
+// purgeExpired 每小時清除超過保留天數的投遞記錄
+func (s *WebhookService) purgeExpired() {
+	if time.Since(s.lastPurge) < webhookPurgeInterval {
+		return
+	}
+	s.lastPurge = time.Now()
+
+	before := time.Now().AddDate(0, 0, -s.Conf.Webhook.RetentionDays)
+	deleted, err := s.Repo.DeleteWebhookDeliveriesBefore(before)
+	if err != nil {
+		utils.LogError("清除過期 Webhook 投遞記錄失敗: %v", err)
+		return
+	}
+	if deleted > 0 {
+		utils.LogInfo("已清除 %d 筆過期 Webhook 投遞記錄", deleted)
+	}
+}
+
+// findEndpoint 查找端點，非管理員只能存取自己的端點
+func (s *WebhookService) findEndpoint(repo *postgresqlRepo.PostgreSQLRepo, userID uint, isAdmin bool, endpointID uint) (*models.WebhookEndpoint, error) {
+	endpoint, err := repo.FindWebhookEndpointByID(endpointID)
+	if err != nil || (!isAdmin && endpoint.UserID != userID) {
+		return nil, ErrWebhookNotFound
+	}
+	return endpoint, nil
+}
+
+// findDelivery 查找端點下的投遞記錄
+func (s *WebhookService) findDelivery(repo *postgresqlRepo.PostgreSQLRepo, userID uint, isAdmin bool, endpointID, deliveryID uint) (*models.WebhookDelivery, error) {
+	if _, err := s.findEndpoint(repo, userID, isAdmin, endpointID); err != nil {
+		return nil, err
+	}
+	delivery, err := repo.FindWebhookDeliveryByID(deliveryID)
+	if err != nil || delivery.EndpointID != endpointID {
+		return nil, ErrWebhookDeliveryNotFound
+	}
+	return delivery, nil
+}
+
+// newWebhookHTTPClient 投遞用 HTTP 客戶端：不跟隨重新導向，預設拒絕連線到內部位址
+func newWebhookHTTPClient(conf config.WebhookConfiguration) *http.Client {
+	dialer := &net.Dialer{Timeout: 5 * time.Second}
+	if !conf.AllowPrivateNetworks {
+		dialer.Control = rejectPrivateAddress
+	}
+
+	return &http.Client{
+		Timeout: time.Duration(conf.Timeout) * time.Second,
+		Transport: &http.Transport{
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: 5 * time.Second,
+			MaxIdleConnsPerHost: 2,
+		},
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// rejectPrivateAddress 在連線前檢查解析後的位址，避免 Webhook 被用來存取內網或本機服務
+func rejectPrivateAddress(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsMulticast() {
+		return fmt.Errorf("不允許投遞到內部位址 %s", host)
+	}
+	return nil
+}
+
+// validateWebhookURL 檢查端點網址，只接受 http 與 https
+func validateWebhookURL(raw string) error {
+	parsed, err := url.Parse(raw)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Hostname() == "" {
+		return fmt.Errorf("無效的 Webhook 網址")
+	}
+	if parsed.User != nil {
+		return fmt.Errorf("Webhook 網址不能包含帳號密碼")
+	}
+	return nil
+}
+
+// normalizeWebhookEvents 檢查並去除重複的事件類型，回傳逗號分隔字串
+func normalizeWebhookEvents(events []string) (string, error) {
+	seen := make(map[string]bool, len(events))
+	normalized := make([]string, 0, len(events))
+	for _, event := range events {
+		event = strings.TrimSpace(event)
+		if !IsValidWebhookEvent(event) {
+			return "", fmt.Errorf("不支援的事件類型: %s", event)
+		}
+		if !seen[event] {
+			seen[event] = true
+			normalized = append(normalized, event)
+		}
+	}
+	if len(normalized) == 0 {
+		return "", fmt.Errorf("至少需要訂閱一種事件")
+	}
+	return strings.Join(normalized, ","), nil
+}
+
+// webhookSubscribes 端點是否訂閱該事件
+func webhookSubscribes(events, eventType string) bool {
+	for _, event := range strings.Split(events, ",") {
+		if event == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// generateWebhookSecret 產生簽章金鑰
+func generateWebhookSecret() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("產生金鑰失敗: %v", err)
+	}
+	return "whsec_" + hex.EncodeToString(buf), nil
+}
+
+// truncateRunes 截斷為最多 limit 個字元並移除無效的 UTF-8
+func truncateRunes(value string, limit int) string {
+	value = strings.ToValidUTF8(value, "")
+	runes := []rune(value)
+	if len(runes) <= limit {
+		return value
+	}
+	return string(runes[:limit])
+}
+
+// toWebhookEndpointDTO 轉換為端點 DTO（不含金鑰）
+func toWebhookEndpointDTO(endpoint *models.WebhookEndpoint) *dto.WebhookEndpointDTO {
+	return &dto.WebhookEndpointDTO{
+		ID:          endpoint.ID,
+		UserID:      endpoint.UserID,
+		URL:         endpoint.URL,
+		Events:      strings.Split(endpoint.Events, ","),
+		AllUsers:    endpoint.AllUsers,
+		Description: endpoint.Description,
+		Enabled:     endpoint.Enabled,
+		CreatedAt:   endpoint.CreatedAt,
+		UpdatedAt:   endpoint.UpdatedAt,
+	}
+}
+
+// toWebhookDeliveryDTO 轉換為投遞 DTO，withPayload 時附上投遞內容
+func toWebhookDeliveryDTO(delivery *models.WebhookDelivery, withPayload bool) *dto.WebhookDeliveryDTO {
+	result := &dto.WebhookDeliveryDTO{
+		ID:             delivery.ID,
+		EndpointID:     delivery.EndpointID,
+		EventID:        delivery.EventID,
+		EventType:      delivery.EventType,
+		Status:         delivery.Status,
+		Attempts:       delivery.Attempts,
+		ResponseStatus: delivery.ResponseStatus,
+		ResponseBody:   delivery.ResponseBody,
+		Error:          delivery.Error,
+		NextAttemptAt:  delivery.NextAttemptAt,
+		DeliveredAt:    delivery.DeliveredAt,
+		RedeliveryOf:   delivery.RedeliveryOf,
+		CreatedAt:      delivery.CreatedAt,
+	}
+	if withPayload && delivery.Payload != "" {
+		result.Payload = json.RawMessage(delivery.Payload)
+	}
+	return result
+}
@@ -0,0 +1,63 @@
+package test
+
+import (
+	"testing"
+
+	"stream-demo/backend/config"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// newMockDBConfig 建立主從庫都指向 sqlmock 的設定，供需要資料庫的服務測試使用
+func newMockDBConfig(t *testing.T) (*config.Config, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	gormDB, err := gorm.Open(postgres.New(postgres.Config{Conn: db}), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+
+	return &config.Config{
+		Configurations: &config.Configurations{},
+		DB:             map[string]*gorm.DB{"master": gormDB, "slave": gormDB},
+	}, mock
+}
+
+// expectVideo 預期查詢一筆影片與其擁有者
+func expectVideo(mock sqlmock.Sqlmock, id, userID uint, visibility, status string) {
+	mock.ExpectQuery(`SELECT \* FROM "videos"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "title", "visibility", "status"}).
+			AddRow(id, userID, "來源影片", visibility, status))
+	mock.ExpectQuery(`SELECT \* FROM "users"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "username"}).AddRow(userID, "owner"))
+}
+
+// expectUser 預期依ID查詢一位用戶，found 為 false 時表示用戶不存在
+func expectUser(mock sqlmock.Sqlmock, id uint, username string, found bool) {
+	rows := sqlmock.NewRows([]string{"id", "username"})
+	if found {
+		rows.AddRow(id, username)
+	}
+	mock.ExpectQuery(`SELECT \* FROM "users" WHERE "users"\."id" = \$1`).
+		WithArgs(id, 1).
+		WillReturnRows(rows)
+}
+
+// recordingNotifier 記錄推送給用戶的事件，供需要 UserNotifier 的服務測試使用
+type recordingNotifier struct {
+	userIDs []uint
+	names   []string
+	events  []interface{}
+}
+
+func (n *recordingNotifier) NotifyUser(userID uint, event string, data interface{}) {
+	n.userIDs = append(n.userIDs, userID)
+	n.names = append(n.names, event)
+	n.events = append(n.events, data)
+}
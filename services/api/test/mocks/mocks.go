@@ -377,24 +377,24 @@ func (m *MockVideoService) ConfirmUploadAndStartProcessingWithKey(videoID uint,
 	return args.Error(0)
 }
 
-func (m *MockVideoService) GetVideos(offset, limit int) ([]*dto.VideoDTO, int64, error) {
-	args := m.Called(offset, limit)
+func (m *MockVideoService) GetVideos(viewerID uint, offset, limit int) ([]*dto.VideoDTO, int64, error) {
+	args := m.Called(viewerID, offset, limit)
 	if args.Get(0) == nil {
 		return nil, args.Get(1).(int64), args.Error(2)
 	}
 	return args.Get(0).([]*dto.VideoDTO), args.Get(1).(int64), args.Error(2)
 }
 
-func (m *MockVideoService) GetVideoByID(videoID uint) (*dto.VideoDTO, error) {
-	args := m.Called(videoID)
+func (m *MockVideoService) GetVideoByID(viewerID, videoID uint) (*dto.VideoDTO, error) {
+	args := m.Called(viewerID, videoID)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).(*dto.VideoDTO), args.Error(1)
 }
 
-func (m *MockVideoService) GetVideosByUserID(userID uint) ([]*dto.VideoDTO, int64, error) {
-	args := m.Called(userID)
+func (m *MockVideoService) GetVideosByUserID(viewerID, userID uint) ([]*dto.VideoDTO, int64, error) {
+	args := m.Called(viewerID, userID)
 	if args.Get(0) == nil {
 		return nil, args.Get(1).(int64), args.Error(2)
 	}
@@ -411,25 +411,25 @@ func (m *MockVideoService) DeleteVideo(id uint) error {
 	return args.Error(0)
 }
 
-func (m *MockVideoService) SearchVideos(query string, offset, limit int) ([]*dto.VideoDTO, int64, error) {
-	args := m.Called(query, offset, limit)
+func (m *MockVideoService) SearchVideos(viewerID uint, req *dto.VideoSearchDTO) (*dto.VideoSearchResultDTO, error) {
+	args := m.Called(viewerID, req)
 	if args.Get(0) == nil {
-		return nil, args.Get(1).(int64), args.Error(2)
+		return nil, args.Error(1)
 	}
-	return args.Get(0).([]*dto.VideoDTO), args.Get(1).(int64), args.Error(2)
+	return args.Get(0).(*dto.VideoSearchResultDTO), args.Error(1)
 }
 
-func (m *MockVideoService) LikeVideo(id uint) error {
-	args := m.Called(id)
+func (m *MockVideoService) LikeVideo(userID, id uint) error {
+	args := m.Called(userID, id)
 	return args.Error(0)
 }
 
-func (m *MockVideoService) IncrementViews(id uint) error {
-	args := m.Called(id)
+func (m *MockVideoService) UnlikeVideo(userID, id uint) error {
+	args := m.Called(userID, id)
 	return args.Error(0)
 }
 
-func (m *MockVideoService) IncrementLikes(id uint) error {
+func (m *MockVideoService) IncrementViews(id uint) error {
 	args := m.Called(id)
 	return args.Error(0)
 }
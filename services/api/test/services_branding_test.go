@@ -0,0 +1,88 @@
+package test
+
+import (
+	"testing"
+
+	"stream-demo/backend/dto"
+	"stream-demo/backend/services"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// expectNoBranding 預期查詢品牌設定時尚未設定
+func expectNoBranding(mock sqlmock.Sqlmock, userID uint) {
+	mock.ExpectQuery(`SELECT \* FROM "user_brandings" WHERE user_id = \$1`).
+		WithArgs(userID, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id"}))
+}
+
+func TestValidateWatermarkSettings(t *testing.T) {
+	assert.NoError(t, services.ValidateWatermarkSettings("bottom-right", 0.8, 0.15))
+	assert.NoError(t, services.ValidateWatermarkSettings("center", 1, 0.5))
+	assert.Error(t, services.ValidateWatermarkSettings("middle", 0.8, 0.15))
+	assert.Error(t, services.ValidateWatermarkSettings("top-left", 0, 0.15))
+	assert.Error(t, services.ValidateWatermarkSettings("top-left", 1.2, 0.15))
+	assert.Error(t, services.ValidateWatermarkSettings("top-left", 0.8, 0.8))
+}
+
+func TestBrandingService_GetBrandingDefaults(t *testing.T) {
+	conf, mock := newMockDBConfig(t)
+	service := services.NewBrandingService(conf, nil)
+	expectNoBranding(mock, 7)
+
+	branding, err := service.GetBranding(7)
+	require.NoError(t, err)
+	assert.True(t, branding.Enabled)
+	assert.Equal(t, "bottom-right", branding.WatermarkPosition)
+	assert.Equal(t, 0.8, branding.WatermarkOpacity)
+	assert.Equal(t, 0.15, branding.WatermarkScale)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestBrandingService_UpdateBrandingSetsIntroVideo(t *testing.T) {
+	conf, mock := newMockDBConfig(t)
+	service := services.NewBrandingService(conf, nil)
+	expectNoBranding(mock, 7)
+	expectVideo(mock, 3, 7, "public", "ready")
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO "user_brandings"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectCommit()
+
+	position := "top-left"
+	introVideoID := uint(3)
+	branding, err := service.UpdateBranding(7, &dto.UserBrandingUpdateDTO{
+		WatermarkPosition: &position,
+		IntroVideoID:      &introVideoID,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "top-left", branding.WatermarkPosition)
+	require.NotNil(t, branding.IntroVideoID)
+	assert.Equal(t, uint(3), *branding.IntroVideoID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestBrandingService_UpdateBrandingRejectsOthersVideo(t *testing.T) {
+	conf, mock := newMockDBConfig(t)
+	service := services.NewBrandingService(conf, nil)
+	expectNoBranding(mock, 7)
+	expectVideo(mock, 3, 8, "public", "ready")
+
+	outroVideoID := uint(3)
+	_, err := service.UpdateBranding(7, &dto.UserBrandingUpdateDTO{OutroVideoID: &outroVideoID})
+	assert.Error(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestBrandingService_UpdateBrandingRejectsForeignWatermark(t *testing.T) {
+	conf, mock := newMockDBConfig(t)
+	service := services.NewBrandingService(conf, nil)
+	expectNoBranding(mock, 7)
+
+	key := "branding/8/watermark.png"
+	_, err := service.UpdateBranding(7, &dto.UserBrandingUpdateDTO{WatermarkKey: &key})
+	assert.Error(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
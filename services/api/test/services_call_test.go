@@ -0,0 +1,137 @@
+package test
+
+import (
+	"testing"
+	"time"
+
+	"stream-demo/backend/database/models"
+	"stream-demo/backend/dto"
+	"stream-demo/backend/services"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newCallService 建立使用 sqlmock 的通話服務，測試環境沒有 Redis
+func newCallService(t *testing.T) (*services.CallService, sqlmock.Sqlmock) {
+	conversationService, mock, _ := newConversationService(t)
+	conf := conversationService.Conf
+	conf.Call.TURNURLs = []string{"turn:localhost:3478?transport=udp"}
+	conf.Call.STUNURLs = []string{"stun:localhost:3478"}
+	conf.Call.TURNSecret = "stream-demo-turn-secret"
+	conf.Call.CredentialTTL = 3600
+	return services.NewCallService(conf, conversationService, nil), mock
+}
+
+func TestTURNCredentials(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	username, password, expiresAt := services.TURNCredentials("stream-demo-turn-secret", 7, 12*time.Hour, now)
+	assert.Equal(t, "1700043200:7", username)
+	assert.Equal(t, "gRDFpYd6afBLUvF2z2pVnyCA7ls=", password)
+	assert.Equal(t, now.Add(12*time.Hour), expiresAt)
+}
+
+func TestCallStateOutcome(t *testing.T) {
+	newCall := func(statuses ...string) *services.CallState {
+		call := &services.CallState{CallerID: 1}
+		call.Participants = append(call.Participants, services.CallParticipantState{UserID: 1, Status: models.CallParticipantJoined})
+		for i, status := range statuses {
+			call.Participants = append(call.Participants, services.CallParticipantState{UserID: uint(i + 2), Status: status})
+		}
+		return call
+	}
+
+	status, ended := newCall(models.CallParticipantInvited, models.CallParticipantDeclined).Outcome()
+	assert.Equal(t, models.CallStatusRinging, status)
+	assert.False(t, ended)
+
+	status, ended = newCall(models.CallParticipantDeclined, models.CallParticipantBusy).Outcome()
+	assert.Equal(t, models.CallStatusRejected, status)
+	assert.True(t, ended)
+
+	status, _ = newCall(models.CallParticipantDeclined, models.CallParticipantMissed).Outcome()
+	assert.Equal(t, models.CallStatusMissed, status)
+
+	// 發起者在接聽前掛斷
+	call := newCall(models.CallParticipantInvited)
+	call.Participants[0].Status = models.CallParticipantLeft
+	status, ended = call.Outcome()
+	assert.Equal(t, models.CallStatusCancelled, status)
+	assert.True(t, ended)
+
+	// 接聽後剩一人即結束
+	answeredAt := time.Unix(1700000000, 0)
+	call = newCall(models.CallParticipantJoined, models.CallParticipantInvited)
+	call.AnsweredAt = &answeredAt
+	status, ended = call.Outcome()
+	assert.Equal(t, models.CallStatusActive, status)
+	assert.False(t, ended)
+
+	call.Participants[1].Status = models.CallParticipantLeft
+	status, ended = call.Outcome()
+	assert.Equal(t, models.CallStatusCompleted, status)
+	assert.True(t, ended)
+
+	call.Finish(status, answeredAt.Add(90*time.Second))
+	assert.Equal(t, 90, call.Duration())
+	assert.Equal(t, models.CallParticipantLeft, call.Participants[0].Status)
+	assert.Equal(t, models.CallParticipantMissed, call.Participants[2].Status)
+	assert.Equal(t, []uint{1, 2, 3}, call.UserIDsWithStatus())
+}
+
+func TestCallService_ICEServers(t *testing.T) {
+	service, _ := newCallService(t)
+
+	servers := service.ICEServers(7)
+	require.Len(t, servers.ICEServers, 2)
+	assert.Equal(t, []string{"stun:localhost:3478"}, servers.ICEServers[0].URLs)
+	assert.Empty(t, servers.ICEServers[0].Username)
+
+	turn := servers.ICEServers[1]
+	username, password, _ := services.TURNCredentials("stream-demo-turn-secret", 7, time.Hour, servers.ExpiresAt.Add(-time.Hour))
+	assert.Equal(t, username, turn.Username)
+	assert.Equal(t, password, turn.Credential)
+
+	// 未設定共享密鑰時只提供 STUN
+	service.Conf.Call.TURNSecret = ""
+	assert.Len(t, service.ICEServers(7).ICEServers, 1)
+}
+
+func TestCallService_InviteRequiresRedis(t *testing.T) {
+	service, mock := newCallService(t)
+
+	_, err := service.Invite(7, &dto.CallInviteDTO{ConversationID: 4, Type: models.CallTypeVideo})
+	assert.ErrorIs(t, err, services.ErrCallUnavailable)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCallService_ListCalls(t *testing.T) {
+	service, mock := newCallService(t)
+
+	mock.ExpectQuery(`SELECT count\(\*\) FROM "calls" WHERE id IN \(SELECT "call_id" FROM "call_participants" WHERE user_id = \$1\)`).
+		WithArgs(7).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	mock.ExpectQuery(`SELECT \* FROM "calls" WHERE id IN \(SELECT "call_id" FROM "call_participants" WHERE user_id = \$1\) ORDER BY created_at DESC, id DESC LIMIT \$2`).
+		WithArgs(7, 50).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "call_id", "conversation_id", "caller_id", "type", "status", "duration"}).
+			AddRow(3, "call-uuid", 4, 7, models.CallTypeAudio, models.CallStatusCompleted, 90))
+	mock.ExpectQuery(`SELECT \* FROM "call_participants" WHERE "call_participants"\."call_id" = \$1`).
+		WithArgs(3).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "call_id", "user_id", "status"}).
+			AddRow(1, 3, 7, models.CallParticipantLeft).
+			AddRow(2, 3, 8, models.CallParticipantLeft))
+	mock.ExpectQuery(`SELECT \* FROM "users" WHERE "users"\."id" IN`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "username"}).AddRow(7, "caller").AddRow(8, "callee"))
+
+	// 每頁筆數超過上限時使用上限
+	calls, total, err := service.ListCalls(7, -1, 500)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	require.Len(t, calls, 1)
+	assert.Equal(t, "call-uuid", calls[0].CallID)
+	assert.Equal(t, 90, calls[0].Duration)
+	require.Len(t, calls[0].Participants, 2)
+	assert.Equal(t, "callee", calls[0].Participants[1].Username)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
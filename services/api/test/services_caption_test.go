@@ -0,0 +1,115 @@
+package test
+
+import (
+	"testing"
+
+	"stream-demo/backend/database/models"
+	"stream-demo/backend/services"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeCaptionLanguage(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{"en", "en", false},
+		{"zh-tw", "zh-TW", false},
+		{"zh_TW", "zh-TW", false},
+		{"zh-hant-tw", "zh-Hant-TW", false},
+		{"ZH", "zh", false},
+		{"", "", true},
+		{"english!", "", true},
+		{"zh-TW\"", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := services.NormalizeCaptionLanguage(tt.input)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestCaptionFormatFromFilename(t *testing.T) {
+	format, err := services.CaptionFormatFromFilename("movie.zh-TW.SRT")
+	assert.NoError(t, err)
+	assert.Equal(t, "srt", format)
+
+	format, err = services.CaptionFormatFromFilename("movie.vtt")
+	assert.NoError(t, err)
+	assert.Equal(t, "vtt", format)
+
+	_, err = services.CaptionFormatFromFilename("movie.ass")
+	assert.Error(t, err)
+}
+
+func TestCaptionService_ListCaptionsRequiresVisibleVideo(t *testing.T) {
+	conf, mock := newMockDBConfig(t)
+	captionService := services.NewCaptionService(conf, nil)
+
+	expectVideo(mock, 10, 1, models.VideoVisibilityPrivate, "ready")
+	mock.ExpectQuery(`SELECT count\(\*\) FROM "video_share_grants"`).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+
+	_, err := captionService.ListCaptions(2, 10)
+	assert.ErrorIs(t, err, services.ErrVideoNotVisible)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// expectCaption 預期查詢影片的某語言字幕
+func expectCaption(mock sqlmock.Sqlmock, id, videoID uint, language, status string) {
+	mock.ExpectQuery(`SELECT \* FROM "video_captions" WHERE video_id = \$1 AND language = \$2`).
+		WithArgs(videoID, language, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "video_id", "language", "format", "status"}).
+			AddRow(id, videoID, language, "srt", status))
+}
+
+func TestCaptionService_ConfirmCaptionUpload(t *testing.T) {
+	conf, mock := newMockDBConfig(t)
+	captionService := services.NewCaptionService(conf, nil)
+
+	// 語言代碼正規化後查找字幕，上傳完成後交由 converter 處理
+	expectVideo(mock, 10, 1, models.VideoVisibilityPublic, "ready")
+	expectCaption(mock, 3, 10, "zh-TW", "uploading")
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE "video_captions" SET .*"status"=`).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	caption, err := captionService.ConfirmCaptionUpload(1, 10, "zh_tw")
+	require.NoError(t, err)
+	assert.Equal(t, "processing", caption.Status)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCaptionService_ConfirmCaptionUploadRejectsProcessedCaption(t *testing.T) {
+	conf, mock := newMockDBConfig(t)
+	captionService := services.NewCaptionService(conf, nil)
+
+	expectVideo(mock, 10, 1, models.VideoVisibilityPublic, "ready")
+	expectCaption(mock, 3, 10, "en", "ready")
+
+	_, err := captionService.ConfirmCaptionUpload(1, 10, "en")
+	assert.Error(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCaptionService_DeleteCaptionRequiresOwner(t *testing.T) {
+	conf, mock := newMockDBConfig(t)
+	captionService := services.NewCaptionService(conf, nil)
+
+	expectVideo(mock, 10, 1, models.VideoVisibilityPublic, "ready")
+
+	assert.Error(t, captionService.DeleteCaption(2, 10, "en"))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
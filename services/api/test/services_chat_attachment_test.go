@@ -0,0 +1,119 @@
+package test
+
+import (
+	"strings"
+	"testing"
+
+	"stream-demo/backend/database/models"
+	"stream-demo/backend/dto"
+	"stream-demo/backend/pkg/storage"
+	"stream-demo/backend/services"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newAttachmentConversationService 建立附件儲存指向假 S3 的私訊服務
+func newAttachmentConversationService(t *testing.T) (*services.ConversationService, sqlmock.Sqlmock, *fakeS3) {
+	service, mock, _ := newConversationService(t)
+	service.Conf.Chat.MaxImageSizeMB = 10
+	service.Conf.Chat.MaxFileSizeMB = 1
+	service.Conf.Chat.AttachmentURLMinutes = 10
+	chatStorage, fake := newFakeS3Storage(t)
+	service.ChatStorage = chatStorage
+	return service, mock, fake
+}
+
+func TestAttachmentKind(t *testing.T) {
+	kind, ok := services.AttachmentKind("photo.JPG")
+	assert.True(t, ok)
+	assert.Equal(t, models.MessageTypeImage, kind)
+
+	kind, ok = services.AttachmentKind("clip.mov")
+	assert.True(t, ok)
+	assert.Equal(t, models.MessageTypeVideo, kind)
+
+	kind, ok = services.AttachmentKind("report.pdf")
+	assert.True(t, ok)
+	assert.Equal(t, models.MessageTypeFile, kind)
+
+	_, ok = services.AttachmentKind("setup.exe")
+	assert.False(t, ok)
+}
+
+func TestAttachmentFilename(t *testing.T) {
+	assert.Equal(t, "report.pdf", services.AttachmentFilename("../../etc/report.pdf", "x.pdf"))
+	assert.Equal(t, "report.pdf", services.AttachmentFilename(`C:\Users\me\report.pdf`, "x.pdf"))
+	assert.Equal(t, "x.pdf", services.AttachmentFilename("  ", "x.pdf"))
+	assert.Len(t, []rune(services.AttachmentFilename(strings.Repeat("字", 300), "x.pdf")), 255)
+}
+
+func TestConversationService_CreateAttachmentUploadURL(t *testing.T) {
+	service, mock, _ := newAttachmentConversationService(t)
+
+	expectConversation(mock, 4, models.ConversationTypeDirect, directMembers(7, 8)...)
+
+	upload, err := service.CreateAttachmentUploadURL(7, 4, &dto.AttachmentUploadRequestDTO{Filename: "photo.PNG", Size: 1024})
+	require.NoError(t, err)
+	assert.Equal(t, models.MessageTypeImage, upload.Kind)
+	assert.True(t, strings.HasPrefix(upload.Key, storage.ChatAttachmentPrefix(4)))
+	assert.True(t, strings.HasSuffix(upload.Key, ".png"))
+	assert.NotEmpty(t, upload.UploadURL)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestConversationService_CreateAttachmentUploadURLRejectsOversizedFile(t *testing.T) {
+	service, mock, _ := newAttachmentConversationService(t)
+
+	// 檔案附件上限 1 MB
+	expectConversation(mock, 4, models.ConversationTypeDirect, directMembers(7, 8)...)
+	_, err := service.CreateAttachmentUploadURL(7, 4, &dto.AttachmentUploadRequestDTO{Filename: "report.pdf", Size: 2 << 20})
+	assert.EqualError(t, err, "檔案大小不能超過 1 MB")
+
+	expectConversation(mock, 4, models.ConversationTypeDirect, directMembers(7, 8)...)
+	_, err = service.CreateAttachmentUploadURL(7, 4, &dto.AttachmentUploadRequestDTO{Filename: "setup.exe", Size: 1024})
+	assert.EqualError(t, err, "不支援的附件格式")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestConversationService_SendMessageWithFileAttachment(t *testing.T) {
+	service, mock, fake := newAttachmentConversationService(t)
+
+	// 以實際上傳的檔案大小記錄附件
+	key := storage.ChatAttachmentPrefix(4) + "upload.pdf"
+	fake.objects[key] = []byte("%PDF-1.4 fake report")
+
+	expectConversation(mock, 4, models.ConversationTypeDirect, directMembers(7, 8)...)
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO "messages"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(10))
+	mock.ExpectQuery(`INSERT INTO "message_attachments"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectExec(`UPDATE "conversations"`).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`UPDATE "conversation_members"`).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	message, err := service.SendMessage(7, 4, &dto.MessageSendDTO{
+		Attachment: &dto.MessageAttachmentInputDTO{Key: key, Filename: "../季報.pdf"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, models.MessageTypeFile, message.Type)
+	require.NotNil(t, message.Attachment)
+	assert.Equal(t, "季報.pdf", message.Attachment.Filename)
+	assert.Equal(t, int64(len(fake.objects[key])), message.Attachment.Size)
+	assert.Contains(t, message.Attachment.URL, key)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestConversationService_SendMessageRejectsForeignAttachment(t *testing.T) {
+	service, mock, _ := newAttachmentConversationService(t)
+
+	// 附件只能引用此會話上傳的檔案
+	expectConversation(mock, 4, models.ConversationTypeDirect, directMembers(7, 8)...)
+	_, err := service.SendMessage(7, 4, &dto.MessageSendDTO{
+		Attachment: &dto.MessageAttachmentInputDTO{Key: storage.ChatAttachmentPrefix(5) + "upload.pdf"},
+	})
+	assert.EqualError(t, err, "無效的附件")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,115 @@
+package test
+
+import (
+	"testing"
+
+	"stream-demo/backend/database/models"
+	"stream-demo/backend/dto"
+	"stream-demo/backend/services"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateClipRange(t *testing.T) {
+	tests := []struct {
+		name      string
+		start     float64
+		end       float64
+		available float64
+		wantErr   bool
+	}{
+		{"有效範圍", 10, 40, 120, false},
+		{"來源長度未知", 10, 40, 0, false},
+		{"結束早於開始", 40, 10, 120, true},
+		{"開始為負數", -1, 10, 120, true},
+		{"片段過短", 10, 10.5, 120, true},
+		{"片段過長", 0, 200, 300, true},
+		{"超出來源長度", 100, 130, 120, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := services.ValidateClipRange(tt.start, tt.end, tt.available, 1, 180)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestClipService_CreateVideoClipRequiresVisibleSource(t *testing.T) {
+	conf, mock := newMockDBConfig(t)
+	clipService := services.NewClipService(conf, nil, nil)
+
+	// 未獲授權的用戶不能剪輯他人的私人影片，也不會建立片段
+	expectVideo(mock, 10, 1, models.VideoVisibilityPrivate, "ready")
+	mock.ExpectQuery(`SELECT count\(\*\) FROM "video_share_grants"`).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+
+	_, err := clipService.CreateVideoClip(2, 10, &dto.VideoClipCreateDTO{Start: 0, End: 10})
+	assert.ErrorIs(t, err, services.ErrVideoNotVisible)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestClipService_GetClipsBySourceVideoRequiresVisibleSource(t *testing.T) {
+	conf, mock := newMockDBConfig(t)
+	clipService := services.NewClipService(conf, nil, nil)
+
+	expectVideo(mock, 10, 1, models.VideoVisibilityFollowers, "ready")
+	mock.ExpectQuery(`SELECT count\(\*\) FROM "follows"`).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+
+	_, err := clipService.GetClipsBySourceVideo(2, 10)
+	assert.ErrorIs(t, err, services.ErrVideoNotVisible)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestLivePlaylistDuration(t *testing.T) {
+	playlist := "#EXTM3U\n#EXT-X-VERSION:3\n#EXT-X-MEDIA-SEQUENCE:42\n#EXT-X-TARGETDURATION:2\n" +
+		"#EXTINF:2.000,\n42.ts\n#EXTINF:2.000,\n43.ts\n#EXTINF:1.500,\n44.ts\n"
+
+	assert.InDelta(t, 5.5, services.LivePlaylistDuration(playlist), 0.001)
+	assert.Equal(t, 0.0, services.LivePlaylistDuration("#EXTM3U\n#EXT-X-TARGETDURATION:2\n"))
+}
+
+func TestClipService_CreateVideoClipInheritsUnlistedSource(t *testing.T) {
+	conf, mock := newMockDBConfig(t)
+	clipService := services.NewClipService(conf, nil, nil)
+
+	// 擁有者剪輯自己的不公開影片：建立 clipping 影片與剪輯記錄，原始檔路徑依新影片ID產生
+	expectVideo(mock, 10, 1, models.VideoVisibilityUnlisted, "ready")
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO "videos"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(11))
+	mock.ExpectExec(`UPDATE "videos" SET "original_key"=\$1`).
+		WithArgs("videos/original/1/clip_11.mp4", sqlmock.AnyArg(), 11).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(`INSERT INTO "video_clips"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectCommit()
+
+	video, err := clipService.CreateVideoClip(1, 10, &dto.VideoClipCreateDTO{Start: 5, End: 20})
+	require.NoError(t, err)
+	assert.Equal(t, "clipping", video.Status)
+	assert.Equal(t, models.VideoVisibilityUnlisted, video.Visibility)
+	assert.Equal(t, 15, video.Duration)
+	require.NotNil(t, video.Clip)
+	assert.Equal(t, "video", video.Clip.SourceType)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestClipService_CreateVideoClipRejectsLongClip(t *testing.T) {
+	conf, mock := newMockDBConfig(t)
+	conf.Video.Clip.MaxDuration = 30
+	clipService := services.NewClipService(conf, nil, nil)
+
+	expectVideo(mock, 10, 1, models.VideoVisibilityPublic, "ready")
+
+	_, err := clipService.CreateVideoClip(1, 10, &dto.VideoClipCreateDTO{Start: 0, End: 60})
+	assert.Error(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,167 @@
+package test
+
+import (
+	"testing"
+
+	"stream-demo/backend/database/models"
+	"stream-demo/backend/dto"
+	"stream-demo/backend/services"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingChatNotifier 記錄推送給會話成員的私訊事件
+type recordingChatNotifier struct {
+	userIDs [][]uint
+	events  []string
+	data    []interface{}
+}
+
+func (n *recordingChatNotifier) NotifyUsers(userIDs []uint, event string, data interface{}) {
+	n.userIDs = append(n.userIDs, userIDs)
+	n.events = append(n.events, event)
+	n.data = append(n.data, data)
+}
+
+// newConversationService 建立使用 sqlmock 與記錄推送的私訊服務
+func newConversationService(t *testing.T) (*services.ConversationService, sqlmock.Sqlmock, *recordingChatNotifier) {
+	conf, mock := newMockDBConfig(t)
+	conf.Chat.MaxMessageLength = 2000
+	conf.Chat.MaxGroupMembers = 3
+	service := services.NewConversationService(conf, nil, nil)
+	notifier := &recordingChatNotifier{}
+	service.SetNotifier(notifier)
+	return service, mock, notifier
+}
+
+// expectConversation 預期依ID查詢會話、成員與成員的用戶資料
+func expectConversation(mock sqlmock.Sqlmock, id uint, conversationType string, members ...models.ConversationMember) {
+	mock.ExpectQuery(`SELECT \* FROM "conversations" WHERE "conversations"\."id" = \$1`).
+		WithArgs(id, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "type", "name", "created_by"}).
+			AddRow(id, conversationType, "", members[0].UserID))
+
+	memberRows := sqlmock.NewRows([]string{"id", "conversation_id", "user_id", "role", "last_delivered_message_id", "last_read_message_id"})
+	userRows := sqlmock.NewRows([]string{"id", "username"})
+	for i, member := range members {
+		memberRows.AddRow(i+1, id, member.UserID, member.Role, member.LastDeliveredMessageID, member.LastReadMessageID)
+		userRows.AddRow(member.UserID, "user")
+	}
+	mock.ExpectQuery(`SELECT \* FROM "conversation_members" WHERE "conversation_members"\."conversation_id" = \$1`).
+		WithArgs(id).
+		WillReturnRows(memberRows)
+	mock.ExpectQuery(`SELECT \* FROM "users" WHERE "users"\."id" IN`).
+		WillReturnRows(userRows)
+}
+
+// directMembers 一對一會話的兩位成員
+func directMembers(userID, otherID uint) []models.ConversationMember {
+	return []models.ConversationMember{
+		{UserID: userID, Role: models.ConversationRoleMember},
+		{UserID: otherID, Role: models.ConversationRoleMember},
+	}
+}
+
+func TestDirectConversationKey(t *testing.T) {
+	assert.Equal(t, "3:7", services.DirectConversationKey(3, 7))
+	assert.Equal(t, "3:7", services.DirectConversationKey(7, 3))
+}
+
+func TestMessageReceiptStatus(t *testing.T) {
+	members := []models.ConversationMember{
+		{UserID: 1, LastDeliveredMessageID: 10, LastReadMessageID: 10},
+		{UserID: 2, LastDeliveredMessageID: 8, LastReadMessageID: 5},
+	}
+
+	assert.Equal(t, services.MessageStatusRead, services.MessageReceiptStatus(5, 1, members))
+	assert.Equal(t, services.MessageStatusDelivered, services.MessageReceiptStatus(8, 1, members))
+	assert.Equal(t, services.MessageStatusSent, services.MessageReceiptStatus(9, 1, members))
+	// 發送者自己的回條不影響狀態
+	assert.Equal(t, services.MessageStatusSent, services.MessageReceiptStatus(9, 2, []models.ConversationMember{
+		{UserID: 1, LastDeliveredMessageID: 8, LastReadMessageID: 8},
+		{UserID: 2, LastDeliveredMessageID: 12, LastReadMessageID: 12},
+	}))
+}
+
+func TestConversationService_SendMessage(t *testing.T) {
+	service, mock, notifier := newConversationService(t)
+
+	expectConversation(mock, 4, models.ConversationTypeDirect, directMembers(7, 8)...)
+	mock.ExpectQuery(`SELECT \* FROM "messages" WHERE sender_id = \$1 AND client_id = \$2`).
+		WithArgs(7, "client_1", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO "messages"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(10))
+	mock.ExpectExec(`UPDATE "conversations" SET "last_message_at"=\$1,"last_message_id"=\$2,"updated_at"=\$3 WHERE id = \$4`).
+		WithArgs(sqlmock.AnyArg(), 10, sqlmock.AnyArg(), 4).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	// 發送者自己的回條直接前移到新訊息
+	mock.ExpectExec(`UPDATE "conversation_members" SET "last_delivered_message_id"=\$1,"last_read_message_id"=\$2 WHERE conversation_id = \$3 AND user_id = \$4`).
+		WithArgs(10, 10, 4, 7).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	message, err := service.SendMessage(7, 4, &dto.MessageSendDTO{Content: "  哈囉  ", ClientID: "client_1"})
+	require.NoError(t, err)
+	assert.Equal(t, uint(10), message.ID)
+	assert.Equal(t, "哈囉", message.Content)
+	assert.Equal(t, services.MessageStatusSent, message.Status)
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	require.Len(t, notifier.events, 1)
+	assert.Equal(t, services.ChatEventMessage, notifier.events[0])
+	assert.Equal(t, []uint{7, 8}, notifier.userIDs[0])
+}
+
+func TestConversationService_SendMessageReturnsExistingForClientID(t *testing.T) {
+	service, mock, notifier := newConversationService(t)
+
+	expectConversation(mock, 4, models.ConversationTypeDirect, directMembers(7, 8)...)
+	mock.ExpectQuery(`SELECT \* FROM "messages" WHERE sender_id = \$1 AND client_id = \$2`).
+		WithArgs(7, "client_1", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "conversation_id", "sender_id", "type", "content", "client_id"}).
+			AddRow(10, 4, 7, models.MessageTypeText, "哈囉", "client_1"))
+	mock.ExpectQuery(`SELECT \* FROM "message_attachments" WHERE "message_attachments"\."message_id" = \$1`).
+		WithArgs(10).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	// 重送時回傳原訊息，不重複建立也不重複推送
+	message, err := service.SendMessage(7, 4, &dto.MessageSendDTO{Content: "哈囉", ClientID: "client_1"})
+	require.NoError(t, err)
+	assert.Equal(t, uint(10), message.ID)
+	assert.Empty(t, notifier.events)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestConversationService_SendMessageRequiresMember(t *testing.T) {
+	service, mock, _ := newConversationService(t)
+
+	expectConversation(mock, 4, models.ConversationTypeDirect, directMembers(8, 9)...)
+
+	_, err := service.SendMessage(7, 4, &dto.MessageSendDTO{Content: "哈囉"})
+	assert.ErrorIs(t, err, services.ErrConversationNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestConversationService_MarkReadPublishesReceipt(t *testing.T) {
+	service, mock, notifier := newConversationService(t)
+
+	expectConversation(mock, 4, models.ConversationTypeDirect, directMembers(7, 8)...)
+	mock.ExpectQuery(`SELECT count\(\*\) FROM "messages" WHERE id = \$1 AND conversation_id = \$2`).
+		WithArgs(10, 4).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE "conversation_members" SET .*WHERE conversation_id = \$\d+ AND user_id = \$\d+ AND last_read_message_id < \$\d+`).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	require.NoError(t, service.MarkRead(8, 4, 10))
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	require.Len(t, notifier.events, 1)
+	assert.Equal(t, services.ChatEventRead, notifier.events[0])
+	assert.Equal(t, []uint{7, 8}, notifier.userIDs[0])
+}
@@ -0,0 +1,86 @@
+package test
+
+import (
+	"testing"
+
+	"stream-demo/backend/services"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// expectFollowCounts 預期統計用戶的追隨者數與追蹤數
+func expectFollowCounts(mock sqlmock.Sqlmock, userID uint, followers, following int64) {
+	mock.ExpectQuery(`SELECT count\(\*\) FROM "follows" WHERE followee_id = \$1`).
+		WithArgs(userID).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(followers))
+	mock.ExpectQuery(`SELECT count\(\*\) FROM "follows" WHERE follower_id = \$1`).
+		WithArgs(userID).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(following))
+}
+
+func TestParseFeedMember(t *testing.T) {
+	kind, id, ok := services.ParseFeedMember("video:42")
+	assert.True(t, ok)
+	assert.Equal(t, "video", kind)
+	assert.Equal(t, "42", id)
+
+	kind, id, ok = services.ParseFeedMember("live:room_abc:1")
+	assert.True(t, ok)
+	assert.Equal(t, "live", kind)
+	assert.Equal(t, "room_abc:1", id)
+
+	_, _, ok = services.ParseFeedMember("clip:1")
+	assert.False(t, ok)
+	_, _, ok = services.ParseFeedMember("video:")
+	assert.False(t, ok)
+}
+
+func TestFollowService_Follow(t *testing.T) {
+	conf, mock := newMockDBConfig(t)
+	service := services.NewFollowService(conf, nil, nil)
+
+	expectUser(mock, 8, "creator", true)
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO "follows" .*ON CONFLICT DO NOTHING`).
+		WithArgs(7, 8, sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectCommit()
+	expectFollowCounts(mock, 8, 3, 1)
+
+	status, err := service.Follow(7, 8)
+	require.NoError(t, err)
+	assert.True(t, status.Following)
+	assert.Equal(t, int64(3), status.FollowerCount)
+	assert.Equal(t, int64(1), status.FollowingCount)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestFollowService_FollowRejectsSelfAndMissingUser(t *testing.T) {
+	conf, mock := newMockDBConfig(t)
+	service := services.NewFollowService(conf, nil, nil)
+
+	_, err := service.Follow(7, 7)
+	assert.Error(t, err)
+
+	expectUser(mock, 8, "", false)
+	_, err = service.Follow(7, 8)
+	assert.Error(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestFollowService_GetFollowStatusForSelf(t *testing.T) {
+	conf, mock := newMockDBConfig(t)
+	service := services.NewFollowService(conf, nil, nil)
+
+	// 查看自己時不查詢追蹤關係
+	expectUser(mock, 7, "viewer", true)
+	expectFollowCounts(mock, 7, 2, 5)
+
+	status, err := service.GetFollowStatus(7, 7)
+	require.NoError(t, err)
+	assert.False(t, status.Following)
+	assert.Equal(t, int64(2), status.FollowerCount)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,133 @@
+package test
+
+import (
+	"testing"
+	"time"
+
+	"stream-demo/backend/database/models"
+	"stream-demo/backend/services"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// expectFriendships 預期查詢兩位用戶之間的好友關係記錄
+func expectFriendships(mock sqlmock.Sqlmock, userID, otherID uint, friendships ...models.Friendship) {
+	rows := sqlmock.NewRows([]string{"id", "user_id", "friend_id", "status"})
+	for i, friendship := range friendships {
+		rows.AddRow(i+1, friendship.UserID, friendship.FriendID, friendship.Status)
+	}
+	mock.ExpectQuery(`SELECT \* FROM "friendships" WHERE \(user_id = \$1 AND friend_id = \$2\) OR \(user_id = \$3 AND friend_id = \$4\)`).
+		WithArgs(userID, otherID, otherID, userID).
+		WillReturnRows(rows)
+}
+
+// expectBlockRelatedUsers 預期查詢與用戶有封鎖關係的用戶ID
+func expectBlockRelatedUsers(mock sqlmock.Sqlmock, userID uint, blockedIDs ...uint) {
+	rows := sqlmock.NewRows([]string{"id"})
+	for _, id := range blockedIDs {
+		rows.AddRow(id)
+	}
+	mock.ExpectQuery(`SELECT CASE WHEN user_id = \$1 THEN friend_id ELSE user_id END FROM "friendships"`).
+		WithArgs(userID, userID, userID, models.FriendshipStatusBlocked).
+		WillReturnRows(rows)
+}
+
+func TestFriendshipStatusFor(t *testing.T) {
+	pending := []models.Friendship{{UserID: 1, FriendID: 2, Status: models.FriendshipStatusPending}}
+	assert.Equal(t, services.FriendStatusPendingOutgoing, services.FriendshipStatusFor(1, pending))
+	assert.Equal(t, services.FriendStatusPendingIncoming, services.FriendshipStatusFor(2, pending))
+
+	accepted := []models.Friendship{{UserID: 1, FriendID: 2, Status: models.FriendshipStatusAccepted}}
+	assert.Equal(t, services.FriendStatusFriends, services.FriendshipStatusFor(2, accepted))
+	assert.Equal(t, services.FriendStatusNone, services.FriendshipStatusFor(1, nil))
+
+	// 被對方封鎖時不透露
+	blocked := []models.Friendship{{UserID: 1, FriendID: 2, Status: models.FriendshipStatusBlocked}}
+	assert.Equal(t, services.FriendStatusBlocked, services.FriendshipStatusFor(1, blocked))
+	assert.Equal(t, services.FriendStatusNone, services.FriendshipStatusFor(2, blocked))
+}
+
+func TestIsPresenceFresh(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	assert.True(t, services.IsPresenceFresh(map[string]string{"a": "1699999990"}, now))
+	assert.True(t, services.IsPresenceFresh(map[string]string{"a": "1699990000", "b": "1699999950"}, now))
+	assert.False(t, services.IsPresenceFresh(map[string]string{"a": "1699990000"}, now))
+	assert.False(t, services.IsPresenceFresh(map[string]string{"a": "invalid"}, now))
+	assert.False(t, services.IsPresenceFresh(nil, now))
+}
+
+func TestFriendService_SendRequest(t *testing.T) {
+	conf, mock := newMockDBConfig(t)
+	service := services.NewFriendService(conf, nil, nil)
+
+	expectUser(mock, 8, "friend", true)
+	expectFriendships(mock, 7, 8)
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO "friendships"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectCommit()
+
+	status, err := service.SendRequest(7, 8)
+	require.NoError(t, err)
+	assert.Equal(t, services.FriendStatusPendingOutgoing, status.Status)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestFriendService_SendRequestAcceptsIncomingRequest(t *testing.T) {
+	conf, mock := newMockDBConfig(t)
+	service := services.NewFriendService(conf, nil, nil)
+
+	// 對方已邀請自己時直接成為好友
+	expectUser(mock, 8, "friend", true)
+	expectFriendships(mock, 7, 8, models.Friendship{UserID: 8, FriendID: 7, Status: models.FriendshipStatusPending})
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE "friendships" SET "status"=\$1.* WHERE user_id = \$\d+ AND friend_id = \$\d+ AND status = \$\d+`).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	mock.ExpectBegin()
+	mock.ExpectExec(`DELETE FROM "friendships" WHERE user_id = \$1 AND friend_id = \$2 AND status = \$3`).
+		WithArgs(7, 8, models.FriendshipStatusPending).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	status, err := service.SendRequest(7, 8)
+	require.NoError(t, err)
+	assert.Equal(t, services.FriendStatusFriends, status.Status)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestFriendService_SendRequestRejectsBlocked(t *testing.T) {
+	conf, mock := newMockDBConfig(t)
+	service := services.NewFriendService(conf, nil, nil)
+
+	expectUser(mock, 8, "friend", true)
+	expectFriendships(mock, 7, 8, models.Friendship{UserID: 8, FriendID: 7, Status: models.FriendshipStatusBlocked})
+
+	_, err := service.SendRequest(7, 8)
+	assert.ErrorIs(t, err, services.ErrUserBlocked)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestFriendService_BlockedWithCachesUntilUnblock(t *testing.T) {
+	conf, mock := newMockDBConfig(t)
+	service := services.NewFriendService(conf, nil, nil)
+
+	expectBlockRelatedUsers(mock, 7, 8)
+	assert.Equal(t, map[uint]bool{8: true}, service.BlockedWith(7))
+	// 快取期間不再查詢資料庫
+	assert.Equal(t, map[uint]bool{8: true}, service.BlockedWith(7))
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`DELETE FROM "friendships" WHERE user_id = \$1 AND friend_id = \$2 AND status = \$3`).
+		WithArgs(7, 8, models.FriendshipStatusBlocked).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	require.NoError(t, service.Unblock(7, 8))
+
+	// 解除封鎖後清除快取
+	expectBlockRelatedUsers(mock, 7)
+	assert.Empty(t, service.BlockedWith(7))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
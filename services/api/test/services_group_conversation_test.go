@@ -0,0 +1,108 @@
+package test
+
+import (
+	"testing"
+	"time"
+
+	"stream-demo/backend/database/models"
+	postgresqlRepo "stream-demo/backend/repositories/postgresql"
+	"stream-demo/backend/services"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+// expectInvite 預期依邀請碼查詢邀請連結
+func expectInvite(mock sqlmock.Sqlmock, code string, conversationID uint, maxUses, uses int, expiresAt time.Time) {
+	mock.ExpectQuery(`SELECT \* FROM "conversation_invites" WHERE code = \$1`).
+		WithArgs(code, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "conversation_id", "code", "created_by", "max_uses", "uses", "expires_at"}).
+			AddRow(1, conversationID, code, 7, maxUses, uses, expiresAt))
+}
+
+func TestCanManageMember(t *testing.T) {
+	assert.True(t, services.CanManageMember(models.ConversationRoleOwner, models.ConversationRoleAdmin))
+	assert.True(t, services.CanManageMember(models.ConversationRoleAdmin, models.ConversationRoleMember))
+	assert.False(t, services.CanManageMember(models.ConversationRoleAdmin, models.ConversationRoleAdmin))
+	assert.False(t, services.CanManageMember(models.ConversationRoleAdmin, models.ConversationRoleOwner))
+	assert.False(t, services.CanManageMember(models.ConversationRoleMember, models.ConversationRoleMember))
+}
+
+func TestNextGroupOwner(t *testing.T) {
+	members := []models.ConversationMember{
+		{ID: 1, UserID: 10, Role: models.ConversationRoleOwner},
+		{ID: 2, UserID: 11, Role: models.ConversationRoleMember},
+		{ID: 4, UserID: 13, Role: models.ConversationRoleAdmin},
+		{ID: 3, UserID: 12, Role: models.ConversationRoleAdmin},
+	}
+	next, ok := services.NextGroupOwner(members, 10)
+	assert.True(t, ok)
+	assert.Equal(t, uint(12), next)
+
+	// 沒有管理員時由最早加入的成員接手
+	next, ok = services.NextGroupOwner(members[:2], 10)
+	assert.True(t, ok)
+	assert.Equal(t, uint(11), next)
+
+	_, ok = services.NextGroupOwner(members[:1], 10)
+	assert.False(t, ok)
+}
+
+func TestInviteUsable(t *testing.T) {
+	now := time.Now()
+	expiresAt := now.Add(time.Hour)
+	assert.True(t, services.InviteUsable(&models.ConversationInvite{ExpiresAt: &expiresAt}, now))
+	assert.True(t, services.InviteUsable(&models.ConversationInvite{MaxUses: 3, Uses: 2}, now))
+	assert.False(t, services.InviteUsable(&models.ConversationInvite{MaxUses: 3, Uses: 3}, now))
+	assert.False(t, services.InviteUsable(&models.ConversationInvite{ExpiresAt: &expiresAt}, expiresAt))
+}
+
+func TestConversationService_JoinByInviteRejectsFullGroup(t *testing.T) {
+	service, mock, notifier := newConversationService(t)
+
+	expectInvite(mock, "invite_code", 4, 0, 0, time.Now().Add(time.Hour))
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT count\(\*\) FROM "conversation_members" WHERE conversation_id = \$1 AND user_id = \$2`).
+		WithArgs(4, 9).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	mock.ExpectExec(`UPDATE "conversation_invites" SET "uses"=uses \+ 1`).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(`SELECT \* FROM "conversations" WHERE "conversations"\."id" = \$1 .*FOR UPDATE`).
+		WithArgs(4, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "type"}).AddRow(4, models.ConversationTypeGroup))
+	// 已有 3 位成員，達到人數上限
+	mock.ExpectQuery(`SELECT "user_id" FROM "conversation_members" WHERE conversation_id = \$1`).
+		WithArgs(4).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id"}).AddRow(7).AddRow(8).AddRow(10))
+	mock.ExpectRollback()
+
+	_, err := service.JoinByInvite(9, " invite_code ")
+	assert.EqualError(t, err, "群組人數上限為 3 人")
+	assert.Empty(t, notifier.events)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestConversationService_JoinByInviteRejectsExpiredInvite(t *testing.T) {
+	service, mock, _ := newConversationService(t)
+
+	expectInvite(mock, "invite_code", 4, 0, 0, time.Now().Add(-time.Minute))
+
+	_, err := service.JoinByInvite(9, "invite_code")
+	assert.ErrorIs(t, err, postgresqlRepo.ErrInviteUnavailable)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestConversationService_RemoveMemberRequiresHigherRole(t *testing.T) {
+	service, mock, _ := newConversationService(t)
+
+	// 管理員不能移除其他管理員
+	expectConversation(mock, 4, models.ConversationTypeGroup,
+		models.ConversationMember{UserID: 10, Role: models.ConversationRoleOwner},
+		models.ConversationMember{UserID: 7, Role: models.ConversationRoleAdmin},
+		models.ConversationMember{UserID: 8, Role: models.ConversationRoleAdmin},
+	)
+
+	err := service.RemoveMember(7, 4, 8)
+	assert.ErrorIs(t, err, services.ErrGroupPermission)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -2,6 +2,11 @@ package test
 
 import (
 	"testing"
+	"time"
+
+	"stream-demo/backend/services"
+
+	"github.com/stretchr/testify/assert"
 )
 
 func TestLiveRoomService_CreateRoom(t *testing.T) {
@@ -18,3 +23,39 @@ func TestLiveRoomService_CloseRoom(t *testing.T) {
 	// 由於 LiveRoomService 需要真實的 Redis 連接，我們跳過這些測試
 	t.Skip("LiveRoomService 需要真實的 Redis 連接，無法進行單元測試")
 }
+
+func TestLiveRoomService_ScheduleRoom(t *testing.T) {
+	// 由於 LiveRoomService 需要真實的 Redis 連接，我們跳過這些測試
+	t.Skip("LiveRoomService 需要真實的 Redis 連接，無法進行單元測試")
+}
+
+func TestScheduledRoomAction(t *testing.T) {
+	scheduledAt := time.Date(2024, 1, 1, 20, 0, 0, 0, time.UTC)
+	openBefore := 15 * time.Minute
+	grace := 30 * time.Minute
+
+	tests := []struct {
+		name   string
+		status string
+		now    time.Time
+		want   string
+	}{
+		{"尚未到開放時間", "scheduled", scheduledAt.Add(-time.Hour), services.ScheduleActionNone},
+		{"到達開放時間", "scheduled", scheduledAt.Add(-openBefore), services.ScheduleActionOpen},
+		{"已開放等待中", "waiting", scheduledAt.Add(-5 * time.Minute), services.ScheduleActionNone},
+		{"預定時間後寬限期內", "waiting", scheduledAt.Add(10 * time.Minute), services.ScheduleActionNone},
+		{"等待中超過寬限期", "waiting", scheduledAt.Add(31 * time.Minute), services.ScheduleActionCancel},
+		{"未開放且超過寬限期", "scheduled", scheduledAt.Add(time.Hour), services.ScheduleActionCancel},
+		{"已開播不處理", "live", scheduledAt.Add(time.Hour), services.ScheduleActionNone},
+		{"已取消不處理", "cancelled", scheduledAt.Add(time.Hour), services.ScheduleActionNone},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := services.ScheduledRoomAction(tt.status, scheduledAt, tt.now, openBefore, grace)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	assert.Equal(t, services.ScheduleActionNone, services.ScheduledRoomAction("scheduled", time.Time{}, scheduledAt, openBefore, grace))
+}
@@ -0,0 +1,100 @@
+package test
+
+import (
+	"testing"
+
+	"stream-demo/backend/database/models"
+	"stream-demo/backend/dto"
+	"stream-demo/backend/services"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsValidNotificationType(t *testing.T) {
+	for _, notificationType := range models.NotificationTypes {
+		assert.True(t, services.IsValidNotificationType(notificationType))
+	}
+	assert.False(t, services.IsValidNotificationType("system"))
+	assert.False(t, services.IsValidNotificationType(""))
+}
+
+func TestNotificationService_NotifyManySkipsDisabledUsers(t *testing.T) {
+	conf, mock := newMockDBConfig(t)
+	service := services.NewNotificationService(conf, nil)
+	notifier := &recordingNotifier{}
+	service.SetNotifier(notifier)
+
+	mock.ExpectQuery(`SELECT "user_id" FROM "notification_preferences" WHERE user_id IN \(\$1,\$2\) AND type = \$3 AND enabled = \$4`).
+		WithArgs(7, 8, models.NotificationNewFollower, false).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id"}).AddRow(8))
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO "notifications"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectCommit()
+
+	err := service.NotifyMany([]uint{7, 8}, models.NotificationNewFollower, "新的追隨者", "viewer 開始追蹤你", map[string]interface{}{"follower_id": 9})
+	require.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	// 關閉該類型通知的用戶不建立也不推送
+	assert.Equal(t, []uint{7}, notifier.userIDs)
+	assert.Equal(t, []string{services.NotificationEventCreated}, notifier.names)
+	notification, ok := notifier.events[0].(*dto.NotificationDTO)
+	require.True(t, ok)
+	assert.JSONEq(t, `{"follower_id":9}`, string(notification.Data))
+}
+
+func TestNotificationService_MarkReadPushesUnreadCount(t *testing.T) {
+	conf, mock := newMockDBConfig(t)
+	service := services.NewNotificationService(conf, nil)
+	notifier := &recordingNotifier{}
+	service.SetNotifier(notifier)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE "notifications" SET "read_at"=\$1 WHERE id = \$2 AND user_id = \$3 AND read_at IS NULL`).
+		WithArgs(sqlmock.AnyArg(), 3, 7).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	mock.ExpectQuery(`SELECT count\(\*\) FROM "notifications" WHERE user_id = \$1 AND read_at IS NULL`).
+		WithArgs(7).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(4))
+
+	unread, err := service.MarkRead(7, 3)
+	require.NoError(t, err)
+	assert.Equal(t, int64(4), unread)
+	assert.Equal(t, []string{services.NotificationEventUnreadCount}, notifier.names)
+	assert.Equal(t, map[string]interface{}{"unread_count": int64(4)}, notifier.events[0])
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestNotificationService_MarkReadOthersNotification(t *testing.T) {
+	conf, mock := newMockDBConfig(t)
+	service := services.NewNotificationService(conf, nil)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE "notifications" SET "read_at"=\$1`).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+	mock.ExpectQuery(`SELECT count\(\*\) FROM "notifications" WHERE id = \$1 AND user_id = \$2`).
+		WithArgs(3, 7).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+
+	_, err := service.MarkRead(7, 3)
+	assert.ErrorIs(t, err, services.ErrNotificationNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestNotificationService_UpdatePreferencesRejectsUnknownType(t *testing.T) {
+	conf, mock := newMockDBConfig(t)
+	service := services.NewNotificationService(conf, nil)
+
+	_, err := service.UpdatePreferences(7, []dto.NotificationPreferenceDTO{
+		{Type: models.NotificationNewFollower, Enabled: false},
+		{Type: "system", Enabled: false},
+	})
+	assert.Error(t, err)
+	// 有任何無效類型時不寫入任何設定
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
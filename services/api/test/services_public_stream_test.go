@@ -2,6 +2,15 @@ package test
 
 import (
 	"testing"
+	"time"
+
+	"stream-demo/backend/config"
+	"stream-demo/backend/services"
+	"stream-demo/backend/utils"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestPublicStreamService_GetAvailableStreams(t *testing.T) {
@@ -18,3 +27,40 @@ func TestPublicStreamService_GetStreamURL(t *testing.T) {
 	// 由於需要真實的 Redis 連接，我們跳過這些測試
 	t.Skip("PublicStreamService 需要真實的 Redis 連接，無法進行單元測試")
 }
+
+// newOfflinePublicStreamService 建立快取指向無法連線 Redis 的公開流服務，快取錯誤只記錄日誌
+func newOfflinePublicStreamService(t *testing.T, conf *config.Config) *services.PublicStreamService {
+	client := redis.NewClient(&redis.Options{Addr: "127.0.0.1:1"})
+	utils.RedisClient, utils.RedisSlaveClient = client, client
+	cache := utils.NewRedisCache(0, "", time.Minute)
+	utils.RedisClient, utils.RedisSlaveClient = nil, nil
+	t.Cleanup(func() { client.Close() })
+
+	service, err := services.NewPublicStreamService(conf, cache)
+	require.NoError(t, err)
+	return service
+}
+
+func TestPublicStreamService_GetStreamURLs(t *testing.T) {
+	conf := &config.Config{Configurations: &config.Configurations{}}
+	service := newOfflinePublicStreamService(t, conf)
+
+	// MPEG-TS 封裝只提供 HLS
+	urls, err := service.GetStreamURLs("mux_test")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"hls": "http://localhost:8083/mux_test/index.m3u8"}, urls)
+	assert.Equal(t, "hls", service.PreferredManifest())
+
+	// CMAF 封裝以同一組 fMP4 分段同時提供 HLS 與 DASH
+	conf.Video.Packaging = "CMAF"
+	urls, err = service.GetStreamURLs("mux_test")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"hls":  "http://localhost:8083/mux_test/index.m3u8",
+		"dash": "http://localhost:8083/mux_test/index.mpd",
+	}, urls)
+	assert.Equal(t, "dash", service.PreferredManifest())
+
+	_, err = service.GetStreamURLs("unknown")
+	assert.Error(t, err)
+}
@@ -0,0 +1,50 @@
+package test
+
+import (
+	"testing"
+
+	"stream-demo/backend/database/models"
+	postgresqlRepo "stream-demo/backend/repositories/postgresql"
+	"stream-demo/backend/services"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAudioTrackLanguages(t *testing.T) {
+	tracks := []models.VideoAudioTrack{
+		{Name: "audio_0", Language: "eng"},
+		{Name: "audio_1", Language: "jpn"},
+		{Name: "audio_2", Language: "eng"},
+		{Name: "audio_3", Language: "und"},
+	}
+
+	assert.Equal(t, []string{"eng", "jpn"}, services.AudioTrackLanguages(tracks))
+	assert.Empty(t, services.AudioTrackLanguages(nil))
+}
+
+func TestVideoService_GetVideoByIDIncludesAudioTracks(t *testing.T) {
+	conf, mock := newMockDBConfig(t)
+	videoService := &services.VideoService{Conf: conf, Repo: postgresqlRepo.NewPostgreSQLRepo(conf.DB["master"])}
+
+	expectVideo(mock, 10, 1, models.VideoVisibilityPublic, "ready")
+	mock.ExpectQuery(`SELECT \* FROM "video_qualities"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectQuery(`SELECT \* FROM "video_audio_tracks"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "video_id", "name", "language", "label", "is_default", "playlist_url"}).
+			AddRow(1, 10, "audio_0", "eng", "English", true, "audio_0/index.m3u8").
+			AddRow(2, 10, "audio_1", "jpn", "日本語", false, "audio_1/index.m3u8"))
+	mock.ExpectQuery(`SELECT \* FROM "video_clips"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectQuery(`SELECT \* FROM "video_captions"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	video, err := videoService.GetVideoByID(0, 10)
+	require.NoError(t, err)
+	require.Len(t, video.AudioTracks, 2)
+	assert.True(t, video.AudioTracks[0].IsDefault)
+	assert.Equal(t, "audio_1/index.m3u8", video.AudioTracks[1].PlaylistURL)
+	assert.Equal(t, []string{"eng", "jpn"}, video.AudioLanguages)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
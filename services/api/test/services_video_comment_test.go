@@ -0,0 +1,116 @@
+package test
+
+import (
+	"testing"
+
+	"stream-demo/backend/config"
+	"stream-demo/backend/database/models"
+	"stream-demo/backend/dto"
+	"stream-demo/backend/services"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newVideoCommentService 建立使用 sqlmock 的留言服務
+func newVideoCommentService(t *testing.T, bannedWordAction string) (*services.VideoCommentService, sqlmock.Sqlmock) {
+	conf, mock := newMockDBConfig(t)
+	conf.Video.Comments = config.CommentConfiguration{
+		MaxLength:        500,
+		BannedWords:      []string{"壞話"},
+		BannedWordAction: bannedWordAction,
+	}
+	return services.NewVideoCommentService(conf), mock
+}
+
+// expectComment 預期查詢一筆留言與留言者
+func expectComment(mock sqlmock.Sqlmock, id, videoID, userID uint, parentID interface{}) {
+	mock.ExpectQuery(`SELECT \* FROM "video_comments" WHERE "video_comments"\."id" = \$1`).
+		WithArgs(id, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "video_id", "user_id", "parent_id", "content", "status"}).
+			AddRow(id, videoID, userID, parentID, "留言", models.CommentStatusVisible))
+	mock.ExpectQuery(`SELECT \* FROM "users"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "username"}).AddRow(userID, "commenter"))
+}
+
+func TestFormatCommentTimestamp(t *testing.T) {
+	assert.Equal(t, "00:00", services.FormatCommentTimestamp(0))
+	assert.Equal(t, "02:31", services.FormatCommentTimestamp(151))
+	assert.Equal(t, "59:59", services.FormatCommentTimestamp(3599))
+	assert.Equal(t, "1:02:03", services.FormatCommentTimestamp(3723))
+}
+
+func TestCommentCursor(t *testing.T) {
+	cursor := services.EncodeCommentCursor(1718000000123456, 42)
+	key, id, err := services.DecodeCommentCursor(cursor)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1718000000123456), key)
+	assert.Equal(t, uint(42), id)
+
+	_, _, err = services.DecodeCommentCursor("not-a-cursor")
+	assert.Error(t, err)
+}
+
+func TestVideoCommentService_CreateCommentFlattensNestedReply(t *testing.T) {
+	service, mock := newVideoCommentService(t, config.BannedWordMask)
+
+	expectVideo(mock, 5, 1, models.VideoVisibilityPublic, "ready")
+	// 回覆其他回覆時掛在同一則主留言下，並記錄被回覆的用戶
+	expectComment(mock, 20, 5, 8, 10)
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO "video_comments"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(30))
+	mock.ExpectExec(`UPDATE "video_comments" SET "reply_count"=reply_count \+ 1 WHERE id = \$1`).
+		WithArgs(10).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	mock.ExpectQuery(`SELECT \* FROM "video_comments" WHERE "video_comments"\."id" = \$1`).
+		WithArgs(30, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "video_id", "user_id", "parent_id", "reply_to_user_id", "content", "status"}).
+			AddRow(30, 5, 7, 10, 8, "**", models.CommentStatusVisible))
+	mock.ExpectQuery(`SELECT \* FROM "users"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "username"}).AddRow(8, "commenter"))
+	mock.ExpectQuery(`SELECT \* FROM "users"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "username"}).AddRow(7, "viewer"))
+
+	parentID := uint(20)
+	comment, err := service.CreateComment(7, 5, &dto.VideoCommentCreateDTO{Content: "壞話", ParentID: &parentID})
+	require.NoError(t, err)
+	require.NotNil(t, comment.ParentID)
+	assert.Equal(t, uint(10), *comment.ParentID)
+	require.NotNil(t, comment.ReplyToUserID)
+	assert.Equal(t, uint(8), *comment.ReplyToUserID)
+	assert.Equal(t, "commenter", comment.ReplyToUsername)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestVideoCommentService_CreateCommentRejectsBannedWords(t *testing.T) {
+	service, mock := newVideoCommentService(t, config.BannedWordReject)
+
+	expectVideo(mock, 5, 1, models.VideoVisibilityPublic, "ready")
+
+	_, err := service.CreateComment(7, 5, &dto.VideoCommentCreateDTO{Content: "這是壞話"})
+	assert.Error(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestVideoCommentService_PinCommentRejectsReply(t *testing.T) {
+	service, mock := newVideoCommentService(t, config.BannedWordMask)
+
+	expectVideo(mock, 5, 1, models.VideoVisibilityPublic, "ready")
+	expectComment(mock, 30, 5, 7, 10)
+
+	assert.Error(t, service.PinComment(1, 5, 30))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestVideoCommentService_UpdateCommentRequiresAuthor(t *testing.T) {
+	service, mock := newVideoCommentService(t, config.BannedWordMask)
+
+	expectComment(mock, 30, 5, 8, nil)
+
+	_, err := service.UpdateComment(7, 5, 30, "改過的留言")
+	assert.Error(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
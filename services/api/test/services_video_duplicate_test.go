@@ -0,0 +1,112 @@
+package test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"stream-demo/backend/database/models"
+	"stream-demo/backend/dto"
+	"stream-demo/backend/services"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// expectDuplicateVideo 預期查詢一筆影片（含重複來源與共用轉碼欄位）與其擁有者
+func expectDuplicateVideo(mock sqlmock.Sqlmock, id, userID uint, status string, duplicateOfID, renditionsFromID interface{}, encrypted bool) {
+	mock.ExpectQuery(`SELECT \* FROM "videos"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "title", "status", "duplicate_of_id", "duplicate_match", "renditions_from_id", "hls_master_url", "encrypted"}).
+			AddRow(id, userID, "重複影片", status, duplicateOfID, services.DuplicateMatchSHA256, renditionsFromID, "https://cdn/videos/processed/7/1/master.m3u8", encrypted))
+	mock.ExpectQuery(`SELECT \* FROM "users"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "username"}).AddRow(userID, "owner"))
+}
+
+func TestVideoDuplicateService_LinkDuplicate(t *testing.T) {
+	conf, mock := newMockDBConfig(t)
+	service := services.NewVideoDuplicateService(conf)
+
+	expectDuplicateVideo(mock, 2, 7, "duplicate", 1, nil, false)
+	expectDuplicateVideo(mock, 1, 7, "ready", nil, nil, false)
+
+	// 複製轉碼結果與畫質記錄在同一個交易中完成
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE "videos" SET .*"renditions_from_id"=`).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(`SELECT \* FROM "video_qualities" WHERE video_id = \$1`).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "video_id", "quality"}).AddRow(10, 1, "720p"))
+	mock.ExpectQuery(`INSERT INTO "video_qualities"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(11))
+	mock.ExpectQuery(`SELECT \* FROM "video_audio_tracks" WHERE video_id = \$1`).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectCommit()
+
+	expectDuplicateVideo(mock, 2, 7, "ready", 1, 1, false)
+
+	video, err := service.LinkDuplicate(7, 2)
+	require.NoError(t, err)
+	assert.Equal(t, "ready", video.Status)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestVideoDuplicateService_LinkDuplicateRejectsEncryptedSource(t *testing.T) {
+	conf, mock := newMockDBConfig(t)
+	service := services.NewVideoDuplicateService(conf)
+
+	expectDuplicateVideo(mock, 2, 7, "duplicate", 1, nil, false)
+	expectDuplicateVideo(mock, 1, 7, "ready", nil, nil, true)
+
+	_, err := service.LinkDuplicate(7, 2)
+	assert.Error(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestVideoDuplicateService_LinkDuplicateRequiresPendingDuplicate(t *testing.T) {
+	conf, mock := newMockDBConfig(t)
+	service := services.NewVideoDuplicateService(conf)
+
+	expectDuplicateVideo(mock, 2, 7, "processing", nil, nil, false)
+
+	_, err := service.LinkDuplicate(7, 2)
+	assert.Error(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// TestNotificationService_NotifyTranscodeResultsOffersDuplicate converter 標記重複上傳後，通知擁有者共用轉碼結果
+func TestNotificationService_NotifyTranscodeResultsOffersDuplicate(t *testing.T) {
+	conf, mock := newMockDBConfig(t)
+	service := services.NewNotificationService(conf, nil)
+	notifier := &recordingNotifier{}
+	service.SetNotifier(notifier)
+
+	mock.ExpectQuery(`SELECT .*duplicate_of_id, duplicate_match FROM "videos" WHERE \(status IN \(\$1,\$2,\$3\)`).
+		WithArgs("ready", "failed", "duplicate", sqlmock.AnyArg(), 100).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "title", "status", "duplicate_of_id", "duplicate_match"}).
+			AddRow(2, 7, "重複影片", "duplicate", 1, services.DuplicateMatchPerceptual))
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE "videos" SET "notified_status"=\$1`).
+		WithArgs("duplicate", 2, "duplicate").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	mock.ExpectQuery(`FROM "notification_preferences"`).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id"}))
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO "notifications"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectCommit()
+
+	service.NotifyTranscodeResults()
+	require.NoError(t, mock.ExpectationsWereMet())
+
+	require.Len(t, notifier.events, 1)
+	notification, ok := notifier.events[0].(*dto.NotificationDTO)
+	require.True(t, ok)
+	assert.Equal(t, models.NotificationDuplicateUpload, notification.Type)
+
+	var data map[string]interface{}
+	require.NoError(t, json.Unmarshal(notification.Data, &data))
+	assert.EqualValues(t, 1, data["duplicate_of_id"])
+	assert.Equal(t, services.DuplicateMatchPerceptual, data["duplicate_match"])
+	assert.Equal(t, "/api/videos/2/duplicate/link", data["link_path"])
+}
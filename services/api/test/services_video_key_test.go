@@ -0,0 +1,201 @@
+package test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"stream-demo/backend/config"
+	"stream-demo/backend/database/models"
+	"stream-demo/backend/services"
+	"stream-demo/backend/utils"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newVideoKeyService 建立使用 sqlmock 與測試金鑰的內容金鑰服務
+func newVideoKeyService(t *testing.T) (*services.VideoKeyService, sqlmock.Sqlmock) {
+	conf, mock := newMockDBConfig(t)
+	conf.Video.Encryption = config.EncryptionConfiguration{
+		Enabled:     true,
+		KeySecret:   "key-secret",
+		TokenSecret: "token-secret",
+		TokenTTL:    300,
+	}
+	return services.NewVideoKeyService(conf), mock
+}
+
+// expectEncryptedVideo 預期查詢一筆加密影片與其擁有者
+func expectEncryptedVideo(mock sqlmock.Sqlmock, id, userID uint, visibility, status string, encrypted bool) {
+	mock.ExpectQuery(`SELECT \* FROM "videos"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "title", "visibility", "status", "encrypted"}).
+			AddRow(id, userID, "加密影片", visibility, status, encrypted))
+	mock.ExpectQuery(`SELECT \* FROM "users"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "username"}).AddRow(userID, "owner"))
+}
+
+// expectUnlistedVideo 預期查詢一筆帶分享代碼的不公開加密影片與其擁有者
+func expectUnlistedVideo(mock sqlmock.Sqlmock, id, userID uint, shareSlug string) {
+	mock.ExpectQuery(`SELECT \* FROM "videos"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "title", "visibility", "status", "encrypted", "share_slug"}).
+			AddRow(id, userID, "加密影片", models.VideoVisibilityUnlisted, "ready", true, shareSlug))
+	mock.ExpectQuery(`SELECT \* FROM "users"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "username"}).AddRow(userID, "owner"))
+}
+
+func TestPlaybackToken(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	token := services.SignPlaybackToken("secret", 7, 3, "", now.Add(time.Minute))
+
+	userID, shareSlug, err := services.VerifyPlaybackToken("secret", token, 7, "", now)
+	assert.NoError(t, err)
+	assert.Equal(t, uint(3), userID)
+	assert.Empty(t, shareSlug)
+
+	_, _, err = services.VerifyPlaybackToken("secret", token, 8, "", now)
+	assert.Error(t, err, "其他影片不能使用")
+
+	_, _, err = services.VerifyPlaybackToken("other", token, 7, "", now)
+	assert.Error(t, err, "簽章不符")
+
+	_, _, err = services.VerifyPlaybackToken("secret", token, 7, "", now.Add(2*time.Minute))
+	assert.Error(t, err, "已過期")
+
+	_, _, err = services.VerifyPlaybackToken("secret", "7.4"+token[3:], 7, "", now)
+	assert.Error(t, err, "竄改用戶")
+}
+
+func TestPlaybackTokenWithShareSlug(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	token := services.SignPlaybackToken("secret", 7, 3, "slug-1", now.Add(time.Minute))
+
+	userID, shareSlug, err := services.VerifyPlaybackToken("secret", token, 7, "slug-1", now)
+	assert.NoError(t, err)
+	assert.Equal(t, uint(3), userID)
+	assert.Equal(t, "slug-1", shareSlug)
+
+	// 分享連結重新產生或移除後憑證失效
+	_, _, err = services.VerifyPlaybackToken("secret", token, 7, "slug-2", now)
+	assert.Error(t, err)
+	_, _, err = services.VerifyPlaybackToken("secret", token, 7, "", now)
+	assert.Error(t, err)
+}
+
+func TestVideoKeyService_IssuePlaybackToken(t *testing.T) {
+	service, mock := newVideoKeyService(t)
+	expectEncryptedVideo(mock, 7, 1, models.VideoVisibilityPublic, "ready", true)
+
+	token, err := service.IssuePlaybackToken(3, 7, "")
+	require.NoError(t, err)
+	assert.Equal(t, "/api/videos/7/key", token.KeyURL)
+	assert.WithinDuration(t, time.Now().Add(300*time.Second), token.ExpiresAt, 5*time.Second)
+
+	// 簽發的憑證僅能用於同一部影片
+	expectEncryptedVideo(mock, 7, 1, models.VideoVisibilityPublic, "ready", true)
+	userID, shareSlug, err := service.VerifyPlaybackToken(token.Token, 7)
+	require.NoError(t, err)
+	assert.Equal(t, uint(3), userID)
+	assert.Empty(t, shareSlug)
+	expectEncryptedVideo(mock, 8, 1, models.VideoVisibilityPublic, "ready", true)
+	_, _, err = service.VerifyPlaybackToken(token.Token, 8)
+	assert.Error(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestVideoKeyService_IssuePlaybackTokenRejectsUnencryptedVideo(t *testing.T) {
+	service, mock := newVideoKeyService(t)
+	expectEncryptedVideo(mock, 7, 1, models.VideoVisibilityPublic, "ready", false)
+
+	_, err := service.IssuePlaybackToken(3, 7, "")
+	assert.Error(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestVideoKeyService_IssuePlaybackTokenRequiresShareGrant(t *testing.T) {
+	service, mock := newVideoKeyService(t)
+	expectEncryptedVideo(mock, 7, 1, models.VideoVisibilityPrivate, "ready", true)
+	mock.ExpectQuery(`SELECT count\(\*\) FROM "video_share_grants"`).
+		WithArgs(7, 3).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+
+	_, err := service.IssuePlaybackToken(3, 7, "")
+	assert.Error(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestVideoKeyService_IssuePlaybackTokenRequiresShareSlug(t *testing.T) {
+	service, mock := newVideoKeyService(t)
+
+	// 只知道影片ID不能播放不公開影片
+	expectUnlistedVideo(mock, 7, 1, "slug-1")
+	_, err := service.IssuePlaybackToken(3, 7, "")
+	assert.Error(t, err)
+
+	expectUnlistedVideo(mock, 7, 1, "slug-1")
+	_, err = service.IssuePlaybackToken(3, 7, "wrong-slug")
+	assert.Error(t, err)
+
+	expectUnlistedVideo(mock, 7, 1, "slug-1")
+	token, err := service.IssuePlaybackToken(3, 7, "slug-1")
+	require.NoError(t, err)
+
+	// 取得金鑰時以影片目前的分享代碼驗證並重新檢查權限
+	expectUnlistedVideo(mock, 7, 1, "slug-1")
+	userID, shareSlug, err := service.VerifyPlaybackToken(token.Token, 7)
+	require.NoError(t, err)
+	assert.Equal(t, uint(3), userID)
+	assert.Equal(t, "slug-1", shareSlug)
+
+	expectUnlistedVideo(mock, 7, 1, "slug-1")
+	_, err = service.AuthorizePlayback(userID, 7, shareSlug)
+	assert.NoError(t, err)
+
+	// 分享連結重新產生後舊憑證失效
+	expectUnlistedVideo(mock, 7, 1, "slug-2")
+	_, _, err = service.VerifyPlaybackToken(token.Token, 7)
+	assert.Error(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestVideoKeyService_GetContentKey(t *testing.T) {
+	service, mock := newVideoKeyService(t)
+	hexKey := strings.Repeat("ab", 16)
+	encrypted, err := utils.EncryptString("key-secret", hexKey)
+	require.NoError(t, err)
+
+	mock.ExpectQuery(`SELECT \* FROM "video_encryption_keys" WHERE video_id = \$1 AND key_index = \$2`).
+		WithArgs(7, 0, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "video_id", "key_index", "key"}).AddRow(1, 7, 0, encrypted))
+
+	key, err := service.GetContentKey(7, 0)
+	require.NoError(t, err)
+	assert.Len(t, key, 16)
+	assert.Equal(t, byte(0xab), key[0])
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestVideoService_GetVideoByIDHidesUnencryptedFiles(t *testing.T) {
+	conf, mock := newMockDBConfig(t)
+	service := services.NewVideoService(conf)
+
+	// 加密影片不回傳可繞過 AES-128 HLS 的原始檔與 MP4 網址
+	mock.ExpectQuery(`SELECT \* FROM "videos"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "title", "visibility", "status", "encrypted", "original_url", "mp4_url", "hls_master_url"}).
+			AddRow(7, 3, "加密影片", models.VideoVisibilityPublic, "ready", true,
+				"https://cdn/videos/original/3/input.mp4", "https://cdn/videos/processed/3/7/video.mp4", "https://cdn/videos/processed/3/7/hls/index.m3u8"))
+	mock.ExpectQuery(`SELECT \* FROM "users"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "username"}).AddRow(3, "owner"))
+	for _, table := range []string{"video_qualities", "video_audio_tracks", "video_clips", "video_captions"} {
+		mock.ExpectQuery(`SELECT \* FROM "` + table + `"`).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	}
+
+	video, err := service.GetVideoByID(0, 7)
+	require.NoError(t, err)
+	assert.True(t, video.Encrypted)
+	assert.Empty(t, video.OriginalURL)
+	assert.Empty(t, video.MP4URL)
+	assert.Equal(t, "https://cdn/videos/processed/3/7/hls/index.m3u8", video.HLSMasterURL)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
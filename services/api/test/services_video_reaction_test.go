@@ -0,0 +1,136 @@
+package test
+
+import (
+	"testing"
+
+	"stream-demo/backend/database/models"
+	"stream-demo/backend/services"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// expectReactionCounts 預期由資料庫統計影片反應數量（未啟用 Redis）
+func expectReactionCounts(mock sqlmock.Sqlmock, videoID uint, rows *sqlmock.Rows) {
+	mock.ExpectQuery(`SELECT type, emoji, COUNT\(\*\) AS count FROM "video_reactions" WHERE video_id = \$1`).
+		WithArgs(videoID).
+		WillReturnRows(rows)
+}
+
+func TestValidateReaction(t *testing.T) {
+	allowed := []string{"❤️", "😂"}
+	assert.NoError(t, services.ValidateReaction("like", "", allowed))
+	assert.NoError(t, services.ValidateReaction("dislike", "", allowed))
+	assert.NoError(t, services.ValidateReaction("emoji", "😂", allowed))
+	assert.Error(t, services.ValidateReaction("like", "😂", allowed))
+	assert.Error(t, services.ValidateReaction("emoji", "🤖", allowed))
+	assert.Error(t, services.ValidateReaction("emoji", "", allowed))
+	assert.Error(t, services.ValidateReaction("love", "", allowed))
+}
+
+func TestReactionCounterField(t *testing.T) {
+	assert.Equal(t, "like", services.ReactionCounterField("like", ""))
+	assert.Equal(t, "dislike", services.ReactionCounterField("dislike", ""))
+	assert.Equal(t, "emoji:😂", services.ReactionCounterField("emoji", "😂"))
+}
+
+func TestVideoReactionService_SetReactionWithoutRedis(t *testing.T) {
+	conf, mock := newMockDBConfig(t)
+	conf.Video.Reactions.Emojis = []string{"😂"}
+	service := services.NewVideoReactionService(conf)
+
+	expectVideo(mock, 5, 1, models.VideoVisibilityPublic, "ready")
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT \* FROM "video_reactions" WHERE video_id = \$1 AND user_id = \$2 .*FOR UPDATE`).
+		WithArgs(5, 7, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectQuery(`INSERT INTO "video_reactions" .*ON CONFLICT DO NOTHING`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectCommit()
+
+	// 未啟用 Redis 時直接由資料庫重算並寫回影片的喜歡數
+	expectReactionCounts(mock, 5, sqlmock.NewRows([]string{"type", "emoji", "count"}).
+		AddRow(models.ReactionLike, "", 3).
+		AddRow(models.ReactionEmoji, "😂", 2))
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE "videos" SET "dislikes"=\$1,"likes"=\$2 WHERE id = \$3`).
+		WithArgs(0, 3, 5).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	expectVideo(mock, 5, 1, models.VideoVisibilityPublic, "ready")
+	expectReactionCounts(mock, 5, sqlmock.NewRows([]string{"type", "emoji", "count"}).
+		AddRow(models.ReactionLike, "", 3).
+		AddRow(models.ReactionEmoji, "😂", 2))
+	mock.ExpectQuery(`SELECT \* FROM "video_reactions" WHERE video_id = \$1 AND user_id = \$2`).
+		WithArgs(5, 7, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "video_id", "user_id", "type", "emoji"}).
+			AddRow(1, 5, 7, models.ReactionLike, ""))
+
+	summary, err := service.SetReaction(7, 5, models.ReactionLike, "", "")
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), summary.Likes)
+	assert.Equal(t, int64(0), summary.Dislikes)
+	assert.Equal(t, map[string]int64{"😂": 2}, summary.Emojis)
+	assert.Equal(t, models.ReactionLike, summary.MyReaction)
+	assert.True(t, summary.LikedByMe)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestVideoReactionService_SetReactionRejectsUnknownEmoji(t *testing.T) {
+	conf, mock := newMockDBConfig(t)
+	conf.Video.Reactions.Emojis = []string{"😂"}
+	service := services.NewVideoReactionService(conf)
+
+	_, err := service.SetReaction(7, 5, models.ReactionEmoji, "🤖", "")
+	assert.Error(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestVideoReactionService_SetReactionRequiresVisibleVideo(t *testing.T) {
+	conf, mock := newMockDBConfig(t)
+	service := services.NewVideoReactionService(conf)
+
+	expectVideo(mock, 5, 1, models.VideoVisibilityPrivate, "ready")
+	mock.ExpectQuery(`SELECT count\(\*\) FROM "video_share_grants"`).
+		WithArgs(5, 7).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+
+	_, err := service.SetReaction(7, 5, models.ReactionLike, "", "")
+	assert.ErrorContains(t, err, services.ErrVideoNotVisible.Error())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestVideoReactionService_SetReactionRequiresShareSlugForUnlisted(t *testing.T) {
+	conf, mock := newMockDBConfig(t)
+	service := services.NewVideoReactionService(conf)
+
+	// 只知道影片ID不能對不公開影片反應
+	expectSharedVideo(mock, 5, 1, models.VideoVisibilityUnlisted, "slug-1")
+	_, err := service.SetReaction(7, 5, models.ReactionLike, "", "")
+	assert.ErrorContains(t, err, services.ErrVideoNotVisible.Error())
+
+	expectSharedVideo(mock, 5, 1, models.VideoVisibilityUnlisted, "slug-1")
+	_, err = service.SetReaction(7, 5, models.ReactionLike, "", "wrong-slug")
+	assert.ErrorContains(t, err, services.ErrVideoNotVisible.Error())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestVideoReactionService_GetSummaryWithShareSlug(t *testing.T) {
+	conf, mock := newMockDBConfig(t)
+	service := services.NewVideoReactionService(conf)
+
+	expectSharedVideo(mock, 5, 1, models.VideoVisibilityUnlisted, "slug-1")
+	expectReactionCounts(mock, 5, sqlmock.NewRows([]string{"type", "emoji", "count"}).
+		AddRow(models.ReactionLike, "", 4))
+	mock.ExpectQuery(`SELECT \* FROM "video_reactions" WHERE video_id = \$1 AND user_id = \$2`).
+		WithArgs(5, 7, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	summary, err := service.GetSummary(7, 5, "slug-1")
+	require.NoError(t, err)
+	assert.Equal(t, int64(4), summary.Likes)
+	assert.Empty(t, summary.MyReaction)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
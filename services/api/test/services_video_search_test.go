@@ -0,0 +1,67 @@
+package test
+
+import (
+	"testing"
+	"time"
+
+	"stream-demo/backend/dto"
+	postgresqlRepo "stream-demo/backend/repositories/postgresql"
+	"stream-demo/backend/services"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSearchDurationRange(t *testing.T) {
+	min, max := services.SearchDurationRange("short")
+	assert.Equal(t, 0, min)
+	assert.Equal(t, 240, max)
+
+	min, max = services.SearchDurationRange("medium")
+	assert.Equal(t, 240, min)
+	assert.Equal(t, 1200, max)
+
+	min, max = services.SearchDurationRange("long")
+	assert.Equal(t, 1200, min)
+	assert.Equal(t, 0, max, "長影片不限上限")
+
+	min, max = services.SearchDurationRange("")
+	assert.Equal(t, 0, min)
+	assert.Equal(t, 0, max)
+}
+
+func TestSearchUploadDateSince(t *testing.T) {
+	now := time.Date(2024, 3, 15, 18, 30, 0, 0, time.UTC)
+	assert.Equal(t, time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC), services.SearchUploadDateSince("today", now))
+	assert.Equal(t, time.Date(2024, 3, 8, 18, 30, 0, 0, time.UTC), services.SearchUploadDateSince("week", now))
+	assert.Equal(t, time.Date(2024, 2, 15, 18, 30, 0, 0, time.UTC), services.SearchUploadDateSince("month", now))
+	assert.Equal(t, time.Date(2023, 3, 15, 18, 30, 0, 0, time.UTC), services.SearchUploadDateSince("year", now))
+	assert.True(t, services.SearchUploadDateSince("", now).IsZero())
+}
+
+// TestVideoService_SearchVideosWithoutTrigram 未安裝 pg_trgm 時搜尋不使用 % 運算子與 similarity()
+func TestVideoService_SearchVideosWithoutTrigram(t *testing.T) {
+	conf, mock := newMockDBConfig(t)
+	service := &services.VideoService{Conf: conf, RepoSlave: postgresqlRepo.NewPostgreSQLRepo(conf.DB["slave"])}
+
+	mock.ExpectQuery(`FROM pg_extension WHERE extname = 'pg_trgm'`).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+	mock.ExpectQuery(`SELECT count\(\*\) FROM "videos" WHERE .*videos\.description ILIKE \$\d+\)\) AND`).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	mock.ExpectQuery(`videos\.description ILIKE \$\d+\)\) AND .*ORDER BY ts_rank\([^+]*\) DESC, videos\.id DESC`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectQuery(`videos\.description ILIKE \$\d+\)\) AND .*GROUP BY "value"`).
+		WillReturnRows(sqlmock.NewRows([]string{"value", "count"}))
+	mock.ExpectQuery(`videos\.description ILIKE \$\d+\)\) AND .*GROUP BY "videos"\."status"`).
+		WillReturnRows(sqlmock.NewRows([]string{"value", "count"}))
+	mock.ExpectQuery(`COUNT\(\*\) FILTER`).
+		WillReturnRows(sqlmock.NewRows([]string{"c0", "c1", "c2", "c3"}).AddRow(0, 0, 0, 0))
+	mock.ExpectQuery(`JOIN users ON users\.id = videos\.user_id`).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id", "username", "count"}))
+
+	result, err := service.SearchVideos(0, &dto.VideoSearchDTO{Query: "直播"})
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), result.Total)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,94 @@
+package test
+
+import (
+	"testing"
+	"time"
+
+	"stream-demo/backend/services"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// expectTrashedVideo 預期查詢一筆垃圾桶中的影片
+func expectTrashedVideo(mock sqlmock.Sqlmock, id, userID uint, deletedAt time.Time) {
+	mock.ExpectQuery(`SELECT \* FROM "videos" WHERE id = \$1 AND deleted_at IS NOT NULL`).
+		WithArgs(id, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "title", "status", "deleted_at"}).
+			AddRow(id, userID, "已刪除影片", "ready", deletedAt))
+}
+
+func TestTrashPurgeAt(t *testing.T) {
+	deletedAt := time.Date(2024, 1, 15, 8, 0, 0, 0, time.UTC)
+	assert.Equal(t, time.Date(2024, 2, 14, 8, 0, 0, 0, time.UTC), services.TrashPurgeAt(deletedAt, 30))
+	assert.Equal(t, deletedAt, services.TrashPurgeAt(deletedAt, 0))
+}
+
+func TestParseProcessedVideoPrefix(t *testing.T) {
+	videoID, ok := services.ParseProcessedVideoPrefix("videos/processed/3/42/")
+	assert.True(t, ok)
+	assert.Equal(t, uint(42), videoID)
+
+	_, ok = services.ParseProcessedVideoPrefix("videos/processed/3/")
+	assert.False(t, ok)
+	_, ok = services.ParseProcessedVideoPrefix("videos/processed/3/abc/")
+	assert.False(t, ok)
+	_, ok = services.ParseProcessedVideoPrefix("clips/processed/3/42/")
+	assert.False(t, ok)
+}
+
+func TestVideoTrashService_RestoreVideo(t *testing.T) {
+	conf, mock := newMockDBConfig(t)
+	conf.Video.Trash.RetentionDays = 30
+	service := services.NewVideoTrashService(conf, nil)
+
+	expectTrashedVideo(mock, 5, 7, time.Now().AddDate(0, 0, -1))
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE "videos" SET "deleted_at"=\$1`).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	video, err := service.RestoreVideo(7, 5)
+	require.NoError(t, err)
+	assert.Equal(t, uint(5), video.ID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestVideoTrashService_RestoreVideoRejectsExpired(t *testing.T) {
+	conf, mock := newMockDBConfig(t)
+	conf.Video.Trash.RetentionDays = 30
+	service := services.NewVideoTrashService(conf, nil)
+
+	// 保留期滿後垃圾回收可能正在清除檔案
+	expectTrashedVideo(mock, 5, 7, time.Now().AddDate(0, 0, -31))
+
+	_, err := service.RestoreVideo(7, 5)
+	assert.Error(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestVideoTrashService_RestoreVideoRequiresOwner(t *testing.T) {
+	conf, mock := newMockDBConfig(t)
+	conf.Video.Trash.RetentionDays = 30
+	service := services.NewVideoTrashService(conf, nil)
+
+	expectTrashedVideo(mock, 5, 8, time.Now().AddDate(0, 0, -1))
+
+	_, err := service.RestoreVideo(7, 5)
+	assert.Error(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestVideoTrashService_RestoreVideoNotInTrash(t *testing.T) {
+	conf, mock := newMockDBConfig(t)
+	service := services.NewVideoTrashService(conf, nil)
+
+	mock.ExpectQuery(`SELECT \* FROM "videos" WHERE id = \$1 AND deleted_at IS NOT NULL`).
+		WithArgs(5, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	_, err := service.RestoreVideo(7, 5)
+	assert.EqualError(t, err, "垃圾桶中沒有此影片")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
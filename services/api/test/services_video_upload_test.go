@@ -0,0 +1,129 @@
+package test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"stream-demo/backend/pkg/storage"
+	"stream-demo/backend/services"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeS3 記錄收到的 PutObject 請求內容，HeadObject 依記錄回應檔案大小
+type fakeS3 struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+}
+
+// newFakeS3Storage 建立指向本地假 S3 端點的儲存服務
+func newFakeS3Storage(t *testing.T) (*storage.S3Storage, *fakeS3) {
+	fake := &fakeS3{objects: map[string][]byte{}}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := strings.TrimPrefix(r.URL.Path, "/test-bucket/")
+		switch r.Method {
+		case http.MethodPut:
+			body, _ := io.ReadAll(r.Body)
+			fake.mu.Lock()
+			fake.objects[key] = body
+			fake.mu.Unlock()
+			w.Header().Set("ETag", `"etag"`)
+			w.WriteHeader(http.StatusOK)
+		case http.MethodHead:
+			fake.mu.Lock()
+			body, ok := fake.objects[key]
+			fake.mu.Unlock()
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+			w.WriteHeader(http.StatusOK)
+		default:
+			w.WriteHeader(http.StatusNotImplemented)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	s3Storage, err := storage.NewS3Storage(storage.S3Config{
+		AccessKey: "test-key",
+		SecretKey: "test-secret",
+		Region:    "us-east-1",
+		Bucket:    "test-bucket",
+		Endpoint:  server.URL,
+	})
+	require.NoError(t, err)
+	return s3Storage, fake
+}
+
+func TestUploadPercent(t *testing.T) {
+	assert.Equal(t, -1, services.UploadPercent(100, 0))
+	assert.Equal(t, 0, services.UploadPercent(0, 1000))
+	assert.Equal(t, 50, services.UploadPercent(500, 1000))
+	assert.Equal(t, 99, services.UploadPercent(1000, 1000), "完成前不回報 100")
+}
+
+func TestVideoUploadService_StreamUploadVideo(t *testing.T) {
+	conf, mock := newMockDBConfig(t)
+	conf.Video.MaxFileSize = 1024
+	s3Storage, fake := newFakeS3Storage(t)
+	service := services.NewVideoUploadService(conf, nil, s3Storage)
+
+	content := "fake video content"
+	sum := sha256.Sum256([]byte(content))
+	contentSHA256 := hex.EncodeToString(sum[:])
+
+	mock.ExpectQuery(`SELECT \* FROM "videos" WHERE \(user_id = \$1 AND content_sha256 = \$2`).
+		WithArgs(7, contentSHA256, "ready", 0, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO "videos"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(11))
+	mock.ExpectCommit()
+
+	uploaded, err := service.StreamUploadVideo(context.Background(), 7, "upload_1", "標題", "", "movie.MP4", strings.NewReader(content), int64(len(content)))
+	require.NoError(t, err)
+	assert.Equal(t, int64(len(content)), uploaded.Size)
+	assert.Equal(t, contentSHA256, uploaded.SHA256)
+	assert.Equal(t, "processing", uploaded.Video.Status)
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	require.Len(t, fake.objects, 1)
+	for key, body := range fake.objects {
+		assert.True(t, strings.HasPrefix(key, "videos/original/7/"))
+		assert.True(t, strings.HasSuffix(key, ".mp4"))
+		assert.Equal(t, content, string(body))
+	}
+}
+
+func TestVideoUploadService_StreamUploadVideoRejectsOversizedBody(t *testing.T) {
+	conf, mock := newMockDBConfig(t)
+	conf.Video.MaxFileSize = 4
+	s3Storage, _ := newFakeS3Storage(t)
+	service := services.NewVideoUploadService(conf, nil, s3Storage)
+
+	_, err := service.StreamUploadVideo(context.Background(), 7, "upload_1", "標題", "", "movie.mp4", strings.NewReader("too large"), 9)
+	assert.ErrorIs(t, err, services.ErrVideoUploadTooLarge)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestVideoUploadService_StreamUploadVideoRejectsFormat(t *testing.T) {
+	conf, mock := newMockDBConfig(t)
+	s3Storage, fake := newFakeS3Storage(t)
+	service := services.NewVideoUploadService(conf, nil, s3Storage)
+
+	_, err := service.StreamUploadVideo(context.Background(), 7, "upload_1", "標題", "", "setup.exe", strings.NewReader("binary"), 6)
+	assert.ErrorIs(t, err, services.ErrUnsupportedVideoFormat)
+	assert.Empty(t, fake.objects)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,111 @@
+package test
+
+import (
+	"testing"
+
+	"stream-demo/backend/database/models"
+	"stream-demo/backend/services"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// expectSharedVideo 預期查詢一筆帶分享代碼的影片與其擁有者
+func expectSharedVideo(mock sqlmock.Sqlmock, id, userID uint, visibility string, shareSlug interface{}) {
+	mock.ExpectQuery(`SELECT \* FROM "videos"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "title", "visibility", "status", "share_slug"}).
+			AddRow(id, userID, "分享影片", visibility, "ready", shareSlug))
+	mock.ExpectQuery(`SELECT \* FROM "users"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "username"}).AddRow(userID, "owner"))
+}
+
+func TestVideoVisibleTo(t *testing.T) {
+	tests := []struct {
+		name       string
+		visibility string
+		viewerID   uint
+		granted    bool
+		want       bool
+	}{
+		{"公開影片", models.VideoVisibilityPublic, 2, false, true},
+		{"舊資料未設定可見度", "", 2, false, true},
+		{"擁有者觀看私人影片", models.VideoVisibilityPrivate, 1, false, true},
+		{"未授權觀看私人影片", models.VideoVisibilityPrivate, 2, false, false},
+		{"已授權觀看私人影片", models.VideoVisibilityPrivate, 2, true, true},
+		{"以ID觀看不公開影片", models.VideoVisibilityUnlisted, 2, true, false},
+		{"擁有者觀看不公開影片", models.VideoVisibilityUnlisted, 1, false, true},
+		{"非追隨者觀看追隨者限定影片", models.VideoVisibilityFollowers, 2, false, false},
+		{"追隨者觀看追隨者限定影片", models.VideoVisibilityFollowers, 2, true, true},
+		{"未登入觀看私人影片", models.VideoVisibilityPrivate, 0, false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, services.VideoVisibleTo(tt.visibility, 1, tt.viewerID, tt.granted))
+		})
+	}
+}
+
+func TestIsValidVideoVisibility(t *testing.T) {
+	assert.True(t, services.IsValidVideoVisibility("public"))
+	assert.True(t, services.IsValidVideoVisibility("unlisted"))
+	assert.True(t, services.IsValidVideoVisibility("private"))
+	assert.True(t, services.IsValidVideoVisibility("followers"))
+	assert.False(t, services.IsValidVideoVisibility("friends"))
+	assert.False(t, services.IsValidVideoVisibility(""))
+}
+
+func TestVideoVisibilityService_UpdateVisibilityCreatesShareSlug(t *testing.T) {
+	conf, mock := newMockDBConfig(t)
+	service := services.NewVideoVisibilityService(conf)
+
+	expectSharedVideo(mock, 5, 7, models.VideoVisibilityPublic, nil)
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE "videos" SET "share_slug"=\$1,"updated_at"=\$2,"visibility"=\$3`).
+		WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), models.VideoVisibilityUnlisted, 5).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	visibility, err := service.UpdateVisibility(7, 5, models.VideoVisibilityUnlisted)
+	require.NoError(t, err)
+	assert.Equal(t, models.VideoVisibilityUnlisted, visibility.Visibility)
+	assert.NotEmpty(t, visibility.ShareSlug)
+	assert.Equal(t, "/api/videos/shared/"+visibility.ShareSlug, visibility.SharePath)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestVideoVisibilityService_UpdateVisibilityKeepsShareSlug(t *testing.T) {
+	conf, mock := newMockDBConfig(t)
+	service := services.NewVideoVisibilityService(conf)
+
+	// 改回不公開時沿用原連結
+	expectSharedVideo(mock, 5, 7, models.VideoVisibilityPrivate, "old-slug")
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE "videos" SET "updated_at"=\$1,"visibility"=\$2`).
+		WithArgs(sqlmock.AnyArg(), models.VideoVisibilityUnlisted, 5).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	visibility, err := service.UpdateVisibility(7, 5, models.VideoVisibilityUnlisted)
+	require.NoError(t, err)
+	assert.Equal(t, "old-slug", visibility.ShareSlug)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestVideoVisibilityService_GetSharedVideoRequiresUnlisted(t *testing.T) {
+	conf, mock := newMockDBConfig(t)
+	service := services.NewVideoVisibilityService(conf)
+
+	// 改為私人後舊分享連結不再有效
+	mock.ExpectQuery(`SELECT \* FROM "videos" WHERE share_slug = \$1`).
+		WithArgs("old-slug", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "visibility", "status", "share_slug"}).
+			AddRow(5, 7, models.VideoVisibilityPrivate, "ready", "old-slug"))
+	mock.ExpectQuery(`SELECT \* FROM "users"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "username"}).AddRow(7, "owner"))
+
+	_, err := service.GetSharedVideo("old-slug")
+	assert.ErrorIs(t, err, services.ErrVideoNotVisible)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,208 @@
+package test
+
+import (
+	"database/sql/driver"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"stream-demo/backend/config"
+	"stream-demo/backend/database/models"
+	"stream-demo/backend/services"
+	"stream-demo/backend/utils"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const webhookTestPayload = `{"id":"evt_1","type":"video.ready"}`
+
+// timeWithin 比對時間參數是否落在預期時間的誤差範圍內
+type timeWithin struct {
+	expected time.Time
+	delta    time.Duration
+}
+
+// Match 實作 sqlmock.Argument
+func (a timeWithin) Match(value driver.Value) bool {
+	actual, ok := value.(time.Time)
+	if !ok {
+		return false
+	}
+	diff := actual.Sub(a.expected)
+	return diff >= -a.delta && diff <= a.delta
+}
+
+// newWebhookService 建立允許投遞到本機測試伺服器的 Webhook 服務
+func newWebhookService(t *testing.T) (*services.WebhookService, sqlmock.Sqlmock) {
+	conf, mock := newMockDBConfig(t)
+	conf.Webhook = config.WebhookConfiguration{
+		Timeout:              5,
+		MaxAttempts:          3,
+		BackoffBase:          30,
+		BackoffMax:           600,
+		Concurrency:          1,
+		AllowPrivateNetworks: true,
+	}
+	return services.NewWebhookService(conf, nil), mock
+}
+
+// expectWebhookDelivery 預期查詢一筆投遞記錄與其端點
+func expectWebhookDelivery(mock sqlmock.Sqlmock, attempts int, endpointURL string, enabled bool) {
+	mock.ExpectQuery(`SELECT \* FROM "webhook_deliveries" WHERE "webhook_deliveries"\."id" = \$1`).
+		WithArgs(3, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "endpoint_id", "event_id", "event_type", "payload", "status", "attempts"}).
+			AddRow(3, 1, "evt_1", models.WebhookEventVideoReady, webhookTestPayload, models.WebhookDeliveryPending, attempts))
+	mock.ExpectQuery(`SELECT \* FROM "webhook_endpoints" WHERE "webhook_endpoints"\."id" = \$1`).
+		WithArgs(1, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "url", "secret", "events", "enabled"}).
+			AddRow(1, 7, endpointURL, "whsec_test", models.WebhookEventVideoReady, enabled))
+}
+
+// expectWebhookDeliveryUpdate 預期保存投遞結果
+func expectWebhookDeliveryUpdate(mock sqlmock.Sqlmock, status string, attempts, responseStatus int, responseBody, errorMessage string, nextAttemptAt, deliveredAt interface{}) {
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE "webhook_deliveries" SET`).
+		WithArgs(1, "evt_1", models.WebhookEventVideoReady, webhookTestPayload, status, attempts, responseStatus, responseBody, errorMessage,
+			nextAttemptAt, deliveredAt, nil, sqlmock.AnyArg(), sqlmock.AnyArg(), 3).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+}
+
+func TestSignWebhookPayload(t *testing.T) {
+	body := []byte(`{"id":"1","type":"video.ready"}`)
+	signature := services.SignWebhookPayload("whsec_test", 1718000000, body)
+	assert.Equal(t, signature, services.SignWebhookPayload("whsec_test", 1718000000, body))
+	assert.Contains(t, signature, "sha256=")
+	assert.Len(t, signature, len("sha256=")+64)
+
+	assert.NotEqual(t, signature, services.SignWebhookPayload("whsec_other", 1718000000, body))
+	assert.NotEqual(t, signature, services.SignWebhookPayload("whsec_test", 1718000001, body))
+}
+
+func TestWebhookBackoff(t *testing.T) {
+	base := 30 * time.Second
+	max := 10 * time.Minute
+	assert.Equal(t, 30*time.Second, services.WebhookBackoff(1, base, max))
+	assert.Equal(t, 60*time.Second, services.WebhookBackoff(2, base, max))
+	assert.Equal(t, 240*time.Second, services.WebhookBackoff(4, base, max))
+	assert.Equal(t, max, services.WebhookBackoff(6, base, max))
+	assert.Equal(t, max, services.WebhookBackoff(50, base, max))
+}
+
+func TestWebhookEventForMessage(t *testing.T) {
+	eventType, ownerID, ok := services.WebhookEventForMessage("video_processing", &utils.Message{
+		Type:    "status_update",
+		Payload: map[string]interface{}{"video_id": float64(1), "status": "ready", "user_id": float64(7)},
+	})
+	assert.True(t, ok)
+	assert.Equal(t, models.WebhookEventVideoReady, eventType)
+	assert.Equal(t, uint(7), ownerID)
+
+	eventType, ownerID, ok = services.WebhookEventForMessage("live_updates", &utils.Message{
+		Type:    "live_ended",
+		Payload: map[string]interface{}{"room_id": "room_1", "creator_id": float64(3)},
+	})
+	assert.True(t, ok)
+	assert.Equal(t, models.WebhookEventLiveEnded, eventType)
+	assert.Equal(t, uint(3), ownerID)
+
+	eventType, _, ok = services.WebhookEventForMessage("payment_events", &utils.Message{
+		Type:    "completed",
+		Payload: map[string]interface{}{"payment_id": float64(9), "user_id": float64(2)},
+	})
+	assert.True(t, ok)
+	assert.Equal(t, models.WebhookEventPaymentCompleted, eventType)
+
+	// 處理中的影片與舊版以 live_id 發布的直播事件不投遞
+	_, _, ok = services.WebhookEventForMessage("video_processing", &utils.Message{
+		Payload: map[string]interface{}{"status": "processing", "user_id": float64(7)},
+	})
+	assert.False(t, ok)
+	_, _, ok = services.WebhookEventForMessage("live_updates", &utils.Message{
+		Type:    "live_started",
+		Payload: map[string]interface{}{"live_id": float64(1)},
+	})
+	assert.False(t, ok)
+}
+
+func TestWebhookService_DeliverSignsRequest(t *testing.T) {
+	var received *http.Request
+	var receivedBody []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r
+		receivedBody, _ = io.ReadAll(r.Body)
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	service, mock := newWebhookService(t)
+	expectWebhookDelivery(mock, 0, server.URL, true)
+	expectWebhookDeliveryUpdate(mock, models.WebhookDeliverySucceeded, 1, http.StatusOK, "ok", "", nil, sqlmock.AnyArg())
+
+	service.Deliver(3)
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	require.NotNil(t, received)
+	assert.Equal(t, http.MethodPost, received.Method)
+	assert.Equal(t, webhookTestPayload, string(receivedBody))
+	assert.Equal(t, models.WebhookEventVideoReady, received.Header.Get("X-Webhook-Event"))
+	assert.Equal(t, "evt_1", received.Header.Get("X-Webhook-Event-ID"))
+	assert.Equal(t, "3", received.Header.Get("X-Webhook-Delivery"))
+
+	// 接收端以時間戳記與內容驗證簽章
+	timestamp, err := strconv.ParseInt(received.Header.Get("X-Webhook-Timestamp"), 10, 64)
+	require.NoError(t, err)
+	assert.Equal(t, services.SignWebhookPayload("whsec_test", timestamp, receivedBody), received.Header.Get("X-Webhook-Signature"))
+}
+
+func TestWebhookService_DeliverSchedulesRetry(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte("boom"))
+	}))
+	defer server.Close()
+
+	service, mock := newWebhookService(t)
+	expectWebhookDelivery(mock, 1, server.URL, true)
+	// 第二次失敗後依指數退避等待 60 秒
+	expectWebhookDeliveryUpdate(mock, models.WebhookDeliveryPending, 2, http.StatusInternalServerError, "boom", "端點回應 500",
+		timeWithin{expected: time.Now().Add(60 * time.Second), delta: 5 * time.Second}, nil)
+
+	service.Deliver(3)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestWebhookService_DeliverFailsAfterMaxAttempts(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	service, mock := newWebhookService(t)
+	expectWebhookDelivery(mock, 2, server.URL, true)
+	expectWebhookDeliveryUpdate(mock, models.WebhookDeliveryFailed, 3, http.StatusBadGateway, "", "端點回應 502", nil, nil)
+
+	service.Deliver(3)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestWebhookService_DeliverSkipsDisabledEndpoint(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+	}))
+	defer server.Close()
+
+	service, mock := newWebhookService(t)
+	expectWebhookDelivery(mock, 0, server.URL, false)
+	expectWebhookDeliveryUpdate(mock, models.WebhookDeliveryFailed, 0, 0, "", "端點已停用或已刪除", nil, nil)
+
+	service.Deliver(3)
+	assert.NoError(t, mock.ExpectationsWereMet())
+	assert.Zero(t, requests)
+}
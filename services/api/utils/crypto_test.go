@@ -0,0 +1,61 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncryptDecryptString(t *testing.T) {
+	secret := "test-secret"
+	plaintext := "live_sk_abcdef123456"
+
+	ciphertext, err := EncryptString(secret, plaintext)
+	assert.NoError(t, err)
+	assert.NotEqual(t, plaintext, ciphertext)
+
+	// 同一明文每次加密結果應不同
+	ciphertext2, err := EncryptString(secret, plaintext)
+	assert.NoError(t, err)
+	assert.NotEqual(t, ciphertext, ciphertext2)
+
+	decrypted, err := DecryptString(secret, ciphertext)
+	assert.NoError(t, err)
+	assert.Equal(t, plaintext, decrypted)
+}
+
+func TestDecryptString_WrongSecret(t *testing.T) {
+	ciphertext, err := EncryptString("secret-a", "value")
+	assert.NoError(t, err)
+
+	_, err = DecryptString("secret-b", ciphertext)
+	assert.Error(t, err)
+}
+
+func TestDecryptString_InvalidInput(t *testing.T) {
+	_, err := DecryptString("secret", "not-base64!!")
+	assert.Error(t, err)
+
+	_, err = DecryptString("secret", "YWJj")
+	assert.Error(t, err)
+
+	_, err = EncryptString("", "value")
+	assert.Error(t, err)
+}
+
+func TestMaskSecret(t *testing.T) {
+	assert.Equal(t, "********3456", MaskSecret("abcdefgh3456"))
+	assert.Equal(t, "***", MaskSecret("abc"))
+	assert.Equal(t, "", MaskSecret(""))
+}
+
+func TestRandomToken(t *testing.T) {
+	token, err := RandomToken(16)
+	assert.NoError(t, err)
+	assert.Len(t, token, 22)
+	assert.NotContains(t, token, "=")
+
+	other, err := RandomToken(16)
+	assert.NoError(t, err)
+	assert.NotEqual(t, token, other)
+}
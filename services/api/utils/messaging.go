@@ -132,12 +132,19 @@ func (m *RedisMessaging) Publish(channel string, messageType string, payload map
 }
 
 // PublishVideoProcessing 發布影片處理訊息
-func (m *RedisMessaging) PublishVideoProcessing(videoID uint, status string, progress int) error {
-	return m.Publish("video_processing", "status_update", map[string]interface{}{
+func (m *RedisMessaging) PublishVideoProcessing(videoID uint, status string, progress int, data map[string]interface{}) error {
+	payload := map[string]interface{}{
 		"video_id": videoID,
 		"status":   status,
 		"progress": progress,
-	})
+	}
+
+	// 合併額外數據
+	for k, v := range data {
+		payload[k] = v
+	}
+
+	return m.Publish("video_processing", "status_update", payload)
 }
 
 // PublishLiveUpdate 發布直播更新訊息
@@ -155,12 +162,60 @@ func (m *RedisMessaging) PublishLiveUpdate(liveID uint, eventType string, data m
 	return m.Publish("live_updates", eventType, payload)
 }
 
-// PublishUserNotification 發布用戶通知訊息
-func (m *RedisMessaging) PublishUserNotification(userID uint, notificationType string, title, content string) error {
-	return m.Publish("user_notifications", notificationType, map[string]interface{}{
+// PublishLiveRoomUpdate 發布直播間更新訊息（直播間ID為字串，與 live_id 區分）
+func (m *RedisMessaging) PublishLiveRoomUpdate(roomID string, creatorID uint, eventType string, data map[string]interface{}) error {
+	payload := map[string]interface{}{
+		"room_id":    roomID,
+		"creator_id": creatorID,
+		"event":      eventType,
+	}
+
+	// 合併額外數據
+	for k, v := range data {
+		payload[k] = v
+	}
+
+	return m.Publish("live_updates", eventType, payload)
+}
+
+// PublishPaymentUpdate 發布支付狀態訊息
+func (m *RedisMessaging) PublishPaymentUpdate(paymentID, userID uint, status string, data map[string]interface{}) error {
+	payload := map[string]interface{}{
+		"payment_id": paymentID,
+		"user_id":    userID,
+		"status":     status,
+	}
+
+	// 合併額外數據
+	for k, v := range data {
+		payload[k] = v
+	}
+
+	return m.Publish("payment_events", status, payload)
+}
+
+// PublishUserNotification 發布用戶通知事件，由各實例推送給該用戶的 WebSocket 連線
+func (m *RedisMessaging) PublishUserNotification(userID uint, event string, data interface{}) error {
+	return m.Publish("user_notifications", event, map[string]interface{}{
 		"user_id": userID,
-		"title":   title,
-		"content": content,
+		"data":    data,
+	})
+}
+
+// PublishChatEvent 發布私訊事件，由各實例推送給指定用戶的私訊 WebSocket 連線
+func (m *RedisMessaging) PublishChatEvent(userIDs []uint, event string, data interface{}) error {
+	return m.Publish("chat_user_events", event, map[string]interface{}{
+		"user_ids": userIDs,
+		"data":     data,
+	})
+}
+
+// PublishUploadProgress 發布串流上傳進度，由各實例推送給訂閱該上傳的 WebSocket 連線
+func (m *RedisMessaging) PublishUploadProgress(userID uint, uploadID, event string, data interface{}) error {
+	return m.Publish("upload_progress", event, map[string]interface{}{
+		"user_id":   userID,
+		"upload_id": uploadID,
+		"data":      data,
 	})
 }
 
@@ -0,0 +1,68 @@
+package utils
+
+import "strings"
+
+// WordFilter 敏感詞過濾器，比對時不分大小寫
+type WordFilter struct {
+	words []string
+}
+
+// NewWordFilter 創建敏感詞過濾器，忽略空白詞
+func NewWordFilter(words []string) *WordFilter {
+	filter := &WordFilter{}
+	for _, word := range words {
+		word = strings.ToLower(strings.TrimSpace(word))
+		if word != "" {
+			filter.words = append(filter.words, word)
+		}
+	}
+	return filter
+}
+
+// Contains 檢查文字是否包含敏感詞
+func (f *WordFilter) Contains(text string) bool {
+	lower := strings.ToLower(text)
+	for _, word := range f.words {
+		if strings.Contains(lower, word) {
+			return true
+		}
+	}
+	return false
+}
+
+// Mask 將文字中的敏感詞逐字替換為 *
+func (f *WordFilter) Mask(text string) string {
+	if len(f.words) == 0 {
+		return text
+	}
+
+	// 以 rune 比對，大小寫轉換改變位元組長度時仍能正確對應原文位置
+	runes := []rune(text)
+	lower := []rune(strings.ToLower(text))
+	if len(lower) != len(runes) {
+		return text
+	}
+
+	masked := make([]bool, len(runes))
+	for _, word := range f.words {
+		target := []rune(word)
+		for i := 0; i+len(target) <= len(lower); i++ {
+			if string(lower[i:i+len(target)]) == word {
+				for j := i; j < i+len(target); j++ {
+					masked[j] = true
+				}
+			}
+		}
+	}
+
+	var builder strings.Builder
+	builder.Grow(len(text))
+	for i, r := range runes {
+		if masked[i] {
+			builder.WriteRune('*')
+		} else {
+			builder.WriteRune(r)
+		}
+	}
+	return builder.String()
+}
@@ -0,0 +1,25 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWordFilter(t *testing.T) {
+	filter := NewWordFilter([]string{"Spam", "壞話", " "})
+
+	assert.True(t, filter.Contains("buy SPAM now"))
+	assert.True(t, filter.Contains("不要說壞話"))
+	assert.False(t, filter.Contains("hello world"))
+
+	assert.Equal(t, "buy **** now", filter.Mask("buy SPAM now"))
+	assert.Equal(t, "不要說**", filter.Mask("不要說壞話"))
+	assert.Equal(t, "hello", filter.Mask("hello"))
+}
+
+func TestWordFilter_Empty(t *testing.T) {
+	filter := NewWordFilter(nil)
+	assert.False(t, filter.Contains("anything"))
+	assert.Equal(t, "anything", filter.Mask("anything"))
+}
@@ -0,0 +1,14 @@
+package ws
+
+// BlockChecker 查詢與用戶有封鎖關係的用戶，互相封鎖的用戶在直播聊天室看不到彼此的訊息
+type BlockChecker interface {
+	BlockedWith(userID uint) map[uint]bool
+}
+
+// blockedWith 查詢發送者的封鎖名單，未設置或系統訊息時回傳 nil
+func blockedWith(checker BlockChecker, senderID uint) map[uint]bool {
+	if checker == nil || senderID == 0 {
+		return nil
+	}
+	return checker.BlockedWith(senderID)
+}
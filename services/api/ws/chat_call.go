@@ -0,0 +1,83 @@
+package ws
+
+import (
+	"encoding/json"
+
+	"stream-demo/backend/dto"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CallActions 私訊連線上的通話信令操作，由通話服務實作
+type CallActions interface {
+	Invite(userID uint, req *dto.CallInviteDTO) (*dto.CallDTO, error)
+	Ringing(userID uint, callID string) error
+	Accept(userID uint, callID string) (*dto.CallDTO, error)
+	Reject(userID uint, callID string) error
+	Hangup(userID uint, callID string) error
+	Signal(userID uint, req *dto.CallSignalDTO) error
+	UserDisconnected(userID uint)
+}
+
+// callInbound 通話操作的欄位，與私訊操作共用同一個消息
+// type: call_invite, call_ringing, call_accept, call_reject, call_hangup, call_signal
+type callInbound struct {
+	CallID   string          `json:"call_id"`
+	CallType string          `json:"call_type"` // audio, video
+	ToUserID uint            `json:"to_user_id"`
+	Kind     string          `json:"kind"` // offer, answer, ice
+	Payload  json.RawMessage `json:"payload"`
+}
+
+// SetCallActions 設置通話操作
+func (h *ChatHandler) SetCallActions(calls CallActions) {
+	h.calls = calls
+}
+
+// handleCallInbound 執行通話操作，發起通話時回應 call_created
+func (h *ChatHandler) handleCallInbound(userID uint, inbound *chatInbound) (string, interface{}) {
+	if h.calls == nil {
+		return "error", gin.H{"message": "通話服務未啟用"}
+	}
+
+	var err error
+	switch inbound.Type {
+	case "call_invite":
+		var call *dto.CallDTO
+		call, err = h.calls.Invite(userID, &dto.CallInviteDTO{
+			ConversationID: inbound.ConversationID,
+			Type:           inbound.CallType,
+		})
+		if err == nil {
+			return "call_created", call
+		}
+	case "call_ringing":
+		err = h.calls.Ringing(userID, inbound.CallID)
+	case "call_accept":
+		// 接聽結果以 call_accepted 事件推送給所有成員
+		_, err = h.calls.Accept(userID, inbound.CallID)
+	case "call_reject":
+		err = h.calls.Reject(userID, inbound.CallID)
+	case "call_hangup":
+		err = h.calls.Hangup(userID, inbound.CallID)
+	case "call_signal":
+		err = h.calls.Signal(userID, &dto.CallSignalDTO{
+			CallID:   inbound.CallID,
+			ToUserID: inbound.ToUserID,
+			Kind:     inbound.Kind,
+			Payload:  inbound.Payload,
+		})
+	default:
+		return "error", gin.H{"message": "不支援的操作: " + inbound.Type}
+	}
+
+	if err != nil {
+		return "error", gin.H{
+			"message":         err.Error(),
+			"action":          inbound.Type,
+			"call_id":         inbound.CallID,
+			"conversation_id": inbound.ConversationID,
+		}
+	}
+	return "", nil
+}
@@ -0,0 +1,348 @@
+package ws
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"stream-demo/backend/dto"
+	"stream-demo/backend/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+// chatMaxMessageSize 私訊連線的讀取上限，需容納訊息內容與通話的 SDP
+const chatMaxMessageSize = 96 * 1024
+
+// ChatActions 私訊連線可執行的操作，由私訊服務實作
+type ChatActions interface {
+	SendMessage(userID, conversationID uint, req *dto.MessageSendDTO) (*dto.MessageDTO, error)
+	MarkDelivered(userID, conversationID, messageID uint) error
+	MarkRead(userID, conversationID, messageID uint) error
+	SetTyping(userID, conversationID uint, typing bool) error
+}
+
+// ChatHandler 私訊 WebSocket 處理器
+// 啟用 Redis 訊息時訂閱 chat_user_events 頻道，推送給連線在本實例的會話成員
+type ChatHandler struct {
+	// 連線映射：userID -> clients（同一用戶可有多個分頁或裝置）
+	clients map[uint]map[*chatClient]bool
+	mu      sync.RWMutex
+	// JWT 工具
+	jwtUtil *utils.JWTUtil
+	// 上線狀態
+	presence PresenceTracker
+	// 私訊操作
+	actions ChatActions
+	// 通話信令
+	calls CallActions
+}
+
+// chatClient 私訊連線
+type chatClient struct {
+	conn *websocket.Conn
+	send chan []byte
+}
+
+// ChatMessage 私訊事件消息
+// 推送 type: message, typing, delivered, read 與 call_* 通話事件；回應 type: sent（發送成功）, call_created, error
+type ChatMessage struct {
+	Type      string      `json:"type"`
+	Data      interface{} `json:"data,omitempty"`
+	Timestamp int64       `json:"timestamp"`
+}
+
+// chatInbound 客戶端送出的操作
+// type: send, typing, delivered, read 與 call_* 通話操作
+type chatInbound struct {
+	Type           string                         `json:"type"`
+	ConversationID uint                           `json:"conversation_id"`
+	MessageID      uint                           `json:"message_id"`
+	Content        string                         `json:"content"`
+	ClientID       string                         `json:"client_id"`
+	Attachment     *dto.MessageAttachmentInputDTO `json:"attachment"` // 先以 REST 取得上傳URL並上傳
+	Typing         bool                           `json:"typing"`
+
+	callInbound
+}
+
+// NewChatHandler 創建私訊處理器
+func NewChatHandler(jwtUtil *utils.JWTUtil, messaging *utils.RedisMessaging) *ChatHandler {
+	h := &ChatHandler{
+		clients: make(map[uint]map[*chatClient]bool),
+		jwtUtil: jwtUtil,
+	}
+
+	if messaging != nil {
+		if err := messaging.Subscribe("chat_user_events", h.handleChatEvent); err != nil {
+			log.Printf("訂閱 chat_user_events 頻道失敗: %v", err)
+		}
+	}
+
+	return h
+}
+
+// SetActions 設置私訊操作
+func (h *ChatHandler) SetActions(actions ChatActions) {
+	h.actions = actions
+}
+
+// SetPresenceTracker 設置上線狀態追蹤
+func (h *ChatHandler) SetPresenceTracker(presence PresenceTracker) {
+	h.presence = presence
+}
+
+// ServeWS 連線收發私訊
+func (h *ChatHandler) ServeWS(c *gin.Context) {
+	// 從 URL 參數或 header 獲取 JWT token
+	token := c.Query("token")
+	if token == "" {
+		token = c.GetHeader("Authorization")
+		if token != "" && len(token) > 7 {
+			token = token[7:] // 移除 "Bearer " 前綴
+		}
+	}
+
+	if token == "" {
+		c.JSON(401, gin.H{"error": "未提供認證 token"})
+		return
+	}
+
+	claims, err := h.jwtUtil.ValidateToken(token)
+	if err != nil {
+		c.JSON(401, gin.H{"error": "無效的 token"})
+		return
+	}
+
+	upgrader := websocket.Upgrader{
+		CheckOrigin: func(r *http.Request) bool {
+			return true // 允許所有來源
+		},
+	}
+
+	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		log.Printf("WebSocket 升級失敗: %v", err)
+		return
+	}
+
+	client := &chatClient{
+		conn: conn,
+		send: make(chan []byte, 256),
+	}
+	h.register(claims.UserID, client)
+
+	go client.writePump()
+	go h.readPump(claims.UserID, client)
+}
+
+// NotifyUsers 推送事件給用戶在本實例的連線，連線緩衝已滿時丟棄該事件
+func (h *ChatHandler) NotifyUsers(userIDs []uint, event string, data interface{}) {
+	payload, err := encodeChatMessage(event, data)
+	if err != nil {
+		log.Printf("私訊事件序列化失敗: %v", err)
+		return
+	}
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	for _, userID := range userIDs {
+		for client := range h.clients[userID] {
+			select {
+			case client.send <- payload:
+			default:
+			}
+		}
+	}
+}
+
+// handleChatEvent 處理 Redis 轉發的私訊事件
+func (h *ChatHandler) handleChatEvent(channel string, payload []byte) error {
+	var message utils.Message
+	if err := utils.UnmarshalMessage(payload, &message); err != nil {
+		return err
+	}
+
+	rawIDs, ok := message.Payload["user_ids"].([]interface{})
+	if !ok {
+		return nil
+	}
+	userIDs := make([]uint, 0, len(rawIDs))
+	for _, raw := range rawIDs {
+		if id, ok := raw.(float64); ok {
+			userIDs = append(userIDs, uint(id))
+		}
+	}
+	h.NotifyUsers(userIDs, message.Type, message.Payload["data"])
+	return nil
+}
+
+// handleInbound 執行客戶端操作，回傳要回應給該連線的事件
+func (h *ChatHandler) handleInbound(userID uint, inbound *chatInbound) (string, interface{}) {
+	if strings.HasPrefix(inbound.Type, "call_") {
+		return h.handleCallInbound(userID, inbound)
+	}
+	if h.actions == nil {
+		return "error", gin.H{"message": "私訊服務未啟用"}
+	}
+
+	var err error
+	switch inbound.Type {
+	case "send":
+		var message *dto.MessageDTO
+		message, err = h.actions.SendMessage(userID, inbound.ConversationID, &dto.MessageSendDTO{
+			Content:    inbound.Content,
+			ClientID:   inbound.ClientID,
+			Attachment: inbound.Attachment,
+		})
+		if err == nil {
+			return "sent", message
+		}
+	case "typing":
+		err = h.actions.SetTyping(userID, inbound.ConversationID, inbound.Typing)
+	case "delivered":
+		err = h.actions.MarkDelivered(userID, inbound.ConversationID, inbound.MessageID)
+	case "read":
+		err = h.actions.MarkRead(userID, inbound.ConversationID, inbound.MessageID)
+	default:
+		return "error", gin.H{"message": "不支援的操作: " + inbound.Type}
+	}
+
+	if err != nil {
+		return "error", gin.H{
+			"message":         err.Error(),
+			"action":          inbound.Type,
+			"conversation_id": inbound.ConversationID,
+			"client_id":       inbound.ClientID,
+		}
+	}
+	return "", nil
+}
+
+// register 加入連線
+func (h *ChatHandler) register(userID uint, client *chatClient) {
+	h.mu.Lock()
+	if h.clients[userID] == nil {
+		h.clients[userID] = make(map[*chatClient]bool)
+	}
+	h.clients[userID][client] = true
+	h.mu.Unlock()
+
+	if h.presence != nil {
+		h.presence.Connected(userID)
+	}
+}
+
+// unregister 移除連線並關閉發送頻道
+func (h *ChatHandler) unregister(userID uint, client *chatClient) {
+	removed := false
+	h.mu.Lock()
+	if clients, ok := h.clients[userID]; ok {
+		if _, ok := clients[client]; ok {
+			delete(clients, client)
+			close(client.send)
+			removed = true
+		}
+		if len(clients) == 0 {
+			delete(h.clients, userID)
+		}
+	}
+	h.mu.Unlock()
+
+	if removed && h.presence != nil {
+		h.presence.Disconnected(userID)
+	}
+	// 上線狀態更新後才檢查，用戶仍有其他連線時不離開通話
+	if removed && h.calls != nil {
+		go h.calls.UserDisconnected(userID)
+	}
+}
+
+// readPump 讀取客戶端操作，連線中斷時移除連線
+func (h *ChatHandler) readPump(userID uint, client *chatClient) {
+	defer func() {
+		h.unregister(userID, client)
+		client.conn.Close()
+	}()
+
+	client.conn.SetReadLimit(chatMaxMessageSize)
+	client.conn.SetReadDeadline(time.Now().Add(pongWait))
+	client.conn.SetPongHandler(func(string) error {
+		client.conn.SetReadDeadline(time.Now().Add(pongWait))
+		return nil
+	})
+
+	for {
+		_, raw, err := client.conn.ReadMessage()
+		if err != nil {
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
+				log.Printf("私訊連線錯誤: %v", err)
+			}
+			return
+		}
+
+		var inbound chatInbound
+		event, data := "error", interface{}(gin.H{"message": "無效的消息格式"})
+		if err := json.Unmarshal(raw, &inbound); err == nil {
+			event, data = h.handleInbound(userID, &inbound)
+		}
+		if event == "" {
+			continue
+		}
+
+		payload, err := encodeChatMessage(event, data)
+		if err != nil {
+			continue
+		}
+		h.mu.RLock()
+		if h.clients[userID][client] {
+			select {
+			case client.send <- payload:
+			default:
+			}
+		}
+		h.mu.RUnlock()
+	}
+}
+
+// writePump 寫入泵
+func (c *chatClient) writePump() {
+	ticker := time.NewTicker(pingPeriod)
+	defer func() {
+		ticker.Stop()
+		c.conn.Close()
+	}()
+
+	for {
+		select {
+		case message, ok := <-c.send:
+			c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if !ok {
+				c.conn.WriteMessage(websocket.CloseMessage, []byte{})
+				return
+			}
+			if err := c.conn.WriteMessage(websocket.TextMessage, message); err != nil {
+				return
+			}
+		case <-ticker.C:
+			c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if err := c.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// encodeChatMessage 序列化私訊事件
+func encodeChatMessage(event string, data interface{}) ([]byte, error) {
+	return json.Marshal(ChatMessage{
+		Type:      event,
+		Data:      data,
+		Timestamp: time.Now().Unix(),
+	})
+}
@@ -15,6 +15,8 @@ type Hub struct {
 	mu sync.RWMutex
 	// Redis訊息系統
 	messaging *utils.RedisMessaging
+	// 封鎖關係，過濾聊天訊息
+	blockChecker BlockChecker
 }
 
 // Room 單一聊天室
@@ -51,6 +53,11 @@ func NewHub(messaging *utils.RedisMessaging) *Hub {
 	return hub
 }
 
+// SetBlockChecker 設置封鎖關係查詢
+func (h *Hub) SetBlockChecker(checker BlockChecker) {
+	h.blockChecker = checker
+}
+
 // GetRoom 取得或建立聊天室
 func (h *Hub) GetRoom(liveID uint) *Room {
 	h.mu.Lock()
@@ -227,10 +234,15 @@ func (r *Room) run() {
 			r.mu.Unlock()
 
 		case message := <-r.broadcast:
+			// 與發送者有封鎖關係的用戶不會收到
+			blocked := blockedWith(r.hub.blockChecker, message.UserID)
+
 			r.mu.RLock()
 			clients := make([]*Client, 0, len(r.clients))
 			for client := range r.clients {
-				clients = append(clients, client)
+				if !blocked[client.userID] {
+					clients = append(clients, client)
+				}
 			}
 			r.mu.RUnlock()
 
@@ -35,6 +35,8 @@ type LiveRoomHandler struct {
 	mu    sync.RWMutex
 	// JWT 工具
 	jwtUtil *utils.JWTUtil
+	// 封鎖關係，過濾聊天訊息
+	blockChecker BlockChecker
 }
 
 // LiveRoom 直播間
@@ -70,6 +72,11 @@ func NewLiveRoomHandler(jwtUtil *utils.JWTUtil) *LiveRoomHandler {
 	}
 }
 
+// SetBlockChecker 設置封鎖關係查詢
+func (h *LiveRoomHandler) SetBlockChecker(checker BlockChecker) {
+	h.blockChecker = checker
+}
+
 // ServeWS WebSocket 連接處理
 func (h *LiveRoomHandler) ServeWS(c *gin.Context) {
 	roomID := c.Param("roomID")
@@ -360,8 +367,8 @@ func (h *LiveRoomHandler) handleClientMessage(client *LiveRoomClient, message []
 
 // handleChatMessage 處理聊天消息
 func (h *LiveRoomHandler) handleChatMessage(client *LiveRoomClient, msg LiveRoomMessage) {
-	// 廣播聊天消息
-	h.broadcastToRoom(client.roomID, LiveRoomMessage{
+	// 廣播聊天消息（與發送者有封鎖關係的用戶不會收到）
+	h.broadcastToRoomExcept(client.roomID, LiveRoomMessage{
 		Type:      "chat",
 		RoomID:    client.roomID,
 		UserID:    client.userID,
@@ -369,7 +376,7 @@ func (h *LiveRoomHandler) handleChatMessage(client *LiveRoomClient, msg LiveRoom
 		Role:      client.role,
 		Content:   msg.Content,
 		Timestamp: time.Now().Unix(),
-	})
+	}, blockedWith(h.blockChecker, uint(client.userID)))
 
 	// 記錄聊天消息到 Redis（可選）
 	ctx := context.Background()
@@ -389,6 +396,11 @@ func (h *LiveRoomHandler) handleChatMessage(client *LiveRoomClient, msg LiveRoom
 
 // broadcastToRoom 廣播到房間
 func (h *LiveRoomHandler) broadcastToRoom(roomID string, message LiveRoomMessage) {
+	h.broadcastToRoomExcept(roomID, message, nil)
+}
+
+// broadcastToRoomExcept 廣播到房間，略過指定的用戶
+func (h *LiveRoomHandler) broadcastToRoomExcept(roomID string, message LiveRoomMessage, excluded map[uint]bool) {
 	h.mu.RLock()
 	room, exists := h.rooms[roomID]
 	h.mu.RUnlock()
@@ -400,7 +412,9 @@ func (h *LiveRoomHandler) broadcastToRoom(roomID string, message LiveRoomMessage
 	room.mu.RLock()
 	clients := make([]*LiveRoomClient, 0, len(room.clients))
 	for client := range room.clients {
-		clients = append(clients, client)
+		if !excluded[uint(client.userID)] {
+			clients = append(clients, client)
+		}
 	}
 	room.mu.RUnlock()
 
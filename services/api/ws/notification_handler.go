@@ -0,0 +1,227 @@
+package ws
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"stream-demo/backend/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+// NotificationHandler 站內通知 WebSocket 處理器
+// 啟用 Redis 訊息時訂閱 user_notifications 頻道，推送給連線在本實例的用戶
+type NotificationHandler struct {
+	// 連線映射：userID -> clients（同一用戶可有多個分頁或裝置）
+	clients map[uint]map[*notificationClient]bool
+	mu      sync.RWMutex
+	// JWT 工具
+	jwtUtil *utils.JWTUtil
+	// 上線狀態
+	presence PresenceTracker
+}
+
+// notificationClient 通知訂閱者
+type notificationClient struct {
+	conn *websocket.Conn
+	send chan []byte
+}
+
+// NotificationMessage 通知事件消息
+// type: notification（新通知）, unread_count（已讀後的未讀數）
+type NotificationMessage struct {
+	Type      string      `json:"type"`
+	Data      interface{} `json:"data,omitempty"`
+	Timestamp int64       `json:"timestamp"`
+}
+
+// NewNotificationHandler 創建站內通知處理器
+func NewNotificationHandler(jwtUtil *utils.JWTUtil, messaging *utils.RedisMessaging) *NotificationHandler {
+	h := &NotificationHandler{
+		clients: make(map[uint]map[*notificationClient]bool),
+		jwtUtil: jwtUtil,
+	}
+
+	if messaging != nil {
+		if err := messaging.Subscribe("user_notifications", h.handleUserNotification); err != nil {
+			log.Printf("訂閱 user_notifications 頻道失敗: %v", err)
+		}
+	}
+
+	return h
+}
+
+// SetPresenceTracker 設置上線狀態追蹤
+func (h *NotificationHandler) SetPresenceTracker(presence PresenceTracker) {
+	h.presence = presence
+}
+
+// ServeWS 連線接收自己的通知
+func (h *NotificationHandler) ServeWS(c *gin.Context) {
+	// 從 URL 參數或 header 獲取 JWT token
+	token := c.Query("token")
+	if token == "" {
+		token = c.GetHeader("Authorization")
+		if token != "" && len(token) > 7 {
+			token = token[7:] // 移除 "Bearer " 前綴
+		}
+	}
+
+	if token == "" {
+		c.JSON(401, gin.H{"error": "未提供認證 token"})
+		return
+	}
+
+	claims, err := h.jwtUtil.ValidateToken(token)
+	if err != nil {
+		c.JSON(401, gin.H{"error": "無效的 token"})
+		return
+	}
+
+	upgrader := websocket.Upgrader{
+		CheckOrigin: func(r *http.Request) bool {
+			return true // 允許所有來源
+		},
+	}
+
+	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		log.Printf("WebSocket 升級失敗: %v", err)
+		return
+	}
+
+	client := &notificationClient{
+		conn: conn,
+		send: make(chan []byte, 64),
+	}
+	h.register(claims.UserID, client)
+
+	go client.writePump()
+	go h.readPump(claims.UserID, client)
+}
+
+// NotifyUser 推送事件給用戶在本實例的連線，連線緩衝已滿時丟棄該事件
+func (h *NotificationHandler) NotifyUser(userID uint, event string, data interface{}) {
+	payload, err := json.Marshal(NotificationMessage{
+		Type:      event,
+		Data:      data,
+		Timestamp: time.Now().Unix(),
+	})
+	if err != nil {
+		log.Printf("通知序列化失敗: %v", err)
+		return
+	}
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	for client := range h.clients[userID] {
+		select {
+		case client.send <- payload:
+		default:
+		}
+	}
+}
+
+// handleUserNotification 處理 Redis 轉發的通知事件
+func (h *NotificationHandler) handleUserNotification(channel string, payload []byte) error {
+	var message utils.Message
+	if err := utils.UnmarshalMessage(payload, &message); err != nil {
+		return err
+	}
+
+	userID, ok := message.Payload["user_id"].(float64)
+	if !ok {
+		return nil
+	}
+	h.NotifyUser(uint(userID), message.Type, message.Payload["data"])
+	return nil
+}
+
+// register 加入連線
+func (h *NotificationHandler) register(userID uint, client *notificationClient) {
+	h.mu.Lock()
+	if h.clients[userID] == nil {
+		h.clients[userID] = make(map[*notificationClient]bool)
+	}
+	h.clients[userID][client] = true
+	h.mu.Unlock()
+
+	if h.presence != nil {
+		h.presence.Connected(userID)
+	}
+}
+
+// unregister 移除連線並關閉發送頻道
+func (h *NotificationHandler) unregister(userID uint, client *notificationClient) {
+	removed := false
+	h.mu.Lock()
+	if clients, ok := h.clients[userID]; ok {
+		if _, ok := clients[client]; ok {
+			delete(clients, client)
+			close(client.send)
+			removed = true
+		}
+		if len(clients) == 0 {
+			delete(h.clients, userID)
+		}
+	}
+	h.mu.Unlock()
+
+	if removed && h.presence != nil {
+		h.presence.Disconnected(userID)
+	}
+}
+
+// readPump 只處理 pong 與關閉，連線中斷時移除連線
+func (h *NotificationHandler) readPump(userID uint, client *notificationClient) {
+	defer func() {
+		h.unregister(userID, client)
+		client.conn.Close()
+	}()
+
+	client.conn.SetReadLimit(maxMessageSize)
+	client.conn.SetReadDeadline(time.Now().Add(pongWait))
+	client.conn.SetPongHandler(func(string) error {
+		client.conn.SetReadDeadline(time.Now().Add(pongWait))
+		return nil
+	})
+
+	for {
+		if _, _, err := client.conn.ReadMessage(); err != nil {
+			return
+		}
+	}
+}
+
+// writePump 寫入泵
+func (c *notificationClient) writePump() {
+	ticker := time.NewTicker(pingPeriod)
+	defer func() {
+		ticker.Stop()
+		c.conn.Close()
+	}()
+
+	for {
+		select {
+		case message, ok := <-c.send:
+			c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if !ok {
+				c.conn.WriteMessage(websocket.CloseMessage, []byte{})
+				return
+			}
+			if err := c.conn.WriteMessage(websocket.TextMessage, message); err != nil {
+				return
+			}
+		case <-ticker.C:
+			c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if err := c.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,7 @@
+package ws
+
+// PresenceTracker 追蹤用戶的 WebSocket 連線，作為好友上線狀態
+type PresenceTracker interface {
+	Connected(userID uint)
+	Disconnected(userID uint)
+}
@@ -0,0 +1,231 @@
+package ws
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"stream-demo/backend/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+// UploadProgressHandler 串流上傳進度 WebSocket 處理器
+// 啟用 Redis 訊息時訂閱 upload_progress 頻道，上傳請求與 WebSocket 可落在不同實例
+type UploadProgressHandler struct {
+	// 訂閱映射：userID:uploadID -> clients
+	subscribers map[string]map[*uploadProgressClient]bool
+	mu          sync.RWMutex
+	// JWT 工具
+	jwtUtil *utils.JWTUtil
+}
+
+// uploadProgressClient 上傳進度訂閱者
+type uploadProgressClient struct {
+	conn *websocket.Conn
+	send chan []byte
+}
+
+// UploadProgressMessage 上傳進度消息
+// type: started, progress, completed, failed
+type UploadProgressMessage struct {
+	Type      string      `json:"type"`
+	UploadID  string      `json:"upload_id"`
+	Data      interface{} `json:"data,omitempty"`
+	Timestamp int64       `json:"timestamp"`
+}
+
+// NewUploadProgressHandler 創建上傳進度處理器
+func NewUploadProgressHandler(jwtUtil *utils.JWTUtil, messaging *utils.RedisMessaging) *UploadProgressHandler {
+	h := &UploadProgressHandler{
+		subscribers: make(map[string]map[*uploadProgressClient]bool),
+		jwtUtil:     jwtUtil,
+	}
+
+	if messaging != nil {
+		if err := messaging.Subscribe("upload_progress", h.handleUploadProgress); err != nil {
+			log.Printf("訂閱 upload_progress 頻道失敗: %v", err)
+		}
+	}
+
+	return h
+}
+
+// ServeWS 訂閱指定上傳的進度，只能訂閱自己的上傳
+func (h *UploadProgressHandler) ServeWS(c *gin.Context) {
+	uploadID := c.Param("uploadID")
+	if uploadID == "" {
+		c.JSON(400, gin.H{"error": "上傳ID不能為空"})
+		return
+	}
+
+	// 從 URL 參數或 header 獲取 JWT token
+	token := c.Query("token")
+	if token == "" {
+		token = c.GetHeader("Authorization")
+		if token != "" && len(token) > 7 {
+			token = token[7:] // 移除 "Bearer " 前綴
+		}
+	}
+
+	if token == "" {
+		c.JSON(401, gin.H{"error": "未提供認證 token"})
+		return
+	}
+
+	claims, err := h.jwtUtil.ValidateToken(token)
+	if err != nil {
+		c.JSON(401, gin.H{"error": "無效的 token"})
+		return
+	}
+
+	upgrader := websocket.Upgrader{
+		CheckOrigin: func(r *http.Request) bool {
+			return true // 允許所有來源
+		},
+	}
+
+	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		log.Printf("WebSocket 升級失敗: %v", err)
+		return
+	}
+
+	client := &uploadProgressClient{
+		conn: conn,
+		send: make(chan []byte, 64),
+	}
+	key := uploadProgressKey(claims.UserID, uploadID)
+	h.subscribe(key, client)
+
+	go client.writePump()
+	go h.readPump(key, client)
+}
+
+// NotifyUploadProgress 推送上傳事件給本實例的訂閱者，訂閱者緩衝已滿時丟棄該筆進度
+func (h *UploadProgressHandler) NotifyUploadProgress(userID uint, uploadID, event string, data interface{}) {
+	payload, err := json.Marshal(UploadProgressMessage{
+		Type:      event,
+		UploadID:  uploadID,
+		Data:      data,
+		Timestamp: time.Now().Unix(),
+	})
+	if err != nil {
+		log.Printf("上傳進度序列化失敗: %v", err)
+		return
+	}
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	for client := range h.subscribers[uploadProgressKey(userID, uploadID)] {
+		select {
+		case client.send <- payload:
+		default:
+		}
+	}
+}
+
+// handleUploadProgress 處理 Redis 轉發的上傳進度
+func (h *UploadProgressHandler) handleUploadProgress(channel string, payload []byte) error {
+	var message utils.Message
+	if err := utils.UnmarshalMessage(payload, &message); err != nil {
+		return err
+	}
+
+	userID, ok := message.Payload["user_id"].(float64)
+	if !ok {
+		return nil
+	}
+	uploadID, ok := message.Payload["upload_id"].(string)
+	if !ok {
+		return nil
+	}
+	h.NotifyUploadProgress(uint(userID), uploadID, message.Type, message.Payload["data"])
+	return nil
+}
+
+// subscribe 加入訂閱
+func (h *UploadProgressHandler) subscribe(key string, client *uploadProgressClient) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.subscribers[key] == nil {
+		h.subscribers[key] = make(map[*uploadProgressClient]bool)
+	}
+	h.subscribers[key][client] = true
+}
+
+// unsubscribe 移除訂閱並關閉發送頻道
+func (h *UploadProgressHandler) unsubscribe(key string, client *uploadProgressClient) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if clients, ok := h.subscribers[key]; ok {
+		if _, ok := clients[client]; ok {
+			delete(clients, client)
+			close(client.send)
+		}
+		if len(clients) == 0 {
+			delete(h.subscribers, key)
+		}
+	}
+}
+
+// readPump 只處理 pong 與關閉，連線中斷時取消訂閱
+func (h *UploadProgressHandler) readPump(key string, client *uploadProgressClient) {
+	defer func() {
+		h.unsubscribe(key, client)
+		client.conn.Close()
+	}()
+
+	client.conn.SetReadLimit(maxMessageSize)
+	client.conn.SetReadDeadline(time.Now().Add(pongWait))
+	client.conn.SetPongHandler(func(string) error {
+		client.conn.SetReadDeadline(time.Now().Add(pongWait))
+		return nil
+	})
+
+	for {
+		if _, _, err := client.conn.ReadMessage(); err != nil {
+			return
+		}
+	}
+}
+
+// writePump 寫入泵
+func (c *uploadProgressClient) writePump() {
+	ticker := time.NewTicker(pingPeriod)
+	defer func() {
+		ticker.Stop()
+		c.conn.Close()
+	}()
+
+	for {
+		select {
+		case message, ok := <-c.send:
+			c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if !ok {
+				c.conn.WriteMessage(websocket.CloseMessage, []byte{})
+				return
+			}
+			if err := c.conn.WriteMessage(websocket.TextMessage, message); err != nil {
+				return
+			}
+		case <-ticker.C:
+			c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if err := c.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// uploadProgressKey 訂閱鍵，包含用戶 ID 以避免訂閱他人的上傳
+func uploadProgressKey(userID uint, uploadID string) string {
+	return fmt.Sprintf("%d:%s", userID, uploadID)
+}
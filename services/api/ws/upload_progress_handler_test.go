@@ -0,0 +1,58 @@
+package ws
+
+import (
+	"encoding/json"
+	"testing"
+
+	"stream-demo/backend/utils"
+)
+
+// publishedUploadProgress 模擬其他實例經由 Redis 發布的上傳進度
+func publishedUploadProgress(t *testing.T, userID uint, uploadID, event string) []byte {
+	t.Helper()
+	payload, err := json.Marshal(utils.Message{
+		Channel: "upload_progress",
+		Type:    event,
+		Payload: map[string]interface{}{
+			"user_id":   userID,
+			"upload_id": uploadID,
+			"data":      map[string]interface{}{"percent": 42},
+		},
+	})
+	if err != nil {
+		t.Fatalf("序列化訊息失敗: %v", err)
+	}
+	return payload
+}
+
+func TestUploadProgressHandler_HandleUploadProgress(t *testing.T) {
+	h := NewUploadProgressHandler(nil, nil)
+	owner := &uploadProgressClient{send: make(chan []byte, 1)}
+	other := &uploadProgressClient{send: make(chan []byte, 1)}
+	h.subscribe(uploadProgressKey(7, "upload-1"), owner)
+	h.subscribe(uploadProgressKey(8, "upload-1"), other)
+
+	if err := h.handleUploadProgress("upload_progress", publishedUploadProgress(t, 7, "upload-1", "progress")); err != nil {
+		t.Fatalf("處理上傳進度失敗: %v", err)
+	}
+
+	select {
+	case raw := <-owner.send:
+		var message UploadProgressMessage
+		if err := json.Unmarshal(raw, &message); err != nil {
+			t.Fatalf("解析推送內容失敗: %v", err)
+		}
+		if message.Type != "progress" || message.UploadID != "upload-1" {
+			t.Errorf("message = %+v, want progress for upload-1", message)
+		}
+	default:
+		t.Fatal("上傳者未收到進度")
+	}
+
+	// 相同上傳ID但不同用戶的訂閱不應收到
+	select {
+	case <-other.send:
+		t.Error("其他用戶不應收到上傳進度")
+	default:
+	}
+}
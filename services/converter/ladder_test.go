@@ -0,0 +1,72 @@
+package main
+
+import (
+	"os/exec"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// runLadder 執行 scripts/ladder.sh，回傳保留的畫質
+func runLadder(t *testing.T, minStep string, rungs ...string) []string {
+	t.Helper()
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("需要 bash 才能執行 ladder.sh")
+	}
+
+	output, err := exec.Command("bash", append([]string{"scripts/ladder.sh", minStep}, rungs...)...).Output()
+	if err != nil {
+		t.Fatalf("ladder.sh 執行失敗: %v", err)
+	}
+	return strings.Fields(string(output))
+}
+
+func TestLadder(t *testing.T) {
+	tests := []struct {
+		name  string
+		rungs []string
+		want  []string
+	}{
+		{
+			// 複雜度極低時各畫質都採用最低位元率，仍保留最低畫質作為備援
+			name:  "flat complexity",
+			rungs: []string{"360p:640:360:150k", "480p:854:480:150k", "720p:1280:720:150k"},
+			want:  []string{"360p:640:360:150k", "720p:1280:720:150k"},
+		},
+		{
+			name:  "flat complexity with four rungs",
+			rungs: []string{"240p:426:240:150k", "360p:640:360:150k", "480p:854:480:150k", "1080p:1920:1080:150k"},
+			want:  []string{"240p:426:240:150k", "1080p:1920:1080:150k"},
+		},
+		{
+			name:  "distinct bitrates keep every rung",
+			rungs: []string{"360p:640:360:400k", "480p:854:480:700k", "720p:1280:720:1500k"},
+			want:  []string{"360p:640:360:400k", "480p:854:480:700k", "720p:1280:720:1500k"},
+		},
+		{
+			// 480p 與 360p 相近而略過，720p 與保留的 360p 比較
+			name:  "compares against last kept rung",
+			rungs: []string{"360p:640:360:400k", "480p:854:480:500k", "720p:1280:720:1400k"},
+			want:  []string{"360p:640:360:400k", "720p:1280:720:1400k"},
+		},
+		{
+			// 最高畫質與保留的中間畫質相近時取代中間畫質
+			name:  "top rung replaces close middle rung",
+			rungs: []string{"240p:426:240:200k", "360p:640:360:350k", "480p:854:480:400k", "720p:1280:720:450k"},
+			want:  []string{"240p:426:240:200k", "720p:1280:720:450k"},
+		},
+		{
+			name:  "single rung",
+			rungs: []string{"360p:640:360:800k"},
+			want:  []string{"360p:640:360:800k"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := runLadder(t, "1.5", tt.rungs...); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ladder = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,11 +1,21 @@
 package main
 
 import (
+	"bufio"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
 	"fmt"
+	"io"
 	"log"
+	"math/bits"
 	"os"
 	"os/exec"
 	"os/signal"
+	"strconv"
+	"strings"
 	"syscall"
 	"time"
 
@@ -30,18 +40,42 @@ type Video struct {
 	HLSMasterURL string `json:"hls_master_url" gorm:"size:500"`
 	HLSKey       string `json:"hls_key" gorm:"size:500"`
 
+	// 封裝格式（ts / cmaf），cmaf 時另有與 HLS 共用分段的 DASH MPD
+	Packaging       string `json:"packaging" gorm:"size:10;default:ts"`
+	DASHManifestURL string `json:"dash_manifest_url" gorm:"size:500"`
+
+	// HLS 分段是否以 AES-128 加密
+	Encrypted bool `json:"encrypted" gorm:"default:false"`
+
+	// 轉碼時實際套用的品牌元素（watermark, intro, outro 以逗號分隔）
+	BrandingApplied string `json:"branding_applied" gorm:"size:50"`
+
 	// MP4轉碼版本（網頁播放）
 	MP4URL string `json:"mp4_url" gorm:"size:500"`
 	MP4Key string `json:"mp4_key" gorm:"size:500"`
 
+	// 拖曳預覽（雪碧圖 + WebVTT 縮圖軌）
+	PreviewVTTURL      string `json:"preview_vtt_url" gorm:"size:500"`
+	PreviewSpriteCount int    `json:"preview_sprite_count" gorm:"default:0"`
+	PreviewInterval    int    `json:"preview_interval" gorm:"default:0"` // 每張縮圖間隔秒數
+
 	// 影片屬性
 	Duration       int    `json:"duration" gorm:"default:0"`      // 秒數
 	FileSize       int64  `json:"file_size" gorm:"default:0"`     // 位元組
 	OriginalFormat string `json:"original_format" gorm:"size:10"` // mp4, avi等
 
+	// 重複上傳偵測：原始檔 SHA-256 與取樣畫面的 dHash，與同一用戶已完成的影片相同或相近時標記為 duplicate
+	ContentSHA256  string `json:"-" gorm:"size:64;index"`
+	PerceptualHash string `json:"-" gorm:"size:512"`
+	DuplicateOfID  *uint  `json:"duplicate_of_id" gorm:"index"`
+	DuplicateMatch string `json:"duplicate_match" gorm:"size:20"`
+
+	// 畫質階梯上限（name:width:height:bitrate，逗號分隔），空值時使用腳本預設
+	TranscodeLadder string `json:"-" gorm:"size:500"`
+
 	// 狀態管理
 	Status string `json:"status" gorm:"size:20;not null;index:idx_videos_user_status,priority:2;index:idx_videos_status_created,priority:1"`
-	// 狀態: uploading, processing, transcoding, ready, failed
+	// 狀態: uploading, clipping, processing, transcoding, duplicate, ready, failed
 	ProcessingProgress int    `json:"processing_progress" gorm:"default:0"` // 0-100
 	ErrorMessage       string `json:"error_message" gorm:"size:500"`
 
@@ -50,6 +84,121 @@ type Video struct {
 	Likes     int64     `json:"likes" gorm:"default:0"`
 	CreatedAt time.Time `json:"created_at" gorm:"index:idx_videos_user_created,priority:2;index:idx_videos_status_created,priority:2"`
 	UpdatedAt time.Time `json:"updated_at"`
+
+	// 軟刪除（垃圾桶中的影片不再轉碼）
+	DeletedAt gorm.DeletedAt `json:"deleted_at,omitempty" gorm:"index"`
+}
+
+// VideoQuality 影片品質資訊 - 與 API 服務保持一致，資料表由 API 服務遷移
+type VideoQuality struct {
+	ID        uint   `gorm:"primaryKey"`
+	VideoID   uint   `gorm:"not null"`
+	Quality   string `gorm:"size:10"`
+	Width     int
+	Height    int
+	Bitrate   int
+	FileURL   string `gorm:"size:500"`
+	FileKey   string `gorm:"size:500"`
+	Status    string `gorm:"size:20"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// TableName 指定表名
+func (VideoQuality) TableName() string {
+	return "video_qualities"
+}
+
+// VideoAudioTrack 影片音軌資訊 - 與 API 服務保持一致，資料表由 API 服務遷移
+type VideoAudioTrack struct {
+	ID             uint   `gorm:"primaryKey"`
+	VideoID        uint   `gorm:"not null"`
+	Name           string `gorm:"size:20"`
+	Language       string `gorm:"size:35"`
+	Label          string `gorm:"size:100"`
+	Channels       int
+	SourceChannels int
+	Bitrate        int
+	PlaylistURL    string `gorm:"size:500"`
+	IsDefault      bool
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+// TableName 指定表名
+func (VideoAudioTrack) TableName() string {
+	return "video_audio_tracks"
+}
+
+// VideoClip 精華片段剪輯任務 - 與 API 服務保持一致，資料表由 API 服務遷移
+type VideoClip struct {
+	ID            uint   `gorm:"primaryKey"`
+	VideoID       uint   `gorm:"not null"`
+	SourceType    string `gorm:"size:20"`
+	SourceVideoID *uint
+	SourceInput   string  `gorm:"size:500"`
+	StartTime     float64 `gorm:"not null"`
+	EndTime       float64 `gorm:"not null"`
+	StartAt       *time.Time
+	CutMode       string `gorm:"size:20"`
+}
+
+// TableName 指定表名
+func (VideoClip) TableName() string {
+	return "video_clips"
+}
+
+// VideoCaption 字幕軌 - 與 API 服務保持一致，資料表由 API 服務遷移
+type VideoCaption struct {
+	ID           uint   `gorm:"primaryKey"`
+	VideoID      uint   `gorm:"not null"`
+	Language     string `gorm:"size:35"`
+	Label        string `gorm:"size:100"`
+	Format       string `gorm:"size:10"`
+	SourceKey    string `gorm:"size:500"`
+	VTTURL       string `gorm:"column:vtt_url;size:500"`
+	PlaylistURL  string `gorm:"size:500"`
+	IsDefault    bool
+	Status       string `gorm:"size:20"`
+	ErrorMessage string `gorm:"size:500"`
+	UpdatedAt    time.Time
+}
+
+// TableName 指定表名
+func (VideoCaption) TableName() string {
+	return "video_captions"
+}
+
+// VideoEncryptionKey 內容金鑰 - 與 API 服務保持一致，資料表由 API 服務遷移
+type VideoEncryptionKey struct {
+	ID        uint   `gorm:"primaryKey"`
+	VideoID   uint   `gorm:"not null"`
+	KeyIndex  int    `gorm:"not null"`
+	Key       string `gorm:"size:255;not null"`
+	CreatedAt time.Time
+}
+
+// TableName 指定表名
+func (VideoEncryptionKey) TableName() string {
+	return "video_encryption_keys"
+}
+
+// UserBranding 創作者品牌設定 - 與 API 服務保持一致，資料表由 API 服務遷移
+type UserBranding struct {
+	ID                uint `gorm:"primaryKey"`
+	UserID            uint `gorm:"not null"`
+	Enabled           bool
+	WatermarkKey      string `gorm:"size:500"`
+	WatermarkPosition string `gorm:"size:20"`
+	WatermarkOpacity  float64
+	WatermarkScale    float64
+	IntroVideoID      *uint
+	OutroVideoID      *uint
+}
+
+// TableName 指定表名
+func (UserBranding) TableName() string {
+	return "user_brandings"
 }
 
 // ConverterService 轉碼服務
@@ -119,6 +268,12 @@ func (cs *ConverterService) monitorTasks() {
 
 // checkPendingVideos 檢查待轉碼影片
 func (cs *ConverterService) checkPendingVideos() {
+	// 先處理剪輯任務，剪輯完成後影片會進入 processing 走一般轉碼流程
+	cs.checkPendingClips()
+
+	// 字幕需等影片轉碼完成後才能加入 HLS 主播放列表
+	cs.checkPendingCaptions()
+
 	var videos []Video
 	err := cs.db.Where("status = ?", "processing").
 		Limit(10).
@@ -167,6 +322,11 @@ func (cs *ConverterService) processVideo(video *Video) {
 		return
 	}
 
+	// 與同一用戶已完成的影片內容相同或畫面相近時暫停轉碼，等待用戶決定是否共用轉碼結果
+	if cs.detectDuplicate(video) {
+		return
+	}
+
 	// 執行轉碼
 	if err := cs.executeTranscoding(video); err != nil {
 		cs.markVideoAsFailed(video, err.Error())
@@ -184,13 +344,38 @@ func (cs *ConverterService) executeTranscoding(video *Video) error {
 	log.Printf("🎬 執行轉碼 - VideoID: %d, InputKey: %s, OutputPrefix: %s",
 		video.ID, video.OriginalKey, outputPrefix)
 
-	// 執行 FFmpeg 轉碼腳本
-	cmd := exec.Command("/scripts/transcode.sh",
+	// 執行 FFmpeg 轉碼腳本，畫質階梯上限以影片建立時的設定為準
+	args := []string{
 		video.OriginalKey,
 		outputPrefix,
 		fmt.Sprintf("%d", video.UserID),
 		fmt.Sprintf("%d", video.ID),
-	)
+	}
+	if video.TranscodeLadder != "" {
+		args = append(args, video.TranscodeLadder)
+	}
+	cmd := exec.Command("/scripts/transcode.sh", args...)
+	cmd.Env = append(os.Environ(), cs.brandingEnv(video)...)
+	if video.Packaging != "" {
+		cmd.Env = append(cmd.Env, "PACKAGING="+video.Packaging)
+	}
+
+	// 加密影片的金鑰寫入獨立檔案，避免出現在腳本輸出與日誌中
+	keyFile := ""
+	if video.Encrypted {
+		keyFile = fmt.Sprintf("/tmp/transcoding/keys_%d", video.ID)
+		defer os.Remove(keyFile)
+
+		keyServerURL := os.Getenv("KEY_SERVER_URL")
+		if keyServerURL == "" {
+			keyServerURL = "http://localhost:8080"
+		}
+		cmd.Env = append(cmd.Env,
+			"HLS_ENCRYPTION=true",
+			"HLS_KEY_FILE="+keyFile,
+			fmt.Sprintf("HLS_KEY_URI=%s/api/videos/%d/key", strings.TrimSuffix(keyServerURL, "/"), video.ID),
+		)
+	}
 
 	output, err := cmd.CombinedOutput()
 	if err != nil {
@@ -199,8 +384,391 @@ func (cs *ConverterService) executeTranscoding(video *Video) error {
 
 	log.Printf("✅ 轉碼腳本執行成功: %s", string(output))
 
+	if keyFile != "" {
+		if err := cs.saveEncryptionKeys(video, keyFile); err != nil {
+			return fmt.Errorf("儲存內容金鑰失敗: %v", err)
+		}
+	}
+
 	// 更新影片狀態和 URL
-	return cs.updateVideoAfterTranscoding(video, outputPrefix)
+	return cs.updateVideoAfterTranscoding(video, outputPrefix, string(output))
+}
+
+// detectDuplicate 轉碼前計算原始檔 SHA-256 與感知雜湊，並比對同一用戶已完成的影片
+// 內容完全相同優先於畫面相近；用戶已決定照常轉碼（duplicate_of_id 已設定）或已計算過雜湊時略過；計算失敗不影響轉碼
+func (cs *ConverterService) detectDuplicate(video *Video) bool {
+	perceptual := os.Getenv("DEDUP_PERCEPTUAL") != "false"
+	if video.DuplicateOfID != nil || (video.ContentSHA256 != "" && (!perceptual || video.PerceptualHash != "")) {
+		return false
+	}
+
+	cmd := exec.Command("/scripts/fingerprint.sh", video.OriginalKey, fmt.Sprintf("%d", video.ID))
+	cmd.Env = append(os.Environ(), fmt.Sprintf("FINGERPRINT_PERCEPTUAL=%t", perceptual))
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		log.Printf("⚠️ 計算影片雜湊失敗 - ID: %d, 錯誤: %v, 輸出: %s", video.ID, err, string(output))
+		return false
+	}
+
+	result := parseScriptOutput(string(output))
+	updates := map[string]interface{}{}
+	if hash := result["CONTENT_SHA256"]; hash != "" {
+		video.ContentSHA256 = hash
+		updates["content_sha256"] = hash
+	}
+	if hash := result["PERCEPTUAL_HASH"]; hash != "" {
+		video.PerceptualHash = hash
+		updates["perceptual_hash"] = hash
+	}
+	if len(updates) > 0 {
+		if err := cs.db.Model(&Video{}).Where("id = ?", video.ID).Updates(updates).Error; err != nil {
+			log.Printf("⚠️ 儲存影片雜湊失敗 - ID: %d, 錯誤: %v", video.ID, err)
+		}
+	}
+
+	if video.ContentSHA256 != "" {
+		var sources []Video
+		if err := cs.db.Select("id").
+			Where("user_id = ? AND content_sha256 = ? AND status = ? AND id <> ?", video.UserID, video.ContentSHA256, "ready", video.ID).
+			Order("created_at ASC").
+			Limit(1).
+			Find(&sources).Error; err != nil {
+			log.Printf("⚠️ 查詢重複候選影片失敗: %v", err)
+		} else if len(sources) > 0 {
+			return cs.markDuplicate(video, sources[0].ID, "sha256", "內容完全相同")
+		}
+	}
+
+	if !perceptual || video.PerceptualHash == "" {
+		return false
+	}
+
+	// 只比對長度相近的影片，容許片頭片尾的些微差異
+	duration, _ := strconv.ParseFloat(result["DURATION"], 64)
+	var candidates []Video
+	if err := cs.db.Select("id", "perceptual_hash").
+		Where("user_id = ? AND status = ? AND id <> ? AND perceptual_hash <> ''", video.UserID, "ready", video.ID).
+		Where("duration BETWEEN ? AND ?", int(duration)-2, int(duration)+2).
+		Find(&candidates).Error; err != nil {
+		log.Printf("⚠️ 查詢重複候選影片失敗: %v", err)
+		return false
+	}
+
+	threshold := 10
+	if value, err := strconv.Atoi(os.Getenv("DEDUP_PERCEPTUAL_THRESHOLD")); err == nil && value >= 0 {
+		threshold = value
+	}
+
+	for _, candidate := range candidates {
+		distance, ok := perceptualDistance(video.PerceptualHash, candidate.PerceptualHash)
+		if !ok || distance > threshold {
+			continue
+		}
+		return cs.markDuplicate(video, candidate.ID, "perceptual", fmt.Sprintf("畫面相近（平均差異 %d 位元）", distance))
+	}
+	return false
+}
+
+// markDuplicate 標記影片為重複上傳並停止轉碼，API 服務會通知擁有者選擇共用轉碼結果或重新轉碼
+func (cs *ConverterService) markDuplicate(video *Video, sourceID uint, match, reason string) bool {
+	if err := cs.db.Model(&Video{}).Where("id = ?", video.ID).Updates(map[string]interface{}{
+		"status":              "duplicate",
+		"processing_progress": 0,
+		"duplicate_of_id":     sourceID,
+		"duplicate_match":     match,
+		"updated_at":          time.Now(),
+	}).Error; err != nil {
+		log.Printf("❌ 標記重複影片失敗: %v", err)
+		return false
+	}
+
+	// fingerprint.sh 下載的原始檔不會再由 transcode.sh 使用
+	os.RemoveAll(fmt.Sprintf("/tmp/transcoding/%d", video.ID))
+	log.Printf("♻️ 影片 ID: %d 與影片 ID: %d %s，等待用戶決定", video.ID, sourceID, reason)
+	return true
+}
+
+// perceptualDistance 計算兩組感知雜湊逐張畫面的平均漢明距離，畫面數差異過大時視為不相符
+func perceptualDistance(a, b string) (int, bool) {
+	const frameLength = 16
+	framesA, framesB := len(a)/frameLength, len(b)/frameLength
+	frames := framesA
+	if framesB < frames {
+		frames = framesB
+	}
+	if frames == 0 || framesA-frames > 1 || framesB-frames > 1 {
+		return 0, false
+	}
+
+	total := 0
+	for i := 0; i < frames; i++ {
+		x, errA := strconv.ParseUint(a[i*frameLength:(i+1)*frameLength], 16, 64)
+		y, errB := strconv.ParseUint(b[i*frameLength:(i+1)*frameLength], 16, 64)
+		if errA != nil || errB != nil {
+			return 0, false
+		}
+		total += bits.OnesCount64(x ^ y)
+	}
+	return total / frames, true
+}
+
+// checkPendingClips 檢查待剪輯的精華片段
+func (cs *ConverterService) checkPendingClips() {
+	var videos []Video
+	err := cs.db.Where("status = ?", "clipping").
+		Limit(10).
+		Find(&videos).Error
+
+	if err != nil {
+		log.Printf("❌ 查詢待剪輯影片失敗: %v", err)
+		return
+	}
+
+	if len(videos) == 0 {
+		return
+	}
+
+	log.Printf("📋 找到 %d 個待剪輯片段", len(videos))
+
+	for _, video := range videos {
+		cs.processClip(&video)
+	}
+}
+
+// processClip 處理單個精華片段
+func (cs *ConverterService) processClip(video *Video) {
+	log.Printf("✂️ 開始剪輯片段 ID: %d, 標題: %s", video.ID, video.Title)
+
+	var clip VideoClip
+	if err := cs.db.Where("video_id = ?", video.ID).First(&clip).Error; err != nil {
+		cs.markVideoAsFailed(video, fmt.Sprintf("找不到剪輯資訊: %v", err))
+		return
+	}
+
+	if err := cs.updateVideoStatus(video.ID, "clipping", 10); err != nil {
+		log.Printf("❌ 更新影片狀態失敗: %v", err)
+		return
+	}
+
+	if err := cs.executeClipping(video, &clip); err != nil {
+		cs.markVideoAsFailed(video, err.Error())
+		return
+	}
+
+	log.Printf("✅ 片段 ID: %d 剪輯完成，進入轉碼流程", video.ID)
+}
+
+// executeClipping 執行剪輯
+func (cs *ConverterService) executeClipping(video *Video, clip *VideoClip) error {
+	outputPrefix := fmt.Sprintf("videos/processed/%d/%d", video.UserID, video.ID)
+
+	log.Printf("✂️ 執行剪輯 - VideoID: %d, Source: %s, Start: %.2f, End: %.2f",
+		video.ID, clip.SourceType, clip.StartTime, clip.EndTime)
+
+	cmd := exec.Command("/scripts/clip.sh",
+		clip.SourceInput,
+		video.OriginalKey,
+		strconv.FormatFloat(clip.StartTime, 'f', 3, 64),
+		strconv.FormatFloat(clip.EndTime-clip.StartTime, 'f', 3, 64),
+		outputPrefix,
+		fmt.Sprintf("%d", video.ID),
+	)
+
+	// 直播片段的起點相對於開播時間，需告知腳本目前已直播的秒數以換算 DVR 視窗位置
+	cmd.Env = os.Environ()
+	if clip.SourceType == "live" && clip.StartAt != nil {
+		startedAt := clip.StartAt.Add(-time.Duration(clip.StartTime * float64(time.Second)))
+		cmd.Env = append(cmd.Env, fmt.Sprintf("CLIP_LIVE_ELAPSED=%.3f", time.Since(startedAt).Seconds()))
+	}
+
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("剪輯失敗: %v, 輸出: %s", err, string(output))
+	}
+
+	log.Printf("✅ 剪輯腳本執行成功: %s", string(output))
+
+	result := parseScriptOutput(string(output))
+
+	if err := cs.db.Model(&VideoClip{}).Where("id = ?", clip.ID).
+		Update("cut_mode", result["CUT_MODE"]).Error; err != nil {
+		log.Printf("⚠️ 更新剪輯模式失敗: %v", err)
+	}
+
+	cdnBaseURL := os.Getenv("CDN_BASE_URL")
+	if cdnBaseURL == "" {
+		cdnBaseURL = "http://localhost:9000/stream-demo-processed"
+	}
+
+	fileSize, _ := strconv.ParseInt(result["FILE_SIZE"], 10, 64)
+
+	// 剪輯完成後交由一般轉碼流程產生 HLS 與 MP4
+	return cs.db.Model(&Video{}).Where("id = ?", video.ID).Updates(map[string]interface{}{
+		"status":              "processing",
+		"processing_progress": 0,
+		"file_size":           fileSize,
+		"thumbnail_url":       fmt.Sprintf("%s/%s/thumbnails/thumb_640x480.jpg", cdnBaseURL, outputPrefix),
+		"updated_at":          time.Now(),
+	}).Error
+}
+
+// checkPendingCaptions 檢查待處理的字幕（新增、更新或刪除）
+func (cs *ConverterService) checkPendingCaptions() {
+	var videoIDs []uint
+	err := cs.db.Model(&VideoCaption{}).
+		Joins("JOIN videos ON videos.id = video_captions.video_id").
+		Where("video_captions.status IN ? AND videos.status = ? AND videos.deleted_at IS NULL", []string{"processing", "deleting"}, "ready").
+		Distinct().
+		Limit(10).
+		Pluck("video_captions.video_id", &videoIDs).Error
+
+	if err != nil {
+		log.Printf("❌ 查詢待處理字幕失敗: %v", err)
+		return
+	}
+
+	for _, videoID := range videoIDs {
+		var video Video
+		if err := cs.db.First(&video, videoID).Error; err != nil {
+			log.Printf("❌ 查詢影片失敗 - ID: %d, 錯誤: %v", videoID, err)
+			continue
+		}
+		cs.processCaptions(&video)
+	}
+}
+
+// processCaptions 處理單支影片的字幕並重建主播放列表
+func (cs *ConverterService) processCaptions(video *Video) {
+	var captions []VideoCaption
+	if err := cs.db.Where("video_id = ?", video.ID).Order("is_default DESC, language ASC").Find(&captions).Error; err != nil {
+		log.Printf("❌ 查詢影片字幕失敗 - ID: %d, 錯誤: %v", video.ID, err)
+		return
+	}
+
+	outputPrefix := fmt.Sprintf("videos/processed/%d/%d", video.UserID, video.ID)
+	cdnBaseURL := os.Getenv("CDN_BASE_URL")
+	if cdnBaseURL == "" {
+		cdnBaseURL = "http://localhost:9000/stream-demo-processed"
+	}
+
+	var processed, deleting []VideoCaption
+	masterArgs := []string{"master", outputPrefix}
+
+	for _, caption := range captions {
+		switch caption.Status {
+		case "processing":
+			log.Printf("📝 處理字幕 - VideoID: %d, 語言: %s", video.ID, caption.Language)
+			output, err := exec.Command("/scripts/caption.sh", "segment",
+				caption.SourceKey, caption.Format, outputPrefix, caption.Language).CombinedOutput()
+			if err != nil {
+				cs.markCaptionAsFailed(&caption, fmt.Sprintf("字幕處理失敗: %v, 輸出: %s", err, string(output)))
+				continue
+			}
+			processed = append(processed, caption)
+		case "deleting":
+			deleting = append(deleting, caption)
+			continue
+		case "ready":
+		default:
+			continue
+		}
+
+		masterArgs = append(masterArgs, caption.Language, captionPlaylistLabel(caption.Label), captionDefaultFlag(caption.IsDefault))
+	}
+
+	if len(processed) == 0 && len(deleting) == 0 {
+		return
+	}
+
+	if output, err := exec.Command("/scripts/caption.sh", masterArgs...).CombinedOutput(); err != nil {
+		message := fmt.Sprintf("更新主播放列表失敗: %v, 輸出: %s", err, string(output))
+		for i := range processed {
+			cs.markCaptionAsFailed(&processed[i], message)
+		}
+		// 刪除中的字幕保持原狀態，下次輪詢重試
+		return
+	}
+
+	for _, caption := range processed {
+		subsPrefix := fmt.Sprintf("%s/%s/hls/subs/%s", cdnBaseURL, outputPrefix, caption.Language)
+		if err := cs.db.Model(&VideoCaption{}).Where("id = ?", caption.ID).Updates(map[string]interface{}{
+			"status":        "ready",
+			"vtt_url":       subsPrefix + "/captions.vtt",
+			"playlist_url":  subsPrefix + "/index.m3u8",
+			"error_message": "",
+			"updated_at":    time.Now(),
+		}).Error; err != nil {
+			log.Printf("❌ 更新字幕狀態失敗: %v", err)
+			continue
+		}
+		log.Printf("✅ 字幕完成 - VideoID: %d, 語言: %s", video.ID, caption.Language)
+	}
+
+	for _, caption := range deleting {
+		if output, err := exec.Command("/scripts/caption.sh", "remove", outputPrefix, caption.Language).CombinedOutput(); err != nil {
+			log.Printf("⚠️ 移除字幕檔案失敗: %v, 輸出: %s", err, string(output))
+		}
+		if err := cs.db.Delete(&VideoCaption{}, caption.ID).Error; err != nil {
+			log.Printf("❌ 刪除字幕記錄失敗: %v", err)
+			continue
+		}
+		log.Printf("🗑️ 字幕已刪除 - VideoID: %d, 語言: %s", video.ID, caption.Language)
+	}
+}
+
+// markCaptionAsFailed 標記字幕為失敗狀態
+func (cs *ConverterService) markCaptionAsFailed(caption *VideoCaption, errorMessage string) {
+	log.Printf("❌ 字幕處理失敗 - ID: %d, 錯誤: %s", caption.ID, errorMessage)
+
+	if len(errorMessage) > 450 {
+		errorMessage = errorMessage[:450] + "..."
+	}
+
+	if err := cs.db.Model(&VideoCaption{}).Where("id = ?", caption.ID).Updates(map[string]interface{}{
+		"status":        "failed",
+		"error_message": errorMessage,
+		"updated_at":    time.Now(),
+	}).Error; err != nil {
+		log.Printf("❌ 更新字幕失敗狀態失敗: %v", err)
+	}
+}
+
+// parseScriptOutputValues 解析腳本輸出中重複出現的 KEY=VALUE 行
+func parseScriptOutputValues(output, key string) []string {
+	var values []string
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		if value, ok := strings.CutPrefix(strings.TrimSpace(scanner.Text()), key+"="); ok {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// captionPlaylistLabel 移除不能出現在 HLS 引號字串中的字元
+func captionPlaylistLabel(label string) string {
+	return strings.NewReplacer("\"", "", "\r", "", "\n", "", "\\", "").Replace(label)
+}
+
+// captionDefaultFlag 轉換為 HLS DEFAULT 屬性值
+func captionDefaultFlag(isDefault bool) string {
+	if isDefault {
+		return "YES"
+	}
+	return "NO"
+}
+
+// parseScriptOutput 解析腳本輸出的 KEY=VALUE 行
+func parseScriptOutput(output string) map[string]string {
+	result := make(map[string]string)
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(strings.TrimSpace(scanner.Text()), "=")
+		if ok && key != "" && strings.ToUpper(key) == key {
+			result[key] = value
+		}
+	}
+	return result
 }
 
 // updateVideoStatus 更新影片狀態
@@ -213,7 +781,9 @@ func (cs *ConverterService) updateVideoStatus(videoID uint, status string, progr
 }
 
 // updateVideoAfterTranscoding 轉碼完成後更新影片資訊
-func (cs *ConverterService) updateVideoAfterTranscoding(video *Video, outputPrefix string) error {
+func (cs *ConverterService) updateVideoAfterTranscoding(video *Video, outputPrefix string, output string) error {
+	result := parseScriptOutput(output)
+
 	// 從環境變數獲取 CDN 基礎 URL
 	cdnBaseURL := os.Getenv("CDN_BASE_URL")
 	if cdnBaseURL == "" {
@@ -236,7 +806,261 @@ func (cs *ConverterService) updateVideoAfterTranscoding(video *Video, outputPref
 		"updated_at":          time.Now(),
 	}
 
-	return cs.db.Model(&Video{}).Where("id = ?", video.ID).Updates(updates).Error
+	// 加密影片不產生未加密的 MP4，避免繞過 AES-128 HLS 直接下載
+	if video.Encrypted {
+		updates["mp4_url"] = ""
+		updates["mp4_key"] = ""
+	}
+
+	// 記錄實際套用的品牌元素
+	updates["branding_applied"] = result["BRANDING_APPLIED"]
+
+	// CMAF 封裝時 DASH MPD 與 HLS 共用同一組分段
+	updates["packaging"] = "ts"
+	updates["dash_manifest_url"] = ""
+	if result["PACKAGING"] == "cmaf" {
+		updates["packaging"] = "cmaf"
+	}
+	if result["DASH_MANIFEST"] != "" {
+		updates["dash_manifest_url"] = fmt.Sprintf("%s/%s/%s", cdnBaseURL, outputPrefix, result["DASH_MANIFEST"])
+	}
+
+	// 拖曳預覽為選用輸出，未產生雪碧圖時不寫入
+	if spriteCount, _ := strconv.Atoi(result["PREVIEW_SPRITE_COUNT"]); spriteCount > 0 {
+		interval, _ := strconv.Atoi(result["PREVIEW_INTERVAL"])
+		updates["preview_vtt_url"] = fmt.Sprintf("%s/%s/thumbnails/preview/thumbnails.vtt", cdnBaseURL, outputPrefix)
+		updates["preview_sprite_count"] = spriteCount
+		updates["preview_interval"] = interval
+	}
+
+	if err := cs.db.Model(&Video{}).Where("id = ?", video.ID).Updates(updates).Error; err != nil {
+		return err
+	}
+
+	if err := cs.saveRenditions(video, outputPrefix, cdnBaseURL, output); err != nil {
+		log.Printf("⚠️ 儲存畫質與音軌資訊失敗: %v", err)
+	}
+
+	// 轉碼會覆寫主播放列表，已完成的字幕需重新加入
+	return cs.db.Model(&VideoCaption{}).
+		Where("video_id = ? AND status = ?", video.ID, "ready").
+		Update("status", "processing").Error
+}
+
+// saveRenditions 依轉碼腳本輸出重建影片的畫質與音軌記錄
+func (cs *ConverterService) saveRenditions(video *Video, outputPrefix, cdnBaseURL, output string) error {
+	hlsPrefix := fmt.Sprintf("%s/hls", outputPrefix)
+	var qualities []VideoQuality
+	var tracks []VideoAudioTrack
+
+	// QUALITY=720p:1280:720:2500k
+	for _, value := range parseScriptOutputValues(output, "QUALITY") {
+		parts := strings.Split(value, ":")
+		if len(parts) != 4 {
+			continue
+		}
+		width, _ := strconv.Atoi(parts[1])
+		height, _ := strconv.Atoi(parts[2])
+		qualities = append(qualities, VideoQuality{
+			VideoID: video.ID,
+			Quality: parts[0],
+			Width:   width,
+			Height:  height,
+			Bitrate: parseKbps(parts[3]),
+			FileURL: fmt.Sprintf("%s/%s/%s/index.m3u8", cdnBaseURL, hlsPrefix, parts[0]),
+			FileKey: fmt.Sprintf("%s/%s/index.m3u8", hlsPrefix, parts[0]),
+			Status:  "ready",
+		})
+	}
+
+	// AUDIO_ONLY=audio_only|64k
+	for _, value := range parseScriptOutputValues(output, "AUDIO_ONLY") {
+		parts := strings.Split(value, "|")
+		if len(parts) != 2 {
+			continue
+		}
+		qualities = append(qualities, VideoQuality{
+			VideoID: video.ID,
+			Quality: "audio",
+			Bitrate: parseKbps(parts[1]),
+			FileURL: fmt.Sprintf("%s/%s/%s/index.m3u8", cdnBaseURL, hlsPrefix, parts[0]),
+			FileKey: fmt.Sprintf("%s/%s/index.m3u8", hlsPrefix, parts[0]),
+			Status:  "ready",
+		})
+	}
+
+	// AUDIO_TRACK=audio_0|6|eng|Main|128k（名稱|來源聲道|語言|標題|位元率）
+	for i, value := range parseScriptOutputValues(output, "AUDIO_TRACK") {
+		parts := strings.Split(value, "|")
+		if len(parts) != 5 {
+			continue
+		}
+		sourceChannels, _ := strconv.Atoi(parts[1])
+		channels := sourceChannels
+		if channels > 2 || channels <= 0 {
+			channels = 2
+		}
+		label := parts[3]
+		if label == "" {
+			label = parts[2]
+		}
+		tracks = append(tracks, VideoAudioTrack{
+			VideoID:        video.ID,
+			Name:           parts[0],
+			Language:       parts[2],
+			Label:          label,
+			Channels:       channels,
+			SourceChannels: sourceChannels,
+			Bitrate:        parseKbps(parts[4]),
+			PlaylistURL:    fmt.Sprintf("%s/%s/%s/index.m3u8", cdnBaseURL, hlsPrefix, parts[0]),
+			IsDefault:      i == 0,
+		})
+	}
+
+	return cs.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("video_id = ?", video.ID).Delete(&VideoQuality{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("video_id = ?", video.ID).Delete(&VideoAudioTrack{}).Error; err != nil {
+			return err
+		}
+		if len(qualities) > 0 {
+			if err := tx.Create(&qualities).Error; err != nil {
+				return err
+			}
+		}
+		if len(tracks) > 0 {
+			if err := tx.Create(&tracks).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// parseKbps 解析 2500k 形式的位元率
+func parseKbps(value string) int {
+	kbps, _ := strconv.Atoi(strings.TrimSuffix(strings.ToLower(value), "k"))
+	return kbps
+}
+
+// brandingEnv 依創作者品牌設定產生轉碼腳本的環境變數
+// 精華片段不接片頭/片尾，來源影片已燒入浮水印時也不重複套用
+func (cs *ConverterService) brandingEnv(video *Video) []string {
+	var branding UserBranding
+	if err := cs.db.Where("user_id = ? AND enabled = ?", video.UserID, true).First(&branding).Error; err != nil {
+		return nil
+	}
+
+	isClip := false
+	sourceWatermarked := false
+	var clip VideoClip
+	if err := cs.db.Where("video_id = ?", video.ID).First(&clip).Error; err == nil {
+		isClip = true
+		if clip.SourceVideoID != nil {
+			var source Video
+			if err := cs.db.Select("branding_applied").First(&source, *clip.SourceVideoID).Error; err == nil {
+				sourceWatermarked = strings.Contains(source.BrandingApplied, "watermark")
+			}
+		}
+	}
+
+	var env []string
+	if branding.WatermarkKey != "" && !sourceWatermarked {
+		env = append(env,
+			"BRANDING_WATERMARK_KEY="+branding.WatermarkKey,
+			"BRANDING_WATERMARK_POSITION="+branding.WatermarkPosition,
+			fmt.Sprintf("BRANDING_WATERMARK_OPACITY=%.2f", branding.WatermarkOpacity),
+			fmt.Sprintf("BRANDING_WATERMARK_SCALE=%.2f", branding.WatermarkScale),
+		)
+	}
+
+	if !isClip {
+		refs := []struct {
+			name    string
+			videoID *uint
+		}{
+			{"INTRO", branding.IntroVideoID},
+			{"OUTRO", branding.OutroVideoID},
+		}
+		for _, ref := range refs {
+			// 片頭/片尾影片本身轉碼時不套用
+			if ref.videoID == nil || *ref.videoID == video.ID {
+				continue
+			}
+			var source Video
+			if err := cs.db.Select("original_key").Where("id = ? AND status = ?", *ref.videoID, "ready").
+				First(&source).Error; err == nil && source.OriginalKey != "" {
+				env = append(env, fmt.Sprintf("BRANDING_%s_KEY=%s", ref.name, source.OriginalKey))
+			}
+		}
+	}
+
+	return env
+}
+
+// saveEncryptionKeys 讀取轉碼腳本產生的金鑰檔（每行 index:hex），加密後取代影片原有的金鑰
+func (cs *ConverterService) saveEncryptionKeys(video *Video, keyFile string) error {
+	file, err := os.Open(keyFile)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	secret := os.Getenv("HLS_KEY_SECRET")
+	if secret == "" {
+		secret = "local_video_key_secret"
+	}
+
+	var keys []VideoEncryptionKey
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		index, hexKey, found := strings.Cut(strings.TrimSpace(scanner.Text()), ":")
+		if !found {
+			continue
+		}
+		keyIndex, err := strconv.Atoi(index)
+		if err != nil {
+			continue
+		}
+		encrypted, err := encryptSecret(secret, hexKey)
+		if err != nil {
+			return err
+		}
+		keys = append(keys, VideoEncryptionKey{VideoID: video.ID, KeyIndex: keyIndex, Key: encrypted})
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	if len(keys) == 0 {
+		return fmt.Errorf("未產生任何金鑰")
+	}
+
+	return cs.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("video_id = ?", video.ID).Delete(&VideoEncryptionKey{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(&keys).Error
+	})
+}
+
+// encryptSecret 以 AES-256-GCM 加密字串，格式與 API 服務的 utils.EncryptString 相同
+func encryptSecret(secret, plaintext string) (string, error) {
+	key := sha256.Sum256([]byte(secret))
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return "", err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(gcm.Seal(nonce, nonce, []byte(plaintext), nil)), nil
 }
 
 // markVideoAsFailed 標記影片為失敗狀態
@@ -0,0 +1,246 @@
+#!/bin/bash
+
+# 字幕處理腳本
+# 支援：SRT/WebVTT 正規化為 WebVTT、依 HLS 分段切割字幕、重建主播放列表的字幕軌
+
+set -e
+
+usage() {
+    echo "用法:"
+    echo "  $0 segment <source_key> <format> <output_prefix> <language>"
+    echo "  $0 remove <output_prefix> <language>"
+    echo "  $0 master <output_prefix> [<language> <label> <YES|NO>]..."
+    echo "範例: $0 segment captions/1/zh-TW_xxx.srt srt videos/processed/1/1 zh-TW"
+    echo "範例: $0 master videos/processed/1/1 zh-TW 中文 YES en English NO"
+    exit 1
+}
+
+if [ "$#" -lt 2 ]; then
+    usage
+fi
+
+MODE="$1"
+shift
+
+# 環境變數
+MINIO_ENDPOINT="${MINIO_ENDPOINT:-http://minio:9000}"
+MINIO_ACCESS_KEY="${MINIO_ACCESS_KEY:-minioadmin}"
+MINIO_SECRET_KEY="${MINIO_SECRET_KEY:-minioadmin}"
+MINIO_BUCKET="${MINIO_BUCKET:-stream-demo-videos}"
+MINIO_PROCESSED_BUCKET="${MINIO_PROCESSED_BUCKET:-stream-demo-processed}"
+
+# 字幕分段長度（秒）與非 UTF-8 字幕的備用編碼
+CAPTION_SEGMENT_DURATION="${CAPTION_SEGMENT_DURATION:-10}"
+CAPTION_FALLBACK_CHARSET="${CAPTION_FALLBACK_CHARSET:-BIG5}"
+
+# MinIO Client 配置
+echo "🔧 配置 MinIO Client..."
+mc alias set s3 "$MINIO_ENDPOINT" "$MINIO_ACCESS_KEY" "$MINIO_SECRET_KEY"
+
+case "$MODE" in
+segment)
+    if [ "$#" -ne 4 ]; then
+        usage
+    fi
+
+    SOURCE_KEY="$1"
+    FORMAT="$2"
+    OUTPUT_PREFIX="$3"
+    LANGUAGE="$4"
+    HLS_PREFIX="s3/$MINIO_PROCESSED_BUCKET/${OUTPUT_PREFIX}/hls"
+
+    WORK_DIR="/tmp/transcoding/caption_$(echo "${OUTPUT_PREFIX}_${LANGUAGE}" | tr '/' '_')"
+    SUBS_DIR="$WORK_DIR/subs"
+    rm -rf "$WORK_DIR"
+    mkdir -p "$SUBS_DIR"
+
+    # 下載字幕檔
+    echo "📥 下載字幕: $SOURCE_KEY"
+    INPUT_FILE="$WORK_DIR/input.$FORMAT"
+    mc cp "s3/$MINIO_BUCKET/$SOURCE_KEY" "$INPUT_FILE"
+
+    # 正規化為 WebVTT，非 UTF-8 字幕改用備用編碼重新解碼
+    echo "📝 轉換為 WebVTT..."
+    FULL_VTT="$SUBS_DIR/captions.vtt"
+    if ! ffmpeg -i "$INPUT_FILE" -c:s webvtt "$FULL_VTT" -y 2> "$WORK_DIR/ffmpeg.log" \
+        || grep -q "Invalid UTF-8" "$WORK_DIR/ffmpeg.log"; then
+        echo "⚠️ 字幕非 UTF-8 編碼，改用 $CAPTION_FALLBACK_CHARSET 解碼"
+        ffmpeg -sub_charenc "$CAPTION_FALLBACK_CHARSET" -i "$INPUT_FILE" -c:s webvtt "$FULL_VTT" -y
+    fi
+
+    # 從影片的第一個畫質取得總長度與第一個分段的起始時間戳，讓字幕與影片分段對齊
+    echo "📊 分析影片 HLS 時間軸..."
+    VARIANT=$(mc cat "$HLS_PREFIX/index.m3u8" | grep -v '^#' | grep -v '^[[:space:]]*$' | head -n 1 | tr -d '\r')
+    if [ -z "$VARIANT" ]; then
+        echo "❌ 找不到影片 HLS 播放列表"
+        exit 1
+    fi
+    VARIANT_DIR=$(dirname "$VARIANT")
+    mc cat "$HLS_PREFIX/$VARIANT" > "$WORK_DIR/variant.m3u8"
+
+    DURATION=$(awk -F'[:,]' '/^#EXTINF/ { s += $2 } END { printf "%.3f", s }' "$WORK_DIR/variant.m3u8")
+    FIRST_SEGMENT=$(grep -v '^#' "$WORK_DIR/variant.m3u8" | grep -v '^[[:space:]]*$' | head -n 1 | tr -d '\r')
+
+    # fMP4 分段需搭配初始化分段才能解析
+    INIT_SEGMENT=$(grep '^#EXT-X-MAP' "$WORK_DIR/variant.m3u8" | sed 's/.*URI="\([^"]*\)".*/\1/' | tr -d '\r')
+
+    # WebVTT 的 X-TIMESTAMP-MAP 以 90kHz 表示影片第一個分段的 PTS
+    # 加密影片的分段無法直接解析，改用轉碼時記錄的 timestamp_map
+    MPEGTS=0
+    if mc cat "$HLS_PREFIX/timestamp_map" > "$WORK_DIR/timestamp_map" 2>/dev/null; then
+        MPEGTS=$(tr -d '[:space:]' < "$WORK_DIR/timestamp_map")
+        MPEGTS="${MPEGTS:-0}"
+    elif [ -n "$FIRST_SEGMENT" ] && { [ -z "$INIT_SEGMENT" ] || mc cat "$HLS_PREFIX/$VARIANT_DIR/$INIT_SEGMENT" > "$WORK_DIR/first_segment" 2>/dev/null; } \
+        && mc cat "$HLS_PREFIX/$VARIANT_DIR/$FIRST_SEGMENT" >> "$WORK_DIR/first_segment" 2>/dev/null; then
+        START_TIME=$(ffprobe -v quiet -show_entries format=start_time -of csv="p=0" "$WORK_DIR/first_segment" || echo "0")
+        MPEGTS=$(awk -v t="${START_TIME:-0}" 'BEGIN { printf "%d", t * 90000 + 0.5 }')
+    fi
+
+    echo "📏 影片長度: ${DURATION}秒, MPEGTS: ${MPEGTS}"
+
+    # 依固定長度切割字幕，跨越分段邊界的字幕會同時出現在相鄰分段
+    echo "✂️ 切割字幕分段..."
+    awk -v duration="$DURATION" -v segdur="$CAPTION_SEGMENT_DURATION" -v mpegts="$MPEGTS" -v dir="$SUBS_DIR" '
+    function secs(ts,   n, p) {
+        n = split(ts, p, ":")
+        return (n == 3) ? p[1] * 3600 + p[2] * 60 + p[3] : p[1] * 60 + p[2]
+    }
+    function flush() {
+        if (timing != "") {
+            split(timing, t, /[ \t]+-->[ \t]+/)
+            split(t[2], e, /[ \t]/)
+            count++
+            starts[count] = secs(t[1]); ends[count] = secs(e[1]); cues[count] = timing "\n" body
+        }
+        timing = ""; body = ""
+    }
+    /\r$/ { sub(/\r$/, "") }
+    /-->/ { flush(); timing = $0; next }
+    /^[[:space:]]*$/ { flush(); next }
+    { if (timing != "") body = body $0 "\n" }
+    END {
+        flush()
+        segments = int(duration / segdur)
+        if (segments * segdur < duration) segments++
+        if (segments < 1) segments = 1
+
+        playlist = dir "/index.m3u8"
+        print "#EXTM3U" > playlist
+        print "#EXT-X-VERSION:3" > playlist
+        printf "#EXT-X-TARGETDURATION:%d\n", segdur > playlist
+        print "#EXT-X-MEDIA-SEQUENCE:0" > playlist
+        print "#EXT-X-PLAYLIST-TYPE:VOD" > playlist
+
+        for (i = 0; i < segments; i++) {
+            from = i * segdur
+            to = from + segdur
+            if (to > duration && duration > 0) to = duration
+            file = sprintf("segment_%03d.vtt", i)
+            out = dir "/" file
+            print "WEBVTT" > out
+            printf "X-TIMESTAMP-MAP=MPEGTS:%d,LOCAL:00:00:00.000\n\n", mpegts > out
+            for (c = 1; c <= count; c++) {
+                if (starts[c] < to && ends[c] > from) printf "%s\n", cues[c] > out
+            }
+            close(out)
+            printf "#EXTINF:%.3f,\n%s\n", to - from, file > playlist
+        }
+        print "#EXT-X-ENDLIST" > playlist
+        close(playlist)
+    }' "$FULL_VTT"
+
+    # 上傳字幕分段，先清除舊的分段避免殘留
+    echo "📤 上傳字幕分段..."
+    mc rm --recursive --force "$HLS_PREFIX/subs/$LANGUAGE/" > /dev/null 2>&1 || true
+    if ! mc cp --recursive "$SUBS_DIR/" "$HLS_PREFIX/subs/$LANGUAGE/"; then
+        echo "❌ 字幕上傳失敗"
+        exit 1
+    fi
+
+    rm -rf "$WORK_DIR"
+    echo "🎉 字幕處理完成: ${OUTPUT_PREFIX}/hls/subs/${LANGUAGE}/index.m3u8"
+    ;;
+
+remove)
+    if [ "$#" -ne 2 ]; then
+        usage
+    fi
+
+    OUTPUT_PREFIX="$1"
+    LANGUAGE="$2"
+
+    echo "🗑️ 移除字幕分段: ${OUTPUT_PREFIX}/hls/subs/${LANGUAGE}/"
+    mc rm --recursive --force "s3/$MINIO_PROCESSED_BUCKET/${OUTPUT_PREFIX}/hls/subs/$LANGUAGE/" || true
+    ;;
+
+master)
+    OUTPUT_PREFIX="$1"
+    shift
+    if [ $(($# % 3)) -ne 0 ]; then
+        usage
+    fi
+
+    MASTER_KEY="s3/$MINIO_PROCESSED_BUCKET/${OUTPUT_PREFIX}/hls/index.m3u8"
+    WORK_FILE=$(mktemp)
+
+    # 重建字幕軌：移除舊的 SUBTITLES 項目後依目前的字幕重新加入
+    echo "📺 重建主播放列表字幕軌..."
+    MEDIA_LINES=""
+    while [ "$#" -gt 0 ]; do
+        MEDIA_LINES="${MEDIA_LINES}#EXT-X-MEDIA:TYPE=SUBTITLES,GROUP-ID=\"subs\",NAME=\"$2\",LANGUAGE=\"$1\",DEFAULT=$3,AUTOSELECT=YES,FORCED=NO,URI=\"subs/$1/index.m3u8\"
+"
+        shift 3
+    done
+
+    mc cat "$MASTER_KEY" | tr -d '\r' | awk -v media="$MEDIA_LINES" '
+    /^#EXT-X-MEDIA:TYPE=SUBTITLES/ { next }
+    /^#EXT-X-STREAM-INF/ {
+        gsub(/,SUBTITLES="subs"/, "")
+        if (media != "") $0 = $0 ",SUBTITLES=\"subs\""
+    }
+    { print }
+    /^#EXT-X-VERSION/ && media != "" { printf "%s", media }
+    ' > "$WORK_FILE"
+
+    if ! mc cp "$WORK_FILE" "$MASTER_KEY"; then
+        rm -f "$WORK_FILE"
+        echo "❌ 主播放列表上傳失敗"
+        exit 1
+    fi
+
+    # CMAF 封裝時同步更新 DASH MPD 的字幕 AdaptationSet（引用完整的 captions.vtt）
+    MPD_KEY="s3/$MINIO_PROCESSED_BUCKET/${OUTPUT_PREFIX}/hls/manifest.mpd"
+    if mc stat "$MPD_KEY" > /dev/null 2>&1; then
+        echo "📺 重建 DASH MPD 字幕軌..."
+        printf '%s' "$MEDIA_LINES" | awk -F'"' '{
+            for (i = 1; i < NF; i++) {
+                if ($i ~ /NAME=$/) name = $(i + 1)
+                else if ($i ~ /,LANGUAGE=$/) lang = $(i + 1)
+            }
+            role = ($0 ~ /DEFAULT=YES/) ? "main" : "alternate"
+            gsub(/&/, "\\&amp;", name); gsub(/</, "\\&lt;", name); gsub(/>/, "\\&gt;", name)
+            printf "    <AdaptationSet contentType=\"text\" mimeType=\"text/vtt\" lang=\"%s\"><Role schemeIdUri=\"urn:mpeg:dash:role:2011\" value=\"%s\"/><Label>%s</Label><Representation id=\"subs_%s\" bandwidth=\"256\"><BaseURL>subs/%s/captions.vtt</BaseURL></Representation></AdaptationSet>\n", lang, role, name, lang, lang
+        }' > "$WORK_FILE.subs"
+
+        mc cat "$MPD_KEY" | awk -v subs="$WORK_FILE.subs" '
+        /<AdaptationSet contentType="text"/ { next }
+        /<\/Period>/ { while ((getline line < subs) > 0) print line }
+        { print }
+        ' > "$WORK_FILE"
+
+        if ! mc cp "$WORK_FILE" "$MPD_KEY"; then
+            rm -f "$WORK_FILE" "$WORK_FILE.subs"
+            echo "❌ DASH MPD 上傳失敗"
+            exit 1
+        fi
+        rm -f "$WORK_FILE.subs"
+    fi
+
+    rm -f "$WORK_FILE"
+    echo "🎉 主播放列表已更新: ${OUTPUT_PREFIX}/hls/index.m3u8"
+    ;;
+
+*)
+    usage
+    ;;
+esac
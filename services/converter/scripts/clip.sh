@@ -0,0 +1,139 @@
+#!/bin/bash
+
+# FFmpeg 精華片段剪輯腳本
+# 支援：點播影片剪輯、直播 DVR 視窗剪輯、關鍵幀對齊時直接複製串流
+
+set -e
+
+# 參數檢查
+if [ "$#" -ne 6 ]; then
+    echo "用法: $0 <source_input> <output_key> <start> <duration> <output_prefix> <video_id>"
+    echo "範例: $0 stream-demo-processed/videos/processed/1/1/video.mp4 videos/original/1/clip_2.mp4 12.5 30 videos/processed/1/2 2"
+    echo "範例: $0 http://live-cdn/live/hls/<stream_key>/index.m3u8 videos/original/1/clip_3.mp4 40 20 videos/processed/1/3 3"
+    exit 1
+fi
+
+SOURCE_INPUT="$1"
+OUTPUT_KEY="$2"
+START="$3"
+DURATION="$4"
+OUTPUT_PREFIX="$5"
+VIDEO_ID="$6"
+
+# 環境變數
+MINIO_ENDPOINT="${MINIO_ENDPOINT:-http://minio:9000}"
+MINIO_ACCESS_KEY="${MINIO_ACCESS_KEY:-minioadmin}"
+MINIO_SECRET_KEY="${MINIO_SECRET_KEY:-minioadmin}"
+MINIO_BUCKET="${MINIO_BUCKET:-stream-demo-videos}"
+MINIO_PROCESSED_BUCKET="${MINIO_PROCESSED_BUCKET:-stream-demo-processed}"
+
+# 起點與最近關鍵幀的容許誤差（秒），超過時改為重新編碼以取得精準切點
+KEYFRAME_TOLERANCE="${CLIP_KEYFRAME_TOLERANCE:-0.5}"
+
+# 工作目錄
+WORK_DIR="/tmp/transcoding/clip_${VIDEO_ID}"
+mkdir -p "$WORK_DIR"
+
+# MinIO Client 配置
+echo "🔧 配置 MinIO Client..."
+mc alias set s3 "$MINIO_ENDPOINT" "$MINIO_ACCESS_KEY" "$MINIO_SECRET_KEY"
+
+OUTPUT_FILE="$WORK_DIR/clip.mp4"
+
+case "$SOURCE_INPUT" in
+    http://*|https://*)
+        # 直播來源：起點相對於開播時間，播放列表只保留 DVR 視窗，需以視窗長度換算
+        echo "📡 擷取直播 DVR 內容: $SOURCE_INPUT"
+        LIVE_ELAPSED="${CLIP_LIVE_ELAPSED:?直播剪輯需要 CLIP_LIVE_ELAPSED}"
+        WINDOW=$(curl -fsS "$SOURCE_INPUT" | awk -F'[:,]' '/^#EXTINF/ { s += $2 } END { print s + 0 }')
+        START=$(echo "$START - ($LIVE_ELAPSED - $WINDOW)" | bc -l)
+        if [ "$(echo "$START < 0" | bc -l)" -eq 1 ]; then
+            START=0
+        fi
+
+        # 只擷取到片段結尾，避免持續跟隨直播播放列表
+        INPUT_FILE="$WORK_DIR/source.ts"
+        CAPTURE=$(echo "$START + $DURATION + 1" | bc -l)
+        ffmpeg -live_start_index 0 -i "$SOURCE_INPUT" -t "$CAPTURE" -c copy -f mpegts "$INPUT_FILE" -y
+        ;;
+    *)
+        # 點播來源：從儲存桶下載
+        echo "📥 下載來源影片: $SOURCE_INPUT"
+        INPUT_FILE="$WORK_DIR/source.$(echo $SOURCE_INPUT | rev | cut -d. -f1 | rev)"
+        mc cp "s3/$SOURCE_INPUT" "$INPUT_FILE"
+        ;;
+esac
+
+if [ ! -f "$INPUT_FILE" ]; then
+    echo "❌ 來源取得失敗: $INPUT_FILE"
+    exit 1
+fi
+
+# MPEG-TS 的時間戳不一定從 0 開始，比對關鍵幀時需扣除
+INPUT_START=$(ffprobe -v quiet -show_entries format=start_time -of csv="p=0" "$INPUT_FILE" || echo "0")
+
+# 查找起點之前最近的關鍵幀
+echo "🔍 分析關鍵幀位置..."
+SEEK_FROM=$(echo "$START - 10" | bc -l)
+if [ "$(echo "$SEEK_FROM < 0" | bc -l)" -eq 1 ]; then
+    SEEK_FROM=0
+fi
+KEYFRAME=$(ffprobe -v quiet -select_streams v:0 -skip_frame nokey \
+    -read_intervals "${SEEK_FROM}%+20" \
+    -show_entries frame=pts_time -of csv="p=0" "$INPUT_FILE" \
+    | awk -v start="$START" -v offset="${INPUT_START:-0}" '{ t = $1 - offset; if (t <= start + 0.001) last = t } END { if (last != "") print last }')
+
+CUT_MODE="reencode"
+if [ -n "$KEYFRAME" ] && [ "$(echo "$START - $KEYFRAME <= $KEYFRAME_TOLERANCE" | bc -l)" -eq 1 ]; then
+    CUT_MODE="copy"
+fi
+
+echo "✂️ 剪輯片段: 起點 ${START}s, 長度 ${DURATION}s, 最近關鍵幀 ${KEYFRAME:-無}, 模式 ${CUT_MODE}"
+
+if [ "$CUT_MODE" = "copy" ]; then
+    # 起點接近關鍵幀，直接複製串流
+    ffmpeg -ss "$KEYFRAME" -i "$INPUT_FILE" -t "$DURATION" \
+        -c copy -avoid_negative_ts make_zero \
+        -movflags +faststart \
+        -f mp4 "$OUTPUT_FILE" -y
+else
+    # 起點不在關鍵幀上，重新編碼以取得精準切點
+    ffmpeg -ss "$START" -i "$INPUT_FILE" -t "$DURATION" \
+        -c:v libx264 -preset veryfast -crf 20 \
+        -c:a aac -ac 2 -b:a 128k \
+        -movflags +faststart \
+        -f mp4 "$OUTPUT_FILE" -y
+fi
+
+if [ ! -f "$OUTPUT_FILE" ]; then
+    echo "❌ 片段檔案未生成"
+    exit 1
+fi
+
+# 生成預覽縮圖，轉碼完成後會被正式縮圖覆蓋
+THUMB_DIR="$WORK_DIR/thumbnails"
+mkdir -p "$THUMB_DIR"
+THUMB_TIME=$(echo "$DURATION / 2" | bc)
+ffmpeg -i "$OUTPUT_FILE" -ss "$THUMB_TIME" -vframes 1 -s 640x480 "$THUMB_DIR/thumb_640x480.jpg" -y || true
+
+# 上傳片段作為新影片的原始檔
+echo "📤 上傳片段: $OUTPUT_KEY"
+if ! mc cp "$OUTPUT_FILE" "s3/$MINIO_BUCKET/$OUTPUT_KEY"; then
+    echo "❌ 片段上傳失敗"
+    exit 1
+fi
+
+if [ -f "$THUMB_DIR/thumb_640x480.jpg" ]; then
+    mc cp "$THUMB_DIR/thumb_640x480.jpg" "s3/$MINIO_PROCESSED_BUCKET/${OUTPUT_PREFIX}/thumbnails/thumb_640x480.jpg" || true
+fi
+
+FILE_SIZE=$(stat -f%z "$OUTPUT_FILE" 2>/dev/null || stat -c%s "$OUTPUT_FILE")
+
+# 清理工作目錄
+echo "🧹 清理臨時文件..."
+rm -rf "$WORK_DIR"
+
+echo "🎉 剪輯完成！"
+# 以下輸出供 converter 解析
+echo "CUT_MODE=$CUT_MODE"
+echo "FILE_SIZE=$FILE_SIZE"
@@ -0,0 +1,101 @@
+#!/bin/bash
+
+# 影片雜湊腳本
+# 計算原始檔 SHA-256，並平均取樣數張畫面，每張縮為 9x8 灰階後計算 dHash（相鄰像素亮度比較，64 位元）
+# 重新編碼、改變解析度或位元率後感知雜湊仍相近，供 converter 轉碼前比對重複上傳
+
+set -e
+
+# 參數檢查
+if [ "$#" -ne 2 ]; then
+    echo "用法: $0 <input_key> <video_id>"
+    echo "範例: $0 videos/original/1/input.mov 1"
+    exit 1
+fi
+
+INPUT_KEY="$1"
+VIDEO_ID="$2"
+
+# 環境變數
+MINIO_ENDPOINT="${MINIO_ENDPOINT:-http://minio:9000}"
+MINIO_ACCESS_KEY="${MINIO_ACCESS_KEY:-minioadmin}"
+MINIO_SECRET_KEY="${MINIO_SECRET_KEY:-minioadmin}"
+MINIO_BUCKET="${MINIO_BUCKET:-stream-demo-videos}"
+
+# 取樣畫面數，FINGERPRINT_PERCEPTUAL=false 時只計算 SHA-256
+FINGERPRINT_FRAMES="${FINGERPRINT_FRAMES:-16}"
+FINGERPRINT_PERCEPTUAL="${FINGERPRINT_PERCEPTUAL:-true}"
+
+# 與 transcode.sh 共用工作目錄與下載檔，不重複時可直接接著轉碼
+WORK_DIR="/tmp/transcoding/${VIDEO_ID}"
+mkdir -p "$WORK_DIR"
+
+# MinIO Client 配置
+echo "🔧 配置 MinIO Client..."
+mc alias set s3 "$MINIO_ENDPOINT" "$MINIO_ACCESS_KEY" "$MINIO_SECRET_KEY"
+
+# 下載原始文件（先寫入暫存檔，避免中斷時留下不完整的輸入）
+INPUT_FILE="$WORK_DIR/input.$(echo $INPUT_KEY | rev | cut -d. -f1 | rev)"
+if [ ! -f "$INPUT_FILE" ]; then
+    echo "📥 下載原始影片: $INPUT_KEY"
+    mc cp "s3/$MINIO_BUCKET/$INPUT_KEY" "$INPUT_FILE.part"
+    mv "$INPUT_FILE.part" "$INPUT_FILE"
+fi
+
+CONTENT_SHA256=$(sha256sum "$INPUT_FILE" | cut -d' ' -f1)
+echo "✅ 內容雜湊完成"
+
+if [ "$FINGERPRINT_PERCEPTUAL" = "false" ]; then
+    echo "CONTENT_SHA256=$CONTENT_SHA256"
+    exit 0
+fi
+
+DURATION=$(ffprobe -v quiet -show_entries format=duration -of csv="p=0" "$INPUT_FILE")
+if [ -z "$DURATION" ] || [ "$(echo "$DURATION <= 0" | bc -l)" -eq 1 ]; then
+    echo "❌ 無法取得影片長度"
+    exit 1
+fi
+
+# 平均分佈取樣點，從每段中間取畫面以避開片頭黑畫面
+INTERVAL=$(echo "$DURATION / $FINGERPRINT_FRAMES" | bc -l)
+RAW_FILE="$WORK_DIR/fingerprint.gray"
+ffmpeg -v error -ss "$(echo "$INTERVAL / 2" | bc -l)" -i "$INPUT_FILE" \
+    -vf "fps=1/${INTERVAL},scale=9:8:flags=area,format=gray" \
+    -frames:v "$FINGERPRINT_FRAMES" -f rawvideo "$RAW_FILE" -y
+
+# 每張畫面 72 個像素，逐列比較相鄰像素產生 64 位元，以 16 位十六進位輸出
+HASH=$(od -An -v -tu1 "$RAW_FILE" | awk '
+{ for (i = 1; i <= NF; i++) px[n++] = $i }
+END {
+    size = 72
+    frames = int(n / size)
+    for (f = 0; f < frames; f++) {
+        base = f * size
+        nibble = 0
+        bits = 0
+        for (y = 0; y < 8; y++) {
+            for (x = 0; x < 8; x++) {
+                i = base + y * 9 + x
+                nibble = nibble * 2 + (px[i] > px[i + 1] ? 1 : 0)
+                if (++bits == 4) {
+                    printf "%x", nibble
+                    nibble = 0
+                    bits = 0
+                }
+            }
+        }
+    }
+}')
+rm -f "$RAW_FILE"
+
+if [ -z "$HASH" ]; then
+    echo "❌ 無法取樣畫面"
+    exit 1
+fi
+
+echo "✅ 感知雜湊完成: $(( ${#HASH} / 16 )) 張畫面"
+
+# 輸出供 converter 解析
+echo "CONTENT_SHA256=$CONTENT_SHA256"
+echo "PERCEPTUAL_HASH=$HASH"
+echo "DURATION=$DURATION"
@@ -0,0 +1,55 @@
+#!/bin/bash
+
+# Per-title 畫質階梯篩選腳本
+# 輸入由低到高排列、已決定位元率的畫質（name:width:height:bitrate），輸出保留的畫質（每行一個）
+#
+# 規則：
+#   - 最低畫質一定保留，作為頻寬不足時的備援
+#   - 其餘畫質的位元率需達到上一個「保留」畫質的 min_step 倍，否則代表提高解析度幾乎沒有畫質提升而略過
+#   - 最高畫質一定保留；與上一個保留畫質過於接近且該畫質不是最低畫質時，以最高畫質取代
+
+set -e
+
+if [ "$#" -lt 2 ]; then
+    echo "用法: $0 <min_step> <name:width:height:bitrate>..." >&2
+    echo "範例: $0 1.5 360p:640:360:400k 480p:854:480:500k 720p:1280:720:1400k" >&2
+    exit 1
+fi
+
+MIN_STEP="$1"
+shift
+RUNGS=("$@")
+
+# below_step 判斷位元率是否未達上一個保留畫質的 MIN_STEP 倍
+below_step() {
+    awk -v chosen="$1" -v prev="$2" -v step="$MIN_STEP" 'BEGIN { exit !(chosen < prev * step) }'
+}
+
+declare -a KEPT=()
+prev_bitrate=0
+last=$((${#RUNGS[@]} - 1))
+for i in "${!RUNGS[@]}"; do
+    rung="${RUNGS[$i]}"
+    IFS=':' read -r name width height bitrate <<< "$rung"
+    bitrate="${bitrate%k}"
+
+    if [ "${#KEPT[@]}" -eq 0 ] || ! below_step "$bitrate" "$prev_bitrate"; then
+        KEPT+=("$rung")
+        prev_bitrate="$bitrate"
+        continue
+    fi
+
+    if [ "$i" -ne "$last" ]; then
+        echo "✂️ 略過 ${name}：位元率 ${bitrate}k 與 ${KEPT[-1]%%:*} 相近" >&2
+        continue
+    fi
+
+    if [ "${#KEPT[@]}" -gt 1 ]; then
+        echo "✂️ 捨棄 ${KEPT[-1]%%:*}：${name} 以相近位元率提供更高解析度" >&2
+        unset 'KEPT[-1]'
+    fi
+    KEPT+=("$rung")
+    prev_bitrate="$bitrate"
+done
+
+printf '%s\n' "${KEPT[@]}"
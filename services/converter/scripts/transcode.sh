@@ -1,14 +1,14 @@
 #!/bin/bash
 
 # FFmpeg 影片轉碼腳本
-# 支援：多品質 HLS 串流、MP4 轉換、縮圖生成
+# 支援：多品質 HLS 串流（MPEG-TS 或 CMAF fMP4 + DASH）、MP4 轉換、縮圖生成
 
 set -e
 
 # 參數檢查
-if [ "$#" -ne 4 ]; then
-    echo "用法: $0 <input_key> <output_prefix> <user_id> <video_id>"
-    echo "範例: $0 videos/original/1/input.mov videos/processed/1/1 1 1"
+if [ "$#" -lt 4 ] || [ "$#" -gt 5 ]; then
+    echo "用法: $0 <input_key> <output_prefix> <user_id> <video_id> [ladder]"
+    echo "範例: $0 videos/original/1/input.mov videos/processed/1/1 1 1 720p:1280:720:2500k,360p:640:360:800k"
     exit 1
 fi
 
@@ -17,6 +17,9 @@ OUTPUT_PREFIX="$2"
 USER_ID="$3"
 VIDEO_ID="$4"
 
+# 畫質階梯上限（name:width:height:bitrate，以逗號分隔），實際位元率由內容複雜度決定
+TRANSCODE_LADDER="${5:-${TRANSCODE_LADDER:-720p:1280:720:2500k,480p:854:480:1200k,360p:640:360:800k}}"
+
 # 環境變數
 MINIO_ENDPOINT="${MINIO_ENDPOINT:-http://minio:9000}"
 MINIO_ACCESS_KEY="${MINIO_ACCESS_KEY:-minioadmin}"
@@ -24,6 +27,36 @@ MINIO_SECRET_KEY="${MINIO_SECRET_KEY:-minioadmin}"
 MINIO_BUCKET="${MINIO_BUCKET:-stream-demo-videos}"
 MINIO_PROCESSED_BUCKET="${MINIO_PROCESSED_BUCKET:-stream-demo-processed}"
 
+# 封裝格式：ts 輸出 MPEG-TS 分段的 HLS；cmaf 輸出 fMP4 分段，並以同一組分段產生 HLS 與 DASH
+PACKAGING="${PACKAGING:-ts}"
+if [ "$PACKAGING" != "cmaf" ]; then
+    PACKAGING="ts"
+fi
+HLS_SEGMENT_DURATION=10
+
+# HLS AES-128 加密：每 HLS_KEY_ROTATION_SEGMENTS 個分段輪換一把金鑰（0 表示整部影片使用同一把）
+# 金鑰寫入 HLS_KEY_FILE（每行 index:hex）由 converter 加密保存，播放列表以 HLS_KEY_URI?k=<index> 取得金鑰
+HLS_ENCRYPTION="${HLS_ENCRYPTION:-false}"
+HLS_KEY_ROTATION_SEGMENTS="${HLS_KEY_ROTATION_SEGMENTS:-30}"
+HLS_KEY_URI="${HLS_KEY_URI:-}"
+HLS_KEY_FILE="${HLS_KEY_FILE:-}"
+if [ "$HLS_ENCRYPTION" = "true" ] && { [ -z "$HLS_KEY_URI" ] || [ -z "$HLS_KEY_FILE" ]; }; then
+    echo "❌ 加密需要設定 HLS_KEY_URI 與 HLS_KEY_FILE"
+    exit 1
+fi
+
+# hls_args 產生指定畫質目錄的 HLS 輸出參數
+hls_args() {
+    local dir="$1"
+    HLS_ARGS=(-f hls -hls_time "$HLS_SEGMENT_DURATION" -hls_list_size 0)
+    if [ "$PACKAGING" = "cmaf" ]; then
+        HLS_ARGS+=(-hls_segment_type fmp4 -hls_fmp4_init_filename init.mp4 -hls_segment_filename "$dir/segment_%03d.m4s")
+    else
+        HLS_ARGS+=(-hls_segment_filename "$dir/segment_%03d.ts")
+    fi
+    HLS_ARGS+=("$dir/index.m3u8")
+}
+
 # 工作目錄
 WORK_DIR="/tmp/transcoding/${VIDEO_ID}"
 mkdir -p "$WORK_DIR"
@@ -32,10 +65,13 @@ mkdir -p "$WORK_DIR"
 echo "🔧 配置 MinIO Client..."
 mc alias set s3 "$MINIO_ENDPOINT" "$MINIO_ACCESS_KEY" "$MINIO_SECRET_KEY"
 
-# 下載原始文件
-echo "📥 下載原始影片: $INPUT_KEY"
+# 下載原始文件（fingerprint.sh 已下載時直接沿用；先寫入暫存檔，避免中斷時留下不完整的輸入）
 INPUT_FILE="$WORK_DIR/input.$(echo $INPUT_KEY | rev | cut -d. -f1 | rev)"
-mc cp "s3/$MINIO_BUCKET/$INPUT_KEY" "$INPUT_FILE"
+if [ ! -f "$INPUT_FILE" ]; then
+    echo "📥 下載原始影片: $INPUT_KEY"
+    mc cp "s3/$MINIO_BUCKET/$INPUT_KEY" "$INPUT_FILE.part"
+    mv "$INPUT_FILE.part" "$INPUT_FILE"
+fi
 
 if [ ! -f "$INPUT_FILE" ]; then
     echo "❌ 下載失敗: $INPUT_FILE"
@@ -55,6 +91,139 @@ HEIGHT=$(ffprobe -v quiet -select_streams v:0 -show_entries stream=height -of cs
 
 echo "📏 影片資訊: ${WIDTH}x${HEIGHT}, 時長: ${DURATION}秒"
 
+# 套用創作者品牌：浮水印燒入正片，片頭/片尾統一為正片規格後串接，產生後續轉碼使用的中間檔
+BRANDING_WATERMARK_KEY="${BRANDING_WATERMARK_KEY:-}"
+BRANDING_WATERMARK_POSITION="${BRANDING_WATERMARK_POSITION:-bottom-right}"
+BRANDING_WATERMARK_OPACITY="${BRANDING_WATERMARK_OPACITY:-0.8}"
+BRANDING_WATERMARK_SCALE="${BRANDING_WATERMARK_SCALE:-0.15}"
+BRANDING_INTRO_KEY="${BRANDING_INTRO_KEY:-}"
+BRANDING_OUTRO_KEY="${BRANDING_OUTRO_KEY:-}"
+BRANDING_MARGIN=20
+declare -a BRANDING_APPLIED=()
+
+# download_branding 下載品牌素材（原始桶），失敗時回傳空字串並略過該項
+download_branding() {
+    local key="$1" dest="$2"
+    if [ -n "$key" ] && mc cp "s3/$MINIO_BUCKET/$key" "$dest" > /dev/null 2>&1; then
+        echo "$dest"
+    elif [ -n "$key" ]; then
+        echo "⚠️ 品牌素材下載失敗，略過: $key" >&2
+    fi
+}
+
+WATERMARK_FILE=$(download_branding "$BRANDING_WATERMARK_KEY" "$WORK_DIR/watermark.${BRANDING_WATERMARK_KEY##*.}")
+INTRO_FILE=$(download_branding "$BRANDING_INTRO_KEY" "$WORK_DIR/intro.${BRANDING_INTRO_KEY##*.}")
+OUTRO_FILE=$(download_branding "$BRANDING_OUTRO_KEY" "$WORK_DIR/outro.${BRANDING_OUTRO_KEY##*.}")
+
+# 串接只保留一條音軌，多音軌影片略過片頭/片尾以保留各語言音軌
+MAIN_AUDIO_COUNT=$(ffprobe -v quiet -select_streams a -show_entries stream=index -of csv="p=0" "$INPUT_FILE" | wc -l | tr -d ' ')
+if { [ -n "$INTRO_FILE" ] || [ -n "$OUTRO_FILE" ]; } && [ "$MAIN_AUDIO_COUNT" -gt 1 ]; then
+    echo "⚠️ 正片包含 ${MAIN_AUDIO_COUNT} 條音軌，略過片頭/片尾"
+    INTRO_FILE=""
+    OUTRO_FILE=""
+fi
+
+if [ -n "$WATERMARK_FILE" ] || [ -n "$INTRO_FILE" ] || [ -n "$OUTRO_FILE" ]; then
+    echo "🎨 套用品牌元素..."
+    FPS=$(ffprobe -v quiet -select_streams v:0 -show_entries stream=r_frame_rate -of csv="p=0" "$INPUT_FILE")
+    FPS="${FPS:-30}"
+    NORMALIZE="scale=${WIDTH}:${HEIGHT}:force_original_aspect_ratio=decrease,pad=${WIDTH}:${HEIGHT}:(ow-iw)/2:(oh-ih)/2,setsar=1,fps=${FPS},format=yuv420p"
+    AUDIO_FORMAT="aformat=sample_rates=48000:channel_layouts=stereo"
+
+    declare -a BRANDING_INPUTS=(-i "$INPUT_FILE")
+    FILTER="[0:v]setsar=1,fps=${FPS},format=yuv420p[main]"
+    input_index=1
+
+    if [ -n "$WATERMARK_FILE" ]; then
+        case "$BRANDING_WATERMARK_POSITION" in
+            top-left) OVERLAY_XY="x=${BRANDING_MARGIN}:y=${BRANDING_MARGIN}" ;;
+            top-right) OVERLAY_XY="x=W-w-${BRANDING_MARGIN}:y=${BRANDING_MARGIN}" ;;
+            bottom-left) OVERLAY_XY="x=${BRANDING_MARGIN}:y=H-h-${BRANDING_MARGIN}" ;;
+            center) OVERLAY_XY="x=(W-w)/2:y=(H-h)/2" ;;
+            *) OVERLAY_XY="x=W-w-${BRANDING_MARGIN}:y=H-h-${BRANDING_MARGIN}" ;;
+        esac
+        WATERMARK_WIDTH=$(awk -v w="$WIDTH" -v s="$BRANDING_WATERMARK_SCALE" 'BEGIN { v = int(w * s / 2) * 2; print (v < 2 ? 2 : v) }')
+
+        BRANDING_INPUTS+=(-i "$WATERMARK_FILE")
+        FILTER="${FILTER};[${input_index}:v]scale=${WATERMARK_WIDTH}:-1,format=rgba,colorchannelmixer=aa=${BRANDING_WATERMARK_OPACITY}[wm];[main][wm]overlay=${OVERLAY_XY}:format=auto,format=yuv420p[branded]"
+        input_index=$((input_index + 1))
+        BRANDING_APPLIED+=("watermark")
+    else
+        FILTER="${FILTER};[main]null[branded]"
+    fi
+
+    declare -a BRANDING_MAP=()
+    if [ -z "$INTRO_FILE" ] && [ -z "$OUTRO_FILE" ]; then
+        # 僅浮水印：保留所有原始音軌
+        BRANDING_MAP=(-map "[branded]" -map "0:a?" -c:a copy)
+    else
+        # 依序串接片頭、正片、片尾，沒有音訊的片段補上等長靜音
+        declare -a SEGMENTS=()
+        [ -n "$INTRO_FILE" ] && SEGMENTS+=("$INTRO_FILE")
+        SEGMENTS+=("$INPUT_FILE")
+        [ -n "$OUTRO_FILE" ] && SEGMENTS+=("$OUTRO_FILE")
+
+        HAS_AUDIO=0
+        for segment in "${SEGMENTS[@]}"; do
+            if [ -n "$(ffprobe -v quiet -select_streams a -show_entries stream=index -of csv="p=0" "$segment")" ]; then
+                HAS_AUDIO=1
+            fi
+        done
+
+        CONCAT_INPUTS=""
+        for i in "${!SEGMENTS[@]}"; do
+            segment="${SEGMENTS[$i]}"
+            if [ "$segment" = "$INPUT_FILE" ]; then
+                CONCAT_INPUTS="${CONCAT_INPUTS}[branded]"
+                audio_input=0
+            else
+                BRANDING_INPUTS+=(-i "$segment")
+                FILTER="${FILTER};[${input_index}:v]${NORMALIZE}[seg${i}v]"
+                CONCAT_INPUTS="${CONCAT_INPUTS}[seg${i}v]"
+                audio_input=$input_index
+                input_index=$((input_index + 1))
+            fi
+
+            if [ "$HAS_AUDIO" -eq 1 ]; then
+                if [ -n "$(ffprobe -v quiet -select_streams a -show_entries stream=index -of csv="p=0" "$segment")" ]; then
+                    FILTER="${FILTER};[${audio_input}:a:0]${AUDIO_FORMAT}[seg${i}a]"
+                else
+                    segment_duration=$(ffprobe -v quiet -show_entries format=duration -of csv="p=0" "$segment")
+                    BRANDING_INPUTS+=(-f lavfi -t "$segment_duration" -i "anullsrc=r=48000:cl=stereo")
+                    FILTER="${FILTER};[${input_index}:a]${AUDIO_FORMAT}[seg${i}a]"
+                    input_index=$((input_index + 1))
+                fi
+                CONCAT_INPUTS="${CONCAT_INPUTS}[seg${i}a]"
+            fi
+        done
+
+        FILTER="${FILTER};${CONCAT_INPUTS}concat=n=${#SEGMENTS[@]}:v=1:a=${HAS_AUDIO}[outv]"
+        BRANDING_MAP=(-map "[outv]")
+        if [ "$HAS_AUDIO" -eq 1 ]; then
+            FILTER="${FILTER%\[outv\]}[outv][outa]"
+            MAIN_LANGUAGE=$(ffprobe -v quiet -select_streams a:0 -show_entries stream_tags=language -of csv="p=0" "$INPUT_FILE")
+            BRANDING_MAP+=(-map "[outa]" -c:a aac -b:a 192k -metadata:s:a:0 "language=${MAIN_LANGUAGE:-und}")
+        fi
+
+        [ -n "$INTRO_FILE" ] && BRANDING_APPLIED+=("intro")
+        [ -n "$OUTRO_FILE" ] && BRANDING_APPLIED+=("outro")
+    fi
+
+    BRANDED_FILE="$WORK_DIR/branded.mkv"
+    if ffmpeg "${BRANDING_INPUTS[@]}" \
+        -filter_complex "$FILTER" \
+        "${BRANDING_MAP[@]}" \
+        -c:v libx264 -preset veryfast -crf 18 \
+        "$BRANDED_FILE" -y; then
+        INPUT_FILE="$BRANDED_FILE"
+        DURATION=$(ffprobe -v quiet -show_entries format=duration -of csv="p=0" "$INPUT_FILE")
+        echo "✅ 品牌元素已套用: ${BRANDING_APPLIED[*]}, 時長: ${DURATION}秒"
+    else
+        echo "⚠️ 品牌元素套用失敗，使用原始影片"
+        BRANDING_APPLIED=()
+    fi
+fi
+
 # 創建輸出目錄
 HLS_DIR="$WORK_DIR/hls"
 MP4_DIR="$WORK_DIR/mp4"
@@ -62,34 +231,194 @@ THUMB_DIR="$WORK_DIR/thumbnails"
 
 mkdir -p "$HLS_DIR" "$MP4_DIR" "$THUMB_DIR"
 
-# 1. 生成 MP4 版本（網頁播放）
-echo "🎬 轉換為 MP4..."
-ffmpeg -i "$INPUT_FILE" \
-    -c:v libx264 -profile:v high -level 4.0 \
-    -c:a aac -ac 2 -b:a 128k \
-    -movflags +faststart \
-    -f mp4 \
-    "$MP4_DIR/video.mp4" \
-    -y
+# 1. 生成 MP4 版本（網頁播放）；加密影片只能以 AES-128 HLS 播放，不產生未加密的 MP4
+if [ "$HLS_ENCRYPTION" = "true" ]; then
+    echo "🔐 加密影片不產生 MP4"
+else
+    echo "🎬 轉換為 MP4..."
+    ffmpeg -i "$INPUT_FILE" \
+        -c:v libx264 -profile:v high -level 4.0 \
+        -c:a aac -ac 2 -b:a 128k \
+        -movflags +faststart \
+        -f mp4 \
+        "$MP4_DIR/video.mp4" \
+        -y
+fi
 
 # 2. 生成多品質 HLS 串流
 echo "📺 生成 HLS 串流..."
 
-# 根據原始尺寸決定品質
+# 根據原始尺寸決定品質：只保留不超過原始高度的畫質，至少保留最低畫質
+declare -a CEILINGS=()
+IFS=',' read -r -a LADDER_RUNGS <<< "$TRANSCODE_LADDER"
+while IFS= read -r rung; do
+    [ -n "$rung" ] && CEILINGS+=("$rung")
+done < <(printf '%s\n' "${LADDER_RUNGS[@]}" | sort -t: -k3,3n | awk -F: -v h="$HEIGHT" 'NF == 4 && ($3 <= h || NR == 1)')
+
+# Per-title 編碼：以固定 CRF 試編取樣片段，估算各畫質達到目標畫質所需位元率
+PER_TITLE_ENABLED="${PER_TITLE_ENABLED:-true}"
+PER_TITLE_CRF="${PER_TITLE_CRF:-23}"
+PER_TITLE_SAMPLES="${PER_TITLE_SAMPLES:-4}"
+PER_TITLE_SAMPLE_DURATION="${PER_TITLE_SAMPLE_DURATION:-4}"
+PER_TITLE_MIN_BITRATE="${PER_TITLE_MIN_BITRATE:-150}"
+PER_TITLE_MIN_STEP="${PER_TITLE_MIN_STEP:-1.5}"
+SAMPLE_DIR="$WORK_DIR/samples"
+
+# trial_bitrate 回傳指定寬度下 CRF 試編的平均位元率(kbps)
+trial_bitrate() {
+    local width="$1"
+    local total_bytes=0
+    local total_duration=0
+    local samples="$PER_TITLE_SAMPLES"
+    local sample_duration="$PER_TITLE_SAMPLE_DURATION"
+
+    # 影片過短時以整支影片作為單一樣本
+    if [ "$(echo "$DURATION < $samples * $sample_duration * 2" | bc -l)" -eq 1 ]; then
+        samples=1
+        sample_duration="$DURATION"
+    fi
+
+    mkdir -p "$SAMPLE_DIR"
+    for ((n = 1; n <= samples; n++)); do
+        local position
+        position=$(echo "$DURATION * $n / ($samples + 1) - $sample_duration / 2" | bc -l)
+        if [ "$samples" -eq 1 ] || [ "$(echo "$position < 0" | bc -l)" -eq 1 ]; then
+            position=0
+        fi
+
+        local sample="$SAMPLE_DIR/sample_${width}_${n}.mp4"
+        ffmpeg -v error -ss "$position" -t "$sample_duration" -i "$INPUT_FILE" \
+            -map 0:v:0 -an \
+            -vf "scale=$width:-2" \
+            -c:v libx264 -preset veryfast -crf "$PER_TITLE_CRF" \
+            -f mp4 "$sample" -y || continue
+
+        local bytes seconds
+        bytes=$(stat -f%z "$sample" 2>/dev/null || stat -c%s "$sample")
+        seconds=$(ffprobe -v quiet -show_entries format=duration -of csv="p=0" "$sample")
+        total_bytes=$((total_bytes + bytes))
+        total_duration=$(echo "$total_duration + ${seconds:-0}" | bc -l)
+    done
+
+    if [ "$(echo "$total_duration <= 0" | bc -l)" -eq 1 ]; then
+        echo 0
+        return
+    fi
+    echo "$total_bytes * 8 / $total_duration / 1000" | bc
+}
+
 declare -a QUALITIES=()
+declare -a LADDER_REPORT=()
 
-if [ "$HEIGHT" -ge 720 ]; then
-    QUALITIES+=("720p:1280:720:2500k")
-fi
-if [ "$HEIGHT" -ge 480 ]; then
-    QUALITIES+=("480p:854:480:1200k")
+if [ "$PER_TITLE_ENABLED" = "true" ]; then
+    echo "🔬 分析內容複雜度 (CRF ${PER_TITLE_CRF}, ${PER_TITLE_SAMPLES} 個取樣)..."
+
+    # 由低到高決定各畫質位元率，再由 ladder.sh 略過與上一個保留畫質位元率過於接近的畫質
+    declare -a CANDIDATES=()
+    for rung in "${CEILINGS[@]}"; do
+        IFS=':' read -r name width height ceiling <<< "$rung"
+        ceiling_num=$(echo "$ceiling" | sed 's/k//')
+
+        needed=$(trial_bitrate "$width")
+        chosen="$needed"
+        if [ "$chosen" -le 0 ] || [ "$chosen" -gt "$ceiling_num" ]; then
+            chosen="$ceiling_num"
+        fi
+        if [ "$chosen" -lt "$PER_TITLE_MIN_BITRATE" ]; then
+            chosen="$PER_TITLE_MIN_BITRATE"
+        fi
+
+        echo "📐 $name: 試編 ${needed}k, 上限 ${ceiling}, 採用 ${chosen}k"
+        LADDER_REPORT+=("$name:${needed}k:${chosen}k")
+        CANDIDATES+=("$name:$width:$height:${chosen}k")
+    done
+
+    mapfile -t QUALITIES < <("$(dirname "$0")/ladder.sh" "$PER_TITLE_MIN_STEP" "${CANDIDATES[@]}")
+    if [ "${#QUALITIES[@]}" -eq 0 ]; then
+        echo "❌ 篩選畫質階梯失敗"
+        exit 1
+    fi
+
+    rm -rf "$SAMPLE_DIR"
+else
+    QUALITIES=("${CEILINGS[@]}")
 fi
-QUALITIES+=("360p:640:360:800k")
+
+# 由高到低輸出，與主播放列表順序一致
+mapfile -t QUALITIES < <(printf '%s\n' "${QUALITIES[@]}" | sort -t: -k3,3nr)
+
+echo "🎚️ 畫質階梯: ${QUALITIES[*]}"
+
+# 偵測所有音軌（語言、聲道數、標題），每條音軌輸出為獨立的 HLS 音訊群組
+AUDIO_BITRATE="${AUDIO_BITRATE:-128k}"
+AUDIO_ONLY_BITRATE="${AUDIO_ONLY_BITRATE:-64k}"
+declare -a AUDIO_TRACKS=()
+while IFS= read -r line; do
+    [ -z "$line" ] && continue
+    AUDIO_TRACKS+=("$line")
+done < <(ffprobe -v quiet -select_streams a \
+    -show_entries stream=channels:stream_tags=language,title \
+    -of compact=p=0:nk=0 "$INPUT_FILE" \
+    | awk -F'|' '{
+        channels = 2; lang = "und"; title = ""
+        for (i = 1; i <= NF; i++) {
+            split($i, kv, "=")
+            if (kv[1] == "channels") channels = kv[2]
+            else if (kv[1] == "tag:language" && kv[2] != "") lang = kv[2]
+            else if (kv[1] == "tag:title") title = substr($i, length(kv[1]) + 2)
+        }
+        gsub(/["|]/, "", title)
+        print channels "|" lang "|" title
+    }')
+
+echo "🔊 偵測到 ${#AUDIO_TRACKS[@]} 條音軌"
 
 # 生成 HLS 主播放列表
 MASTER_PLAYLIST="$HLS_DIR/index.m3u8"
 echo "#EXTM3U" > "$MASTER_PLAYLIST"
-echo "#EXT-X-VERSION:3" >> "$MASTER_PLAYLIST"
+# fMP4 分段需要 EXT-X-MAP，播放列表版本至少為 7
+if [ "$PACKAGING" = "cmaf" ]; then
+    echo "#EXT-X-VERSION:7" >> "$MASTER_PLAYLIST"
+else
+    echo "#EXT-X-VERSION:3" >> "$MASTER_PLAYLIST"
+fi
+
+audio_bitrate_num=$(echo "$AUDIO_BITRATE" | sed 's/k//')
+AUDIO_GROUP_ATTR=""
+declare -a AUDIO_LABELS=()
+declare -a AUDIO_OUT_CHANNELS=()
+if [ "${#AUDIO_TRACKS[@]}" -gt 0 ]; then
+    AUDIO_GROUP_ATTR=",AUDIO=\"audio\""
+fi
+
+for i in "${!AUDIO_TRACKS[@]}"; do
+    IFS='|' read -r channels lang title <<< "${AUDIO_TRACKS[$i]}"
+    name="audio_${i}"
+    out_channels=$(( channels > 2 ? 2 : channels ))
+    label="${title:-$lang}"
+    [ "$label" = "und" ] && label="音軌 $((i + 1))"
+    default="NO"
+    [ "$i" -eq 0 ] && default="YES"
+
+    AUDIO_LABELS[$i]="$label"
+    AUDIO_OUT_CHANNELS[$i]="$out_channels"
+
+    echo "🎧 生成音軌 $name (${lang}, ${channels}ch)..."
+    mkdir -p "$HLS_DIR/$name"
+    hls_args "$HLS_DIR/$name"
+
+    # 多聲道統一降混為立體聲 AAC，確保各平台播放相容
+    if ! ffmpeg -i "$INPUT_FILE" \
+        -map "0:a:$i" -vn \
+        -c:a aac -b:a "$AUDIO_BITRATE" -ac "$out_channels" \
+        "${HLS_ARGS[@]}" \
+        -y; then
+        echo "❌ 音軌 $name 轉碼失敗"
+        exit 1
+    fi
+
+    echo "#EXT-X-MEDIA:TYPE=AUDIO,GROUP-ID=\"audio\",NAME=\"$label\",LANGUAGE=\"$lang\",DEFAULT=$default,AUTOSELECT=YES,CHANNELS=\"$out_channels\",URI=\"$name/index.m3u8\"" >> "$MASTER_PLAYLIST"
+done
 
 for quality in "${QUALITIES[@]}"; do
     IFS=':' read -r name width height bitrate <<< "$quality"
@@ -104,42 +433,25 @@ for quality in "${QUALITIES[@]}"; do
     bufsize=$((bitrate_num * 2))k
     bandwidth=$((bitrate_num * 1000))
     
-    # 檢查是否有音頻軌道
-    has_audio=$(ffprobe -v quiet -select_streams a:0 -show_entries stream=codec_type -of csv=p=0 "$INPUT_FILE" 2>/dev/null || echo "")
-    
-    # FFmpeg 轉碼 - 根據是否有音頻調整參數
-    if [ -n "$has_audio" ]; then
-        # 有音頻軌道
-        if ! ffmpeg -i "$INPUT_FILE" \
-            -c:v libx264 -preset medium -profile:v high \
-            -vf "scale=$width:-1" \
-            -b:v "$bitrate" -maxrate "$bitrate" -bufsize "$bufsize" \
-            -c:a aac -b:a 128k -ac 2 \
-            -f hls \
-            -hls_time 10 \
-            -hls_list_size 0 \
-            -hls_segment_filename "$HLS_DIR/$name/segment_%03d.ts" \
-            "$HLS_DIR/$name/index.m3u8" \
-            -y; then
-            echo "❌ $name 品質轉碼失敗"
-            exit 1
-        fi
-    else
-        # 沒有音頻軌道
-        if ! ffmpeg -i "$INPUT_FILE" \
-            -c:v libx264 -preset medium -profile:v high \
-            -vf "scale=$width:-1" \
-            -b:v "$bitrate" -maxrate "$bitrate" -bufsize "$bufsize" \
-            -an \
-            -f hls \
-            -hls_time 10 \
-            -hls_list_size 0 \
-            -hls_segment_filename "$HLS_DIR/$name/segment_%03d.ts" \
-            "$HLS_DIR/$name/index.m3u8" \
-            -y; then
-            echo "❌ $name 品質轉碼失敗"
-            exit 1
-        fi
+    # CMAF 需各畫質關鍵幀對齊，DASH 播放器才能在分段邊界切換畫質
+    declare -a KEYFRAME_ARGS=()
+    if [ "$PACKAGING" = "cmaf" ]; then
+        KEYFRAME_ARGS=(-force_key_frames "expr:gte(t,n_forced*${HLS_SEGMENT_DURATION})" -sc_threshold 0)
+    fi
+    hls_args "$HLS_DIR/$name"
+
+    # 影像畫質僅包含影像，音訊由音訊群組提供
+    if ! ffmpeg -i "$INPUT_FILE" \
+        -map 0:v:0 \
+        -c:v libx264 -preset medium -profile:v high \
+        -vf "scale=$width:-1" \
+        -b:v "$bitrate" -maxrate "$bitrate" -bufsize "$bufsize" \
+        "${KEYFRAME_ARGS[@]}" \
+        -an \
+        "${HLS_ARGS[@]}" \
+        -y; then
+        echo "❌ $name 品質轉碼失敗"
+        exit 1
     fi
     
     # 檢查生成的檔案是否存在
@@ -148,11 +460,37 @@ for quality in "${QUALITIES[@]}"; do
         exit 1
     fi
     
+    # 頻寬需包含音訊群組的位元率
+    if [ -n "$AUDIO_GROUP_ATTR" ]; then
+        bandwidth=$((bandwidth + audio_bitrate_num * 1000))
+    fi
+
     # 添加到主播放列表
-    echo "#EXT-X-STREAM-INF:BANDWIDTH=$bandwidth,RESOLUTION=${width}x${height}" >> "$MASTER_PLAYLIST"
+    echo "#EXT-X-STREAM-INF:BANDWIDTH=$bandwidth,RESOLUTION=${width}x${height}${AUDIO_GROUP_ATTR}" >> "$MASTER_PLAYLIST"
     echo "$name/index.m3u8" >> "$MASTER_PLAYLIST"
 done
 
+# 純音訊畫質（低位元率，供 Podcast 式收聽）
+AUDIO_ONLY=""
+if [ "${#AUDIO_TRACKS[@]}" -gt 0 ]; then
+    echo "🎙️ 生成純音訊畫質..."
+    mkdir -p "$HLS_DIR/audio_only"
+    hls_args "$HLS_DIR/audio_only"
+    if ! ffmpeg -i "$INPUT_FILE" \
+        -map 0:a:0 -vn \
+        -c:a aac -b:a "$AUDIO_ONLY_BITRATE" -ac 2 \
+        "${HLS_ARGS[@]}" \
+        -y; then
+        echo "❌ 純音訊畫質轉碼失敗"
+        exit 1
+    fi
+
+    audio_only_num=$(echo "$AUDIO_ONLY_BITRATE" | sed 's/k//')
+    echo "#EXT-X-STREAM-INF:BANDWIDTH=$((audio_only_num * 1000)),CODECS=\"mp4a.40.2\"" >> "$MASTER_PLAYLIST"
+    echo "audio_only/index.m3u8" >> "$MASTER_PLAYLIST"
+    AUDIO_ONLY="$audio_only_num"
+fi
+
 # 檢查主播放列表是否生成
 if [ ! -f "$MASTER_PLAYLIST" ]; then
     echo "❌ HLS 主播放列表未生成"
@@ -161,6 +499,156 @@ fi
 
 echo "✅ HLS 串流生成完成"
 
+# 加密 HLS 分段：未指定 IV 時播放器以分段序號作為 IV，金鑰序號變更時插入新的 EXT-X-KEY
+if [ "$HLS_ENCRYPTION" = "true" ]; then
+    echo "🔐 加密 HLS 分段 (每 ${HLS_KEY_ROTATION_SEGMENTS} 個分段輪換金鑰)..."
+    declare -a HLS_KEYS=()
+
+    # 分段加密後無法再解析時間戳，先記錄字幕對齊所需的 MPEGTS
+    first_variant="${QUALITIES[0]%%:*}"
+    first_init=$(grep '^#EXT-X-MAP' "$HLS_DIR/$first_variant/index.m3u8" | sed 's/.*URI="\([^"]*\)".*/\1/')
+    first_segment=$(grep -v '^#' "$HLS_DIR/$first_variant/index.m3u8" | grep -v '^[[:space:]]*$' | head -n 1)
+    cat ${first_init:+"$HLS_DIR/$first_variant/$first_init"} "$HLS_DIR/$first_variant/$first_segment" > "$WORK_DIR/first_segment"
+    START_TIME=$(ffprobe -v quiet -show_entries format=start_time -of csv="p=0" "$WORK_DIR/first_segment" || echo "0")
+    awk -v t="${START_TIME:-0}" 'BEGIN { printf "%d\n", t * 90000 + 0.5 }' > "$HLS_DIR/timestamp_map"
+    rm -f "$WORK_DIR/first_segment"
+
+    encrypt_rendition() {
+        local dir="$1" playlist="$1/index.m3u8" output="$1/index.m3u8.tmp"
+        local seq current=-1 key_index line
+        seq=$(grep '^#EXT-X-MEDIA-SEQUENCE' "$playlist" | cut -d: -f2 | tr -d '\r')
+        seq="${seq:-0}"
+
+        : > "$output"
+        while IFS= read -r line; do
+            line="${line%$'\r'}"
+            case "$line" in
+            "#EXTINF"*)
+                key_index=0
+                if [ "$HLS_KEY_ROTATION_SEGMENTS" -gt 0 ]; then
+                    key_index=$((seq / HLS_KEY_ROTATION_SEGMENTS))
+                fi
+                if [ "$key_index" -ne "$current" ]; then
+                    if [ -z "${HLS_KEYS[$key_index]}" ]; then
+                        HLS_KEYS[$key_index]=$(openssl rand -hex 16)
+                    fi
+                    echo "#EXT-X-KEY:METHOD=AES-128,URI=\"${HLS_KEY_URI}?k=${key_index}\"" >> "$output"
+                    current="$key_index"
+                fi
+                echo "$line" >> "$output"
+                ;;
+            "#"* | "")
+                echo "$line" >> "$output"
+                ;;
+            *)
+                openssl aes-128-cbc -K "${HLS_KEYS[$current]}" -iv "$(printf '%032x' "$seq")" \
+                    -in "$dir/$line" -out "$dir/$line.enc"
+                mv "$dir/$line.enc" "$dir/$line"
+                echo "$line" >> "$output"
+                seq=$((seq + 1))
+                ;;
+            esac
+        done < "$playlist"
+        mv "$output" "$playlist"
+    }
+
+    for rendition_dir in "$HLS_DIR"/*/; do
+        if [ -f "$rendition_dir/index.m3u8" ]; then
+            encrypt_rendition "${rendition_dir%/}"
+        fi
+    done
+
+    # 金鑰僅寫入 converter 指定的檔案，不輸出到標準輸出
+    (umask 077 && : > "$HLS_KEY_FILE")
+    for key_index in "${!HLS_KEYS[@]}"; do
+        echo "${key_index}:${HLS_KEYS[$key_index]}" >> "$HLS_KEY_FILE"
+    done
+
+    echo "✅ 已加密 HLS 分段，共 ${#HLS_KEYS[@]} 把金鑰"
+fi
+
+# CMAF 封裝時以相同的 fMP4 分段產生 DASH MPD（純音訊畫質僅供 HLS 使用）
+DASH_MANIFEST=""
+if [ "$PACKAGING" = "cmaf" ] && [ "$HLS_ENCRYPTION" = "true" ]; then
+    echo "⚠️ DASH 不支援 HLS AES-128 整段加密，略過 MPD"
+elif [ "$PACKAGING" = "cmaf" ]; then
+    echo "📺 生成 DASH MPD..."
+
+    # segment_timeline 將 HLS 播放列表的 EXTINF 轉為 SegmentTimeline（毫秒）
+    segment_timeline() {
+        awk -F'[:,]' '
+        function out() { printf "          <S %sd=\"%d\"%s/>\n", (n == 1 ? "t=\"0\" " : ""), last, (r > 0 ? sprintf(" r=\"%d\"", r) : "") }
+        /^#EXTINF/ {
+            d = int($2 * 1000 + 0.5)
+            if (started && d == last) { r++; next }
+            if (started) out()
+            last = d; r = 0; n++; started = 1
+        }
+        END { if (started) out() }' "$1"
+    }
+
+    # avc_codec 由初始化分段讀取 profile 與 level，產生 RFC 6381 codecs 字串
+    avc_codec() {
+        local profile level idc
+        profile=$(ffprobe -v quiet -select_streams v:0 -show_entries stream=profile -of csv="p=0" "$1")
+        level=$(ffprobe -v quiet -select_streams v:0 -show_entries stream=level -of csv="p=0" "$1")
+        case "$profile" in
+            Baseline|"Constrained Baseline") idc=66 ;;
+            Main) idc=77 ;;
+            *) idc=100 ;;
+        esac
+        printf "avc1.%02X00%02X" "$idc" "${level:-40}"
+    }
+
+    xml_escape() {
+        echo "$1" | sed -e 's/&/\&amp;/g' -e 's/</\&lt;/g' -e 's/>/\&gt;/g'
+    }
+
+    DASH_MANIFEST="$HLS_DIR/manifest.mpd"
+    {
+        echo '<?xml version="1.0" encoding="UTF-8"?>'
+        printf '<MPD xmlns="urn:mpeg:dash:schema:mpd:2011" profiles="urn:mpeg:dash:profile:isoff-live:2011" type="static" mediaPresentationDuration="PT%.3fS" minBufferTime="PT%dS">\n' "$DURATION" "$HLS_SEGMENT_DURATION"
+        echo '  <Period id="0" start="PT0S">'
+        echo '    <AdaptationSet id="0" contentType="video" mimeType="video/mp4" segmentAlignment="true" startWithSAP="1">'
+        for quality in "${QUALITIES[@]}"; do
+            IFS=':' read -r name width height bitrate <<< "$quality"
+            printf '      <Representation id="%s" codecs="%s" bandwidth="%d" width="%d" height="%d">\n' \
+                "$name" "$(avc_codec "$HLS_DIR/$name/init.mp4")" "$(( $(echo "$bitrate" | sed 's/k//') * 1000 ))" "$width" "$height"
+            printf '        <SegmentTemplate timescale="1000" initialization="%s/init.mp4" media="%s/segment_$Number%%03d$.m4s" startNumber="0">\n' "$name" "$name"
+            echo '          <SegmentTimeline>'
+            segment_timeline "$HLS_DIR/$name/index.m3u8"
+            echo '          </SegmentTimeline>'
+            echo '        </SegmentTemplate>'
+            echo '      </Representation>'
+        done
+        echo '    </AdaptationSet>'
+
+        for i in "${!AUDIO_TRACKS[@]}"; do
+            IFS='|' read -r channels lang title <<< "${AUDIO_TRACKS[$i]}"
+            name="audio_${i}"
+            role="alternate"
+            [ "$i" -eq 0 ] && role="main"
+            printf '    <AdaptationSet id="%d" contentType="audio" mimeType="audio/mp4" lang="%s" segmentAlignment="true">\n' "$((i + 1))" "$lang"
+            printf '      <Role schemeIdUri="urn:mpeg:dash:role:2011" value="%s"/>\n' "$role"
+            printf '      <Label>%s</Label>\n' "$(xml_escape "${AUDIO_LABELS[$i]}")"
+            printf '      <Representation id="%s" codecs="mp4a.40.2" bandwidth="%d">\n' "$name" "$((audio_bitrate_num * 1000))"
+            printf '        <AudioChannelConfiguration schemeIdUri="urn:mpeg:dash:23003:3:audio_channel_configuration:2011" value="%s"/>\n' "${AUDIO_OUT_CHANNELS[$i]}"
+            printf '        <SegmentTemplate timescale="1000" initialization="%s/init.mp4" media="%s/segment_$Number%%03d$.m4s" startNumber="0">\n' "$name" "$name"
+            echo '          <SegmentTimeline>'
+            segment_timeline "$HLS_DIR/$name/index.m3u8"
+            echo '          </SegmentTimeline>'
+            echo '        </SegmentTemplate>'
+            echo '      </Representation>'
+            echo '    </AdaptationSet>'
+        done
+
+        echo '  </Period>'
+        echo '</MPD>'
+    } > "$DASH_MANIFEST"
+
+    echo "✅ DASH MPD 生成完成"
+fi
+
 # 3. 生成縮圖
 echo "🖼️ 生成縮圖..."
 
@@ -172,13 +660,49 @@ ffmpeg -i "$INPUT_FILE" -ss "$THUMB_TIME" -vframes 1 -s 320x240 "$THUMB_DIR/thum
 ffmpeg -i "$INPUT_FILE" -ss "$THUMB_TIME" -vframes 1 -s 640x480 "$THUMB_DIR/thumb_640x480.jpg" -y
 ffmpeg -i "$INPUT_FILE" -ss "$THUMB_TIME" -vframes 1 -s 1280x720 "$THUMB_DIR/thumb_1280x720.jpg" -y
 
-# 生成時間軸縮圖（每 10 秒一張）
-INTERVAL=10
-COUNT=0
-for ((i=0; i<$(echo "$DURATION" | cut -d. -f1); i+=INTERVAL)); do
-    ffmpeg -i "$INPUT_FILE" -ss "$i" -vframes 1 -s 320x240 "$THUMB_DIR/timeline_${COUNT}.jpg" -y 2>/dev/null || true
-    COUNT=$((COUNT + 1))
-done
+# 生成拖曳預覽雪碧圖與 WebVTT 縮圖軌
+# 每 PREVIEW_INTERVAL 秒取一張縮圖，依 PREVIEW_COLUMNS x PREVIEW_ROWS 拼成一張雪碧圖
+PREVIEW_INTERVAL="${PREVIEW_INTERVAL:-5}"
+PREVIEW_WIDTH="${PREVIEW_WIDTH:-160}"
+PREVIEW_HEIGHT="${PREVIEW_HEIGHT:-90}"
+PREVIEW_COLUMNS="${PREVIEW_COLUMNS:-10}"
+PREVIEW_ROWS="${PREVIEW_ROWS:-10}"
+PREVIEW_DIR="$THUMB_DIR/preview"
+mkdir -p "$PREVIEW_DIR"
+
+echo "🖼️ 生成拖曳預覽雪碧圖 (每 ${PREVIEW_INTERVAL} 秒)..."
+if ! ffmpeg -i "$INPUT_FILE" \
+    -vf "fps=1/${PREVIEW_INTERVAL},scale=${PREVIEW_WIDTH}:${PREVIEW_HEIGHT}:force_original_aspect_ratio=decrease,pad=${PREVIEW_WIDTH}:${PREVIEW_HEIGHT}:(ow-iw)/2:(oh-ih)/2,tile=${PREVIEW_COLUMNS}x${PREVIEW_ROWS}" \
+    -q:v 5 -start_number 1 \
+    "$PREVIEW_DIR/sprite_%03d.jpg" -y; then
+    echo "⚠️ 拖曳預覽生成失敗，略過"
+    rm -f "$PREVIEW_DIR"/sprite_*.jpg
+fi
+
+PREVIEW_SPRITE_COUNT=$(ls "$PREVIEW_DIR"/sprite_*.jpg 2>/dev/null | wc -l | tr -d ' ')
+
+# 依縮圖序號計算所在雪碧圖與座標，寫入 WebVTT
+PREVIEW_VTT="$PREVIEW_DIR/thumbnails.vtt"
+awk -v duration="$DURATION" -v interval="$PREVIEW_INTERVAL" \
+    -v w="$PREVIEW_WIDTH" -v h="$PREVIEW_HEIGHT" \
+    -v cols="$PREVIEW_COLUMNS" -v rows="$PREVIEW_ROWS" -v sheets="$PREVIEW_SPRITE_COUNT" '
+function ts(t,   hh, mm, ss) {
+    hh = int(t / 3600); mm = int((t % 3600) / 60); ss = t - hh * 3600 - mm * 60
+    return sprintf("%02d:%02d:%06.3f", hh, mm, ss)
+}
+BEGIN {
+    print "WEBVTT"
+    print ""
+    per = cols * rows
+    for (i = 0; i * interval < duration && int(i / per) < sheets; i++) {
+        start = i * interval
+        end = start + interval
+        if (end > duration) end = duration
+        pos = i % per
+        printf "%s --> %s\n", ts(start), ts(end)
+        printf "sprite_%03d.jpg#xywh=%d,%d,%d,%d\n\n", int(i / per) + 1, (pos % cols) * w, int(pos / cols) * h, w, h
+    }
+}' > "$PREVIEW_VTT"
 
 echo "✅ 縮圖生成完成"
 
@@ -187,8 +711,10 @@ echo "📤 上傳處理後的文件到處理後桶..."
 echo "🔧 使用處理後桶: $MINIO_PROCESSED_BUCKET"
 echo "🔧 輸出前綴: $OUTPUT_PREFIX"
 
-# 檢查並上傳 MP4
-if [ -f "$MP4_DIR/video.mp4" ]; then
+# 檢查並上傳 MP4；加密影片移除先前未加密轉碼留下的 MP4
+if [ "$HLS_ENCRYPTION" = "true" ]; then
+    mc rm "s3/$MINIO_PROCESSED_BUCKET/${OUTPUT_PREFIX}/video.mp4" >/dev/null 2>&1 || true
+elif [ -f "$MP4_DIR/video.mp4" ]; then
     echo "📤 上傳 MP4..."
     if ! mc cp "$MP4_DIR/video.mp4" "s3/$MINIO_PROCESSED_BUCKET/${OUTPUT_PREFIX}/video.mp4"; then
         echo "❌ MP4 上傳失敗"
@@ -237,11 +763,22 @@ cat > "$REPORT_FILE" << EOF
     "file_size": $(stat -f%z "$INPUT_FILE" 2>/dev/null || stat -c%s "$INPUT_FILE")
   },
   "outputs": {
-    "mp4": "${OUTPUT_PREFIX}/video.mp4",
+    "mp4": "$([ "$HLS_ENCRYPTION" != "true" ] && echo "${OUTPUT_PREFIX}/video.mp4")",
     "hls_master": "${OUTPUT_PREFIX}/hls/index.m3u8",
-    "thumbnail": "${OUTPUT_PREFIX}/thumbnails/thumb_640x480.jpg"
+    "dash_manifest": "$([ -n "$DASH_MANIFEST" ] && echo "${OUTPUT_PREFIX}/hls/manifest.mpd")",
+    "thumbnail": "${OUTPUT_PREFIX}/thumbnails/thumb_640x480.jpg",
+    "preview_vtt": "${OUTPUT_PREFIX}/thumbnails/preview/thumbnails.vtt"
+  },
+  "preview": {
+    "interval": $PREVIEW_INTERVAL,
+    "sprite_count": $PREVIEW_SPRITE_COUNT
   },
+  "packaging": "$PACKAGING",
+  "branding": [$(if [ "${#BRANDING_APPLIED[@]}" -gt 0 ]; then printf '"%s",' "${BRANDING_APPLIED[@]}" | sed 's/,$//'; fi)],
+  "encrypted": $([ "$HLS_ENCRYPTION" = "true" ] && echo true || echo false),
+  "audio_tracks": ${#AUDIO_TRACKS[@]},
   "qualities": [$(printf '"%s",' "${QUALITIES[@]}" | sed 's/,$//')],
+  "per_title": [$(if [ "${#LADDER_REPORT[@]}" -gt 0 ]; then printf '"%s",' "${LADDER_REPORT[@]}" | sed 's/,$//'; fi)],
   "completed_at": "$(date -u +%Y-%m-%dT%H:%M:%SZ)"
 }
 EOF
@@ -255,5 +792,28 @@ rm -rf "$WORK_DIR"
 
 echo "🎉 轉碼完成！"
 echo "📺 HLS 主播放列表: ${OUTPUT_PREFIX}/hls/index.m3u8"
-echo "🎬 MP4 影片: ${OUTPUT_PREFIX}/video.mp4"
-echo "🖼️ 縮圖: ${OUTPUT_PREFIX}/thumbnails/thumb_640x480.jpg" 
\ No newline at end of file
+if [ -n "$DASH_MANIFEST" ]; then
+    echo "📺 DASH MPD: ${OUTPUT_PREFIX}/hls/manifest.mpd"
+fi
+if [ "$HLS_ENCRYPTION" != "true" ]; then
+    echo "🎬 MP4 影片: ${OUTPUT_PREFIX}/video.mp4"
+fi
+echo "🖼️ 縮圖: ${OUTPUT_PREFIX}/thumbnails/thumb_640x480.jpg"
+echo "🎞️ 拖曳預覽: ${OUTPUT_PREFIX}/thumbnails/preview/thumbnails.vtt"
+# 以下輸出供 converter 解析
+echo "PACKAGING=$PACKAGING"
+echo "BRANDING_APPLIED=$(IFS=,; echo "${BRANDING_APPLIED[*]}")"
+if [ -n "$DASH_MANIFEST" ]; then
+    echo "DASH_MANIFEST=hls/manifest.mpd"
+fi
+echo "PREVIEW_INTERVAL=$PREVIEW_INTERVAL"
+echo "PREVIEW_SPRITE_COUNT=$PREVIEW_SPRITE_COUNT"
+for quality in "${QUALITIES[@]}"; do
+    echo "QUALITY=$quality"
+done
+for i in "${!AUDIO_TRACKS[@]}"; do
+    echo "AUDIO_TRACK=audio_${i}|${AUDIO_TRACKS[$i]}|${AUDIO_BITRATE}"
+done
+if [ -n "$AUDIO_ONLY" ]; then
+    echo "AUDIO_ONLY=audio_only|${AUDIO_ONLY}k"
+fi 
\ No newline at end of file
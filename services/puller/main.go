@@ -41,7 +41,8 @@ type StreamPuller struct {
 	mu            sync.RWMutex
 	db            *gorm.DB
 	configService *services.PublicStreamConfigService
-	maxConcurrent int // 最大同時轉檔數
+	maxConcurrent int    // 最大同時轉檔數
+	packaging     string // ts: MPEG-TS HLS, cmaf: fMP4 分段同時輸出 HLS 與 DASH
 }
 
 // 簡單的日誌函數
@@ -87,6 +88,37 @@ func NewStreamPuller(outputDir string, httpPort int, db *gorm.DB) *StreamPuller
 		db:            db,
 		configService: configService,
 		maxConcurrent: 2, // 限制最多同時轉檔 2 個流
+		packaging:     strings.ToLower(getEnv("PACKAGING", "ts")),
+	}
+}
+
+// outputArgs 根據封裝格式產生 FFmpeg 輸出參數
+// cmaf 時以 DASH 封裝器輸出 fMP4 分段，並由同一組分段產生 index.m3u8 主播放列表
+func (sp *StreamPuller) outputArgs(outputPath string) []string {
+	if sp.packaging == "cmaf" {
+		return []string{
+			"-f", "dash",
+			"-seg_duration", "2",
+			"-window_size", "5",
+			"-extra_window_size", "5",
+			"-remove_at_exit", "1",
+			"-use_template", "1",
+			"-use_timeline", "1",
+			"-init_seg_name", "init_$RepresentationID$.m4s",
+			"-media_seg_name", "chunk_$RepresentationID$_$Number%05d$.m4s",
+			"-hls_playlist", "1",
+			"-hls_master_name", "index.m3u8",
+			fmt.Sprintf("%s/index.mpd", outputPath),
+		}
+	}
+
+	return []string{
+		"-f", "hls",
+		"-hls_time", "2",
+		"-hls_list_size", "5",
+		"-hls_flags", "delete_segments",
+		"-hls_segment_filename", fmt.Sprintf("%s/%%03d.ts", outputPath),
+		fmt.Sprintf("%s/index.m3u8", outputPath),
 	}
 }
 
@@ -236,34 +268,13 @@ func (sp *StreamPuller) startExternalStream(name string, streamProcess *StreamPr
 	switch strings.ToLower(config.Type) {
 	case "hls":
 		cmd = exec.Command("ffmpeg",
-			"-i", config.URL,
-			"-c", "copy",
-			"-f", "hls",
-			"-hls_time", "2",
-			"-hls_list_size", "5",
-			"-hls_flags", "delete_segments",
-			"-hls_segment_filename", fmt.Sprintf("%s/%%03d.ts", outputPath),
-			fmt.Sprintf("%s/index.m3u8", outputPath))
+			append([]string{"-i", config.URL, "-c", "copy"}, sp.outputArgs(outputPath)...)...)
 	case "rtmp":
 		cmd = exec.Command("ffmpeg",
-			"-i", config.URL,
-			"-c", "copy",
-			"-f", "hls",
-			"-hls_time", "2",
-			"-hls_list_size", "5",
-			"-hls_flags", "delete_segments",
-			"-hls_segment_filename", fmt.Sprintf("%s/%%03d.ts", outputPath),
-			fmt.Sprintf("%s/index.m3u8", outputPath))
+			append([]string{"-i", config.URL, "-c", "copy"}, sp.outputArgs(outputPath)...)...)
 	case "rtsp":
 		cmd = exec.Command("ffmpeg",
-			"-i", config.URL,
-			"-c", "copy",
-			"-f", "hls",
-			"-hls_time", "2",
-			"-hls_list_size", "5",
-			"-hls_flags", "delete_segments",
-			"-hls_segment_filename", fmt.Sprintf("%s/%%03d.ts", outputPath),
-			fmt.Sprintf("%s/index.m3u8", outputPath))
+			append([]string{"-i", config.URL, "-c", "copy"}, sp.outputArgs(outputPath)...)...)
 	default:
 		logError("不支援的流類型: %s", config.Type)
 		return
@@ -427,17 +438,22 @@ func (sp *StreamPuller) startHTTPServer() {
 		c.JSON(http.StatusOK, gin.H{"status": "ok"})
 	})
 
-	// 靜態文件服務 - 提供 HLS 文件 (最後註冊)
+	// 靜態文件服務 - 提供 HLS / DASH 文件 (最後註冊)
 	r.GET("/hls/*filepath", func(c *gin.Context) {
 		filepath := c.Param("filepath")
 
 		// 處理靜態文件
-		if strings.HasSuffix(filepath, ".m3u8") || strings.HasSuffix(filepath, ".ts") {
+		if strings.HasSuffix(filepath, ".m3u8") || strings.HasSuffix(filepath, ".ts") ||
+			strings.HasSuffix(filepath, ".mpd") || strings.HasSuffix(filepath, ".m4s") {
 			// 設置正確的 MIME 類型
 			if strings.HasSuffix(filepath, ".m3u8") {
 				c.Header("Content-Type", "application/vnd.apple.mpegurl")
 			} else if strings.HasSuffix(filepath, ".ts") {
 				c.Header("Content-Type", "video/mp2t")
+			} else if strings.HasSuffix(filepath, ".mpd") {
+				c.Header("Content-Type", "application/dash+xml")
+			} else if strings.HasSuffix(filepath, ".m4s") {
+				c.Header("Content-Type", "video/iso.segment")
 			}
 
 			// 構建文件路徑
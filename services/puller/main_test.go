@@ -0,0 +1,64 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+// argValue 取得 FFmpeg 參數中選項的值
+func argValue(args []string, option string) (string, bool) {
+	for i := 0; i < len(args)-1; i++ {
+		if args[i] == option {
+			return args[i+1], true
+		}
+	}
+	return "", false
+}
+
+func TestOutputArgsTS(t *testing.T) {
+	sp := &StreamPuller{packaging: "ts"}
+	args := sp.outputArgs("/tmp/public_streams/nasa")
+
+	want := []string{
+		"-f", "hls",
+		"-hls_time", "2",
+		"-hls_list_size", "5",
+		"-hls_flags", "delete_segments",
+		"-hls_segment_filename", "/tmp/public_streams/nasa/%03d.ts",
+		"/tmp/public_streams/nasa/index.m3u8",
+	}
+	if !reflect.DeepEqual(args, want) {
+		t.Errorf("outputArgs = %v, want %v", args, want)
+	}
+}
+
+func TestOutputArgsCMAF(t *testing.T) {
+	sp := &StreamPuller{packaging: "cmaf"}
+	args := sp.outputArgs("/tmp/public_streams/nasa")
+
+	// 以 DASH 封裝器輸出 fMP4 分段，並由同一組分段產生 HLS 主播放列表
+	expected := map[string]string{
+		"-f":               "dash",
+		"-seg_duration":    "2",
+		"-window_size":     "5",
+		"-remove_at_exit":  "1",
+		"-use_template":    "1",
+		"-use_timeline":    "1",
+		"-init_seg_name":   "init_$RepresentationID$.m4s",
+		"-media_seg_name":  "chunk_$RepresentationID$_$Number%05d$.m4s",
+		"-hls_playlist":    "1",
+		"-hls_master_name": "index.m3u8",
+	}
+	for option, want := range expected {
+		if got, ok := argValue(args, option); !ok || got != want {
+			t.Errorf("%s = %q, want %q", option, got, want)
+		}
+	}
+
+	if output := args[len(args)-1]; output != "/tmp/public_streams/nasa/index.mpd" {
+		t.Errorf("output = %q, want index.mpd", output)
+	}
+	if _, ok := argValue(args, "-hls_segment_filename"); ok {
+		t.Error("cmaf 不應輸出 MPEG-TS 分段")
+	}
+}